@@ -18,6 +18,7 @@ import (
 	"github.com/fiveseconds/server/internal/repository"
 	"github.com/fiveseconds/server/internal/service"
 	"github.com/fiveseconds/server/internal/ws"
+	"github.com/fiveseconds/server/pkg/geoip"
 	pkglogger "github.com/fiveseconds/server/pkg/logger"
 
 	"github.com/gin-gonic/gin"
@@ -84,8 +85,12 @@ func main() {
 	_ = roomActivityLogger
 	_ = reconciliationLogger
 
+	// 初始化币种及各币种下注金额
+	service.ConfigureCurrencies(cfg.Currency)
+
 	// 初始化仓库
 	userRepo := repository.NewUserRepo()
+	walletRepo := repository.NewWalletRepo()
 	roomRepo := repository.NewRoomRepo()
 	gameRepo := repository.NewGameRepo()
 	txRepo := repository.NewTransactionRepo()
@@ -99,6 +104,26 @@ func main() {
 	themeRepo := repository.NewThemeRepo()
 	friendRepo := repository.NewFriendRepo()
 	invitationRepo := repository.NewInvitationRepo()
+	transferRepo := repository.NewTransferRepo()
+	statementRepo := repository.NewStatementRepo()
+	settlementRepo := repository.NewSettlementRepo()
+	bonusRepo := repository.NewBonusRepo()
+	rebateRepo := repository.NewRebateRepo()
+	referralRepo := repository.NewReferralRepo()
+	balanceSnapshotRepo := repository.NewBalanceSnapshotRepo()
+	creditLimitRepo := repository.NewCreditLimitRepo()
+	practiceRepo := repository.NewPracticeRepo()
+	tournamentRepo := repository.NewTournamentRepo()
+	jackpotRepo := repository.NewJackpotRepo()
+	riskRuleRepo := repository.NewRiskRuleRepo()
+	collusionRepo := repository.NewCollusionRepo()
+	loginEventRepo := repository.NewLoginEventRepo()
+	restrictionRepo := repository.NewRestrictionRepo()
+	riskScoreRepo := repository.NewRiskScoreRepo()
+	riskCaseRepo := repository.NewRiskCaseRepo()
+	ownerRiskRepo := repository.NewOwnerRiskRepo()
+	fairnessRepo := repository.NewFairnessRepo()
+	notificationRepo := repository.NewNotificationRepo()
 
 	// 初始化 WebSocket Hub
 	hub := ws.NewHub()
@@ -109,17 +134,111 @@ func main() {
 		balanceCache = cache.NewBalanceCache(cache.RedisClient, userRepo, zapLogger)
 	}
 
+	// 余额写回模式：下注与派奖先写 Redis，由 outbox 异步落库
+	if balanceCache != nil && cfg.Redis.WriteBehind.Enabled {
+		balanceCache.EnableWriteBehind(cfg.Redis.WriteBehind, txRepo, repository.NewBalanceOutboxRepo())
+		// 恢复未结算回合前先落库上次遗留的 outbox 条目
+		if n, err := balanceCache.Flush(context.Background()); err != nil {
+			zapLogger.Error("Failed to flush balance outbox on startup", zap.Error(err))
+		} else if n > 0 {
+			zapLogger.Info("Flushed balance outbox on startup", zap.Int("entries", n))
+		}
+		// 重放上次进程提交结算后未能执行的奖金加款
+		if n, err := balanceCache.ReplayRecordedCredits(context.Background(), 0); err != nil {
+			zapLogger.Error("Failed to replay pending credits on startup", zap.Error(err))
+		} else if n > 0 {
+			zapLogger.Info("Replayed pending credits on startup", zap.Int("credits", n))
+		}
+		if err := balanceCache.StartWriteBehind(context.Background()); err != nil {
+			zapLogger.Error("Failed to start balance write-behind", zap.Error(err))
+		}
+	}
+
 	// 初始化告警管理器和风控服务
-	alertManager := service.NewAlertManager(alertRepo, nil, zapLogger) // broadcaster 稍后设置
+	// 告警通过 WebSocket 推送给在线管理员，并按路由规则投递到通知渠道（webhook、邮件、Slack、钉钉）
+	alertManager := service.NewAlertManager(alertRepo, service.NewAdminAlertBroadcaster(hub, userRepo, zapLogger), zapLogger)
+	notificationService := service.NewNotificationService(notificationRepo, alertRepo, cfg, zapLogger)
+	alertManager.SetNotifier(notificationService)
+	startNotificationWorker(notificationService, zapLogger)
 	riskService := service.NewRiskControlService(riskRepo, alertManager, zapLogger)
 
+	// 初始化风控规则引擎（规则存储在数据库，定时重新加载）
+	riskRuleService := service.NewRiskRuleService(riskRuleRepo, riskRepo, loginEventRepo, cfg, zapLogger)
+	if err := riskRuleService.Reload(context.Background()); err != nil {
+		zapLogger.Error("Failed to load risk rules", zap.Error(err))
+	}
+	riskService.SetRuleEngine(riskRuleService)
+	startRiskRuleReloadJob(riskRuleService, zapLogger)
+
+	// 初始化串通检测（回合结算后增量分析，每晚全量分析）
+	collusionService := service.NewCollusionService(collusionRepo, cfg, zapLogger)
+	riskService.SetCollusionAnalyzer(collusionService)
+	startCollusionBatchJob(riskService, collusionService, zapLogger)
+
+	// 初始化登录历史（可选的本地 IP 地区库）
+	var geoDB *geoip.DB
+	if cfg.LoginHistory.GeoDBPath != "" {
+		if geoDB, err = geoip.Open(cfg.LoginHistory.GeoDBPath); err != nil {
+			zapLogger.Error("Failed to load geo ip database", zap.String("path", cfg.LoginHistory.GeoDBPath), zap.Error(err))
+		} else {
+			zapLogger.Info("Geo ip database loaded", zap.Int("networks", geoDB.Len()))
+		}
+	}
+	loginHistoryService := service.NewLoginHistoryService(loginEventRepo, geoDB, cfg, zapLogger)
+	riskService.SetLoginHistory(loginHistoryService) // 新设备登录后提现检测
+
+	// 初始化账户限制（规则触发按严重程度自动限制，到期自动解除并通知玩家）
+	restrictionService := service.NewRestrictionService(restrictionRepo, cfg, zapLogger)
+	restrictionService.SetNotifier(hub)
+	riskService.SetRestrictions(restrictionService)
+	startRestrictionExpiryJob(restrictionService, zapLogger)
+
+	// 初始化用户风险评分（标记与行为信号事件触发重算，定时重算使分值衰减）
+	riskScoreService := service.NewRiskScoreService(riskScoreRepo, alertManager, cfg, zapLogger)
+	riskService.SetRiskScorer(riskScoreService)
+	startRiskScoreDecayJob(riskScoreService, zapLogger)
+
+	// 初始化风控案件（归集风控标记，案件内标记的审核写入案件审计）
+	riskCaseService := service.NewRiskCaseService(riskCaseRepo, riskRepo, userRepo, zapLogger)
+	riskService.SetCases(riskCaseService)
+
+	// 初始化房主维度风控（每日房主对账后检测佣金、保证金、玩家胜率、守恒差额与提现前充值审批）
+	ownerRiskService := service.NewOwnerRiskService(ownerRiskRepo, platformRepo, cfg, zapLogger)
+	riskService.SetOwnerRiskAnalyzer(ownerRiskService)
+
+	// 初始化回合结果公平性统计（定时检验赢家分布，显著偏离时告警）
+	fairnessService := service.NewFairnessService(fairnessRepo, alertManager, cfg, zapLogger)
+	startFairnessJob(fairnessService, zapLogger)
+
 	// 初始化资金异常日志器
 	fundAnomalyLogger := service.NewFundAnomalyLogger(structuredLogger, alertManager)
-	_ = fundAnomalyLogger
+	riskService.SetFundAnomalyLogger(fundAnomalyLogger) // 资金变动检查命中时输出结构化日志
 
 	// 初始化游戏管理器
 	manager := game.NewManager(hub, userRepo, roomRepo, gameRepo, txRepo, platformRepo, balanceCache, riskService, zapLogger)
 
+	// 初始化奖励余额服务（结算时累计流水，发放奖励后同步房间内存中的奖励余额）
+	bonusService := service.NewBonusService(userRepo, bonusRepo, txRepo, platformRepo, cfg, zapLogger)
+	bonusService.SetListener(manager)
+	bonusService.SetRestrictionChecker(restrictionService)
+	manager.SetBonusTracker(bonusService)
+
+	// 初始化练习房游戏币服务（练习房下注与派奖只变动游戏币，补充游戏币后同步房间内存）
+	practiceService := service.NewPracticeService(practiceRepo, cfg, zapLogger)
+	practiceService.SetListener(manager)
+	manager.SetPracticeLedger(practiceService)
+
+	// 初始化锦标赛服务（锦标赛房间以筹码下注结算，报名费、退款与奖金走真实账本）
+	tournamentService := service.NewTournamentService(tournamentRepo, userRepo, walletRepo, roomRepo, txRepo, cfg, zapLogger)
+	tournamentService.SetHub(hub)
+	tournamentService.SetBalanceCache(balanceCache) // 写回模式下扣除报名费前先落库
+	manager.SetTournamentLedger(tournamentService)
+
+	// 初始化累进奖池服务（结算时注入抽取金额与舍入残值，由回合种子确定性触发）
+	jackpotService := service.NewJackpotService(jackpotRepo, roomRepo, userRepo, cfg, zapLogger)
+	manager.SetJackpotPool(jackpotService)
+	manager.SetRestrictionChecker(restrictionService)
+
 	// 设置 Hub 断开连接回调，用于清理游戏引擎中的用户状态
 	hub.SetDisconnectCallback(func(roomID, userID int64, reason string) {
 		zapLogger.Info("Hub disconnect callback triggered",
@@ -141,15 +260,37 @@ func main() {
 	// 初始化服务
 	authService := service.NewAuthService(userRepo, cfg)
 	authService.SetRiskService(riskService) // 设置风控服务用于设备指纹检测
+	authService.SetLoginRecorder(loginHistoryService)
 	roomService := service.NewRoomService(roomRepo, userRepo, manager)
-	fundService := service.NewFundService(userRepo, fundRepo, txRepo, platformRepo, conservationRepo, cfg)
+	roomService.SetTournamentGuard(tournamentService)
+	fundService := service.NewFundService(userRepo, walletRepo, fundRepo, txRepo, platformRepo, conservationRepo, balanceSnapshotRepo, cfg)
 	fundService.SetHub(hub) // 设置 WebSocket Hub 用于发送余额更新通知
+	fundService.SetRiskChecker(riskService) // 资金申请提交后的新设备提现检查
+	fundService.SetRestrictionChecker(restrictionService)
+	fundService.SetFundEventHook(riskService) // 审批通过后的资金申请规则（或大额、日交易量固定检查）与资金频率检查
+	fundService.SetOwnerRiskChecker(riskService) // 每日房主对账后的房主维度风控检查
+	fundService.SetBalanceCache(balanceCache) // 写回模式下审批变动余额前先落库
 	chatService := service.NewChatService(chatRepo, zapLogger)
+	chatService.SetRestrictionChecker(restrictionService)
 
 	// 启动资金守恒自动对账任务（每2小时一次）
 	startConservationAutoCheck(fundService, zapLogger)
-	// 启动每日按房主维度对账任务
-	startDailyOwnerConservationCheck(fundService, zapLogger)
+	// 启动日终余额快照任务（快照完成后按快照执行每日房主维度对账）
+	balanceSnapshotService := service.NewBalanceSnapshotService(userRepo, balanceSnapshotRepo, cfg, zapLogger)
+	balanceSnapshotService.SetReconciler(fundService)
+	startBalanceSnapshotJob(balanceSnapshotService, zapLogger)
+	// 启动资金申请过期与提醒任务
+	startFundRequestSLAJob(fundService, zapLogger)
+
+	// 初始化房主信用额度服务（玩家充值受保证金倍数限制，超限房主的房间自动暂停）
+	creditLimitService := service.NewCreditLimitService(creditLimitRepo, roomRepo, roomService, alertManager, cfg, zapLogger)
+	fundService.SetCreditLimiter(creditLimitService)
+	roomService.SetCreditGuard(creditLimitService)
+	if cfg.CreditLimit.Enabled {
+		startCreditLimitJob(creditLimitService, cfg.CreditLimit.CheckIntervalMinutes, zapLogger)
+	}
+	// 启动锦标赛巡检任务（开始到期的锦标赛，结束已完成的锦标赛并派奖）
+	startTournamentJob(tournamentService, cfg.Tournament.CheckIntervalSeconds, zapLogger)
 
 	// 初始化游戏历史服务
 	gameHistoryService := service.NewGameHistoryService(gameRepo, zapLogger)
@@ -169,7 +310,40 @@ func main() {
 	invitationService := service.NewInvitationService(invitationRepo, friendRepo, roomRepo, userRepo, hub)
 
 	// 初始化钱包服务
-	walletService := service.NewWalletService(userRepo, txRepo)
+	walletService := service.NewWalletService(userRepo, walletRepo, roomRepo, txRepo)
+	walletService.SetBalanceCache(balanceCache) // 写回模式下切换币种前先落库
+	walletService.SetFundEventHook(riskService)
+
+	// 初始化玩家转账服务
+	transferService := service.NewTransferService(userRepo, walletRepo, transferRepo, txRepo, zapLogger)
+	transferService.SetHub(hub)
+	transferService.SetRiskChecker(riskService) // 循环转账检测
+	transferService.SetRestrictionChecker(restrictionService)
+	transferService.SetBalanceCache(balanceCache) // 写回模式下转出前先落库
+
+	// 初始化账单服务（重启后重新执行中断的导出任务）
+	statementService := service.NewStatementService(userRepo, statementRepo, cfg, zapLogger)
+	statementService.RecoverJobs(context.Background())
+
+	// 初始化玩家返水服务
+	rebateService := service.NewRebateService(userRepo, walletRepo, rebateRepo, txRepo, cfg, zapLogger)
+	rebateService.SetHub(hub)
+
+	// 初始化玩家推荐奖励服务（注册时记录推荐关系，发放前检测自我推荐）
+	referralService := service.NewReferralService(userRepo, walletRepo, referralRepo, txRepo, platformRepo, cfg, zapLogger)
+	referralService.SetHub(hub)
+	referralService.SetRiskChecker(riskService)
+	authService.SetReferralRecorder(referralService)
+	if cfg.Referral.Enabled {
+		startReferralRewardJob(referralService, zapLogger)
+	}
+
+	// 初始化房主佣金结算服务
+	settlementService := service.NewSettlementService(userRepo, settlementRepo, walletService, cfg, zapLogger)
+	settlementService.SetFundEventHook(riskService)
+	if cfg.Settlement.Enabled {
+		startSettlementJob(settlementService, zapLogger)
+	}
 
 	// 初始化处理器
 	h := handler.NewHandler(authService, roomService, fundService)
@@ -181,6 +355,24 @@ func main() {
 	alertHandler := handler.NewAlertHandler(alertManager)
 	friendHandler := handler.NewFriendHandler(friendService)
 	invitationHandler := handler.NewInvitationHandler(invitationService, roomService)
+	transferHandler := handler.NewTransferHandler(transferService)
+	statementHandler := handler.NewStatementHandler(statementService)
+	settlementHandler := handler.NewSettlementHandler(settlementService)
+	bonusHandler := handler.NewBonusHandler(bonusService)
+	rebateHandler := handler.NewRebateHandler(rebateService)
+	referralHandler := handler.NewReferralHandler(referralService)
+	balanceSnapshotHandler := handler.NewBalanceSnapshotHandler(balanceSnapshotService)
+	creditLimitHandler := handler.NewCreditLimitHandler(creditLimitService)
+	practiceHandler := handler.NewPracticeHandler(practiceService)
+	tournamentHandler := handler.NewTournamentHandler(tournamentService)
+	jackpotHandler := handler.NewJackpotHandler(jackpotService)
+	riskRuleHandler := handler.NewRiskRuleHandler(riskRuleService)
+	loginHistoryHandler := handler.NewLoginHistoryHandler(loginHistoryService)
+	restrictionHandler := handler.NewRestrictionHandler(restrictionService)
+	riskScoreHandler := handler.NewRiskScoreHandler(riskScoreService)
+	riskCaseHandler := handler.NewRiskCaseHandler(riskCaseService)
+	fairnessHandler := handler.NewFairnessHandler(fairnessService)
+	notificationHandler := handler.NewNotificationHandler(notificationService)
 	authMiddleware := handler.NewMiddleware(authService)
 	wsHandler := handler.NewWSHandler(hub, manager, authService, authService, chatService, roomRepo, zapLogger)
 
@@ -192,12 +384,16 @@ func main() {
 		gin.SetMode(gin.ReleaseMode)
 	}
 	r := gin.New()
+	// 客户端 IP 仅采信来自可信代理（nginx）的转发头
+	if err := r.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+		zapLogger.Fatal("Invalid trusted proxies", zap.Error(err))
+	}
 	r.Use(middleware.RecoveryWithLogging(structuredLogger))
 	r.Use(middleware.RequestLogging(structuredLogger))
 	r.Use(handler.CORS())
 
 	// 路由
-	setupRoutes(r, h, walletHandler, gameHistoryHandler, monitoringHandler, themeHandler, riskHandler, alertHandler, friendHandler, invitationHandler, authMiddleware, wsHandler, logLevelHandler)
+	setupRoutes(r, h, walletHandler, gameHistoryHandler, monitoringHandler, themeHandler, riskHandler, alertHandler, friendHandler, invitationHandler, transferHandler, statementHandler, settlementHandler, bonusHandler, rebateHandler, referralHandler, balanceSnapshotHandler, creditLimitHandler, practiceHandler, tournamentHandler, jackpotHandler, riskRuleHandler, loginHistoryHandler, restrictionHandler, riskScoreHandler, riskCaseHandler, fairnessHandler, notificationHandler, authMiddleware, wsHandler, logLevelHandler)
 
 	// Prometheus 指标
 	r.GET("/metrics", gin.WrapH(promhttp.Handler()))
@@ -238,7 +434,7 @@ func main() {
 	zapLogger.Info("Server exited")
 }
 
-func setupRoutes(r *gin.Engine, h *handler.Handler, wh *handler.WalletHandler, gh *handler.GameHistoryHandler, mh *handler.MonitoringHandler, th *handler.ThemeHandler, rh *handler.RiskHandler, ah *handler.AlertHandler, fh *handler.FriendHandler, ih *handler.InvitationHandler, m *handler.Middleware, wsHandler *handler.WSHandler, llh *handler.LogLevelHandler) {
+func setupRoutes(r *gin.Engine, h *handler.Handler, wh *handler.WalletHandler, gh *handler.GameHistoryHandler, mh *handler.MonitoringHandler, th *handler.ThemeHandler, rh *handler.RiskHandler, ah *handler.AlertHandler, fh *handler.FriendHandler, ih *handler.InvitationHandler, trh *handler.TransferHandler, sth *handler.StatementHandler, seh *handler.SettlementHandler, bh *handler.BonusHandler, rbh *handler.RebateHandler, rfh *handler.ReferralHandler, bsh *handler.BalanceSnapshotHandler, clh *handler.CreditLimitHandler, pch *handler.PracticeHandler, tnh *handler.TournamentHandler, jph *handler.JackpotHandler, rrh *handler.RiskRuleHandler, lhh *handler.LoginHistoryHandler, rsh *handler.RestrictionHandler, rsch *handler.RiskScoreHandler, rch *handler.RiskCaseHandler, fnh *handler.FairnessHandler, nh *handler.NotificationHandler, m *handler.Middleware, wsHandler *handler.WSHandler, llh *handler.LogLevelHandler) {
 	api := r.Group("/api")
 	{
 		// 公开接口
@@ -271,11 +467,40 @@ func setupRoutes(r *gin.Engine, h *handler.Handler, wh *handler.WalletHandler, g
 			auth.GET("/transactions", h.ListTransactions)
 			auth.GET("/fund-summary", h.GetFundSummary)
 
+			// 账户限制（原因代码与到期时间）
+			auth.GET("/restrictions", rsh.GetMyRestrictions)
+
 			// 钱包
 			auth.GET("/wallet", wh.GetWallet)
 			auth.GET("/wallet/transactions", wh.GetTransactions)
 			auth.GET("/wallet/earnings", wh.GetEarnings)
 			auth.POST("/wallet/transfer-earnings", wh.TransferEarnings)
+			// 多币种钱包
+			auth.GET("/wallet/currencies", wh.ListCurrencyWallets)
+			auth.POST("/wallet/currency", wh.SwitchCurrency)
+
+			// 玩家转账
+			auth.POST("/transfers", trh.CreateTransfer)
+			auth.GET("/transfers", trh.ListMyTransfers)
+
+			// 账户账单
+			auth.GET("/statements/download", sth.DownloadStatement)
+			auth.POST("/statements/jobs", sth.CreateStatementJob)
+			auth.GET("/statements/jobs", sth.ListStatementJobs)
+			auth.GET("/statements/jobs/:id", sth.GetStatementJob)
+			auth.GET("/statements/jobs/:id/download", sth.DownloadStatementJob)
+
+			// 奖励余额
+			auth.GET("/bonus", bh.GetMyBonus)
+			// 推荐面板（推荐码、被推荐人、推荐奖励）
+			auth.GET("/referrals", rfh.GetMyReferrals)
+			// 练习房游戏币
+			auth.GET("/practice/chips", pch.GetMyChips)
+			auth.POST("/practice/refill", pch.RefillChips)
+			// 锦标赛（玩家查看所属房主的锦标赛、报名）
+			auth.GET("/tournaments", tnh.ListMyTournaments)
+			auth.GET("/tournaments/:id", tnh.GetTournament)
+			auth.POST("/tournaments/:id/register", tnh.Register)
 
 			// 游戏历史
 			auth.GET("/game-history", gh.GetGameHistory)
@@ -312,6 +537,37 @@ func setupRoutes(r *gin.Engine, h *handler.Handler, wh *handler.WalletHandler, g
 			owner.PUT("/rooms/:id/theme", th.UpdateRoomTheme)
 			owner.GET("/fund-requests", h.ListOwnerFundRequests)
 			owner.POST("/fund-requests/:id/process", h.ProcessOwnerFundRequest)
+			owner.GET("/fund-requests/:id/approvals", h.ListFundRequestApprovals)
+			owner.GET("/transfers", trh.ListOwnerTransfers)
+			owner.POST("/transfers/:id/process", trh.ProcessTransfer)
+			owner.GET("/transfer-settings", trh.GetTransferSettings)
+			owner.PUT("/transfer-settings", trh.UpdateTransferSettings)
+			// 佣金结算单
+			owner.GET("/settlements", seh.ListOwnerSettlements)
+			owner.GET("/settlements/:id", seh.GetSettlement)
+			owner.GET("/settlements/:id/reconcile", seh.ReconcileSettlement)
+			// 奖励发放
+			owner.POST("/bonus-grants", bh.GrantBonus)
+			owner.GET("/bonus-grants", bh.ListOwnerGrants)
+			// 玩家返水
+			owner.GET("/rebate-rules", rbh.GetRules)
+			owner.PUT("/rebate-rules", rbh.UpdateRules)
+			owner.GET("/rebates/preview", rbh.PreviewRebates)
+			owner.POST("/rebates/payout", rbh.PayoutRebates)
+			owner.GET("/rebates", rbh.ListOwnerPayouts)
+			owner.GET("/rebates/:id", rbh.GetPayout)
+			// 推荐奖励
+			owner.GET("/referral-rewards", rfh.ListOwnerRewards)
+			// 信用额度（玩家余额总额与保证金挂钩）
+			owner.GET("/credit-limit", clh.GetMyCreditLimit)
+			// 锦标赛
+			owner.POST("/tournaments", tnh.CreateTournament)
+			owner.GET("/tournaments", tnh.ListOwnerTournaments)
+			owner.POST("/tournaments/:id/cancel", tnh.CancelOwnerTournament)
+			// 累进奖池
+			owner.POST("/jackpots", jph.SaveJackpot)
+			owner.GET("/jackpots", jph.ListOwnerJackpots)
+			owner.GET("/jackpots/:id/hits", jph.ListOwnerJackpotHits)
 		}
 
 		// 管理员接口
@@ -319,6 +575,15 @@ func setupRoutes(r *gin.Engine, h *handler.Handler, wh *handler.WalletHandler, g
 		admin.Use(m.Auth(), m.RequireRole(model.RoleAdmin))
 		{
 			admin.GET("/users", h.ListUsers)
+			// 历史余额（日终快照）
+			admin.GET("/users/:id/balance-history", bsh.GetBalanceHistory)
+			admin.GET("/users/:id/login-events", lhh.ListLoginEvents)
+			admin.GET("/users/:id/devices", lhh.ListKnownDevices)
+			admin.GET("/users/:id/restrictions", rsh.ListUserRestrictions)
+			admin.POST("/users/:id/restrictions", rsh.ApplyRestriction)
+			admin.GET("/users/:id/restriction-audit", rsh.ListRestrictionAudit)
+			admin.POST("/restrictions/:id/revoke", rsh.RevokeRestriction)
+			admin.GET("/users/:id/risk-score", rsch.GetUserRiskScore)
 			admin.POST("/owners", h.CreateOwner)
 			admin.POST("/fund-requests/:id/process", h.ProcessFundRequest)
 			admin.PUT("/rooms/:id/status", h.AdminUpdateRoomStatus)
@@ -330,6 +595,25 @@ func setupRoutes(r *gin.Engine, h *handler.Handler, wh *handler.WalletHandler, g
 			admin.GET("/reports/balance-check", h.GetBalanceCheckReport)
 			// 资金对账历史（全局 + 房主）
 			admin.GET("/reports/balance-check/history", h.ListBalanceCheckHistory)
+			// 房主佣金结算
+			admin.GET("/settlements", seh.ListSettlements)
+			admin.POST("/settlements/run", seh.RunSettlement)
+			// 奖励发放
+			admin.POST("/bonus-grants", bh.GrantBonus)
+			admin.GET("/bonus-grants", bh.ListGrants)
+			// 玩家返水
+			admin.GET("/rebates", rbh.ListPayouts)
+			// 推荐奖励
+			admin.GET("/referral-rewards", rfh.ListRewards)
+			// 房主信用额度
+			admin.GET("/credit-limits", clh.ListCreditLimits)
+			admin.PUT("/owners/:id/credit-limit", clh.UpdateCreditLimit)
+			// 锦标赛
+			admin.GET("/tournaments", tnh.ListTournaments)
+			admin.POST("/tournaments/:id/cancel", tnh.CancelTournament)
+			// 累进奖池
+			admin.GET("/jackpots", jph.ListJackpots)
+			admin.GET("/jackpots/:id/hits", jph.ListJackpotHits)
 			// 监控指标
 			admin.GET("/metrics/realtime", mh.GetRealtimeMetrics)
 			admin.GET("/metrics/history", mh.GetHistoricalMetrics)
@@ -337,11 +621,48 @@ func setupRoutes(r *gin.Engine, h *handler.Handler, wh *handler.WalletHandler, g
 			admin.GET("/risk-flags", rh.ListRiskFlags)
 			admin.GET("/risk-flags/:id", rh.GetRiskFlag)
 			admin.POST("/risk-flags/:id/review", rh.ReviewRiskFlag)
+			// 风控案件
+			admin.GET("/risk-cases", rch.ListCases)
+			admin.POST("/risk-cases", rch.CreateCase)
+			admin.GET("/risk-cases/:id", rch.GetCase)
+			admin.POST("/risk-cases/:id/flags", rch.AddFlags)
+			admin.PUT("/risk-cases/:id/assignee", rch.AssignCase)
+			admin.PUT("/risk-cases/:id/status", rch.UpdateCaseStatus)
+			admin.POST("/risk-cases/:id/notes", rch.AddNote)
+			admin.POST("/risk-cases/:id/evidence", rch.AddEvidence)
+			admin.GET("/risk-cases/:id/audit", rch.ListCaseAudit)
+
+			// 回合结果公平性统计
+			admin.GET("/fairness", fnh.GetDashboard)
+			admin.GET("/fairness/reports", fnh.ListReports)
+			admin.POST("/fairness/run", fnh.RunNow)
+			// 风控规则
+			admin.GET("/risk-rules", rrh.ListRiskRules)
+			admin.POST("/risk-rules", rrh.CreateRiskRule)
+			admin.GET("/risk-rules/variables", rrh.ListRiskRuleVariables)
+			admin.POST("/risk-rules/dry-run", rrh.DryRunRiskRule)
+			admin.GET("/risk-rules/:id", rrh.GetRiskRule)
+			admin.PUT("/risk-rules/:id", rrh.UpdateRiskRule)
+			admin.GET("/risk-rules/:id/versions", rrh.ListRiskRuleVersions)
+			admin.POST("/risk-rules/:id/versions/:version/restore", rrh.RestoreRiskRuleVersion)
 			// 告警
 			admin.GET("/alerts", ah.ListAlerts)
 			admin.GET("/alerts/summary", ah.GetAlertSummary)
 			admin.GET("/alerts/:id", ah.GetAlert)
 			admin.POST("/alerts/:id/acknowledge", ah.AcknowledgeAlert)
+			admin.GET("/alerts/:id/deliveries", nh.ListAlertDeliveries)
+			// 告警通知渠道与路由
+			admin.GET("/notification-channels", nh.ListChannels)
+			admin.POST("/notification-channels", nh.CreateChannel)
+			admin.GET("/notification-channels/:id", nh.GetChannel)
+			admin.PUT("/notification-channels/:id", nh.UpdateChannel)
+			admin.POST("/notification-channels/:id/test", nh.TestChannel)
+			admin.GET("/notification-routes", nh.ListRoutes)
+			admin.POST("/notification-routes", nh.CreateRoute)
+			admin.PUT("/notification-routes/:id", nh.UpdateRoute)
+			admin.DELETE("/notification-routes/:id", nh.DeleteRoute)
+			admin.GET("/alert-deliveries", nh.ListDeliveries)
+			admin.POST("/alert-deliveries/:id/retry", nh.RetryDelivery)
 			// 日志级别管理
 			admin.GET("/log-level", llh.GetLogLevel)
 			admin.PUT("/log-level", llh.SetLogLevel)
@@ -380,56 +701,283 @@ func startConservationAutoCheck(fundService *service.FundService, logger *zap.Lo
 			periodStart := now.Add(-2 * time.Hour)
 
 			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-			check, err := fundService.CheckConservation(ctx)
+			checks, err := fundService.CheckAllConservation(ctx)
 			if err != nil {
 				cancel()
 				logger.Error("auto conservation check failed", zap.Error(err))
 				continue
 			}
 
-			// 记录全局对账历史 + 房主维度 2 小时对账历史
-			_ = fundService.RecordGlobalConservation(ctx, "2h", periodStart, periodEnd, check)
+			// 记录各币种全局对账历史 + 房主维度 2 小时对账历史
+			for _, check := range checks {
+				_ = fundService.RecordGlobalConservation(ctx, "2h", periodStart, periodEnd, check)
+			}
 			_ = fundService.RecordOwnerConservation2h(ctx, periodStart, periodEnd)
 			cancel()
 
-			if !check.IsBalanced {
-				logger.Warn("funds imbalance detected in auto check",
-					zap.String("total_player_balance", check.TotalPlayerBalance.String()),
-					zap.String("total_custody_quota", check.TotalCustodyQuota.String()),
-					zap.String("total_margin", check.TotalMargin.String()),
-					zap.String("platform_balance", check.PlatformBalance.String()),
-					zap.String("difference", check.Difference.String()),
-				)
-			} else {
-				logger.Info("auto conservation check passed",
-					zap.String("total_player_balance", check.TotalPlayerBalance.String()),
-					zap.String("total_custody_quota", check.TotalCustodyQuota.String()),
-					zap.String("total_margin", check.TotalMargin.String()),
-					zap.String("platform_balance", check.PlatformBalance.String()),
-					zap.String("difference", check.Difference.String()),
-				)
+			for _, check := range checks {
+				logConservationCheck(logger, check)
+			}
+		}
+	}()
+}
+
+// logConservationCheck 记录单个币种的自动对账结果
+func logConservationCheck(logger *zap.Logger, check *model.ConservationCheck) {
+	fields := []zap.Field{
+		zap.String("currency", check.Currency),
+		zap.String("total_player_balance", check.TotalPlayerBalance.String()),
+		zap.String("total_custody_quota", check.TotalCustodyQuota.String()),
+		zap.String("total_margin", check.TotalMargin.String()),
+		zap.String("platform_balance", check.PlatformBalance.String()),
+		zap.String("difference", check.Difference.String()),
+	}
+	if !check.IsBalanced {
+		logger.Warn("funds imbalance detected in auto check", fields...)
+	} else {
+		logger.Info("auto conservation check passed", fields...)
+	}
+}
+
+// startBalanceSnapshotJob 启动日终余额快照任务（启动时立即补齐一次，之后在每个日终时刻后执行）
+// 快照余额按日终时刻从流水回推，停机期间缺失的日期在下次执行时补齐，重复执行不会覆盖已有快照
+func startBalanceSnapshotJob(snapshotService *service.BalanceSnapshotService, logger *zap.Logger) {
+	go func() {
+		run := func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+			defer cancel()
+			if _, err := snapshotService.RunDueSnapshots(ctx, time.Now()); err != nil {
+				logger.Error("balance snapshot failed", zap.Error(err))
 			}
 		}
+
+		run()
+		for {
+			time.Sleep(time.Until(snapshotService.NextBoundary(time.Now())) + time.Second)
+			run()
+		}
 	}()
 }
 
-// startDailyOwnerConservationCheck 启动每日房主维度对账任务
-// 简化实现: 每 24 小时执行一次, 以任务触发时间为当日区间
-func startDailyOwnerConservationCheck(fundService *service.FundService, logger *zap.Logger) {
+// startFundRequestSLAJob 启动资金申请过期与提醒任务（每分钟检查一次）
+// 有效期与提醒间隔由 fund.request_ttl / fund.reminder_interval 配置
+func startFundRequestSLAJob(fundService *service.FundService, logger *zap.Logger) {
 	go func() {
-		ticker := time.NewTicker(24 * time.Hour)
+		ticker := time.NewTicker(time.Minute)
 		defer ticker.Stop()
 
 		for range ticker.C {
-			now := time.Now().UTC()
-			dayEnd := now
-			dayStart := now.Add(-24 * time.Hour)
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			if n, err := fundService.ExpireStaleRequests(ctx); err != nil {
+				logger.Error("expire stale fund requests failed", zap.Error(err))
+			} else if n > 0 {
+				logger.Info("stale fund requests expired", zap.Int("count", n))
+			}
+			if n, err := fundService.SendPendingReminders(ctx); err != nil {
+				logger.Error("send fund request reminders failed", zap.Error(err))
+			} else if n > 0 {
+				logger.Info("fund request reminders sent", zap.Int("owners", n))
+			}
+			cancel()
+		}
+	}()
+}
 
+// startSettlementJob 启动房主佣金结算任务（每小时检查一次，启动时立即执行一次）
+// 每次为最近一个已结束的周期补齐缺失的结算单，重复执行不会重复结算
+func startSettlementJob(settlementService *service.SettlementService, logger *zap.Logger) {
+	go func() {
+		run := func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+			defer cancel()
+			if n, err := settlementService.RunDueSettlements(ctx, time.Now()); err != nil {
+				logger.Error("owner settlement failed", zap.Error(err))
+			} else if n > 0 {
+				logger.Info("owner settlements created", zap.Int("count", n))
+			}
+		}
+
+		run()
+		ticker := time.NewTicker(time.Hour)
+		defer ticker.Stop()
+		for range ticker.C {
+			run()
+		}
+	}()
+}
+
+// startCreditLimitJob 启动房主信用额度巡检任务（启动时立即执行一次）
+// 新超限的房主暂停全部房间并告警，恢复额度后清除超限记录
+func startCreditLimitJob(creditService *service.CreditLimitService, intervalMinutes int, logger *zap.Logger) {
+	if intervalMinutes <= 0 {
+		intervalMinutes = 10
+	}
+	go func() {
+		run := func() {
+			ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+			defer cancel()
+			if n, err := creditService.EnforceAll(ctx); err != nil {
+				logger.Error("credit limit check failed", zap.Error(err))
+			} else if n > 0 {
+				logger.Warn("owners exceeded credit limit", zap.Int("count", n))
+			}
+		}
+
+		run()
+		ticker := time.NewTicker(time.Duration(intervalMinutes) * time.Minute)
+		defer ticker.Stop()
+		for range ticker.C {
+			run()
+		}
+	}()
+}
+
+// startTournamentJob 启动锦标赛巡检任务（启动时立即执行一次）
+// 到达开始时间的锦标赛置为进行中；达到回合上限或结束时间且回合全部结算的锦标赛结束并按名次派奖
+func startTournamentJob(tournamentService *service.TournamentService, intervalSeconds int, logger *zap.Logger) {
+	if intervalSeconds <= 0 {
+		intervalSeconds = 30
+	}
+	go func() {
+		run := func() {
+			ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+			defer cancel()
+			started, finished, err := tournamentService.RunSchedule(ctx)
+			if err != nil {
+				logger.Error("tournament schedule failed", zap.Error(err))
+			}
+			if started > 0 || finished > 0 {
+				logger.Info("tournament schedule", zap.Int("started", started), zap.Int("finished", finished))
+			}
+		}
+
+		run()
+		ticker := time.NewTicker(time.Duration(intervalSeconds) * time.Second)
+		defer ticker.Stop()
+		for range ticker.C {
+			run()
+		}
+	}()
+}
+
+// startRiskRuleReloadJob 启动风控规则重新加载任务（使其他实例在管理后台修改后的规则无需重启即可生效）
+func startRiskRuleReloadJob(riskRuleService *service.RiskRuleService, logger *zap.Logger) {
+	go func() {
+		ticker := time.NewTicker(riskRuleService.ReloadInterval())
+		defer ticker.Stop()
+		for range ticker.C {
 			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-			if err := fundService.RecordOwnerConservationDaily(ctx, dayStart, dayEnd); err != nil {
-				logger.Error("daily owner conservation check failed", zap.Error(err))
+			if err := riskRuleService.Reload(ctx); err != nil {
+				logger.Error("risk rule reload failed", zap.Error(err))
 			}
 			cancel()
 		}
 	}()
 }
+
+// startCollusionBatchJob 启动夜间串通全量分析任务（每天 collusion.batch_hour 点执行一次）
+func startCollusionBatchJob(riskService *service.RiskControlService, collusionService *service.CollusionService, logger *zap.Logger) {
+	go func() {
+		for {
+			time.Sleep(time.Until(collusionService.NextBatchTime(time.Now())))
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Minute)
+			if n, err := riskService.RunCollusionBatch(ctx, time.Now()); err != nil {
+				logger.Error("collusion batch failed", zap.Error(err))
+			} else if n > 0 {
+				logger.Info("collusion flags created", zap.Int("count", n))
+			}
+			cancel()
+		}
+	}()
+}
+
+// startRestrictionExpiryJob 启动账户限制到期处理任务（每分钟一次，写入到期审计记录并通知玩家）
+func startRestrictionExpiryJob(restrictionService *service.RestrictionService, logger *zap.Logger) {
+	go func() {
+		ticker := time.NewTicker(time.Minute)
+		defer ticker.Stop()
+		for range ticker.C {
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			if n, err := restrictionService.ExpireDue(ctx, time.Now()); err != nil {
+				logger.Error("restriction expiry failed", zap.Error(err))
+			} else if n > 0 {
+				logger.Info("restrictions expired", zap.Int("count", n))
+			}
+			cancel()
+		}
+	}()
+}
+
+// startRiskScoreDecayJob 启动风险评分衰减任务（每小时重算一次评分大于 0 的用户）
+func startRiskScoreDecayJob(riskScoreService *service.RiskScoreService, logger *zap.Logger) {
+	go func() {
+		ticker := time.NewTicker(time.Hour)
+		defer ticker.Stop()
+		for range ticker.C {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+			if n, err := riskScoreService.RecomputeAll(ctx); err != nil {
+				logger.Error("risk score decay failed", zap.Int("recomputed", n), zap.Error(err))
+			} else if n > 0 {
+				logger.Info("risk scores recomputed", zap.Int("count", n))
+			}
+			cancel()
+		}
+	}()
+}
+
+// startFairnessJob 启动回合结果公平性统计任务（按配置间隔执行）
+func startFairnessJob(fairnessService *service.FairnessService, logger *zap.Logger) {
+	go func() {
+		ticker := time.NewTicker(fairnessService.Interval())
+		defer ticker.Stop()
+		for range ticker.C {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+			if _, err := fairnessService.Run(ctx, time.Now()); err != nil {
+				logger.Error("fairness statistics failed", zap.Error(err))
+			}
+			cancel()
+		}
+	}()
+}
+
+// startNotificationWorker 启动告警通知投递任务（定时检查，有新投递记录时立即发送）
+func startNotificationWorker(notificationService *service.NotificationService, logger *zap.Logger) {
+	go func() {
+		ticker := time.NewTicker(notificationService.PollInterval())
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+			case <-notificationService.Pending():
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+			if _, err := notificationService.DeliverDue(ctx, time.Now()); err != nil {
+				logger.Error("alert notification delivery failed", zap.Error(err))
+			}
+			cancel()
+		}
+	}()
+}
+
+// startReferralRewardJob 启动推荐奖励发放任务（每小时检查一次，启动时立即执行一次）
+// 佣金分成按最近一个已结束的自然日发放，重复执行不会重复发放
+func startReferralRewardJob(referralService *service.ReferralService, logger *zap.Logger) {
+	go func() {
+		run := func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+			defer cancel()
+			if n, err := referralService.RunDueRewards(ctx, time.Now()); err != nil {
+				logger.Error("referral rewards failed", zap.Error(err))
+			} else if n > 0 {
+				logger.Info("referral rewards paid", zap.Int("count", n))
+			}
+		}
+
+		run()
+		ticker := time.NewTicker(time.Hour)
+		defer ticker.Stop()
+		for range ticker.C {
+			run()
+		}
+	}()
+}
@@ -39,6 +39,7 @@ type BalanceCache struct {
 	redis    *redis.Client
 	userRepo *repository.UserRepo
 	logger   *zap.Logger
+	wb       *writeBehind // 写回模式状态（未开启时为 nil）
 }
 
 // NewBalanceCache 创建余额缓存实例
@@ -57,6 +58,10 @@ func (c *BalanceCache) cacheKey(userID int64) string {
 
 // Get 从缓存获取余额，如果缓存未命中则从数据库加载
 func (c *BalanceCache) Get(ctx context.Context, userID int64) (*CachedBalance, error) {
+	if c.wb != nil {
+		return c.getWriteBehind(ctx, userID)
+	}
+
 	key := c.cacheKey(userID)
 
 	// 尝试从 Redis 获取
@@ -77,6 +82,10 @@ func (c *BalanceCache) Get(ctx context.Context, userID int64) (*CachedBalance, e
 
 // LoadFromDB 从数据库加载余额并缓存
 func (c *BalanceCache) LoadFromDB(ctx context.Context, userID int64) (*CachedBalance, error) {
+	if c.wb != nil {
+		return c.loadWriteBehind(ctx, userID)
+	}
+
 	user, err := c.userRepo.GetByID(ctx, userID)
 	if err != nil {
 		return nil, fmt.Errorf("get user from db: %w", err)
@@ -115,8 +124,12 @@ func (c *BalanceCache) Set(ctx context.Context, userID int64, balance *CachedBal
 
 // Invalidate 使缓存失效
 func (c *BalanceCache) Invalidate(ctx context.Context, userID int64) error {
-	key := c.cacheKey(userID)
-	if err := c.redis.Del(ctx, key).Err(); err != nil {
+	keys := []string{c.cacheKey(userID)}
+	if c.wb != nil {
+		// 写回模式下删除后重新加载时按 数据库余额 + 未落库变动 重建
+		keys = append(keys, c.wbKey(userID))
+	}
+	if err := c.redis.Del(ctx, keys...).Err(); err != nil {
 		return fmt.Errorf("redis del: %w", err)
 	}
 	return nil
@@ -0,0 +1,1075 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fiveseconds/server/internal/config"
+	"github.com/fiveseconds/server/internal/model"
+	"github.com/fiveseconds/server/internal/repository"
+	"github.com/jackc/pgx/v5"
+	"github.com/redis/go-redis/v9"
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+// 写回模式下 Redis 中的余额为权威值：
+//   - balance_wb:u:{userID}  哈希 {cents, ver}，余额（分）与已同步的数据库版本号
+//   - balance_wb:pending     哈希 {userID: cents}，已写入 Redis 但尚未落库的余额变动
+//   - balance_wb:outbox      Stream，每个条目对应一次批量扣款/加款操作，由 flusher 异步落库
+//   - balance_wb:op:{op}     已执行的操作ID，保证同一操作只写入一次
+//   - balance_wb:hold:{userID} 数据库路径修改余额期间暂停该用户的缓存扣款
+//   - balance_wb:dead        Stream，落库会使余额为负的条目，需人工处理
+//
+// 结算奖金在结算事务内登记为待写回加款（balance_pending_credits），事务提交后写入 Redis，
+// Redis 与数据库兜底都失败或进程中断时由启动时与 flusher 按操作ID重放，操作ID落库时删除登记。
+//
+// 不变量：cents = users.balance × 100 + pending
+// 其他路径对 users.balance 的修改须先调用 PrepareDBWrite 暂停缓存扣款并落库，
+// 变更通过 balance_changed 通知同步到 Redis，对账任务定期按不变量校正遗漏的通知。
+const (
+	writeBehindUserPrefix = "balance_wb:u:"
+	writeBehindPendingKey = "balance_wb:pending"
+	writeBehindStreamKey  = "balance_wb:outbox"
+	writeBehindOpPrefix   = "balance_wb:op:"
+	writeBehindHoldPrefix = "balance_wb:hold:"
+	writeBehindDeadKey    = "balance_wb:dead"
+	writeBehindGroup      = "flusher"
+	writeBehindConsumer   = "flusher-1"
+
+	// writeBehindOpTTL 操作ID保留时间
+	writeBehindOpTTL = 24 * time.Hour
+	// writeBehindHoldTTL 暂停缓存扣款的最长时间（释放函数未调用时自动恢复）
+	writeBehindHoldTTL = 30 * time.Second
+
+	defaultFlushInterval     = 200 * time.Millisecond
+	defaultFlushBatchSize    = 500
+	defaultReconcileInterval = 5 * time.Minute
+	maxListenBackoff         = 30 * time.Second
+
+	// pendingCreditReplayInterval flusher 重放待写回加款的间隔
+	pendingCreditReplayInterval = 5 * time.Second
+	// pendingCreditMinAge 登记后留给结算流程自行加款的时间，超过后才由 flusher 重放
+	pendingCreditMinAge = 30 * time.Second
+)
+
+var (
+	ErrWriteBehindDisabled = errors.New("write-behind disabled")
+	ErrDuplicateOp         = errors.New("outbox op already applied")
+	ErrOpSkipped           = errors.New("outbox op skipped: required op not applied")
+	ErrFlushOverdraw       = errors.New("outbox flush would overdraw balance")
+)
+
+// applyScript 原子批量扣款/加款并写入 outbox
+// KEYS: pending, stream, op, required op, (user key, hold key)...
+// ARGV: meta, ttl, op ttl, (userID, delta)...
+// 任一用户缓存缺失时不做任何修改，返回 {'miss', userIDs}
+// 余额不足或被数据库路径暂停扣款的用户不扣款
+var applyScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[3]) == 1 then return {'dup'} end
+if KEYS[4] ~= '' and redis.call('EXISTS', KEYS[4]) == 0 then return {'skip'} end
+local n = (#KEYS - 4) / 2
+local missing = {}
+for i = 1, n do
+  if redis.call('EXISTS', KEYS[3 + 2 * i]) == 0 then missing[#missing + 1] = ARGV[2 + 2 * i] end
+end
+if #missing > 0 then return {'miss', missing} end
+local results = {}
+local records = {}
+for i = 1, n do
+  local key = KEYS[3 + 2 * i]
+  local uid = ARGV[2 + 2 * i]
+  local delta = tonumber(ARGV[3 + 2 * i])
+  local before = tonumber(redis.call('HGET', key, 'cents'))
+  if before + delta < 0 or (delta < 0 and redis.call('EXISTS', KEYS[4 + 2 * i]) == 1) then
+    results[#results + 1] = {uid, 0, string.format('%d', before), string.format('%d', before)}
+  else
+    redis.call('HINCRBY', key, 'cents', delta)
+    redis.call('HINCRBY', KEYS[1], uid, delta)
+    redis.call('EXPIRE', key, ARGV[2])
+    local after = string.format('%d', before + delta)
+    results[#results + 1] = {uid, 1, string.format('%d', before), after}
+    records[#records + 1] = {uid, ARGV[3 + 2 * i], string.format('%d', before), after}
+  end
+end
+if #records > 0 then
+  redis.call('XADD', KEYS[2], '*', 'meta', ARGV[1], 'records', cjson.encode(records))
+  redis.call('SET', KEYS[3], '1', 'EX', ARGV[3])
+end
+return {'ok', results}
+`)
+
+// loadScript 按数据库快照加载（或强制校正）用户缓存：cents = 数据库余额 + 未落库变动
+// KEYS: user key, pending
+// ARGV: userID, db cents, db version, ttl, force
+// 返回 {状态, 原余额}：0 未变化，1 已校正，2 新加载
+var loadScript = redis.NewScript(`
+local pend = tonumber(redis.call('HGET', KEYS[2], ARGV[1]) or '0')
+local want = string.format('%d', tonumber(ARGV[2]) + pend)
+if redis.call('EXISTS', KEYS[1]) == 1 then
+  if ARGV[5] ~= '1' then return {0, '0'} end
+  local cur = redis.call('HGET', KEYS[1], 'cents')
+  redis.call('HSET', KEYS[1], 'cents', want, 'ver', ARGV[3])
+  if cur == want then return {0, cur} end
+  return {1, cur}
+end
+redis.call('HSET', KEYS[1], 'cents', want, 'ver', ARGV[3])
+redis.call('EXPIRE', KEYS[1], ARGV[4])
+return {2, '0'}
+`)
+
+// mirrorScript 同步其他路径的余额变更（版本号不大于已同步版本的通知跳过）
+// KEYS: user key
+// ARGV: delta cents, version
+var mirrorScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 0 then return 0 end
+local ver = tonumber(redis.call('HGET', KEYS[1], 'ver') or '0')
+if tonumber(ARGV[2]) <= ver then return 0 end
+redis.call('HINCRBY', KEYS[1], 'cents', ARGV[1])
+redis.call('HSET', KEYS[1], 'ver', ARGV[2])
+return 1
+`)
+
+// flushedScript 落库完成后扣减未落库变动并确认 outbox 条目
+// KEYS: pending, stream
+// ARGV: group, pair count, (userID, delta)..., entry ids...
+var flushedScript = redis.NewScript(`
+local np = tonumber(ARGV[2])
+for i = 1, np do
+  local uid = ARGV[1 + 2 * i]
+  local left = redis.call('HINCRBY', KEYS[1], uid, -tonumber(ARGV[2 + 2 * i]))
+  if left == 0 then redis.call('HDEL', KEYS[1], uid) end
+end
+local ids = {}
+for i = 3 + 2 * np, #ARGV do ids[#ids + 1] = ARGV[i] end
+if #ids > 0 then
+  redis.call('XACK', KEYS[2], ARGV[1], unpack(ids))
+  redis.call('XDEL', KEYS[2], unpack(ids))
+end
+return #ids
+`)
+
+// OutboxMeta outbox 操作信息（写入 Stream 条目，落库时生成交易记录）
+type OutboxMeta struct {
+	Op       string                `json:"op"`                 // 操作ID，如 round:123:bet
+	Requires string                `json:"requires,omitempty"` // 仅当该操作已执行时才执行（用于补偿）
+	RoomID   *int64                `json:"room_id,omitempty"`
+	RoundID  *int64                `json:"round_id,omitempty"`
+	Type     model.TransactionType `json:"type"`
+}
+
+// AppliedBalance 写回操作后的用户余额
+type AppliedBalance struct {
+	Before decimal.Decimal
+	After  decimal.Decimal
+}
+
+// outboxEntry 解析后的 outbox 条目
+type outboxEntry struct {
+	id      string
+	meta    OutboxMeta
+	records []outboxRecord
+}
+
+// outboxRecord 单个用户的余额变动（分）
+type outboxRecord struct {
+	userID int64
+	delta  int64
+	before int64
+	after  int64
+}
+
+// writeBehind 写回模式状态
+type writeBehind struct {
+	cfg        config.WriteBehindConfig
+	txRepo     *repository.TransactionRepo
+	outboxRepo *repository.BalanceOutboxRepo
+
+	// flushMu 串行化落库、加载、通知同步与对账，保证不变量计算时数据库与 pending 一致
+	flushMu sync.Mutex
+
+	// groupReady outbox 消费组是否已创建（Redis 不可用时延迟到落库时创建）
+	groupReady bool
+
+	// pending 进程内未落库变动副本，Redis 不可用时用于排除数据库路径
+	pendingMu sync.Mutex
+	pending   map[int64]int64
+}
+
+// EnableWriteBehind 开启写回模式
+func (c *BalanceCache) EnableWriteBehind(cfg config.WriteBehindConfig, txRepo *repository.TransactionRepo, outboxRepo *repository.BalanceOutboxRepo) {
+	if cfg.FlushInterval <= 0 {
+		cfg.FlushInterval = defaultFlushInterval
+	}
+	if cfg.FlushBatchSize <= 0 {
+		cfg.FlushBatchSize = defaultFlushBatchSize
+	}
+	if cfg.ReconcileInterval <= 0 {
+		cfg.ReconcileInterval = defaultReconcileInterval
+	}
+	c.wb = &writeBehind{
+		cfg:        cfg,
+		txRepo:     txRepo,
+		outboxRepo: outboxRepo,
+		pending:    make(map[int64]int64),
+	}
+}
+
+// WriteBehindEnabled 是否开启写回模式
+func (c *BalanceCache) WriteBehindEnabled() bool {
+	return c != nil && c.wb != nil
+}
+
+// wbKey 写回模式用户余额键
+func (c *BalanceCache) wbKey(userID int64) string {
+	return fmt.Sprintf("%s%d", writeBehindUserPrefix, userID)
+}
+
+// holdKey 暂停缓存扣款键
+func (c *BalanceCache) holdKey(userID int64) string {
+	return fmt.Sprintf("%s%d", writeBehindHoldPrefix, userID)
+}
+
+// toCents 金额转换为分
+func toCents(amount decimal.Decimal) int64 {
+	return amount.Shift(2).Round(0).IntPart()
+}
+
+// fromCents 分转换为金额
+func fromCents(cents int64) decimal.Decimal {
+	return decimal.New(cents, -2)
+}
+
+// ApplyStakes 在 Redis 中批量扣除下注金额，余额不足的用户不在返回结果中
+func (c *BalanceCache) ApplyStakes(ctx context.Context, userIDs []int64, amount decimal.Decimal, meta OutboxMeta) (map[int64]AppliedBalance, error) {
+	deltas := make(map[int64]int64, len(userIDs))
+	for _, id := range userIDs {
+		deltas[id] = -toCents(amount)
+	}
+	return c.apply(ctx, deltas, meta)
+}
+
+// ApplyCredits 在 Redis 中批量加款
+func (c *BalanceCache) ApplyCredits(ctx context.Context, amounts map[int64]decimal.Decimal, meta OutboxMeta) (map[int64]AppliedBalance, error) {
+	deltas := make(map[int64]int64, len(amounts))
+	for id, amount := range amounts {
+		if cents := toCents(amount); cents != 0 {
+			deltas[id] = cents
+		}
+	}
+	return c.apply(ctx, deltas, meta)
+}
+
+// apply 执行写回操作，缓存缺失的用户从数据库加载后重试一次
+func (c *BalanceCache) apply(ctx context.Context, deltas map[int64]int64, meta OutboxMeta) (map[int64]AppliedBalance, error) {
+	if !c.WriteBehindEnabled() {
+		return nil, ErrWriteBehindDisabled
+	}
+	if len(deltas) == 0 {
+		return map[int64]AppliedBalance{}, nil
+	}
+	metaJSON, err := json.Marshal(meta)
+	if err != nil {
+		return nil, fmt.Errorf("marshal outbox meta: %w", err)
+	}
+
+	requires := ""
+	if meta.Requires != "" {
+		requires = writeBehindOpPrefix + meta.Requires
+	}
+	keys := []string{writeBehindPendingKey, writeBehindStreamKey, writeBehindOpPrefix + meta.Op, requires}
+	args := []interface{}{string(metaJSON), int64(BalanceCacheTTL / time.Second), int64(writeBehindOpTTL / time.Second)}
+	for id, delta := range deltas {
+		keys = append(keys, c.wbKey(id), c.holdKey(id))
+		args = append(args, id, delta)
+	}
+
+	for attempt := 0; ; attempt++ {
+		reply, err := applyScript.Run(ctx, c.redis, keys, args...).Slice()
+		if err != nil {
+			return nil, fmt.Errorf("apply script: %w", err)
+		}
+		status, _ := reply[0].(string)
+		switch status {
+		case "dup":
+			return nil, ErrDuplicateOp
+		case "skip":
+			return nil, ErrOpSkipped
+		case "miss":
+			if attempt > 0 {
+				return nil, ErrCacheMiss
+			}
+			missing, err := parseInt64s(reply[1])
+			if err != nil {
+				return nil, err
+			}
+			if err := c.Warm(ctx, missing); err != nil {
+				return nil, fmt.Errorf("warm missing balances: %w", err)
+			}
+			continue
+		case "ok":
+			return c.applyResults(reply[1], deltas)
+		default:
+			return nil, fmt.Errorf("unexpected apply script reply: %v", reply)
+		}
+	}
+}
+
+// applyResults 解析写回结果并更新进程内未落库变动
+func (c *BalanceCache) applyResults(raw interface{}, deltas map[int64]int64) (map[int64]AppliedBalance, error) {
+	rows, ok := raw.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("unexpected apply results: %v", raw)
+	}
+	applied := make(map[int64]AppliedBalance, len(rows))
+	c.wb.pendingMu.Lock()
+	defer c.wb.pendingMu.Unlock()
+	for _, row := range rows {
+		fields, ok := row.([]interface{})
+		if !ok || len(fields) != 4 {
+			return nil, fmt.Errorf("unexpected apply result row: %v", row)
+		}
+		values, err := parseInt64s(fields)
+		if err != nil {
+			return nil, err
+		}
+		if values[1] != 1 {
+			continue
+		}
+		userID := values[0]
+		c.wb.pending[userID] += deltas[userID]
+		applied[userID] = AppliedBalance{Before: fromCents(values[2]), After: fromCents(values[3])}
+	}
+	return applied, nil
+}
+
+// CreditToDB Redis 写入失败时直接在数据库中加款（按操作ID去重，与 outbox 落库互斥）
+// 若 Redis 实际已执行该操作，其 outbox 条目落库时会被跳过，缓存偏差由对账校正
+func (c *BalanceCache) CreditToDB(ctx context.Context, amounts map[int64]decimal.Decimal, meta OutboxMeta) (map[int64]AppliedBalance, error) {
+	if !c.WriteBehindEnabled() {
+		return nil, ErrWriteBehindDisabled
+	}
+	applied := make(map[int64]AppliedBalance, len(amounts))
+	err := repository.Tx(ctx, func(tx pgx.Tx) error {
+		fresh, err := c.wb.outboxRepo.MarkAppliedTx(ctx, tx, []string{meta.Op})
+		if err != nil {
+			return fmt.Errorf("mark op applied: %w", err)
+		}
+		if len(fresh) == 0 {
+			return ErrDuplicateOp
+		}
+		results, err := c.userRepo.BatchAddBalanceTx(ctx, tx, amounts)
+		if err != nil {
+			return fmt.Errorf("batch add balance: %w", err)
+		}
+		records := make([]*model.BalanceTransaction, 0, len(results))
+		for _, r := range results {
+			amount := amounts[r.UserID]
+			applied[r.UserID] = AppliedBalance{Before: r.NewBalance.Sub(amount), After: r.NewBalance}
+			records = append(records, &model.BalanceTransaction{
+				UserID:        r.UserID,
+				RoomID:        meta.RoomID,
+				RoundID:       meta.RoundID,
+				Type:          meta.Type,
+				Amount:        amount,
+				BalanceBefore: r.NewBalance.Sub(amount),
+				BalanceAfter:  r.NewBalance,
+			})
+		}
+		return c.wb.txRepo.BatchCreateTx(ctx, tx, records)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return applied, nil
+}
+
+// RecordCreditsTx 在结算事务内登记待写回加款，事务提交后由 ApplyRecordedCredits 执行
+// 加款未落库前登记一直保留，进程中断或加款失败时由 ReplayRecordedCredits 重放
+func (c *BalanceCache) RecordCreditsTx(ctx context.Context, tx pgx.Tx, amounts map[int64]decimal.Decimal, meta OutboxMeta) error {
+	if !c.WriteBehindEnabled() {
+		return ErrWriteBehindDisabled
+	}
+	// 不足一分的金额不会写入 Redis，也不登记，避免登记永远无法删除
+	credits := make(map[int64]decimal.Decimal, len(amounts))
+	for id, amount := range amounts {
+		if toCents(amount) != 0 {
+			credits[id] = amount
+		}
+	}
+	if len(credits) == 0 {
+		return nil
+	}
+	return c.wb.outboxRepo.CreatePendingCreditTx(ctx, tx, &repository.PendingCredit{
+		Op:      meta.Op,
+		RoomID:  meta.RoomID,
+		RoundID: meta.RoundID,
+		Type:    meta.Type,
+		Amounts: credits,
+	})
+}
+
+// ApplyRecordedCredits 执行已登记的加款：先写入 Redis，失败时直接落库
+// 两条路径使用同一操作ID，保证只执行一次；返回 ErrDuplicateOp 表示该操作已执行
+func (c *BalanceCache) ApplyRecordedCredits(ctx context.Context, amounts map[int64]decimal.Decimal, meta OutboxMeta) (map[int64]AppliedBalance, error) {
+	applied, err := c.ApplyCredits(ctx, amounts, meta)
+	if err == nil || errors.Is(err, ErrDuplicateOp) {
+		return applied, err
+	}
+	c.logger.Warn("Cached credit failed, crediting in db", zap.String("op", meta.Op), zap.Error(err))
+	return c.CreditToDB(ctx, amounts, meta)
+}
+
+// ReplayRecordedCredits 重放登记时长不少于 minAge 的待写回加款，返回本次执行的操作数
+// 已写入 Redis、等待落库的操作按操作ID跳过；单个操作失败时继续重放其余操作，返回第一个错误
+func (c *BalanceCache) ReplayRecordedCredits(ctx context.Context, minAge time.Duration) (int, error) {
+	if !c.WriteBehindEnabled() {
+		return 0, ErrWriteBehindDisabled
+	}
+	credits, err := c.wb.outboxRepo.ListPendingCredits(ctx, minAge, c.wb.cfg.FlushBatchSize)
+	if err != nil {
+		return 0, fmt.Errorf("list pending credits: %w", err)
+	}
+
+	replayed := 0
+	var firstErr error
+	for _, credit := range credits {
+		meta := OutboxMeta{Op: credit.Op, RoomID: credit.RoomID, RoundID: credit.RoundID, Type: credit.Type}
+		_, err := c.ApplyRecordedCredits(ctx, credit.Amounts, meta)
+		if errors.Is(err, ErrDuplicateOp) {
+			continue
+		}
+		if err != nil {
+			c.logger.Error("Failed to replay pending credit", zap.String("op", credit.Op), zap.Error(err))
+			if firstErr == nil {
+				firstErr = fmt.Errorf("replay %s: %w", credit.Op, err)
+			}
+			continue
+		}
+		replayed++
+		c.logger.Warn("Replayed pending credit",
+			zap.String("op", credit.Op), zap.Time("recorded_at", credit.CreatedAt))
+	}
+	return replayed, firstErr
+}
+
+// Warm 从数据库加载缺失的用户缓存
+func (c *BalanceCache) Warm(ctx context.Context, userIDs []int64) error {
+	if len(userIDs) == 0 {
+		return nil
+	}
+	c.wb.flushMu.Lock()
+	defer c.wb.flushMu.Unlock()
+
+	snapshots, err := c.userRepo.GetBalanceSnapshots(ctx, userIDs)
+	if err != nil {
+		return fmt.Errorf("get balance snapshots: %w", err)
+	}
+	for userID, snap := range snapshots {
+		if _, _, err := c.loadSnapshot(ctx, userID, snap, false); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadSnapshot 按数据库快照加载用户缓存（调用方需持有 flushMu）
+// 返回状态（0 未变化，1 已校正，2 新加载）与原余额（分）
+func (c *BalanceCache) loadSnapshot(ctx context.Context, userID int64, snap repository.BalanceSnapshot, force bool) (int64, int64, error) {
+	forceArg := "0"
+	if force {
+		forceArg = "1"
+	}
+	reply, err := loadScript.Run(ctx, c.redis, []string{c.wbKey(userID), writeBehindPendingKey},
+		userID, toCents(snap.Balance), snap.Version, int64(BalanceCacheTTL/time.Second), forceArg,
+	).Slice()
+	if err != nil {
+		return 0, 0, fmt.Errorf("load script: %w", err)
+	}
+	values, err := parseInt64s(reply)
+	if err != nil || len(values) != 2 {
+		return 0, 0, fmt.Errorf("unexpected load script reply: %v", reply)
+	}
+	return values[0], values[1], nil
+}
+
+// getWriteBehind 写回模式下读取余额，Redis 不可用时以数据库余额加进程内未落库变动估算
+func (c *BalanceCache) getWriteBehind(ctx context.Context, userID int64) (*CachedBalance, error) {
+	values, err := c.redis.HMGet(ctx, c.wbKey(userID), "cents", "ver").Result()
+	if err == nil && values[0] != nil {
+		cents, _ := strconv.ParseInt(fmt.Sprint(values[0]), 10, 64)
+		version, _ := strconv.ParseInt(fmt.Sprint(values[1]), 10, 64)
+		return &CachedBalance{Balance: fromCents(cents), Version: version, CachedAt: time.Now()}, nil
+	}
+	if err != nil {
+		c.logger.Warn("Redis hmget error", zap.Error(err))
+	}
+	return c.LoadFromDB(ctx, userID)
+}
+
+// loadWriteBehind 写回模式下从数据库加载并预热缓存，返回包含未落库变动的余额
+func (c *BalanceCache) loadWriteBehind(ctx context.Context, userID int64) (*CachedBalance, error) {
+	user, err := c.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("get user from db: %w", err)
+	}
+	cached := &CachedBalance{
+		Balance:       user.Balance.Add(fromCents(c.pendingCents(userID))),
+		FrozenBalance: user.FrozenBalance,
+		Version:       user.BalanceVersion,
+		CachedAt:      time.Now(),
+	}
+
+	c.wb.flushMu.Lock()
+	defer c.wb.flushMu.Unlock()
+	snap := repository.BalanceSnapshot{Balance: user.Balance, Version: user.BalanceVersion}
+	if _, _, err := c.loadSnapshot(ctx, userID, snap, false); err != nil {
+		c.logger.Warn("Failed to warm write-behind balance", zap.Int64("user_id", userID), zap.Error(err))
+		return cached, nil
+	}
+	if cents, err := c.redis.HGet(ctx, c.wbKey(userID), "cents").Int64(); err == nil {
+		cached.Balance = fromCents(cents)
+	}
+	return cached, nil
+}
+
+// pendingCents 进程内记录的未落库变动（分）
+func (c *BalanceCache) pendingCents(userID int64) int64 {
+	c.wb.pendingMu.Lock()
+	defer c.wb.pendingMu.Unlock()
+	return c.wb.pending[userID]
+}
+
+// PrepareDBWrite 数据库路径修改余额前调用：暂停这些用户的缓存扣款并落库未落库变动
+// 返回仍有未落库变动、不能走数据库路径的用户（已恢复其缓存扣款），
+// 其余用户在数据库事务提交后须调用 release 按数据库余额重新加载缓存并恢复扣款
+func (c *BalanceCache) PrepareDBWrite(ctx context.Context, userIDs []int64) (release func(), blocked []int64) {
+	if !c.WriteBehindEnabled() || len(userIDs) == 0 {
+		return func() {}, nil
+	}
+	// Redis 不可用时缓存扣款也无法执行，按进程内未落库变动判断
+	pipe := c.redis.Pipeline()
+	for _, id := range userIDs {
+		pipe.Set(ctx, c.holdKey(id), "1", writeBehindHoldTTL)
+	}
+	held := true
+	if _, err := pipe.Exec(ctx); err != nil {
+		c.logger.Warn("Failed to hold cached debits", zap.Int64s("user_ids", userIDs), zap.Error(err))
+		held = false
+	}
+
+	if len(c.pendingUsers(userIDs)) > 0 {
+		if _, err := c.Flush(ctx); err != nil {
+			c.logger.Warn("Flush before db write failed", zap.Error(err))
+		}
+	}
+	blocked = c.pendingUsers(userIDs)
+	if !held {
+		return func() {}, blocked
+	}
+	blockedSet := make(map[int64]bool, len(blocked))
+	for _, id := range blocked {
+		blockedSet[id] = true
+	}
+	writable := make([]int64, 0, len(userIDs))
+	for _, id := range userIDs {
+		if !blockedSet[id] {
+			writable = append(writable, id)
+		}
+	}
+	c.releaseHold(ctx, blocked)
+	return func() { c.releaseHold(ctx, writable) }, blocked
+}
+
+// pendingUsers 返回仍有未落库变动的用户
+func (c *BalanceCache) pendingUsers(userIDs []int64) []int64 {
+	c.wb.pendingMu.Lock()
+	defer c.wb.pendingMu.Unlock()
+	var pending []int64
+	for _, id := range userIDs {
+		if c.wb.pending[id] != 0 {
+			pending = append(pending, id)
+		}
+	}
+	return pending
+}
+
+// releaseHold 按数据库余额重新加载缓存后恢复缓存扣款
+// 数据库路径的变更通知可能晚于释放到达，先加载快照使其按版本号跳过
+func (c *BalanceCache) releaseHold(ctx context.Context, userIDs []int64) {
+	if len(userIDs) == 0 {
+		return
+	}
+	c.wb.flushMu.Lock()
+	defer c.wb.flushMu.Unlock()
+
+	snapshots, err := c.userRepo.GetBalanceSnapshots(ctx, userIDs)
+	if err != nil {
+		c.logger.Warn("Failed to reload balances after db write", zap.Int64s("user_ids", userIDs), zap.Error(err))
+	}
+	keys := make([]string, 0, len(userIDs))
+	for _, id := range userIDs {
+		keys = append(keys, c.holdKey(id))
+		snap, ok := snapshots[id]
+		if !ok {
+			continue
+		}
+		if _, _, err := c.loadSnapshot(ctx, id, snap, true); err != nil {
+			c.logger.Warn("Failed to reload balance after db write", zap.Int64("user_id", id), zap.Error(err))
+		}
+	}
+	if err := c.redis.Del(ctx, keys...).Err(); err != nil {
+		c.logger.Warn("Failed to release cached debit hold", zap.Int64s("user_ids", userIDs), zap.Error(err))
+	}
+}
+
+// Flush 将 outbox 中的余额变动落库，返回处理的条目数
+func (c *BalanceCache) Flush(ctx context.Context) (int, error) {
+	if !c.WriteBehindEnabled() {
+		return 0, ErrWriteBehindDisabled
+	}
+	c.wb.flushMu.Lock()
+	defer c.wb.flushMu.Unlock()
+
+	if !c.wb.groupReady {
+		err := c.redis.XGroupCreateMkStream(ctx, writeBehindStreamKey, writeBehindGroup, "0").Err()
+		if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+			return 0, fmt.Errorf("create outbox group: %w", err)
+		}
+		c.wb.groupReady = true
+	}
+
+	total := 0
+	// 先处理已读取但未确认的条目（上次落库中断），再读取新条目
+	for _, start := range []string{"0", ">"} {
+		for {
+			streams, err := c.redis.XReadGroup(ctx, &redis.XReadGroupArgs{
+				Group:    writeBehindGroup,
+				Consumer: writeBehindConsumer,
+				Streams:  []string{writeBehindStreamKey, start},
+				Count:    int64(c.wb.cfg.FlushBatchSize),
+				Block:    -1,
+			}).Result()
+			if errors.Is(err, redis.Nil) {
+				break
+			}
+			if err != nil {
+				return total, fmt.Errorf("read outbox: %w", err)
+			}
+			if len(streams) == 0 || len(streams[0].Messages) == 0 {
+				break
+			}
+			n, err := c.flushBatch(ctx, streams[0].Messages)
+			if errors.Is(err, ErrFlushOverdraw) {
+				n, err = c.flushEach(ctx, streams[0].Messages)
+			}
+			total += n
+			if err != nil {
+				return total, err
+			}
+		}
+	}
+
+	if err := c.syncPending(ctx); err != nil {
+		return total, err
+	}
+	return total, nil
+}
+
+// flushBatch 在一个数据库事务内落库一批 outbox 条目，成功后确认条目
+func (c *BalanceCache) flushBatch(ctx context.Context, messages []redis.XMessage) (int, error) {
+	entries := make([]*outboxEntry, 0, len(messages))
+	ids := make([]string, 0, len(messages))
+	for _, msg := range messages {
+		ids = append(ids, msg.ID)
+		entry, err := parseOutboxEntry(msg)
+		if err != nil {
+			// 无法解析的条目无法落库，记录后确认以免阻塞后续条目
+			c.logger.Error("Skipping malformed outbox entry", zap.String("entry_id", msg.ID), zap.Error(err))
+			continue
+		}
+		entries = append(entries, entry)
+	}
+
+	ops := make([]string, 0, len(entries))
+	for _, e := range entries {
+		ops = append(ops, e.meta.Op)
+	}
+
+	err := repository.Tx(ctx, func(tx pgx.Tx) error {
+		if err := c.wb.outboxRepo.MarkOutboxWriterTx(ctx, tx); err != nil {
+			return fmt.Errorf("mark outbox writer: %w", err)
+		}
+		fresh, err := c.wb.outboxRepo.MarkAppliedTx(ctx, tx, ops)
+		if err != nil {
+			return fmt.Errorf("mark outbox applied: %w", err)
+		}
+		freshOps := make(map[string]bool, len(fresh))
+		for _, op := range fresh {
+			freshOps[op] = true
+		}
+
+		amounts := make(map[int64]decimal.Decimal)
+		var records []*model.BalanceTransaction
+		for _, e := range entries {
+			if !freshOps[e.meta.Op] {
+				continue
+			}
+			// 同一批次内重复的操作只落库一次
+			delete(freshOps, e.meta.Op)
+			for _, r := range e.records {
+				amounts[r.userID] = amounts[r.userID].Add(fromCents(r.delta))
+				records = append(records, &model.BalanceTransaction{
+					UserID:        r.userID,
+					RoomID:        e.meta.RoomID,
+					RoundID:       e.meta.RoundID,
+					Type:          e.meta.Type,
+					Amount:        fromCents(r.delta),
+					BalanceBefore: fromCents(r.before),
+					BalanceAfter:  fromCents(r.after),
+				})
+			}
+		}
+		for id, amount := range amounts {
+			if amount.IsZero() {
+				delete(amounts, id)
+			}
+		}
+		results, err := c.userRepo.BatchAddBalanceTx(ctx, tx, amounts)
+		if err != nil {
+			return fmt.Errorf("batch add balance: %w", err)
+		}
+		if len(results) != len(amounts) {
+			return ErrFlushOverdraw
+		}
+		if err := c.wb.txRepo.BatchCreateTx(ctx, tx, records); err != nil {
+			return fmt.Errorf("batch create transactions: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("flush outbox: %w", err)
+	}
+
+	// 所有条目（包括此前已落库但未确认的）的变动都已计入数据库，扣减 pending
+	if err := c.ackEntries(ctx, entries, ids); err != nil {
+		return 0, err
+	}
+	return len(messages), nil
+}
+
+// flushEach 逐条落库，会使余额为负的条目转入死信队列，避免阻塞后续条目
+func (c *BalanceCache) flushEach(ctx context.Context, messages []redis.XMessage) (int, error) {
+	for i, msg := range messages {
+		_, err := c.flushBatch(ctx, messages[i:i+1])
+		if errors.Is(err, ErrFlushOverdraw) {
+			err = c.deadLetter(ctx, msg)
+		}
+		if err != nil {
+			return i, err
+		}
+	}
+	return len(messages), nil
+}
+
+// deadLetter 将无法落库的条目转入死信队列并确认，按数据库余额校正相关用户缓存（调用方需持有 flushMu）
+func (c *BalanceCache) deadLetter(ctx context.Context, msg redis.XMessage) error {
+	entry, err := parseOutboxEntry(msg)
+	if err != nil {
+		return fmt.Errorf("parse outbox entry: %w", err)
+	}
+	values := make(map[string]interface{}, len(msg.Values)+1)
+	for k, v := range msg.Values {
+		values[k] = v
+	}
+	values["entry_id"] = msg.ID
+	if err := c.redis.XAdd(ctx, &redis.XAddArgs{Stream: writeBehindDeadKey, Values: values}).Err(); err != nil {
+		return fmt.Errorf("dead letter outbox entry: %w", err)
+	}
+	c.logger.Error("Outbox entry would overdraw balance, moved to dead letter, manual check required",
+		zap.String("entry_id", msg.ID), zap.String("op", entry.meta.Op))
+
+	// 条目不再落库：扣减 pending 后缓存余额须按数据库余额重新计算
+	if err := c.ackEntries(ctx, []*outboxEntry{entry}, []string{msg.ID}); err != nil {
+		return err
+	}
+	userIDs := make([]int64, 0, len(entry.records))
+	for _, r := range entry.records {
+		userIDs = append(userIDs, r.userID)
+	}
+	snapshots, err := c.userRepo.GetBalanceSnapshots(ctx, userIDs)
+	if err != nil {
+		return fmt.Errorf("get balance snapshots: %w", err)
+	}
+	for id, snap := range snapshots {
+		if _, _, err := c.loadSnapshot(ctx, id, snap, true); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ackEntries 扣减已处理条目的未落库变动并确认 outbox 条目
+func (c *BalanceCache) ackEntries(ctx context.Context, entries []*outboxEntry, ids []string) error {
+	flushed := make(map[int64]int64)
+	for _, e := range entries {
+		for _, r := range e.records {
+			flushed[r.userID] += r.delta
+		}
+	}
+	args := []interface{}{writeBehindGroup, 0}
+	pairs := 0
+	for id, delta := range flushed {
+		if delta != 0 {
+			args = append(args, id, delta)
+			pairs++
+		}
+	}
+	args[1] = pairs
+	for _, id := range ids {
+		args = append(args, id)
+	}
+	if err := flushedScript.Run(ctx, c.redis, []string{writeBehindPendingKey, writeBehindStreamKey}, args...).Err(); err != nil {
+		return fmt.Errorf("ack outbox: %w", err)
+	}
+	return nil
+}
+
+// syncPending 从 Redis 刷新进程内未落库变动副本
+func (c *BalanceCache) syncPending(ctx context.Context) error {
+	values, err := c.redis.HGetAll(ctx, writeBehindPendingKey).Result()
+	if err != nil {
+		return fmt.Errorf("read pending: %w", err)
+	}
+	pending := make(map[int64]int64, len(values))
+	for k, v := range values {
+		id, err1 := strconv.ParseInt(k, 10, 64)
+		cents, err2 := strconv.ParseInt(v, 10, 64)
+		if err1 == nil && err2 == nil && cents != 0 {
+			pending[id] = cents
+		}
+	}
+	c.wb.pendingMu.Lock()
+	c.wb.pending = pending
+	c.wb.pendingMu.Unlock()
+	return nil
+}
+
+// mirror 同步其他路径的余额变更到 Redis
+func (c *BalanceCache) mirror(ctx context.Context, change *repository.BalanceChange) {
+	c.wb.flushMu.Lock()
+	defer c.wb.flushMu.Unlock()
+	if err := mirrorScript.Run(ctx, c.redis, []string{c.wbKey(change.UserID)}, toCents(change.Delta), change.Version).Err(); err != nil {
+		c.logger.Warn("Failed to mirror balance change",
+			zap.Int64("user_id", change.UserID), zap.Int64("version", change.Version), zap.Error(err))
+	}
+}
+
+// Reconcile 对账：按 cents = 数据库余额 + 未落库变动 校正 Redis 中的余额，返回校正的用户数
+func (c *BalanceCache) Reconcile(ctx context.Context) (int, error) {
+	if !c.WriteBehindEnabled() {
+		return 0, ErrWriteBehindDisabled
+	}
+	c.wb.flushMu.Lock()
+	defer c.wb.flushMu.Unlock()
+
+	repaired := 0
+	var cursor uint64
+	for {
+		keys, next, err := c.redis.Scan(ctx, cursor, writeBehindUserPrefix+"*", 200).Result()
+		if err != nil {
+			return repaired, fmt.Errorf("scan balances: %w", err)
+		}
+		userIDs := make([]int64, 0, len(keys))
+		for _, key := range keys {
+			if id, err := strconv.ParseInt(strings.TrimPrefix(key, writeBehindUserPrefix), 10, 64); err == nil {
+				userIDs = append(userIDs, id)
+			}
+		}
+		snapshots, err := c.userRepo.GetBalanceSnapshots(ctx, userIDs)
+		if err != nil {
+			return repaired, fmt.Errorf("get balance snapshots: %w", err)
+		}
+		for _, id := range userIDs {
+			snap, ok := snapshots[id]
+			if !ok {
+				_ = c.redis.Del(ctx, c.wbKey(id)).Err()
+				continue
+			}
+			status, old, err := c.loadSnapshot(ctx, id, snap, true)
+			if err != nil {
+				return repaired, err
+			}
+			if status == 1 {
+				repaired++
+				c.logger.Warn("Write-behind balance repaired",
+					zap.Int64("user_id", id),
+					zap.String("cached", fromCents(old).String()),
+					zap.String("db_balance", snap.Balance.String()),
+					zap.Int64("pending_cents", c.pendingCents(id)))
+			}
+		}
+		cursor = next
+		if cursor == 0 {
+			break
+		}
+	}
+	return repaired, nil
+}
+
+// StartWriteBehind 启动写回后台任务：定时落库、监听余额变更通知、定期对账
+func (c *BalanceCache) StartWriteBehind(ctx context.Context) error {
+	if !c.WriteBehindEnabled() {
+		return ErrWriteBehindDisabled
+	}
+
+	go c.flushLoop(ctx)
+	go c.listenLoop(ctx)
+	go c.reconcileLoop(ctx)
+	return nil
+}
+
+// flushLoop 定时落库与重放待写回加款，Redis 或数据库恢复后立即对账
+func (c *BalanceCache) flushLoop(ctx context.Context) {
+	ticker := time.NewTicker(c.wb.cfg.FlushInterval)
+	defer ticker.Stop()
+	replay := time.NewTicker(pendingCreditReplayInterval)
+	defer replay.Stop()
+
+	healthy := true
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-replay.C:
+			// 失败的操作已逐条记录日志，登记保留到下次重放
+			_, _ = c.ReplayRecordedCredits(ctx, pendingCreditMinAge)
+		case <-ticker.C:
+			_, err := c.Flush(ctx)
+			if err != nil {
+				if healthy {
+					c.logger.Error("Write-behind flush failed", zap.Error(err))
+				}
+				healthy = false
+				continue
+			}
+			if !healthy {
+				healthy = true
+				c.logger.Info("Write-behind flush recovered, reconciling")
+				if _, err := c.Reconcile(ctx); err != nil {
+					c.logger.Error("Reconcile after recovery failed", zap.Error(err))
+				}
+			}
+		}
+	}
+}
+
+// listenLoop 监听余额变更通知，断开后按指数退避重连
+func (c *BalanceCache) listenLoop(ctx context.Context) {
+	backoff := time.Second
+	for {
+		err := c.wb.outboxRepo.ListenBalanceChanges(ctx, func(change *repository.BalanceChange) {
+			backoff = time.Second
+			c.mirror(ctx, change)
+		})
+		if ctx.Err() != nil {
+			return
+		}
+		c.logger.Warn("Balance change listener disconnected", zap.Error(err), zap.Duration("retry_in", backoff))
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		// 断线期间的通知可能丢失，重连后对账
+		if _, err := c.Reconcile(ctx); err != nil {
+			c.logger.Warn("Reconcile after listener reconnect failed", zap.Error(err))
+		}
+		if backoff < maxListenBackoff {
+			backoff *= 2
+		}
+	}
+}
+
+// reconcileLoop 定期对账
+func (c *BalanceCache) reconcileLoop(ctx context.Context) {
+	ticker := time.NewTicker(c.wb.cfg.ReconcileInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			repaired, err := c.Reconcile(ctx)
+			if err != nil {
+				c.logger.Error("Write-behind reconcile failed", zap.Error(err))
+				continue
+			}
+			if repaired > 0 {
+				c.logger.Warn("Write-behind reconcile repaired balances", zap.Int("count", repaired))
+			}
+		}
+	}
+}
+
+// parseOutboxEntry 解析 outbox 条目
+func parseOutboxEntry(msg redis.XMessage) (*outboxEntry, error) {
+	metaRaw, _ := msg.Values["meta"].(string)
+	recordsRaw, _ := msg.Values["records"].(string)
+	entry := &outboxEntry{id: msg.ID}
+	if err := json.Unmarshal([]byte(metaRaw), &entry.meta); err != nil {
+		return nil, fmt.Errorf("parse meta: %w", err)
+	}
+	if entry.meta.Op == "" {
+		return nil, errors.New("missing op")
+	}
+	var rows [][]string
+	if err := json.Unmarshal([]byte(recordsRaw), &rows); err != nil {
+		return nil, fmt.Errorf("parse records: %w", err)
+	}
+	for _, row := range rows {
+		values := make([]interface{}, len(row))
+		for i, v := range row {
+			values[i] = v
+		}
+		parsed, err := parseInt64s(values)
+		if err != nil || len(parsed) != 4 {
+			return nil, fmt.Errorf("parse record %v: %w", row, err)
+		}
+		entry.records = append(entry.records, outboxRecord{
+			userID: parsed[0], delta: parsed[1], before: parsed[2], after: parsed[3],
+		})
+	}
+	return entry, nil
+}
+
+// parseInt64s 将 Lua 脚本返回的数组转换为 int64 切片
+func parseInt64s(raw interface{}) ([]int64, error) {
+	items, ok := raw.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("unexpected script reply: %v", raw)
+	}
+	values := make([]int64, len(items))
+	for i, item := range items {
+		switch v := item.(type) {
+		case int64:
+			values[i] = v
+		case string:
+			n, err := strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				return nil, err
+			}
+			values[i] = n
+		default:
+			return nil, fmt.Errorf("unexpected script value: %v", item)
+		}
+	}
+	return values, nil
+}
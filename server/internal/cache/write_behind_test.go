@@ -0,0 +1,291 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/fiveseconds/server/internal/config"
+	"github.com/fiveseconds/server/internal/model"
+	"github.com/fiveseconds/server/internal/repository"
+	"github.com/redis/go-redis/v9"
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+// newTestWriteBehind 创建连接 miniredis 的写回模式余额缓存（不访问数据库，用户缓存须先 seed）
+func newTestWriteBehind(t *testing.T) (*BalanceCache, *miniredis.Miniredis) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+
+	c := NewBalanceCache(client, nil, zap.NewNop())
+	c.EnableWriteBehind(config.WriteBehindConfig{}, nil, nil)
+	return c, mr
+}
+
+// seed 按数据库快照加载用户缓存
+func seed(t *testing.T, c *BalanceCache, userID, cents, version int64) {
+	t.Helper()
+	snap := repository.BalanceSnapshot{Balance: fromCents(cents), Version: version}
+	if _, _, err := c.loadSnapshot(context.Background(), userID, snap, false); err != nil {
+		t.Fatalf("seed user %d: %v", userID, err)
+	}
+}
+
+// cachedCents 读取 Redis 中的用户余额（分）
+func cachedCents(t *testing.T, mr *miniredis.Miniredis, c *BalanceCache, userID int64) string {
+	t.Helper()
+	return mr.HGet(c.wbKey(userID), "cents")
+}
+
+// outboxEntries 读取并解析 outbox 中的全部条目
+func outboxEntries(t *testing.T, mr *miniredis.Miniredis) []*outboxEntry {
+	t.Helper()
+	stream, err := mr.Stream(writeBehindStreamKey)
+	if err != nil {
+		t.Fatalf("read outbox: %v", err)
+	}
+	entries := make([]*outboxEntry, 0, len(stream))
+	for _, s := range stream {
+		values := make(map[string]interface{}, len(s.Values)/2)
+		for i := 0; i+1 < len(s.Values); i += 2 {
+			values[s.Values[i]] = s.Values[i+1]
+		}
+		entry, err := parseOutboxEntry(redis.XMessage{ID: s.ID, Values: values})
+		if err != nil {
+			t.Fatalf("parse outbox entry %s: %v", s.ID, err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// TestApplyStakes 测试批量扣款只扣余额充足的用户，并写入 pending、outbox 与操作ID
+func TestApplyStakes(t *testing.T) {
+	c, mr := newTestWriteBehind(t)
+	ctx := context.Background()
+	seed(t, c, 1, 1000, 1)
+	seed(t, c, 2, 300, 1)
+
+	meta := OutboxMeta{Op: "round:1:bet", Type: model.TxGameBet}
+	applied, err := c.ApplyStakes(ctx, []int64{1, 2}, decimal.NewFromInt(5), meta)
+	if err != nil {
+		t.Fatalf("ApplyStakes: %v", err)
+	}
+	if len(applied) != 1 {
+		t.Fatalf("Expected only user 1 to be charged, got %v", applied)
+	}
+	if got := applied[1]; !got.Before.Equal(decimal.NewFromInt(10)) || !got.After.Equal(decimal.NewFromInt(5)) {
+		t.Errorf("Expected 10 -> 5, got %s -> %s", got.Before, got.After)
+	}
+	if got := cachedCents(t, mr, c, 1); got != "500" {
+		t.Errorf("Expected user 1 cents 500, got %s", got)
+	}
+	if got := cachedCents(t, mr, c, 2); got != "300" {
+		t.Errorf("Expected user 2 untouched at 300, got %s", got)
+	}
+	if got := mr.HGet(writeBehindPendingKey, "1"); got != "-500" {
+		t.Errorf("Expected pending -500 for user 1, got %q", got)
+	}
+	if mr.HGet(writeBehindPendingKey, "2") != "" {
+		t.Error("Expected no pending change for user 2")
+	}
+	if got := c.pendingCents(1); got != -500 {
+		t.Errorf("Expected in-process pending -500, got %d", got)
+	}
+	if !mr.Exists(writeBehindOpPrefix+meta.Op) || mr.TTL(writeBehindOpPrefix+meta.Op) != writeBehindOpTTL {
+		t.Error("Expected the op id to be recorded with its TTL")
+	}
+
+	entries := outboxEntries(t, mr)
+	if len(entries) != 1 {
+		t.Fatalf("Expected one outbox entry, got %d", len(entries))
+	}
+	if entries[0].meta.Op != meta.Op || entries[0].meta.Type != model.TxGameBet {
+		t.Errorf("Unexpected outbox meta %+v", entries[0].meta)
+	}
+	want := []outboxRecord{{userID: 1, delta: -500, before: 1000, after: 500}}
+	if len(entries[0].records) != 1 || entries[0].records[0] != want[0] {
+		t.Errorf("Expected records %v, got %v", want, entries[0].records)
+	}
+
+	// 同一操作ID只执行一次
+	if _, err := c.ApplyStakes(ctx, []int64{1, 2}, decimal.NewFromInt(5), meta); !errors.Is(err, ErrDuplicateOp) {
+		t.Errorf("Expected ErrDuplicateOp, got %v", err)
+	}
+	if got := cachedCents(t, mr, c, 1); got != "500" {
+		t.Errorf("Expected user 1 cents to stay 500, got %s", got)
+	}
+}
+
+// TestApplyCreditsRequiresOp 测试补偿加款仅在所依赖的操作已执行时执行
+func TestApplyCreditsRequiresOp(t *testing.T) {
+	c, mr := newTestWriteBehind(t)
+	ctx := context.Background()
+	seed(t, c, 1, 1000, 1)
+
+	refund := OutboxMeta{Op: "round:2:bet_refund", Requires: "round:2:bet", Type: model.TxGameRefund}
+	amounts := map[int64]decimal.Decimal{1: decimal.NewFromInt(3)}
+	if _, err := c.ApplyCredits(ctx, amounts, refund); !errors.Is(err, ErrOpSkipped) {
+		t.Fatalf("Expected ErrOpSkipped before the stake, got %v", err)
+	}
+	if mr.Exists(writeBehindOpPrefix + refund.Op) {
+		t.Error("A skipped op must not be recorded, it may run once the stake is applied")
+	}
+
+	if _, err := c.ApplyStakes(ctx, []int64{1}, decimal.NewFromInt(3), OutboxMeta{Op: "round:2:bet", Type: model.TxGameBet}); err != nil {
+		t.Fatalf("ApplyStakes: %v", err)
+	}
+	applied, err := c.ApplyCredits(ctx, amounts, refund)
+	if err != nil {
+		t.Fatalf("ApplyCredits: %v", err)
+	}
+	if !applied[1].After.Equal(decimal.NewFromInt(10)) {
+		t.Errorf("Expected balance back at 10, got %s", applied[1].After)
+	}
+	if got := c.pendingCents(1); got != 0 {
+		t.Errorf("Expected pending to net to 0, got %d", got)
+	}
+	if n := len(outboxEntries(t, mr)); n != 2 {
+		t.Errorf("Expected two outbox entries, got %d", n)
+	}
+}
+
+// TestHoldBlocksCachedDebitsOnly 测试数据库路径暂停期间缓存扣款不执行，加款照常执行
+func TestHoldBlocksCachedDebitsOnly(t *testing.T) {
+	c, mr := newTestWriteBehind(t)
+	ctx := context.Background()
+	seed(t, c, 1, 1000, 1)
+	if err := mr.Set(c.holdKey(1), "1"); err != nil {
+		t.Fatal(err)
+	}
+
+	stake := OutboxMeta{Op: "round:3:bet", Type: model.TxGameBet}
+	applied, err := c.ApplyStakes(ctx, []int64{1}, decimal.NewFromInt(1), stake)
+	if err != nil || len(applied) != 0 {
+		t.Fatalf("Expected the held user to be skipped, got %v (%v)", applied, err)
+	}
+	if mr.Exists(writeBehindOpPrefix+stake.Op) || len(outboxEntries(t, mr)) != 0 {
+		t.Error("A stake that charged nobody must leave no op id or outbox entry")
+	}
+
+	applied, err = c.ApplyCredits(ctx, map[int64]decimal.Decimal{1: decimal.NewFromInt(2)}, OutboxMeta{Op: "round:3:win", Type: model.TxGameWin})
+	if err != nil || len(applied) != 1 {
+		t.Fatalf("Expected the credit to pass the hold, got %v (%v)", applied, err)
+	}
+	if got := cachedCents(t, mr, c, 1); got != "1200" {
+		t.Errorf("Expected cents 1200, got %s", got)
+	}
+
+	// 不足一分的加款不写入
+	applied, err = c.ApplyCredits(ctx, map[int64]decimal.Decimal{1: decimal.RequireFromString("0.004")}, OutboxMeta{Op: "round:4:win", Type: model.TxGameWin})
+	if err != nil || len(applied) != 0 || mr.Exists(writeBehindOpPrefix+"round:4:win") {
+		t.Errorf("Expected a sub-cent credit to be a no-op, got %v (%v)", applied, err)
+	}
+}
+
+// TestLoadSnapshotAddsPending 测试加载与强制校正按 cents = 数据库余额 + 未落库变动 计算
+func TestLoadSnapshotAddsPending(t *testing.T) {
+	c, mr := newTestWriteBehind(t)
+	ctx := context.Background()
+	seed(t, c, 1, 1000, 1)
+	if _, err := c.ApplyStakes(ctx, []int64{1}, decimal.NewFromInt(2), OutboxMeta{Op: "round:5:bet", Type: model.TxGameBet}); err != nil {
+		t.Fatalf("ApplyStakes: %v", err)
+	}
+
+	snap := repository.BalanceSnapshot{Balance: decimal.NewFromInt(10), Version: 1}
+	if status, _, err := c.loadSnapshot(ctx, 1, snap, false); err != nil || status != 0 {
+		t.Errorf("Expected an existing key to be left alone, got status %d (%v)", status, err)
+	}
+
+	mr.HSet(c.wbKey(1), "cents", "9999")
+	status, old, err := c.loadSnapshot(ctx, 1, repository.BalanceSnapshot{Balance: decimal.NewFromInt(10), Version: 2}, true)
+	if err != nil || status != 1 || old != 9999 {
+		t.Fatalf("Expected a repair from 9999, got status %d old %d (%v)", status, old, err)
+	}
+	if got := cachedCents(t, mr, c, 1); got != "800" {
+		t.Errorf("Expected db 1000 plus pending -200, got %s", got)
+	}
+	if got := mr.HGet(c.wbKey(1), "ver"); got != "2" {
+		t.Errorf("Expected version 2, got %s", got)
+	}
+
+	if status, _, err := c.loadSnapshot(ctx, 2, snap, false); err != nil || status != 2 {
+		t.Errorf("Expected a fresh load, got status %d (%v)", status, err)
+	}
+}
+
+// TestMirrorSkipsStaleVersions 测试变更通知按版本号同步，已包含在快照中的通知跳过
+func TestMirrorSkipsStaleVersions(t *testing.T) {
+	c, mr := newTestWriteBehind(t)
+	ctx := context.Background()
+	seed(t, c, 1, 1000, 5)
+
+	tests := []struct {
+		version int64
+		want    string
+	}{
+		{4, "1000"},
+		{5, "1000"},
+		{6, "1200"},
+		{6, "1200"},
+		{8, "1400"},
+	}
+	for _, tt := range tests {
+		c.mirror(ctx, &repository.BalanceChange{UserID: 1, Delta: decimal.NewFromInt(2), Version: tt.version})
+		if got := cachedCents(t, mr, c, 1); got != tt.want {
+			t.Errorf("After version %d expected cents %s, got %s", tt.version, tt.want, got)
+		}
+	}
+
+	// 未加载的用户不创建缓存
+	c.mirror(ctx, &repository.BalanceChange{UserID: 2, Delta: decimal.NewFromInt(2), Version: 1})
+	if mr.Exists(c.wbKey(2)) {
+		t.Error("Expected no cache entry for an unloaded user")
+	}
+}
+
+// TestAckEntriesReleasesPending 测试确认 outbox 条目时扣减未落库变动并删除条目
+func TestAckEntriesReleasesPending(t *testing.T) {
+	c, mr := newTestWriteBehind(t)
+	ctx := context.Background()
+	seed(t, c, 1, 1000, 1)
+	seed(t, c, 2, 1000, 1)
+	if _, err := c.ApplyStakes(ctx, []int64{1, 2}, decimal.NewFromInt(1), OutboxMeta{Op: "round:6:bet", Type: model.TxGameBet}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.ApplyStakes(ctx, []int64{1}, decimal.NewFromInt(1), OutboxMeta{Op: "round:7:bet", Type: model.TxGameBet}); err != nil {
+		t.Fatal(err)
+	}
+
+	entries := outboxEntries(t, mr)
+	if err := c.ackEntries(ctx, entries[:1], []string{entries[0].id}); err != nil {
+		t.Fatalf("ack first entry: %v", err)
+	}
+	if got := mr.HGet(writeBehindPendingKey, "1"); got != "-100" {
+		t.Errorf("Expected user 1 pending -100 after the first ack, got %q", got)
+	}
+	if mr.HGet(writeBehindPendingKey, "2") != "" {
+		t.Error("Expected user 2 pending to be removed once it reaches zero")
+	}
+
+	if err := c.ackEntries(ctx, entries[1:], []string{entries[1].id}); err != nil {
+		t.Fatalf("ack second entry: %v", err)
+	}
+	if n := len(outboxEntries(t, mr)); n != 0 {
+		t.Errorf("Expected acked entries to be deleted, %d left", n)
+	}
+	if err := c.syncPending(ctx); err != nil {
+		t.Fatalf("syncPending: %v", err)
+	}
+	if c.pendingCents(1) != 0 || c.pendingCents(2) != 0 {
+		t.Errorf("Expected no in-process pending, got %d and %d", c.pendingCents(1), c.pendingCents(2))
+	}
+	// 余额缓存保持扣款后的值，等于数据库落库后的余额
+	if got := cachedCents(t, mr, c, 1); got != "800" {
+		t.Errorf("Expected user 1 cents 800, got %s", got)
+	}
+}
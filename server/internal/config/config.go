@@ -9,12 +9,33 @@ import (
 
 // Config 应用配置
 type Config struct {
-	Server   ServerConfig   `yaml:"server"`
-	Database DatabaseConfig `yaml:"database"`
-	Redis    RedisConfig    `yaml:"redis"`
-	Game     GameConfig     `yaml:"game"`
-	Auth     AuthConfig     `yaml:"auth"`
-	Logging  LoggingConfig  `yaml:"logging"`
+	Server     ServerConfig     `yaml:"server"`
+	Database   DatabaseConfig   `yaml:"database"`
+	Redis      RedisConfig      `yaml:"redis"`
+	Game       GameConfig       `yaml:"game"`
+	Auth       AuthConfig       `yaml:"auth"`
+	Logging    LoggingConfig    `yaml:"logging"`
+	Fund       FundConfig       `yaml:"fund"`
+	Statement  StatementConfig  `yaml:"statement"`
+	Settlement SettlementConfig `yaml:"settlement"`
+	Bonus      BonusConfig      `yaml:"bonus"`
+	Rebate     RebateConfig     `yaml:"rebate"`
+	Referral   ReferralConfig   `yaml:"referral"`
+
+	BalanceSnapshot BalanceSnapshotConfig `yaml:"balance_snapshot"`
+	Currency        CurrencyConfig        `yaml:"currency"`
+	CreditLimit     CreditLimitConfig     `yaml:"credit_limit"`
+	Practice        PracticeConfig        `yaml:"practice"`
+	Tournament      TournamentConfig      `yaml:"tournament"`
+	Jackpot         JackpotConfig         `yaml:"jackpot"`
+	RiskRules       RiskRuleConfig        `yaml:"risk_rules"`
+	Collusion       CollusionConfig       `yaml:"collusion"`
+	LoginHistory    LoginHistoryConfig    `yaml:"login_history"`
+	Restriction     RestrictionConfig     `yaml:"restriction"`
+	RiskScore       RiskScoreConfig       `yaml:"risk_score"`
+	OwnerRisk       OwnerRiskConfig       `yaml:"owner_risk"`
+	Fairness        FairnessConfig        `yaml:"fairness"`
+	Notification    NotificationConfig    `yaml:"notification"`
 }
 
 // ServerConfig 服务器配置
@@ -24,6 +45,9 @@ type ServerConfig struct {
 	WSPath      string `yaml:"ws_path"`
 	MetricsPort int    `yaml:"metrics_port"`
 	Mode        string `yaml:"mode"`
+
+	// 可信反向代理（IP 或 CIDR），仅来自这些地址的请求才采信 X-Forwarded-For / X-Real-IP；为空时直接使用连接地址
+	TrustedProxies []string `yaml:"trusted_proxies"`
 }
 
 // DatabaseConfig 数据库配置
@@ -46,6 +70,17 @@ type RedisConfig struct {
 	Password string `yaml:"password"`
 	DB       int    `yaml:"db"`
 	PoolSize int    `yaml:"pool_size"`
+
+	WriteBehind WriteBehindConfig `yaml:"write_behind"`
+}
+
+// WriteBehindConfig 余额写回缓存配置
+// 开启后下注扣款与派奖先写 Redis，再由 outbox 异步批量落库（Redis 需开启 AOF 持久化）
+type WriteBehindConfig struct {
+	Enabled           bool          `yaml:"enabled"`            // 是否开启写回模式
+	FlushInterval     time.Duration `yaml:"flush_interval"`     // outbox 落库间隔
+	FlushBatchSize    int           `yaml:"flush_batch_size"`   // 单次落库最大条目数
+	ReconcileInterval time.Duration `yaml:"reconcile_interval"` // 缓存与 users.balance 对账间隔
 }
 
 // GameConfig 游戏配置
@@ -77,6 +112,179 @@ type LoggingConfig struct {
 	Output string `yaml:"output"`
 }
 
+// FundConfig 资金配置
+type FundConfig struct {
+	Approval         FundApprovalConfig `yaml:"approval"`
+	RequestTTL       time.Duration      `yaml:"request_ttl"`       // 待审批申请有效期，超时自动过期（0 表示不过期）
+	ReminderInterval time.Duration      `yaml:"reminder_interval"` // 提醒房主处理待审批申请的间隔（0 表示不提醒）
+}
+
+// FundApprovalConfig 资金申请审批策略（金额阈值为 0 表示不启用）
+type FundApprovalConfig struct {
+	DualApprovalThreshold float64 `yaml:"dual_approval_threshold"`  // 达到该金额需两名不同审批人
+	EscalationThreshold   float64 `yaml:"escalation_threshold"`     // 达到该金额必须由平台管理员审批
+	OwnerDailyApprovalCap float64 `yaml:"owner_daily_approval_cap"` // 房主每日审批通过总额上限（含双人审批的首次审批），超出部分升级至管理员
+}
+
+// StatementConfig 账单导出配置
+type StatementConfig struct {
+	OutputDir     string `yaml:"output_dir"`      // 异步导出文件存放目录
+	MaxSyncRows   int    `yaml:"max_sync_rows"`   // 同步下载的最大交易笔数，超过需使用异步任务
+	MaxPeriodDays int    `yaml:"max_period_days"` // 单次账单最长周期（天）
+}
+
+// SettlementConfig 房主佣金结算配置
+type SettlementConfig struct {
+	Enabled      bool   `yaml:"enabled"`       // 是否开启周期结算
+	Period       string `yaml:"period"`        // 结算周期：daily/weekly
+	AutoTransfer bool   `yaml:"auto_transfer"` // 结算后自动将佣金转入房主可用余额
+}
+
+// BonusConfig 奖励余额配置
+type BonusConfig struct {
+	DefaultWageringMultiplier float64 `yaml:"default_wagering_multiplier"` // 默认流水倍数
+	MaxWageringMultiplier     float64 `yaml:"max_wagering_multiplier"`     // 最大流水倍数
+	MaxGrantAmount            float64 `yaml:"max_grant_amount"`            // 单笔奖励上限，0 表示不限
+}
+
+// RebateConfig 返水配置
+type RebateConfig struct {
+	MaxRate       float64 `yaml:"max_rate"`        // 单档返水比例上限
+	MaxPeriodDays int     `yaml:"max_period_days"` // 单次返水周期最大天数
+}
+
+// ReferralConfig 玩家推荐奖励配置
+type ReferralConfig struct {
+	Enabled             bool    `yaml:"enabled"`               // 是否开启推荐奖励发放任务
+	FundingSource       string  `yaml:"funding_source"`        // 资金来源：owner（房主佣金）/platform（平台抽成）
+	CommissionShareRate float64 `yaml:"commission_share_rate"` // 被推荐人佣金分成比例，0 表示不分成
+	QualifyingRounds    int     `yaml:"qualifying_rounds"`     // 一次性奖励所需完成局数，0 表示关闭
+	FirstRoundsReward   float64 `yaml:"first_rounds_reward"`   // 完成前 N 局的一次性奖励金额
+}
+
+// BalanceSnapshotConfig 日终余额快照配置
+type BalanceSnapshotConfig struct {
+	Timezone string `yaml:"timezone"` // 日终时区（如 Asia/Shanghai），为空使用服务器本地时区
+}
+
+// CurrencyConfig 多币种配置
+// 支持的币种即 bet_amounts 中配置了下注金额的币种（默认币种始终支持）
+type CurrencyConfig struct {
+	Default    string               `yaml:"default"`     // 默认币种（新房主及历史数据），为空使用 CNY
+	BetAmounts map[string][]float64 `yaml:"bet_amounts"` // 各币种有效的下注金额，默认币种未配置时沿用内置档位
+}
+
+// CreditLimitConfig 房主信用额度配置（名下玩家资金敞口与保证金挂钩）
+type CreditLimitConfig struct {
+	Enabled              bool    `yaml:"enabled"`                // 是否开启敞口限制
+	MarginMultiplier     float64 `yaml:"margin_multiplier"`      // 默认倍数 K：名下玩家余额总额不得超过 K × 保证金，0 表示不限
+	CheckIntervalMinutes int     `yaml:"check_interval_minutes"` // 超限巡检间隔（分钟），超限时自动暂停房主房间
+}
+
+// PracticeConfig 练习房配置（游戏币与真实余额隔离）
+type PracticeConfig struct {
+	InitialChips float64 `yaml:"initial_chips"` // 初始游戏币数量，余额低于该数量时可补充到该数量
+}
+
+// TournamentConfig 锦标赛配置
+type TournamentConfig struct {
+	CheckIntervalSeconds int `yaml:"check_interval_seconds"` // 开始/结束锦标赛的巡检间隔（秒）
+	SettleGraceSeconds   int `yaml:"settle_grace_seconds"`   // 达到回合上限后仍有回合未结算时，最多等待多久强制结束（秒）
+}
+
+// JackpotConfig 累进奖池配置（房主开设奖池时的取值范围）
+type JackpotConfig struct {
+	MaxContributionRate float64 `yaml:"max_contribution_rate"` // 每回合从奖池抽取比例上限
+	MinTriggerOdds      int     `yaml:"min_trigger_odds"`      // 触发概率 1/N 中 N 的下限
+}
+
+// RiskRuleConfig 风控规则引擎配置
+type RiskRuleConfig struct {
+	ReloadIntervalSeconds int `yaml:"reload_interval_seconds"` // 重新加载规则的间隔（秒），管理后台修改后本实例立即生效，其他实例按此间隔生效
+	WinRateWindow         int `yaml:"win_rate_window"`         // user.recent_win_rate 统计的最近回合数
+	MaxDryRunDays         int `yaml:"max_dry_run_days"`        // 试运行最多回溯的天数
+}
+
+// CollusionConfig 串通检测配置
+type CollusionConfig struct {
+	WindowDays           int     `yaml:"window_days"`            // 分析的时间窗口（天）
+	MinCoPlayRounds      int     `yaml:"min_co_play_rounds"`     // 判定联合胜率所需的最少同场回合数
+	MinJointWins         int     `yaml:"min_joint_wins"`         // 判定联合胜率所需的最少联合获胜回合数
+	JointWinRatio        float64 `yaml:"joint_win_ratio"`        // 联合获胜回合数达到随机期望的倍数即视为异常
+	FunnelMinSources     int     `yaml:"funnel_min_sources"`     // 资金归集：同一账户至少收到多少个关联账户的转账
+	FunnelMinAmount      float64 `yaml:"funnel_min_amount"`      // 资金归集：归集金额下限
+	IncrementalMaxRounds int     `yaml:"incremental_max_rounds"` // 回合结算后增量分析最多读取的历史回合数
+	BatchHour            int     `yaml:"batch_hour"`             // 夜间全量分析的执行时刻（0~23 点）
+}
+
+// LoginHistoryConfig 登录历史配置
+type LoginHistoryConfig struct {
+	GeoDBPath                string `yaml:"geo_db_path"`                 // 本地 IP 地区库（每行 "cidr,地区"），为空时不标注地区
+	NewDeviceWithdrawMinutes int    `yaml:"new_device_withdraw_minutes"` // 新设备登录后多少分钟内申请提现需要标记
+}
+
+// RestrictionConfig 账户限制配置
+type RestrictionConfig struct {
+	DefaultHours int                     `yaml:"default_hours"` // 人工施加限制未指定时长时的默认时长（小时）
+	Auto         []AutoRestrictionConfig `yaml:"auto"`          // 风控规则触发时按严重程度自动施加的限制，为空时不自动施加
+}
+
+// AutoRestrictionConfig 按风控规则严重程度自动施加的限制
+type AutoRestrictionConfig struct {
+	Severity string   `yaml:"severity"` // low/medium/high/critical
+	Types    []string `yaml:"types"`    // no_betting/no_withdrawal/chat_muted/suspended
+	Hours    int      `yaml:"hours"`    // 限制时长（小时）
+}
+
+// RiskScoreConfig 用户风险评分配置
+type RiskScoreConfig struct {
+	HalfLifeDays          int     `yaml:"half_life_days"`          // 风控标记得分的半衰期（天）
+	WindowDays            int     `yaml:"window_days"`             // 行为信号（胜率、充值/下注比）统计窗口（天）
+	MinRounds             int     `yaml:"min_rounds"`              // 计算胜率偏离所需的最少回合数
+	MinDeposit            float64 `yaml:"min_deposit"`             // 计算充值/下注比所需的最低充值金额
+	NewAccountDays        int     `yaml:"new_account_days"`        // 账户年龄低于该天数时计入新账户得分
+	MediumScore           float64 `yaml:"medium_score"`            // 中风险分值线
+	HighScore             float64 `yaml:"high_score"`              // 高风险分值线
+	CriticalScore         float64 `yaml:"critical_score"`          // 极高风险分值线
+	AlertLevel            string  `yaml:"alert_level"`             // 评分升至该等级及以上时告警（high/critical）
+	RoundRecomputeMinutes int     `yaml:"round_recompute_minutes"` // 回合结算触发重算的最小间隔（分钟）
+}
+
+// OwnerRiskConfig 房主维度风控配置（每日房主对账后检测）
+type OwnerRiskConfig struct {
+	LookbackDays              int     `yaml:"lookback_days"`                // 对比基线（平均抽成比例、保证金峰值）的天数
+	CommissionMinAmount       float64 `yaml:"commission_min_amount"`        // 当日抽成收入低于该金额时不检测
+	MaxCommissionRate         float64 `yaml:"max_commission_rate"`          // 抽成收入 / 房间流水的上限，为 0 时使用 game.max_total_commission
+	CommissionSpikeRatio      float64 `yaml:"commission_spike_ratio"`       // 抽成比例达到基线的倍数即视为异常
+	DepositWindowMinutes      int     `yaml:"deposit_window_minutes"`       // 房主提现前多少分钟内的玩家充值审批计入
+	MinDepositsBeforeWithdraw int     `yaml:"min_deposits_before_withdraw"` // 窗口内审批的玩家充值笔数达到该值即标记
+	MarginDrawdownRatio       float64 `yaml:"margin_drawdown_ratio"`        // 保证金较基线峰值回撤的比例
+	WinRateMinRounds          int     `yaml:"win_rate_min_rounds"`          // 检测玩家整体胜率所需的最少参与人次
+	WinRateZScore             float64 `yaml:"win_rate_z_score"`             // 玩家整体获胜数偏离期望的标准差倍数
+	ConservationSwingAmount   float64 `yaml:"conservation_swing_amount"`    // 资金守恒差额较上一日的变化金额
+}
+
+// FairnessConfig 回合结果公平性统计配置
+type FairnessConfig struct {
+	WindowDays      int     `yaml:"window_days"`       // 统计的时间窗口（天）
+	IntervalHours   int     `yaml:"interval_hours"`    // 定时统计的间隔（小时）
+	PValue          float64 `yaml:"p_value"`           // 错误发现率：同一次统计的全部检验按 Benjamini–Hochberg 校正后判定显著
+	MinRounds       int     `yaml:"min_rounds"`        // 全局或房间进行卡方检验所需的最少回合数
+	MinPlayerRounds int     `yaml:"min_player_rounds"` // 玩家进行游程检验与二项检验所需的最少参与回合数
+	MinExpected     float64 `yaml:"min_expected"`      // 卡方检验中期望获胜次数低于该值的玩家合并为一组
+	MaxRounds       int     `yaml:"max_rounds"`        // 每次统计最多读取的最近回合数
+}
+
+// NotificationConfig 告警通知投递配置（通知渠道与路由规则保存在数据库中）
+type NotificationConfig struct {
+	PollSeconds       int `yaml:"poll_seconds"`        // 后台任务检查待投递记录的间隔（秒）
+	BatchSize         int `yaml:"batch_size"`          // 每次最多发送的投递记录数
+	TimeoutSeconds    int `yaml:"timeout_seconds"`     // 单次发送的超时时间（秒）
+	MaxAttempts       int `yaml:"max_attempts"`        // 最大发送次数，达到后标记为失败
+	BackoffSeconds    int `yaml:"backoff_seconds"`     // 首次重试的等待时间（秒），之后每次翻倍
+	MaxBackoffSeconds int `yaml:"max_backoff_seconds"` // 重试等待时间的上限（秒）
+}
+
 // Load 从文件加载配置
 func Load(path string) (*Config, error) {
 	data, err := os.ReadFile(path)
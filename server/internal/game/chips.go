@@ -0,0 +1,190 @@
+package game
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/fiveseconds/server/internal/model"
+
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+// ErrChipLedgerUnavailable 未配置筹码账本
+var ErrChipLedgerUnavailable = errors.New("chip ledger not configured")
+
+// chipLedger 当前房间使用的筹码账本（练习房为游戏币，锦标赛房间为所属锦标赛的筹码）
+func (rp *RoomProcessor) chipLedger() ChipLedger {
+	switch {
+	case rp.Room.IsPractice() && rp.practice != nil:
+		return rp.practice
+	case rp.Room.IsTournament() && rp.tournament != nil && rp.Room.TournamentID != nil:
+		return &tournamentChips{ledger: rp.tournament, tournamentID: *rp.Room.TournamentID}
+	}
+	return nil
+}
+
+// loadPlayerBalance 加载玩家可下注余额
+// 筹码房间返回筹码余额（无奖励余额），普通房间返回真实余额（写回模式下从缓存读取）与奖励余额
+func (rp *RoomProcessor) loadPlayerBalance(ctx context.Context, user *model.User) (decimal.Decimal, decimal.Decimal) {
+	if rp.Room.UsesChips() {
+		ledger := rp.chipLedger()
+		if ledger == nil {
+			rp.logger.Error("Chip room without chip ledger", zap.Int64("user_id", user.ID))
+			return decimal.Zero, decimal.Zero
+		}
+		chips, err := ledger.GetChips(ctx, user.ID)
+		if err != nil {
+			rp.logger.Warn("Failed to load chips", zap.Int64("user_id", user.ID), zap.Error(err))
+			return decimal.Zero, decimal.Zero
+		}
+		return chips, decimal.Zero
+	}
+
+	balance := user.Balance
+	if rp.balanceCache != nil {
+		if cached, err := rp.balanceCache.LoadFromDB(ctx, user.ID); err == nil {
+			balance = cached.Balance
+		}
+	}
+	return balance, user.BonusBalance
+}
+
+// enterBettingChips 筹码房间下注：批量扣除筹码，不创建回合记录与交易流水
+func (rp *RoomProcessor) enterBettingChips(ctx context.Context, eligiblePlayers, skipped []int64) {
+	betAmount := rp.Room.BetAmount
+
+	var balances map[int64]decimal.Decimal
+	err := ErrChipLedgerUnavailable
+	if ledger := rp.chipLedger(); ledger != nil {
+		balances, err = ledger.DeductStakes(ctx, eligiblePlayers, betAmount)
+	}
+	if err != nil {
+		rp.logger.Error("Chip betting failed", zap.Error(err))
+		rp.Broadcaster.BroadcastToRoom(rp.RoomID, &model.WSMessage{
+			Type: model.WSTypeRoundFailed,
+			Payload: &model.WSRoundFailed{
+				Reason:   "betting_failed",
+				Refunded: []int64{},
+			},
+		})
+		rp.enterWaiting()
+		return
+	}
+
+	participants := make([]int64, 0, len(balances))
+	for _, userID := range eligiblePlayers {
+		newBalance, ok := balances[userID]
+		if !ok {
+			// 筹码不足或已在其他途径变动，本轮跳过
+			skipped = append(skipped, userID)
+			continue
+		}
+		participants = append(participants, userID)
+		if p := rp.State.Players[userID]; p != nil {
+			p.Balance = newBalance
+		}
+	}
+
+	if len(participants) < rp.getMinPlayers() {
+		rp.refundChipsAndWait(ctx, participants, "not_enough_players")
+		return
+	}
+
+	poolAmount := betAmount.Mul(decimal.NewFromInt(int64(len(participants))))
+	rp.startBetting(participants, skipped, poolAmount, 0, nil, false)
+}
+
+// settleChips 筹码房间结算：奖池全部以筹码派发给赢家
+// 不产生房主抽成与平台收入，不记录回合，不触发风控检查
+func (rp *RoomProcessor) settleChips(ctx context.Context, winners []int64, revealSeed string) {
+	var prizePerWinner decimal.Decimal
+	var balances map[int64]decimal.Decimal
+	err := ErrChipLedgerUnavailable
+	if ledger := rp.chipLedger(); ledger != nil {
+		prizePerWinner, balances, err = ledger.SettleRound(ctx, rp.State.Participants, winners, rp.State.PoolAmount)
+	}
+	if err != nil {
+		rp.logger.Error("Chip settlement failed", zap.Error(err))
+		rp.refundChipsAndWait(ctx, rp.State.Participants, "settlement_error")
+		return
+	}
+
+	winnerNames := make([]string, 0, len(winners))
+	for _, winnerID := range winners {
+		if p := rp.State.Players[winnerID]; p != nil {
+			winnerNames = append(winnerNames, p.Username)
+			if newBalance, ok := balances[winnerID]; ok {
+				p.Balance = newBalance
+			}
+		}
+	}
+
+	rp.State.Phase = model.PhaseSettlement
+	rp.State.PhaseEndTime = time.Now().Add(PhaseDuration)
+	rp.broadcastPhaseChange()
+
+	rp.Broadcaster.BroadcastToRoom(rp.RoomID, &model.WSMessage{
+		Type: model.WSTypeRoundResult,
+		Payload: &model.WSRoundResult{
+			Winners:        winners,
+			WinnerNames:    winnerNames,
+			PrizePerWinner: prizePerWinner.String(),
+			RevealSeed:     revealSeed,
+			CommitHash:     rp.State.CommitHash,
+		},
+	})
+
+	rp.logger.Info("Phase changed", zap.String("phase", "settlement"), zap.String("room_type", string(rp.Room.RoomType)),
+		zap.Int64s("winners", winners), zap.String("prize", prizePerWinner.String()))
+}
+
+// refundChipsAndWait 退回筹码房间下注的筹码并返回等待阶段
+func (rp *RoomProcessor) refundChipsAndWait(ctx context.Context, participants []int64, reason string) {
+	if len(participants) > 0 {
+		var balances map[int64]decimal.Decimal
+		err := ErrChipLedgerUnavailable
+		if ledger := rp.chipLedger(); ledger != nil {
+			balances, err = ledger.RefundStakes(ctx, participants, rp.Room.BetAmount)
+		}
+		if err != nil {
+			rp.logger.Error("Chip refund failed", zap.Int64s("user_ids", participants), zap.Error(err))
+		}
+		for userID, newBalance := range balances {
+			if p := rp.State.Players[userID]; p != nil {
+				p.Balance = newBalance
+			}
+		}
+	}
+
+	rp.Broadcaster.BroadcastToRoom(rp.RoomID, &model.WSMessage{
+		Type: model.WSTypeRoundFailed,
+		Payload: &model.WSRoundFailed{
+			Reason:   reason,
+			Refunded: participants,
+		},
+	})
+
+	rp.enterWaiting()
+}
+
+// UpdatePracticeChips 更新练习房内玩家的游戏币余额(补充游戏币后调用)
+func (rp *RoomProcessor) UpdatePracticeChips(userID int64, chips decimal.Decimal) {
+	if !rp.Room.IsPractice() {
+		return
+	}
+	rp.mu.Lock()
+	defer rp.mu.Unlock()
+
+	if p := rp.State.Players[userID]; p != nil {
+		p.Balance = chips
+		rp.Broadcaster.BroadcastToRoom(rp.RoomID, &model.WSMessage{
+			Type: model.WSTypePlayerUpdate,
+			Payload: &model.WSPlayerUpdate{
+				UserID:  userID,
+				Balance: chips.String(),
+			},
+		})
+	}
+}
@@ -11,3 +11,9 @@ var (
 	ErrRoomFull              = errors.New("room is full")
 	ErrNotParticipant        = errors.New("not a participant")
 )
+
+// ErrCurrencyMismatch 玩家活跃币种与房间币种不一致，不能参与游戏（可观战）
+var ErrCurrencyMismatch = errors.New("currency mismatch with room")
+
+// ErrNotTournamentEntrant 未报名（或已退赛）的玩家不能参与锦标赛房间的游戏（可观战）
+var ErrNotTournamentEntrant = errors.New("not registered for this tournament")
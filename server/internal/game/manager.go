@@ -7,6 +7,7 @@ import (
 	"github.com/fiveseconds/server/internal/cache"
 	"github.com/fiveseconds/server/internal/repository"
 
+	"github.com/shopspring/decimal"
 	"go.uber.org/zap"
 )
 
@@ -23,6 +24,11 @@ type Manager struct {
 	platformRepo *repository.PlatformRepo
 	balanceCache *cache.BalanceCache
 	riskChecker  RiskChecker
+	bonusTracker BonusTracker
+	practice     ChipLedger
+	tournament   TournamentLedger
+	jackpot      JackpotPool
+	restrictions RestrictionChecker
 	logger       *zap.Logger
 }
 
@@ -52,6 +58,41 @@ func NewManager(
 	}
 }
 
+// SetBonusTracker 设置奖励余额流水跟踪器（需在创建房间处理器之前调用）
+func (m *Manager) SetBonusTracker(tracker BonusTracker) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.bonusTracker = tracker
+}
+
+// SetPracticeLedger 设置练习房游戏币账本（需在创建房间处理器之前调用）
+func (m *Manager) SetPracticeLedger(ledger ChipLedger) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.practice = ledger
+}
+
+// SetTournamentLedger 设置锦标赛筹码账本（需在创建房间处理器之前调用）
+func (m *Manager) SetTournamentLedger(ledger TournamentLedger) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.tournament = ledger
+}
+
+// SetJackpotPool 设置累进奖池（需在创建房间处理器之前调用）
+func (m *Manager) SetJackpotPool(pool JackpotPool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.jackpot = pool
+}
+
+// SetRestrictionChecker 设置账户限制检查（需在创建房间处理器之前调用）
+func (m *Manager) SetRestrictionChecker(checker RestrictionChecker) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.restrictions = checker
+}
+
 // GetOrCreateRoom 获取或创建房间处理器
 func (m *Manager) GetOrCreateRoom(ctx context.Context, roomID int64) (*RoomProcessor, error) {
 	m.mu.Lock()
@@ -78,6 +119,11 @@ func (m *Manager) GetOrCreateRoom(ctx context.Context, roomID int64) (*RoomProce
 		m.riskChecker,
 		m.logger,
 	)
+	rp.SetBonusTracker(m.bonusTracker)
+	rp.SetPracticeLedger(m.practice)
+	rp.SetTournamentLedger(m.tournament)
+	rp.SetJackpotPool(m.jackpot)
+	rp.SetRestrictionChecker(m.restrictions)
 
 	// 从数据库加载已有玩家（服务器重启后恢复状态）
 	// 所有玩家初始状态为离线，等待他们重新连接 WebSocket
@@ -134,6 +180,26 @@ func (m *Manager) GetPlayerCount() int {
 	return count
 }
 
+// UpdatePlayerBonusBalance 更新玩家所在房间内存中的奖励余额
+func (m *Manager) UpdatePlayerBonusBalance(userID int64, bonusBalance decimal.Decimal) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, rp := range m.rooms {
+		rp.UpdatePlayerBonusBalance(userID, bonusBalance)
+	}
+}
+
+// UpdatePracticeChips 更新玩家所在练习房内存中的游戏币余额
+func (m *Manager) UpdatePracticeChips(userID int64, chips decimal.Decimal) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, rp := range m.rooms {
+		rp.UpdatePracticeChips(userID, chips)
+	}
+}
+
 // Shutdown 关闭所有房间
 func (m *Manager) Shutdown() {
 	m.mu.Lock()
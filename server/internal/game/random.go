@@ -3,6 +3,7 @@ package game
 import (
 	"crypto/rand"
 	"crypto/sha256"
+	"encoding/binary"
 	"encoding/hex"
 	"fmt"
 	mrand "math/rand"
@@ -79,6 +80,21 @@ func (cr *CommitReveal) SelectWinners(playerIDs []int64, winnerCount int, seed [
 	return shuffled[:winnerCount]
 }
 
+// JackpotTriggered 根据公开种子判断本回合是否触发累进奖池
+// 计算 SHA-256(种子 || "jackpot")，取前 8 字节按大端解释为无符号整数，对 odds 取模为 0 即触发（概率 1/odds）
+// 与赢家选择使用同一种子，任何人拿到 reveal_seed 即可复核
+func (cr *CommitReveal) JackpotTriggered(revealSeed string, odds int) bool {
+	if odds <= 0 {
+		return false
+	}
+	seedBytes, err := hex.DecodeString(revealSeed)
+	if err != nil {
+		return false
+	}
+	h := sha256.Sum256(append(seedBytes, "jackpot"...))
+	return binary.BigEndian.Uint64(h[:8])%uint64(odds) == 0
+}
+
 // seedSource 使用完整种子的随机源
 type seedSource struct {
 	seed  []byte
@@ -0,0 +1,36 @@
+package game
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestJackpotTriggered 测试累进奖池触发条件：SHA-256(种子 || "jackpot") 前 8 字节对触发概率取模为 0
+func TestJackpotTriggered(t *testing.T) {
+	cr := NewCommitReveal()
+	zeros := strings.Repeat("00", 32)    // 前 8 字节 = 7782340633395836379 = 3 × 53 × ...
+	repeated := strings.Repeat("ab", 32) // 前 8 字节 = 14766445052012495074（偶数，不被 3 整除）
+	tests := []struct {
+		name string
+		seed string
+		odds int
+		want bool
+	}{
+		{"odds of 1 always trigger", zeros, 1, true},
+		{"divisor triggers", zeros, 3, true},
+		{"larger divisor triggers", zeros, 159, true},
+		{"non-divisor misses", zeros, 2, false},
+		{"large odds miss", zeros, 1000, false},
+		{"even value at odds 2", repeated, 2, true},
+		{"even value at odds 3", repeated, 3, false},
+		{"upper-case hex is the same seed", strings.ToUpper(repeated), 2, true},
+		{"zero odds never trigger", zeros, 0, false},
+		{"negative odds never trigger", zeros, -3, false},
+		{"malformed seed never triggers", "not-hex", 1, false},
+	}
+	for _, tt := range tests {
+		if got := cr.JackpotTriggered(tt.seed, tt.odds); got != tt.want {
+			t.Errorf("%s: Expected %v, got %v", tt.name, tt.want, got)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package game
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sync"
 	"time"
@@ -38,6 +39,46 @@ type RiskChecker interface {
 	OnRoundSettled(ctx context.Context, participants []int64, winners []int64)
 }
 
+// BonusTracker 奖励余额流水跟踪接口
+// 在结算事务内累计玩家下注流水，流水达标的奖励余额转为真实余额
+type BonusTracker interface {
+	RecordWagersTx(ctx context.Context, tx pgx.Tx, wagers map[int64]decimal.Decimal) ([]model.BonusConversion, error)
+}
+
+// ChipLedger 筹码账本（练习房游戏币、锦标赛筹码）
+// 筹码房间下注、派奖与退款只变动筹码，不涉及真实余额、交易流水、房主抽成与平台账户
+type ChipLedger interface {
+	GetChips(ctx context.Context, userID int64) (decimal.Decimal, error)
+	DeductStakes(ctx context.Context, userIDs []int64, stake decimal.Decimal) (map[int64]decimal.Decimal, error)
+	SettleRound(ctx context.Context, participants, winners []int64, pool decimal.Decimal) (decimal.Decimal, map[int64]decimal.Decimal, error)
+	RefundStakes(ctx context.Context, userIDs []int64, stake decimal.Decimal) (map[int64]decimal.Decimal, error)
+}
+
+// TournamentLedger 锦标赛筹码账本
+// 各方法与 ChipLedger 对应，按锦标赛区分筹码；此外控制是否可以开始新回合及参赛资格
+type TournamentLedger interface {
+	GetChips(ctx context.Context, tournamentID, userID int64) (decimal.Decimal, error)
+	DeductStakes(ctx context.Context, tournamentID int64, userIDs []int64, stake decimal.Decimal) (map[int64]decimal.Decimal, error)
+	SettleRound(ctx context.Context, tournamentID int64, participants, winners []int64, pool decimal.Decimal) (decimal.Decimal, map[int64]decimal.Decimal, error)
+	RefundStakes(ctx context.Context, tournamentID int64, userIDs []int64, stake decimal.Decimal) (map[int64]decimal.Decimal, error)
+	AcceptingRounds(ctx context.Context, tournamentID int64) bool
+	CheckEntrant(ctx context.Context, tournamentID, userID int64) error
+}
+
+// JackpotPool 累进奖池
+// ActiveJackpot 返回房间当前生效的奖池（未开启时返回 nil）；
+// SettleRoundTx 在结算事务内注入本回合抽取金额，触发时计算派发给赢家的金额（由结算流程记入余额与流水）
+type JackpotPool interface {
+	ActiveJackpot(ctx context.Context, room *model.Room) (*model.Jackpot, error)
+	SettleRoundTx(ctx context.Context, tx pgx.Tx, jackpotID, roomID, roundID int64, amount decimal.Decimal, winners []int64, triggered bool) (*model.JackpotSettlement, error)
+}
+
+// RestrictionChecker 账户限制检查
+// BettingRestrictions 返回被禁止下注（含全面封停）的玩家及推送给该玩家的原因代码
+type RestrictionChecker interface {
+	BettingRestrictions(ctx context.Context, userIDs []int64) (map[int64]string, error)
+}
+
 // RoomProcessor 房间游戏处理器
 type RoomProcessor struct {
 	mu sync.RWMutex
@@ -54,6 +95,11 @@ type RoomProcessor struct {
 	platformRepo *repository.PlatformRepo
 	balanceCache *cache.BalanceCache
 	riskChecker  RiskChecker
+	bonusTracker BonusTracker
+	practice     ChipLedger
+	tournament   TournamentLedger
+	jackpot      JackpotPool
+	restrictions RestrictionChecker
 	commitReveal *CommitReveal
 	logger       *zap.Logger
 
@@ -107,10 +153,36 @@ func NewRoomProcessor(
 	}
 }
 
+// SetBonusTracker 设置奖励余额流水跟踪器
+func (rp *RoomProcessor) SetBonusTracker(tracker BonusTracker) {
+	rp.bonusTracker = tracker
+}
+
+// SetPracticeLedger 设置练习房游戏币账本
+func (rp *RoomProcessor) SetPracticeLedger(ledger ChipLedger) {
+	rp.practice = ledger
+}
+
+// SetTournamentLedger 设置锦标赛筹码账本
+func (rp *RoomProcessor) SetTournamentLedger(ledger TournamentLedger) {
+	rp.tournament = ledger
+}
+
+// SetJackpotPool 设置累进奖池
+func (rp *RoomProcessor) SetJackpotPool(pool JackpotPool) {
+	rp.jackpot = pool
+}
+
+// SetRestrictionChecker 设置账户限制检查
+func (rp *RoomProcessor) SetRestrictionChecker(checker RestrictionChecker) {
+	rp.restrictions = checker
+}
+
 // tickState 用于增量比较的状态快照
 type tickState struct {
 	Phase          model.GamePhase
 	PoolAmount     string
+	JackpotAmount  string
 	PlayerCount    int
 	SpectatorCount int
 }
@@ -268,11 +340,15 @@ func (rp *RoomProcessor) sendPhaseTick() {
 		PlayerCount:    len(rp.State.Players),
 		SpectatorCount: len(rp.State.Spectators),
 	}
+	if rp.State.JackpotAmount != nil {
+		currentState.JackpotAmount = rp.State.JackpotAmount.String()
+	}
 
 	// 检查是否有状态变化
 	hasChanges := rp.lastTickState == nil ||
 		rp.lastTickState.Phase != currentState.Phase ||
 		rp.lastTickState.PoolAmount != currentState.PoolAmount ||
+		rp.lastTickState.JackpotAmount != currentState.JackpotAmount ||
 		rp.lastTickState.PlayerCount != currentState.PlayerCount ||
 		rp.lastTickState.SpectatorCount != currentState.SpectatorCount
 
@@ -296,6 +372,10 @@ func (rp *RoomProcessor) sendPhaseTick() {
 		PlayerCount:    &playerCount,
 		SpectatorCount: &spectatorCount,
 	}
+	if currentState.JackpotAmount != "" {
+		jackpotAmount := currentState.JackpotAmount
+		tick.JackpotAmount = &jackpotAmount
+	}
 
 	// 广播
 	rp.Broadcaster.BroadcastToRoom(rp.RoomID, &model.WSMessage{
@@ -367,9 +447,15 @@ func (rp *RoomProcessor) tryStartCountdown() {
 	}
 
 	if readyCount >= rp.getMinPlayers() {
+		// 锦标赛未开始、已结束或已达回合上限时不再开始新回合
+		if rp.Room.IsTournament() && !rp.acceptingRounds() {
+			return
+		}
 		rp.State.Phase = model.PhaseCountdown
 		rp.State.PhaseEndTime = time.Now().Add(PhaseDuration)
 		rp.State.CurrentRound++
+		// 房主级奖池由多个房间共享，每回合开始前刷新金额
+		rp.refreshJackpot(context.Background())
 		rp.broadcastPhaseChange()
 		rp.logger.Info("Phase changed", zap.String("phase", "countdown"), zap.Int("round", rp.State.CurrentRound))
 	}
@@ -394,6 +480,20 @@ func (rp *RoomProcessor) enterBetting() {
 	eligiblePlayers := []int64{}
 	skipped := []int64{}
 	disqualifiedPlayers := []model.WSPlayerDisqualified{}
+	restricted, err := rp.bettingRestrictions(ctx)
+	if err != nil {
+		// 无法确认账户限制时本轮不下注，返回等待阶段后重试
+		rp.Broadcaster.BroadcastToRoom(rp.RoomID, &model.WSMessage{
+			Type: model.WSTypeRoundCancelled,
+			Payload: &model.WSRoundCancelled{
+				Reason:              "restriction_check_failed",
+				DisqualifiedPlayers: disqualifiedPlayers,
+				MinPlayersRequired:  rp.getMinPlayers(),
+			},
+		})
+		rp.enterWaiting()
+		return
+	}
 
 	for userID, p := range rp.State.Players {
 		if !p.IsOnline {
@@ -404,39 +504,23 @@ func (rp *RoomProcessor) enterBetting() {
 			skipped = append(skipped, userID)
 			continue
 		}
-		if p.Balance.LessThan(betAmount) {
+		// 账户被限制下注
+		if reasonCode, ok := restricted[userID]; ok {
 			skipped = append(skipped, userID)
-			// 标记玩家被取消资格
-			p.Disqualified = true
-			p.DisqualifyReason = "insufficient_balance"
-			disqualifiedPlayers = append(disqualifiedPlayers, model.WSPlayerDisqualified{
-				UserID:   userID,
-				Username: p.Username,
-				Reason:   "insufficient_balance",
-			})
-			// 发送个人通知给被取消资格的玩家
-			rp.Broadcaster.SendToUser(userID, &model.WSMessage{
-				Type: model.WSTypePlayerDisqualified,
-				Payload: &model.WSPlayerDisqualified{
-					UserID:   userID,
-					Username: p.Username,
-					Reason:   "insufficient_balance",
-				},
-			})
-			// 广播玩家状态更新（让其他玩家看到）
-			disqualified := true
-			reason := "insufficient_balance"
-			rp.Broadcaster.BroadcastToRoom(rp.RoomID, &model.WSMessage{
-				Type: model.WSTypePlayerUpdate,
-				Payload: &model.WSPlayerUpdate{
-					UserID:           userID,
-					Disqualified:     &disqualified,
-					DisqualifyReason: &reason,
-				},
-			})
+			disqualifiedPlayers = append(disqualifiedPlayers, rp.disqualifyPlayer(userID, p, "account_restricted", reasonCode))
+			rp.logger.Info("Player disqualified due to account restriction",
+				zap.Int64("user_id", userID),
+				zap.String("reason_code", reasonCode))
+			continue
+		}
+		// 可下注金额 = 真实余额 + 奖励余额
+		if p.Balance.Add(p.BonusBalance).LessThan(betAmount) {
+			skipped = append(skipped, userID)
+			disqualifiedPlayers = append(disqualifiedPlayers, rp.disqualifyPlayer(userID, p, "insufficient_balance", ""))
 			rp.logger.Info("Player disqualified due to insufficient balance",
 				zap.Int64("user_id", userID),
 				zap.String("balance", p.Balance.String()),
+				zap.String("bonus_balance", p.BonusBalance.String()),
 				zap.String("bet_amount", betAmount.String()))
 			continue
 		}
@@ -462,19 +546,52 @@ func (rp *RoomProcessor) enterBetting() {
 		return
 	}
 
+	// 练习房、锦标赛房间：只扣除筹码
+	if rp.Room.UsesChips() {
+		rp.enterBettingChips(ctx, eligiblePlayers, skipped)
+		return
+	}
+
+	// 写回模式：下注在余额缓存中原子扣除，由 outbox 异步落库
+	if rp.canUseCacheStakes(ctx, eligiblePlayers) {
+		rp.enterBettingCached(ctx, eligiblePlayers, skipped, commitHash)
+		return
+	}
+	// 写回模式下扣款期间暂停这些玩家的缓存扣款；仍有未落库变动的玩家（Redis 不可用时）不能走数据库路径，本轮跳过
+	release, blocked := rp.balanceCache.PrepareDBWrite(ctx, eligiblePlayers)
+	defer release()
+	if len(blocked) > 0 {
+		rp.logger.Warn("Players with unflushed balance skipped", zap.Int64s("user_ids", blocked))
+		blockedSet := make(map[int64]bool, len(blocked))
+		for _, userID := range blocked {
+			blockedSet[userID] = true
+		}
+		kept := eligiblePlayers[:0]
+		for _, userID := range eligiblePlayers {
+			if blockedSet[userID] {
+				skipped = append(skipped, userID)
+				continue
+			}
+			kept = append(kept, userID)
+		}
+		eligiblePlayers = kept
+	}
+
 	// 使用事务执行批量扣款操作（优化：单条 SQL）
 	participants := []int64{}
 	poolAmount := decimal.Zero
 	playerNewBalances := make(map[int64]decimal.Decimal)
 	playerOldBalances := make(map[int64]decimal.Decimal)
+	playerNewBonus := make(map[int64]decimal.Decimal)
+	bonusStakes := make(map[int64]decimal.Decimal) // 下注中由奖励余额支付的部分
 	var roundID int64
 
 	// 获取回合号（在事务外获取，避免长事务）
 	lastNum, _ := rp.gameRepo.GetLastRoundNumber(ctx, rp.RoomID)
 
 	err = repository.Tx(ctx, func(tx pgx.Tx) error {
-		// 批量扣款（单条 SQL）
-		deductResults, err := rp.userRepo.BatchDeductBalanceTx(ctx, tx, eligiblePlayers, betAmount)
+		// 批量扣款（单条 SQL，先扣真实余额，不足部分扣奖励余额）
+		deductResults, err := rp.userRepo.BatchDeductStakeTx(ctx, tx, eligiblePlayers, betAmount)
 		if err != nil {
 			return fmt.Errorf("batch deduct balance: %w", err)
 		}
@@ -483,8 +600,12 @@ func (rp *RoomProcessor) enterBetting() {
 		for _, result := range deductResults {
 			participants = append(participants, result.UserID)
 			playerNewBalances[result.UserID] = result.NewBalance
-			// 从新余额反推旧余额（新余额 + 扣款金额 = 旧余额）
-			playerOldBalances[result.UserID] = result.NewBalance.Add(betAmount)
+			playerNewBonus[result.UserID] = result.NewBonusBalance
+			// 从新余额反推旧余额（新余额 + 真实余额扣款部分 = 旧余额）
+			playerOldBalances[result.UserID] = result.NewBalance.Add(betAmount.Sub(result.BonusPart))
+			if result.BonusPart.IsPositive() {
+				bonusStakes[result.UserID] = result.BonusPart
+			}
 			poolAmount = poolAmount.Add(betAmount)
 		}
 
@@ -510,19 +631,35 @@ func (rp *RoomProcessor) enterBetting() {
 		roundID = round.ID
 
 		// 批量创建交易记录（单条 SQL，包含 RoundID）
+		// 真实余额与奖励余额支付部分分别记账
 		txRecords := make([]*model.BalanceTransaction, 0, len(participants))
 		for _, userID := range participants {
 			oldBalance := playerOldBalances[userID]
 			newBalance := playerNewBalances[userID]
-			txRecords = append(txRecords, &model.BalanceTransaction{
-				UserID:        userID,
-				RoomID:        &rp.RoomID,
-				RoundID:       &roundID,
-				Type:          model.TxGameBet,
-				Amount:        betAmount.Neg(),
-				BalanceBefore: oldBalance,
-				BalanceAfter:  newBalance,
-			})
+			if realPart := oldBalance.Sub(newBalance); realPart.IsPositive() {
+				txRecords = append(txRecords, &model.BalanceTransaction{
+					UserID:        userID,
+					RoomID:        &rp.RoomID,
+					RoundID:       &roundID,
+					Type:          model.TxGameBet,
+					Amount:        realPart.Neg(),
+					BalanceBefore: oldBalance,
+					BalanceAfter:  newBalance,
+				})
+			}
+			if bonusPart, ok := bonusStakes[userID]; ok {
+				newBonus := playerNewBonus[userID]
+				txRecords = append(txRecords, &model.BalanceTransaction{
+					UserID:        userID,
+					RoomID:        &rp.RoomID,
+					RoundID:       &roundID,
+					Type:          model.TxBonusBet,
+					Amount:        bonusPart.Neg(),
+					BalanceBefore: newBonus.Add(bonusPart),
+					BalanceAfter:  newBonus,
+					BalanceField:  "bonus_balance",
+				})
+			}
 		}
 		if err := rp.txRepo.BatchCreateTx(ctx, tx, txRecords); err != nil {
 			return fmt.Errorf("batch create bet transactions: %w", err)
@@ -549,6 +686,7 @@ func (rp *RoomProcessor) enterBetting() {
 	for userID, newBalance := range playerNewBalances {
 		if p := rp.State.Players[userID]; p != nil {
 			p.Balance = newBalance
+			p.BonusBalance = playerNewBonus[userID]
 		}
 		// 使缓存失效
 		if rp.balanceCache != nil {
@@ -565,10 +703,17 @@ func (rp *RoomProcessor) enterBetting() {
 		}
 	}
 
+	rp.startBetting(participants, skipped, poolAmount, roundID, bonusStakes, false)
+}
+
+// startBetting 扣款完成后记录回合状态并广播下注结果
+func (rp *RoomProcessor) startBetting(participants, skipped []int64, poolAmount decimal.Decimal, roundID int64, bonusStakes map[int64]decimal.Decimal, cacheStakes bool) {
 	rp.State.Participants = participants
 	rp.State.SkippedPlayers = skipped
 	rp.State.PoolAmount = poolAmount
 	rp.State.RoundID = roundID
+	rp.State.BonusStakes = bonusStakes
+	rp.State.CacheStakes = cacheStakes
 
 	rp.State.Phase = model.PhaseBetting
 	rp.State.PhaseEndTime = time.Now().Add(PhaseDuration)
@@ -588,6 +733,138 @@ func (rp *RoomProcessor) enterBetting() {
 		zap.Int("participants", len(participants)), zap.String("pool", poolAmount.String()))
 }
 
+// canUseCacheStakes 是否使用余额写回缓存扣除下注
+// 需开启写回模式且 Redis 可用；持有奖励余额的玩家需按真实余额优先规则扣款，仍走数据库路径
+func (rp *RoomProcessor) canUseCacheStakes(ctx context.Context, players []int64) bool {
+	if !rp.balanceCache.WriteBehindEnabled() || !rp.balanceCache.IsAvailable(ctx) {
+		return false
+	}
+	for _, userID := range players {
+		if p := rp.State.Players[userID]; p != nil && p.BonusBalance.IsPositive() {
+			return false
+		}
+	}
+	return true
+}
+
+// enterBettingCached 写回模式下注：先创建回合，再在余额缓存中原子批量扣款
+func (rp *RoomProcessor) enterBettingCached(ctx context.Context, eligiblePlayers, skipped []int64, commitHash string) {
+	betAmount := rp.Room.BetAmount
+	lastNum, _ := rp.gameRepo.GetLastRoundNumber(ctx, rp.RoomID)
+
+	round := &model.GameRound{
+		RoomID:         rp.RoomID,
+		RoundNumber:    lastNum + 1,
+		ParticipantIDs: eligiblePlayers,
+		SkippedIDs:     skipped,
+		BetAmount:      betAmount,
+		PoolAmount:     betAmount.Mul(decimal.NewFromInt(int64(len(eligiblePlayers)))),
+		CommitHash:     &commitHash,
+		Status:         model.RoundStatusBetting,
+	}
+	if err := rp.gameRepo.CreateRound(ctx, round); err != nil {
+		rp.logger.Error("Create round failed", zap.Error(err))
+		rp.failCachedBetting(ctx, 0)
+		return
+	}
+
+	applied, err := rp.balanceCache.ApplyStakes(ctx, eligiblePlayers, betAmount, cache.OutboxMeta{
+		Op:      fmt.Sprintf("round:%d:bet", round.ID),
+		RoomID:  &rp.RoomID,
+		RoundID: &round.ID,
+		Type:    model.TxGameBet,
+	})
+	if err != nil {
+		rp.logger.Error("Cached betting failed", zap.Int64("round_id", round.ID), zap.Error(err))
+		// 扣款结果未知（如 Redis 应答丢失），按操作ID补偿：仅当扣款已执行时退款
+		rp.refundCachedStakes(ctx, round.ID, eligiblePlayers)
+		rp.failCachedBetting(ctx, round.ID)
+		return
+	}
+
+	participants := make([]int64, 0, len(applied))
+	for _, userID := range eligiblePlayers {
+		result, ok := applied[userID]
+		if !ok {
+			skipped = append(skipped, userID)
+			continue
+		}
+		participants = append(participants, userID)
+		if p := rp.State.Players[userID]; p != nil {
+			p.Balance = result.After
+		}
+	}
+
+	// 检查参与人数（必须大于 winner_count）
+	if len(participants) < rp.getMinPlayers() {
+		rp.logger.Warn("Not enough participants after cached deduction",
+			zap.Int("need", rp.getMinPlayers()), zap.Int("got", len(participants)))
+		rp.refundCachedStakes(ctx, round.ID, participants)
+		rp.failCachedBetting(ctx, round.ID)
+		return
+	}
+
+	poolAmount := betAmount.Mul(decimal.NewFromInt(int64(len(participants))))
+	if len(participants) != len(eligiblePlayers) {
+		round.ParticipantIDs = participants
+		round.SkippedIDs = skipped
+		round.PoolAmount = poolAmount
+		if err := rp.gameRepo.UpdateRoundParticipants(ctx, round); err != nil {
+			rp.logger.Error("Failed to update round participants", zap.Int64("round_id", round.ID), zap.Error(err))
+		}
+	}
+
+	rp.startBetting(participants, skipped, poolAmount, round.ID, nil, true)
+}
+
+// refundCachedStakes 写回模式下退回本回合已扣除的下注（依赖扣款操作，扣款未执行时跳过）
+func (rp *RoomProcessor) refundCachedStakes(ctx context.Context, roundID int64, userIDs []int64) {
+	if len(userIDs) == 0 {
+		return
+	}
+	amounts := make(map[int64]decimal.Decimal, len(userIDs))
+	for _, userID := range userIDs {
+		amounts[userID] = rp.Room.BetAmount
+	}
+	applied, err := rp.balanceCache.ApplyCredits(ctx, amounts, cache.OutboxMeta{
+		Op:       fmt.Sprintf("round:%d:bet_refund", roundID),
+		Requires: fmt.Sprintf("round:%d:bet", roundID),
+		RoomID:   &rp.RoomID,
+		RoundID:  &roundID,
+		Type:     model.TxGameRefund,
+	})
+	if err != nil {
+		if errors.Is(err, cache.ErrOpSkipped) || errors.Is(err, cache.ErrDuplicateOp) {
+			return
+		}
+		rp.logger.Error("Failed to refund cached stakes, manual check required",
+			zap.Int64("round_id", roundID), zap.Int64s("user_ids", userIDs), zap.Error(err))
+		return
+	}
+	for userID, result := range applied {
+		if p := rp.State.Players[userID]; p != nil {
+			p.Balance = result.After
+		}
+	}
+}
+
+// failCachedBetting 写回模式下注失败：标记回合失败并返回等待
+func (rp *RoomProcessor) failCachedBetting(ctx context.Context, roundID int64) {
+	if roundID > 0 {
+		if err := rp.gameRepo.FailRound(ctx, roundID, "betting_failed"); err != nil {
+			rp.logger.Error("Failed to mark round as failed", zap.Int64("round_id", roundID), zap.Error(err))
+		}
+	}
+	rp.Broadcaster.BroadcastToRoom(rp.RoomID, &model.WSMessage{
+		Type: model.WSTypeRoundFailed,
+		Payload: &model.WSRoundFailed{
+			Reason:   "betting_failed",
+			Refunded: []int64{},
+		},
+	})
+	rp.enterWaiting()
+}
+
 // enterInGame 进入游戏中阶段
 func (rp *RoomProcessor) enterInGame() {
 	ctx := context.Background()
@@ -613,6 +890,12 @@ func (rp *RoomProcessor) enterSettlement() {
 	winners := rp.commitReveal.SelectWinners(rp.State.Participants, rp.Room.WinnerCount, rp.State.Seed)
 	revealSeed := rp.commitReveal.Reveal(rp.State.Seed)
 
+	// 练习房、锦标赛房间：奖池全部以筹码派发，无抽成、不记录回合、不做风控检查
+	if rp.Room.UsesChips() {
+		rp.settleChips(ctx, winners, revealSeed)
+		return
+	}
+
 	// 计算抽成
 	// 注意：费率已经是小数形式，如 0.03 表示 3%
 	poolAmount := rp.State.PoolAmount
@@ -623,13 +906,23 @@ func (rp *RoomProcessor) enterSettlement() {
 	platformEarning := poolAmount.Mul(platformRate).Round(2)
 	prizePool := poolAmount.Sub(ownerEarning).Sub(platformEarning)
 
+	// 累进奖池：从奖池按比例抽取，连同正的舍入残值一起注入；触发条件由公开种子确定性推导
+	jackpot := rp.activeJackpot(ctx)
+	var jackpotContribution decimal.Decimal
+	jackpotTriggered := false
+	if jackpot != nil {
+		jackpotContribution = jackpot.Contribution(poolAmount, prizePool)
+		prizePool = prizePool.Sub(jackpotContribution)
+		jackpotTriggered = rp.commitReveal.JackpotTriggered(revealSeed, jackpot.TriggerOdds)
+	}
+
 	var prizePerWinner decimal.Decimal
 	var residual decimal.Decimal
 
 	if len(winners) > 0 {
-		prizePerWinner = prizePool.Div(decimal.NewFromInt(int64(len(winners)))).Round(2)
-		residual = prizePool.Sub(prizePerWinner.Mul(decimal.NewFromInt(int64(len(winners)))))
+		prizePerWinner, residual = splitPrizePool(prizePool, len(winners))
 	}
+	jackpotResidual, platformResidual := splitResidual(residual, jackpot != nil)
 
 	// 使用事务执行批量结算操作（优化：减少 SQL 次数）
 	winnerNames := []string{}
@@ -638,10 +931,16 @@ func (rp *RoomProcessor) enterSettlement() {
 
 	// 收集赢家信息
 	// 注意：必须确保所有赢家都能收到奖金，否则应该退款
+	// 使用奖励余额下注的赢家，奖金按下注中奖励余额的占比计入奖励余额，其余计入真实余额
 	winnerAmounts := make(map[int64]decimal.Decimal)
+	bonusWinAmounts := make(map[int64]decimal.Decimal)
 	for _, winnerID := range winners {
+		realShare, bonusShare := splitPrizeByStake(prizePerWinner, rp.Room.BetAmount, rp.State.BonusStakes[winnerID])
+		if bonusShare.IsPositive() {
+			bonusWinAmounts[winnerID] = bonusShare
+		}
 		if p := rp.State.Players[winnerID]; p != nil {
-			winnerAmounts[winnerID] = prizePerWinner
+			winnerAmounts[winnerID] = realShare
 			winnerNames = append(winnerNames, p.Username)
 		} else {
 			// 赢家不在内存中，尝试从数据库获取用户信息
@@ -649,7 +948,7 @@ func (rp *RoomProcessor) enterSettlement() {
 				zap.Int64("winner_id", winnerID),
 				zap.Int64("round_id", rp.State.RoundID))
 			if user, err := rp.userRepo.GetByID(ctx, winnerID); err == nil && user != nil {
-				winnerAmounts[winnerID] = prizePerWinner
+				winnerAmounts[winnerID] = realShare
 				winnerNames = append(winnerNames, user.Username)
 			} else {
 				rp.logger.Error("Failed to get winner from DB, settlement will fail",
@@ -669,17 +968,32 @@ func (rp *RoomProcessor) enterSettlement() {
 		return
 	}
 
+	winnerBonusBalances := make(map[int64]decimal.Decimal)
+	var bonusConversions []model.BonusConversion
+	var jackpotResult *model.JackpotSettlement
+
+	// 写回模式下赢家奖金在事务内登记为待写回加款，事务提交后写入余额缓存
+	dbWinnerAmounts := winnerAmounts
+	if rp.State.CacheStakes {
+		dbWinnerAmounts = nil
+	}
+
 	err := repository.Tx(ctx, func(tx pgx.Tx) error {
 		// 1. 批量发放奖金给赢家（单条 SQL）
-		if len(winnerAmounts) > 0 {
-			addResults, err := rp.userRepo.BatchAddBalanceTx(ctx, tx, winnerAmounts)
+		if rp.State.CacheStakes {
+			if err := rp.balanceCache.RecordCreditsTx(ctx, tx, winnerAmounts, rp.cachedCreditMeta("win", model.TxGameWin)); err != nil {
+				return fmt.Errorf("record winner credits: %w", err)
+			}
+		}
+		if len(dbWinnerAmounts) > 0 {
+			addResults, err := rp.userRepo.BatchAddBalanceTx(ctx, tx, dbWinnerAmounts)
 			if err != nil {
 				return fmt.Errorf("batch add winner balance: %w", err)
 			}
 			for _, result := range addResults {
 				winnerBalances[result.UserID] = result.NewBalance
 				// 从新余额反推旧余额（新余额 - 奖金 = 旧余额）
-				winnerOldBalances[result.UserID] = result.NewBalance.Sub(prizePerWinner)
+				winnerOldBalances[result.UserID] = result.NewBalance.Sub(dbWinnerAmounts[result.UserID])
 			}
 		}
 
@@ -687,6 +1001,9 @@ func (rp *RoomProcessor) enterSettlement() {
 		if len(winners) > 0 {
 			txRecords := make([]*model.BalanceTransaction, 0, len(winners))
 			for _, winnerID := range winners {
+				if _, ok := dbWinnerAmounts[winnerID]; !ok {
+					continue
+				}
 				newBalance, ok := winnerBalances[winnerID]
 				if !ok {
 					// 赢家不在结果中，跳过
@@ -694,18 +1011,45 @@ func (rp *RoomProcessor) enterSettlement() {
 					continue
 				}
 				oldBalance := winnerOldBalances[winnerID]
-				txRecords = append(txRecords, &model.BalanceTransaction{
-					UserID:        winnerID,
-					RoomID:        &rp.RoomID,
-					RoundID:       &rp.State.RoundID,
-					Type:          model.TxGameWin,
-					Amount:        prizePerWinner,
-					BalanceBefore: oldBalance,
-					BalanceAfter:  newBalance,
-				})
+				if amount := winnerAmounts[winnerID]; amount.IsPositive() {
+					txRecords = append(txRecords, &model.BalanceTransaction{
+						UserID:        winnerID,
+						RoomID:        &rp.RoomID,
+						RoundID:       &rp.State.RoundID,
+						Type:          model.TxGameWin,
+						Amount:        amount,
+						BalanceBefore: oldBalance,
+						BalanceAfter:  newBalance,
+					})
+				}
 			}
-			if err := rp.txRepo.BatchCreateTx(ctx, tx, txRecords); err != nil {
-				return fmt.Errorf("batch create win transactions: %w", err)
+
+			// 奖金中奖励余额部分计入奖励余额
+			if len(bonusWinAmounts) > 0 {
+				bonusResults, err := rp.userRepo.BatchAddBonusBalanceTx(ctx, tx, bonusWinAmounts)
+				if err != nil {
+					return fmt.Errorf("batch add winner bonus balance: %w", err)
+				}
+				for winnerID, newBonus := range bonusResults {
+					winnerBonusBalances[winnerID] = newBonus
+					bonusShare := bonusWinAmounts[winnerID]
+					txRecords = append(txRecords, &model.BalanceTransaction{
+						UserID:        winnerID,
+						RoomID:        &rp.RoomID,
+						RoundID:       &rp.State.RoundID,
+						Type:          model.TxBonusWin,
+						Amount:        bonusShare,
+						BalanceBefore: newBonus.Sub(bonusShare),
+						BalanceAfter:  newBonus,
+						BalanceField:  "bonus_balance",
+					})
+				}
+			}
+
+			if len(txRecords) > 0 {
+				if err := rp.txRepo.BatchCreateTx(ctx, tx, txRecords); err != nil {
+					return fmt.Errorf("batch create win transactions: %w", err)
+				}
 			}
 		}
 
@@ -714,13 +1058,13 @@ func (rp *RoomProcessor) enterSettlement() {
 			return fmt.Errorf("add owner earning: %w", err)
 		}
 
-		// 4. 平台抽成（合并残值）
-		totalPlatformEarning := platformEarning.Add(residual)
-		if err := rp.platformRepo.UpdateBalanceTx(ctx, tx, totalPlatformEarning); err != nil {
+		// 4. 平台抽成（合并未注入累进奖池的残值）
+		totalPlatformEarning := platformEarning.Add(platformResidual)
+		if err := rp.platformRepo.UpdateBalanceTx(ctx, tx, rp.Room.Currency, totalPlatformEarning); err != nil {
 			return fmt.Errorf("add platform earning: %w", err)
 		}
 
-		// 5. 更新回合记录
+		// 5. 累进奖池注入，触发时派发给赢家（写回模式下在事务提交后写入余额缓存）
 		round := &model.GameRound{
 			ID:              rp.State.RoundID,
 			WinnerIDs:       winners,
@@ -731,10 +1075,48 @@ func (rp *RoomProcessor) enterSettlement() {
 			RevealSeed:      &revealSeed,
 			Status:          model.RoundStatusSettled,
 		}
+		if jackpot != nil {
+			result, err := rp.jackpot.SettleRoundTx(ctx, tx, jackpot.ID, rp.RoomID, rp.State.RoundID,
+				jackpotContribution.Add(jackpotResidual), winners, jackpotTriggered)
+			if err != nil {
+				return fmt.Errorf("settle jackpot: %w", err)
+			}
+			if len(result.Payouts) > 0 && !rp.State.CacheStakes {
+				if err := rp.creditJackpotTx(ctx, tx, result.Payouts, winnerBalances); err != nil {
+					return fmt.Errorf("credit jackpot: %w", err)
+				}
+			}
+			if len(result.Payouts) > 0 && rp.State.CacheStakes {
+				if err := rp.balanceCache.RecordCreditsTx(ctx, tx, result.Payouts, rp.cachedCreditMeta("jackpot", model.TxJackpotWin)); err != nil {
+					return fmt.Errorf("record jackpot credits: %w", err)
+				}
+			}
+			jackpotResult = result
+			odds := jackpot.TriggerOdds
+			round.JackpotID = &jackpot.ID
+			round.JackpotContribution = result.Contribution
+			round.JackpotOdds = &odds
+			round.JackpotHit = result.Hit
+			round.JackpotPayout = result.Paid
+		}
+
+		// 6. 更新回合记录
 		if err := rp.gameRepo.SettleRoundTx(ctx, tx, round); err != nil {
 			return fmt.Errorf("settle round: %w", err)
 		}
 
+		// 7. 累计奖励流水（只统计已结算回合，退款回合不计入）
+		if rp.bonusTracker != nil {
+			wagers := rp.bonusWagers()
+			if len(wagers) > 0 {
+				conversions, err := rp.bonusTracker.RecordWagersTx(ctx, tx, wagers)
+				if err != nil {
+					return fmt.Errorf("record bonus wagers: %w", err)
+				}
+				bonusConversions = conversions
+			}
+		}
+
 		return nil
 	})
 
@@ -745,34 +1127,62 @@ func (rp *RoomProcessor) enterSettlement() {
 		return
 	}
 
+	if rp.State.CacheStakes {
+		rp.creditCachedWinnings(ctx, winnerAmounts, winnerBalances)
+		if jackpotResult != nil && len(jackpotResult.Payouts) > 0 {
+			rp.creditCached(ctx, "jackpot", model.TxJackpotWin, jackpotResult.Payouts, winnerBalances)
+		}
+	}
+	if jackpotResult != nil {
+		balance := jackpotResult.Balance
+		rp.State.JackpotAmount = &balance
+	} else {
+		rp.State.JackpotAmount = nil
+	}
+
 	// 事务成功后更新内存状态和缓存
 	for winnerID, newBalance := range winnerBalances {
 		if p := rp.State.Players[winnerID]; p != nil {
 			p.Balance = newBalance
 		}
-		// 使缓存失效，下次读取时从数据库加载
-		if rp.balanceCache != nil {
+		// 使缓存失效，下次读取时从数据库加载（写回模式下缓存已是最新余额）
+		if rp.balanceCache != nil && !rp.State.CacheStakes {
 			if err := rp.balanceCache.Invalidate(ctx, winnerID); err != nil {
 				rp.logger.Warn("Failed to invalidate winner balance cache", zap.Int64("user_id", winnerID), zap.Error(err))
 			}
 		}
 	}
+	for winnerID, newBonus := range winnerBonusBalances {
+		if p := rp.State.Players[winnerID]; p != nil {
+			p.BonusBalance = newBonus
+		}
+	}
+	rp.applyBonusConversions(ctx, bonusConversions)
 
 	rp.State.Phase = model.PhaseSettlement
 	rp.State.PhaseEndTime = time.Now().Add(PhaseDuration)
 	rp.broadcastPhaseChange()
 
 	// 广播结果
+	roundResult := &model.WSRoundResult{
+		RoundID:        rp.State.RoundID,
+		Winners:        winners,
+		WinnerNames:    winnerNames,
+		PrizePerWinner: prizePerWinner.String(),
+		RevealSeed:     revealSeed,
+		CommitHash:     rp.State.CommitHash,
+	}
+	if jackpotResult != nil && jackpotResult.Hit {
+		roundResult.JackpotHit = true
+		roundResult.JackpotPrizePerWinner = jackpotResult.PrizePerWinner.String()
+		rp.logger.Info("Jackpot hit",
+			zap.Int64("jackpot_id", jackpotResult.JackpotID),
+			zap.Int64("round_id", rp.State.RoundID),
+			zap.String("paid", jackpotResult.Paid.String()))
+	}
 	rp.Broadcaster.BroadcastToRoom(rp.RoomID, &model.WSMessage{
-		Type: model.WSTypeRoundResult,
-		Payload: &model.WSRoundResult{
-			RoundID:        rp.State.RoundID,
-			Winners:        winners,
-			WinnerNames:    winnerNames,
-			PrizePerWinner: prizePerWinner.String(),
-			RevealSeed:     revealSeed,
-			CommitHash:     rp.State.CommitHash,
-		},
+		Type:    model.WSTypeRoundResult,
+		Payload: roundResult,
 	})
 
 	rp.logger.Info("Phase changed", zap.String("phase", "settlement"),
@@ -784,20 +1194,205 @@ func (rp *RoomProcessor) enterSettlement() {
 	}
 }
 
+// creditCachedWinnings 写回模式下将赢家奖金写入余额缓存，Redis 写入失败时直接落库
+// 两条路径使用同一操作ID，保证奖金只发放一次
+func (rp *RoomProcessor) creditCachedWinnings(ctx context.Context, amounts map[int64]decimal.Decimal, balances map[int64]decimal.Decimal) {
+	rp.creditCached(ctx, "win", model.TxGameWin, amounts, balances)
+}
+
+// cachedCreditMeta 写回模式加款的操作信息（操作ID为 round:<回合ID>:<kind>）
+func (rp *RoomProcessor) cachedCreditMeta(kind string, txType model.TransactionType) cache.OutboxMeta {
+	return cache.OutboxMeta{
+		Op:      fmt.Sprintf("round:%d:%s", rp.State.RoundID, kind),
+		RoomID:  &rp.RoomID,
+		RoundID: &rp.State.RoundID,
+		Type:    txType,
+	}
+}
+
+// creditCached 写回模式下执行结算事务内登记的加款
+// 失败时登记保留在数据库中，由 flusher 按同一操作ID重放
+func (rp *RoomProcessor) creditCached(ctx context.Context, kind string, txType model.TransactionType, amounts map[int64]decimal.Decimal, balances map[int64]decimal.Decimal) {
+	applied, err := rp.balanceCache.ApplyRecordedCredits(ctx, amounts, rp.cachedCreditMeta(kind, txType))
+	if err != nil {
+		if !errors.Is(err, cache.ErrDuplicateOp) {
+			rp.logger.Error("Failed to credit winners, pending credit will be replayed",
+				zap.Int64("round_id", rp.State.RoundID), zap.String("kind", kind), zap.Error(err))
+		}
+		return
+	}
+	for winnerID, result := range applied {
+		balances[winnerID] = result.After
+	}
+}
+
+// creditJackpotTx 在结算事务内将累进奖池派发金额计入赢家真实余额并记录流水
+func (rp *RoomProcessor) creditJackpotTx(ctx context.Context, tx pgx.Tx, payouts map[int64]decimal.Decimal, balances map[int64]decimal.Decimal) error {
+	results, err := rp.userRepo.BatchAddBalanceTx(ctx, tx, payouts)
+	if err != nil {
+		return err
+	}
+	records := make([]*model.BalanceTransaction, 0, len(results))
+	for _, result := range results {
+		amount := payouts[result.UserID]
+		balances[result.UserID] = result.NewBalance
+		records = append(records, &model.BalanceTransaction{
+			UserID:        result.UserID,
+			RoomID:        &rp.RoomID,
+			RoundID:       &rp.State.RoundID,
+			Type:          model.TxJackpotWin,
+			Amount:        amount,
+			BalanceBefore: result.NewBalance.Sub(amount),
+			BalanceAfter:  result.NewBalance,
+		})
+	}
+	return rp.txRepo.BatchCreateTx(ctx, tx, records)
+}
+
+// activeJackpot 获取房间当前生效的累进奖池，查询失败时本回合不启用奖池（残值照常计入平台账户）
+func (rp *RoomProcessor) activeJackpot(ctx context.Context) *model.Jackpot {
+	if rp.jackpot == nil || rp.Room.UsesChips() {
+		return nil
+	}
+	jackpot, err := rp.jackpot.ActiveJackpot(ctx, rp.Room)
+	if err != nil {
+		rp.logger.Warn("Failed to load jackpot", zap.Error(err))
+		return nil
+	}
+	return jackpot
+}
+
+// refreshJackpot 刷新内存中的累进奖池金额（用于房间状态与 phase_tick）
+func (rp *RoomProcessor) refreshJackpot(ctx context.Context) {
+	if jackpot := rp.activeJackpot(ctx); jackpot != nil {
+		balance := jackpot.Balance
+		rp.State.JackpotAmount = &balance
+	} else {
+		rp.State.JackpotAmount = nil
+	}
+}
+
+// splitPrizePool 平分奖池，返回（每位赢家奖金, 舍入残值），奖金四舍五入到分
+func splitPrizePool(prizePool decimal.Decimal, winnerCount int) (decimal.Decimal, decimal.Decimal) {
+	n := decimal.NewFromInt(int64(winnerCount))
+	perWinner := prizePool.Div(n).Round(2)
+	return perWinner, prizePool.Sub(perWinner.Mul(n))
+}
+
+// splitResidual 拆分舍入残值，返回（注入累进奖池部分, 计入平台部分）
+// 奖金四舍五入可能多派几分使残值为负，负残值始终由平台承担，避免奖池余额被扣为负
+func splitResidual(residual decimal.Decimal, jackpotActive bool) (decimal.Decimal, decimal.Decimal) {
+	if jackpotActive && residual.IsPositive() {
+		return residual, decimal.Zero
+	}
+	return decimal.Zero, residual
+}
+
+// splitPrizeByStake 按下注中奖励余额的占比拆分奖金，返回（真实余额部分, 奖励余额部分）
+func splitPrizeByStake(prize, betAmount, bonusStake decimal.Decimal) (decimal.Decimal, decimal.Decimal) {
+	if !bonusStake.IsPositive() || !betAmount.IsPositive() {
+		return prize, decimal.Zero
+	}
+	if bonusStake.GreaterThanOrEqual(betAmount) {
+		return decimal.Zero, prize
+	}
+	bonusShare := prize.Mul(bonusStake).Div(betAmount).Round(2)
+	return prize.Sub(bonusShare), bonusShare
+}
+
+// bonusWagers 本回合需累计奖励流水的玩家及下注金额
+// 只统计持有奖励余额或本回合使用了奖励余额下注的玩家
+func (rp *RoomProcessor) bonusWagers() map[int64]decimal.Decimal {
+	wagers := make(map[int64]decimal.Decimal)
+	for _, userID := range rp.State.Participants {
+		_, usedBonus := rp.State.BonusStakes[userID]
+		p := rp.State.Players[userID]
+		if usedBonus || (p != nil && p.BonusBalance.IsPositive()) {
+			wagers[userID] = rp.Room.BetAmount
+		}
+	}
+	return wagers
+}
+
+// applyBonusConversions 流水达标、奖励余额转为真实余额后更新内存状态并通知玩家
+func (rp *RoomProcessor) applyBonusConversions(ctx context.Context, conversions []model.BonusConversion) {
+	for _, c := range conversions {
+		if p := rp.State.Players[c.UserID]; p != nil {
+			p.Balance = c.NewBalance
+			p.BonusBalance = c.NewBonusBalance
+		}
+		if rp.balanceCache != nil {
+			if err := rp.balanceCache.Invalidate(ctx, c.UserID); err != nil {
+				rp.logger.Warn("Failed to invalidate balance cache after bonus conversion", zap.Int64("user_id", c.UserID), zap.Error(err))
+			}
+		}
+		rp.Broadcaster.SendToUser(c.UserID, &model.WSMessage{
+			Type: model.WSTypeBalanceUpdate,
+			Payload: &model.WSBalanceUpdate{
+				Balance:       c.NewBalance.String(),
+				FrozenBalance: "0",
+				BonusBalance:  c.NewBonusBalance.String(),
+			},
+		})
+		rp.logger.Info("Bonus balance converted to real balance",
+			zap.Int64("user_id", c.UserID),
+			zap.String("amount", c.Amount.String()))
+	}
+}
+
+// refundBonusStakesTx 将下注中奖励余额支付的部分退回奖励余额（支持事务），返回新的奖励余额
+func (rp *RoomProcessor) refundBonusStakesTx(ctx context.Context, tx pgx.Tx, bonusStakes map[int64]decimal.Decimal, roundID *int64, remark *string) (map[int64]decimal.Decimal, error) {
+	if len(bonusStakes) == 0 {
+		return nil, nil
+	}
+	newBonus, err := rp.userRepo.BatchAddBonusBalanceTx(ctx, tx, bonusStakes)
+	if err != nil {
+		return nil, fmt.Errorf("batch refund bonus: %w", err)
+	}
+
+	txRecords := make([]*model.BalanceTransaction, 0, len(newBonus))
+	for userID, bonus := range newBonus {
+		stake := bonusStakes[userID]
+		txRecords = append(txRecords, &model.BalanceTransaction{
+			UserID:        userID,
+			RoomID:        &rp.RoomID,
+			RoundID:       roundID,
+			Type:          model.TxBonusRefund,
+			Amount:        stake,
+			BalanceBefore: bonus.Sub(stake),
+			BalanceAfter:  bonus,
+			BalanceField:  "bonus_balance",
+			Remark:        remark,
+		})
+	}
+	if err := rp.txRepo.BatchCreateTx(ctx, tx, txRecords); err != nil {
+		return nil, fmt.Errorf("batch create bonus refund transactions: %w", err)
+	}
+	return newBonus, nil
+}
+
 // handleSettlementFailure 处理结算失败，退款给所有参与者（使用批量操作优化）
 func (rp *RoomProcessor) handleSettlementFailure(ctx context.Context, reason string) {
+	if rp.Room.UsesChips() {
+		rp.refundChipsAndWait(ctx, rp.State.Participants, reason)
+		return
+	}
+
 	betAmount := rp.Room.BetAmount
 	playerNewBalances := make(map[int64]decimal.Decimal)
 	playerOldBalances := make(map[int64]decimal.Decimal)
 
-	// 收集退款信息
+	// 收集退款信息（奖励余额支付的部分退回奖励余额）
 	refundAmounts := make(map[int64]decimal.Decimal)
 	for _, userID := range rp.State.Participants {
 		if p := rp.State.Players[userID]; p != nil {
-			refundAmounts[userID] = betAmount
+			if realPart := betAmount.Sub(rp.State.BonusStakes[userID]); realPart.IsPositive() {
+				refundAmounts[userID] = realPart
+			}
 			playerOldBalances[userID] = p.Balance
 		}
 	}
+	var playerNewBonus map[int64]decimal.Decimal
 
 	// 退款给所有参与者
 	err := repository.Tx(ctx, func(tx pgx.Tx) error {
@@ -814,17 +1409,21 @@ func (rp *RoomProcessor) handleSettlementFailure(ctx context.Context, reason str
 			// 批量创建退款交易记录（单条 SQL）
 			txRecords := make([]*model.BalanceTransaction, 0, len(rp.State.Participants))
 			for _, userID := range rp.State.Participants {
+				amount, ok := refundAmounts[userID]
+				if !ok {
+					continue
+				}
 				oldBalance := playerOldBalances[userID]
 				newBalance := playerNewBalances[userID]
 				if newBalance.IsZero() {
-					newBalance = oldBalance.Add(betAmount)
+					newBalance = oldBalance.Add(amount)
 					playerNewBalances[userID] = newBalance
 				}
 				txRecords = append(txRecords, &model.BalanceTransaction{
 					UserID:        userID,
 					RoomID:        &rp.RoomID,
 					Type:          model.TxGameRefund,
-					Amount:        betAmount,
+					Amount:        amount,
 					BalanceBefore: oldBalance,
 					BalanceAfter:  newBalance,
 				})
@@ -834,6 +1433,13 @@ func (rp *RoomProcessor) handleSettlementFailure(ctx context.Context, reason str
 			}
 		}
 
+		// 奖励余额部分退回奖励余额
+		newBonus, err := rp.refundBonusStakesTx(ctx, tx, rp.State.BonusStakes, &rp.State.RoundID, nil)
+		if err != nil {
+			return err
+		}
+		playerNewBonus = newBonus
+
 		// 标记回合失败（使用事务版本）
 		if err := rp.gameRepo.FailRoundTx(ctx, tx, rp.State.RoundID, reason); err != nil {
 			return fmt.Errorf("fail round: %w", err)
@@ -851,6 +1457,11 @@ func (rp *RoomProcessor) handleSettlementFailure(ctx context.Context, reason str
 				p.Balance = newBalance
 			}
 		}
+		for userID, newBonus := range playerNewBonus {
+			if p := rp.State.Players[userID]; p != nil {
+				p.BonusBalance = newBonus
+			}
+		}
 	}
 
 	// 广播失败
@@ -883,12 +1494,68 @@ func (rp *RoomProcessor) enterWaiting() {
 	rp.State.CommitHash = ""
 	rp.State.Seed = nil
 	rp.State.RoundID = 0
+	rp.State.BonusStakes = nil
+	rp.State.CacheStakes = false
 	// 重置被取消资格玩家的状态
 	rp.resetDisqualifiedPlayers()
 	rp.broadcastPhaseChange()
 	rp.logger.Info("Phase changed", zap.String("phase", "waiting"))
 }
 
+// bettingRestrictions 查询在线且已准备的玩家中被限制下注的玩家
+// 查询失败时返回错误，调用方取消本轮（不在无法确认限制时放行下注）
+func (rp *RoomProcessor) bettingRestrictions(ctx context.Context) (map[int64]string, error) {
+	if rp.restrictions == nil {
+		return nil, nil
+	}
+	candidates := make([]int64, 0, len(rp.State.Players))
+	for userID, p := range rp.State.Players {
+		if p.IsOnline && p.AutoReady {
+			candidates = append(candidates, userID)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+	restricted, err := rp.restrictions.BettingRestrictions(ctx, candidates)
+	if err != nil {
+		rp.logger.Error("Check betting restrictions failed, skipping round", zap.Error(err))
+		return nil, err
+	}
+	return restricted, nil
+}
+
+// disqualifyPlayer 取消玩家本轮资格：私发通知（含账户限制原因代码）并广播玩家状态，返回回合取消消息中的条目
+func (rp *RoomProcessor) disqualifyPlayer(userID int64, p *model.PlayerState, reason, reasonCode string) model.WSPlayerDisqualified {
+	p.Disqualified = true
+	p.DisqualifyReason = reason
+	// 发送个人通知给被取消资格的玩家
+	rp.Broadcaster.SendToUser(userID, &model.WSMessage{
+		Type: model.WSTypePlayerDisqualified,
+		Payload: &model.WSPlayerDisqualified{
+			UserID:     userID,
+			Username:   p.Username,
+			Reason:     reason,
+			ReasonCode: reasonCode,
+		},
+	})
+	// 广播玩家状态更新（让其他玩家看到）
+	disqualified := true
+	rp.Broadcaster.BroadcastToRoom(rp.RoomID, &model.WSMessage{
+		Type: model.WSTypePlayerUpdate,
+		Payload: &model.WSPlayerUpdate{
+			UserID:           userID,
+			Disqualified:     &disqualified,
+			DisqualifyReason: &reason,
+		},
+	})
+	return model.WSPlayerDisqualified{
+		UserID:   userID,
+		Username: p.Username,
+		Reason:   reason,
+	}
+}
+
 // resetDisqualifiedPlayers 重置被取消资格玩家的状态
 func (rp *RoomProcessor) resetDisqualifiedPlayers() {
 	for _, p := range rp.State.Players {
@@ -916,19 +1583,30 @@ func (rp *RoomProcessor) refundAndWait(ctx context.Context, participants []int64
 		rp.enterWaiting()
 		return
 	}
+	if rp.Room.UsesChips() {
+		rp.refundChipsAndWait(ctx, participants, "not_enough_players")
+		return
+	}
 
 	betAmount := rp.Room.BetAmount
 	playerNewBalances := make(map[int64]decimal.Decimal)
 	playerOldBalances := make(map[int64]decimal.Decimal)
 
-	// 收集退款信息
+	// 收集退款信息（奖励余额支付的部分退回奖励余额）
 	refundAmounts := make(map[int64]decimal.Decimal)
+	bonusStakes := make(map[int64]decimal.Decimal)
 	for _, userID := range participants {
 		if p := rp.State.Players[userID]; p != nil {
-			refundAmounts[userID] = betAmount
+			if stake, ok := rp.State.BonusStakes[userID]; ok {
+				bonusStakes[userID] = stake
+			}
+			if realPart := betAmount.Sub(bonusStakes[userID]); realPart.IsPositive() {
+				refundAmounts[userID] = realPart
+			}
 			playerOldBalances[userID] = p.Balance
 		}
 	}
+	var playerNewBonus map[int64]decimal.Decimal
 
 	err := repository.Tx(ctx, func(tx pgx.Tx) error {
 		// 批量退款（单条 SQL）
@@ -943,17 +1621,21 @@ func (rp *RoomProcessor) refundAndWait(ctx context.Context, participants []int64
 		// 批量创建退款交易记录（单条 SQL）
 		txRecords := make([]*model.BalanceTransaction, 0, len(participants))
 		for _, userID := range participants {
+			amount, ok := refundAmounts[userID]
+			if !ok {
+				continue
+			}
 			oldBalance := playerOldBalances[userID]
 			newBalance := playerNewBalances[userID]
 			if newBalance.IsZero() {
-				newBalance = oldBalance.Add(betAmount)
+				newBalance = oldBalance.Add(amount)
 				playerNewBalances[userID] = newBalance
 			}
 			txRecords = append(txRecords, &model.BalanceTransaction{
 				UserID:        userID,
 				RoomID:        &rp.RoomID,
 				Type:          model.TxGameRefund,
-				Amount:        betAmount,
+				Amount:        amount,
 				BalanceBefore: oldBalance,
 				BalanceAfter:  newBalance,
 			})
@@ -962,6 +1644,13 @@ func (rp *RoomProcessor) refundAndWait(ctx context.Context, participants []int64
 			return fmt.Errorf("batch create refund transactions: %w", err)
 		}
 
+		// 奖励余额部分退回奖励余额
+		newBonus, err := rp.refundBonusStakesTx(ctx, tx, bonusStakes, &rp.State.RoundID, nil)
+		if err != nil {
+			return err
+		}
+		playerNewBonus = newBonus
+
 		return nil
 	})
 
@@ -980,6 +1669,11 @@ func (rp *RoomProcessor) refundAndWait(ctx context.Context, participants []int64
 				}
 			}
 		}
+		for userID, newBonus := range playerNewBonus {
+			if p := rp.State.Players[userID]; p != nil {
+				p.BonusBalance = newBonus
+			}
+		}
 	}
 
 	// 广播失败
@@ -1017,19 +1711,14 @@ func (rp *RoomProcessor) AddPlayer(user *model.User) {
 	defer rp.mu.Unlock()
 
 	// 加载余额到缓存
-	balance := user.Balance
-	if rp.balanceCache != nil {
-		ctx := context.Background()
-		if cached, err := rp.balanceCache.LoadFromDB(ctx, user.ID); err == nil {
-			balance = cached.Balance
-		}
-	}
+	balance, bonusBalance := rp.loadPlayerBalance(context.Background(), user)
 
 	// 检查是否是重连（已存在的玩家）
 	if existingPlayer, exists := rp.State.Players[user.ID]; exists {
 		// 重连：保留原有的 AutoReady 状态，只更新在线状态和余额
 		existingPlayer.IsOnline = true
 		existingPlayer.Balance = balance
+		existingPlayer.BonusBalance = bonusBalance
 		// 广播玩家上线状态
 		online := true
 		rp.Broadcaster.BroadcastToRoom(rp.RoomID, &model.WSMessage{
@@ -1052,11 +1741,12 @@ func (rp *RoomProcessor) AddPlayer(user *model.User) {
 	}
 
 	rp.State.Players[user.ID] = &model.PlayerState{
-		UserID:    user.ID,
-		Username:  user.Username,
-		Balance:   balance,
-		AutoReady: autoReady,
-		IsOnline:  true,
+		UserID:       user.ID,
+		Username:     user.Username,
+		Balance:      balance,
+		BonusBalance: bonusBalance,
+		AutoReady:    autoReady,
+		IsOnline:     true,
 	}
 
 	rp.Broadcaster.BroadcastToRoom(rp.RoomID, &model.WSMessage{
@@ -1107,23 +1797,21 @@ func (rp *RoomProcessor) LoadPlayersFromDB(ctx context.Context) error {
 		}
 
 		// 加载余额
-		balance := user.Balance
-		if rp.balanceCache != nil {
-			if cached, err := rp.balanceCache.LoadFromDB(ctx, user.ID); err == nil {
-				balance = cached.Balance
-			}
-		}
+		balance, bonusBalance := rp.loadPlayerBalance(ctx, user)
 
 		// 添加玩家，初始状态为离线
 		rp.State.Players[user.ID] = &model.PlayerState{
-			UserID:    user.ID,
-			Username:  user.Username,
-			Balance:   balance,
-			AutoReady: rp2.AutoReady,
-			IsOnline:  false, // 初始为离线，等待 WebSocket 连接
+			UserID:       user.ID,
+			Username:     user.Username,
+			Balance:      balance,
+			BonusBalance: bonusBalance,
+			AutoReady:    rp2.AutoReady,
+			IsOnline:     false, // 初始为离线，等待 WebSocket 连接
 		}
 	}
 
+	rp.refreshJackpot(ctx)
+
 	rp.logger.Info("Loaded players from DB", zap.Int("count", len(roomPlayers)))
 	return nil
 }
@@ -1138,23 +1826,58 @@ func (rp *RoomProcessor) refundPendingRound(ctx context.Context, round *model.Ga
 		return
 	}
 
+	// 写回模式下下注可能仍在 outbox 中未落库，先落库再按下注流水退款；
+	// 落库失败时暂不处理该回合（保持未结算状态，下次恢复时重试），避免漏退仅在 Redis 中扣款的参与者
+	if rp.balanceCache.WriteBehindEnabled() {
+		if _, err := rp.balanceCache.Flush(ctx); err != nil {
+			rp.logger.Error("Failed to flush balance outbox, pending round refund deferred",
+				zap.Int64("round_id", round.ID), zap.Error(err))
+			return
+		}
+	}
+
 	betAmount := round.BetAmount
 	playerNewBalances := make(map[int64]decimal.Decimal)
 	playerOldBalances := make(map[int64]decimal.Decimal)
 
+	// 从下注流水中获取奖励余额支付的部分，退款时退回奖励余额
+	bonusStakes, err := rp.txRepo.GetRoundBonusStakes(ctx, round.ID)
+	if err != nil {
+		rp.logger.Error("Failed to get round bonus stakes", zap.Int64("round_id", round.ID), zap.Error(err))
+		return
+	}
+
+	// 只退款有下注流水的参与者（写回模式下回合先于扣款创建，扣款可能未执行）
+	bettors, err := rp.txRepo.GetRoundBettors(ctx, round.ID)
+	if err != nil {
+		rp.logger.Error("Failed to get round bettors", zap.Int64("round_id", round.ID), zap.Error(err))
+		return
+	}
+
 	// 收集退款信息，从数据库获取最新余额
 	refundAmounts := make(map[int64]decimal.Decimal)
+	refundBonus := make(map[int64]decimal.Decimal)
 	for _, userID := range round.ParticipantIDs {
+		if !bettors[userID] {
+			rp.logger.Warn("Participant has no bet record, skipping refund",
+				zap.Int64("round_id", round.ID), zap.Int64("user_id", userID))
+			continue
+		}
 		user, err := rp.userRepo.GetByID(ctx, userID)
 		if err != nil {
 			rp.logger.Warn("Failed to get user for refund", zap.Int64("user_id", userID), zap.Error(err))
 			continue
 		}
-		refundAmounts[userID] = betAmount
+		if stake, ok := bonusStakes[userID]; ok && stake.IsPositive() {
+			refundBonus[userID] = stake
+		}
+		if realPart := betAmount.Sub(refundBonus[userID]); realPart.IsPositive() {
+			refundAmounts[userID] = realPart
+		}
 		playerOldBalances[userID] = user.Balance
 	}
 
-	if len(refundAmounts) == 0 {
+	if len(playerOldBalances) == 0 {
 		rp.logger.Warn("No users to refund")
 		if err := rp.gameRepo.FailRound(ctx, round.ID, "server_restart_no_users"); err != nil {
 			rp.logger.Error("Failed to mark round as failed", zap.Error(err))
@@ -1163,7 +1886,7 @@ func (rp *RoomProcessor) refundPendingRound(ctx context.Context, round *model.Ga
 	}
 
 	// 执行退款事务
-	err := repository.Tx(ctx, func(tx pgx.Tx) error {
+	err = repository.Tx(ctx, func(tx pgx.Tx) error {
 		// 批量退款
 		addResults, err := rp.userRepo.BatchAddBalanceTx(ctx, tx, refundAmounts)
 		if err != nil {
@@ -1176,20 +1899,21 @@ func (rp *RoomProcessor) refundPendingRound(ctx context.Context, round *model.Ga
 		// 批量创建退款交易记录
 		txRecords := make([]*model.BalanceTransaction, 0, len(round.ParticipantIDs))
 		for _, userID := range round.ParticipantIDs {
-			oldBalance, ok := playerOldBalances[userID]
+			amount, ok := refundAmounts[userID]
 			if !ok {
 				continue
 			}
+			oldBalance := playerOldBalances[userID]
 			newBalance := playerNewBalances[userID]
 			if newBalance.IsZero() {
-				newBalance = oldBalance.Add(betAmount)
+				newBalance = oldBalance.Add(amount)
 			}
 			txRecords = append(txRecords, &model.BalanceTransaction{
 				UserID:        userID,
 				RoomID:        &rp.RoomID,
 				RoundID:       &round.ID,
 				Type:          model.TxGameRefund,
-				Amount:        betAmount,
+				Amount:        amount,
 				BalanceBefore: oldBalance,
 				BalanceAfter:  newBalance,
 				Remark:        stringPtr("服务器重启自动退款"),
@@ -1201,6 +1925,11 @@ func (rp *RoomProcessor) refundPendingRound(ctx context.Context, round *model.Ga
 			}
 		}
 
+		// 奖励余额部分退回奖励余额
+		if _, err := rp.refundBonusStakesTx(ctx, tx, refundBonus, &round.ID, stringPtr("服务器重启自动退款")); err != nil {
+			return err
+		}
+
 		// 标记回合失败（使用事务版本）
 		if err := rp.gameRepo.FailRoundTx(ctx, tx, round.ID, "server_restart"); err != nil {
 			return fmt.Errorf("fail round: %w", err)
@@ -1326,9 +2055,11 @@ func (rp *RoomProcessor) GetRoomState() *model.WSRoomState {
 		}
 	}
 
-	return &model.WSRoomState{
+	state := &model.WSRoomState{
 		RoomID:       rp.RoomID,
 		RoomName:     rp.Room.Name,
+		RoomType:     rp.Room.RoomType,
+		TournamentID: rp.Room.TournamentID,
 		BetAmount:    rp.Room.BetAmount.String(),
 		WinnerCount:  rp.Room.WinnerCount,
 		MaxPlayers:   rp.Room.MaxPlayers,
@@ -1338,10 +2069,18 @@ func (rp *RoomProcessor) GetRoomState() *model.WSRoomState {
 		Players:      players,
 		PoolAmount:   rp.State.PoolAmount.String(),
 	}
+	if rp.State.JackpotAmount != nil {
+		state.JackpotAmount = rp.State.JackpotAmount.String()
+	}
+	return state
 }
 
 // UpdatePlayerBalance 更新玩家余额(外部充值/提现后调用)
 func (rp *RoomProcessor) UpdatePlayerBalance(userID int64, balance decimal.Decimal) {
+	// 练习房、锦标赛房间余额为筹码，不受真实余额变动影响
+	if rp.Room.UsesChips() {
+		return
+	}
 	rp.mu.Lock()
 	defer rp.mu.Unlock()
 
@@ -1357,6 +2096,28 @@ func (rp *RoomProcessor) UpdatePlayerBalance(userID int64, balance decimal.Decim
 	}
 }
 
+// UpdatePlayerBonusBalance 更新玩家奖励余额(发放奖励后调用)
+func (rp *RoomProcessor) UpdatePlayerBonusBalance(userID int64, bonusBalance decimal.Decimal) {
+	// 练习房、锦标赛房间余额为筹码，不受真实余额变动影响
+	if rp.Room.UsesChips() {
+		return
+	}
+	rp.mu.Lock()
+	defer rp.mu.Unlock()
+
+	if p := rp.State.Players[userID]; p != nil {
+		p.BonusBalance = bonusBalance
+		rp.Broadcaster.SendToUser(userID, &model.WSMessage{
+			Type: model.WSTypeBalanceUpdate,
+			Payload: &model.WSBalanceUpdate{
+				Balance:       p.Balance.String(),
+				FrozenBalance: "0",
+				BonusBalance:  bonusBalance.String(),
+			},
+		})
+	}
+}
+
 
 // ===== 观战者相关接口 =====
 
@@ -1439,25 +2200,25 @@ func (rp *RoomProcessor) SpectatorToParticipant(user *model.User) error {
 		return ErrRoomFull
 	}
 
+	// 检查参与资格（币种、锦标赛报名）
+	if err := rp.CheckParticipant(context.Background(), user); err != nil {
+		return err
+	}
+
 	// 从观战者列表移除
 	delete(rp.State.Spectators, user.ID)
 
 	// 从数据库/缓存获取最新余额
-	balance := user.Balance
-	if rp.balanceCache != nil {
-		ctx := context.Background()
-		if cached, err := rp.balanceCache.LoadFromDB(ctx, user.ID); err == nil {
-			balance = cached.Balance
-		}
-	}
+	balance, bonusBalance := rp.loadPlayerBalance(context.Background(), user)
 
 	// 添加到玩家列表
 	rp.State.Players[user.ID] = &model.PlayerState{
-		UserID:    user.ID,
-		Username:  spectator.Username,
-		Balance:   balance,
-		AutoReady: false,
-		IsOnline:  true,
+		UserID:       user.ID,
+		Username:     spectator.Username,
+		Balance:      balance,
+		BonusBalance: bonusBalance,
+		AutoReady:    false,
+		IsOnline:     true,
 	}
 
 	// 广播观战者切换为参与者
@@ -1531,9 +2292,11 @@ func (rp *RoomProcessor) GetRoomStateForUser(userID int64) *model.WSRoomState {
 
 	_, isSpectator := rp.State.Spectators[userID]
 
-	return &model.WSRoomState{
+	state := &model.WSRoomState{
 		RoomID:        rp.RoomID,
 		RoomName:      rp.Room.Name,
+		RoomType:      rp.Room.RoomType,
+		TournamentID:  rp.Room.TournamentID,
 		BetAmount:     rp.Room.BetAmount.String(),
 		WinnerCount:   rp.Room.WinnerCount,
 		MaxPlayers:    rp.Room.MaxPlayers,
@@ -1546,4 +2309,8 @@ func (rp *RoomProcessor) GetRoomStateForUser(userID int64) *model.WSRoomState {
 		PoolAmount:    rp.State.PoolAmount.String(),
 		IsSpectator:   isSpectator,
 	}
+	if rp.State.JackpotAmount != nil {
+		state.JackpotAmount = rp.State.JackpotAmount.String()
+	}
+	return state
 }
@@ -0,0 +1,55 @@
+package game
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+// TestSplitPrizePoolAndResidual 测试平分奖池的舍入残值及其在累进奖池与平台之间的分配
+func TestSplitPrizePoolAndResidual(t *testing.T) {
+	tests := []struct {
+		name          string
+		prizePool     string
+		winners       int
+		jackpotActive bool
+		perWinner     string
+		toJackpot     string
+		toPlatform    string
+	}{
+		{"even split", "30.00", 3, true, "10", "0", "0"},
+		{"positive residual goes to the jackpot", "10.00", 3, true, "3.33", "0.01", "0"},
+		{"positive residual without jackpot goes to the platform", "10.00", 3, false, "3.33", "0", "0.01"},
+		{"rounded-up prize leaves a negative residual for the platform", "20.00", 3, true, "6.67", "0", "-0.01"},
+		{"negative residual without jackpot", "20.00", 3, false, "6.67", "0", "-0.01"},
+		{"single winner", "19.99", 1, true, "19.99", "0", "0"},
+		{"sub-cent pool", "0.05", 6, true, "0.01", "0", "-0.01"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pool := decimal.RequireFromString(tt.prizePool)
+			perWinner, residual := splitPrizePool(pool, tt.winners)
+			if !perWinner.Equal(decimal.RequireFromString(tt.perWinner)) {
+				t.Errorf("per winner = %s, want %s", perWinner, tt.perWinner)
+			}
+			if total := perWinner.Mul(decimal.NewFromInt(int64(tt.winners))).Add(residual); !total.Equal(pool) {
+				t.Errorf("prizes plus residual = %s, want %s", total, pool)
+			}
+
+			toJackpot, toPlatform := splitResidual(residual, tt.jackpotActive)
+			if !toJackpot.Equal(decimal.RequireFromString(tt.toJackpot)) {
+				t.Errorf("to jackpot = %s, want %s", toJackpot, tt.toJackpot)
+			}
+			if !toPlatform.Equal(decimal.RequireFromString(tt.toPlatform)) {
+				t.Errorf("to platform = %s, want %s", toPlatform, tt.toPlatform)
+			}
+			if toJackpot.IsNegative() {
+				t.Errorf("jackpot must never receive a negative residual, got %s", toJackpot)
+			}
+			if !toJackpot.Add(toPlatform).Equal(residual) {
+				t.Errorf("split %s + %s does not add up to residual %s", toJackpot, toPlatform, residual)
+			}
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package game
+
+import (
+	"context"
+
+	"github.com/fiveseconds/server/internal/model"
+
+	"github.com/shopspring/decimal"
+)
+
+// tournamentChips 将锦标赛筹码账本绑定到房间所属锦标赛，作为房间的筹码账本使用
+type tournamentChips struct {
+	ledger       TournamentLedger
+	tournamentID int64
+}
+
+func (c *tournamentChips) GetChips(ctx context.Context, userID int64) (decimal.Decimal, error) {
+	return c.ledger.GetChips(ctx, c.tournamentID, userID)
+}
+
+func (c *tournamentChips) DeductStakes(ctx context.Context, userIDs []int64, stake decimal.Decimal) (map[int64]decimal.Decimal, error) {
+	return c.ledger.DeductStakes(ctx, c.tournamentID, userIDs, stake)
+}
+
+func (c *tournamentChips) SettleRound(ctx context.Context, participants, winners []int64, pool decimal.Decimal) (decimal.Decimal, map[int64]decimal.Decimal, error) {
+	return c.ledger.SettleRound(ctx, c.tournamentID, participants, winners, pool)
+}
+
+func (c *tournamentChips) RefundStakes(ctx context.Context, userIDs []int64, stake decimal.Decimal) (map[int64]decimal.Decimal, error) {
+	return c.ledger.RefundStakes(ctx, c.tournamentID, userIDs, stake)
+}
+
+// acceptingRounds 锦标赛房间是否可以开始新回合（锦标赛进行中且未达到回合上限与结束时间）
+func (rp *RoomProcessor) acceptingRounds() bool {
+	if rp.tournament == nil || rp.Room.TournamentID == nil {
+		return false
+	}
+	return rp.tournament.AcceptingRounds(context.Background(), *rp.Room.TournamentID)
+}
+
+// CheckParticipant 检查用户能否作为参与者加入房间（不满足时只能观战）
+// 普通房间要求活跃币种与房间币种一致；练习房不限；锦标赛房间要求已报名该锦标赛
+func (rp *RoomProcessor) CheckParticipant(ctx context.Context, user *model.User) error {
+	switch {
+	case rp.Room.IsPractice():
+		return nil
+	case rp.Room.IsTournament():
+		if rp.tournament == nil || rp.Room.TournamentID == nil {
+			return ErrChipLedgerUnavailable
+		}
+		return rp.tournament.CheckEntrant(ctx, *rp.Room.TournamentID, user.ID)
+	}
+	if user.Currency != rp.Room.Currency {
+		return ErrCurrencyMismatch
+	}
+	return nil
+}
@@ -23,14 +23,14 @@ func NewAuditHandler(auditService *service.AuditService, fundService *service.Fu
 	}
 }
 
-// RunConservationCheck 执行资金守恒检查
+// RunConservationCheck 执行资金守恒检查（每个币种一条结果）
 func (h *AuditHandler) RunConservationCheck(c *gin.Context) {
-	check, err := h.auditService.RunGlobalConservationCheck(c.Request.Context())
+	checks, err := h.auditService.RunGlobalConservationCheck(c.Request.Context())
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
-	c.JSON(http.StatusOK, check)
+	c.JSON(http.StatusOK, gin.H{"items": checks, "total": len(checks)})
 }
 
 // RunPeriodicAudit 执行定期审计
@@ -74,6 +74,9 @@ func (h *AuditHandler) GetAuditHistory(c *gin.Context) {
 	if periodType := c.Query("period_type"); periodType != "" {
 		query.PeriodType = &periodType
 	}
+	if currency := service.NormalizeCurrency(c.Query("currency")); currency != "" {
+		query.Currency = &currency
+	}
 
 	query.Page = 1
 	query.PageSize = 20
@@ -106,7 +109,7 @@ func (h *AuditHandler) GetFundSummary(c *gin.Context) {
 		}
 	}
 
-	summary, err := h.fundService.GetFundSummary(c.Request.Context(), userID)
+	summary, err := h.fundService.GetFundSummary(c.Request.Context(), userID, service.NormalizeCurrency(c.Query("currency")))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
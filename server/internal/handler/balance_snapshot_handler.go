@@ -0,0 +1,71 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/fiveseconds/server/internal/model"
+	"github.com/fiveseconds/server/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BalanceSnapshotHandler 日终余额快照处理器
+type BalanceSnapshotHandler struct {
+	snapshotService *service.BalanceSnapshotService
+}
+
+// NewBalanceSnapshotHandler 创建日终余额快照处理器
+func NewBalanceSnapshotHandler(snapshotService *service.BalanceSnapshotService) *BalanceSnapshotHandler {
+	return &BalanceSnapshotHandler{
+		snapshotService: snapshotService,
+	}
+}
+
+// GetBalanceHistory 管理员查询用户历史日终余额
+// GET /api/admin/users/:id/balance-history?start_date=2025-01-01&end_date=2025-01-31
+func (h *BalanceSnapshotHandler) GetBalanceHistory(c *gin.Context) {
+	userID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	query := &model.BalanceHistoryQuery{UserID: userID, Page: 1, PageSize: 20}
+	if p, ok := c.GetQuery("page"); ok {
+		if v, err := parseInt(p); err == nil && v > 0 {
+			query.Page = v
+		}
+	}
+	if ps, ok := c.GetQuery("page_size"); ok {
+		if v, err := parseInt(ps); err == nil && v > 0 && v <= 100 {
+			query.PageSize = v
+		}
+	}
+	for param, target := range map[string]**time.Time{"start_date": &query.From, "end_date": &query.To} {
+		v, ok := c.GetQuery(param)
+		if !ok || v == "" {
+			continue
+		}
+		date, err := time.ParseInLocation("2006-01-02", v, time.UTC)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid " + param + ", use YYYY-MM-DD"})
+			return
+		}
+		*target = &date
+	}
+
+	snapshots, total, err := h.snapshotService.GetBalanceHistory(c.Request.Context(), query)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, service.ErrBalanceHistoryUserNotFound) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"items": snapshots, "total": total})
+}
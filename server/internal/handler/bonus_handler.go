@@ -0,0 +1,140 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/fiveseconds/server/internal/model"
+	"github.com/fiveseconds/server/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BonusHandler 奖励余额处理器
+type BonusHandler struct {
+	bonusService *service.BonusService
+}
+
+// NewBonusHandler 创建奖励余额处理器
+func NewBonusHandler(bonusService *service.BonusService) *BonusHandler {
+	return &BonusHandler{
+		bonusService: bonusService,
+	}
+}
+
+// GetMyBonus 获取我的奖励余额及流水进度
+func (h *BonusHandler) GetMyBonus(c *gin.Context) {
+	summary, err := h.bonusService.GetSummary(c.Request.Context(), GetUserID(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, summary)
+}
+
+// GrantBonus 发放奖励（房主发放给名下玩家，管理员不限）
+func (h *BonusHandler) GrantBonus(c *gin.Context) {
+	var req model.GrantBonusReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	grant, err := h.bonusService.GrantBonus(c.Request.Context(), GetUserID(c), GetRole(c), &req)
+	if err != nil {
+		c.JSON(bonusErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, grant)
+}
+
+// ListOwnerGrants 获取房主名下玩家的奖励发放记录
+func (h *BonusHandler) ListOwnerGrants(c *gin.Context) {
+	query, ok := bindBonusGrantListQuery(c)
+	if !ok {
+		return
+	}
+	ownerID := GetUserID(c)
+	query.OwnerID = &ownerID
+
+	grants, total, err := h.bonusService.ListGrants(c.Request.Context(), query)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"items": grants, "total": total})
+}
+
+// ListGrants 管理员获取奖励发放记录（可按 owner_id 过滤）
+func (h *BonusHandler) ListGrants(c *gin.Context) {
+	query, ok := bindBonusGrantListQuery(c)
+	if !ok {
+		return
+	}
+	if v, exists := c.GetQuery("owner_id"); exists && v != "" {
+		ownerID, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid owner_id"})
+			return
+		}
+		query.OwnerID = &ownerID
+	}
+
+	grants, total, err := h.bonusService.ListGrants(c.Request.Context(), query)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"items": grants, "total": total})
+}
+
+// bonusErrorStatus 奖励错误对应的 HTTP 状态码
+func bonusErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrBonusUserNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrBonusForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, service.ErrBonusInvalidAmount), errors.Is(err, service.ErrBonusExceedsMaxGrant),
+		errors.Is(err, service.ErrBonusInvalidMultiplier), errors.Is(err, service.ErrBonusNotPlayer),
+		errors.Is(err, service.ErrBonusCurrencyMismatch):
+		return http.StatusBadRequest
+	case errors.Is(err, service.ErrBonusInsufficientFunds):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// bindBonusGrantListQuery 解析奖励发放记录查询参数（user_id、status、分页）
+func bindBonusGrantListQuery(c *gin.Context) (*model.BonusGrantListQuery, bool) {
+	query := &model.BonusGrantListQuery{Page: 1, PageSize: 20}
+	if p, ok := c.GetQuery("page"); ok {
+		if v, err := parseInt(p); err == nil && v > 0 {
+			query.Page = v
+		}
+	}
+	if ps, ok := c.GetQuery("page_size"); ok {
+		if v, err := parseInt(ps); err == nil && v > 0 && v <= 100 {
+			query.PageSize = v
+		}
+	}
+	if v, ok := c.GetQuery("user_id"); ok && v != "" {
+		userID, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user_id"})
+			return nil, false
+		}
+		query.UserID = &userID
+	}
+	if v, ok := c.GetQuery("status"); ok && v != "" {
+		status := model.BonusGrantStatus(v)
+		query.Status = &status
+	}
+	return query, true
+}
@@ -0,0 +1,81 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/fiveseconds/server/internal/model"
+	"github.com/fiveseconds/server/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreditLimitHandler 房主信用额度处理器
+type CreditLimitHandler struct {
+	creditService *service.CreditLimitService
+}
+
+// NewCreditLimitHandler 创建房主信用额度处理器
+func NewCreditLimitHandler(creditService *service.CreditLimitService) *CreditLimitHandler {
+	return &CreditLimitHandler{
+		creditService: creditService,
+	}
+}
+
+// GetMyCreditLimit 房主查看自己的敞口与信用额度
+func (h *CreditLimitHandler) GetMyCreditLimit(c *gin.Context) {
+	exposure, err := h.creditService.GetExposure(c.Request.Context(), GetUserID(c))
+	if err != nil {
+		c.JSON(creditLimitErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, exposure)
+}
+
+// ListCreditLimits 管理员查看全部房主的敞口与信用额度
+func (h *CreditLimitHandler) ListCreditLimits(c *gin.Context) {
+	exposures, err := h.creditService.ListExposures(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"items": exposures, "total": len(exposures)})
+}
+
+// UpdateCreditLimit 管理员设置房主信用额度倍数
+func (h *CreditLimitHandler) UpdateCreditLimit(c *gin.Context) {
+	ownerID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid owner id"})
+		return
+	}
+
+	var req model.UpdateOwnerCreditLimitReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	exposure, err := h.creditService.UpdateMultiplier(c.Request.Context(), ownerID, GetUserID(c), &req)
+	if err != nil {
+		c.JSON(creditLimitErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, exposure)
+}
+
+// creditLimitErrorStatus 信用额度错误对应的 HTTP 状态码
+func creditLimitErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrCreditLimitNotOwner):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrInvalidCreditMultiplier):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,65 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/fiveseconds/server/internal/model"
+	"github.com/fiveseconds/server/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// FairnessHandler 回合结果公平性统计处理器
+type FairnessHandler struct {
+	fairness *service.FairnessService
+}
+
+// NewFairnessHandler 创建公平性统计处理器
+func NewFairnessHandler(fairness *service.FairnessService) *FairnessHandler {
+	return &FairnessHandler{
+		fairness: fairness,
+	}
+}
+
+// GetDashboard 公平性看板（最近一次统计的全局与各房间报告）
+// GET /api/admin/fairness
+func (h *FairnessHandler) GetDashboard(c *gin.Context) {
+	dashboard, err := h.fairness.Dashboard(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, dashboard)
+}
+
+// ListReports 公平性报告历史
+// GET /api/admin/fairness/reports
+func (h *FairnessHandler) ListReports(c *gin.Context) {
+	query := model.FairnessReportQuery{Page: 1, PageSize: 20}
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	reports, total, err := h.fairness.ListReports(c.Request.Context(), &query)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"items": reports, "total": total})
+}
+
+// RunNow 立即执行一次公平性统计
+// POST /api/admin/fairness/run
+func (h *FairnessHandler) RunNow(c *gin.Context) {
+	reports, err := h.fairness.Run(c.Request.Context(), time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"items": reports, "total": len(reports)})
+}
@@ -59,6 +59,8 @@ func (h *Handler) Login(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	req.ClientIP = c.ClientIP()
+	req.UserAgent = c.Request.UserAgent()
 
 	resp, err := h.authService.Login(c.Request.Context(), &req)
 	if err != nil {
@@ -144,6 +146,10 @@ func (h *Handler) CreateOwner(c *gin.Context) {
 			c.JSON(http.StatusConflict, gin.H{"error": "username already exists"})
 			return
 		}
+		if errors.Is(err, service.ErrUnsupportedCurrency) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -227,6 +233,10 @@ func (h *Handler) ListRooms(c *gin.Context) {
 		s := model.RoomStatus(status)
 		query.Status = &s
 	}
+	if roomType := c.Query("room_type"); roomType != "" {
+		t := model.RoomType(roomType)
+		query.RoomType = &t
+	}
 	query.Page = 1
 	query.PageSize = 20
 	if page := c.Query("page"); page != "" {
@@ -419,6 +429,15 @@ func (h *Handler) CreateFundRequest(c *gin.Context) {
 	userID := GetUserID(c)
 	fundReq, err := h.fundService.CreateFundRequest(c.Request.Context(), userID, &req)
 	if err != nil {
+		var restricted *service.RestrictedError
+		if errors.As(err, &restricted) {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error":       err.Error(),
+				"reason_code": restricted.Restriction.Reason,
+				"expires_at":  restricted.Restriction.ExpiresAt,
+			})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -463,12 +482,13 @@ func (h *Handler) ProcessFundRequest(c *gin.Context) {
 	}
 
 	userID := GetUserID(c)
-	if err := h.fundService.ProcessFundRequest(c.Request.Context(), reqID, userID, &req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	status, err := h.fundService.ProcessFundRequest(c.Request.Context(), reqID, userID, &req)
+	if err != nil {
+		respondFundProcessError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "processed"})
+	c.JSON(http.StatusOK, gin.H{"message": "processed", "status": status})
 }
 
 // ListOwnerFundRequests 获取 owner 下级玩家的资金申请
@@ -515,12 +535,47 @@ func (h *Handler) ProcessOwnerFundRequest(c *gin.Context) {
 		return
 	}
 
-	if err := h.fundService.ProcessFundRequest(c.Request.Context(), reqID, ownerID, &req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	status, err := h.fundService.ProcessFundRequest(c.Request.Context(), reqID, ownerID, &req)
+	if err != nil {
+		respondFundProcessError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "processed", "status": status})
+}
+
+// respondFundProcessError 审批失败响应：申请人账户被限制时返回 403 及原因代码
+func respondFundProcessError(c *gin.Context, err error) {
+	var restricted *service.RestrictedError
+	if errors.As(err, &restricted) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error":       err.Error(),
+			"reason_code": restricted.Restriction.Reason,
+			"expires_at":  restricted.Restriction.ExpiresAt,
+		})
+		return
+	}
+	c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+}
+
+// ListFundRequestApprovals 获取资金申请的审批记录（owner 仅能查看下级玩家的申请）
+func (h *Handler) ListFundRequestApprovals(c *gin.Context) {
+	reqID, _ := strconv.ParseInt(c.Param("id"), 10, 64)
+
+	if GetRole(c) != model.RoleAdmin {
+		if err := h.fundService.ValidateOwnerFundRequest(c.Request.Context(), reqID, GetUserID(c)); err != nil {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	approvals, err := h.fundService.ListFundRequestApprovals(c.Request.Context(), reqID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "processed"})
+	c.JSON(http.StatusOK, gin.H{"items": approvals})
 }
 
 func (h *Handler) ListTransactions(c *gin.Context) {
@@ -551,8 +606,16 @@ func (h *Handler) ListTransactions(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"items": txs, "total": total})
 }
 
+// currencyQuery 读取 ?currency= 参数，未指定时使用默认币种
+func currencyQuery(c *gin.Context) string {
+	if currency := service.NormalizeCurrency(c.Query("currency")); currency != "" {
+		return currency
+	}
+	return service.DefaultCurrency
+}
+
 func (h *Handler) GetPlatformAccount(c *gin.Context) {
-	acc, err := h.fundService.GetPlatformAccount(c.Request.Context())
+	acc, err := h.fundService.GetPlatformAccount(c.Request.Context(), currencyQuery(c))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -561,7 +624,7 @@ func (h *Handler) GetPlatformAccount(c *gin.Context) {
 }
 
 func (h *Handler) CheckConservation(c *gin.Context) {
-	check, err := h.fundService.CheckConservation(c.Request.Context())
+	check, err := h.fundService.CheckConservation(c.Request.Context(), currencyQuery(c))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -571,7 +634,7 @@ func (h *Handler) CheckConservation(c *gin.Context) {
 
 // GetReconciliationReport 获取详细的资金对账报告
 func (h *Handler) GetReconciliationReport(c *gin.Context) {
-	report, err := h.fundService.GetReconciliationReport(c.Request.Context())
+	report, err := h.fundService.GetReconciliationReport(c.Request.Context(), currencyQuery(c))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -586,7 +649,7 @@ func (h *Handler) GetFundSummary(c *gin.Context) {
 		userID = &id
 	}
 
-	summary, err := h.fundService.GetFundSummary(c.Request.Context(), userID)
+	summary, err := h.fundService.GetFundSummary(c.Request.Context(), userID, service.NormalizeCurrency(c.Query("currency")))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -597,14 +660,15 @@ func (h *Handler) GetFundSummary(c *gin.Context) {
 // GetBalanceCheckReport 管理后台资金对账详情（资金守恒结果 + 对账类目汇总）
 func (h *Handler) GetBalanceCheckReport(c *gin.Context) {
 	ctx := c.Request.Context()
+	currency := currencyQuery(c)
 
-	check, err := h.fundService.CheckConservation(ctx)
+	check, err := h.fundService.CheckConservation(ctx, currency)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	summary, err := h.fundService.GetFundSummary(ctx, nil)
+	summary, err := h.fundService.GetFundSummary(ctx, nil, currency)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -0,0 +1,134 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/fiveseconds/server/internal/model"
+	"github.com/fiveseconds/server/internal/repository"
+	"github.com/fiveseconds/server/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// JackpotHandler 累进奖池处理器
+type JackpotHandler struct {
+	jackpotService *service.JackpotService
+}
+
+// NewJackpotHandler 创建累进奖池处理器
+func NewJackpotHandler(jackpotService *service.JackpotService) *JackpotHandler {
+	return &JackpotHandler{
+		jackpotService: jackpotService,
+	}
+}
+
+// SaveJackpot 房主开设或修改累进奖池（room_id 为空时为房主级奖池）
+func (h *JackpotHandler) SaveJackpot(c *gin.Context) {
+	var req model.SaveJackpotReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	jackpot, err := h.jackpotService.Save(c.Request.Context(), GetUserID(c), &req)
+	if err != nil {
+		c.JSON(jackpotErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, jackpot)
+}
+
+// ListOwnerJackpots 获取房主自己的累进奖池
+func (h *JackpotHandler) ListOwnerJackpots(c *gin.Context) {
+	ownerID := GetUserID(c)
+	query := bindJackpotListQuery(c)
+	query.OwnerID = &ownerID
+
+	jackpots, total, err := h.jackpotService.List(c.Request.Context(), query)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"items": jackpots, "total": total})
+}
+
+// ListOwnerJackpotHits 获取房主累进奖池的中奖记录
+func (h *JackpotHandler) ListOwnerJackpotHits(c *gin.Context) {
+	ownerID := GetUserID(c)
+	h.listHits(c, &ownerID)
+}
+
+// ListJackpots 管理员获取累进奖池（可按 owner_id 过滤）
+func (h *JackpotHandler) ListJackpots(c *gin.Context) {
+	query := bindJackpotListQuery(c)
+	if v, ok := c.GetQuery("owner_id"); ok && v != "" {
+		ownerID, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid owner_id"})
+			return
+		}
+		query.OwnerID = &ownerID
+	}
+
+	jackpots, total, err := h.jackpotService.List(c.Request.Context(), query)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"items": jackpots, "total": total})
+}
+
+// ListJackpotHits 管理员获取累进奖池的中奖记录
+func (h *JackpotHandler) ListJackpotHits(c *gin.Context) {
+	h.listHits(c, nil)
+}
+
+// listHits 分页返回中奖记录
+func (h *JackpotHandler) listHits(c *gin.Context, ownerID *int64) {
+	id, _ := strconv.ParseInt(c.Param("id"), 10, 64)
+	query := bindJackpotListQuery(c)
+
+	hits, total, err := h.jackpotService.ListHits(c.Request.Context(), id, ownerID, query.Page, query.PageSize)
+	if err != nil {
+		c.JSON(jackpotErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"items": hits, "total": total})
+}
+
+// jackpotErrorStatus 累进奖池错误对应的 HTTP 状态码
+func jackpotErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrJackpotNotFound), errors.Is(err, repository.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrJackpotForbidden), errors.Is(err, service.ErrJackpotRoomNotOwned):
+		return http.StatusForbidden
+	case errors.Is(err, service.ErrJackpotInvalidRate), errors.Is(err, service.ErrJackpotInvalidOdds),
+		errors.Is(err, service.ErrJackpotRoomType):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// bindJackpotListQuery 解析累进奖池列表分页参数
+func bindJackpotListQuery(c *gin.Context) *model.JackpotListQuery {
+	query := &model.JackpotListQuery{Page: 1, PageSize: 20}
+	if p, ok := c.GetQuery("page"); ok {
+		if v, err := parseInt(p); err == nil && v > 0 {
+			query.Page = v
+		}
+	}
+	if ps, ok := c.GetQuery("page_size"); ok {
+		if v, err := parseInt(ps); err == nil && v > 0 && v <= 100 {
+			query.PageSize = v
+		}
+	}
+	return query
+}
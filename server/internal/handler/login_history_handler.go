@@ -0,0 +1,65 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/fiveseconds/server/internal/model"
+	"github.com/fiveseconds/server/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// LoginHistoryHandler 登录历史处理器
+type LoginHistoryHandler struct {
+	loginHistory *service.LoginHistoryService
+}
+
+// NewLoginHistoryHandler 创建登录历史处理器
+func NewLoginHistoryHandler(loginHistory *service.LoginHistoryService) *LoginHistoryHandler {
+	return &LoginHistoryHandler{
+		loginHistory: loginHistory,
+	}
+}
+
+// ListLoginEvents 获取用户登录历史（含失败的登录尝试）
+// GET /api/admin/users/:id/login-events
+func (h *LoginHistoryHandler) ListLoginEvents(c *gin.Context) {
+	userID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	query := model.LoginEventListQuery{Page: 1, PageSize: 20}
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	events, total, err := h.loginHistory.ListByUser(c.Request.Context(), userID, &query)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"items": events, "total": total})
+}
+
+// ListKnownDevices 获取用户登录成功过的设备
+// GET /api/admin/users/:id/devices
+func (h *LoginHistoryHandler) ListKnownDevices(c *gin.Context) {
+	userID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	devices, err := h.loginHistory.ListKnownDevices(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"items": devices, "total": len(devices)})
+}
@@ -0,0 +1,274 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/fiveseconds/server/internal/model"
+	"github.com/fiveseconds/server/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// NotificationHandler 告警通知渠道、路由与投递记录处理器
+type NotificationHandler struct {
+	notifications *service.NotificationService
+}
+
+// NewNotificationHandler 创建告警通知处理器
+func NewNotificationHandler(notifications *service.NotificationService) *NotificationHandler {
+	return &NotificationHandler{
+		notifications: notifications,
+	}
+}
+
+// parseNotificationID 解析路径中的ID，失败时返回 "invalid <name> id"
+func parseNotificationID(c *gin.Context, name string) (int64, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid " + name + " id"})
+		return 0, false
+	}
+	return id, true
+}
+
+// ListChannels 通知渠道列表
+// GET /api/admin/notification-channels
+func (h *NotificationHandler) ListChannels(c *gin.Context) {
+	channels, err := h.notifications.ListChannels(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"items": channels, "total": len(channels)})
+}
+
+// CreateChannel 创建通知渠道
+// POST /api/admin/notification-channels
+func (h *NotificationHandler) CreateChannel(c *gin.Context) {
+	var req model.CreateNotificationChannelReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ch, err := h.notifications.CreateChannel(c.Request.Context(), &req, GetUserID(c))
+	if err != nil {
+		c.JSON(notificationErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, ch)
+}
+
+// GetChannel 获取通知渠道
+// GET /api/admin/notification-channels/:id
+func (h *NotificationHandler) GetChannel(c *gin.Context) {
+	id, ok := parseNotificationID(c, "channel")
+	if !ok {
+		return
+	}
+
+	ch, err := h.notifications.GetChannel(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(notificationErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, ch)
+}
+
+// UpdateChannel 更新通知渠道（名称、配置、启用状态）
+// PUT /api/admin/notification-channels/:id
+func (h *NotificationHandler) UpdateChannel(c *gin.Context) {
+	id, ok := parseNotificationID(c, "channel")
+	if !ok {
+		return
+	}
+
+	var req model.UpdateNotificationChannelReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ch, err := h.notifications.UpdateChannel(c.Request.Context(), id, &req, GetUserID(c))
+	if err != nil {
+		c.JSON(notificationErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, ch)
+}
+
+// TestChannel 通过渠道发送测试告警
+// POST /api/admin/notification-channels/:id/test
+func (h *NotificationHandler) TestChannel(c *gin.Context) {
+	id, ok := parseNotificationID(c, "channel")
+	if !ok {
+		return
+	}
+
+	if err := h.notifications.TestChannel(c.Request.Context(), id, GetUserID(c)); err != nil {
+		status := notificationErrorStatus(err)
+		if status == http.StatusInternalServerError {
+			// 渠道发送失败属于下游错误
+			status = http.StatusBadGateway
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "test notification sent"})
+}
+
+// ListRoutes 通知路由列表
+// GET /api/admin/notification-routes
+func (h *NotificationHandler) ListRoutes(c *gin.Context) {
+	var channelID int64
+	if v := c.Query("channel_id"); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid channel id"})
+			return
+		}
+		channelID = id
+	}
+
+	routes, err := h.notifications.ListRoutes(c.Request.Context(), channelID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"items": routes, "total": len(routes)})
+}
+
+// CreateRoute 创建通知路由
+// POST /api/admin/notification-routes
+func (h *NotificationHandler) CreateRoute(c *gin.Context) {
+	var req model.CreateNotificationRouteReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rt, err := h.notifications.CreateRoute(c.Request.Context(), &req, GetUserID(c))
+	if err != nil {
+		c.JSON(notificationErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, rt)
+}
+
+// UpdateRoute 更新通知路由
+// PUT /api/admin/notification-routes/:id
+func (h *NotificationHandler) UpdateRoute(c *gin.Context) {
+	id, ok := parseNotificationID(c, "route")
+	if !ok {
+		return
+	}
+
+	var req model.UpdateNotificationRouteReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rt, err := h.notifications.UpdateRoute(c.Request.Context(), id, &req, GetUserID(c))
+	if err != nil {
+		c.JSON(notificationErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, rt)
+}
+
+// DeleteRoute 删除通知路由
+// DELETE /api/admin/notification-routes/:id
+func (h *NotificationHandler) DeleteRoute(c *gin.Context) {
+	id, ok := parseNotificationID(c, "route")
+	if !ok {
+		return
+	}
+
+	if err := h.notifications.DeleteRoute(c.Request.Context(), id, GetUserID(c)); err != nil {
+		c.JSON(notificationErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "route deleted"})
+}
+
+// ListDeliveries 告警投递记录
+// GET /api/admin/alert-deliveries
+func (h *NotificationHandler) ListDeliveries(c *gin.Context) {
+	query := model.AlertDeliveryQuery{Page: 1, PageSize: 20}
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	deliveries, total, err := h.notifications.ListDeliveries(c.Request.Context(), &query)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"items": deliveries, "total": total})
+}
+
+// ListAlertDeliveries 单个告警的投递记录
+// GET /api/admin/alerts/:id/deliveries
+func (h *NotificationHandler) ListAlertDeliveries(c *gin.Context) {
+	id, ok := parseNotificationID(c, "alert")
+	if !ok {
+		return
+	}
+
+	query := model.AlertDeliveryQuery{AlertID: &id, Page: 1, PageSize: 100}
+	deliveries, total, err := h.notifications.ListDeliveries(c.Request.Context(), &query)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"items": deliveries, "total": total})
+}
+
+// RetryDelivery 手动重试失败的投递
+// POST /api/admin/alert-deliveries/:id/retry
+func (h *NotificationHandler) RetryDelivery(c *gin.Context) {
+	id, ok := parseNotificationID(c, "delivery")
+	if !ok {
+		return
+	}
+
+	d, err := h.notifications.RetryDelivery(c.Request.Context(), id, GetUserID(c))
+	if err != nil {
+		c.JSON(notificationErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, d)
+}
+
+// notificationErrorStatus 告警通知错误对应的 HTTP 状态码
+func notificationErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrNotificationChannelNotFound),
+		errors.Is(err, service.ErrNotificationRouteNotFound),
+		errors.Is(err, service.ErrAlertDeliveryNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrNotificationChannelNameTaken),
+		errors.Is(err, service.ErrAlertDeliveryNotFailed):
+		return http.StatusConflict
+	case errors.Is(err, service.ErrNotificationChannelInvalid):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,48 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/fiveseconds/server/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PracticeHandler 练习房游戏币处理器
+type PracticeHandler struct {
+	practiceService *service.PracticeService
+}
+
+// NewPracticeHandler 创建练习房游戏币处理器
+func NewPracticeHandler(practiceService *service.PracticeService) *PracticeHandler {
+	return &PracticeHandler{
+		practiceService: practiceService,
+	}
+}
+
+// GetMyChips 获取我的游戏币余额
+func (h *PracticeHandler) GetMyChips(c *gin.Context) {
+	info, err := h.practiceService.GetInfo(c.Request.Context(), GetUserID(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, info)
+}
+
+// RefillChips 补充游戏币到初始数量
+func (h *PracticeHandler) RefillChips(c *gin.Context) {
+	info, err := h.practiceService.Refill(c.Request.Context(), GetUserID(c))
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, service.ErrPracticeRefillNotNeeded) {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, info)
+}
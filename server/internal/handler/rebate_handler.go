@@ -0,0 +1,171 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/fiveseconds/server/internal/model"
+	"github.com/fiveseconds/server/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RebateHandler 玩家返水处理器
+type RebateHandler struct {
+	rebateService *service.RebateService
+}
+
+// NewRebateHandler 创建玩家返水处理器
+func NewRebateHandler(rebateService *service.RebateService) *RebateHandler {
+	return &RebateHandler{
+		rebateService: rebateService,
+	}
+}
+
+// GetRules 获取房主返水规则
+func (h *RebateHandler) GetRules(c *gin.Context) {
+	rules, err := h.rebateService.GetRules(c.Request.Context(), GetUserID(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, rules)
+}
+
+// UpdateRules 更新房主返水规则
+func (h *RebateHandler) UpdateRules(c *gin.Context) {
+	var req model.UpdateRebateRulesReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rules, err := h.rebateService.UpdateRules(c.Request.Context(), GetUserID(c), &req)
+	if err != nil {
+		c.JSON(rebateErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, rules)
+}
+
+// PreviewRebates 预览周期返水
+func (h *RebateHandler) PreviewRebates(c *gin.Context) {
+	var req model.RebatePeriodReq
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	preview, err := h.rebateService.Preview(c.Request.Context(), GetUserID(c), &req)
+	if err != nil {
+		c.JSON(rebateErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, preview)
+}
+
+// PayoutRebates 发放周期返水
+func (h *RebateHandler) PayoutRebates(c *gin.Context) {
+	var req model.RebatePeriodReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ownerID := GetUserID(c)
+	payout, err := h.rebateService.Payout(c.Request.Context(), ownerID, ownerID, &req)
+	if err != nil {
+		c.JSON(rebateErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, payout)
+}
+
+// ListOwnerPayouts 获取房主自己的返水发放记录
+func (h *RebateHandler) ListOwnerPayouts(c *gin.Context) {
+	ownerID := GetUserID(c)
+	query := bindRebatePayoutListQuery(c)
+	query.OwnerID = &ownerID
+
+	payouts, total, err := h.rebateService.ListPayouts(c.Request.Context(), query)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"items": payouts, "total": total})
+}
+
+// ListPayouts 管理员获取返水发放记录（可按 owner_id 过滤）
+func (h *RebateHandler) ListPayouts(c *gin.Context) {
+	query := bindRebatePayoutListQuery(c)
+	if v, ok := c.GetQuery("owner_id"); ok && v != "" {
+		ownerID, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid owner_id"})
+			return
+		}
+		query.OwnerID = &ownerID
+	}
+
+	payouts, total, err := h.rebateService.ListPayouts(c.Request.Context(), query)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"items": payouts, "total": total})
+}
+
+// GetPayout 获取返水发放详情（含玩家明细）
+func (h *RebateHandler) GetPayout(c *gin.Context) {
+	id, _ := strconv.ParseInt(c.Param("id"), 10, 64)
+
+	payout, err := h.rebateService.GetPayout(c.Request.Context(), id, GetUserID(c), GetRole(c))
+	if err != nil {
+		c.JSON(rebateErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, payout)
+}
+
+// rebateErrorStatus 返水错误对应的 HTTP 状态码
+func rebateErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrRebateNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrRebateForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, service.ErrRebateAlreadyPaid):
+		return http.StatusConflict
+	case errors.Is(err, service.ErrRebateInvalidBasis), errors.Is(err, service.ErrRebateInvalidTier),
+		errors.Is(err, service.ErrRebateInvalidPeriod), errors.Is(err, service.ErrRebatePeriodTooLong),
+		errors.Is(err, service.ErrRebatePeriodOpen), errors.Is(err, service.ErrRebateNoRules),
+		errors.Is(err, service.ErrRebateNothingToPay), errors.Is(err, service.ErrRebateInsufficientFunds):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// bindRebatePayoutListQuery 解析返水发放记录分页参数
+func bindRebatePayoutListQuery(c *gin.Context) *model.RebatePayoutListQuery {
+	query := &model.RebatePayoutListQuery{Page: 1, PageSize: 20}
+	if p, ok := c.GetQuery("page"); ok {
+		if v, err := parseInt(p); err == nil && v > 0 {
+			query.Page = v
+		}
+	}
+	if ps, ok := c.GetQuery("page_size"); ok {
+		if v, err := parseInt(ps); err == nil && v > 0 && v <= 100 {
+			query.PageSize = v
+		}
+	}
+	return query
+}
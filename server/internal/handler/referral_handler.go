@@ -0,0 +1,115 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/fiveseconds/server/internal/model"
+	"github.com/fiveseconds/server/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ReferralHandler 玩家推荐奖励处理器
+type ReferralHandler struct {
+	referralService *service.ReferralService
+}
+
+// NewReferralHandler 创建玩家推荐奖励处理器
+func NewReferralHandler(referralService *service.ReferralService) *ReferralHandler {
+	return &ReferralHandler{
+		referralService: referralService,
+	}
+}
+
+// GetMyReferrals 获取我的推荐面板（推荐码、被推荐人、奖励记录）
+func (h *ReferralHandler) GetMyReferrals(c *gin.Context) {
+	dashboard, err := h.referralService.GetDashboard(c.Request.Context(), GetUserID(c))
+	if err != nil {
+		c.JSON(referralErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, dashboard)
+}
+
+// ListOwnerRewards 获取房主名下的推荐奖励记录
+func (h *ReferralHandler) ListOwnerRewards(c *gin.Context) {
+	query, ok := bindReferralRewardListQuery(c)
+	if !ok {
+		return
+	}
+	ownerID := GetUserID(c)
+	query.OwnerID = &ownerID
+
+	rewards, total, err := h.referralService.ListRewards(c.Request.Context(), query)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"items": rewards, "total": total})
+}
+
+// ListRewards 管理员获取推荐奖励记录（可按 owner_id 过滤）
+func (h *ReferralHandler) ListRewards(c *gin.Context) {
+	query, ok := bindReferralRewardListQuery(c)
+	if !ok {
+		return
+	}
+	if v, exists := c.GetQuery("owner_id"); exists && v != "" {
+		ownerID, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid owner_id"})
+			return
+		}
+		query.OwnerID = &ownerID
+	}
+
+	rewards, total, err := h.referralService.ListRewards(c.Request.Context(), query)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"items": rewards, "total": total})
+}
+
+// referralErrorStatus 推荐奖励错误对应的 HTTP 状态码
+func referralErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrReferralNotPlayer):
+		return http.StatusForbidden
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// bindReferralRewardListQuery 解析推荐奖励记录查询参数（referrer_id、status、分页）
+func bindReferralRewardListQuery(c *gin.Context) (*model.ReferralRewardListQuery, bool) {
+	query := &model.ReferralRewardListQuery{Page: 1, PageSize: 20}
+	if p, ok := c.GetQuery("page"); ok {
+		if v, err := parseInt(p); err == nil && v > 0 {
+			query.Page = v
+		}
+	}
+	if ps, ok := c.GetQuery("page_size"); ok {
+		if v, err := parseInt(ps); err == nil && v > 0 && v <= 100 {
+			query.PageSize = v
+		}
+	}
+	if v, ok := c.GetQuery("referrer_id"); ok && v != "" {
+		referrerID, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid referrer_id"})
+			return nil, false
+		}
+		query.ReferrerID = &referrerID
+	}
+	if v, ok := c.GetQuery("status"); ok && v != "" {
+		status := model.ReferralRewardStatus(v)
+		query.Status = &status
+	}
+	return query, true
+}
@@ -0,0 +1,143 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/fiveseconds/server/internal/model"
+	"github.com/fiveseconds/server/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RestrictionHandler 账户限制处理器
+type RestrictionHandler struct {
+	restrictions *service.RestrictionService
+}
+
+// NewRestrictionHandler 创建账户限制处理器
+func NewRestrictionHandler(restrictions *service.RestrictionService) *RestrictionHandler {
+	return &RestrictionHandler{
+		restrictions: restrictions,
+	}
+}
+
+// GetMyRestrictions 获取当前用户生效中的限制（含原因代码与到期时间）
+// GET /api/restrictions
+func (h *RestrictionHandler) GetMyRestrictions(c *gin.Context) {
+	restrictions, err := h.restrictions.ListActive(c.Request.Context(), GetUserID(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	items := make([]gin.H, 0, len(restrictions))
+	for _, r := range restrictions {
+		items = append(items, gin.H{
+			"type":        r.Type,
+			"reason_code": r.Reason,
+			"expires_at":  r.ExpiresAt,
+		})
+	}
+	c.JSON(http.StatusOK, gin.H{"items": items, "total": len(items)})
+}
+
+// ListUserRestrictions 获取用户的全部限制
+// GET /api/admin/users/:id/restrictions
+func (h *RestrictionHandler) ListUserRestrictions(c *gin.Context) {
+	userID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	restrictions, err := h.restrictions.ListByUser(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"items": restrictions, "total": len(restrictions)})
+}
+
+// ApplyRestriction 对用户施加限制
+// POST /api/admin/users/:id/restrictions
+func (h *RestrictionHandler) ApplyRestriction(c *gin.Context) {
+	userID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	var req model.ApplyRestrictionReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	restrictions, err := h.restrictions.ApplyManual(c.Request.Context(), userID, &req, GetUserID(c))
+	if err != nil {
+		c.JSON(restrictionErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"items": restrictions, "total": len(restrictions)})
+}
+
+// RevokeRestriction 解除限制
+// POST /api/admin/restrictions/:id/revoke
+func (h *RestrictionHandler) RevokeRestriction(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid restriction id"})
+		return
+	}
+
+	var req model.RevokeRestrictionReq
+	_ = c.ShouldBindJSON(&req) // 可选参数
+
+	restriction, err := h.restrictions.Revoke(c.Request.Context(), id, GetUserID(c), req.Remark)
+	if err != nil {
+		c.JSON(restrictionErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, restriction)
+}
+
+// ListRestrictionAudit 获取用户的限制审计记录
+// GET /api/admin/users/:id/restriction-audit
+func (h *RestrictionHandler) ListRestrictionAudit(c *gin.Context) {
+	userID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	query := model.RestrictionAuditQuery{Page: 1, PageSize: 20}
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	entries, total, err := h.restrictions.ListAudit(c.Request.Context(), userID, &query)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"items": entries, "total": total})
+}
+
+// restrictionErrorStatus 账户限制错误对应的 HTTP 状态码
+func restrictionErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrRestrictionNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrInvalidRestrictionType):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,247 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/fiveseconds/server/internal/model"
+	"github.com/fiveseconds/server/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RiskCaseHandler 风控案件处理器
+type RiskCaseHandler struct {
+	cases *service.RiskCaseService
+}
+
+// NewRiskCaseHandler 创建风控案件处理器
+func NewRiskCaseHandler(cases *service.RiskCaseService) *RiskCaseHandler {
+	return &RiskCaseHandler{
+		cases: cases,
+	}
+}
+
+// parseCaseID 解析路径中的案件ID
+func parseCaseID(c *gin.Context) (int64, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid case id"})
+		return 0, false
+	}
+	return id, true
+}
+
+// ListCases 案件列表
+// GET /api/admin/risk-cases
+func (h *RiskCaseHandler) ListCases(c *gin.Context) {
+	query := model.RiskCaseListQuery{Page: 1, PageSize: 20}
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	cases, total, err := h.cases.List(c.Request.Context(), &query)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"items": cases, "total": total})
+}
+
+// CreateCase 由风控标记创建案件
+// POST /api/admin/risk-cases
+func (h *RiskCaseHandler) CreateCase(c *gin.Context) {
+	var req model.CreateRiskCaseReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rc, err := h.cases.Create(c.Request.Context(), &req, GetUserID(c))
+	if err != nil {
+		c.JSON(riskCaseErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, rc)
+}
+
+// GetCase 获取案件详情（标记、备注、证据）
+// GET /api/admin/risk-cases/:id
+func (h *RiskCaseHandler) GetCase(c *gin.Context) {
+	id, ok := parseCaseID(c)
+	if !ok {
+		return
+	}
+
+	detail, err := h.cases.Get(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(riskCaseErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, detail)
+}
+
+// AddFlags 向案件追加风控标记
+// POST /api/admin/risk-cases/:id/flags
+func (h *RiskCaseHandler) AddFlags(c *gin.Context) {
+	id, ok := parseCaseID(c)
+	if !ok {
+		return
+	}
+
+	var req model.AddRiskCaseFlagsReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rc, err := h.cases.AddFlags(c.Request.Context(), id, &req, GetUserID(c))
+	if err != nil {
+		c.JSON(riskCaseErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, rc)
+}
+
+// AssignCase 指派案件处理人
+// PUT /api/admin/risk-cases/:id/assignee
+func (h *RiskCaseHandler) AssignCase(c *gin.Context) {
+	id, ok := parseCaseID(c)
+	if !ok {
+		return
+	}
+
+	var req model.AssignRiskCaseReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rc, err := h.cases.Assign(c.Request.Context(), id, &req, GetUserID(c))
+	if err != nil {
+		c.JSON(riskCaseErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, rc)
+}
+
+// UpdateCaseStatus 变更案件状态
+// PUT /api/admin/risk-cases/:id/status
+func (h *RiskCaseHandler) UpdateCaseStatus(c *gin.Context) {
+	id, ok := parseCaseID(c)
+	if !ok {
+		return
+	}
+
+	var req model.UpdateRiskCaseStatusReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rc, err := h.cases.UpdateStatus(c.Request.Context(), id, &req, GetUserID(c))
+	if err != nil {
+		c.JSON(riskCaseErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, rc)
+}
+
+// AddNote 添加案件备注
+// POST /api/admin/risk-cases/:id/notes
+func (h *RiskCaseHandler) AddNote(c *gin.Context) {
+	id, ok := parseCaseID(c)
+	if !ok {
+		return
+	}
+
+	var req model.AddRiskCaseNoteReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	note, err := h.cases.AddNote(c.Request.Context(), id, &req, GetUserID(c))
+	if err != nil {
+		c.JSON(riskCaseErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, note)
+}
+
+// AddEvidence 添加案件证据（回合、资金流水、登录事件）
+// POST /api/admin/risk-cases/:id/evidence
+func (h *RiskCaseHandler) AddEvidence(c *gin.Context) {
+	id, ok := parseCaseID(c)
+	if !ok {
+		return
+	}
+
+	var req model.AddRiskCaseEvidenceReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ev, err := h.cases.AddEvidence(c.Request.Context(), id, &req, GetUserID(c))
+	if err != nil {
+		c.JSON(riskCaseErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, ev)
+}
+
+// ListCaseAudit 获取案件审计记录
+// GET /api/admin/risk-cases/:id/audit
+func (h *RiskCaseHandler) ListCaseAudit(c *gin.Context) {
+	id, ok := parseCaseID(c)
+	if !ok {
+		return
+	}
+
+	query := model.RiskCaseAuditQuery{Page: 1, PageSize: 20}
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	entries, total, err := h.cases.ListAudit(c.Request.Context(), id, &query)
+	if err != nil {
+		c.JSON(riskCaseErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"items": entries, "total": total})
+}
+
+// riskCaseErrorStatus 风控案件错误对应的 HTTP 状态码
+func riskCaseErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrRiskCaseNotFound),
+		errors.Is(err, service.ErrRiskCaseFlagNotFound),
+		errors.Is(err, service.ErrRiskCaseNoteNotFound),
+		errors.Is(err, service.ErrRiskCaseEvidenceNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrRiskCaseFlagLinked),
+		errors.Is(err, service.ErrRiskCaseResolved),
+		errors.Is(err, service.ErrRiskCaseInvalidTransition),
+		errors.Is(err, service.ErrRiskCaseEvidenceAttached),
+		errors.Is(err, service.ErrRiskCaseConflict):
+		return http.StatusConflict
+	case errors.Is(err, service.ErrRiskCaseResolutionRequired),
+		errors.Is(err, service.ErrRiskCaseInvalidAssignee):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
@@ -94,10 +95,15 @@ func (h *RiskHandler) ReviewRiskFlag(c *gin.Context) {
 
 	userID := c.GetInt64("user_id")
 
-	if err := h.riskService.ReviewFlag(c.Request.Context(), id, req.Action, userID); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	restrictions, err := h.riskService.ReviewFlag(c.Request.Context(), id, &req, userID)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, service.ErrRestrictionRequiresConfirm) || errors.Is(err, service.ErrRestrictionUnavailable) {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "flag reviewed"})
+	c.JSON(http.StatusOK, gin.H{"message": "flag reviewed", "restrictions": restrictions})
 }
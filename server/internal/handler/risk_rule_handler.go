@@ -0,0 +1,182 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/fiveseconds/server/internal/model"
+	"github.com/fiveseconds/server/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RiskRuleHandler 风控规则处理器
+type RiskRuleHandler struct {
+	ruleService *service.RiskRuleService
+}
+
+// NewRiskRuleHandler 创建风控规则处理器
+func NewRiskRuleHandler(ruleService *service.RiskRuleService) *RiskRuleHandler {
+	return &RiskRuleHandler{
+		ruleService: ruleService,
+	}
+}
+
+// ListRiskRules 获取风控规则列表
+// GET /api/admin/risk-rules
+func (h *RiskRuleHandler) ListRiskRules(c *gin.Context) {
+	query := model.RiskRuleListQuery{Page: 1, PageSize: 20}
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rules, total, err := h.ruleService.List(c.Request.Context(), &query)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"items": rules, "total": total})
+}
+
+// ListRiskRuleVariables 获取各触发事件可引用的变量
+// GET /api/admin/risk-rules/variables
+func (h *RiskRuleHandler) ListRiskRuleVariables(c *gin.Context) {
+	c.JSON(http.StatusOK, h.ruleService.Variables())
+}
+
+// CreateRiskRule 创建风控规则
+// POST /api/admin/risk-rules
+func (h *RiskRuleHandler) CreateRiskRule(c *gin.Context) {
+	var req model.SaveRiskRuleReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rule, err := h.ruleService.Create(c.Request.Context(), GetUserID(c), &req)
+	if err != nil {
+		c.JSON(riskRuleErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, rule)
+}
+
+// GetRiskRule 获取风控规则
+// GET /api/admin/risk-rules/:id
+func (h *RiskRuleHandler) GetRiskRule(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid rule id"})
+		return
+	}
+
+	rule, err := h.ruleService.Get(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(riskRuleErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, rule)
+}
+
+// UpdateRiskRule 修改风控规则（生成新版本）
+// PUT /api/admin/risk-rules/:id
+func (h *RiskRuleHandler) UpdateRiskRule(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid rule id"})
+		return
+	}
+
+	var req model.SaveRiskRuleReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rule, err := h.ruleService.Update(c.Request.Context(), id, GetUserID(c), &req)
+	if err != nil {
+		c.JSON(riskRuleErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, rule)
+}
+
+// ListRiskRuleVersions 获取风控规则的历史版本
+// GET /api/admin/risk-rules/:id/versions
+func (h *RiskRuleHandler) ListRiskRuleVersions(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid rule id"})
+		return
+	}
+
+	versions, err := h.ruleService.ListVersions(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(riskRuleErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"items": versions, "total": len(versions)})
+}
+
+// RestoreRiskRuleVersion 将风控规则恢复为指定历史版本（作为新版本保存）
+// POST /api/admin/risk-rules/:id/versions/:version/restore
+func (h *RiskRuleHandler) RestoreRiskRuleVersion(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid rule id"})
+		return
+	}
+	version, err := parseInt(c.Param("version"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid version"})
+		return
+	}
+
+	rule, err := h.ruleService.Restore(c.Request.Context(), id, version, GetUserID(c))
+	if err != nil {
+		c.JSON(riskRuleErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, rule)
+}
+
+// DryRunRiskRule 试运行风控规则：重放最近 N 天的事件，返回规则会命中的情况
+// POST /api/admin/risk-rules/dry-run
+func (h *RiskRuleHandler) DryRunRiskRule(c *gin.Context) {
+	var req model.RiskRuleDryRunReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.ruleService.DryRun(c.Request.Context(), &req)
+	if err != nil {
+		c.JSON(riskRuleErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// riskRuleErrorStatus 风控规则错误对应的 HTTP 状态码
+func riskRuleErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrRiskRuleNotFound), errors.Is(err, service.ErrRiskRuleVersionNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrRiskRuleNameTaken):
+		return http.StatusConflict
+	case errors.Is(err, service.ErrRiskRuleInvalidEvent), errors.Is(err, service.ErrRiskRuleInvalidCondition),
+		errors.Is(err, service.ErrRiskRuleDryRunDays):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,46 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/fiveseconds/server/internal/repository"
+	"github.com/fiveseconds/server/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RiskScoreHandler 用户风险评分处理器
+type RiskScoreHandler struct {
+	riskScore *service.RiskScoreService
+}
+
+// NewRiskScoreHandler 创建用户风险评分处理器
+func NewRiskScoreHandler(riskScore *service.RiskScoreService) *RiskScoreHandler {
+	return &RiskScoreHandler{
+		riskScore: riskScore,
+	}
+}
+
+// GetUserRiskScore 获取用户当前风险评分及评分历史
+// GET /api/admin/users/:id/risk-score
+func (h *RiskScoreHandler) GetUserRiskScore(c *gin.Context) {
+	userID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	score, history, err := h.riskScore.Get(c.Request.Context(), userID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"score": score, "history": history})
+}
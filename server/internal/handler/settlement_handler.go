@@ -0,0 +1,145 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/fiveseconds/server/internal/model"
+	"github.com/fiveseconds/server/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SettlementHandler 房主佣金结算处理器
+type SettlementHandler struct {
+	settlementService *service.SettlementService
+}
+
+// NewSettlementHandler 创建房主佣金结算处理器
+func NewSettlementHandler(settlementService *service.SettlementService) *SettlementHandler {
+	return &SettlementHandler{
+		settlementService: settlementService,
+	}
+}
+
+// RunSettlementReq 手动补结请求
+type RunSettlementReq struct {
+	PeriodType model.SettlementPeriodType `json:"period_type" binding:"required"`
+	Date       string                     `json:"date" binding:"required"` // 周期内任意日期 YYYY-MM-DD
+}
+
+// ListOwnerSettlements 获取房主自己的结算单
+func (h *SettlementHandler) ListOwnerSettlements(c *gin.Context) {
+	ownerID := GetUserID(c)
+	query := bindSettlementListQuery(c)
+	query.OwnerID = &ownerID
+
+	settlements, total, err := h.settlementService.ListSettlements(c.Request.Context(), query)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"items": settlements, "total": total})
+}
+
+// ListSettlements 管理员获取结算单（可按 owner_id 过滤）
+func (h *SettlementHandler) ListSettlements(c *gin.Context) {
+	query := bindSettlementListQuery(c)
+	if v, ok := c.GetQuery("owner_id"); ok && v != "" {
+		ownerID, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid owner_id"})
+			return
+		}
+		query.OwnerID = &ownerID
+	}
+
+	settlements, total, err := h.settlementService.ListSettlements(c.Request.Context(), query)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"items": settlements, "total": total})
+}
+
+// GetSettlement 获取结算单详情（含按房间明细）
+func (h *SettlementHandler) GetSettlement(c *gin.Context) {
+	id, _ := strconv.ParseInt(c.Param("id"), 10, 64)
+
+	settlement, err := h.settlementService.GetSettlement(c.Request.Context(), id, GetUserID(c), GetRole(c))
+	if err != nil {
+		c.JSON(settlementErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, settlement)
+}
+
+// ReconcileSettlement 结算单与回合数据对账
+func (h *SettlementHandler) ReconcileSettlement(c *gin.Context) {
+	id, _ := strconv.ParseInt(c.Param("id"), 10, 64)
+
+	result, err := h.settlementService.ReconcileSettlement(c.Request.Context(), id, GetUserID(c), GetRole(c))
+	if err != nil {
+		c.JSON(settlementErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// RunSettlement 管理员手动补结指定周期
+func (h *SettlementHandler) RunSettlement(c *gin.Context) {
+	var req RunSettlementReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	date, err := time.ParseInLocation("2006-01-02", req.Date, time.Local)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid date, use YYYY-MM-DD"})
+		return
+	}
+
+	created, err := h.settlementService.SettlePeriodOf(c.Request.Context(), req.PeriodType, date)
+	if err != nil {
+		c.JSON(settlementErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"created": created})
+}
+
+// settlementErrorStatus 结算错误对应的 HTTP 状态码
+func settlementErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrSettlementNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrSettlementForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, service.ErrSettlementInvalidPeriod), errors.Is(err, service.ErrSettlementPeriodOpen):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// bindSettlementListQuery 解析结算单列表分页参数
+func bindSettlementListQuery(c *gin.Context) *model.SettlementListQuery {
+	query := &model.SettlementListQuery{Page: 1, PageSize: 20}
+	if p, ok := c.GetQuery("page"); ok {
+		if v, err := parseInt(p); err == nil && v > 0 {
+			query.Page = v
+		}
+	}
+	if ps, ok := c.GetQuery("page_size"); ok {
+		if v, err := parseInt(ps); err == nil && v > 0 && v <= 100 {
+			query.PageSize = v
+		}
+	}
+	return query
+}
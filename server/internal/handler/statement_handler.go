@@ -0,0 +1,133 @@
+package handler
+
+import (
+	"errors"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"github.com/fiveseconds/server/internal/model"
+	"github.com/fiveseconds/server/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// StatementHandler 账单处理器
+type StatementHandler struct {
+	statementService *service.StatementService
+}
+
+// NewStatementHandler 创建账单处理器
+func NewStatementHandler(statementService *service.StatementService) *StatementHandler {
+	return &StatementHandler{
+		statementService: statementService,
+	}
+}
+
+// DownloadStatement 直接下载账单
+// GET /api/statements/download?format=csv|pdf&month=2025-01 或 &start_date=2025-01-01&end_date=2025-01-31
+func (h *StatementHandler) DownloadStatement(c *gin.Context) {
+	var query model.StatementPeriodQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	data, filename, err := h.statementService.RenderStatement(c.Request.Context(), GetUserID(c), &query)
+	if err != nil {
+		c.JSON(statementErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Content-Disposition", attachmentDisposition(filename))
+	c.Data(http.StatusOK, statementContentType(query.Format), data)
+}
+
+// CreateStatementJob 创建异步账单导出任务
+func (h *StatementHandler) CreateStatementJob(c *gin.Context) {
+	var req model.StatementPeriodQuery
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	job, err := h.statementService.CreateJob(c.Request.Context(), GetUserID(c), &req)
+	if err != nil {
+		c.JSON(statementErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, job)
+}
+
+// ListStatementJobs 获取我的账单导出任务
+func (h *StatementHandler) ListStatementJobs(c *gin.Context) {
+	jobs, err := h.statementService.ListJobs(c.Request.Context(), GetUserID(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"items": jobs, "total": len(jobs)})
+}
+
+// GetStatementJob 获取账单导出任务状态
+func (h *StatementHandler) GetStatementJob(c *gin.Context) {
+	jobID, _ := strconv.ParseInt(c.Param("id"), 10, 64)
+
+	job, err := h.statementService.GetJob(c.Request.Context(), GetUserID(c), jobID)
+	if err != nil {
+		c.JSON(statementErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, job)
+}
+
+// DownloadStatementJob 下载已完成的导出文件
+func (h *StatementHandler) DownloadStatementJob(c *gin.Context) {
+	jobID, _ := strconv.ParseInt(c.Param("id"), 10, 64)
+
+	path, filename, err := h.statementService.GetJobFile(c.Request.Context(), GetUserID(c), jobID)
+	if err != nil {
+		c.JSON(statementErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.FileAttachment(path, filename)
+}
+
+// statementErrorStatus 账单错误对应的 HTTP 状态码
+func statementErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrStatementJobNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrStatementJobNotReady):
+		return http.StatusConflict
+	case errors.Is(err, service.ErrStatementTooLarge):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(err, service.ErrStatementInvalidFormat),
+		errors.Is(err, service.ErrStatementInvalidPeriod),
+		errors.Is(err, service.ErrStatementPeriodTooLong):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// statementContentType 导出格式对应的 Content-Type
+func statementContentType(format model.StatementFormat) string {
+	if format == model.StatementFormatPDF {
+		return "application/pdf"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+// attachmentDisposition 生成附件下载的 Content-Disposition
+// 文件名含非 ASCII 字符时按 RFC 2231/5987 编码为 filename*，引号与控制字符不会破坏响应头
+func attachmentDisposition(filename string) string {
+	if v := mime.FormatMediaType("attachment", map[string]string{"filename": filename}); v != "" {
+		return v
+	}
+	return "attachment"
+}
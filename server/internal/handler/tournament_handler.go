@@ -0,0 +1,178 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/fiveseconds/server/internal/model"
+	"github.com/fiveseconds/server/internal/repository"
+	"github.com/fiveseconds/server/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TournamentHandler 锦标赛处理器
+type TournamentHandler struct {
+	tournamentService *service.TournamentService
+}
+
+// NewTournamentHandler 创建锦标赛处理器
+func NewTournamentHandler(tournamentService *service.TournamentService) *TournamentHandler {
+	return &TournamentHandler{
+		tournamentService: tournamentService,
+	}
+}
+
+// CreateTournament 房主创建锦标赛
+func (h *TournamentHandler) CreateTournament(c *gin.Context) {
+	var req model.CreateTournamentReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tournament, err := h.tournamentService.Create(c.Request.Context(), GetUserID(c), &req)
+	if err != nil {
+		c.JSON(tournamentErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, tournament)
+}
+
+// ListOwnerTournaments 获取房主自己的锦标赛
+func (h *TournamentHandler) ListOwnerTournaments(c *gin.Context) {
+	ownerID := GetUserID(c)
+	query := bindTournamentListQuery(c)
+	query.OwnerID = &ownerID
+
+	tournaments, total, err := h.tournamentService.List(c.Request.Context(), query)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"items": tournaments, "total": total})
+}
+
+// CancelOwnerTournament 房主取消锦标赛（全额退回报名费）
+func (h *TournamentHandler) CancelOwnerTournament(c *gin.Context) {
+	id, _ := strconv.ParseInt(c.Param("id"), 10, 64)
+	ownerID := GetUserID(c)
+
+	if err := h.tournamentService.Cancel(c.Request.Context(), id, &ownerID); err != nil {
+		c.JSON(tournamentErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "cancelled"})
+}
+
+// ListTournaments 管理员获取锦标赛（可按 owner_id 过滤）
+func (h *TournamentHandler) ListTournaments(c *gin.Context) {
+	query := bindTournamentListQuery(c)
+	if v, ok := c.GetQuery("owner_id"); ok && v != "" {
+		ownerID, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid owner_id"})
+			return
+		}
+		query.OwnerID = &ownerID
+	}
+
+	tournaments, total, err := h.tournamentService.List(c.Request.Context(), query)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"items": tournaments, "total": total})
+}
+
+// CancelTournament 管理员取消锦标赛（全额退回报名费）
+func (h *TournamentHandler) CancelTournament(c *gin.Context) {
+	id, _ := strconv.ParseInt(c.Param("id"), 10, 64)
+
+	if err := h.tournamentService.Cancel(c.Request.Context(), id, nil); err != nil {
+		c.JSON(tournamentErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "cancelled"})
+}
+
+// ListMyTournaments 玩家获取所属房主的锦标赛
+func (h *TournamentHandler) ListMyTournaments(c *gin.Context) {
+	tournaments, total, err := h.tournamentService.ListForPlayer(c.Request.Context(), GetUserID(c), bindTournamentListQuery(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"items": tournaments, "total": total})
+}
+
+// GetTournament 获取锦标赛详情（含实时排名与房间）
+func (h *TournamentHandler) GetTournament(c *gin.Context) {
+	id, _ := strconv.ParseInt(c.Param("id"), 10, 64)
+
+	detail, err := h.tournamentService.GetDetail(c.Request.Context(), id, GetUserID(c), GetRole(c))
+	if err != nil {
+		c.JSON(tournamentErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, detail)
+}
+
+// Register 玩家报名锦标赛（扣除报名费）
+func (h *TournamentHandler) Register(c *gin.Context) {
+	id, _ := strconv.ParseInt(c.Param("id"), 10, 64)
+
+	entry, err := h.tournamentService.Register(c.Request.Context(), GetUserID(c), id)
+	if err != nil {
+		c.JSON(tournamentErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, entry)
+}
+
+// tournamentErrorStatus 锦标赛错误对应的 HTTP 状态码
+func tournamentErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrTournamentNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrTournamentForbidden), errors.Is(err, service.ErrTournamentNotInvited):
+		return http.StatusForbidden
+	case errors.Is(err, service.ErrTournamentAlreadyRegistered), errors.Is(err, service.ErrBalanceBusy):
+		return http.StatusConflict
+	case errors.Is(err, service.ErrTournamentInvalidParams), errors.Is(err, service.ErrTournamentNoLimit),
+		errors.Is(err, service.ErrTournamentInvalidPayouts), errors.Is(err, service.ErrTournamentClosed),
+		errors.Is(err, service.ErrTournamentNotOpen), errors.Is(err, repository.ErrInsufficientBalance):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// bindTournamentListQuery 解析锦标赛列表分页与状态参数
+func bindTournamentListQuery(c *gin.Context) *model.TournamentListQuery {
+	query := &model.TournamentListQuery{Page: 1, PageSize: 20}
+	if p, ok := c.GetQuery("page"); ok {
+		if v, err := parseInt(p); err == nil && v > 0 {
+			query.Page = v
+		}
+	}
+	if ps, ok := c.GetQuery("page_size"); ok {
+		if v, err := parseInt(ps); err == nil && v > 0 && v <= 100 {
+			query.PageSize = v
+		}
+	}
+	if s, ok := c.GetQuery("status"); ok && s != "" {
+		status := model.TournamentStatus(s)
+		query.Status = &status
+	}
+	return query
+}
@@ -0,0 +1,154 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/fiveseconds/server/internal/model"
+	"github.com/fiveseconds/server/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TransferHandler 玩家转账处理器
+type TransferHandler struct {
+	transferService *service.TransferService
+}
+
+// NewTransferHandler 创建玩家转账处理器
+func NewTransferHandler(transferService *service.TransferService) *TransferHandler {
+	return &TransferHandler{
+		transferService: transferService,
+	}
+}
+
+// CreateTransfer 发起转账
+func (h *TransferHandler) CreateTransfer(c *gin.Context) {
+	var req model.CreateTransferReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	transfer, err := h.transferService.CreateTransfer(c.Request.Context(), GetUserID(c), &req)
+	if err != nil {
+		var restricted *service.RestrictedError
+		if errors.As(err, &restricted) {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error":       err.Error(),
+				"reason_code": restricted.Restriction.Reason,
+				"expires_at":  restricted.Restriction.ExpiresAt,
+			})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, transfer)
+}
+
+// ListMyTransfers 获取我的转账记录（转出和转入）
+func (h *TransferHandler) ListMyTransfers(c *gin.Context) {
+	userID := GetUserID(c)
+	query := bindTransferListQuery(c)
+	query.UserID = &userID
+
+	transfers, total, err := h.transferService.ListTransfers(c.Request.Context(), query)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"items": transfers, "total": total})
+}
+
+// ListOwnerTransfers 获取房主名下玩家的转账记录
+func (h *TransferHandler) ListOwnerTransfers(c *gin.Context) {
+	ownerID := GetUserID(c)
+	query := bindTransferListQuery(c)
+	query.OwnerID = &ownerID
+
+	transfers, total, err := h.transferService.ListTransfers(c.Request.Context(), query)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"items": transfers, "total": total})
+}
+
+// ProcessTransfer 房主审批转账
+func (h *TransferHandler) ProcessTransfer(c *gin.Context) {
+	transferID, _ := strconv.ParseInt(c.Param("id"), 10, 64)
+	var req model.ProcessTransferReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err := h.transferService.ProcessTransfer(c.Request.Context(), transferID, GetUserID(c), GetRole(c), &req)
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, service.ErrTransferForbidden) || errors.Is(err, service.ErrAccountRestricted) {
+			status = http.StatusForbidden
+		} else if errors.Is(err, service.ErrTransferNotFound) {
+			status = http.StatusNotFound
+		} else if errors.Is(err, service.ErrBalanceBusy) {
+			status = http.StatusConflict
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "processed"})
+}
+
+// GetTransferSettings 获取房主转账设置
+func (h *TransferHandler) GetTransferSettings(c *gin.Context) {
+	settings, err := h.transferService.GetSettings(c.Request.Context(), GetUserID(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, settings)
+}
+
+// UpdateTransferSettings 更新房主转账设置
+func (h *TransferHandler) UpdateTransferSettings(c *gin.Context) {
+	var req model.UpdateTransferSettingsReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	settings, err := h.transferService.UpdateSettings(c.Request.Context(), GetUserID(c), &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, settings)
+}
+
+// bindTransferListQuery 解析转账列表查询参数
+func bindTransferListQuery(c *gin.Context) *model.TransferListQuery {
+	query := &model.TransferListQuery{Page: 1, PageSize: 20}
+	if p, ok := c.GetQuery("page"); ok {
+		if v, err := parseInt(p); err == nil && v > 0 {
+			query.Page = v
+		}
+	}
+	if ps, ok := c.GetQuery("page_size"); ok {
+		if v, err := parseInt(ps); err == nil && v > 0 && v <= 100 {
+			query.PageSize = v
+		}
+	}
+	if st, ok := c.GetQuery("status"); ok && st != "" {
+		status := model.TransferStatus(st)
+		query.Status = &status
+	}
+	return query
+}
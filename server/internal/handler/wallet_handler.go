@@ -1,9 +1,11 @@
 package handler
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 
+	"github.com/fiveseconds/server/internal/model"
 	"github.com/fiveseconds/server/internal/service"
 
 	"github.com/gin-gonic/gin"
@@ -109,6 +111,45 @@ func (h *WalletHandler) TransferEarnings(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "transfer successful"})
 }
 
+// ListCurrencyWallets 获取全部币种钱包及可切换的币种
+func (h *WalletHandler) ListCurrencyWallets(c *gin.Context) {
+	wallets, err := h.walletService.ListWallets(c.Request.Context(), GetUserID(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"items":      wallets,
+		"total":      len(wallets),
+		"currencies": service.SupportedCurrencies(),
+	})
+}
+
+// SwitchCurrency 切换活跃钱包币种
+func (h *WalletHandler) SwitchCurrency(c *gin.Context) {
+	var req model.SwitchCurrencyReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	wallets, err := h.walletService.SwitchCurrency(c.Request.Context(), GetUserID(c), req.Currency)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrCurrencySwitchNotAllowed):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrCurrencySwitchBusy):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"items": wallets, "total": len(wallets)})
+}
+
 // parseInt 解析整数
 func parseInt(s string) (int, error) {
 	if s == "" {
@@ -3,6 +3,7 @@ package handler
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"sync"
 	"time"
@@ -336,6 +337,12 @@ func (c *wsClient) handleJoinRoom(payload interface{}) {
 			return
 		}
 
+		// 检查参与资格：活跃币种与房间币种一致（练习房不限），锦标赛房间须已报名（不满足时可观战）
+		if err := processor.CheckParticipant(context.Background(), user); err != nil {
+			c.sendError(400, err.Error())
+			return
+		}
+
 		// 先添加到数据库
 		if c.roomPlayerManager != nil {
 			rp := &model.RoomPlayer{
@@ -542,6 +549,8 @@ func (c *wsClient) handleSwitchToParticipant() {
 			c.sendError(400, "not a spectator")
 		case game.ErrRoomFull:
 			c.sendError(400, "room is full")
+		case game.ErrCurrencyMismatch, game.ErrNotTournamentEntrant:
+			c.sendError(400, err.Error())
 		default:
 			c.sendError(500, err.Error())
 		}
@@ -585,6 +594,11 @@ func (c *wsClient) handleSendChat(payload interface{}) {
 	// 发送消息
 	msg, err := c.chatService.SendMessage(context.Background(), c.roomID, c.userID, c.username, req.Content)
 	if err != nil {
+		var restricted *service.RestrictedError
+		if errors.As(err, &restricted) {
+			c.sendError(5007, restricted.Error())
+			return
+		}
 		switch err.Error() {
 		case "chat rate limited":
 			c.sendError(5005, "chat rate limit exceeded")
@@ -0,0 +1,73 @@
+// Package integration_test 日终余额快照集成测试
+package integration_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/fiveseconds/server/internal/model"
+	"github.com/fiveseconds/server/internal/repository"
+
+	"github.com/shopspring/decimal"
+)
+
+// TestBackfilledSnapshotRebuildsBalancesAndLeavesFrozenUnknown 测试补采快照按日终时刻回推余额，冻结余额记为未知
+func TestBackfilledSnapshotRebuildsBalancesAndLeavesFrozenUnknown(t *testing.T) {
+	requireTestDB(t)
+	ctx := context.Background()
+	repo := repository.NewBalanceSnapshotRepo()
+
+	userID := createTestUser(t, "player", nil, decimal.NewFromInt(100))
+	if _, err := repository.DB.Exec(ctx,
+		`UPDATE users SET created_at = '2000-01-01', frozen_balance = 5 WHERE id = $1`, userID); err != nil {
+		t.Fatalf("prepare user: %v", err)
+	}
+	// 日终之后入账 40，日终时刻余额为 60
+	if err := repository.NewTransactionRepo().CreateTx(ctx, nil, &model.BalanceTransaction{
+		UserID:        userID,
+		Type:          model.TxDeposit,
+		Amount:        decimal.NewFromInt(40),
+		BalanceBefore: decimal.NewFromInt(60),
+		BalanceAfter:  decimal.NewFromInt(100),
+	}); err != nil {
+		t.Fatalf("create transaction: %v", err)
+	}
+
+	backfilled := time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC)
+	onTime := backfilled.AddDate(0, 0, 1)
+	if _, err := repo.CaptureDay(ctx, backfilled, backfilled.AddDate(0, 0, 1), false); err != nil {
+		t.Fatalf("capture backfilled day: %v", err)
+	}
+	if _, err := repo.CaptureDay(ctx, onTime, onTime.AddDate(0, 0, 1), true); err != nil {
+		t.Fatalf("capture on-time day: %v", err)
+	}
+
+	snapshots, _, err := repo.ListByUser(ctx, &model.BalanceHistoryQuery{UserID: userID, Page: 1, PageSize: 10})
+	if err != nil {
+		t.Fatalf("list snapshots: %v", err)
+	}
+	byDate := make(map[time.Time]*model.DailyBalanceSnapshot)
+	for _, s := range snapshots {
+		byDate[s.SnapshotDate.UTC()] = s
+	}
+
+	old, ok := byDate[backfilled]
+	if !ok {
+		t.Fatalf("missing backfilled snapshot, got %d snapshots", len(snapshots))
+	}
+	if !old.Balance.Equal(decimal.NewFromInt(60)) {
+		t.Errorf("Expected backfilled balance 60, got %s", old.Balance)
+	}
+	if old.FrozenBalance != nil {
+		t.Errorf("Expected backfilled frozen balance to be unknown, got %s", old.FrozenBalance)
+	}
+
+	cur, ok := byDate[onTime]
+	if !ok {
+		t.Fatal("missing on-time snapshot")
+	}
+	if cur.FrozenBalance == nil || !cur.FrozenBalance.Equal(decimal.NewFromInt(5)) {
+		t.Errorf("Expected on-time frozen balance 5, got %v", cur.FrozenBalance)
+	}
+}
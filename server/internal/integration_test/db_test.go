@@ -0,0 +1,69 @@
+// Package integration_test 数据库集成测试辅助
+package integration_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/fiveseconds/server/internal/repository"
+
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+// testDatabaseEnv 集成测试数据库连接串（须指向已执行 init.sql 与全部编号迁移的专用测试库）
+const testDatabaseEnv = "TEST_DATABASE_URL"
+
+var (
+	testDBOnce sync.Once
+	testDBErr  error
+	testSeq    int64
+)
+
+// requireTestDB 连接集成测试数据库，未配置 TEST_DATABASE_URL 时跳过测试
+func requireTestDB(t *testing.T) {
+	t.Helper()
+	dsn := os.Getenv(testDatabaseEnv)
+	if dsn == "" {
+		t.Skipf("%s not set, skipping database integration test", testDatabaseEnv)
+	}
+	testDBOnce.Do(func() {
+		testDBErr = repository.InitDB(dsn, zap.NewNop())
+	})
+	if testDBErr != nil {
+		t.Fatalf("connect test database: %v", testDBErr)
+	}
+}
+
+// uniqueName 生成测试内唯一的用户名（测试数据不清理，用户名不能与上次运行冲突）
+func uniqueName(prefix string) string {
+	return fmt.Sprintf("%s_%d_%d", prefix, time.Now().UnixNano()%1e9, atomic.AddInt64(&testSeq, 1))
+}
+
+// createTestUser 直接插入用户，返回用户ID
+func createTestUser(t *testing.T, role string, invitedBy *int64, balance decimal.Decimal) int64 {
+	t.Helper()
+	var id int64
+	err := repository.DB.QueryRow(context.Background(),
+		`INSERT INTO users (username, password_hash, role, invited_by, balance) VALUES ($1, 'x', $2, $3, $4) RETURNING id`,
+		uniqueName(role), role, invitedBy, balance).Scan(&id)
+	if err != nil {
+		t.Fatalf("create %s: %v", role, err)
+	}
+	return id
+}
+
+// userBalance 查询用户余额
+func userBalance(t *testing.T, userID int64) decimal.Decimal {
+	t.Helper()
+	var balance decimal.Decimal
+	if err := repository.DB.QueryRow(context.Background(), `SELECT balance FROM users WHERE id = $1`, userID).Scan(&balance); err != nil {
+		t.Fatalf("query balance of user %d: %v", userID, err)
+	}
+	return balance
+}
@@ -0,0 +1,180 @@
+// Package integration_test 资金申请审批集成测试
+package integration_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/fiveseconds/server/internal/config"
+	"github.com/fiveseconds/server/internal/model"
+	"github.com/fiveseconds/server/internal/repository"
+	"github.com/fiveseconds/server/internal/service"
+
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+// newTestFundService 创建使用真实仓库的资金服务
+func newTestFundService(approval config.FundApprovalConfig) *service.FundService {
+	cfg := &config.Config{}
+	cfg.Fund.Approval = approval
+	return service.NewFundService(
+		repository.NewUserRepo(),
+		repository.NewWalletRepo(),
+		repository.NewFundRequestRepo(),
+		repository.NewTransactionRepo(),
+		repository.NewPlatformRepo(),
+		repository.NewConservationRepo(),
+		repository.NewBalanceSnapshotRepo(),
+		cfg,
+	)
+}
+
+// createFundRequests 为玩家创建多笔相同金额的资金申请
+func createFundRequests(t *testing.T, s *service.FundService, userID int64, reqType model.FundRequestType, amount decimal.Decimal, n int) []int64 {
+	t.Helper()
+	ids := make([]int64, 0, n)
+	for i := 0; i < n; i++ {
+		req, err := s.CreateFundRequest(context.Background(), userID, &model.CreateFundRequestReq{Type: reqType, Amount: amount})
+		if err != nil {
+			t.Fatalf("create fund request: %v", err)
+		}
+		ids = append(ids, req.ID)
+	}
+	return ids
+}
+
+// TestConcurrentOwnerApprovalsRespectDailyCap 测试同一房主并发审批时每日审批额度不会被突破
+func TestConcurrentOwnerApprovalsRespectDailyCap(t *testing.T) {
+	requireTestDB(t)
+	ctx := context.Background()
+
+	s := newTestFundService(config.FundApprovalConfig{OwnerDailyApprovalCap: 1000})
+	ownerID := createTestUser(t, "owner", nil, decimal.NewFromInt(100000))
+	playerID := createTestUser(t, "player", &ownerID, decimal.Zero)
+	ids := createFundRequests(t, s, playerID, model.FundRequestDeposit, decimal.NewFromInt(300), 6)
+
+	statuses := make([]model.FundRequestStatus, len(ids))
+	errs := make([]error, len(ids))
+	var wg sync.WaitGroup
+	for i, id := range ids {
+		wg.Add(1)
+		go func(i int, id int64) {
+			defer wg.Done()
+			statuses[i], errs[i] = s.ProcessFundRequest(ctx, id, ownerID, &model.ProcessFundRequestReq{Approved: true})
+		}(i, id)
+	}
+	wg.Wait()
+
+	approved, escalated := 0, 0
+	for i := range ids {
+		if errs[i] != nil {
+			t.Fatalf("approve request %d: %v", ids[i], errs[i])
+		}
+		switch statuses[i] {
+		case model.FundStatusApproved:
+			approved++
+		case model.FundStatusEscalated:
+			escalated++
+		default:
+			t.Errorf("request %d: unexpected status %s", ids[i], statuses[i])
+		}
+	}
+	// 额度 1000 内最多通过 3 笔 300，其余升级至管理员
+	if approved != 3 || escalated != 3 {
+		t.Errorf("Expected 3 approved and 3 escalated, got %d approved and %d escalated", approved, escalated)
+	}
+	if got := userBalance(t, playerID); !got.Equal(decimal.NewFromInt(900)) {
+		t.Errorf("Expected player balance 900, got %s", got)
+	}
+}
+
+// TestFirstOfDualApprovalCountsTowardDailyCap 测试双人审批的首次审批占用房主每日审批额度，被拒绝后释放
+func TestFirstOfDualApprovalCountsTowardDailyCap(t *testing.T) {
+	requireTestDB(t)
+	ctx := context.Background()
+
+	s := newTestFundService(config.FundApprovalConfig{OwnerDailyApprovalCap: 1000, DualApprovalThreshold: 500})
+	ownerID := createTestUser(t, "owner", nil, decimal.NewFromInt(100000))
+	adminID := createTestUser(t, "admin", nil, decimal.Zero)
+	playerID := createTestUser(t, "player", &ownerID, decimal.Zero)
+	ids := createFundRequests(t, s, playerID, model.FundRequestDeposit, decimal.NewFromInt(600), 2)
+
+	status, err := s.ProcessFundRequest(ctx, ids[0], ownerID, &model.ProcessFundRequestReq{Approved: true})
+	if err != nil || status != model.FundStatusPendingSecondApproval {
+		t.Fatalf("Expected first approval to wait for a second approver, got %s, %v", status, err)
+	}
+	// 首次审批已占用 600，再通过 600 超出额度
+	status, err = s.ProcessFundRequest(ctx, ids[1], ownerID, &model.ProcessFundRequestReq{Approved: true})
+	if err != nil || status != model.FundStatusEscalated {
+		t.Fatalf("Expected the second request to be escalated, got %s, %v", status, err)
+	}
+
+	// 管理员拒绝第一笔后额度释放
+	if _, err := s.ProcessFundRequest(ctx, ids[0], adminID, &model.ProcessFundRequestReq{Approved: false}); err != nil {
+		t.Fatalf("reject request: %v", err)
+	}
+	more := createFundRequests(t, s, playerID, model.FundRequestDeposit, decimal.NewFromInt(600), 1)
+	status, err = s.ProcessFundRequest(ctx, more[0], ownerID, &model.ProcessFundRequestReq{Approved: true})
+	if err != nil || status != model.FundStatusPendingSecondApproval {
+		t.Fatalf("Expected the released cap to admit a new first approval, got %s, %v", status, err)
+	}
+}
+
+// TestApprovalHeldWhenRestrictionAppliedAfterRequest 测试申请创建后才施加的禁止提现限制会阻止审批，申请保持待审批
+func TestApprovalHeldWhenRestrictionAppliedAfterRequest(t *testing.T) {
+	requireTestDB(t)
+	ctx := context.Background()
+
+	s := newTestFundService(config.FundApprovalConfig{})
+	restrictions := service.NewRestrictionService(repository.NewRestrictionRepo(), &config.Config{}, zap.NewNop())
+	s.SetRestrictionChecker(restrictions)
+
+	ownerID := createTestUser(t, "owner", nil, decimal.Zero)
+	adminID := createTestUser(t, "admin", nil, decimal.Zero)
+	playerID := createTestUser(t, "player", &ownerID, decimal.NewFromInt(500))
+	ids := createFundRequests(t, s, playerID, model.FundRequestWithdraw, decimal.NewFromInt(300), 2)
+
+	applied, err := restrictions.ApplyManual(ctx, playerID, &model.ApplyRestrictionReq{
+		Types: []model.RestrictionType{model.RestrictionNoWithdrawal},
+		Hours: 1,
+	}, adminID)
+	if err != nil || len(applied) != 1 {
+		t.Fatalf("apply restriction: %v", err)
+	}
+
+	_, err = s.ProcessFundRequest(ctx, ids[0], ownerID, &model.ProcessFundRequestReq{Approved: true})
+	var restricted *service.RestrictedError
+	if !errors.As(err, &restricted) || restricted.Restriction.Type != model.RestrictionNoWithdrawal {
+		t.Fatalf("Expected approval to fail with a no_withdrawal restriction, got %v", err)
+	}
+	req, err := repository.NewFundRequestRepo().GetByID(ctx, ids[0])
+	if err != nil {
+		t.Fatalf("get request: %v", err)
+	}
+	if req.Status != model.FundStatusPending {
+		t.Errorf("Expected request to stay pending, got %s", req.Status)
+	}
+	if got := userBalance(t, playerID); !got.Equal(decimal.NewFromInt(500)) {
+		t.Errorf("Expected player balance untouched at 500, got %s", got)
+	}
+
+	// 受限期间仍可拒绝申请
+	if _, err := s.ProcessFundRequest(ctx, ids[1], ownerID, &model.ProcessFundRequestReq{Approved: false}); err != nil {
+		t.Fatalf("reject request: %v", err)
+	}
+
+	// 解除限制后可以审批
+	if _, err := restrictions.Revoke(ctx, applied[0].ID, adminID, "cleared"); err != nil {
+		t.Fatalf("revoke restriction: %v", err)
+	}
+	status, err := s.ProcessFundRequest(ctx, ids[0], ownerID, &model.ProcessFundRequestReq{Approved: true})
+	if err != nil || status != model.FundStatusApproved {
+		t.Fatalf("Expected approval after the restriction is lifted, got %s, %v", status, err)
+	}
+	if got := userBalance(t, playerID); !got.Equal(decimal.NewFromInt(200)) {
+		t.Errorf("Expected player balance 200 after withdrawal, got %s", got)
+	}
+}
@@ -0,0 +1,148 @@
+// Package integration_test 累进奖池回合结算集成测试
+package integration_test
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/fiveseconds/server/internal/config"
+	"github.com/fiveseconds/server/internal/game"
+	"github.com/fiveseconds/server/internal/model"
+	"github.com/fiveseconds/server/internal/repository"
+	"github.com/fiveseconds/server/internal/service"
+
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+// nopBroadcaster 丢弃房间广播
+type nopBroadcaster struct{}
+
+func (nopBroadcaster) BroadcastToRoom(int64, *model.WSMessage) {}
+func (nopBroadcaster) SendToUser(int64, *model.WSMessage)      {}
+
+// settledRound 等待房间第一个回合结束（结算或失败），返回回合状态、舍入残值与注入累进奖池的金额
+func settledRound(t *testing.T, roomID int64, timeout time.Duration) (string, decimal.Decimal, decimal.Decimal) {
+	t.Helper()
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		var status string
+		var residual, contribution decimal.Decimal
+		err := repository.DB.QueryRow(context.Background(),
+			`SELECT status, COALESCE(residual_amount, 0), jackpot_contribution FROM game_rounds WHERE room_id = $1 ORDER BY id LIMIT 1`,
+			roomID).Scan(&status, &residual, &contribution)
+		if err == nil && (status == string(model.RoundStatusSettled) || status == string(model.RoundStatusFailed)) {
+			return status, residual, contribution
+		}
+		time.Sleep(200 * time.Millisecond)
+	}
+	t.Fatalf("round of room %d not finished within %s", roomID, timeout)
+	return "", decimal.Zero, decimal.Zero
+}
+
+// TestJackpotRoundResidual 测试开启累进奖池的房间完整结算一个回合：
+// 正的舍入残值注入奖池；四舍五入多派的负残值由平台承担，奖池余额为 0 时结算仍然成功
+func TestJackpotRoundResidual(t *testing.T) {
+	requireTestDB(t)
+
+	tests := []struct {
+		name         string
+		betAmount    int64 // 4 人参与、3 名赢家、无抽成
+		residual     string
+		jackpot      string
+		playersTotal string // 4 名玩家各 100
+	}{
+		{"positive residual funds the jackpot", 10, "0.01", "0.01", "399.99"},
+		{"negative residual charged to the platform", 5, "-0.01", "0", "400.01"},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			ctx := context.Background()
+			userRepo := repository.NewUserRepo()
+			roomRepo := repository.NewRoomRepo()
+			jackpotRepo := repository.NewJackpotRepo()
+
+			ownerID := createTestUser(t, "owner", nil, decimal.Zero)
+			room := &model.Room{
+				OwnerID:                ownerID,
+				Name:                   uniqueName("jackpot_room"),
+				InviteCode:             fmt.Sprintf("J%09d", (time.Now().UnixNano()/1000+atomic.AddInt64(&testSeq, 1))%1e9),
+				RoomType:               model.RoomTypeStandard,
+				BetAmount:              decimal.NewFromInt(tt.betAmount),
+				Currency:               "CNY",
+				WinnerCount:            3,
+				MaxPlayers:             10,
+				OwnerCommissionRate:    decimal.Zero,
+				PlatformCommissionRate: decimal.Zero,
+				Status:                 model.RoomStatusActive,
+			}
+			if err := roomRepo.Create(ctx, room); err != nil {
+				t.Fatalf("create room: %v", err)
+			}
+			// 不抽取、几乎不会触发，奖池余额只来自舍入残值
+			jackpot := &model.Jackpot{
+				OwnerID:          ownerID,
+				RoomID:           &room.ID,
+				Currency:         "CNY",
+				ContributionRate: decimal.Zero,
+				TriggerOdds:      1 << 30,
+				Enabled:          true,
+			}
+			if err := jackpotRepo.Create(ctx, jackpot); err != nil {
+				t.Fatalf("create jackpot: %v", err)
+			}
+
+			rp := game.NewRoomProcessor(room, nopBroadcaster{}, userRepo, roomRepo,
+				repository.NewGameRepo(), repository.NewTransactionRepo(), repository.NewPlatformRepo(),
+				nil, nil, zap.NewNop())
+			rp.SetJackpotPool(service.NewJackpotService(jackpotRepo, roomRepo, userRepo, &config.Config{}, zap.NewNop()))
+
+			players := make([]int64, 4)
+			for i := range players {
+				players[i] = createTestUser(t, "player", &ownerID, decimal.NewFromInt(100))
+				if err := roomRepo.AddPlayer(ctx, &model.RoomPlayer{RoomID: room.ID, UserID: players[i], AutoReady: true}); err != nil {
+					t.Fatalf("add player: %v", err)
+				}
+				user, err := userRepo.GetByID(ctx, players[i])
+				if err != nil {
+					t.Fatalf("get player: %v", err)
+				}
+				rp.AddPlayer(user)
+			}
+
+			// 回合结算后立即检查，下一回合至少在两个阶段之后才会扣款
+			rp.Start()
+			defer rp.Stop()
+			status, residual, contribution := settledRound(t, room.ID, 45*time.Second)
+
+			if status != string(model.RoundStatusSettled) {
+				t.Fatalf("Expected round settled, got %s", status)
+			}
+			if residual.String() != tt.residual {
+				t.Errorf("Expected residual %s, got %s", tt.residual, residual)
+			}
+			if contribution.String() != tt.jackpot {
+				t.Errorf("Expected jackpot contribution %s, got %s", tt.jackpot, contribution)
+			}
+			updated, err := jackpotRepo.GetByID(ctx, jackpot.ID)
+			if err != nil {
+				t.Fatalf("get jackpot: %v", err)
+			}
+			if updated.Balance.String() != tt.jackpot {
+				t.Errorf("Expected jackpot balance %s, got %s", tt.jackpot, updated.Balance)
+			}
+			total := decimal.Zero
+			for _, id := range players {
+				total = total.Add(userBalance(t, id))
+			}
+			if total.String() != tt.playersTotal {
+				t.Errorf("Expected players to hold %s in total, got %s", tt.playersTotal, total)
+			}
+		})
+	}
+}
@@ -0,0 +1,368 @@
+// Package integration_test 余额写回缓存集成测试（真实 BalanceCache + miniredis + 测试数据库）
+package integration_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fiveseconds/server/internal/cache"
+	"github.com/fiveseconds/server/internal/config"
+	"github.com/fiveseconds/server/internal/model"
+	"github.com/fiveseconds/server/internal/repository"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/jackc/pgx/v5"
+	"github.com/redis/go-redis/v9"
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+// newWriteBehindCache 创建连接 miniredis 的写回模式余额缓存
+func newWriteBehindCache(t *testing.T) (*cache.BalanceCache, *miniredis.Miniredis) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+
+	c := cache.NewBalanceCache(client, repository.NewUserRepo(), zap.NewNop())
+	c.EnableWriteBehind(config.WriteBehindConfig{}, repository.NewTransactionRepo(), repository.NewBalanceOutboxRepo())
+	return c, mr
+}
+
+// recordCredit 模拟结算事务：在事务内登记待写回加款后提交
+func recordCredit(t *testing.T, c *cache.BalanceCache, amounts map[int64]decimal.Decimal, meta cache.OutboxMeta) {
+	t.Helper()
+	err := repository.Tx(context.Background(), func(tx pgx.Tx) error {
+		return c.RecordCreditsTx(context.Background(), tx, amounts, meta)
+	})
+	if err != nil {
+		t.Fatalf("record credits: %v", err)
+	}
+}
+
+// pendingCreditExists 待写回加款登记是否仍存在
+func pendingCreditExists(t *testing.T, op string) bool {
+	t.Helper()
+	var exists bool
+	err := repository.DB.QueryRow(context.Background(),
+		`SELECT EXISTS (SELECT 1 FROM balance_pending_credits WHERE op = $1)`, op).Scan(&exists)
+	if err != nil {
+		t.Fatalf("query pending credit: %v", err)
+	}
+	return exists
+}
+
+// TestRecordedCreditReplayedWhenRedisIsDown 测试提交结算后未能加款（进程中断）时，重放在 Redis 不可用时直接落库且只执行一次
+func TestRecordedCreditReplayedWhenRedisIsDown(t *testing.T) {
+	requireTestDB(t)
+	ctx := context.Background()
+	c, mr := newWriteBehindCache(t)
+
+	userID := createTestUser(t, "player", nil, decimal.NewFromInt(100))
+	meta := cache.OutboxMeta{Op: uniqueName("round") + ":win", Type: model.TxGameWin}
+	recordCredit(t, c, map[int64]decimal.Decimal{userID: decimal.NewFromInt(25)}, meta)
+
+	mr.Close()
+	if _, err := c.ReplayRecordedCredits(ctx, 0); err != nil {
+		t.Fatalf("replay: %v", err)
+	}
+	if got := userBalance(t, userID); !got.Equal(decimal.NewFromInt(125)) {
+		t.Errorf("Expected balance 125 after replay, got %s", got)
+	}
+	if pendingCreditExists(t, meta.Op) {
+		t.Error("Expected the pending credit to be removed once credited in db")
+	}
+
+	// 再次重放与结算流程迟到的加款都按操作ID去重
+	if _, err := c.ReplayRecordedCredits(ctx, 0); err != nil {
+		t.Fatalf("second replay: %v", err)
+	}
+	if _, err := c.ApplyRecordedCredits(ctx, map[int64]decimal.Decimal{userID: decimal.NewFromInt(25)}, meta); !errors.Is(err, cache.ErrDuplicateOp) {
+		t.Errorf("Expected a late credit with the same op to be rejected as duplicate, got %v", err)
+	}
+	if got := userBalance(t, userID); !got.Equal(decimal.NewFromInt(125)) {
+		t.Errorf("Expected balance to stay 125, got %s", got)
+	}
+}
+
+// TestRecordedCreditKeptUntilFlushed 测试重放写入 Redis 后登记保留到 outbox 落库，期间重复重放不会重复加款
+func TestRecordedCreditKeptUntilFlushed(t *testing.T) {
+	requireTestDB(t)
+	ctx := context.Background()
+	c, _ := newWriteBehindCache(t)
+
+	userID := createTestUser(t, "player", nil, decimal.NewFromInt(100))
+	meta := cache.OutboxMeta{Op: uniqueName("round") + ":jackpot", Type: model.TxJackpotWin}
+	recordCredit(t, c, map[int64]decimal.Decimal{userID: decimal.RequireFromString("12.34")}, meta)
+
+	// 刚登记的加款留给结算流程，flusher 不重放
+	if _, err := c.ReplayRecordedCredits(ctx, time.Hour); err != nil {
+		t.Fatalf("replay with grace: %v", err)
+	}
+	if cached, err := c.Get(ctx, userID); err != nil || !cached.Balance.Equal(decimal.NewFromInt(100)) {
+		t.Fatalf("Expected cached balance 100 before replay, got %v (%v)", cached, err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, err := c.ReplayRecordedCredits(ctx, 0); err != nil {
+			t.Fatalf("replay %d: %v", i, err)
+		}
+	}
+	cached, err := c.Get(ctx, userID)
+	if err != nil {
+		t.Fatalf("get cached balance: %v", err)
+	}
+	if !cached.Balance.Equal(decimal.RequireFromString("112.34")) {
+		t.Errorf("Expected cached balance 112.34 after replays, got %s", cached.Balance)
+	}
+	if !pendingCreditExists(t, meta.Op) {
+		t.Error("Expected the pending credit to stay until the outbox entry is flushed")
+	}
+	if got := userBalance(t, userID); !got.Equal(decimal.NewFromInt(100)) {
+		t.Errorf("Expected db balance 100 before flush, got %s", got)
+	}
+
+	if _, err := c.Flush(ctx); err != nil {
+		t.Fatalf("flush: %v", err)
+	}
+	if got := userBalance(t, userID); !got.Equal(decimal.RequireFromString("112.34")) {
+		t.Errorf("Expected db balance 112.34 after flush, got %s", got)
+	}
+	if pendingCreditExists(t, meta.Op) {
+		t.Error("Expected the pending credit to be removed by the flush")
+	}
+}
+
+// TestRolledBackSettlementLeavesNoPendingCredit 测试结算事务回滚时不留下待写回加款
+func TestRolledBackSettlementLeavesNoPendingCredit(t *testing.T) {
+	requireTestDB(t)
+	ctx := context.Background()
+	c, _ := newWriteBehindCache(t)
+
+	userID := createTestUser(t, "player", nil, decimal.NewFromInt(100))
+	meta := cache.OutboxMeta{Op: uniqueName("round") + ":win", Type: model.TxGameWin}
+	errSettlement := errors.New("settlement failed")
+	err := repository.Tx(ctx, func(tx pgx.Tx) error {
+		if err := c.RecordCreditsTx(ctx, tx, map[int64]decimal.Decimal{userID: decimal.NewFromInt(5)}, meta); err != nil {
+			t.Fatalf("record credits: %v", err)
+		}
+		return errSettlement
+	})
+	if !errors.Is(err, errSettlement) {
+		t.Fatalf("Expected the settlement error, got %v", err)
+	}
+	if pendingCreditExists(t, meta.Op) {
+		t.Error("Expected no pending credit after rollback")
+	}
+}
+
+// stake 在缓存中扣除下注，返回是否扣款
+func stake(t *testing.T, c *cache.BalanceCache, userID int64, amount decimal.Decimal) bool {
+	t.Helper()
+	applied, err := c.ApplyStakes(context.Background(), []int64{userID}, amount,
+		cache.OutboxMeta{Op: uniqueName("round") + ":bet", Type: model.TxGameBet})
+	if err != nil {
+		t.Errorf("stake: %v", err)
+		return false
+	}
+	_, ok := applied[userID]
+	return ok
+}
+
+// debitDB 数据库路径扣款（如提现），余额不足时不扣款
+func debitDB(t *testing.T, userID int64, amount decimal.Decimal) bool {
+	t.Helper()
+	tag, err := repository.DB.Exec(context.Background(),
+		`UPDATE users SET balance = balance - $2 WHERE id = $1 AND balance >= $2`, userID, amount)
+	if err != nil {
+		t.Errorf("debit in db: %v", err)
+		return false
+	}
+	return tag.RowsAffected() == 1
+}
+
+// cachedBalance 读取写回缓存中的余额
+func cachedBalance(t *testing.T, c *cache.BalanceCache, userID int64) decimal.Decimal {
+	t.Helper()
+	cached, err := c.Get(context.Background(), userID)
+	if err != nil {
+		t.Fatalf("get cached balance: %v", err)
+	}
+	return cached.Balance
+}
+
+// TestFlushWritesOutboxToDB 测试落库将缓存扣款写入数据库并生成流水，重复落库不重复扣款
+func TestFlushWritesOutboxToDB(t *testing.T) {
+	requireTestDB(t)
+	ctx := context.Background()
+	c, _ := newWriteBehindCache(t)
+	userID := createTestUser(t, "player", nil, decimal.NewFromInt(100))
+
+	if !stake(t, c, userID, decimal.NewFromInt(30)) {
+		t.Fatal("Expected the stake to be charged")
+	}
+	if got := userBalance(t, userID); !got.Equal(decimal.NewFromInt(100)) {
+		t.Errorf("Expected db balance 100 before flush, got %s", got)
+	}
+	if n, err := c.Flush(ctx); err != nil || n != 1 {
+		t.Fatalf("Expected one entry flushed, got %d (%v)", n, err)
+	}
+	if n, err := c.Flush(ctx); err != nil || n != 0 {
+		t.Fatalf("Expected nothing left to flush, got %d (%v)", n, err)
+	}
+	if got := userBalance(t, userID); !got.Equal(decimal.NewFromInt(70)) {
+		t.Errorf("Expected db balance 70, got %s", got)
+	}
+	if got := cachedBalance(t, c, userID); !got.Equal(decimal.NewFromInt(70)) {
+		t.Errorf("Expected cached balance 70, got %s", got)
+	}
+
+	var records int
+	if err := repository.DB.QueryRow(ctx,
+		`SELECT COUNT(*) FROM balance_transactions WHERE user_id = $1 AND tx_type = $2 AND amount = -30 AND balance_after = 70`,
+		userID, model.TxGameBet).Scan(&records); err != nil {
+		t.Fatalf("count transactions: %v", err)
+	}
+	if records != 1 {
+		t.Errorf("Expected one bet transaction, got %d", records)
+	}
+}
+
+// TestPrepareDBWriteDrainsAndHolds 测试数据库路径扣款前先落库未落库的缓存扣款，期间暂停缓存扣款，释放后按数据库余额重新加载
+func TestPrepareDBWriteDrainsAndHolds(t *testing.T) {
+	requireTestDB(t)
+	ctx := context.Background()
+	c, _ := newWriteBehindCache(t)
+	userID := createTestUser(t, "player", nil, decimal.NewFromInt(100))
+
+	if !stake(t, c, userID, decimal.NewFromInt(60)) {
+		t.Fatal("Expected the stake to be charged")
+	}
+	release, blocked := c.PrepareDBWrite(ctx, []int64{userID})
+	if len(blocked) != 0 {
+		t.Fatalf("Expected the pending stake to be flushed, still blocked: %v", blocked)
+	}
+	// 未先落库时数据库余额仍为 100，会允许全额提现
+	if got := userBalance(t, userID); !got.Equal(decimal.NewFromInt(40)) {
+		t.Errorf("Expected db balance 40 after draining the stake, got %s", got)
+	}
+	if debitDB(t, userID, decimal.NewFromInt(100)) {
+		t.Error("Expected a full withdrawal to be refused after the drain")
+	}
+	if stake(t, c, userID, decimal.NewFromInt(10)) {
+		t.Error("Expected cached stakes to be held during the db write")
+	}
+	if !debitDB(t, userID, decimal.NewFromInt(40)) {
+		t.Fatal("Expected the withdrawal of the drained balance to succeed")
+	}
+	release()
+
+	if got := cachedBalance(t, c, userID); !got.IsZero() {
+		t.Errorf("Expected the cache to be reloaded to 0, got %s", got)
+	}
+	if stake(t, c, userID, decimal.NewFromInt(10)) {
+		t.Error("Expected a stake to be refused after the balance was withdrawn")
+	}
+	if _, err := c.Flush(ctx); err != nil {
+		t.Fatalf("flush: %v", err)
+	}
+	if got := userBalance(t, userID); !got.IsZero() {
+		t.Errorf("Expected db balance 0, got %s", got)
+	}
+}
+
+// TestFlushOverdrawGuardDeadLetters 测试落库会使余额为负的条目转入死信队列，后续条目照常落库
+func TestFlushOverdrawGuardDeadLetters(t *testing.T) {
+	requireTestDB(t)
+	ctx := context.Background()
+	c, mr := newWriteBehindCache(t)
+	drained := createTestUser(t, "player", nil, decimal.NewFromInt(50))
+	other := createTestUser(t, "player", nil, decimal.NewFromInt(50))
+
+	if !stake(t, c, drained, decimal.NewFromInt(50)) || !stake(t, c, other, decimal.NewFromInt(10)) {
+		t.Fatal("Expected both stakes to be charged")
+	}
+	// 模拟绕过 PrepareDBWrite 的数据库扣款
+	if !debitDB(t, drained, decimal.NewFromInt(50)) {
+		t.Fatal("Expected the bypassing debit to succeed")
+	}
+	if _, err := c.Flush(ctx); err != nil {
+		t.Fatalf("flush: %v", err)
+	}
+
+	if got := userBalance(t, drained); !got.IsZero() {
+		t.Errorf("Expected the drained balance to stay 0, got %s", got)
+	}
+	if got := userBalance(t, other); !got.Equal(decimal.NewFromInt(40)) {
+		t.Errorf("Expected later entries to flush, got %s", got)
+	}
+	dead, err := mr.Stream("balance_wb:dead")
+	if err != nil || len(dead) != 1 {
+		t.Fatalf("Expected one dead-lettered entry, got %d (%v)", len(dead), err)
+	}
+	if got := cachedBalance(t, c, drained); !got.IsZero() {
+		t.Errorf("Expected the cache to follow the db balance 0, got %s", got)
+	}
+	if n, err := c.Flush(ctx); err != nil || n != 0 {
+		t.Errorf("Expected nothing left to flush, got %d (%v)", n, err)
+	}
+}
+
+// TestConcurrentCachedStakesAndDBDebits 测试并发缓存扣款与数据库路径扣款时余额不为负且守恒
+func TestConcurrentCachedStakesAndDBDebits(t *testing.T) {
+	requireTestDB(t)
+	ctx := context.Background()
+	c, mr := newWriteBehindCache(t)
+	initial := decimal.NewFromInt(1000)
+	userID := createTestUser(t, "player", nil, initial)
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	staked, debited := decimal.Zero, decimal.Zero
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				if stake(t, c, userID, decimal.NewFromInt(5)) {
+					mu.Lock()
+					staked = staked.Add(decimal.NewFromInt(5))
+					mu.Unlock()
+				}
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 5; j++ {
+				release, blocked := c.PrepareDBWrite(ctx, []int64{userID})
+				if len(blocked) == 0 && debitDB(t, userID, decimal.NewFromInt(30)) {
+					mu.Lock()
+					debited = debited.Add(decimal.NewFromInt(30))
+					mu.Unlock()
+				}
+				release()
+			}
+		}()
+	}
+	wg.Wait()
+	if _, err := c.Flush(ctx); err != nil {
+		t.Fatalf("flush: %v", err)
+	}
+
+	if dead, _ := mr.Stream("balance_wb:dead"); len(dead) != 0 {
+		t.Errorf("Expected no dead-lettered entries, got %d", len(dead))
+	}
+	want := initial.Sub(staked).Sub(debited)
+	if want.IsNegative() {
+		t.Fatalf("Charged more than the balance: staked %s debited %s", staked, debited)
+	}
+	if got := userBalance(t, userID); !got.Equal(want) {
+		t.Errorf("Expected db balance %s, got %s", want, got)
+	}
+	if got := cachedBalance(t, c, userID); !got.Equal(want) {
+		t.Errorf("Expected cached balance %s, got %s", want, got)
+	}
+}
@@ -17,6 +17,11 @@ const (
 	AlertTypeSettlementFailed   AlertType = "settlement_failed"
 	AlertTypeConservationFailed AlertType = "conservation_failed"
 	AlertTypeRiskFlagCreated    AlertType = "risk_flag_created"
+	AlertTypeCreditLimitBreach  AlertType = "credit_limit_breach"
+	AlertTypeRiskRuleHit        AlertType = "risk_rule_hit"
+	AlertTypeRiskScoreHigh      AlertType = "risk_score_high"
+	AlertTypeOwnerAnomaly       AlertType = "owner_anomaly"
+	AlertTypeFairnessDeviation  AlertType = "fairness_deviation"
 )
 
 // AlertSeverity 告警严重程度
@@ -28,6 +33,20 @@ const (
 	AlertSeverityCritical AlertSeverity = "critical"
 )
 
+// Rank 严重程度等级（info < warning < critical），未知级别为 0
+func (s AlertSeverity) Rank() int {
+	switch s {
+	case AlertSeverityInfo:
+		return 1
+	case AlertSeverityWarning:
+		return 2
+	case AlertSeverityCritical:
+		return 3
+	default:
+		return 0
+	}
+}
+
 // AlertStatus 告警状态
 type AlertStatus string
 
@@ -59,9 +78,12 @@ type AlertDetails struct {
 	Amount            decimal.Decimal `json:"amount,omitempty"`
 	Balance           decimal.Decimal `json:"balance,omitempty"`
 	Difference        decimal.Decimal `json:"difference,omitempty"`
+	Currency          string          `json:"currency,omitempty"`
 	FailureCount      int             `json:"failure_count,omitempty"`
 	RiskFlagID        *int64          `json:"risk_flag_id,omitempty"`
 	RiskFlagType      string          `json:"risk_flag_type,omitempty"`
+	RiskRuleID        *int64          `json:"risk_rule_id,omitempty"`
+	RiskRuleVersion   int             `json:"risk_rule_version,omitempty"`
 	AdditionalInfo    string          `json:"additional_info,omitempty"`
 }
 
@@ -0,0 +1,43 @@
+package model
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// DailyBalanceSnapshot 日终余额快照
+type DailyBalanceSnapshot struct {
+	UserID            int64            `json:"user_id"`
+	SnapshotDate      time.Time        `json:"snapshot_date"`
+	BoundaryAt        time.Time        `json:"boundary_at"`
+	Role              UserRole         `json:"role"`
+	OwnerID           *int64           `json:"owner_id,omitempty"`
+	Currency          string           `json:"currency"` // 每个币种一条（含玩家非活跃币种钱包）
+	Balance           decimal.Decimal  `json:"balance"`
+	FrozenBalance     *decimal.Decimal `json:"frozen_balance"` // 补采的快照无法回推冻结余额，为 null
+	BonusBalance      decimal.Decimal  `json:"bonus_balance"`
+	CommissionBalance decimal.Decimal  `json:"commission_balance"`
+	MarginBalance     decimal.Decimal  `json:"margin_balance"`
+	CapturedAt        time.Time        `json:"captured_at"`
+}
+
+// OwnerSnapshotSummary 按房主汇总的日终余额（用于每日房主对账）
+type OwnerSnapshotSummary struct {
+	OwnerID           int64
+	Currency          string          // 房主经营币种
+	TotalPlayer       decimal.Decimal // 名下玩家余额 + 冻结
+	TotalPlayerFrozen decimal.Decimal
+	OwnerBalance      decimal.Decimal
+	MarginBalance     decimal.Decimal
+	CommissionBalance decimal.Decimal
+}
+
+// BalanceHistoryQuery 历史余额查询
+type BalanceHistoryQuery struct {
+	UserID   int64
+	From     *time.Time // 起始日期（含）
+	To       *time.Time // 结束日期（含）
+	Page     int
+	PageSize int
+}
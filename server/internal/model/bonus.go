@@ -0,0 +1,67 @@
+package model
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// BonusGrantStatus 奖励发放状态
+type BonusGrantStatus string
+
+const (
+	BonusGrantActive    BonusGrantStatus = "active"    // 流水进行中
+	BonusGrantCompleted BonusGrantStatus = "completed" // 流水已达标
+	BonusGrantForfeited BonusGrantStatus = "forfeited" // 奖励余额已输光，作废
+)
+
+// BonusGrant 奖励发放记录
+type BonusGrant struct {
+	ID                 int64            `json:"id" db:"id"`
+	UserID             int64            `json:"user_id" db:"user_id"`
+	Username           string           `json:"username,omitempty" db:"-"`
+	OwnerID            *int64           `json:"owner_id,omitempty" db:"owner_id"`
+	GrantedBy          int64            `json:"granted_by" db:"granted_by"`
+	Amount             decimal.Decimal  `json:"amount" db:"amount"`
+	WageringMultiplier decimal.Decimal  `json:"wagering_multiplier" db:"wagering_multiplier"`
+	WageringRequired   decimal.Decimal  `json:"wagering_required" db:"wagering_required"`
+	Wagered            decimal.Decimal  `json:"wagered" db:"wagered"`
+	Status             BonusGrantStatus `json:"status" db:"status"`
+	Remark             *string          `json:"remark,omitempty" db:"remark"`
+	CreatedAt          time.Time        `json:"created_at" db:"created_at"`
+	CompletedAt        *time.Time       `json:"completed_at,omitempty" db:"completed_at"`
+}
+
+// GrantBonusReq 发放奖励请求
+type GrantBonusReq struct {
+	Username           string          `json:"username" binding:"required"`
+	Amount             decimal.Decimal `json:"amount" binding:"required"`
+	WageringMultiplier decimal.Decimal `json:"wagering_multiplier"` // 为 0 时使用默认倍数
+	Remark             string          `json:"remark"`
+}
+
+// BonusGrantListQuery 奖励发放记录查询
+type BonusGrantListQuery struct {
+	UserID   *int64
+	OwnerID  *int64
+	Status   *BonusGrantStatus
+	Page     int
+	PageSize int
+}
+
+// BonusSummary 玩家奖励余额概览
+type BonusSummary struct {
+	BonusBalance      decimal.Decimal `json:"bonus_balance"`
+	WageringRequired  decimal.Decimal `json:"wagering_required"`  // 未完成奖励的流水要求合计
+	Wagered           decimal.Decimal `json:"wagered"`            // 未完成奖励的已完成流水合计
+	WageringRemaining decimal.Decimal `json:"wagering_remaining"` // 距离转为真实余额还需的流水
+	ActiveGrants      []*BonusGrant   `json:"active_grants"`
+}
+
+// BonusConversion 奖励余额转真实余额结果
+type BonusConversion struct {
+	UserID          int64
+	Amount          decimal.Decimal
+	NewBalance      decimal.Decimal
+	NewBonusBalance decimal.Decimal
+}
@@ -0,0 +1,72 @@
+package model
+
+import (
+	"github.com/shopspring/decimal"
+)
+
+// CollusionSignal 串通检测信号
+type CollusionSignal string
+
+const (
+	CollusionSignalJointWin     CollusionSignal = "joint_win"     // 关联账户同场时联合胜率显著高于随机期望
+	CollusionSignalFunnel       CollusionSignal = "funnel"        // 多个关联账户向同一账户转账归集
+	CollusionSignalSharedDevice CollusionSignal = "shared_device" // 共用设备指纹
+	CollusionSignalSharedIP     CollusionSignal = "shared_ip"     // 共用登录 IP
+	CollusionSignalTransfer     CollusionSignal = "transfer"      // 相互之间有转账
+)
+
+// CollusionLinkKind 账户关联类型
+type CollusionLinkKind string
+
+const (
+	CollusionLinkDevice CollusionLinkKind = "device"
+	CollusionLinkIP     CollusionLinkKind = "ip"
+)
+
+// CollusionRound 串通分析使用的已结算回合
+type CollusionRound struct {
+	ID             int64
+	ParticipantIDs []int64
+	WinnerIDs      []int64
+}
+
+// CollusionLink 两个账户之间的身份关联（共用设备指纹或登录 IP）
+type CollusionLink struct {
+	UserA int64
+	UserB int64
+	Kind  CollusionLinkKind
+	Value string // 指纹或 IP
+}
+
+// CollusionTransfer 串通分析使用的已完成玩家转账（同一房主名下）
+type CollusionTransfer struct {
+	FromUserID int64
+	ToUserID   int64
+	OwnerID    int64
+	Amount     decimal.Decimal
+}
+
+// CollusionCluster 疑似串通的账户簇
+type CollusionCluster struct {
+	UserIDs           []int64           `json:"user_ids"`
+	Signals           []CollusionSignal `json:"signals"`
+	CoPlayRounds      int               `json:"co_play_rounds"`      // 至少两名成员同场的回合数
+	JointWins         int               `json:"joint_wins"`          // 其中有成员获胜的回合数
+	ExpectedJointWins float64           `json:"expected_joint_wins"` // 随机开奖下的期望值
+	SharedDevices     []string          `json:"shared_devices,omitempty"`
+	SharedIPs         []string          `json:"shared_ips,omitempty"`
+	FunnelUserID      *int64            `json:"funnel_user_id,omitempty"` // 资金归集目标账户
+	FunnelOwnerID     *int64            `json:"funnel_owner_id,omitempty"`
+	FunnelSources     int               `json:"funnel_sources,omitempty"`
+	FunnelAmount      decimal.Decimal   `json:"funnel_amount,omitempty"`
+}
+
+// HasSignal 是否包含指定信号
+func (c *CollusionCluster) HasSignal(signal CollusionSignal) bool {
+	for _, s := range c.Signals {
+		if s == signal {
+			return true
+		}
+	}
+	return false
+}
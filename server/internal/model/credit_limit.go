@@ -0,0 +1,29 @@
+package model
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// OwnerExposure 房主资金敞口与信用额度
+// 敞口为名下玩家在房主经营币种下的余额总额（可用 + 冻结 + 非活跃币种钱包，不含奖励余额）
+type OwnerExposure struct {
+	OwnerID          int64            `json:"owner_id"`
+	Username         string           `json:"username"`
+	Currency         string           `json:"currency"`
+	MarginBalance    decimal.Decimal  `json:"margin_balance"`
+	PlayerBalance    decimal.Decimal  `json:"player_balance"`              // 名下玩家余额总额（敞口）
+	CustomMultiplier *decimal.Decimal `json:"custom_multiplier,omitempty"` // 单独设置的倍数，为空使用默认倍数
+	MarginMultiplier decimal.Decimal  `json:"margin_multiplier"`           // 生效倍数 K，0 表示不限
+	Unlimited        bool             `json:"unlimited"`                   // 是否不限额度
+	CreditLimit      decimal.Decimal  `json:"credit_limit"`                // 额度 = K × 保证金
+	Available        decimal.Decimal  `json:"available"`                   // 剩余额度（超限时为 0）
+	Breached         bool             `json:"breached"`
+	BreachedAt       *time.Time       `json:"breached_at,omitempty"` // 当前超限开始时间（巡检记录）
+}
+
+// UpdateOwnerCreditLimitReq 管理员设置房主信用额度倍数（为空恢复默认倍数）
+type UpdateOwnerCreditLimitReq struct {
+	MarginMultiplier *decimal.Decimal `json:"margin_multiplier"`
+}
@@ -0,0 +1,29 @@
+package model
+
+import (
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+// DefaultCurrency 内置默认币种（历史数据及未配置 currency.default 时使用）
+const DefaultCurrency = "CNY"
+
+// WalletBalanceField 非活跃币种钱包流水的 balance_field（如 wallet_usd），与活跃余额 balance 的流水链分开
+func WalletBalanceField(currency string) string {
+	return "wallet_" + strings.ToLower(currency)
+}
+
+// CurrencyWallet 玩家单一币种钱包
+type CurrencyWallet struct {
+	Currency      string          `json:"currency"`
+	Balance       decimal.Decimal `json:"balance"`
+	FrozenBalance decimal.Decimal `json:"frozen_balance"` // 仅活跃钱包可能有冻结余额
+	BonusBalance  decimal.Decimal `json:"bonus_balance"`  // 仅活跃钱包可能有奖励余额
+	Active        bool            `json:"active"`         // 是否为当前活跃钱包（游戏下注使用活跃钱包）
+}
+
+// SwitchCurrencyReq 切换活跃钱包币种请求
+type SwitchCurrencyReq struct {
+	Currency string `json:"currency" binding:"required"`
+}
@@ -0,0 +1,78 @@
+package model
+
+import "time"
+
+// FairnessScope 公平性统计范围
+type FairnessScope string
+
+const (
+	FairnessScopeGlobal FairnessScope = "global"
+	FairnessScopeRoom   FairnessScope = "room"
+)
+
+// FairnessRound 公平性统计读取的已结算回合
+type FairnessRound struct {
+	ID             int64
+	RoomID         int64
+	ParticipantIDs []int64
+	WinnerIDs      []int64
+}
+
+// FairnessRunsResult 玩家输赢序列的游程检验结果
+type FairnessRunsResult struct {
+	UserID int64   `json:"user_id"`
+	Rounds int     `json:"rounds"`
+	Wins   int     `json:"wins"`
+	Runs   int     `json:"runs"`
+	ZScore float64 `json:"z_score"`
+	PValue float64 `json:"p_value"`
+}
+
+// FairnessOutlier 获胜次数显著超出期望的玩家（按每回合获胜概率的泊松二项分布）
+type FairnessOutlier struct {
+	UserID       int64   `json:"user_id"`
+	Rounds       int     `json:"rounds"`
+	Wins         int     `json:"wins"`
+	ExpectedWins float64 `json:"expected_wins"`
+	PValue       float64 `json:"p_value"`
+}
+
+// FairnessReport 公平性统计报告（全局或单个房间）
+// 卡方检验按玩家比较获胜次数与按参与回合计算的期望；游程检验与获胜次数检验只列出显著的玩家
+// 显著性按同一次统计的全部检验（FamilySize 个）做 Benjamini–Hochberg 校正，p 值不超过 PValueCutoff 即显著
+type FairnessReport struct {
+	ID               int64                 `json:"id" db:"id"`
+	RunID            int64                 `json:"run_id" db:"run_id"` // 同一次统计生成的报告共用
+	Scope            FairnessScope         `json:"scope" db:"scope"`
+	RoomID           *int64                `json:"room_id,omitempty" db:"room_id"`
+	WindowStart      time.Time             `json:"window_start" db:"window_start"`
+	WindowEnd        time.Time             `json:"window_end" db:"window_end"`
+	Rounds           int                   `json:"rounds" db:"rounds"`
+	Players          int                   `json:"players" db:"players"`
+	ChiSquare        *float64              `json:"chi_square,omitempty" db:"chi_square"` // 样本不足时为空
+	DegreesOfFreedom int                   `json:"degrees_of_freedom" db:"degrees_of_freedom"`
+	ChiSquarePValue  *float64              `json:"chi_square_p_value,omitempty" db:"chi_square_p_value"`
+	RunsTested       int                   `json:"runs_tested" db:"runs_tested"`
+	RunsDeviations   []*FairnessRunsResult `json:"runs_deviations" db:"runs_deviations"`
+	Outliers         []*FairnessOutlier    `json:"outliers" db:"outliers"`
+	PValueThreshold  float64               `json:"p_value_threshold" db:"p_value_threshold"`     // 错误发现率
+	FamilySize       int                   `json:"family_size" db:"family_size"`                 // 同一次统计的检验总数
+	PValueCutoff     *float64              `json:"p_value_cutoff,omitempty" db:"p_value_cutoff"` // 校正后的 p 值界限，无显著检验时为空
+	Significant      bool                  `json:"significant" db:"significant"`                 // 任一检验显著
+	CreatedAt        time.Time             `json:"created_at" db:"created_at"`
+}
+
+// FairnessDashboard 公平性看板：最近一次统计的全局与各房间报告
+type FairnessDashboard struct {
+	Global *FairnessReport   `json:"global"`
+	Rooms  []*FairnessReport `json:"rooms"`
+}
+
+// FairnessReportQuery 公平性报告历史查询
+type FairnessReportQuery struct {
+	Scope       *FairnessScope `form:"scope" binding:"omitempty,oneof=global room"`
+	RoomID      *int64         `form:"room_id"`
+	Significant *bool          `form:"significant"`
+	Page        int            `form:"page" binding:"min=1"`
+	PageSize    int            `form:"page_size" binding:"min=1,max=100"`
+}
@@ -47,6 +47,13 @@ type GameRound struct {
 	PlatformEarning *decimal.Decimal `json:"platform_earning,omitempty" db:"platform_earning"`
 	ResidualAmount  *decimal.Decimal `json:"residual_amount,omitempty" db:"residual_amount"`
 
+	// 累进奖池（未开启时 JackpotID 为空）
+	JackpotID           *int64          `json:"jackpot_id,omitempty" db:"jackpot_id"`
+	JackpotContribution decimal.Decimal `json:"jackpot_contribution" db:"jackpot_contribution"` // 本回合注入累进奖池的金额（含正的舍入残值）
+	JackpotOdds         *int            `json:"jackpot_odds,omitempty" db:"jackpot_odds"`       // 本回合使用的触发概率 1/N
+	JackpotHit          bool            `json:"jackpot_hit" db:"jackpot_hit"`
+	JackpotPayout       decimal.Decimal `json:"jackpot_payout" db:"jackpot_payout"`             // 本回合触发时派发的总额
+
 	// Commit-Reveal 随机
 	CommitHash *string `json:"commit_hash,omitempty" db:"commit_hash"`
 	RevealSeed *string `json:"reveal_seed,omitempty" db:"reveal_seed"`
@@ -74,6 +81,10 @@ type RoomState struct {
 	PoolAmount     decimal.Decimal   `json:"pool_amount"`
 	CommitHash     string            `json:"commit_hash,omitempty"`
 	Seed           []byte            `json:"-"` // 内存中保存,不序列化
+	BonusStakes    map[int64]decimal.Decimal `json:"-"` // 本回合各参与者下注中由奖励余额支付的部分
+	CacheStakes    bool                      `json:"-"` // 本回合下注在余额写回缓存中扣除（派奖同样走缓存）
+
+	JackpotAmount *decimal.Decimal `json:"jackpot_amount,omitempty"` // 当前累进奖池金额，为空表示房间未开启累进奖池
 }
 
 // PlayerState 玩家内存状态
@@ -81,6 +92,7 @@ type PlayerState struct {
 	UserID            int64           `json:"user_id"`
 	Username          string          `json:"username"`
 	Balance           decimal.Decimal `json:"balance"`
+	BonusBalance      decimal.Decimal `json:"bonus_balance"`
 	AutoReady         bool            `json:"auto_ready"`
 	IsOnline          bool            `json:"is_online"`
 	OfflineSince      *time.Time      `json:"-"` // 离线开始时间，用于超时清理
@@ -56,6 +56,9 @@ type RoundDetail struct {
 	Winners         []Winner        `json:"winners"`
 	CreatedAt       time.Time       `json:"created_at"`
 	SettledAt       *time.Time      `json:"settled_at"`
+	JackpotOdds     *int            `json:"jackpot_odds,omitempty"` // 开启累进奖池时本回合的触发概率 1/N
+	JackpotHit      bool            `json:"jackpot_hit"`
+	JackpotPayout   decimal.Decimal `json:"jackpot_payout"`
 }
 
 // Participant 参与者信息
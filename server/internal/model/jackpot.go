@@ -0,0 +1,76 @@
+package model
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Jackpot 累进奖池
+// RoomID 为空时为房主级奖池，房主该币种下未单独开设奖池的房间共享
+type Jackpot struct {
+	ID               int64           `json:"id" db:"id"`
+	OwnerID          int64           `json:"owner_id" db:"owner_id"`
+	RoomID           *int64          `json:"room_id,omitempty" db:"room_id"`
+	Currency         string          `json:"currency" db:"currency"`
+	ContributionRate decimal.Decimal `json:"contribution_rate" db:"contribution_rate"` // 每回合从奖池抽取的比例
+	TriggerOdds      int             `json:"trigger_odds" db:"trigger_odds"`           // 每回合触发概率为 1/N
+	Balance          decimal.Decimal `json:"balance" db:"balance"`
+	TotalContributed decimal.Decimal `json:"total_contributed" db:"total_contributed"`
+	TotalPaid        decimal.Decimal `json:"total_paid" db:"total_paid"`
+	HitCount         int             `json:"hit_count" db:"hit_count"`
+	Enabled          bool            `json:"enabled" db:"enabled"`
+	LastHitAt        *time.Time      `json:"last_hit_at,omitempty" db:"last_hit_at"`
+	CreatedAt        time.Time       `json:"created_at" db:"created_at"`
+	UpdatedAt        time.Time       `json:"updated_at" db:"updated_at"`
+}
+
+// Contribution 本回合从奖池抽取注入累进奖池的金额（不超过赢家可分配的奖金）
+func (j *Jackpot) Contribution(pool, prizePool decimal.Decimal) decimal.Decimal {
+	amount := pool.Mul(j.ContributionRate).Round(2)
+	if amount.GreaterThan(prizePool) {
+		amount = prizePool
+	}
+	if amount.IsNegative() {
+		return decimal.Zero
+	}
+	return amount
+}
+
+// JackpotHit 累进奖池中奖记录
+type JackpotHit struct {
+	ID             int64           `json:"id" db:"id"`
+	JackpotID      int64           `json:"jackpot_id" db:"jackpot_id"`
+	RoomID         int64           `json:"room_id" db:"room_id"`
+	RoundID        int64           `json:"round_id" db:"round_id"`
+	WinnerIDs      []int64         `json:"winner_ids" db:"winner_ids"`
+	PrizePerWinner decimal.Decimal `json:"prize_per_winner" db:"prize_per_winner"`
+	Amount         decimal.Decimal `json:"amount" db:"amount"`
+	CreatedAt      time.Time       `json:"created_at" db:"created_at"`
+}
+
+// JackpotSettlement 回合结算中累进奖池的注入与派发结果
+type JackpotSettlement struct {
+	JackpotID      int64
+	Contribution   decimal.Decimal
+	Hit            bool
+	PrizePerWinner decimal.Decimal
+	Paid           decimal.Decimal
+	Payouts        map[int64]decimal.Decimal // 赢家 -> 派发金额（计入真实余额）
+	Balance        decimal.Decimal           // 注入与派发后的奖池余额
+}
+
+// SaveJackpotReq 房主开设或修改累进奖池
+type SaveJackpotReq struct {
+	RoomID           *int64          `json:"room_id"` // 为空表示房主级奖池
+	ContributionRate decimal.Decimal `json:"contribution_rate"`
+	TriggerOdds      int             `json:"trigger_odds" binding:"required,min=1"`
+	Enabled          *bool           `json:"enabled"` // 为空时保持原状态（新建默认开启）
+}
+
+// JackpotListQuery 累进奖池列表查询
+type JackpotListQuery struct {
+	OwnerID  *int64 `form:"owner_id"`
+	Page     int    `form:"page" binding:"min=1"`
+	PageSize int    `form:"page_size" binding:"min=1,max=100"`
+}
@@ -0,0 +1,46 @@
+package model
+
+import "time"
+
+// LoginFailureReason 登录失败原因
+type LoginFailureReason string
+
+const (
+	LoginFailureUserNotFound    LoginFailureReason = "user_not_found"
+	LoginFailureInvalidPassword LoginFailureReason = "invalid_password"
+)
+
+// LoginEvent 登录事件
+type LoginEvent struct {
+	ID                int64              `json:"id" db:"id"`
+	UserID            *int64             `json:"user_id,omitempty" db:"user_id"` // 用户名不存在时为空
+	Username          string             `json:"username" db:"username"`
+	Success           bool               `json:"success" db:"success"`
+	FailureReason     LoginFailureReason `json:"failure_reason,omitempty" db:"failure_reason"`
+	IP                string             `json:"ip" db:"ip"`
+	UserAgent         string             `json:"user_agent" db:"user_agent"`
+	DeviceFingerprint string             `json:"device_fingerprint,omitempty" db:"device_fingerprint"`
+	DeviceKey         string             `json:"device_key" db:"device_key"` // 设备标识：设备指纹，无指纹时为 User-Agent 摘要
+	NewDevice         bool               `json:"new_device" db:"new_device"` // 该用户首次以此设备登录成功
+	GeoLabel          string             `json:"geo_label,omitempty" db:"geo_label"`
+	CreatedAt         time.Time          `json:"created_at" db:"created_at"`
+}
+
+// KnownDevice 用户登录成功过的设备
+type KnownDevice struct {
+	DeviceKey         string    `json:"device_key"`
+	DeviceFingerprint string    `json:"device_fingerprint,omitempty"`
+	UserAgent         string    `json:"user_agent"` // 最近一次登录的 User-Agent
+	LastIP            string    `json:"last_ip"`
+	LastGeoLabel      string    `json:"last_geo_label,omitempty"`
+	LoginCount        int       `json:"login_count"`
+	FirstSeenAt       time.Time `json:"first_seen_at"`
+	LastSeenAt        time.Time `json:"last_seen_at"`
+}
+
+// LoginEventListQuery 登录历史查询
+type LoginEventListQuery struct {
+	Success  *bool `form:"success"`
+	Page     int   `form:"page" binding:"min=1"`
+	PageSize int   `form:"page_size" binding:"min=1,max=100"`
+}
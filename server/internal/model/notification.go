@@ -0,0 +1,175 @@
+package model
+
+import (
+	"net/url"
+	"time"
+)
+
+// NotificationChannelType 通知渠道类型
+type NotificationChannelType string
+
+const (
+	NotificationChannelWebhook  NotificationChannelType = "webhook"  // 通用 webhook（POST JSON，可选 HMAC 签名）
+	NotificationChannelEmail    NotificationChannelType = "email"    // SMTP 邮件
+	NotificationChannelSlack    NotificationChannelType = "slack"    // Slack 兼容的 incoming webhook
+	NotificationChannelDingTalk NotificationChannelType = "dingtalk" // 钉钉机器人 webhook（可选加签）
+)
+
+// NotificationSecretMask 返回给前端的渠道配置中密钥、密码、请求头取值与 webhook 地址的掩码；更新时提交掩码表示保留原值
+const NotificationSecretMask = "******"
+
+// NotificationChannelConfig 通知渠道配置（按渠道类型使用其中的字段）
+type NotificationChannelConfig struct {
+	URL      string            `json:"url,omitempty"`       // webhook/slack/dingtalk
+	Headers  map[string]string `json:"headers,omitempty"`   // webhook 附加请求头
+	Secret   string            `json:"secret,omitempty"`    // webhook 签名密钥 / 钉钉加签密钥
+	SMTPHost string            `json:"smtp_host,omitempty"` // email
+	SMTPPort int               `json:"smtp_port,omitempty"`
+	Username string            `json:"username,omitempty"`
+	Password string            `json:"password,omitempty"`
+	From     string            `json:"from,omitempty"`
+	To       []string          `json:"to,omitempty"`
+}
+
+// Masked 返回隐藏密钥、密码与请求头取值后的配置
+// webhook/Slack/钉钉地址的路径与查询参数中带有访问令牌，只保留协议与主机
+func (c NotificationChannelConfig) Masked() NotificationChannelConfig {
+	if c.URL != "" {
+		c.URL = MaskNotificationURL(c.URL)
+	}
+	if len(c.Headers) > 0 {
+		headers := make(map[string]string, len(c.Headers))
+		for name := range c.Headers {
+			headers[name] = NotificationSecretMask
+		}
+		c.Headers = headers
+	}
+	if c.Secret != "" {
+		c.Secret = NotificationSecretMask
+	}
+	if c.Password != "" {
+		c.Password = NotificationSecretMask
+	}
+	return c
+}
+
+// Unmask 将提交的配置中未修改的掩码还原为原配置的取值（地址与隐藏后的原地址相同时保留原地址）
+// 原配置中没有对应取值的掩码保持不变，由配置校验拒绝
+func (c NotificationChannelConfig) Unmask(original NotificationChannelConfig) NotificationChannelConfig {
+	if c.URL != "" && original.URL != "" && c.URL == MaskNotificationURL(original.URL) {
+		c.URL = original.URL
+	}
+	if len(c.Headers) > 0 {
+		headers := make(map[string]string, len(c.Headers))
+		for name, value := range c.Headers {
+			if prev, ok := original.Headers[name]; ok && value == NotificationSecretMask {
+				value = prev
+			}
+			headers[name] = value
+		}
+		c.Headers = headers
+	}
+	if c.Secret == NotificationSecretMask {
+		c.Secret = original.Secret
+	}
+	if c.Password == NotificationSecretMask {
+		c.Password = original.Password
+	}
+	return c
+}
+
+// MaskNotificationURL 隐藏地址中的访问令牌：只保留协议与主机，其余部分替换为掩码；无法解析时整体替换
+func MaskNotificationURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return NotificationSecretMask
+	}
+	return u.Scheme + "://" + u.Host + "/" + NotificationSecretMask
+}
+
+// NotificationChannel 通知渠道
+type NotificationChannel struct {
+	ID        int64                     `json:"id" db:"id"`
+	Name      string                    `json:"name" db:"name"`
+	Type      NotificationChannelType   `json:"type" db:"channel_type"`
+	Config    NotificationChannelConfig `json:"config" db:"config"`
+	Enabled   bool                      `json:"enabled" db:"enabled"`
+	CreatedBy *int64                    `json:"created_by,omitempty" db:"created_by"`
+	CreatedAt time.Time                 `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time                 `json:"updated_at" db:"updated_at"`
+}
+
+// NotificationRoute 通知路由规则：告警类型（为空表示全部）且严重程度不低于 MinSeverity 时投递到渠道
+type NotificationRoute struct {
+	ID          int64         `json:"id" db:"id"`
+	ChannelID   int64         `json:"channel_id" db:"channel_id"`
+	AlertTypes  []AlertType   `json:"alert_types" db:"alert_types"`
+	MinSeverity AlertSeverity `json:"min_severity" db:"min_severity"`
+	Enabled     bool          `json:"enabled" db:"enabled"`
+	CreatedAt   time.Time     `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time     `json:"updated_at" db:"updated_at"`
+}
+
+// AlertDeliveryStatus 告警投递状态
+type AlertDeliveryStatus string
+
+const (
+	AlertDeliveryPending AlertDeliveryStatus = "pending" // 待发送或等待重试
+	AlertDeliverySent    AlertDeliveryStatus = "sent"
+	AlertDeliveryFailed  AlertDeliveryStatus = "failed" // 达到最大重试次数
+)
+
+// AlertDelivery 告警投递记录（告警的通知日志）
+type AlertDelivery struct {
+	ID            int64                   `json:"id" db:"id"`
+	AlertID       int64                   `json:"alert_id" db:"alert_id"`
+	ChannelID     int64                   `json:"channel_id" db:"channel_id"`
+	ChannelName   string                  `json:"channel_name" db:"channel_name"`
+	ChannelType   NotificationChannelType `json:"channel_type" db:"channel_type"`
+	Status        AlertDeliveryStatus     `json:"status" db:"status"`
+	Attempts      int                     `json:"attempts" db:"attempts"`
+	LastError     *string                 `json:"last_error,omitempty" db:"last_error"`
+	NextAttemptAt time.Time               `json:"next_attempt_at" db:"next_attempt_at"`
+	DeliveredAt   *time.Time              `json:"delivered_at,omitempty" db:"delivered_at"`
+	CreatedAt     time.Time               `json:"created_at" db:"created_at"`
+	UpdatedAt     time.Time               `json:"updated_at" db:"updated_at"`
+}
+
+// CreateNotificationChannelReq 创建通知渠道请求
+type CreateNotificationChannelReq struct {
+	Name    string                    `json:"name" binding:"required,max=100"`
+	Type    NotificationChannelType   `json:"type" binding:"required,oneof=webhook email slack dingtalk"`
+	Config  NotificationChannelConfig `json:"config"`
+	Enabled *bool                     `json:"enabled"`
+}
+
+// UpdateNotificationChannelReq 更新通知渠道请求（为空的字段保持不变）
+type UpdateNotificationChannelReq struct {
+	Name    *string                    `json:"name" binding:"omitempty,max=100"`
+	Config  *NotificationChannelConfig `json:"config"`
+	Enabled *bool                      `json:"enabled"`
+}
+
+// CreateNotificationRouteReq 创建通知路由请求
+type CreateNotificationRouteReq struct {
+	ChannelID   int64         `json:"channel_id" binding:"required,min=1"`
+	AlertTypes  []AlertType   `json:"alert_types" binding:"max=50"`
+	MinSeverity AlertSeverity `json:"min_severity" binding:"required,oneof=info warning critical"`
+	Enabled     *bool         `json:"enabled"`
+}
+
+// UpdateNotificationRouteReq 更新通知路由请求（为空的字段保持不变）
+type UpdateNotificationRouteReq struct {
+	AlertTypes  *[]AlertType   `json:"alert_types"`
+	MinSeverity *AlertSeverity `json:"min_severity" binding:"omitempty,oneof=info warning critical"`
+	Enabled     *bool          `json:"enabled"`
+}
+
+// AlertDeliveryQuery 告警投递记录查询
+type AlertDeliveryQuery struct {
+	AlertID   *int64               `form:"alert_id"`
+	ChannelID *int64               `form:"channel_id"`
+	Status    *AlertDeliveryStatus `form:"status" binding:"omitempty,oneof=pending sent failed"`
+	Page      int                  `form:"page" binding:"min=1"`
+	PageSize  int                  `form:"page_size" binding:"min=1,max=100"`
+}
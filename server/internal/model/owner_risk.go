@@ -0,0 +1,72 @@
+package model
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// OwnerRiskDay 房主当日风控指标（每日房主对账后汇总）
+type OwnerRiskDay struct {
+	OwnerID                int64           `json:"owner_id" db:"owner_id"`
+	Day                    time.Time       `json:"day" db:"day"`
+	Currency               string          `json:"currency" db:"currency"`
+	RoomVolume             decimal.Decimal `json:"room_volume" db:"room_volume"`
+	Commission             decimal.Decimal `json:"commission" db:"commission"`
+	MarginBalance          decimal.Decimal `json:"margin_balance" db:"margin_balance"`
+	ConservationDifference decimal.Decimal `json:"conservation_difference" db:"conservation_difference"`
+	PlayerRounds           int             `json:"player_rounds" db:"player_rounds"`
+	PlayerWins             int             `json:"player_wins" db:"player_wins"`
+	ExpectedWins           float64         `json:"expected_wins" db:"expected_wins"`
+	WinVariance            float64         `json:"win_variance" db:"win_variance"`
+	CreatedAt              time.Time       `json:"created_at" db:"created_at"`
+}
+
+// OwnerWithdrawDeposits 房主提现及其前一段时间内房主审批通过的玩家充值
+type OwnerWithdrawDeposits struct {
+	OwnerID       int64           `json:"owner_id"`
+	RequestID     int64           `json:"request_id"`
+	Amount        decimal.Decimal `json:"amount"`
+	Currency      string          `json:"currency"`
+	RequestedAt   time.Time       `json:"requested_at"`
+	Deposits      int             `json:"deposits"`
+	DepositAmount decimal.Decimal `json:"deposit_amount"`
+}
+
+// OwnerRiskSignal 房主维度风控标记详情（只填写与命中信号相关的字段）
+type OwnerRiskSignal struct {
+	Day      string `json:"day,omitempty"`
+	Currency string `json:"currency,omitempty"`
+
+	// 佣金收入与房间流水
+	RoomVolume     *decimal.Decimal `json:"room_volume,omitempty"`
+	Commission     *decimal.Decimal `json:"commission,omitempty"`
+	CommissionRate *decimal.Decimal `json:"commission_rate,omitempty"` // 当日抽成收入 / 房间流水
+	BaselineRate   *decimal.Decimal `json:"baseline_rate,omitempty"`   // 此前窗口内的平均抽成比例
+
+	// 保证金回撤
+	MarginBalance *decimal.Decimal `json:"margin_balance,omitempty"`
+	MarginPeak    *decimal.Decimal `json:"margin_peak,omitempty"`
+	Drawdown      float64          `json:"drawdown,omitempty"`
+
+	// 玩家整体胜率
+	PlayerRounds int     `json:"player_rounds,omitempty"`
+	PlayerWins   int     `json:"player_wins,omitempty"`
+	ExpectedWins float64 `json:"expected_wins,omitempty"`
+	WinZScore    float64 `json:"win_z_score,omitempty"`
+
+	// 资金守恒差额波动
+	Difference     *decimal.Decimal `json:"difference,omitempty"`
+	PrevDifference *decimal.Decimal `json:"prev_difference,omitempty"`
+
+	// 提现前集中审批充值
+	Withdraw *OwnerWithdrawDeposits `json:"withdraw,omitempty"`
+}
+
+// OwnerRiskFinding 房主维度风控检测结果
+type OwnerRiskFinding struct {
+	OwnerID  int64
+	FlagType RiskFlagType
+	Severity RiskSeverity
+	Signal   *OwnerRiskSignal
+}
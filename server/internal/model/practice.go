@@ -0,0 +1,23 @@
+package model
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// PracticeBalance 练习房游戏币余额（与真实余额完全隔离）
+type PracticeBalance struct {
+	UserID     int64           `json:"user_id" db:"user_id"`
+	Chips      decimal.Decimal `json:"chips" db:"chips"`
+	RefilledAt *time.Time      `json:"refilled_at,omitempty" db:"refilled_at"`
+	UpdatedAt  time.Time       `json:"updated_at" db:"updated_at"`
+}
+
+// PracticeChipsInfo 玩家游戏币信息
+type PracticeChipsInfo struct {
+	Chips        decimal.Decimal `json:"chips"`
+	RefillAmount decimal.Decimal `json:"refill_amount"` // 补充后的游戏币数量
+	CanRefill    bool            `json:"can_refill"`    // 当前余额低于补充数量时可补充
+	RefilledAt   *time.Time      `json:"refilled_at,omitempty"`
+}
@@ -0,0 +1,95 @@
+package model
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// RebateBasis 返水计算基数
+type RebateBasis string
+
+const (
+	RebateBasisVolume  RebateBasis = "volume"   // 按有效流水
+	RebateBasisNetLoss RebateBasis = "net_loss" // 按净输额
+)
+
+// RebateTier 返水档位
+type RebateTier struct {
+	MinAmount decimal.Decimal `json:"min_amount" db:"min_amount"` // 档位门槛（含）
+	Rate      decimal.Decimal `json:"rate" db:"rate"`             // 返水比例（小数形式）
+}
+
+// OwnerRebateRules 房主返水规则
+type OwnerRebateRules struct {
+	OwnerID int64         `json:"owner_id"`
+	Basis   RebateBasis   `json:"basis"`
+	Tiers   []*RebateTier `json:"tiers"` // 按门槛升序
+}
+
+// UpdateRebateRulesReq 更新返水规则请求（整体替换，tiers 为空表示关闭返水）
+type UpdateRebateRulesReq struct {
+	Basis RebateBasis   `json:"basis" binding:"required"`
+	Tiers []*RebateTier `json:"tiers"`
+}
+
+// RebatePeriodReq 返水周期（含首尾日期 YYYY-MM-DD）
+type RebatePeriodReq struct {
+	StartDate string `form:"start_date" json:"start_date" binding:"required"`
+	EndDate   string `form:"end_date" json:"end_date" binding:"required"`
+}
+
+// PlayerWagerStat 玩家周期内游戏流水统计
+type PlayerWagerStat struct {
+	UserID   int64           `json:"user_id"`
+	Username string          `json:"username"`
+	Bet      decimal.Decimal `json:"bet"`    // 下注合计
+	Win      decimal.Decimal `json:"win"`    // 赢得奖金合计
+	Refund   decimal.Decimal `json:"refund"` // 退款合计
+}
+
+// RebatePayout 返水发放记录
+type RebatePayout struct {
+	ID          int64               `json:"id" db:"id"`
+	OwnerID     int64               `json:"owner_id" db:"owner_id"`
+	Basis       RebateBasis         `json:"basis" db:"basis"`
+	PeriodStart time.Time           `json:"period_start" db:"period_start"`
+	PeriodEnd   time.Time           `json:"period_end" db:"period_end"` // 不含
+	PlayerCount int                 `json:"player_count" db:"player_count"`
+	TotalAmount decimal.Decimal     `json:"total_amount" db:"total_amount"`
+	OperatorID  int64               `json:"operator_id" db:"operator_id"`
+	CreatedAt   time.Time           `json:"created_at" db:"created_at"`
+	Items       []*RebatePayoutItem `json:"items,omitempty"`
+}
+
+// RebatePayoutItem 返水发放明细
+type RebatePayoutItem struct {
+	ID             int64           `json:"id" db:"id"`
+	PayoutID       int64           `json:"payout_id" db:"payout_id"`
+	UserID         int64           `json:"user_id" db:"user_id"`
+	Username       string          `json:"username" db:"-"`
+	Volume         decimal.Decimal `json:"volume" db:"volume"`
+	NetLoss        decimal.Decimal `json:"net_loss" db:"net_loss"`
+	EligibleAmount decimal.Decimal `json:"eligible_amount" db:"eligible_amount"`
+	Rate           decimal.Decimal `json:"rate" db:"rate"`
+	Amount         decimal.Decimal `json:"amount" db:"amount"`
+}
+
+// RebatePreview 返水预览（不落库）
+type RebatePreview struct {
+	OwnerID        int64               `json:"owner_id"`
+	Basis          RebateBasis         `json:"basis"`
+	PeriodStart    time.Time           `json:"period_start"`
+	PeriodEnd      time.Time           `json:"period_end"`
+	Items          []*RebatePayoutItem `json:"items"`
+	TotalAmount    decimal.Decimal     `json:"total_amount"`
+	AvailableFunds decimal.Decimal     `json:"available_funds"` // 房主当前佣金余额
+	AlreadyPaid    bool                `json:"already_paid"`    // 周期与已发放记录重叠
+}
+
+// RebatePayoutListQuery 返水发放记录查询
+type RebatePayoutListQuery struct {
+	OwnerID  *int64
+	Page     int
+	PageSize int
+}
@@ -0,0 +1,102 @@
+package model
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// ReferralStatus 推荐关系状态
+type ReferralStatus string
+
+const (
+	ReferralActive  ReferralStatus = "active"  // 正常发放奖励
+	ReferralBlocked ReferralStatus = "blocked" // 疑似作弊，停止发放
+)
+
+// ReferralRewardType 推荐奖励类型
+type ReferralRewardType string
+
+const (
+	ReferralRewardCommissionShare ReferralRewardType = "commission_share" // 被推荐人佣金分成
+	ReferralRewardFirstRounds     ReferralRewardType = "first_rounds"     // 被推荐人完成前 N 局的一次性奖励
+)
+
+// ReferralRewardStatus 推荐奖励状态
+type ReferralRewardStatus string
+
+const (
+	ReferralRewardPaid    ReferralRewardStatus = "paid"
+	ReferralRewardBlocked ReferralRewardStatus = "blocked"
+)
+
+// ReferralFundingSource 推荐奖励资金来源
+type ReferralFundingSource string
+
+const (
+	ReferralFundingOwner    ReferralFundingSource = "owner"    // 房主佣金余额（分成基数为房主佣金）
+	ReferralFundingPlatform ReferralFundingSource = "platform" // 平台余额（分成基数为平台抽成）
+)
+
+// Referral 推荐关系（推荐人与被推荐人属于同一房主）
+type Referral struct {
+	RefereeID   int64          `json:"referee_id" db:"referee_id"`
+	RefereeName string         `json:"referee_name" db:"-"`
+	ReferrerID  int64          `json:"referrer_id" db:"referrer_id"`
+	OwnerID     int64          `json:"owner_id" db:"owner_id"`
+	Status      ReferralStatus `json:"status" db:"status"`
+	BlockReason *string        `json:"block_reason,omitempty" db:"block_reason"`
+	CreatedAt   time.Time      `json:"created_at" db:"created_at"`
+}
+
+// ReferralReward 推荐奖励记录
+type ReferralReward struct {
+	ID            int64                 `json:"id" db:"id"`
+	ReferrerID    int64                 `json:"referrer_id" db:"referrer_id"`
+	ReferrerName  string                `json:"referrer_name,omitempty" db:"-"`
+	RefereeID     int64                 `json:"referee_id" db:"referee_id"`
+	RefereeName   string                `json:"referee_name,omitempty" db:"-"`
+	OwnerID       int64                 `json:"owner_id" db:"owner_id"`
+	RewardType    ReferralRewardType    `json:"reward_type" db:"reward_type"`
+	FundingSource ReferralFundingSource `json:"funding_source" db:"funding_source"`
+	PeriodStart   *time.Time            `json:"period_start,omitempty" db:"period_start"`
+	PeriodEnd     *time.Time            `json:"period_end,omitempty" db:"period_end"` // 不含
+	BaseAmount    decimal.Decimal       `json:"base_amount" db:"base_amount"`
+	Rate          decimal.Decimal       `json:"rate" db:"rate"`
+	Amount        decimal.Decimal       `json:"amount" db:"amount"`
+	Status        ReferralRewardStatus  `json:"status" db:"status"`
+	BlockReason   *string               `json:"block_reason,omitempty" db:"block_reason"`
+	CreatedAt     time.Time             `json:"created_at" db:"created_at"`
+}
+
+// RefereeSummary 推荐面板中的被推荐人概况
+type RefereeSummary struct {
+	UserID       int64           `json:"user_id"`
+	Username     string          `json:"username"`
+	Status       ReferralStatus  `json:"status"`
+	RoundsPlayed int             `json:"rounds_played"`
+	Qualified    bool            `json:"qualified"` // 已完成首 N 局
+	RewardTotal  decimal.Decimal `json:"reward_total"`
+	JoinedAt     time.Time       `json:"joined_at"`
+}
+
+// ReferralDashboard 推荐面板
+type ReferralDashboard struct {
+	ReferralCode        string            `json:"referral_code"`
+	CommissionShareRate decimal.Decimal   `json:"commission_share_rate"`
+	QualifyingRounds    int               `json:"qualifying_rounds"`
+	FirstRoundsReward   decimal.Decimal   `json:"first_rounds_reward"`
+	RefereeCount        int               `json:"referee_count"`
+	TotalRewards        decimal.Decimal   `json:"total_rewards"`
+	Referees            []*RefereeSummary `json:"referees"`
+	RecentRewards       []*ReferralReward `json:"recent_rewards"`
+}
+
+// ReferralRewardListQuery 推荐奖励记录查询
+type ReferralRewardListQuery struct {
+	OwnerID    *int64
+	ReferrerID *int64
+	Status     *ReferralRewardStatus
+	Page       int
+	PageSize   int
+}
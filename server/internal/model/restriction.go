@@ -0,0 +1,109 @@
+package model
+
+import "time"
+
+// RestrictionType 账户限制类型
+type RestrictionType string
+
+const (
+	RestrictionNoBetting    RestrictionType = "no_betting"    // 禁止下注
+	RestrictionNoWithdrawal RestrictionType = "no_withdrawal" // 禁止提现
+	RestrictionChatMuted    RestrictionType = "chat_muted"    // 禁言
+	RestrictionSuspended    RestrictionType = "suspended"     // 全面封停（包含以上全部限制）
+)
+
+// Valid 是否为合法的限制类型
+func (t RestrictionType) Valid() bool {
+	switch t {
+	case RestrictionNoBetting, RestrictionNoWithdrawal, RestrictionChatMuted, RestrictionSuspended:
+		return true
+	}
+	return false
+}
+
+// Blocks 该限制是否禁止 action 对应的操作（全面封停禁止一切受限操作）
+func (t RestrictionType) Blocks(action RestrictionType) bool {
+	return t == action || t == RestrictionSuspended
+}
+
+// RestrictionStatus 账户限制状态
+type RestrictionStatus string
+
+const (
+	RestrictionStatusActive  RestrictionStatus = "active"
+	RestrictionStatusRevoked RestrictionStatus = "revoked"
+	RestrictionStatusExpired RestrictionStatus = "expired"
+)
+
+// RestrictionSource 账户限制来源
+type RestrictionSource string
+
+const (
+	RestrictionSourceAuto   RestrictionSource = "auto"   // 风控规则按严重程度自动施加
+	RestrictionSourceManual RestrictionSource = "manual" // 管理员施加
+)
+
+// 推送给玩家的限制原因代码（不暴露具体规则）
+const (
+	RestrictionReasonRiskControl = "risk_control"          // 触发风控规则，等待审核
+	RestrictionReasonRiskReview  = "risk_review_confirmed" // 风控审核确认违规
+	RestrictionReasonAdminAction = "admin_action"          // 管理员操作
+)
+
+// AccountRestriction 账户限制
+type AccountRestriction struct {
+	ID        int64             `json:"id" db:"id"`
+	UserID    int64             `json:"user_id" db:"user_id"`
+	Type      RestrictionType   `json:"type" db:"restriction_type"`
+	Status    RestrictionStatus `json:"status" db:"status"`
+	Reason    string            `json:"reason_code" db:"reason_code"`
+	Source    RestrictionSource `json:"source" db:"source"`
+	FlagID    *int64            `json:"flag_id,omitempty" db:"flag_id"`
+	RuleID    *int64            `json:"rule_id,omitempty" db:"rule_id"`
+	Remark    string            `json:"remark,omitempty" db:"remark"`
+	CreatedBy *int64            `json:"created_by,omitempty" db:"created_by"`
+	ExpiresAt time.Time         `json:"expires_at" db:"expires_at"`
+	EndedAt   *time.Time        `json:"ended_at,omitempty" db:"ended_at"`
+	EndedBy   *int64            `json:"ended_by,omitempty" db:"ended_by"`
+	CreatedAt time.Time         `json:"created_at" db:"created_at"`
+}
+
+// RestrictionAuditAction 限制审计动作
+type RestrictionAuditAction string
+
+const (
+	RestrictionAuditApplied RestrictionAuditAction = "applied"
+	RestrictionAuditRevoked RestrictionAuditAction = "revoked"
+	RestrictionAuditExpired RestrictionAuditAction = "expired"
+)
+
+// RestrictionAuditEntry 限制审计记录
+type RestrictionAuditEntry struct {
+	ID            int64                  `json:"id" db:"id"`
+	RestrictionID int64                  `json:"restriction_id" db:"restriction_id"`
+	UserID        int64                  `json:"user_id" db:"user_id"`
+	Type          RestrictionType        `json:"type" db:"restriction_type"`
+	Action        RestrictionAuditAction `json:"action" db:"action"`
+	OperatorID    *int64                 `json:"operator_id,omitempty" db:"operator_id"`
+	Remark        string                 `json:"remark,omitempty" db:"remark"`
+	CreatedAt     time.Time              `json:"created_at" db:"created_at"`
+}
+
+// ApplyRestrictionReq 管理员施加账户限制请求
+type ApplyRestrictionReq struct {
+	Types  []RestrictionType `json:"types" binding:"required,min=1,dive,oneof=no_betting no_withdrawal chat_muted suspended"`
+	Hours  int               `json:"hours" binding:"omitempty,min=1,max=8760"` // 为空时使用默认时长
+	FlagID *int64            `json:"flag_id"`
+	Remark string            `json:"remark"`
+}
+
+// RevokeRestrictionReq 解除账户限制请求
+type RevokeRestrictionReq struct {
+	Remark string `json:"remark"`
+}
+
+// RestrictionAuditQuery 限制审计查询
+type RestrictionAuditQuery struct {
+	Page     int `form:"page" binding:"min=1"`
+	PageSize int `form:"page_size" binding:"min=1,max=100"`
+}
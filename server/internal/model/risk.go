@@ -14,8 +14,41 @@ const (
 	RiskFlagHighWinRate     RiskFlagType = "high_win_rate"
 	RiskFlagMultiAccount    RiskFlagType = "multi_account"
 	RiskFlagLargeTransaction RiskFlagType = "large_transaction"
+	RiskFlagCircularTransfer RiskFlagType = "circular_transfer"
+	RiskFlagReferralAbuse    RiskFlagType = "referral_abuse"
+	RiskFlagRuleHit          RiskFlagType = "rule_hit" // 风控规则命中（规则未指定标记类型时使用）
+	RiskFlagCollusion        RiskFlagType = "collusion" // 串通（关联账户联合胜率异常或资金归集）
+	RiskFlagNewDeviceWithdraw RiskFlagType = "new_device_withdrawal" // 新设备登录后不久即申请提现
+	RiskFlagFundVelocity      RiskFlagType = "fund_velocity"         // 短时间内充值或提现次数过多
+	RiskFlagQuickWithdraw     RiskFlagType = "quick_withdrawal"      // 充值后很少下注即提现
+
+	// 房主维度（标记的 user_id 为房主）
+	RiskFlagOwnerCommission      RiskFlagType = "owner_commission_anomaly"        // 佣金收入与房间流水不符
+	RiskFlagOwnerDepositWithdraw RiskFlagType = "owner_deposit_before_withdrawal" // 提现前短时间内集中审批玩家充值
+	RiskFlagOwnerMarginDrawdown  RiskFlagType = "owner_margin_drawdown"           // 保证金大幅回撤
+	RiskFlagOwnerPlayerWinRate   RiskFlagType = "owner_player_win_rate"           // 名下房间玩家整体胜率偏离期望
+	RiskFlagOwnerConservation    RiskFlagType = "owner_conservation_swing"        // 资金守恒差额大幅波动
 )
 
+// OwnerRiskFlagTypes 房主维度的风控标记类型
+var OwnerRiskFlagTypes = []RiskFlagType{
+	RiskFlagOwnerCommission,
+	RiskFlagOwnerDepositWithdraw,
+	RiskFlagOwnerMarginDrawdown,
+	RiskFlagOwnerPlayerWinRate,
+	RiskFlagOwnerConservation,
+}
+
+// IsOwnerScoped 是否为房主维度的标记类型
+func (t RiskFlagType) IsOwnerScoped() bool {
+	for _, ot := range OwnerRiskFlagTypes {
+		if t == ot {
+			return true
+		}
+	}
+	return false
+}
+
 // RiskFlagStatus 风控标记状态
 type RiskFlagStatus string
 
@@ -33,9 +66,13 @@ type RiskFlag struct {
 	FlagType   RiskFlagType   `json:"flag_type" db:"flag_type"`
 	Details    string         `json:"details" db:"details"`
 	Status     RiskFlagStatus `json:"status" db:"status"`
+	Severity   RiskSeverity   `json:"severity" db:"severity"`
+	RuleID     *int64         `json:"rule_id,omitempty" db:"rule_id"` // 由风控规则产生时的规则ID
 	ReviewedBy *int64         `json:"reviewed_by,omitempty" db:"reviewed_by"`
 	ReviewedAt *time.Time     `json:"reviewed_at,omitempty" db:"reviewed_at"`
+	ReviewRemark string       `json:"review_remark,omitempty" db:"review_remark"` // 审核备注
 	CreatedAt  time.Time      `json:"created_at" db:"created_at"`
+	RiskScore  *float64       `json:"risk_score,omitempty" db:"risk_score"` // 用户当前风险评分（仅列表返回）
 }
 
 // RiskFlagDetails 风控标记详情
@@ -46,6 +83,39 @@ type RiskFlagDetails struct {
 	DeviceFingerprint string        `json:"device_fingerprint,omitempty"`
 	RelatedUserIDs  []int64         `json:"related_user_ids,omitempty"`
 	TransactionAmount decimal.Decimal `json:"transaction_amount,omitempty"`
+
+	// 风控规则命中信息
+	RuleID      *int64                 `json:"rule_id,omitempty"`
+	RuleName    string                 `json:"rule_name,omitempty"`
+	RuleVersion int                    `json:"rule_version,omitempty"`
+	Event       RiskRuleEvent          `json:"event,omitempty"`
+	Variables   map[string]interface{} `json:"variables,omitempty"` // 条件引用的变量取值
+
+	// 新设备登录信息
+	LoginEventID  *int64     `json:"login_event_id,omitempty"`
+	LoginIP       string     `json:"login_ip,omitempty"`
+	LoginGeo      string     `json:"login_geo,omitempty"`
+	LoginAt       *time.Time `json:"login_at,omitempty"`
+	FundRequestID *int64     `json:"fund_request_id,omitempty"`
+
+	// 串通检测信息（关联账户见 RelatedUserIDs）
+	Collusion *CollusionCluster `json:"collusion,omitempty"`
+
+	// 资金频率检测信息（资金申请见 FundRequestID）
+	FundVelocity *FundVelocity `json:"fund_velocity,omitempty"`
+
+	// 房主维度检测信息
+	Owner *OwnerRiskSignal `json:"owner,omitempty"`
+}
+
+// FundVelocity 资金频率检测信息
+type FundVelocity struct {
+	WindowMinutes int             `json:"window_minutes"`             // 统计窗口（分钟）
+	Deposits      int             `json:"deposits"`                   // 窗口内已通过的充值笔数
+	Withdrawals   int             `json:"withdrawals"`                // 窗口内已通过的提现笔数
+	DepositAmount decimal.Decimal `json:"deposit_amount"`             // 窗口内已通过的充值金额
+	Wagered       decimal.Decimal `json:"wagered"`                    // 窗口内首笔充值之后的下注金额
+	LastDepositAt *time.Time      `json:"last_deposit_at,omitempty"`  // 窗口内最近一笔充值的通过时间
 }
 
 // RiskFlagListQuery 风控标记列表查询
@@ -53,6 +123,8 @@ type RiskFlagListQuery struct {
 	UserID   *int64          `form:"user_id"`
 	FlagType *RiskFlagType   `form:"flag_type"`
 	Status   *RiskFlagStatus `form:"status"`
+	Scope    string          `form:"scope" binding:"omitempty,oneof=user owner"` // 按标记维度过滤（房主维度见 OwnerRiskFlagTypes）
+	SortBy   string          `form:"sort_by" binding:"omitempty,oneof=created_at risk_score"` // 默认按创建时间
 	Page     int             `form:"page" binding:"min=1"`
 	PageSize int             `form:"page_size" binding:"min=1,max=100"`
 }
@@ -61,15 +133,30 @@ type RiskFlagListQuery struct {
 type ReviewRiskFlagReq struct {
 	Action string `json:"action" binding:"required,oneof=confirm dismiss"`
 	Remark string `json:"remark"`
+
+	// 确认时可同时对账户施加限制（时长为空时使用默认时长）
+	Restrictions     []RestrictionType `json:"restrictions" binding:"omitempty,dive,oneof=no_betting no_withdrawal chat_muted suspended"`
+	RestrictionHours int               `json:"restriction_hours" binding:"omitempty,min=1,max=8760"`
 }
 
 // RiskConfig 风控配置
+// 阈值类检查已迁移到数据库中的风控规则（见 RiskRule，021 迁移按本配置预置了等价规则），
+// 本配置用于未启用规则引擎时的回退检查，以及循环转账与资金变动（大额、日交易量、资金频率）检查
 type RiskConfig struct {
 	ConsecutiveWinThreshold int     `json:"consecutive_win_threshold"` // 连续获胜阈值
 	WinRateThreshold        float64 `json:"win_rate_threshold"`        // 胜率阈值
 	WinRateMinRounds        int     `json:"win_rate_min_rounds"`       // 胜率检测最小回合数
 	LargeTransactionAmount  decimal.Decimal `json:"large_transaction_amount"` // 大额交易阈值
 	DailyVolumeThreshold    decimal.Decimal `json:"daily_volume_threshold"`   // 日交易量阈值
+	CircularTransferWindow  time.Duration   `json:"circular_transfer_window"`  // 循环转账检测时间窗口
+	CircularTransferMaxHops int             `json:"circular_transfer_max_hops"` // 循环转账检测最大跳数
+
+	// 资金频率检查（资金申请审批通过后执行）
+	FundVelocityWindow        time.Duration   `json:"fund_velocity_window"`          // 充值/提现笔数统计窗口
+	MaxDepositsPerWindow      int             `json:"max_deposits_per_window"`       // 窗口内已通过的充值笔数上限
+	MaxWithdrawalsPerWindow   int             `json:"max_withdrawals_per_window"`    // 窗口内已通过的提现笔数上限
+	QuickWithdrawWindow       time.Duration   `json:"quick_withdraw_window"`         // 充值后多久内提现视为快进快出
+	QuickWithdrawMinPlayRatio decimal.Decimal `json:"quick_withdraw_min_play_ratio"` // 下注金额低于充值金额该比例时标记
 }
 
 // DefaultRiskConfig 默认风控配置
@@ -79,4 +166,12 @@ var DefaultRiskConfig = RiskConfig{
 	WinRateMinRounds:        50,
 	LargeTransactionAmount:  decimal.NewFromInt(10000),
 	DailyVolumeThreshold:    decimal.NewFromInt(100000),
+	CircularTransferWindow:  72 * time.Hour,
+	CircularTransferMaxHops: 4,
+
+	FundVelocityWindow:        time.Hour,
+	MaxDepositsPerWindow:      5,
+	MaxWithdrawalsPerWindow:   3,
+	QuickWithdrawWindow:       2 * time.Hour,
+	QuickWithdrawMinPlayRatio: decimal.NewFromFloat(0.3),
 }
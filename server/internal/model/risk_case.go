@@ -0,0 +1,146 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// RiskCaseStatus 风控案件状态
+type RiskCaseStatus string
+
+const (
+	RiskCaseStatusOpen          RiskCaseStatus = "open"
+	RiskCaseStatusInvestigating RiskCaseStatus = "investigating"
+	RiskCaseStatusResolved      RiskCaseStatus = "resolved"
+)
+
+// RiskCaseEvidenceType 案件证据类型
+type RiskCaseEvidenceType string
+
+const (
+	RiskCaseEvidenceRound       RiskCaseEvidenceType = "round"       // 游戏回合
+	RiskCaseEvidenceTransaction RiskCaseEvidenceType = "transaction" // 资金流水
+	RiskCaseEvidenceLoginEvent  RiskCaseEvidenceType = "login_event" // 登录事件
+)
+
+// RiskCaseAuditAction 案件审计动作
+type RiskCaseAuditAction string
+
+const (
+	RiskCaseAuditCreated       RiskCaseAuditAction = "created"
+	RiskCaseAuditFlagsAdded    RiskCaseAuditAction = "flags_added"
+	RiskCaseAuditAssigned      RiskCaseAuditAction = "assigned"
+	RiskCaseAuditStatusChanged RiskCaseAuditAction = "status_changed"
+	RiskCaseAuditNoteAdded     RiskCaseAuditAction = "note_added"
+	RiskCaseAuditEvidenceAdded RiskCaseAuditAction = "evidence_added"
+	RiskCaseAuditFlagReviewed  RiskCaseAuditAction = "flag_reviewed" // 案件内的风控标记被审核
+)
+
+// RiskCase 风控案件（归集同一用户或同一关联簇的风控标记）
+type RiskCase struct {
+	ID         int64          `json:"id" db:"id"`
+	Title      string         `json:"title" db:"title"`
+	UserIDs    []int64        `json:"user_ids" db:"user_ids"`
+	Status     RiskCaseStatus `json:"status" db:"status"`
+	AssigneeID *int64         `json:"assignee_id,omitempty" db:"assignee_id"`
+	Resolution string         `json:"resolution,omitempty" db:"resolution"`
+	CreatedBy  int64          `json:"created_by" db:"created_by"`
+	CreatedAt  time.Time      `json:"created_at" db:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at" db:"updated_at"`
+	ResolvedAt *time.Time     `json:"resolved_at,omitempty" db:"resolved_at"`
+	FlagCount  int            `json:"flag_count" db:"flag_count"`
+}
+
+// RiskCaseNote 案件备注（ParentID 指向回复的备注）
+type RiskCaseNote struct {
+	ID        int64     `json:"id" db:"id"`
+	CaseID    int64     `json:"case_id" db:"case_id"`
+	ParentID  *int64    `json:"parent_id,omitempty" db:"parent_id"`
+	AuthorID  int64     `json:"author_id" db:"author_id"`
+	Body      string    `json:"body" db:"body"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// RiskCaseEvidence 案件证据（Snapshot 为添加时的记录快照）
+type RiskCaseEvidence struct {
+	ID        int64                `json:"id" db:"id"`
+	CaseID    int64                `json:"case_id" db:"case_id"`
+	Type      RiskCaseEvidenceType `json:"type" db:"evidence_type"`
+	RefID     int64                `json:"ref_id" db:"ref_id"`
+	Snapshot  json.RawMessage      `json:"snapshot" db:"snapshot"`
+	Remark    string               `json:"remark,omitempty" db:"remark"`
+	AddedBy   int64                `json:"added_by" db:"added_by"`
+	CreatedAt time.Time            `json:"created_at" db:"created_at"`
+}
+
+// RiskCaseAuditEntry 案件审计记录
+type RiskCaseAuditEntry struct {
+	ID         int64               `json:"id" db:"id"`
+	CaseID     int64               `json:"case_id" db:"case_id"`
+	Action     RiskCaseAuditAction `json:"action" db:"action"`
+	OperatorID *int64              `json:"operator_id,omitempty" db:"operator_id"`
+	Details    json.RawMessage     `json:"details" db:"details"`
+	CreatedAt  time.Time           `json:"created_at" db:"created_at"`
+}
+
+// RiskCaseDetail 案件详情
+type RiskCaseDetail struct {
+	*RiskCase
+	Flags    []*RiskFlag         `json:"flags"`
+	Notes    []*RiskCaseNote     `json:"notes"`
+	Evidence []*RiskCaseEvidence `json:"evidence"`
+}
+
+// ===== 请求/响应类型 =====
+
+// CreateRiskCaseReq 创建案件请求
+type CreateRiskCaseReq struct {
+	Title      string  `json:"title" binding:"required,max=200"`
+	FlagIDs    []int64 `json:"flag_ids" binding:"required,min=1,max=100"`
+	AssigneeID *int64  `json:"assignee_id"`
+	Note       string  `json:"note"` // 可选的首条备注
+}
+
+// AddRiskCaseFlagsReq 追加案件标记请求
+type AddRiskCaseFlagsReq struct {
+	FlagIDs []int64 `json:"flag_ids" binding:"required,min=1,max=100"`
+}
+
+// AssignRiskCaseReq 指派案件请求（AssigneeID 为空时取消指派）
+type AssignRiskCaseReq struct {
+	AssigneeID *int64 `json:"assignee_id"`
+}
+
+// UpdateRiskCaseStatusReq 变更案件状态请求（结案时须填写结论）
+type UpdateRiskCaseStatusReq struct {
+	Status     RiskCaseStatus `json:"status" binding:"required,oneof=open investigating resolved"`
+	Resolution string         `json:"resolution"`
+}
+
+// AddRiskCaseNoteReq 添加案件备注请求
+type AddRiskCaseNoteReq struct {
+	Body     string `json:"body" binding:"required,max=5000"`
+	ParentID *int64 `json:"parent_id"`
+}
+
+// AddRiskCaseEvidenceReq 添加案件证据请求
+type AddRiskCaseEvidenceReq struct {
+	Type   RiskCaseEvidenceType `json:"type" binding:"required,oneof=round transaction login_event"`
+	RefID  int64                `json:"ref_id" binding:"required,min=1"`
+	Remark string               `json:"remark"`
+}
+
+// RiskCaseListQuery 案件列表查询
+type RiskCaseListQuery struct {
+	Status     *RiskCaseStatus `form:"status"`
+	AssigneeID *int64          `form:"assignee_id"`
+	UserID     *int64          `form:"user_id"`
+	Page       int             `form:"page" binding:"min=1"`
+	PageSize   int             `form:"page_size" binding:"min=1,max=100"`
+}
+
+// RiskCaseAuditQuery 案件审计查询
+type RiskCaseAuditQuery struct {
+	Page     int `form:"page" binding:"min=1"`
+	PageSize int `form:"page_size" binding:"min=1,max=100"`
+}
@@ -0,0 +1,164 @@
+package model
+
+import "time"
+
+// RiskRuleEvent 风控规则触发事件
+type RiskRuleEvent string
+
+const (
+	RiskEventRoundSettled RiskRuleEvent = "round_settled" // 回合结算（对每个参与者求值）
+	RiskEventLogin        RiskRuleEvent = "login"         // 登录成功
+	RiskEventFundRequest  RiskRuleEvent = "fund_request"  // 充值/提现申请审批通过
+)
+
+// RiskSeverity 风控严重程度
+type RiskSeverity string
+
+const (
+	RiskSeverityLow      RiskSeverity = "low"
+	RiskSeverityMedium   RiskSeverity = "medium"
+	RiskSeverityHigh     RiskSeverity = "high"
+	RiskSeverityCritical RiskSeverity = "critical"
+)
+
+// RiskRuleAction 风控规则命中后的动作
+type RiskRuleAction string
+
+const (
+	RiskActionFlag  RiskRuleAction = "flag"  // 创建待审核风控标记并告警（同一规则对同一用户仅保留一个待处理标记）
+	RiskActionAlert RiskRuleAction = "alert" // 仅告警
+)
+
+// RiskRule 风控规则
+// Condition 为规则表达式（见 pkg/ruleexpr），可引用的变量取决于触发事件（见 RiskRuleVariable）
+// 每次修改版本号加一并保存一份快照到 risk_rule_versions
+type RiskRule struct {
+	ID          int64          `json:"id" db:"id"`
+	Name        string         `json:"name" db:"name"`
+	Description string         `json:"description" db:"description"`
+	Event       RiskRuleEvent  `json:"event" db:"event"`
+	Condition   string         `json:"condition" db:"condition"`
+	Severity    RiskSeverity   `json:"severity" db:"severity"`
+	Action      RiskRuleAction `json:"action" db:"action"`
+	FlagType    RiskFlagType   `json:"flag_type" db:"flag_type"` // action 为 flag 时创建的标记类型
+	Enabled     bool           `json:"enabled" db:"enabled"`
+	Version     int            `json:"version" db:"version"`
+	UpdatedBy   *int64         `json:"updated_by,omitempty" db:"updated_by"`
+	CreatedAt   time.Time      `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at" db:"updated_at"`
+}
+
+// RiskRuleVersion 风控规则历史版本
+type RiskRuleVersion struct {
+	ID          int64          `json:"id" db:"id"`
+	RuleID      int64          `json:"rule_id" db:"rule_id"`
+	Version     int            `json:"version" db:"version"`
+	Name        string         `json:"name" db:"name"`
+	Description string         `json:"description" db:"description"`
+	Event       RiskRuleEvent  `json:"event" db:"event"`
+	Condition   string         `json:"condition" db:"condition"`
+	Severity    RiskSeverity   `json:"severity" db:"severity"`
+	Action      RiskRuleAction `json:"action" db:"action"`
+	FlagType    RiskFlagType   `json:"flag_type" db:"flag_type"`
+	Enabled     bool           `json:"enabled" db:"enabled"`
+	ChangedBy   *int64         `json:"changed_by,omitempty" db:"changed_by"`
+	CreatedAt   time.Time      `json:"created_at" db:"created_at"`
+}
+
+// RiskRuleVariable 规则条件可引用的变量
+type RiskRuleVariable struct {
+	Name        string `json:"name"`
+	Type        string `json:"type"` // number/string/bool
+	Description string `json:"description"`
+}
+
+// RiskRuleHit 规则命中结果
+type RiskRuleHit struct {
+	Rule      *RiskRule
+	Variables map[string]interface{} // 条件引用的变量取值
+}
+
+// SaveRiskRuleReq 创建或修改风控规则请求
+type SaveRiskRuleReq struct {
+	Name        string         `json:"name" binding:"required,max=100"`
+	Description string         `json:"description" binding:"max=500"`
+	Event       RiskRuleEvent  `json:"event" binding:"required,oneof=round_settled login fund_request"`
+	Condition   string         `json:"condition" binding:"required"`
+	Severity    RiskSeverity   `json:"severity" binding:"required,oneof=low medium high critical"`
+	Action      RiskRuleAction `json:"action" binding:"required,oneof=flag alert"`
+	FlagType    RiskFlagType   `json:"flag_type" binding:"max=50"` // 为空时使用 rule_hit
+	Enabled     *bool          `json:"enabled"`                    // 为空时新建默认开启，修改保持原状态
+}
+
+// RiskRuleListQuery 风控规则列表查询
+type RiskRuleListQuery struct {
+	Event    *RiskRuleEvent `form:"event"`
+	Enabled  *bool          `form:"enabled"`
+	Page     int            `form:"page" binding:"min=1"`
+	PageSize int            `form:"page_size" binding:"min=1,max=100"`
+}
+
+// RiskRuleDryRunReq 规则试运行请求
+// 指定 rule_id 时试运行已保存的规则，否则试运行请求中的 event 与 condition（用于保存前验证）
+type RiskRuleDryRunReq struct {
+	RuleID    *int64        `json:"rule_id"`
+	Event     RiskRuleEvent `json:"event"`
+	Condition string        `json:"condition"`
+	Days      int           `json:"days" binding:"required,min=1"`
+}
+
+// RiskRuleDryRunResult 规则试运行结果：按历史数据重放最近 N 天的事件，统计规则会命中的情况（不产生标记与告警）
+type RiskRuleDryRunResult struct {
+	RuleID    *int64               `json:"rule_id,omitempty"`
+	Event     RiskRuleEvent        `json:"event"`
+	Condition string               `json:"condition"`
+	From      time.Time            `json:"from"`
+	To        time.Time            `json:"to"`
+	Evaluated int                  `json:"evaluated"` // 求值次数
+	Fired     int                  `json:"fired"`     // 命中次数
+	Users     int                  `json:"users"`     // 命中涉及的用户数
+	Errors    int                  `json:"errors"`    // 求值出错次数（如除零）
+	Hits      []*RiskRuleDryRunHit `json:"hits"`      // 命中明细（最多返回前若干条）
+	Truncated bool                 `json:"truncated"` // 命中明细是否被截断
+}
+
+// RiskRuleDryRunHit 试运行命中明细
+type RiskRuleDryRunHit struct {
+	UserID     int64                  `json:"user_id"`
+	OccurredAt time.Time              `json:"occurred_at"`
+	RefType    string                 `json:"ref_type"` // round/fund_request
+	RefID      int64                  `json:"ref_id"`
+	Variables  map[string]interface{} `json:"variables"`
+}
+
+// RiskReplayRound 试运行重放的已结算回合
+type RiskReplayRound struct {
+	ID             int64
+	ParticipantIDs []int64
+	WinnerIDs      []int64
+	SettledAt      time.Time
+}
+
+// RiskReplayFundRequest 试运行重放的资金申请（附审批通过时刻的用户统计）
+type RiskReplayFundRequest struct {
+	ID               int64
+	UserID           int64
+	Type             FundRequestType
+	Amount           float64
+	CreatedAt        time.Time // 审批通过时间（规则求值时刻）
+	DailyVolume      float64   // 审批通过后当日流水（含本次申请）
+	AccountCreatedAt time.Time // 用户注册时间
+}
+
+// RiskReplayLogin 试运行重放的登录事件（附登录时刻的用户统计）
+type RiskReplayLogin struct {
+	ID                int64
+	UserID            int64
+	CreatedAt         time.Time
+	DeviceFingerprint string
+	NewDevice         bool
+	DeviceAccounts    int       // 截至登录时以同一设备指纹登录成功过的账户数
+	RecentRounds      int       // 登录前最近 win_rate_window 个已结算回合数
+	RecentWins        int       // 其中获胜的回合数
+	AccountCreatedAt  time.Time // 用户注册时间
+}
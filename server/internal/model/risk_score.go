@@ -0,0 +1,78 @@
+package model
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// RiskScoreTrigger 触发风险评分重算的事件
+type RiskScoreTrigger string
+
+const (
+	RiskScoreTriggerFlagCreated  RiskScoreTrigger = "flag_created"
+	RiskScoreTriggerFlagReviewed RiskScoreTrigger = "flag_reviewed"
+	RiskScoreTriggerRoundSettled RiskScoreTrigger = "round_settled"
+	RiskScoreTriggerFundRequest  RiskScoreTrigger = "fund_request"
+	RiskScoreTriggerDecay        RiskScoreTrigger = "decay" // 定时重算（标记分值随时间衰减）
+)
+
+// RiskScoreComponents 风险评分分项
+type RiskScoreComponents struct {
+	FlagPoints        float64 `json:"flag_points"`         // 风控标记得分（按半衰期衰减）
+	WinRatePoints     float64 `json:"win_rate_points"`     // 胜率偏离得分
+	DepositPlayPoints float64 `json:"deposit_play_points"` // 充值多、下注少得分
+	AccountAgePoints  float64 `json:"account_age_points"`  // 新账户得分
+
+	Flags          int             `json:"flags"`           // 计入的风控标记数（驳回的不计）
+	ConfirmedFlags int             `json:"confirmed_flags"` // 其中已确认的标记数
+	Rounds         int             `json:"rounds"`          // 统计窗口内参与的回合数
+	Wins           int             `json:"wins"`
+	ExpectedWins   float64         `json:"expected_wins"` // 随机开奖下的期望获胜回合数
+	WinRateZ       float64         `json:"win_rate_z"`    // 获胜回合数偏离期望的标准差倍数
+	Deposits       decimal.Decimal `json:"deposits"`      // 统计窗口内充值金额
+	Wagered        decimal.Decimal `json:"wagered"`       // 统计窗口内下注金额
+	AccountAgeDays float64         `json:"account_age_days"`
+}
+
+// UserRiskScore 用户风险评分（0~100）
+type UserRiskScore struct {
+	UserID       int64               `json:"user_id" db:"user_id"`
+	Score        float64             `json:"score" db:"score"`
+	Level        RiskSeverity        `json:"level" db:"level"`
+	Components   RiskScoreComponents `json:"components" db:"components"`
+	AlertedLevel RiskSeverity        `json:"-" db:"alerted_level"` // 已告警的最高等级
+	ComputedAt   time.Time           `json:"computed_at" db:"computed_at"`
+}
+
+// RiskScoreHistory 风险评分历史
+type RiskScoreHistory struct {
+	ID         int64               `json:"id" db:"id"`
+	UserID     int64               `json:"user_id" db:"user_id"`
+	Score      float64             `json:"score" db:"score"`
+	Level      RiskSeverity        `json:"level" db:"level"`
+	Components RiskScoreComponents `json:"components" db:"components"`
+	Trigger    RiskScoreTrigger    `json:"trigger" db:"trigger_event"`
+	CreatedAt  time.Time           `json:"created_at" db:"created_at"`
+}
+
+// RiskScoreFlag 参与评分的风控标记
+type RiskScoreFlag struct {
+	FlagType   RiskFlagType
+	Severity   RiskSeverity
+	Status     RiskFlagStatus
+	CreatedAt  time.Time
+	ReviewedAt *time.Time
+}
+
+// RiskScoreInput 风险评分输入
+type RiskScoreInput struct {
+	Flags            []RiskScoreFlag
+	AccountCreatedAt time.Time
+	Rounds           int
+	Wins             int
+	ExpectedWins     float64 // 各回合获胜概率（赢家数/参与人数）之和
+	WinVariance      float64 // 各回合 p(1-p) 之和
+	Deposits         decimal.Decimal
+	Wagered          decimal.Decimal
+}
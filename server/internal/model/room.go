@@ -15,16 +15,29 @@ const (
 	RoomStatusLocked RoomStatus = "locked"
 )
 
+// RoomType 房间类型
+type RoomType string
+
+const (
+	RoomTypeStandard   RoomType = "standard"   // 真实余额房间
+	RoomTypePractice   RoomType = "practice"   // 练习房（游戏币下注，不涉及真实资金）
+	RoomTypeTournament RoomType = "tournament" // 锦标赛房间（锦标赛筹码下注，报名费与奖金走真实账本）
+)
+
 // Room 房间模型
 type Room struct {
-	ID         int64  `json:"id" db:"id"`
-	OwnerID    int64  `json:"owner_id" db:"owner_id"`
-	OwnerName  string `json:"owner_name,omitempty" db:"-"`
-	Name       string `json:"name" db:"name"`
-	InviteCode string `json:"invite_code" db:"invite_code"`
+	ID         int64    `json:"id" db:"id"`
+	OwnerID    int64    `json:"owner_id" db:"owner_id"`
+	OwnerName  string   `json:"owner_name,omitempty" db:"-"`
+	Name       string   `json:"name" db:"name"`
+	InviteCode string   `json:"invite_code" db:"invite_code"`
+	RoomType   RoomType `json:"room_type" db:"room_type"`
+
+	TournamentID *int64 `json:"tournament_id,omitempty" db:"tournament_id"` // 锦标赛房间所属锦标赛
 
 	// 房间配置
-	BetAmount              decimal.Decimal `json:"bet_amount" db:"bet_amount"`
+	BetAmount              decimal.Decimal `json:"bet_amount" db:"bet_amount"` // 以房间币种计
+	Currency               string          `json:"currency" db:"currency"`     // 房间币种（与房主经营币种一致）
 	WinnerCount            int             `json:"winner_count" db:"winner_count"`
 	MaxPlayers             int             `json:"max_players" db:"max_players"`
 	OwnerCommissionRate    decimal.Decimal `json:"owner_commission_rate" db:"owner_commission_rate"`
@@ -44,6 +57,23 @@ type RoomListItem struct {
 	CurrentPlayers int    `json:"current_players"`
 	HasPassword    bool   `json:"has_password"`
 	OwnerName      string `json:"owner_name,omitempty"`
+	IsPractice     bool   `json:"is_practice"`   // 大厅标记练习房
+	IsTournament   bool   `json:"is_tournament"` // 大厅标记锦标赛房间
+}
+
+// IsPractice 是否为练习房
+func (r *Room) IsPractice() bool {
+	return r.RoomType == RoomTypePractice
+}
+
+// IsTournament 是否为锦标赛房间
+func (r *Room) IsTournament() bool {
+	return r.RoomType == RoomTypeTournament
+}
+
+// UsesChips 是否以筹码（练习房游戏币、锦标赛筹码）下注，不直接变动真实余额
+func (r *Room) UsesChips() bool {
+	return r.IsPractice() || r.IsTournament()
 }
 
 // TotalCommissionRate 总抽成比例
@@ -60,6 +90,8 @@ type CreateRoomReq struct {
 	OwnerCommissionRate    string `json:"owner_commission_rate"`                      // 使用字符串避免浮点精度问题
 	PlatformCommissionRate string `json:"platform_commission_rate"`                   // 使用字符串避免浮点精度问题
 	Password               string `json:"password"`
+	RoomType               string `json:"room_type"`                                  // standard（默认）、practice 或 tournament
+	TournamentID           int64  `json:"tournament_id"`                              // 锦标赛房间必填
 }
 
 // GetBetAmountDecimal 获取下注金额的 Decimal 类型
@@ -112,6 +144,7 @@ type UpdateRoomStatusReq struct {
 type RoomListQuery struct {
 	OwnerID   *int64      `form:"owner_id"`
 	Status    *RoomStatus `form:"status"`
+	RoomType  *RoomType   `form:"room_type"`
 	InvitedBy *int64      `form:"-"` // 内部使用，用于过滤关联房主
 	Page      int         `form:"page" binding:"min=1"`
 	PageSize  int         `form:"page_size" binding:"min=1,max=100"`
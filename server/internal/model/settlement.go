@@ -0,0 +1,70 @@
+package model
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// SettlementPeriodType 结算周期类型
+type SettlementPeriodType string
+
+const (
+	SettlementPeriodDaily  SettlementPeriodType = "daily"
+	SettlementPeriodWeekly SettlementPeriodType = "weekly"
+)
+
+// OwnerSettlement 房主佣金结算单（生成后不可修改）
+type OwnerSettlement struct {
+	ID                int64                `json:"id" db:"id"`
+	OwnerID           int64                `json:"owner_id" db:"owner_id"`
+	PeriodType        SettlementPeriodType `json:"period_type" db:"period_type"`
+	PeriodStart       time.Time            `json:"period_start" db:"period_start"`
+	PeriodEnd         time.Time            `json:"period_end" db:"period_end"` // 不含
+	RoundCount        int                  `json:"round_count" db:"round_count"`
+	PoolAmount        decimal.Decimal      `json:"pool_amount" db:"pool_amount"`
+	OwnerEarning      decimal.Decimal      `json:"owner_earning" db:"owner_earning"`
+	PlatformEarning   decimal.Decimal      `json:"platform_earning" db:"platform_earning"`
+	TransferredAmount decimal.Decimal      `json:"transferred_amount" db:"transferred_amount"`
+	CreatedAt         time.Time            `json:"created_at" db:"created_at"`
+
+	Items []*OwnerSettlementItem `json:"items,omitempty" db:"-"`
+}
+
+// OwnerSettlementItem 结算明细（按房间）
+type OwnerSettlementItem struct {
+	ID              int64           `json:"id" db:"id"`
+	SettlementID    int64           `json:"settlement_id" db:"settlement_id"`
+	RoomID          int64           `json:"room_id" db:"room_id"`
+	RoundCount      int             `json:"round_count" db:"round_count"`
+	PoolAmount      decimal.Decimal `json:"pool_amount" db:"pool_amount"`
+	OwnerEarning    decimal.Decimal `json:"owner_earning" db:"owner_earning"`
+	PlatformEarning decimal.Decimal `json:"platform_earning" db:"platform_earning"`
+}
+
+// SettlementReconcileItem 结算对账明细：结算单快照与 game_rounds 当前汇总的差异
+type SettlementReconcileItem struct {
+	RoomID               int64           `json:"room_id"`
+	SettledRounds        int             `json:"settled_rounds"`
+	ActualRounds         int             `json:"actual_rounds"`
+	SettledOwnerEarning  decimal.Decimal `json:"settled_owner_earning"`
+	ActualOwnerEarning   decimal.Decimal `json:"actual_owner_earning"`
+	OwnerEarningDiff     decimal.Decimal `json:"owner_earning_diff"`
+	SettledPlatformShare decimal.Decimal `json:"settled_platform_share"`
+	ActualPlatformShare  decimal.Decimal `json:"actual_platform_share"`
+}
+
+// SettlementReconcileResult 结算对账结果
+type SettlementReconcileResult struct {
+	Settlement   *OwnerSettlement           `json:"settlement"`
+	Items        []*SettlementReconcileItem `json:"items"`
+	TotalDiff    decimal.Decimal            `json:"total_diff"`
+	IsReconciled bool                       `json:"is_reconciled"`
+}
+
+// SettlementListQuery 结算单列表查询
+type SettlementListQuery struct {
+	OwnerID  *int64
+	Page     int
+	PageSize int
+}
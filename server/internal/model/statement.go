@@ -0,0 +1,77 @@
+package model
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// StatementFormat 账单导出格式
+type StatementFormat string
+
+const (
+	StatementFormatCSV StatementFormat = "csv"
+	StatementFormatPDF StatementFormat = "pdf"
+)
+
+// StatementJobStatus 账单导出任务状态
+type StatementJobStatus string
+
+const (
+	StatementJobPending   StatementJobStatus = "pending"
+	StatementJobRunning   StatementJobStatus = "running"
+	StatementJobCompleted StatementJobStatus = "completed"
+	StatementJobFailed    StatementJobStatus = "failed"
+)
+
+// StatementJob 账单异步导出任务
+type StatementJob struct {
+	ID           int64              `json:"id" db:"id"`
+	UserID       int64              `json:"user_id" db:"user_id"`
+	Format       StatementFormat    `json:"format" db:"format"`
+	PeriodStart  time.Time          `json:"period_start" db:"period_start"`
+	PeriodEnd    time.Time          `json:"period_end" db:"period_end"`
+	Status       StatementJobStatus `json:"status" db:"status"`
+	FilePath     *string            `json:"-" db:"file_path"`
+	RowCount     int                `json:"row_count" db:"row_count"`
+	ErrorMessage *string            `json:"error_message,omitempty" db:"error_message"`
+	CreatedAt    time.Time          `json:"created_at" db:"created_at"`
+	CompletedAt  *time.Time         `json:"completed_at,omitempty" db:"completed_at"`
+}
+
+// StatementFieldBalance 单个余额账户（BalanceField）的期初/期末余额
+type StatementFieldBalance struct {
+	Field   string          `json:"field"`
+	Display string          `json:"display"`
+	Opening decimal.Decimal `json:"opening"`
+	Closing decimal.Decimal `json:"closing"`
+	Change  decimal.Decimal `json:"change"`
+}
+
+// StatementCategoryTotal 按交易类型汇总
+type StatementCategoryTotal struct {
+	Type    TransactionType `json:"type"`
+	Display string          `json:"display"`
+	Count   int             `json:"count"`
+	Total   decimal.Decimal `json:"total"`
+}
+
+// Statement 账单
+type Statement struct {
+	UserID       int64                     `json:"user_id"`
+	Username     string                    `json:"username"`
+	PeriodStart  time.Time                 `json:"period_start"`
+	PeriodEnd    time.Time                 `json:"period_end"` // 不含
+	Balances     []*StatementFieldBalance  `json:"balances"`
+	Categories   []*StatementCategoryTotal `json:"categories"`
+	Transactions []*BalanceTransaction     `json:"transactions"`
+	GeneratedAt  time.Time                 `json:"generated_at"`
+}
+
+// StatementPeriodQuery 账单周期选择：按月（month=2025-01）或按日期范围（含首尾日期）
+type StatementPeriodQuery struct {
+	Format    StatementFormat `form:"format" json:"format"`
+	Month     string          `form:"month" json:"month"`
+	StartDate string          `form:"start_date" json:"start_date"`
+	EndDate   string          `form:"end_date" json:"end_date"`
+}
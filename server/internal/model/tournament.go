@@ -0,0 +1,116 @@
+package model
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// TournamentStatus 锦标赛状态
+type TournamentStatus string
+
+const (
+	TournamentStatusScheduled TournamentStatus = "scheduled" // 报名中，等待开始
+	TournamentStatusRunning   TournamentStatus = "running"   // 进行中
+	TournamentStatusFinished  TournamentStatus = "finished"  // 已结束并按名次派奖
+	TournamentStatusCancelled TournamentStatus = "cancelled" // 已取消并退回报名费
+)
+
+// TournamentEntryStatus 报名记录状态
+type TournamentEntryStatus string
+
+const (
+	TournamentEntryActive   TournamentEntryStatus = "active"
+	TournamentEntryRefunded TournamentEntryStatus = "refunded"
+	TournamentEntryFinished TournamentEntryStatus = "finished"
+)
+
+// Tournament 锦标赛
+type Tournament struct {
+	ID            int64                    `json:"id" db:"id"`
+	OwnerID       int64                    `json:"owner_id" db:"owner_id"`
+	Name          string                   `json:"name" db:"name"`
+	Currency      string                   `json:"currency" db:"currency"`
+	BuyIn         decimal.Decimal          `json:"buy_in" db:"buy_in"`
+	StartingChips decimal.Decimal          `json:"starting_chips" db:"starting_chips"`
+	MaxRounds     int                      `json:"max_rounds" db:"max_rounds"` // 0 表示不限回合数
+	RoundsStarted int                      `json:"rounds_started" db:"rounds_started"`
+	RoundsPlayed  int                      `json:"rounds_played" db:"rounds_played"`
+	StartsAt      time.Time                `json:"starts_at" db:"starts_at"`
+	EndsAt        *time.Time               `json:"ends_at,omitempty" db:"ends_at"`
+	Status        TournamentStatus         `json:"status" db:"status"`
+	PrizePool     decimal.Decimal          `json:"prize_pool" db:"prize_pool"`     // 报名费累计
+	PoolBalance   decimal.Decimal          `json:"pool_balance" db:"pool_balance"` // 尚未派发或退回的报名费
+	EntrantCount  int                      `json:"entrant_count" db:"-"`
+	Payouts       []*TournamentPayoutShare `json:"payouts,omitempty" db:"-"`
+	CreatedAt     time.Time                `json:"created_at" db:"created_at"`
+	UpdatedAt     time.Time                `json:"updated_at" db:"updated_at"`
+	FinishedAt    *time.Time               `json:"finished_at,omitempty" db:"finished_at"`
+}
+
+// RoundLimitReached 是否已达到回合上限或结束时间（不再开始新回合）
+func (t *Tournament) RoundLimitReached(now time.Time) bool {
+	if t.MaxRounds > 0 && t.RoundsStarted >= t.MaxRounds {
+		return true
+	}
+	return t.EndsAt != nil && !now.Before(*t.EndsAt)
+}
+
+// Finishable 是否可以结束：不再开始新回合且已开始的回合全部结算
+func (t *Tournament) Finishable(now time.Time) bool {
+	return t.Status == TournamentStatusRunning && t.RoundLimitReached(now) && t.RoundsPlayed >= t.RoundsStarted
+}
+
+// TournamentPayoutShare 名次奖金比例
+type TournamentPayoutShare struct {
+	Rank  int             `json:"rank" db:"rank_no"`
+	Share decimal.Decimal `json:"share" db:"share"` // 奖池百分比
+}
+
+// TournamentEntry 锦标赛报名记录
+type TournamentEntry struct {
+	TournamentID int64                 `json:"tournament_id" db:"tournament_id"`
+	UserID       int64                 `json:"user_id" db:"user_id"`
+	Username     string                `json:"username" db:"-"`
+	Chips        decimal.Decimal       `json:"chips" db:"chips"`
+	BuyIn        decimal.Decimal       `json:"buy_in" db:"buy_in"`
+	Status       TournamentEntryStatus `json:"status" db:"status"`
+	FinalRank    *int                  `json:"final_rank,omitempty" db:"final_rank"`
+	Prize        decimal.Decimal       `json:"prize" db:"prize"`
+	JoinedAt     time.Time             `json:"joined_at" db:"joined_at"`
+}
+
+// TournamentStanding 锦标赛排名
+type TournamentStanding struct {
+	Rank     int             `json:"rank"`
+	UserID   int64           `json:"user_id"`
+	Username string          `json:"username"`
+	Chips    decimal.Decimal `json:"chips"`
+	Prize    decimal.Decimal `json:"prize"` // 结束后为实际奖金，进行中为按当前排名预计的奖金
+}
+
+// TournamentDetail 锦标赛详情（含实时排名）
+type TournamentDetail struct {
+	*Tournament
+	Standings []*TournamentStanding `json:"standings"`
+	Rooms     []*Room               `json:"rooms"`
+}
+
+// CreateTournamentReq 创建锦标赛请求
+type CreateTournamentReq struct {
+	Name          string                   `json:"name" binding:"required"`
+	BuyIn         decimal.Decimal          `json:"buy_in"`
+	StartingChips decimal.Decimal          `json:"starting_chips"`
+	MaxRounds     int                      `json:"max_rounds"` // 0 表示不限回合数（须设置结束时间）
+	StartsAt      time.Time                `json:"starts_at"`  // 为空或已过时在下一次巡检时开始
+	EndsAt        *time.Time               `json:"ends_at"`
+	Payouts       []*TournamentPayoutShare `json:"payouts" binding:"required"` // 按名次顺序，合计 100
+}
+
+// TournamentListQuery 锦标赛列表查询
+type TournamentListQuery struct {
+	OwnerID  *int64            `form:"owner_id"`
+	Status   *TournamentStatus `form:"status"`
+	Page     int               `form:"page" binding:"min=1"`
+	PageSize int               `form:"page_size" binding:"min=1,max=100"`
+}
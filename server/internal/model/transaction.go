@@ -21,6 +21,20 @@ const (
 	TxFreeze            TransactionType = "freeze"             // 冻结
 	TxUnfreeze          TransactionType = "unfreeze"           // 解冻
 	TxEarningsTransfer  TransactionType = "earnings_transfer"  // 佣金转可用余额
+	TxTransferIn        TransactionType = "transfer_in"        // 玩家转账转入
+	TxTransferOut       TransactionType = "transfer_out"       // 玩家转账转出
+	TxBonusGrant        TransactionType = "bonus_grant"        // 奖励发放（奖励余额）
+	TxBonusBet          TransactionType = "bonus_bet"          // 奖励余额下注
+	TxBonusWin          TransactionType = "bonus_win"          // 奖励部分赢得的奖金（计入奖励余额）
+	TxBonusRefund       TransactionType = "bonus_refund"       // 奖励余额下注退款
+	TxBonusConvert      TransactionType = "bonus_convert"      // 流水达标，奖励余额转真实余额
+	TxRebate            TransactionType = "rebate"             // 返水（房主佣金余额 -> 玩家余额）
+	TxReferralReward    TransactionType = "referral_reward"    // 推荐奖励（房主佣金余额/平台余额 -> 推荐人余额）
+	TxCurrencySwitch    TransactionType = "currency_switch"    // 切换活跃币种（活跃余额与非活跃币种钱包互换）
+	TxTournamentBuyIn   TransactionType = "tournament_buy_in"  // 锦标赛报名费（玩家余额 -> 锦标赛奖池）
+	TxTournamentRefund  TransactionType = "tournament_refund"  // 锦标赛取消退回报名费（锦标赛奖池 -> 玩家余额）
+	TxTournamentPrize   TransactionType = "tournament_prize"   // 锦标赛名次奖金（锦标赛奖池 -> 玩家余额）
+	TxJackpotWin        TransactionType = "jackpot_win"        // 累进奖池中奖（累进奖池 -> 玩家余额）
 )
 
 // BalanceTransaction 余额交易记录
@@ -34,6 +48,7 @@ type BalanceTransaction struct {
 	BalanceBefore decimal.Decimal `json:"balance_before" db:"balance_before"`
 	BalanceAfter  decimal.Decimal `json:"balance_after" db:"balance_after"`
 	BalanceField  string          `json:"balance_field" db:"balance_field"`
+	Currency      string          `json:"currency" db:"currency"` // 为空时按账户当前币种记录
 	Remark        *string         `json:"remark,omitempty" db:"remark"`
 	CreatedAt     time.Time       `json:"created_at" db:"created_at"`
 }
@@ -53,18 +68,50 @@ const (
 type FundRequestStatus string
 
 const (
-	FundStatusPending  FundRequestStatus = "pending"
-	FundStatusApproved FundRequestStatus = "approved"
-	FundStatusRejected FundRequestStatus = "rejected"
+	FundStatusPending               FundRequestStatus = "pending"
+	FundStatusPendingSecondApproval FundRequestStatus = "pending_second_approval" // 已有一人审批通过，等待第二审批人
+	FundStatusEscalated             FundRequestStatus = "escalated"               // 超出房主权限，等待平台管理员审批
+	FundStatusApproved              FundRequestStatus = "approved"
+	FundStatusRejected              FundRequestStatus = "rejected"
+	FundStatusExpired               FundRequestStatus = "expired" // 超过有效期未处理，自动过期
 )
 
+// IsAwaitingApproval 是否仍处于待审批流程中
+func (s FundRequestStatus) IsAwaitingApproval() bool {
+	return s == FundStatusPending || s == FundStatusPendingSecondApproval || s == FundStatusEscalated
+}
+
+// FundApprovalAction 审批动作
+type FundApprovalAction string
+
+const (
+	FundApprovalApprove FundApprovalAction = "approve"
+	FundApprovalReject  FundApprovalAction = "reject"
+)
+
+// FundRequestApproval 资金申请审批记录（每个审批步骤一条）
+type FundRequestApproval struct {
+	ID           int64              `json:"id" db:"id"`
+	RequestID    int64              `json:"request_id" db:"request_id"`
+	ApproverID   int64              `json:"approver_id" db:"approver_id"`
+	ApproverRole Role               `json:"approver_role" db:"approver_role"`
+	Action       FundApprovalAction `json:"action" db:"action"`
+	StatusBefore FundRequestStatus  `json:"status_before" db:"status_before"`
+	StatusAfter  FundRequestStatus  `json:"status_after" db:"status_after"`
+	Amount       decimal.Decimal    `json:"amount" db:"amount"`
+	Remark       *string            `json:"remark,omitempty" db:"remark"`
+	CreatedAt    time.Time          `json:"created_at" db:"created_at"`
+}
+
 // FundRequest 资金申请
 type FundRequest struct {
 	ID          int64             `json:"id" db:"id"`
 	UserID      int64             `json:"user_id" db:"user_id"`
 	Username    string            `json:"username,omitempty" db:"-"`
+	OwnerID     *int64            `json:"owner_id,omitempty" db:"-"` // 负责审批的房主（申请人的 invited_by）
 	Type        FundRequestType   `json:"type" db:"request_type"`
 	Amount      decimal.Decimal   `json:"amount" db:"amount"`
+	Currency    string            `json:"currency" db:"currency"`
 	Status      FundRequestStatus `json:"status" db:"status"`
 	Remark      *string           `json:"remark,omitempty" db:"remark"`
 	ProcessedBy *int64            `json:"processed_by,omitempty" db:"operator_id"`
@@ -72,9 +119,30 @@ type FundRequest struct {
 	CreatedAt   time.Time         `json:"created_at" db:"created_at"`
 }
 
+// FundEventType 资金变动事件类型
+type FundEventType string
+
+const (
+	FundEventRequestApproved  FundEventType = "fund_request_approved" // 资金申请审批通过
+	FundEventEarningsTransfer FundEventType = "earnings_transfer"     // 房主手动将佣金转入可用余额
+	FundEventSettlementCredit FundEventType = "settlement_credit"     // 佣金结算自动转入可用余额
+)
+
+// FundEvent 资金变动事件（事务提交后触发，用于大额、日交易量与资金频率检查）
+type FundEvent struct {
+	Type        FundEventType
+	UserID      int64
+	Amount      decimal.Decimal
+	Currency    string
+	RequestType FundRequestType // 资金申请审批通过时的申请类型
+	RefID       int64           // 资金申请ID或结算单ID（手动转入佣金时为 0）
+	OccurredAt  time.Time
+}
+
 // PlatformAccount 平台账户
 type PlatformAccount struct {
 	ID              int64           `json:"id" db:"id"`
+	Currency        string          `json:"currency" db:"currency"`
 	PlatformBalance decimal.Decimal `json:"platform_balance" db:"platform_balance"`
 	UpdatedAt       time.Time       `json:"updated_at" db:"updated_at"`
 }
@@ -83,9 +151,10 @@ type PlatformAccount struct {
 
 // CreateFundRequestReq 创建资金申请请求
 type CreateFundRequestReq struct {
-	Type   FundRequestType `json:"type" binding:"required"`
-	Amount decimal.Decimal `json:"amount" binding:"required"`
-	Remark string          `json:"remark"`
+	Type     FundRequestType `json:"type" binding:"required"`
+	Amount   decimal.Decimal `json:"amount" binding:"required"`
+	Currency string          `json:"currency"` // 为空使用房主经营币种
+	Remark   string          `json:"remark"`
 }
 
 // ProcessFundRequestReq 处理资金申请请求
@@ -115,6 +184,7 @@ type TransactionListQuery struct {
 	UserID   *int64           `form:"user_id"`
 	RoomID   *int64           `form:"room_id"`
 	Type     *TransactionType `form:"type"`
+	Currency *string          `form:"currency"`
 	Page     int              `form:"page" binding:"min=1"`
 	PageSize int              `form:"page_size" binding:"min=1,max=100"`
 }
@@ -127,23 +197,33 @@ type TransactionListResp struct {
 
 // FundSummary 资金统计摘要
 type FundSummary struct {
-	TotalDeposit    decimal.Decimal `json:"total_deposit"`
-	TotalWithdraw   decimal.Decimal `json:"total_withdraw"`
-	TotalBet        decimal.Decimal `json:"total_bet"`
-	TotalWin        decimal.Decimal `json:"total_win"`
-	TotalCommission decimal.Decimal `json:"total_commission"`
-	PlatformBalance decimal.Decimal `json:"platform_balance"`
+	TotalDeposit     decimal.Decimal `json:"total_deposit"`
+	TotalWithdraw    decimal.Decimal `json:"total_withdraw"`
+	TotalBet         decimal.Decimal `json:"total_bet"`
+	TotalWin         decimal.Decimal `json:"total_win"`
+	TotalCommission  decimal.Decimal `json:"total_commission"`
+	TotalTransferIn  decimal.Decimal `json:"total_transfer_in"`
+	TotalTransferOut decimal.Decimal `json:"total_transfer_out"`
+	PlatformBalance  decimal.Decimal `json:"platform_balance"`
+}
+
+// TransferLedgerImbalance 转账流水与已完成转账记录的偏差：|转入 - 已完成| + |转出 - 已完成|
+// 分别比较两侧流水，缺失成对流水（转入与转出同时缺少）或单边流水都会产生非 0 偏差
+func TransferLedgerImbalance(completed, transferIn, transferOut decimal.Decimal) decimal.Decimal {
+	return transferIn.Sub(completed).Abs().Add(transferOut.Sub(completed).Abs())
 }
 
 // ConservationCheck 资金守恒检查结果
 // 资金守恒公式: 玩家总余额 + 房主佣金收益 + 平台余额 = 房主净充值额（系统总资金入口）
 // 简化公式: 系统内资金总和 = 玩家余额 + 房主可用余额 + 房主佣金 + 平台余额
 type ConservationCheck struct {
-	IsBalanced bool `json:"is_balanced"`
+	Currency   string `json:"currency"` // 对账币种（各币种分别守恒）
+	IsBalanced bool   `json:"is_balanced"`
 
 	// 玩家侧
 	TotalPlayerBalance decimal.Decimal `json:"total_player_balance"` // 玩家可用余额总和
 	TotalPlayerFrozen  decimal.Decimal `json:"total_player_frozen"`  // 玩家冻结余额总和
+	TotalWalletBalance decimal.Decimal `json:"total_wallet_balance"` // 玩家非活跃币种钱包中该币种的余额总和
 
 	// 房主侧
 	TotalOwnerBalance    decimal.Decimal `json:"total_owner_balance"`     // 房主可用余额总和
@@ -154,16 +234,34 @@ type ConservationCheck struct {
 	// 平台侧
 	PlatformBalance decimal.Decimal `json:"platform_balance"` // 平台账户余额
 
+	// 锦标赛
+	TotalTournamentPool decimal.Decimal `json:"total_tournament_pool"` // 锦标赛奖池中尚未派发或退回的报名费
+
+	// 累进奖池
+	TotalJackpotBalance decimal.Decimal `json:"total_jackpot_balance"` // 累进奖池中尚未派发的金额
+
 	// 汇总
 	SystemTotalFunds   decimal.Decimal `json:"system_total_funds"`   // 系统内资金总和
 	TotalOwnerDeposit  decimal.Decimal `json:"total_owner_deposit"`  // 房主累计充值
 	TotalOwnerWithdraw decimal.Decimal `json:"total_owner_withdraw"` // 房主累计提现
 	ExpectedTotal      decimal.Decimal `json:"expected_total"`       // 预期总额（净充值）
 	Difference         decimal.Decimal `json:"difference"`           // 差额
+
+	// 玩家间转账（系统内部流转，已完成转账金额须与转入、转出流水分别一致）
+	TotalTransferCompleted decimal.Decimal `json:"total_transfer_completed"` // 已完成转账记录金额总和
+	TotalTransferIn        decimal.Decimal `json:"total_transfer_in"`
+	TotalTransferOut       decimal.Decimal `json:"total_transfer_out"`
+	TransferImbalance      decimal.Decimal `json:"transfer_imbalance"` // 流水与转账记录的偏差，非 0 表示转账流水不一致
+
+	// 奖励余额（发放时从房主佣金或平台余额划出，计入系统内资金）
+	TotalBonusBalance   decimal.Decimal `json:"total_bonus_balance"`   // 玩家奖励余额总和
+	PlatformFundedBonus decimal.Decimal `json:"platform_funded_bonus"` // 平台出资发放给该房主名下玩家的奖励（仅房主维度，计入预期总额）
 }
 
 // FundReconciliationReport 资金对账报告（详细版）
 type FundReconciliationReport struct {
+	Currency string `json:"currency"`
+
 	// 外部资金注入（只有房主才能和外部有资金往来）
 	ExternalFunds struct {
 		OwnerDeposit  decimal.Decimal `json:"owner_deposit"`  // 房主充值总额（已批准）
@@ -176,10 +274,13 @@ type FundReconciliationReport struct {
 	SystemFunds struct {
 		PlayerBalance    decimal.Decimal `json:"player_balance"`    // 玩家可用余额
 		PlayerFrozen     decimal.Decimal `json:"player_frozen"`     // 玩家冻结余额
+		PlayerWallet     decimal.Decimal `json:"player_wallet"`     // 玩家非活跃币种钱包余额
 		OwnerBalance     decimal.Decimal `json:"owner_balance"`     // 房主可用余额
 		OwnerCommission  decimal.Decimal `json:"owner_commission"`  // 房主佣金收益
 		OwnerMargin      decimal.Decimal `json:"owner_margin"`      // 房主保证金
 		PlatformBalance  decimal.Decimal `json:"platform_balance"`  // 平台余额
+		TournamentPool   decimal.Decimal `json:"tournament_pool"`   // 锦标赛奖池余额
+		JackpotBalance   decimal.Decimal `json:"jackpot_balance"`   // 累进奖池余额
 		Total            decimal.Decimal `json:"total"`             // 系统内资金总和
 	} `json:"system_funds"`
 
@@ -203,6 +304,7 @@ type FundConservationHistory struct {
 	ID                       int64           `json:"id" db:"id"`
 	Scope                    string          `json:"scope" db:"scope"`                 // global/owner
 	OwnerID                  *int64          `json:"owner_id,omitempty" db:"owner_id"` // scope=owner 时有效
+	Currency                 string          `json:"currency" db:"currency"`
 	PeriodType               string          `json:"period_type" db:"period_type"`     // 2h/daily
 	PeriodStart              time.Time       `json:"period_start" db:"period_start"`
 	PeriodEnd                time.Time       `json:"period_end" db:"period_end"`
@@ -224,6 +326,7 @@ type FundConservationHistoryQuery struct {
 	Scope         *string    `form:"scope"` // global / owner
 	OwnerID       *int64     `form:"owner_id"`
 	PeriodType    *string    `form:"period_type"` // 2h / daily
+	Currency      *string    `form:"currency"`
 	FromCreatedAt *time.Time `form:"from_created_at" time_format:"2006-01-02T15:04:05Z07:00"`
 	ToCreatedAt   *time.Time `form:"to_created_at" time_format:"2006-01-02T15:04:05Z07:00"`
 	Page          int        `form:"page" binding:"min=1"`
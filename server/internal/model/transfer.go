@@ -0,0 +1,75 @@
+package model
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// TransferStatus 玩家转账状态
+type TransferStatus string
+
+const (
+	TransferStatusPending   TransferStatus = "pending"   // 等待房主审批
+	TransferStatusCompleted TransferStatus = "completed" // 已到账
+	TransferStatusRejected  TransferStatus = "rejected"  // 房主拒绝
+)
+
+// PlayerTransfer 玩家间转账（仅限同一房主名下的玩家）
+type PlayerTransfer struct {
+	ID           int64           `json:"id" db:"id"`
+	OwnerID      int64           `json:"owner_id" db:"owner_id"`
+	FromUserID   int64           `json:"from_user_id" db:"from_user_id"`
+	FromUsername string          `json:"from_username,omitempty" db:"-"`
+	ToUserID     int64           `json:"to_user_id" db:"to_user_id"`
+	ToUsername   string          `json:"to_username,omitempty" db:"-"`
+	Amount       decimal.Decimal `json:"amount" db:"amount"`
+	Currency     string          `json:"currency" db:"currency"`
+	Status       TransferStatus  `json:"status" db:"status"`
+	Remark       *string         `json:"remark,omitempty" db:"remark"`
+	OperatorID   *int64          `json:"operator_id,omitempty" db:"operator_id"`
+	CreatedAt    time.Time       `json:"created_at" db:"created_at"`
+	UpdatedAt    time.Time       `json:"updated_at" db:"updated_at"`
+}
+
+// OwnerTransferSettings 房主转账设置（限额为 0 表示不限）
+type OwnerTransferSettings struct {
+	OwnerID          int64           `json:"owner_id" db:"owner_id"`
+	Enabled          bool            `json:"enabled" db:"enabled"`
+	PerTransferLimit decimal.Decimal `json:"per_transfer_limit" db:"per_transfer_limit"`
+	DailyLimit       decimal.Decimal `json:"daily_limit" db:"daily_limit"`
+	RequireApproval  bool            `json:"require_approval" db:"require_approval"`
+	UpdatedAt        time.Time       `json:"updated_at" db:"updated_at"`
+}
+
+// ===== 请求/响应类型 =====
+
+// CreateTransferReq 发起转账请求
+type CreateTransferReq struct {
+	ToUsername string          `json:"to_username" binding:"required"`
+	Amount     decimal.Decimal `json:"amount" binding:"required"`
+	Remark     string          `json:"remark"`
+}
+
+// ProcessTransferReq 房主审批转账请求
+type ProcessTransferReq struct {
+	Approved bool   `json:"approved"`
+	Remark   string `json:"remark"`
+}
+
+// UpdateTransferSettingsReq 更新房主转账设置
+type UpdateTransferSettingsReq struct {
+	Enabled          bool            `json:"enabled"`
+	PerTransferLimit decimal.Decimal `json:"per_transfer_limit"`
+	DailyLimit       decimal.Decimal `json:"daily_limit"`
+	RequireApproval  bool            `json:"require_approval"`
+}
+
+// TransferListQuery 转账记录查询
+type TransferListQuery struct {
+	UserID   *int64          `form:"-"` // 转出或转入方
+	OwnerID  *int64          `form:"-"`
+	Status   *TransferStatus `form:"status"`
+	Page     int             `form:"page"`
+	PageSize int             `form:"page_size"`
+}
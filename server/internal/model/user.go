@@ -36,6 +36,8 @@ type User struct {
 	Balance        decimal.Decimal `json:"balance" db:"balance"`
 	FrozenBalance  decimal.Decimal `json:"frozen_balance" db:"frozen_balance"`
 	BalanceVersion int64           `json:"balance_version" db:"balance_version"`
+	BonusBalance   decimal.Decimal `json:"bonus_balance" db:"bonus_balance"` // 促销奖励余额（流水达标后转为真实余额）
+	Currency       string          `json:"currency" db:"currency"`           // 活跃钱包币种（房主为经营币种）
 
 	// 房主专属字段
 	OwnerRoomBalance   decimal.Decimal `json:"owner_room_balance,omitempty" db:"owner_room_balance"`     // 房主佣金收益
@@ -47,6 +49,10 @@ type User struct {
 	Status    UserStatus `json:"status" db:"status"`
 	CreatedAt time.Time  `json:"created_at" db:"created_at"`
 	UpdatedAt time.Time  `json:"updated_at" db:"updated_at"`
+
+	// 风险评分（仅管理后台用户列表返回）
+	RiskScore *float64      `json:"risk_score,omitempty" db:"risk_score"`
+	RiskLevel *RiskSeverity `json:"risk_level,omitempty" db:"risk_level"`
 }
 
 // UpdateLanguageReq 更新语言偏好请求
@@ -152,6 +158,8 @@ type LoginReq struct {
 	Username          string `json:"username" binding:"required"`
 	Password          string `json:"password" binding:"required"`
 	DeviceFingerprint string `json:"device_fingerprint"` // 设备指纹（可选）
+	ClientIP          string `json:"-"`                  // 客户端 IP（由处理器填写）
+	UserAgent         string `json:"-"`                  // User-Agent（由处理器填写）
 }
 
 // LoginResp 登录响应
@@ -164,12 +172,14 @@ type LoginResp struct {
 type CreateOwnerReq struct {
 	Username string `json:"username" binding:"required,min=3,max=50"`
 	Password string `json:"password" binding:"required,min=6"`
+	Currency string `json:"currency"` // 经营币种，为空使用默认币种
 }
 
 // UserListQuery 用户列表查询
 type UserListQuery struct {
 	Role     *UserRole `form:"role"`
 	Search   *string   `form:"search"`
+	SortBy   string    `form:"sort_by" binding:"omitempty,oneof=created_at risk_score"` // 默认按注册时间
 	Page     int       `form:"page" binding:"min=1"`
 	PageSize int       `form:"page_size" binding:"min=1,max=100"`
 }
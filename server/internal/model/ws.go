@@ -62,6 +62,16 @@ const (
 	WSTypePlayerDisqualified WSMessageType = "player_disqualified"
 	WSTypeRoundCancelled     WSMessageType = "round_cancelled"
 
+	// 资金申请相关
+	WSTypeFundRequestReminder WSMessageType = "fund_request_reminder"
+	WSTypeFundRequestExpired  WSMessageType = "fund_request_expired"
+
+	// 锦标赛相关
+	WSTypeTournamentStandings WSMessageType = "tournament_standings"
+
+	// 账户限制相关
+	WSTypeAccountRestriction WSMessageType = "account_restriction"
+
 	// 告警相关（管理员）
 	WSTypeAlert           WSMessageType = "alert"
 	WSTypeMetricsUpdate   WSMessageType = "metrics_update"
@@ -93,6 +103,8 @@ type WSSetAutoReady struct {
 type WSRoomState struct {
 	RoomID         int64                       `json:"room_id"`
 	RoomName       string                      `json:"room_name"`
+	RoomType       RoomType                    `json:"room_type"` // practice 为练习房，玩家余额为游戏币；tournament 为锦标赛房间，玩家余额为锦标赛筹码
+	TournamentID   *int64                      `json:"tournament_id,omitempty"`
 	BetAmount      string                      `json:"bet_amount"`
 	WinnerCount    int                         `json:"winner_count"`
 	MaxPlayers     int                         `json:"max_players"`
@@ -103,6 +115,7 @@ type WSRoomState struct {
 	Players        map[int64]*WSPlayerState    `json:"players"`
 	Spectators     map[int64]*WSSpectatorState `json:"spectators,omitempty"`
 	PoolAmount     string                      `json:"pool_amount,omitempty"`
+	JackpotAmount  string                      `json:"jackpot_amount,omitempty"` // 累进奖池金额（未开启时为空）
 	IsSpectator    bool                        `json:"is_spectator,omitempty"` // 当前用户是否为观战者
 }
 
@@ -160,6 +173,9 @@ type WSRoundResult struct {
 	PrizePerWinner string   `json:"prize_per_winner"`
 	RevealSeed     string   `json:"reveal_seed"`
 	CommitHash     string   `json:"commit_hash"`
+
+	JackpotHit            bool   `json:"jackpot_hit,omitempty"`              // 本回合触发累进奖池
+	JackpotPrizePerWinner string `json:"jackpot_prize_per_winner,omitempty"` // 每位赢家获得的累进奖池金额
 }
 
 // WSRoundFailed 回合失败
@@ -177,6 +193,7 @@ type WSRoomLocked struct {
 type WSBalanceUpdate struct {
 	Balance       string `json:"balance"`
 	FrozenBalance string `json:"frozen_balance"`
+	BonusBalance  string `json:"bonus_balance,omitempty"`
 }
 
 // WSTimerSync 计时器同步
@@ -286,15 +303,17 @@ type WSPhaseTick struct {
 	TimeRemaining  int64   `json:"time_remaining,omitempty"`   // 剩余时间（毫秒）
 	Phase          *string `json:"phase,omitempty"`            // 当前阶段（仅变化时发送）
 	PoolAmount     *string `json:"pool_amount,omitempty"`      // 奖池金额（仅变化时发送）
+	JackpotAmount  *string `json:"jackpot_amount,omitempty"`   // 累进奖池金额（房间开启累进奖池时发送）
 	PlayerCount    *int    `json:"player_count,omitempty"`     // 玩家数量（仅变化时发送）
 	SpectatorCount *int    `json:"spectator_count,omitempty"`  // 观战者数量（仅变化时发送）
 }
 
 // WSPlayerDisqualified 玩家被取消资格（余额不足等）
 type WSPlayerDisqualified struct {
-	UserID   int64  `json:"user_id"`
-	Username string `json:"username"`
-	Reason   string `json:"reason"` // insufficient_balance, offline, etc.
+	UserID     int64  `json:"user_id"`
+	Username   string `json:"username"`
+	Reason     string `json:"reason"`                // insufficient_balance, account_restricted, etc.
+	ReasonCode string `json:"reason_code,omitempty"` // 账户限制原因代码（仅私发给被取消资格的玩家）
 }
 
 // WSRoundCancelled 回合取消（人数不足）
@@ -304,3 +323,35 @@ type WSRoundCancelled struct {
 	MinPlayersRequired  int                     `json:"min_players_required"`
 	CurrentPlayers      int                     `json:"current_players"`
 }
+
+// WSFundRequestReminder 提醒房主处理待审批的资金申请
+type WSFundRequestReminder struct {
+	PendingCount    int     `json:"pending_count"`
+	RequestIDs      []int64 `json:"request_ids"`
+	OldestCreatedAt int64   `json:"oldest_created_at"` // Unix毫秒
+}
+
+// WSFundRequestExpired 资金申请超时过期通知（发给申请人）
+type WSFundRequestExpired struct {
+	RequestID int64  `json:"request_id"`
+	Type      string `json:"type"`
+	Amount    string `json:"amount"`
+}
+
+// WSTournamentStandings 锦标赛实时排名（推送到该锦标赛的所有房间）
+type WSTournamentStandings struct {
+	TournamentID int64                 `json:"tournament_id"`
+	Status       TournamentStatus      `json:"status"`
+	RoundsPlayed int                   `json:"rounds_played"`
+	MaxRounds    int                   `json:"max_rounds"`
+	Standings    []*TournamentStanding `json:"standings"`
+}
+
+// WSAccountRestriction 账户限制变更通知（发给被限制的玩家）
+type WSAccountRestriction struct {
+	RestrictionID int64  `json:"restriction_id"`
+	Type          string `json:"type"`        // no_betting/no_withdrawal/chat_muted/suspended
+	ReasonCode    string `json:"reason_code"` // risk_control/risk_review_confirmed/admin_action
+	Active        bool   `json:"active"`      // false 表示限制已解除或到期
+	ExpiresAt     int64  `json:"expires_at"`  // Unix毫秒
+}
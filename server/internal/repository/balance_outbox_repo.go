@@ -0,0 +1,149 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/fiveseconds/server/internal/model"
+	"github.com/jackc/pgx/v5"
+	"github.com/shopspring/decimal"
+)
+
+// BalanceChangedChannel 余额变更通知频道（由 trg_users_balance_changed 触发器发送）
+const BalanceChangedChannel = "balance_changed"
+
+// BalanceChange 余额变更通知内容
+type BalanceChange struct {
+	UserID  int64           `json:"user_id"`
+	Delta   decimal.Decimal `json:"delta"`
+	Version int64           `json:"version"`
+}
+
+// PendingCredit 结算事务内登记、尚未落库的写回加款
+type PendingCredit struct {
+	Op        string
+	RoomID    *int64
+	RoundID   *int64
+	Type      model.TransactionType
+	Amounts   map[int64]decimal.Decimal
+	CreatedAt time.Time
+}
+
+// BalanceOutboxRepo 余额写回 outbox 落库仓库
+type BalanceOutboxRepo struct{}
+
+// NewBalanceOutboxRepo 创建余额写回 outbox 落库仓库
+func NewBalanceOutboxRepo() *BalanceOutboxRepo {
+	return &BalanceOutboxRepo{}
+}
+
+// MarkOutboxWriterTx 将事务标记为 outbox 落库写入，使其余额变更不再触发 balance_changed 通知
+func (r *BalanceOutboxRepo) MarkOutboxWriterTx(ctx context.Context, tx pgx.Tx) error {
+	_, err := tx.Exec(ctx, `SELECT set_config('app.balance_writer', 'outbox', true)`)
+	return err
+}
+
+// MarkAppliedTx 在事务内登记 outbox 操作ID，返回首次登记（尚未落库）的操作ID
+// 同时删除这些操作的待写回加款记录
+func (r *BalanceOutboxRepo) MarkAppliedTx(ctx context.Context, tx pgx.Tx, entryIDs []string) ([]string, error) {
+	if len(entryIDs) == 0 {
+		return nil, nil
+	}
+
+	if _, err := tx.Exec(ctx, `DELETE FROM balance_pending_credits WHERE op = ANY($1::VARCHAR[])`, entryIDs); err != nil {
+		return nil, fmt.Errorf("delete pending credits: %w", err)
+	}
+
+	sql := `INSERT INTO balance_outbox_applied (entry_id)
+		SELECT unnest($1::VARCHAR[])
+		ON CONFLICT (entry_id) DO NOTHING
+		RETURNING entry_id`
+	rows, err := tx.Query(ctx, sql, entryIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var fresh []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		fresh = append(fresh, id)
+	}
+	return fresh, rows.Err()
+}
+
+// CreatePendingCreditTx 在事务内登记待写回加款（同一操作ID只登记一次）
+func (r *BalanceOutboxRepo) CreatePendingCreditTx(ctx context.Context, tx pgx.Tx, credit *PendingCredit) error {
+	amounts, err := json.Marshal(credit.Amounts)
+	if err != nil {
+		return fmt.Errorf("marshal amounts: %w", err)
+	}
+	sql := `INSERT INTO balance_pending_credits (op, room_id, round_id, tx_type, amounts)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (op) DO NOTHING`
+	_, err = tx.Exec(ctx, sql, credit.Op, credit.RoomID, credit.RoundID, credit.Type, amounts)
+	return err
+}
+
+// ListPendingCredits 按登记时间查询登记时长不少于 minAge 的待写回加款
+func (r *BalanceOutboxRepo) ListPendingCredits(ctx context.Context, minAge time.Duration, limit int) ([]*PendingCredit, error) {
+	sql := `SELECT op, room_id, round_id, tx_type, amounts, created_at
+		FROM balance_pending_credits
+		WHERE created_at <= NOW() - make_interval(secs => $1)
+		ORDER BY created_at, op
+		LIMIT $2`
+	rows, err := DB.Query(ctx, sql, minAge.Seconds(), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var credits []*PendingCredit
+	for rows.Next() {
+		credit := &PendingCredit{}
+		var amounts []byte
+		if err := rows.Scan(&credit.Op, &credit.RoomID, &credit.RoundID, &credit.Type, &amounts, &credit.CreatedAt); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(amounts, &credit.Amounts); err != nil {
+			return nil, fmt.Errorf("parse amounts of %s: %w", credit.Op, err)
+		}
+		credits = append(credits, credit)
+	}
+	return credits, rows.Err()
+}
+
+// ListenBalanceChanges 监听余额变更通知，直到 ctx 取消或连接出错
+// 每收到一条通知调用一次 fn；返回错误时由调用方负责重连
+func (r *BalanceOutboxRepo) ListenBalanceChanges(ctx context.Context, fn func(*BalanceChange)) error {
+	conn, err := DB.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("acquire conn: %w", err)
+	}
+	defer conn.Release()
+
+	if _, err := conn.Exec(ctx, "LISTEN "+BalanceChangedChannel); err != nil {
+		return fmt.Errorf("listen: %w", err)
+	}
+	// 连接归还连接池前取消监听，避免其他使用者收到通知
+	defer func() {
+		_, _ = conn.Exec(context.Background(), "UNLISTEN "+BalanceChangedChannel)
+	}()
+
+	for {
+		n, err := conn.Conn().WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+		var change BalanceChange
+		if err := json.Unmarshal([]byte(n.Payload), &change); err != nil {
+			continue
+		}
+		fn(&change)
+	}
+}
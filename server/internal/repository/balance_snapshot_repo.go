@@ -0,0 +1,180 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/fiveseconds/server/internal/model"
+	"github.com/jackc/pgx/v5"
+)
+
+// BalanceSnapshotRepo 日终余额快照仓库
+type BalanceSnapshotRepo struct{}
+
+// NewBalanceSnapshotRepo 创建日终余额快照仓库
+func NewBalanceSnapshotRepo() *BalanceSnapshotRepo {
+	return &BalanceSnapshotRepo{}
+}
+
+// CaptureDay 为所有账户记录指定日期的余额快照（单条 SQL），已有快照的账户跳过
+// 活跃币种取 users 余额，玩家非活跃币种钱包各记一条
+// 余额按日终时刻回推：当前余额减去 boundaryAt 之后的流水（同币种、同余额字段），
+// 因此延迟采集或补采历史日期时快照仍为日终时刻的余额；boundaryAt 之后注册的账户不记录
+// 冻结余额没有流水无法回推：frozenKnown 为 true（日终后及时采集）时取当前值，否则记为 NULL（未知）
+// boundary_at 统一按 UTC 存储，返回新增的快照条数
+func (r *BalanceSnapshotRepo) CaptureDay(ctx context.Context, snapshotDate, boundaryAt time.Time, frozenKnown bool) (int64, error) {
+	sql := `WITH later AS (
+			SELECT user_id, currency, balance_field, SUM(amount) AS amount
+			FROM balance_transactions
+			WHERE created_at >= $3
+			GROUP BY user_id, currency, balance_field
+		),
+		holdings AS (
+			SELECT id AS user_id, currency, TRUE AS active, role, invited_by,
+			       balance, frozen_balance, bonus_balance, owner_room_balance, owner_margin_balance
+			FROM users
+			WHERE created_at < $3
+			UNION ALL
+			SELECT u.id, w.currency, FALSE, u.role, u.invited_by,
+			       w.balance, 0, 0, 0, 0
+			FROM user_wallets w
+			JOIN users u ON u.id = w.user_id
+			WHERE w.currency <> u.currency AND u.created_at < $3
+		)
+		INSERT INTO balance_snapshots
+		(user_id, snapshot_date, currency, boundary_at, role, owner_id,
+		 balance, frozen_balance, bonus_balance, commission_balance, margin_balance)
+		SELECT h.user_id, $1, h.currency, $2, h.role, h.invited_by,
+		       h.balance - COALESCE((SELECT SUM(l.amount) FROM later l
+		           WHERE l.user_id = h.user_id AND l.currency = h.currency
+		             AND l.balance_field IN ('balance', 'wallet_' || LOWER(h.currency))), 0),
+		       CASE WHEN NOT h.active THEN 0 WHEN $4 THEN h.frozen_balance END,
+		       h.bonus_balance - CASE WHEN h.active THEN COALESCE((SELECT SUM(l.amount) FROM later l
+		           WHERE l.user_id = h.user_id AND l.currency = h.currency AND l.balance_field = 'bonus_balance'), 0) ELSE 0 END,
+		       h.owner_room_balance - CASE WHEN h.active THEN COALESCE((SELECT SUM(l.amount) FROM later l
+		           WHERE l.user_id = h.user_id AND l.currency = h.currency AND l.balance_field = 'owner_room_balance'), 0) ELSE 0 END,
+		       h.owner_margin_balance - CASE WHEN h.active THEN COALESCE((SELECT SUM(l.amount) FROM later l
+		           WHERE l.user_id = h.user_id AND l.currency = h.currency AND l.balance_field = 'owner_margin_balance'), 0) ELSE 0 END
+		FROM holdings h
+		ON CONFLICT (user_id, snapshot_date, currency) DO NOTHING`
+	tag, err := DB.Exec(ctx, sql, snapshotDate, boundaryAt.UTC(), boundaryAt, frozenKnown)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+// HasDay 指定日期是否已有快照
+func (r *BalanceSnapshotRepo) HasDay(ctx context.Context, snapshotDate time.Time) (bool, error) {
+	sql := `SELECT EXISTS(SELECT 1 FROM balance_snapshots WHERE snapshot_date = $1)`
+	var exists bool
+	err := DB.QueryRow(ctx, sql, snapshotDate).Scan(&exists)
+	return exists, err
+}
+
+// GetLatestDate 获取日终时刻不晚于 before 的最近快照日期
+func (r *BalanceSnapshotRepo) GetLatestDate(ctx context.Context, before time.Time) (time.Time, error) {
+	sql := `SELECT snapshot_date FROM balance_snapshots
+		WHERE boundary_at <= $1
+		ORDER BY snapshot_date DESC
+		LIMIT 1`
+	var date time.Time
+	err := DB.QueryRow(ctx, sql, before.UTC()).Scan(&date)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return time.Time{}, ErrNotFound
+	}
+	return date, err
+}
+
+// ListOwnerSummaries 按房主汇总指定日期的快照（仅统计有回合记录的房主）
+// 玩家余额只统计房主经营币种（含非活跃币种钱包）；补采快照的冻结余额未知，按 0 计
+func (r *BalanceSnapshotRepo) ListOwnerSummaries(ctx context.Context, snapshotDate time.Time) ([]*model.OwnerSnapshotSummary, error) {
+	sql := `WITH active_owners AS (
+	    SELECT DISTINCT r.owner_id
+	    FROM game_rounds gr
+	    JOIN rooms r ON gr.room_id = r.id
+	)
+	SELECT
+	    o.user_id AS owner_id,
+	    o.currency,
+	    COALESCE(SUM(CASE WHEN p.role = 'player' THEN p.balance + COALESCE(p.frozen_balance, 0) ELSE 0 END), 0) AS total_player,
+	    COALESCE(SUM(CASE WHEN p.role = 'player' THEN COALESCE(p.frozen_balance, 0) ELSE 0 END), 0) AS total_player_frozen,
+	    o.balance AS owner_balance,
+	    o.margin_balance,
+	    o.commission_balance
+	FROM balance_snapshots o
+	JOIN active_owners ao ON ao.owner_id = o.user_id
+	LEFT JOIN balance_snapshots p ON p.owner_id = o.user_id AND p.snapshot_date = o.snapshot_date
+	    AND p.currency = o.currency
+	WHERE o.snapshot_date = $1 AND o.role = 'owner'
+	GROUP BY o.user_id, o.currency, o.balance, o.margin_balance, o.commission_balance`
+	rows, err := DB.Query(ctx, sql, snapshotDate)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var summaries []*model.OwnerSnapshotSummary
+	for rows.Next() {
+		s := &model.OwnerSnapshotSummary{}
+		if err := rows.Scan(&s.OwnerID, &s.Currency, &s.TotalPlayer, &s.TotalPlayerFrozen,
+			&s.OwnerBalance, &s.MarginBalance, &s.CommissionBalance); err != nil {
+			return nil, err
+		}
+		summaries = append(summaries, s)
+	}
+	return summaries, rows.Err()
+}
+
+// ListByUser 分页查询用户的历史余额快照（按日期倒序）
+func (r *BalanceSnapshotRepo) ListByUser(ctx context.Context, query *model.BalanceHistoryQuery) ([]*model.DailyBalanceSnapshot, int64, error) {
+	countSQL := `SELECT COUNT(*) FROM balance_snapshots WHERE user_id = $1`
+	listSQL := `SELECT user_id, snapshot_date, currency, boundary_at, role, owner_id,
+			balance, frozen_balance, bonus_balance, commission_balance, margin_balance, captured_at
+		FROM balance_snapshots WHERE user_id = $1`
+
+	args := []interface{}{query.UserID}
+	argIdx := 2
+
+	if query.From != nil {
+		countSQL += fmt.Sprintf(` AND snapshot_date >= $%d`, argIdx)
+		listSQL += fmt.Sprintf(` AND snapshot_date >= $%d`, argIdx)
+		args = append(args, *query.From)
+		argIdx++
+	}
+	if query.To != nil {
+		countSQL += fmt.Sprintf(` AND snapshot_date <= $%d`, argIdx)
+		listSQL += fmt.Sprintf(` AND snapshot_date <= $%d`, argIdx)
+		args = append(args, *query.To)
+		argIdx++
+	}
+
+	var total int64
+	if err := DB.QueryRow(ctx, countSQL, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	listSQL += fmt.Sprintf(` ORDER BY snapshot_date DESC, currency LIMIT $%d OFFSET $%d`, argIdx, argIdx+1)
+	args = append(args, query.PageSize, (query.Page-1)*query.PageSize)
+
+	rows, err := DB.Query(ctx, listSQL, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var snapshots []*model.DailyBalanceSnapshot
+	for rows.Next() {
+		s := &model.DailyBalanceSnapshot{}
+		if err := rows.Scan(
+			&s.UserID, &s.SnapshotDate, &s.Currency, &s.BoundaryAt, &s.Role, &s.OwnerID,
+			&s.Balance, &s.FrozenBalance, &s.BonusBalance, &s.CommissionBalance, &s.MarginBalance, &s.CapturedAt,
+		); err != nil {
+			return nil, 0, err
+		}
+		snapshots = append(snapshots, s)
+	}
+	return snapshots, total, nil
+}
@@ -0,0 +1,147 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/fiveseconds/server/internal/model"
+	"github.com/jackc/pgx/v5"
+	"github.com/shopspring/decimal"
+)
+
+// BonusRepo 奖励发放仓库
+type BonusRepo struct{}
+
+// NewBonusRepo 创建奖励发放仓库
+func NewBonusRepo() *BonusRepo {
+	return &BonusRepo{}
+}
+
+const bonusGrantColumns = `g.id, g.user_id, COALESCE(u.username, ''), g.owner_id, g.granted_by, g.amount,
+	g.wagering_multiplier, g.wagering_required, g.wagered, g.status, g.remark, g.created_at, g.completed_at`
+
+func scanBonusGrant(row pgx.Row) (*model.BonusGrant, error) {
+	g := &model.BonusGrant{}
+	err := row.Scan(
+		&g.ID, &g.UserID, &g.Username, &g.OwnerID, &g.GrantedBy, &g.Amount,
+		&g.WageringMultiplier, &g.WageringRequired, &g.Wagered, &g.Status, &g.Remark, &g.CreatedAt, &g.CompletedAt,
+	)
+	return g, err
+}
+
+// CreateTx 创建奖励发放记录（支持事务）
+func (r *BonusRepo) CreateTx(ctx context.Context, tx pgx.Tx, g *model.BonusGrant) error {
+	sql := `INSERT INTO bonus_grants (user_id, owner_id, granted_by, amount, wagering_multiplier, wagering_required, status, remark)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id, wagered, created_at`
+	return GetExecutor(tx).QueryRow(ctx, sql,
+		g.UserID, g.OwnerID, g.GrantedBy, g.Amount, g.WageringMultiplier, g.WageringRequired, g.Status, g.Remark,
+	).Scan(&g.ID, &g.Wagered, &g.CreatedAt)
+}
+
+// ListActiveForUpdateTx 按发放时间顺序获取用户进行中的奖励并加锁（支持事务）
+func (r *BonusRepo) ListActiveForUpdateTx(ctx context.Context, tx pgx.Tx, userID int64) ([]*model.BonusGrant, error) {
+	sql := `SELECT ` + bonusGrantColumns + `
+		FROM bonus_grants g
+		LEFT JOIN users u ON g.user_id = u.id
+		WHERE g.user_id = $1 AND g.status = 'active'
+		ORDER BY g.created_at, g.id
+		FOR UPDATE OF g`
+	return r.queryGrants(ctx, GetExecutor(tx), sql, userID)
+}
+
+// ListActive 获取用户进行中的奖励
+func (r *BonusRepo) ListActive(ctx context.Context, userID int64) ([]*model.BonusGrant, error) {
+	sql := `SELECT ` + bonusGrantColumns + `
+		FROM bonus_grants g
+		LEFT JOIN users u ON g.user_id = u.id
+		WHERE g.user_id = $1 AND g.status = 'active'
+		ORDER BY g.created_at, g.id`
+	return r.queryGrants(ctx, DB, sql, userID)
+}
+
+func (r *BonusRepo) queryGrants(ctx context.Context, exec TxExecutor, sql string, args ...interface{}) ([]*model.BonusGrant, error) {
+	rows, err := exec.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var grants []*model.BonusGrant
+	for rows.Next() {
+		g, err := scanBonusGrant(rows)
+		if err != nil {
+			return nil, err
+		}
+		grants = append(grants, g)
+	}
+	return grants, rows.Err()
+}
+
+// UpdateProgressTx 更新奖励流水进度，状态变为 completed 时记录完成时间（支持事务）
+func (r *BonusRepo) UpdateProgressTx(ctx context.Context, tx pgx.Tx, id int64, wagered decimal.Decimal, status model.BonusGrantStatus) error {
+	sql := `UPDATE bonus_grants
+		SET wagered = $1, status = $2,
+		    completed_at = CASE WHEN $2 = 'completed' THEN NOW() ELSE completed_at END
+		WHERE id = $3`
+	_, err := GetExecutor(tx).Exec(ctx, sql, wagered, status, id)
+	return err
+}
+
+// ForfeitActiveTx 作废用户所有进行中的奖励（支持事务）
+func (r *BonusRepo) ForfeitActiveTx(ctx context.Context, tx pgx.Tx, userID int64) (int64, error) {
+	sql := `UPDATE bonus_grants SET status = 'forfeited', completed_at = NOW()
+		WHERE user_id = $1 AND status = 'active'`
+	tag, err := GetExecutor(tx).Exec(ctx, sql, userID)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+// List 分页获取奖励发放记录
+func (r *BonusRepo) List(ctx context.Context, query *model.BonusGrantListQuery) ([]*model.BonusGrant, int64, error) {
+	countSQL := `SELECT COUNT(*) FROM bonus_grants g WHERE 1=1`
+	listSQL := `SELECT ` + bonusGrantColumns + `
+		FROM bonus_grants g
+		LEFT JOIN users u ON g.user_id = u.id
+		WHERE 1=1`
+
+	args := []interface{}{}
+	argIdx := 1
+
+	if query.UserID != nil {
+		countSQL += fmt.Sprintf(` AND g.user_id = $%d`, argIdx)
+		listSQL += fmt.Sprintf(` AND g.user_id = $%d`, argIdx)
+		args = append(args, *query.UserID)
+		argIdx++
+	}
+
+	if query.OwnerID != nil {
+		countSQL += fmt.Sprintf(` AND g.owner_id = $%d`, argIdx)
+		listSQL += fmt.Sprintf(` AND g.owner_id = $%d`, argIdx)
+		args = append(args, *query.OwnerID)
+		argIdx++
+	}
+
+	if query.Status != nil {
+		countSQL += fmt.Sprintf(` AND g.status = $%d`, argIdx)
+		listSQL += fmt.Sprintf(` AND g.status = $%d`, argIdx)
+		args = append(args, *query.Status)
+		argIdx++
+	}
+
+	var total int64
+	if err := DB.QueryRow(ctx, countSQL, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	listSQL += fmt.Sprintf(` ORDER BY g.created_at DESC, g.id DESC LIMIT $%d OFFSET $%d`, argIdx, argIdx+1)
+	args = append(args, query.PageSize, (query.Page-1)*query.PageSize)
+
+	grants, err := r.queryGrants(ctx, DB, listSQL, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	return grants, total, nil
+}
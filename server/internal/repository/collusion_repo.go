@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/fiveseconds/server/internal/model"
+)
+
+// CollusionRepo 串通检测数据仓库
+// userIDs 为空时查询全量（夜间批量），否则只查询与这些用户相关的数据（回合结算后的增量分析）
+type CollusionRepo struct{}
+
+// NewCollusionRepo 创建串通检测数据仓库
+func NewCollusionRepo() *CollusionRepo {
+	return &CollusionRepo{}
+}
+
+// ForEachRound 从新到旧遍历窗口内已结算的多人回合
+// userIDs 非空时只返回其中至少两人共同参与的回合，limit > 0 时只取最近的 limit 个
+func (r *CollusionRepo) ForEachRound(ctx context.Context, since time.Time, userIDs []int64, limit int, fn func(*model.CollusionRound) error) error {
+	sql := `SELECT id, participant_ids, COALESCE(winner_ids, '{}')
+		FROM game_rounds
+		WHERE status = 'settled' AND created_at >= $1 AND cardinality(participant_ids) > 1`
+	args := []interface{}{since}
+	if len(userIDs) > 0 {
+		sql += ` AND participant_ids && $2
+			AND cardinality(ARRAY(SELECT unnest(participant_ids) INTERSECT SELECT unnest($2::bigint[]))) >= 2`
+		args = append(args, userIDs)
+	}
+	sql += ` ORDER BY id DESC`
+	if limit > 0 {
+		sql += fmt.Sprintf(` LIMIT $%d`, len(args)+1)
+		args = append(args, limit)
+	}
+
+	rows, err := DB.Query(ctx, sql, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		round := &model.CollusionRound{}
+		if err := rows.Scan(&round.ID, &round.ParticipantIDs, &round.WinnerIDs); err != nil {
+			return err
+		}
+		if err := fn(round); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// ListIdentityLinks 获取共用设备指纹或登录 IP 的账户对（IP 只计窗口内出现过的）
+// userIDs 非空时只返回至少一方属于这些用户的账户对
+func (r *CollusionRepo) ListIdentityLinks(ctx context.Context, since time.Time, userIDs []int64) ([]*model.CollusionLink, error) {
+	sql := `SELECT a.id, b.id, 'device', a.device_fingerprint
+		FROM users a
+		JOIN users b ON b.device_fingerprint = a.device_fingerprint AND b.id > a.id
+		WHERE a.device_fingerprint IS NOT NULL AND a.device_fingerprint <> ''
+		  AND ($1::bigint[] IS NULL OR a.id = ANY($1) OR b.id = ANY($1))
+		UNION ALL
+		SELECT a.user_id, b.user_id, 'ip', a.ip
+		FROM user_login_ips a
+		JOIN user_login_ips b ON b.ip = a.ip AND b.user_id > a.user_id
+		WHERE a.last_seen_at >= $2 AND b.last_seen_at >= $2
+		  AND ($1::bigint[] IS NULL OR a.user_id = ANY($1) OR b.user_id = ANY($1))`
+	var filter []int64
+	if len(userIDs) > 0 {
+		filter = userIDs
+	}
+	rows, err := DB.Query(ctx, sql, filter, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var links []*model.CollusionLink
+	for rows.Next() {
+		link := &model.CollusionLink{}
+		if err := rows.Scan(&link.UserA, &link.UserB, &link.Kind, &link.Value); err != nil {
+			return nil, err
+		}
+		links = append(links, link)
+	}
+	return links, rows.Err()
+}
+
+// ListTransfers 获取窗口内已完成的玩家转账
+// userIDs 非空时只返回转出或转入方属于这些用户的转账
+func (r *CollusionRepo) ListTransfers(ctx context.Context, since time.Time, userIDs []int64) ([]*model.CollusionTransfer, error) {
+	sql := `SELECT from_user_id, to_user_id, owner_id, amount
+		FROM player_transfers
+		WHERE status = 'completed' AND created_at >= $1
+		  AND ($2::bigint[] IS NULL OR from_user_id = ANY($2) OR to_user_id = ANY($2))`
+	var filter []int64
+	if len(userIDs) > 0 {
+		filter = userIDs
+	}
+	rows, err := DB.Query(ctx, sql, since, filter)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var transfers []*model.CollusionTransfer
+	for rows.Next() {
+		t := &model.CollusionTransfer{}
+		if err := rows.Scan(&t.FromUserID, &t.ToUserID, &t.OwnerID, &t.Amount); err != nil {
+			return nil, err
+		}
+		transfers = append(transfers, t)
+	}
+	return transfers, rows.Err()
+}
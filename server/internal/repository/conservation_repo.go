@@ -22,20 +22,20 @@ func (r *ConservationRepo) Insert(ctx context.Context, h *model.FundConservation
         scope, owner_id, period_type, period_start, period_end,
         total_player_balance, total_player_frozen, total_custody_quota, total_margin,
         owner_room_balance, owner_withdrawable_balance, owner_frozen_balance, platform_balance,
-        difference, is_balanced
+        difference, is_balanced, currency
     ) VALUES (
         $1, $2, $3, $4, $5,
         $6, $7, $8, $9,
         $10, $11, $12, $13,
-        $14, $15
-    ) RETURNING id, created_at`
+        $14, $15, $16
+    ) RETURNING id, currency, created_at`
 
 	return DB.QueryRow(ctx, sql,
 		h.Scope, h.OwnerID, h.PeriodType, h.PeriodStart, h.PeriodEnd,
 		h.TotalPlayerBalance, h.TotalPlayerFrozen, h.TotalCustodyQuota, h.TotalMargin,
 		h.OwnerRoomBalance, h.OwnerWithdrawableBalance, h.OwnerFrozenBalance, h.PlatformBalance,
-		h.Difference, h.IsBalanced,
-	).Scan(&h.ID, &h.CreatedAt)
+		h.Difference, h.IsBalanced, h.Currency,
+	).Scan(&h.ID, &h.Currency, &h.CreatedAt)
 }
 
 // FundConservationHistoryQuery 查询条件
@@ -43,7 +43,7 @@ func (r *ConservationRepo) Insert(ctx context.Context, h *model.FundConservation
 
 func (r *ConservationRepo) List(ctx context.Context, q *model.FundConservationHistoryQuery) ([]*model.FundConservationHistory, int64, error) {
 	countSQL := `SELECT COUNT(*) FROM fund_conservation_history WHERE 1=1`
-	listSQL := `SELECT id, scope, owner_id, period_type, period_start, period_end,
+	listSQL := `SELECT id, scope, owner_id, currency, period_type, period_start, period_end,
         total_player_balance, total_player_frozen, total_custody_quota, total_margin,
         owner_room_balance, owner_withdrawable_balance, owner_frozen_balance, platform_balance,
         difference, is_balanced, created_at
@@ -66,6 +66,13 @@ func (r *ConservationRepo) List(ctx context.Context, q *model.FundConservationHi
 		argIdx++
 	}
 
+	if q.Currency != nil {
+		countSQL += fmt.Sprintf(" AND currency = $%d", argIdx)
+		listSQL += fmt.Sprintf(" AND currency = $%d", argIdx)
+		args = append(args, *q.Currency)
+		argIdx++
+	}
+
 	if q.PeriodType != nil {
 		countSQL += fmt.Sprintf(" AND period_type = $%d", argIdx)
 		listSQL += fmt.Sprintf(" AND period_type = $%d", argIdx)
@@ -105,7 +112,7 @@ func (r *ConservationRepo) List(ctx context.Context, q *model.FundConservationHi
 	for rows.Next() {
 		h := &model.FundConservationHistory{}
 		if err := rows.Scan(
-			&h.ID, &h.Scope, &h.OwnerID, &h.PeriodType, &h.PeriodStart, &h.PeriodEnd,
+			&h.ID, &h.Scope, &h.OwnerID, &h.Currency, &h.PeriodType, &h.PeriodStart, &h.PeriodEnd,
 			&h.TotalPlayerBalance, &h.TotalPlayerFrozen, &h.TotalCustodyQuota, &h.TotalMargin,
 			&h.OwnerRoomBalance, &h.OwnerWithdrawableBalance, &h.OwnerFrozenBalance, &h.PlatformBalance,
 			&h.Difference, &h.IsBalanced, &h.CreatedAt,
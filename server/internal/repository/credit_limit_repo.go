@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/fiveseconds/server/internal/model"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/shopspring/decimal"
+)
+
+// CreditLimitRepo 房主信用额度仓库
+type CreditLimitRepo struct{}
+
+// NewCreditLimitRepo 创建房主信用额度仓库
+func NewCreditLimitRepo() *CreditLimitRepo {
+	return &CreditLimitRepo{}
+}
+
+// exposureSQL 房主敞口查询：名下玩家在房主经营币种下的可用 + 冻结余额，加上该币种的非活跃钱包余额
+const exposureSQL = `SELECT o.id, o.username, o.currency, o.owner_margin_balance,
+		COALESCE((SELECT SUM(p.balance + p.frozen_balance) FROM users p
+			WHERE p.role = 'player' AND p.invited_by = o.id AND p.currency = o.currency), 0)
+		+ COALESCE((SELECT SUM(w.balance) FROM user_wallets w JOIN users p ON w.user_id = p.id
+			WHERE p.role = 'player' AND p.invited_by = o.id AND w.currency = o.currency AND p.currency <> o.currency), 0),
+		l.margin_multiplier, l.breached_at
+	FROM users o
+	LEFT JOIN owner_credit_limits l ON l.owner_id = o.id
+	WHERE o.role = 'owner'`
+
+// GetExposureTx 获取房主当前敞口（支持事务）
+func (r *CreditLimitRepo) GetExposureTx(ctx context.Context, tx pgx.Tx, ownerID int64) (*model.OwnerExposure, error) {
+	e := &model.OwnerExposure{}
+	err := GetExecutor(tx).QueryRow(ctx, exposureSQL+` AND o.id = $1`, ownerID).Scan(
+		&e.OwnerID, &e.Username, &e.Currency, &e.MarginBalance, &e.PlayerBalance, &e.CustomMultiplier, &e.BreachedAt,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return e, err
+}
+
+// ListExposures 获取全部房主的当前敞口
+func (r *CreditLimitRepo) ListExposures(ctx context.Context) ([]*model.OwnerExposure, error) {
+	rows, err := DB.Query(ctx, exposureSQL+` ORDER BY o.id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var exposures []*model.OwnerExposure
+	for rows.Next() {
+		e := &model.OwnerExposure{}
+		if err := rows.Scan(
+			&e.OwnerID, &e.Username, &e.Currency, &e.MarginBalance, &e.PlayerBalance, &e.CustomMultiplier, &e.BreachedAt,
+		); err != nil {
+			return nil, err
+		}
+		exposures = append(exposures, e)
+	}
+	return exposures, rows.Err()
+}
+
+// UpsertMultiplier 设置房主信用额度倍数（nil 表示恢复默认倍数）
+func (r *CreditLimitRepo) UpsertMultiplier(ctx context.Context, ownerID int64, multiplier *decimal.Decimal, updatedBy int64) error {
+	sql := `INSERT INTO owner_credit_limits (owner_id, margin_multiplier, updated_by, updated_at)
+		VALUES ($1, $2, $3, NOW())
+		ON CONFLICT (owner_id) DO UPDATE SET
+			margin_multiplier = EXCLUDED.margin_multiplier,
+			updated_by = EXCLUDED.updated_by,
+			updated_at = NOW()`
+	_, err := DB.Exec(ctx, sql, ownerID, multiplier, updatedBy)
+	return err
+}
+
+// SetBreachedAt 记录或清除房主超限开始时间
+func (r *CreditLimitRepo) SetBreachedAt(ctx context.Context, ownerID int64, breachedAt *time.Time) error {
+	sql := `INSERT INTO owner_credit_limits (owner_id, breached_at, updated_at)
+		VALUES ($1, $2, NOW())
+		ON CONFLICT (owner_id) DO UPDATE SET breached_at = EXCLUDED.breached_at, updated_at = NOW()`
+	_, err := DB.Exec(ctx, sql, ownerID, breachedAt)
+	return err
+}
@@ -0,0 +1,171 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/fiveseconds/server/internal/model"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// FairnessRepo 公平性统计仓库
+type FairnessRepo struct{}
+
+// NewFairnessRepo 创建公平性统计仓库
+func NewFairnessRepo() *FairnessRepo {
+	return &FairnessRepo{}
+}
+
+const fairnessReportColumns = `id, run_id, scope, room_id, window_start, window_end, rounds, players,
+	chi_square, degrees_of_freedom, chi_square_p_value, runs_tested, runs_deviations, outliers,
+	p_value_threshold, family_size, p_value_cutoff, significant, created_at`
+
+// scanFairnessReport 扫描公平性报告
+func scanFairnessReport(row pgx.Row) (*model.FairnessReport, error) {
+	rp := &model.FairnessReport{}
+	var runs, outliers []byte
+	err := row.Scan(&rp.ID, &rp.RunID, &rp.Scope, &rp.RoomID, &rp.WindowStart, &rp.WindowEnd, &rp.Rounds, &rp.Players,
+		&rp.ChiSquare, &rp.DegreesOfFreedom, &rp.ChiSquarePValue, &rp.RunsTested, &runs, &outliers,
+		&rp.PValueThreshold, &rp.FamilySize, &rp.PValueCutoff, &rp.Significant, &rp.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(runs, &rp.RunsDeviations); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(outliers, &rp.Outliers); err != nil {
+		return nil, err
+	}
+	return rp, nil
+}
+
+// ForEachRound 按回合顺序（从旧到新）遍历 [since, until) 内已结算的多人回合，limit > 0 时只取最近的 limit 个
+func (r *FairnessRepo) ForEachRound(ctx context.Context, since, until time.Time, limit int, fn func(*model.FairnessRound) error) error {
+	sql := `SELECT id, room_id, participant_ids, COALESCE(winner_ids, '{}')
+		FROM game_rounds
+		WHERE status = 'settled' AND created_at >= $1 AND created_at < $2 AND cardinality(participant_ids) > 1`
+	args := []interface{}{since, until}
+	if limit > 0 {
+		sql = `SELECT * FROM (` + sql + ` ORDER BY id DESC LIMIT $3) r`
+		args = append(args, limit)
+	}
+	sql += ` ORDER BY id`
+
+	rows, err := DB.Query(ctx, sql, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		round := &model.FairnessRound{}
+		if err := rows.Scan(&round.ID, &round.RoomID, &round.ParticipantIDs, &round.WinnerIDs); err != nil {
+			return err
+		}
+		if err := fn(round); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// InsertReports 保存一次统计生成的全部报告
+func (r *FairnessRepo) InsertReports(ctx context.Context, reports []*model.FairnessReport) error {
+	return Tx(ctx, func(tx pgx.Tx) error {
+		for _, rp := range reports {
+			runs, err := json.Marshal(rp.RunsDeviations)
+			if err != nil {
+				return err
+			}
+			outliers, err := json.Marshal(rp.Outliers)
+			if err != nil {
+				return err
+			}
+			err = tx.QueryRow(ctx, `INSERT INTO fairness_reports (run_id, scope, room_id, window_start, window_end, rounds, players,
+					chi_square, degrees_of_freedom, chi_square_p_value, runs_tested, runs_deviations, outliers,
+					p_value_threshold, family_size, p_value_cutoff, significant)
+				VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17)
+				RETURNING id, created_at`,
+				rp.RunID, rp.Scope, rp.RoomID, rp.WindowStart, rp.WindowEnd, rp.Rounds, rp.Players,
+				rp.ChiSquare, rp.DegreesOfFreedom, rp.ChiSquarePValue, rp.RunsTested, runs, outliers,
+				rp.PValueThreshold, rp.FamilySize, rp.PValueCutoff, rp.Significant).Scan(&rp.ID, &rp.CreatedAt)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// ListLatestRun 获取最近一次统计的全部报告（全局在前，房间按 ID 排序）
+func (r *FairnessRepo) ListLatestRun(ctx context.Context) ([]*model.FairnessReport, error) {
+	rows, err := DB.Query(ctx, `SELECT `+fairnessReportColumns+`
+		FROM fairness_reports
+		WHERE run_id = (SELECT MAX(run_id) FROM fairness_reports)
+		ORDER BY scope, room_id NULLS FIRST`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var reports []*model.FairnessReport
+	for rows.Next() {
+		rp, err := scanFairnessReport(rows)
+		if err != nil {
+			return nil, err
+		}
+		reports = append(reports, rp)
+	}
+	return reports, rows.Err()
+}
+
+// List 查询公平性报告历史
+func (r *FairnessRepo) List(ctx context.Context, query *model.FairnessReportQuery) ([]*model.FairnessReport, int64, error) {
+	where := ` WHERE 1=1`
+	args := []interface{}{}
+	argIdx := 1
+
+	if query.Scope != nil {
+		where += fmt.Sprintf(` AND scope = $%d`, argIdx)
+		args = append(args, *query.Scope)
+		argIdx++
+	}
+	if query.RoomID != nil {
+		where += fmt.Sprintf(` AND room_id = $%d`, argIdx)
+		args = append(args, *query.RoomID)
+		argIdx++
+	}
+	if query.Significant != nil {
+		where += fmt.Sprintf(` AND significant = $%d`, argIdx)
+		args = append(args, *query.Significant)
+		argIdx++
+	}
+
+	var total int64
+	if err := DB.QueryRow(ctx, `SELECT COUNT(*) FROM fairness_reports`+where, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	listSQL := `SELECT ` + fairnessReportColumns + ` FROM fairness_reports` + where +
+		fmt.Sprintf(` ORDER BY created_at DESC, id DESC LIMIT $%d OFFSET $%d`, argIdx, argIdx+1)
+	args = append(args, query.PageSize, (query.Page-1)*query.PageSize)
+
+	rows, err := DB.Query(ctx, listSQL, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var reports []*model.FairnessReport
+	for rows.Next() {
+		rp, err := scanFairnessReport(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		reports = append(reports, rp)
+	}
+	return reports, total, rows.Err()
+}
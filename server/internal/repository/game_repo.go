@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/fiveseconds/server/internal/model"
 
@@ -39,12 +40,14 @@ func (r *GameRepo) CreateRoundTx(ctx context.Context, tx pgx.Tx, round *model.Ga
 func (r *GameRepo) GetRoundByID(ctx context.Context, id int64) (*model.GameRound, error) {
 	sql := `SELECT id, room_id, round_number, participant_ids, skipped_ids, winner_ids,
 		bet_amount, pool_amount, prize_per_winner, owner_earning, platform_earning, residual_amount,
+		jackpot_id, jackpot_contribution, jackpot_odds, jackpot_hit, jackpot_payout,
 		commit_hash, reveal_seed, status, failure_reason, created_at, settled_at
 		FROM game_rounds WHERE id = $1`
 	round := &model.GameRound{}
 	err := DB.QueryRow(ctx, sql, id).Scan(
 		&round.ID, &round.RoomID, &round.RoundNumber, &round.ParticipantIDs, &round.SkippedIDs, &round.WinnerIDs,
 		&round.BetAmount, &round.PoolAmount, &round.PrizePerWinner, &round.OwnerEarning, &round.PlatformEarning, &round.ResidualAmount,
+		&round.JackpotID, &round.JackpotContribution, &round.JackpotOdds, &round.JackpotHit, &round.JackpotPayout,
 		&round.CommitHash, &round.RevealSeed, &round.Status, &round.FailureReason, &round.CreatedAt, &round.SettledAt,
 	)
 	if errors.Is(err, pgx.ErrNoRows) {
@@ -70,12 +73,14 @@ func (r *GameRepo) SettleRound(ctx context.Context, round *model.GameRound) erro
 func (r *GameRepo) SettleRoundTx(ctx context.Context, tx pgx.Tx, round *model.GameRound) error {
 	sql := `UPDATE game_rounds SET
 		winner_ids = $1, prize_per_winner = $2, owner_earning = $3, platform_earning = $4, residual_amount = $5,
-		reveal_seed = $6, status = $7, settled_at = NOW()
+		reveal_seed = $6, status = $7, settled_at = NOW(),
+		jackpot_id = $9, jackpot_contribution = $10, jackpot_odds = $11, jackpot_hit = $12, jackpot_payout = $13
 		WHERE id = $8`
 	exec := GetExecutor(tx)
 	tag, err := exec.Exec(ctx, sql,
 		round.WinnerIDs, round.PrizePerWinner, round.OwnerEarning, round.PlatformEarning, round.ResidualAmount,
 		round.RevealSeed, round.Status, round.ID,
+		round.JackpotID, round.JackpotContribution, round.JackpotOdds, round.JackpotHit, round.JackpotPayout,
 	)
 	if err != nil {
 		return err
@@ -86,6 +91,13 @@ func (r *GameRepo) SettleRoundTx(ctx context.Context, tx pgx.Tx, round *model.Ga
 	return nil
 }
 
+// UpdateRoundParticipants 更新回合参与者、跳过列表与奖池（写回模式下扣款在回合创建之后执行）
+func (r *GameRepo) UpdateRoundParticipants(ctx context.Context, round *model.GameRound) error {
+	sql := `UPDATE game_rounds SET participant_ids = $1, skipped_ids = $2, pool_amount = $3 WHERE id = $4`
+	_, err := DB.Exec(ctx, sql, round.ParticipantIDs, round.SkippedIDs, round.PoolAmount, round.ID)
+	return err
+}
+
 // FailRound 标记回合失败
 func (r *GameRepo) FailRound(ctx context.Context, roundID int64, reason string) error {
 	return r.FailRoundTx(ctx, nil, roundID, reason)
@@ -116,6 +128,7 @@ func (r *GameRepo) UpdateRoundStatusTx(ctx context.Context, tx pgx.Tx, roundID i
 func (r *GameRepo) GetPendingRound(ctx context.Context, roomID int64) (*model.GameRound, error) {
 	sql := `SELECT id, room_id, round_number, participant_ids, skipped_ids, winner_ids,
 		bet_amount, pool_amount, prize_per_winner, owner_earning, platform_earning, residual_amount,
+		jackpot_id, jackpot_contribution, jackpot_odds, jackpot_hit, jackpot_payout,
 		commit_hash, reveal_seed, status, failure_reason, created_at, settled_at
 		FROM game_rounds 
 		WHERE room_id = $1 AND status IN ('betting', 'playing')
@@ -124,6 +137,7 @@ func (r *GameRepo) GetPendingRound(ctx context.Context, roomID int64) (*model.Ga
 	err := DB.QueryRow(ctx, sql, roomID).Scan(
 		&round.ID, &round.RoomID, &round.RoundNumber, &round.ParticipantIDs, &round.SkippedIDs, &round.WinnerIDs,
 		&round.BetAmount, &round.PoolAmount, &round.PrizePerWinner, &round.OwnerEarning, &round.PlatformEarning, &round.ResidualAmount,
+		&round.JackpotID, &round.JackpotContribution, &round.JackpotOdds, &round.JackpotHit, &round.JackpotPayout,
 		&round.CommitHash, &round.RevealSeed, &round.Status, &round.FailureReason, &round.CreatedAt, &round.SettledAt,
 	)
 	if errors.Is(err, pgx.ErrNoRows) {
@@ -142,6 +156,7 @@ func (r *GameRepo) ListRounds(ctx context.Context, roomID int64, page, pageSize
 
 	listSQL := `SELECT id, room_id, round_number, participant_ids, skipped_ids, winner_ids,
 		bet_amount, pool_amount, prize_per_winner, owner_earning, platform_earning, residual_amount,
+		jackpot_id, jackpot_contribution, jackpot_odds, jackpot_hit, jackpot_payout,
 		commit_hash, reveal_seed, status, failure_reason, created_at, settled_at
 		FROM game_rounds WHERE room_id = $1 ORDER BY round_number DESC LIMIT $2 OFFSET $3`
 
@@ -157,6 +172,7 @@ func (r *GameRepo) ListRounds(ctx context.Context, roomID int64, page, pageSize
 		if err := rows.Scan(
 			&round.ID, &round.RoomID, &round.RoundNumber, &round.ParticipantIDs, &round.SkippedIDs, &round.WinnerIDs,
 			&round.BetAmount, &round.PoolAmount, &round.PrizePerWinner, &round.OwnerEarning, &round.PlatformEarning, &round.ResidualAmount,
+			&round.JackpotID, &round.JackpotContribution, &round.JackpotOdds, &round.JackpotHit, &round.JackpotPayout,
 			&round.CommitHash, &round.RevealSeed, &round.Status, &round.FailureReason, &round.CreatedAt, &round.SettledAt,
 		); err != nil {
 			return nil, 0, err
@@ -181,17 +197,17 @@ func (r *TransactionRepo) Create(ctx context.Context, tx *model.BalanceTransacti
 
 // CreateTx 创建交易记录(支持事务)
 func (r *TransactionRepo) CreateTx(ctx context.Context, dbTx pgx.Tx, tx *model.BalanceTransaction) error {
-	sql := `INSERT INTO balance_transactions (user_id, room_id, round_id, tx_type, amount, balance_before, balance_after, balance_field, remark)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
-		RETURNING id, created_at`
+	sql := `INSERT INTO balance_transactions (user_id, room_id, round_id, tx_type, amount, balance_before, balance_after, balance_field, remark, currency)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, NULLIF($10, ''))
+		RETURNING id, currency, created_at`
 	balanceField := "balance"
 	if tx.BalanceField != "" {
 		balanceField = tx.BalanceField
 	}
 	exec := GetExecutor(dbTx)
 	return exec.QueryRow(ctx, sql,
-		tx.UserID, tx.RoomID, tx.RoundID, tx.Type, tx.Amount, tx.BalanceBefore, tx.BalanceAfter, balanceField, tx.Remark,
-	).Scan(&tx.ID, &tx.CreatedAt)
+		tx.UserID, tx.RoomID, tx.RoundID, tx.Type, tx.Amount, tx.BalanceBefore, tx.BalanceAfter, balanceField, tx.Remark, tx.Currency,
+	).Scan(&tx.ID, &tx.Currency, &tx.CreatedAt)
 }
 
 // BatchCreateTx 批量创建交易记录（单条 SQL）
@@ -202,7 +218,7 @@ func (r *TransactionRepo) BatchCreateTx(ctx context.Context, dbTx pgx.Tx, txs []
 
 	// 构建批量 INSERT SQL
 	valueStrings := make([]string, 0, len(txs))
-	args := make([]interface{}, 0, len(txs)*9)
+	args := make([]interface{}, 0, len(txs)*10)
 	argIdx := 1
 
 	for _, tx := range txs {
@@ -211,15 +227,15 @@ func (r *TransactionRepo) BatchCreateTx(ctx context.Context, dbTx pgx.Tx, txs []
 			balanceField = tx.BalanceField
 		}
 		valueStrings = append(valueStrings, fmt.Sprintf(
-			"($%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d)",
-			argIdx, argIdx+1, argIdx+2, argIdx+3, argIdx+4, argIdx+5, argIdx+6, argIdx+7, argIdx+8,
+			"($%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, NULLIF($%d, ''))",
+			argIdx, argIdx+1, argIdx+2, argIdx+3, argIdx+4, argIdx+5, argIdx+6, argIdx+7, argIdx+8, argIdx+9,
 		))
-		args = append(args, tx.UserID, tx.RoomID, tx.RoundID, tx.Type, tx.Amount, tx.BalanceBefore, tx.BalanceAfter, balanceField, tx.Remark)
-		argIdx += 9
+		args = append(args, tx.UserID, tx.RoomID, tx.RoundID, tx.Type, tx.Amount, tx.BalanceBefore, tx.BalanceAfter, balanceField, tx.Remark, tx.Currency)
+		argIdx += 10
 	}
 
 	sql := fmt.Sprintf(`INSERT INTO balance_transactions 
-		(user_id, room_id, round_id, tx_type, amount, balance_before, balance_after, balance_field, remark)
+		(user_id, room_id, round_id, tx_type, amount, balance_before, balance_after, balance_field, remark, currency)
 		VALUES %s`, strings.Join(valueStrings, ", "))
 
 	exec := GetExecutor(dbTx)
@@ -227,10 +243,60 @@ func (r *TransactionRepo) BatchCreateTx(ctx context.Context, dbTx pgx.Tx, txs []
 	return err
 }
 
+// GetRoundBonusStakes 获取回合中各用户以奖励余额支付的下注金额
+// 用于服务重启后退款未结算回合时区分真实余额与奖励余额
+func (r *TransactionRepo) GetRoundBonusStakes(ctx context.Context, roundID int64) (map[int64]decimal.Decimal, error) {
+	sql := `SELECT user_id, -SUM(amount) FROM balance_transactions
+		WHERE round_id = $1 AND tx_type = 'bonus_bet' AND balance_field = 'bonus_balance'
+		GROUP BY user_id`
+	rows, err := DB.Query(ctx, sql, roundID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stakes := make(map[int64]decimal.Decimal)
+	for rows.Next() {
+		var userID int64
+		var amount decimal.Decimal
+		if err := rows.Scan(&userID, &amount); err != nil {
+			return nil, err
+		}
+		stakes[userID] = amount
+	}
+	return stakes, rows.Err()
+}
+
+// GetRoundBettors 获取回合中有下注流水且尚未退款的用户
+// 写回模式下回合先于扣款创建，服务重启后只退款实际已扣款的参与者；
+// 重启前已执行的补偿退款同样落库为退款流水，这些参与者不再重复退款
+func (r *TransactionRepo) GetRoundBettors(ctx context.Context, roundID int64) (map[int64]bool, error) {
+	sql := `SELECT user_id FROM balance_transactions
+		WHERE round_id = $1
+		GROUP BY user_id
+		HAVING bool_or(tx_type IN ('game_bet', 'bonus_bet'))
+		   AND NOT bool_or(tx_type IN ('game_refund', 'bonus_refund'))`
+	rows, err := DB.Query(ctx, sql, roundID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	bettors := make(map[int64]bool)
+	for rows.Next() {
+		var userID int64
+		if err := rows.Scan(&userID); err != nil {
+			return nil, err
+		}
+		bettors[userID] = true
+	}
+	return bettors, rows.Err()
+}
+
 // List 分页获取交易记录
 func (r *TransactionRepo) List(ctx context.Context, query *model.TransactionListQuery) ([]*model.BalanceTransaction, int64, error) {
 	countSQL := `SELECT COUNT(*) FROM balance_transactions WHERE 1=1`
-	listSQL := `SELECT id, user_id, room_id, round_id, tx_type, amount, balance_before, balance_after, remark, created_at
+	listSQL := `SELECT id, user_id, room_id, round_id, tx_type, amount, balance_before, balance_after, currency, remark, created_at
 		FROM balance_transactions WHERE 1=1`
 
 	args := []interface{}{}
@@ -257,6 +323,13 @@ func (r *TransactionRepo) List(ctx context.Context, query *model.TransactionList
 		argIdx++
 	}
 
+	if query.Currency != nil {
+		countSQL += fmt.Sprintf(` AND currency = $%d`, argIdx)
+		listSQL += fmt.Sprintf(` AND currency = $%d`, argIdx)
+		args = append(args, *query.Currency)
+		argIdx++
+	}
+
 	var total int64
 	if err := DB.QueryRow(ctx, countSQL, args...).Scan(&total); err != nil {
 		return nil, 0, err
@@ -275,7 +348,7 @@ func (r *TransactionRepo) List(ctx context.Context, query *model.TransactionList
 	for rows.Next() {
 		tx := &model.BalanceTransaction{}
 		if err := rows.Scan(
-			&tx.ID, &tx.UserID, &tx.RoomID, &tx.RoundID, &tx.Type, &tx.Amount, &tx.BalanceBefore, &tx.BalanceAfter, &tx.Remark, &tx.CreatedAt,
+			&tx.ID, &tx.UserID, &tx.RoomID, &tx.RoundID, &tx.Type, &tx.Amount, &tx.BalanceBefore, &tx.BalanceAfter, &tx.Currency, &tx.Remark, &tx.CreatedAt,
 		); err != nil {
 			return nil, 0, err
 		}
@@ -294,19 +367,34 @@ func NewFundRequestRepo() *FundRequestRepo {
 
 // Create 创建资金申请
 func (r *FundRequestRepo) Create(ctx context.Context, req *model.FundRequest) error {
-	sql := `INSERT INTO fund_requests (user_id, request_type, amount, remark)
-		VALUES ($1, $2, $3, $4)
+	sql := `INSERT INTO fund_requests (user_id, request_type, amount, currency, remark)
+		VALUES ($1, $2, $3, $4, $5)
 		RETURNING id, status, created_at`
-	return DB.QueryRow(ctx, sql, req.UserID, req.Type, req.Amount, req.Remark).Scan(&req.ID, &req.Status, &req.CreatedAt)
+	return DB.QueryRow(ctx, sql, req.UserID, req.Type, req.Amount, req.Currency, req.Remark).Scan(&req.ID, &req.Status, &req.CreatedAt)
 }
 
 // GetByID 根据ID获取申请
 func (r *FundRequestRepo) GetByID(ctx context.Context, id int64) (*model.FundRequest, error) {
-	sql := `SELECT id, user_id, request_type, amount, status, remark, operator_id, updated_at, created_at
-		FROM fund_requests WHERE id = $1`
+	sql := `SELECT f.id, f.user_id, u.invited_by, f.request_type, f.amount, f.currency, f.status, f.remark, f.operator_id, f.updated_at, f.created_at
+		FROM fund_requests f LEFT JOIN users u ON f.user_id = u.id WHERE f.id = $1`
 	req := &model.FundRequest{}
 	err := DB.QueryRow(ctx, sql, id).Scan(
-		&req.ID, &req.UserID, &req.Type, &req.Amount, &req.Status, &req.Remark, &req.ProcessedBy, &req.ProcessedAt, &req.CreatedAt,
+		&req.ID, &req.UserID, &req.OwnerID, &req.Type, &req.Amount, &req.Currency, &req.Status, &req.Remark, &req.ProcessedBy, &req.ProcessedAt, &req.CreatedAt,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return req, err
+}
+
+// GetForUpdateTx 获取并锁定申请行（须在事务中调用），并发审批与过期任务在此串行
+func (r *FundRequestRepo) GetForUpdateTx(ctx context.Context, tx pgx.Tx, id int64) (*model.FundRequest, error) {
+	sql := `SELECT f.id, f.user_id, u.invited_by, f.request_type, f.amount, f.currency, f.status, f.remark, f.operator_id, f.updated_at, f.created_at
+		FROM fund_requests f LEFT JOIN users u ON f.user_id = u.id WHERE f.id = $1
+		FOR UPDATE OF f`
+	req := &model.FundRequest{}
+	err := GetExecutor(tx).QueryRow(ctx, sql, id).Scan(
+		&req.ID, &req.UserID, &req.OwnerID, &req.Type, &req.Amount, &req.Currency, &req.Status, &req.Remark, &req.ProcessedBy, &req.ProcessedAt, &req.CreatedAt,
 	)
 	if errors.Is(err, pgx.ErrNoRows) {
 		return nil, ErrNotFound
@@ -314,11 +402,17 @@ func (r *FundRequestRepo) GetByID(ctx context.Context, id int64) (*model.FundReq
 	return req, err
 }
 
-// Process 处理申请
+// Process 处理申请（终态：通过/拒绝），处于任一待审批状态的申请均可处理
 func (r *FundRequestRepo) Process(ctx context.Context, id int64, status model.FundRequestStatus, processedBy int64, remark string) error {
-	sql := `UPDATE fund_requests SET status = $1, operator_id = $2, remark = COALESCE(remark, '') || $3
-		WHERE id = $4 AND status = 'pending'`
-	tag, err := DB.Exec(ctx, sql, status, processedBy, remark, id)
+	return r.ProcessTx(ctx, nil, id, status, processedBy, remark)
+}
+
+// ProcessTx 处理申请(支持事务)
+func (r *FundRequestRepo) ProcessTx(ctx context.Context, tx pgx.Tx, id int64, status model.FundRequestStatus, processedBy int64, remark string) error {
+	sql := `UPDATE fund_requests SET status = $1, operator_id = $2, remark = COALESCE(remark, '') || $3, updated_at = NOW()
+		WHERE id = $4 AND status IN ('pending', 'pending_second_approval', 'escalated')`
+	exec := GetExecutor(tx)
+	tag, err := exec.Exec(ctx, sql, status, processedBy, remark, id)
 	if err != nil {
 		return err
 	}
@@ -328,10 +422,145 @@ func (r *FundRequestRepo) Process(ctx context.Context, id int64, status model.Fu
 	return nil
 }
 
+// UpdateStatusTx 推进审批中间状态（乐观校验当前状态，防止并发审批）
+func (r *FundRequestRepo) UpdateStatusTx(ctx context.Context, tx pgx.Tx, id int64, from, to model.FundRequestStatus) error {
+	sql := `UPDATE fund_requests SET status = $1, updated_at = NOW() WHERE id = $2 AND status = $3`
+	exec := GetExecutor(tx)
+	tag, err := exec.Exec(ctx, sql, to, id, from)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return errors.New("request not found or status changed")
+	}
+	return nil
+}
+
+// CreateApprovalTx 记录一个审批步骤
+func (r *FundRequestRepo) CreateApprovalTx(ctx context.Context, tx pgx.Tx, a *model.FundRequestApproval) error {
+	sql := `INSERT INTO fund_request_approvals (request_id, approver_id, approver_role, action, status_before, status_after, amount, remark)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id, created_at`
+	exec := GetExecutor(tx)
+	return exec.QueryRow(ctx, sql,
+		a.RequestID, a.ApproverID, a.ApproverRole, a.Action, a.StatusBefore, a.StatusAfter, a.Amount, a.Remark,
+	).Scan(&a.ID, &a.CreatedAt)
+}
+
+// ListApprovals 获取申请的全部审批记录（按时间顺序）
+func (r *FundRequestRepo) ListApprovals(ctx context.Context, requestID int64) ([]*model.FundRequestApproval, error) {
+	return r.ListApprovalsTx(ctx, nil, requestID)
+}
+
+// ListApprovalsTx 获取申请的全部审批记录(支持事务)
+func (r *FundRequestRepo) ListApprovalsTx(ctx context.Context, tx pgx.Tx, requestID int64) ([]*model.FundRequestApproval, error) {
+	sql := `SELECT id, request_id, approver_id, approver_role, action, status_before, status_after, amount, remark, created_at
+		FROM fund_request_approvals WHERE request_id = $1 ORDER BY created_at, id`
+	rows, err := GetExecutor(tx).Query(ctx, sql, requestID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var approvals []*model.FundRequestApproval
+	for rows.Next() {
+		a := &model.FundRequestApproval{}
+		if err := rows.Scan(
+			&a.ID, &a.RequestID, &a.ApproverID, &a.ApproverRole, &a.Action, &a.StatusBefore, &a.StatusAfter, &a.Amount, &a.Remark, &a.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		approvals = append(approvals, a)
+	}
+	return approvals, rows.Err()
+}
+
+// LockApproverTx 在事务内锁定审批人的每日审批额度（事务级咨询锁，提交或回滚时释放）
+// 同一审批人的并发审批在此串行，后提交的审批能看到先提交审批占用的额度
+func (r *FundRequestRepo) LockApproverTx(ctx context.Context, tx pgx.Tx, approverID int64) error {
+	_, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtextextended('fund_approval_cap:' || $1::text, 0))`, approverID)
+	return err
+}
+
+// GetApprovedAmountSinceTx 统计审批人自某时刻起审批通过并占用每日审批额度的申请总额
+// 审批人放行的步骤都计入：进入 approved 终态的审批，以及双人审批中等待第二审批人的首次审批；
+// 升级至管理员的步骤由管理员决定，不计入；之后被拒绝或过期的申请释放所占额度
+func (r *FundRequestRepo) GetApprovedAmountSinceTx(ctx context.Context, tx pgx.Tx, approverID int64, since time.Time) (decimal.Decimal, error) {
+	sql := `SELECT COALESCE(SUM(a.amount), 0) FROM fund_request_approvals a
+		JOIN fund_requests f ON f.id = a.request_id
+		WHERE a.approver_id = $1 AND a.action = 'approve' AND a.created_at >= $2
+		  AND a.status_after IN ('approved', 'pending_second_approval')
+		  AND f.status NOT IN ('rejected', 'expired')`
+	var total decimal.Decimal
+	err := GetExecutor(tx).QueryRow(ctx, sql, approverID, since).Scan(&total)
+	return total, err
+}
+
+// ExpireStale 将创建时间早于 createdBefore 的待审批申请标记为过期，返回被过期的申请
+// 与审批使用同一行锁：正在审批（已被 GetForUpdateTx 锁定）的申请跳过，更新时再次校验状态
+func (r *FundRequestRepo) ExpireStale(ctx context.Context, createdBefore time.Time) ([]*model.FundRequest, error) {
+	sql := `UPDATE fund_requests f SET status = 'expired', updated_at = NOW()
+		FROM users u
+		WHERE f.user_id = u.id AND f.status IN ('pending', 'pending_second_approval', 'escalated')
+		  AND f.id IN (
+			SELECT id FROM fund_requests
+			WHERE status IN ('pending', 'pending_second_approval', 'escalated') AND created_at < $1
+			FOR UPDATE SKIP LOCKED
+		  )
+		RETURNING f.id, f.user_id, u.invited_by, f.request_type, f.amount, f.status, f.created_at`
+	rows, err := DB.Query(ctx, sql, createdBefore)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var reqs []*model.FundRequest
+	for rows.Next() {
+		req := &model.FundRequest{}
+		if err := rows.Scan(&req.ID, &req.UserID, &req.OwnerID, &req.Type, &req.Amount, &req.Status, &req.CreatedAt); err != nil {
+			return nil, err
+		}
+		reqs = append(reqs, req)
+	}
+	return reqs, rows.Err()
+}
+
+// ListDueReminders 获取需要提醒房主处理的待审批申请
+// 条件：已等待超过 dueBefore，且从未提醒或上次提醒早于 dueBefore；升级至管理员的申请不提醒房主
+func (r *FundRequestRepo) ListDueReminders(ctx context.Context, dueBefore time.Time) ([]*model.FundRequest, error) {
+	sql := `SELECT f.id, f.user_id, u.invited_by, f.request_type, f.amount, f.status, f.created_at
+		FROM fund_requests f JOIN users u ON f.user_id = u.id
+		WHERE f.status IN ('pending', 'pending_second_approval') AND u.invited_by IS NOT NULL
+		  AND f.created_at < $1 AND (f.last_reminded_at IS NULL OR f.last_reminded_at < $1)
+		ORDER BY f.created_at`
+	rows, err := DB.Query(ctx, sql, dueBefore)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var reqs []*model.FundRequest
+	for rows.Next() {
+		req := &model.FundRequest{}
+		if err := rows.Scan(&req.ID, &req.UserID, &req.OwnerID, &req.Type, &req.Amount, &req.Status, &req.CreatedAt); err != nil {
+			return nil, err
+		}
+		reqs = append(reqs, req)
+	}
+	return reqs, rows.Err()
+}
+
+// MarkReminded 记录已发送提醒
+func (r *FundRequestRepo) MarkReminded(ctx context.Context, ids []int64) error {
+	sql := `UPDATE fund_requests SET last_reminded_at = NOW(), reminder_count = reminder_count + 1 WHERE id = ANY($1)`
+	_, err := DB.Exec(ctx, sql, ids)
+	return err
+}
+
 // List 分页获取申请列表
 func (r *FundRequestRepo) List(ctx context.Context, query *model.FundRequestListQuery) ([]*model.FundRequest, int64, error) {
 	countSQL := `SELECT COUNT(*) FROM fund_requests f WHERE 1=1`
-	listSQL := `SELECT f.id, f.user_id, COALESCE(u.username, '') as username, f.request_type, f.amount, f.status, f.remark, f.operator_id, f.updated_at, f.created_at
+	listSQL := `SELECT f.id, f.user_id, COALESCE(u.username, '') as username, f.request_type, f.amount, f.currency, f.status, f.remark, f.operator_id, f.updated_at, f.created_at
 		FROM fund_requests f LEFT JOIN users u ON f.user_id = u.id WHERE 1=1`
 
 	args := []interface{}{}
@@ -384,7 +613,7 @@ func (r *FundRequestRepo) List(ctx context.Context, query *model.FundRequestList
 	for rows.Next() {
 		req := &model.FundRequest{}
 		if err := rows.Scan(
-			&req.ID, &req.UserID, &req.Username, &req.Type, &req.Amount, &req.Status, &req.Remark, &req.ProcessedBy, &req.ProcessedAt, &req.CreatedAt,
+			&req.ID, &req.UserID, &req.Username, &req.Type, &req.Amount, &req.Currency, &req.Status, &req.Remark, &req.ProcessedBy, &req.ProcessedAt, &req.CreatedAt,
 		); err != nil {
 			return nil, 0, err
 		}
@@ -401,41 +630,72 @@ func NewPlatformRepo() *PlatformRepo {
 	return &PlatformRepo{}
 }
 
-// GetAccount 获取平台账户
-func (r *PlatformRepo) GetAccount(ctx context.Context) (*model.PlatformAccount, error) {
-	sql := `SELECT id, platform_balance, updated_at FROM platform_account WHERE id = 1`
+// GetAccount 获取平台账户（指定币种，尚未入账的币种余额为 0）
+func (r *PlatformRepo) GetAccount(ctx context.Context, currency string) (*model.PlatformAccount, error) {
+	sql := `SELECT id, currency, platform_balance, updated_at FROM platform_account WHERE currency = $1`
 	acc := &model.PlatformAccount{}
-	err := DB.QueryRow(ctx, sql).Scan(&acc.ID, &acc.PlatformBalance, &acc.UpdatedAt)
+	err := DB.QueryRow(ctx, sql, currency).Scan(&acc.ID, &acc.Currency, &acc.PlatformBalance, &acc.UpdatedAt)
 	if errors.Is(err, pgx.ErrNoRows) {
-		return nil, ErrNotFound
+		return &model.PlatformAccount{Currency: currency, PlatformBalance: decimal.Zero}, nil
 	}
 	return acc, err
 }
 
+// ListCurrencies 获取系统中出现过的全部币种（账户、钱包、平台账户）
+func (r *PlatformRepo) ListCurrencies(ctx context.Context) ([]string, error) {
+	sql := `SELECT currency FROM users
+		UNION SELECT currency FROM user_wallets
+		UNION SELECT currency FROM platform_account
+		ORDER BY 1`
+	rows, err := DB.Query(ctx, sql)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var currencies []string
+	for rows.Next() {
+		var currency string
+		if err := rows.Scan(&currency); err != nil {
+			return nil, err
+		}
+		currencies = append(currencies, currency)
+	}
+	return currencies, rows.Err()
+}
+
 // UpdateBalance 更新平台余额
-func (r *PlatformRepo) UpdateBalance(ctx context.Context, delta decimal.Decimal) error {
-	return r.UpdateBalanceTx(ctx, nil, delta)
+func (r *PlatformRepo) UpdateBalance(ctx context.Context, currency string, delta decimal.Decimal) error {
+	return r.UpdateBalanceTx(ctx, nil, currency, delta)
 }
 
-// UpdateBalanceTx 更新平台余额(支持事务)
-func (r *PlatformRepo) UpdateBalanceTx(ctx context.Context, tx pgx.Tx, delta decimal.Decimal) error {
-	sql := `UPDATE platform_account SET platform_balance = platform_balance + $1, updated_at = NOW() WHERE id = 1`
+// UpdateBalanceTx 更新平台余额(支持事务)，币种首次入账时创建该币种的平台账户
+func (r *PlatformRepo) UpdateBalanceTx(ctx context.Context, tx pgx.Tx, currency string, delta decimal.Decimal) error {
+	sql := `INSERT INTO platform_account (currency, platform_balance) VALUES ($1, $2)
+		ON CONFLICT (currency) DO UPDATE
+		SET platform_balance = platform_account.platform_balance + EXCLUDED.platform_balance, updated_at = NOW()`
 	exec := GetExecutor(tx)
-	_, err := exec.Exec(ctx, sql, delta)
+	_, err := exec.Exec(ctx, sql, currency, delta)
 	return err
 }
 
-// CheckConservation 检查资金守恒
+// CheckConservation 检查指定币种的资金守恒
 // 资金守恒公式: 系统内资金总和 = 房主净充值额
-// 系统内资金 = 玩家余额 + 房主可用余额 + 房主佣金 + 平台余额
-func (r *PlatformRepo) CheckConservation(ctx context.Context) (*model.ConservationCheck, error) {
-	result := &model.ConservationCheck{}
+// 系统内资金 = 玩家余额 + 玩家非活跃币种钱包 + 房主可用余额 + 房主佣金 + 平台余额 + 锦标赛奖池
+func (r *PlatformRepo) CheckConservation(ctx context.Context, currency string) (*model.ConservationCheck, error) {
+	result := &model.ConservationCheck{Currency: currency}
 
-	// 1. 获取所有玩家余额（可用+冻结）
+	// 1. 获取所有玩家余额（可用+冻结，以及非活跃币种钱包）
 	err := DB.QueryRow(ctx, `SELECT 
 		COALESCE(SUM(balance), 0), 
 		COALESCE(SUM(frozen_balance), 0) 
-		FROM users WHERE role = 'player'`).Scan(&result.TotalPlayerBalance, &result.TotalPlayerFrozen)
+		FROM users WHERE role = 'player' AND currency = $1`, currency).Scan(&result.TotalPlayerBalance, &result.TotalPlayerFrozen)
+	if err != nil {
+		return nil, err
+	}
+	err = DB.QueryRow(ctx, `SELECT COALESCE(SUM(w.balance), 0)
+		FROM user_wallets w JOIN users u ON w.user_id = u.id
+		WHERE w.currency = $1 AND u.currency <> $1`, currency).Scan(&result.TotalWalletBalance)
 	if err != nil {
 		return nil, err
 	}
@@ -446,7 +706,7 @@ func (r *PlatformRepo) CheckConservation(ctx context.Context) (*model.Conservati
 		COALESCE(SUM(owner_room_balance), 0),
 		COALESCE(SUM(owner_margin_balance), 0),
 		COALESCE(SUM(owner_custody_quota), 0)
-		FROM users WHERE role = 'owner'`).Scan(
+		FROM users WHERE role = 'owner' AND currency = $1`, currency).Scan(
 		&result.TotalOwnerBalance,
 		&result.TotalOwnerCommission,
 		&result.TotalMargin,
@@ -457,7 +717,15 @@ func (r *PlatformRepo) CheckConservation(ctx context.Context) (*model.Conservati
 	}
 
 	// 3. 获取平台余额
-	err = DB.QueryRow(ctx, `SELECT COALESCE(platform_balance, 0) FROM platform_account WHERE id = 1`).Scan(&result.PlatformBalance)
+	err = DB.QueryRow(ctx, `SELECT COALESCE(SUM(platform_balance), 0) FROM platform_account WHERE currency = $1`, currency).Scan(&result.PlatformBalance)
+	if err != nil {
+		return nil, err
+	}
+	err = DB.QueryRow(ctx, `SELECT COALESCE(SUM(pool_balance), 0) FROM tournaments WHERE currency = $1`, currency).Scan(&result.TotalTournamentPool)
+	if err != nil {
+		return nil, err
+	}
+	err = DB.QueryRow(ctx, `SELECT COALESCE(SUM(balance), 0) FROM jackpots WHERE currency = $1`, currency).Scan(&result.TotalJackpotBalance)
 	if err != nil {
 		return nil, err
 	}
@@ -471,7 +739,7 @@ func (r *PlatformRepo) CheckConservation(ctx context.Context) (*model.Conservati
 		COALESCE(SUM(CASE WHEN request_type = 'margin_deposit' THEN amount ELSE 0 END), 0) AS margin_deposit,
 		COALESCE(SUM(CASE WHEN request_type = 'owner_withdraw' THEN amount ELSE 0 END), 0) AS owner_withdraw
 		FROM fund_requests 
-		WHERE status = 'approved'`).Scan(&result.TotalOwnerDeposit, &result.TotalMargin, &result.TotalOwnerWithdraw)
+		WHERE status = 'approved' AND currency = $1`, currency).Scan(&result.TotalOwnerDeposit, &result.TotalMargin, &result.TotalOwnerWithdraw)
 	if err != nil {
 		// 如果查询失败，不影响主流程，设为0
 		result.TotalOwnerDeposit = decimal.Zero
@@ -481,13 +749,20 @@ func (r *PlatformRepo) CheckConservation(ctx context.Context) (*model.Conservati
 	result.TotalOwnerDeposit = result.TotalOwnerDeposit.Add(result.TotalMargin)
 
 	// 5. 计算系统内资金总和
-	// 系统内资金 = 玩家余额(可用+冻结) + 房主可用余额 + 房主佣金收益 + 房主保证金 + 平台余额
+	// 系统内资金 = 玩家余额(可用+冻结) + 玩家奖励余额 + 玩家非活跃币种钱包 + 房主可用余额 + 房主佣金收益 + 房主保证金 + 平台余额 + 锦标赛奖池 + 累进奖池
+	if err := r.checkBonusBalance(ctx, result, nil, currency); err != nil {
+		return nil, err
+	}
 	result.SystemTotalFunds = result.TotalPlayerBalance.
+		Add(result.TotalBonusBalance).
 		Add(result.TotalPlayerFrozen).
+		Add(result.TotalWalletBalance).
 		Add(result.TotalOwnerBalance).
 		Add(result.TotalOwnerCommission).
 		Add(result.TotalMargin).
-		Add(result.PlatformBalance)
+		Add(result.PlatformBalance).
+		Add(result.TotalTournamentPool).
+		Add(result.TotalJackpotBalance)
 
 	// 6. 计算预期总额（房主净充值 = 充值 - 提现）
 	result.ExpectedTotal = result.TotalOwnerDeposit.Sub(result.TotalOwnerWithdraw)
@@ -502,30 +777,70 @@ func (r *PlatformRepo) CheckConservation(ctx context.Context) (*model.Conservati
 	tolerance := decimal.NewFromFloat(0.01)
 	result.IsBalanced = result.Difference.Abs().LessThanOrEqual(tolerance)
 
+	// 8. 玩家间转账为系统内部流转，转入与转出必须相互抵消
+	if err := r.checkTransferBalance(ctx, result, nil, currency); err != nil {
+		return nil, err
+	}
+	if !result.TransferImbalance.Abs().LessThanOrEqual(tolerance) {
+		result.IsBalanced = false
+	}
+
 	return result, nil
 }
 
-// CheckConservationByOwner 按房主维度检查资金守恒
+// checkTransferBalance 核对指定币种的玩家间转账（ownerID 为空时统计全局）
+// 已完成转账记录的金额须分别等于转出流水与转入流水的金额，任何一侧缺失或多出都计入偏差
+func (r *PlatformRepo) checkTransferBalance(ctx context.Context, result *model.ConservationCheck, ownerID *int64, currency string) error {
+	sql := `SELECT
+		COALESCE(SUM(CASE WHEN bt.tx_type = 'transfer_in' THEN bt.amount ELSE 0 END), 0),
+		COALESCE(SUM(CASE WHEN bt.tx_type = 'transfer_out' THEN ABS(bt.amount) ELSE 0 END), 0)
+		FROM balance_transactions bt
+		JOIN users u ON bt.user_id = u.id
+		WHERE bt.tx_type IN ('transfer_in', 'transfer_out') AND bt.currency = $2 AND ($1::BIGINT IS NULL OR u.invited_by = $1)`
+	if err := DB.QueryRow(ctx, sql, ownerID, currency).Scan(&result.TotalTransferIn, &result.TotalTransferOut); err != nil {
+		return err
+	}
+	err := DB.QueryRow(ctx, `SELECT COALESCE(SUM(amount), 0) FROM player_transfers
+		WHERE status = 'completed' AND currency = $2 AND ($1::BIGINT IS NULL OR owner_id = $1)`,
+		ownerID, currency).Scan(&result.TotalTransferCompleted)
+	if err != nil {
+		return err
+	}
+	result.TransferImbalance = model.TransferLedgerImbalance(result.TotalTransferCompleted, result.TotalTransferIn, result.TotalTransferOut)
+	return nil
+}
+
+// checkBonusBalance 汇总指定币种的玩家奖励余额（ownerID 为空时统计全局）
+// 按房主统计时同时汇总平台出资发放给名下玩家的奖励：玩家奖励入账总额减去房主佣金出资总额
+func (r *PlatformRepo) checkBonusBalance(ctx context.Context, result *model.ConservationCheck, ownerID *int64, currency string) error {
+	err := DB.QueryRow(ctx, `SELECT COALESCE(SUM(bonus_balance), 0) FROM users
+		WHERE role = 'player' AND currency = $2 AND ($1::BIGINT IS NULL OR invited_by = $1)`, ownerID, currency).Scan(&result.TotalBonusBalance)
+	if err != nil || ownerID == nil {
+		return err
+	}
+
+	sql := `SELECT COALESCE(SUM(bt.amount), 0)
+		FROM balance_transactions bt
+		JOIN users u ON bt.user_id = u.id
+		WHERE bt.tx_type = 'bonus_grant' AND bt.currency = $2
+			AND ((bt.balance_field = 'bonus_balance' AND u.invited_by = $1)
+				OR (bt.balance_field = 'owner_room_balance' AND bt.user_id = $1))`
+	return DB.QueryRow(ctx, sql, *ownerID, currency).Scan(&result.PlatformFundedBonus)
+}
+
+// CheckConservationByOwner 按房主维度检查资金守恒（以房主经营币种计）
 func (r *PlatformRepo) CheckConservationByOwner(ctx context.Context, ownerID int64) (*model.ConservationCheck, error) {
 	result := &model.ConservationCheck{}
 
-	// 1. 获取该房主名下所有玩家余额
+	// 1. 获取房主自身余额信息及经营币种
 	err := DB.QueryRow(ctx, `SELECT 
-		COALESCE(SUM(balance), 0), 
-		COALESCE(SUM(frozen_balance), 0) 
-		FROM users WHERE role = 'player' AND invited_by = $1`, ownerID).Scan(
-		&result.TotalPlayerBalance, &result.TotalPlayerFrozen)
-	if err != nil {
-		return nil, err
-	}
-
-	// 2. 获取房主自身余额信息
-	err = DB.QueryRow(ctx, `SELECT 
+		currency,
 		balance,
 		owner_room_balance,
 		owner_margin_balance,
 		owner_custody_quota
 		FROM users WHERE id = $1`, ownerID).Scan(
+		&result.Currency,
 		&result.TotalOwnerBalance,
 		&result.TotalOwnerCommission,
 		&result.TotalMargin,
@@ -535,26 +850,60 @@ func (r *PlatformRepo) CheckConservationByOwner(ctx context.Context, ownerID int
 		return nil, err
 	}
 
+	// 2. 获取该房主名下所有玩家该币种的余额（活跃余额 + 非活跃币种钱包）
+	err = DB.QueryRow(ctx, `SELECT 
+		COALESCE(SUM(balance), 0), 
+		COALESCE(SUM(frozen_balance), 0) 
+		FROM users WHERE role = 'player' AND invited_by = $1 AND currency = $2`, ownerID, result.Currency).Scan(
+		&result.TotalPlayerBalance, &result.TotalPlayerFrozen)
+	if err != nil {
+		return nil, err
+	}
+	err = DB.QueryRow(ctx, `SELECT COALESCE(SUM(w.balance), 0)
+		FROM user_wallets w JOIN users u ON w.user_id = u.id
+		WHERE u.role = 'player' AND u.invited_by = $1 AND w.currency = $2 AND u.currency <> $2`,
+		ownerID, result.Currency).Scan(&result.TotalWalletBalance)
+	if err != nil {
+		return nil, err
+	}
+	err = DB.QueryRow(ctx, `SELECT COALESCE(SUM(pool_balance), 0) FROM tournaments WHERE owner_id = $1 AND currency = $2`,
+		ownerID, result.Currency).Scan(&result.TotalTournamentPool)
+	if err != nil {
+		return nil, err
+	}
+	err = DB.QueryRow(ctx, `SELECT COALESCE(SUM(balance), 0) FROM jackpots WHERE owner_id = $1 AND currency = $2`,
+		ownerID, result.Currency).Scan(&result.TotalJackpotBalance)
+	if err != nil {
+		return nil, err
+	}
+
 	// 3. 计算该房主体系内的资金总和
-	// 房主体系内资金 = 玩家余额 + 房主可用余额 + 房主佣金
+	// 房主体系内资金 = 玩家余额 + 玩家奖励余额 + 玩家非活跃币种钱包 + 房主可用余额 + 房主佣金 + 房主锦标赛奖池 + 房主累进奖池
+	if err := r.checkBonusBalance(ctx, result, &ownerID, result.Currency); err != nil {
+		return nil, err
+	}
 	result.SystemTotalFunds = result.TotalPlayerBalance.
+		Add(result.TotalBonusBalance).
 		Add(result.TotalPlayerFrozen).
+		Add(result.TotalWalletBalance).
 		Add(result.TotalOwnerBalance).
-		Add(result.TotalOwnerCommission)
+		Add(result.TotalOwnerCommission).
+		Add(result.TotalTournamentPool).
+		Add(result.TotalJackpotBalance)
 
 	// 4. 从交易记录计算该房主的累计充值和提现
 	err = DB.QueryRow(ctx, `SELECT 
 		COALESCE(SUM(CASE WHEN tx_type = 'deposit' AND amount > 0 THEN amount ELSE 0 END), 0),
 		COALESCE(SUM(CASE WHEN tx_type = 'withdraw' AND amount < 0 THEN ABS(amount) ELSE 0 END), 0)
 		FROM balance_transactions
-		WHERE user_id = $1`, ownerID).Scan(&result.TotalOwnerDeposit, &result.TotalOwnerWithdraw)
+		WHERE user_id = $1 AND currency = $2`, ownerID, result.Currency).Scan(&result.TotalOwnerDeposit, &result.TotalOwnerWithdraw)
 	if err != nil {
 		result.TotalOwnerDeposit = decimal.Zero
 		result.TotalOwnerWithdraw = decimal.Zero
 	}
 
-	// 5. 计算预期总额
-	result.ExpectedTotal = result.TotalOwnerDeposit.Sub(result.TotalOwnerWithdraw)
+	// 5. 计算预期总额（平台出资的奖励是从房主体系外流入的资金）
+	result.ExpectedTotal = result.TotalOwnerDeposit.Sub(result.TotalOwnerWithdraw).Add(result.PlatformFundedBonus)
 
 	// 6. 计算差额
 	result.Difference = result.SystemTotalFunds.Sub(result.ExpectedTotal)
@@ -562,12 +911,20 @@ func (r *PlatformRepo) CheckConservationByOwner(ctx context.Context, ownerID int
 	tolerance := decimal.NewFromFloat(0.01)
 	result.IsBalanced = result.Difference.Abs().LessThanOrEqual(tolerance)
 
+	// 7. 名下玩家间转账必须相互抵消
+	if err := r.checkTransferBalance(ctx, result, &ownerID, result.Currency); err != nil {
+		return nil, err
+	}
+	if !result.TransferImbalance.Abs().LessThanOrEqual(tolerance) {
+		result.IsBalanced = false
+	}
+
 	return result, nil
 }
 
-// GetReconciliationReport 获取详细的资金对账报告
-func (r *PlatformRepo) GetReconciliationReport(ctx context.Context) (*model.FundReconciliationReport, error) {
-	report := &model.FundReconciliationReport{}
+// GetReconciliationReport 获取指定币种的详细资金对账报告
+func (r *PlatformRepo) GetReconciliationReport(ctx context.Context, currency string) (*model.FundReconciliationReport, error) {
+	report := &model.FundReconciliationReport{Currency: currency}
 
 	// 1. 获取外部资金注入（从fund_requests表，只有房主才能和外部有资金往来）
 	err := DB.QueryRow(ctx, `SELECT 
@@ -575,7 +932,7 @@ func (r *PlatformRepo) GetReconciliationReport(ctx context.Context) (*model.Fund
 		COALESCE(SUM(CASE WHEN request_type = 'margin_deposit' THEN amount ELSE 0 END), 0) AS margin_deposit,
 		COALESCE(SUM(CASE WHEN request_type = 'owner_withdraw' THEN amount ELSE 0 END), 0) AS owner_withdraw
 		FROM fund_requests 
-		WHERE status = 'approved'`).Scan(
+		WHERE status = 'approved' AND currency = $1`, currency).Scan(
 		&report.ExternalFunds.OwnerDeposit,
 		&report.ExternalFunds.MarginDeposit,
 		&report.ExternalFunds.OwnerWithdraw,
@@ -591,20 +948,26 @@ func (r *PlatformRepo) GetReconciliationReport(ctx context.Context) (*model.Fund
 	err = DB.QueryRow(ctx, `SELECT 
 		COALESCE(SUM(balance), 0), 
 		COALESCE(SUM(frozen_balance), 0) 
-		FROM users WHERE role = 'player'`).Scan(
+		FROM users WHERE role = 'player' AND currency = $1`, currency).Scan(
 		&report.SystemFunds.PlayerBalance,
 		&report.SystemFunds.PlayerFrozen,
 	)
 	if err != nil {
 		return nil, err
 	}
+	err = DB.QueryRow(ctx, `SELECT COALESCE(SUM(w.balance), 0)
+		FROM user_wallets w JOIN users u ON w.user_id = u.id
+		WHERE w.currency = $1 AND u.currency <> $1`, currency).Scan(&report.SystemFunds.PlayerWallet)
+	if err != nil {
+		return nil, err
+	}
 
 	// 3. 获取房主余额
 	err = DB.QueryRow(ctx, `SELECT 
 		COALESCE(SUM(balance), 0),
 		COALESCE(SUM(owner_room_balance), 0),
 		COALESCE(SUM(owner_margin_balance), 0)
-		FROM users WHERE role = 'owner'`).Scan(
+		FROM users WHERE role = 'owner' AND currency = $1`, currency).Scan(
 		&report.SystemFunds.OwnerBalance,
 		&report.SystemFunds.OwnerCommission,
 		&report.SystemFunds.OwnerMargin,
@@ -614,20 +977,35 @@ func (r *PlatformRepo) GetReconciliationReport(ctx context.Context) (*model.Fund
 	}
 
 	// 4. 获取平台余额
-	err = DB.QueryRow(ctx, `SELECT COALESCE(platform_balance, 0) FROM platform_account WHERE id = 1`).Scan(
+	err = DB.QueryRow(ctx, `SELECT COALESCE(SUM(platform_balance), 0) FROM platform_account WHERE currency = $1`, currency).Scan(
 		&report.SystemFunds.PlatformBalance,
 	)
 	if err != nil {
 		report.SystemFunds.PlatformBalance = decimal.Zero
 	}
+	err = DB.QueryRow(ctx, `SELECT COALESCE(SUM(pool_balance), 0) FROM tournaments WHERE currency = $1`, currency).Scan(
+		&report.SystemFunds.TournamentPool,
+	)
+	if err != nil {
+		return nil, err
+	}
+	err = DB.QueryRow(ctx, `SELECT COALESCE(SUM(balance), 0) FROM jackpots WHERE currency = $1`, currency).Scan(
+		&report.SystemFunds.JackpotBalance,
+	)
+	if err != nil {
+		return nil, err
+	}
 
 	// 5. 计算系统内资金总和
 	report.SystemFunds.Total = report.SystemFunds.PlayerBalance.
 		Add(report.SystemFunds.PlayerFrozen).
+		Add(report.SystemFunds.PlayerWallet).
 		Add(report.SystemFunds.OwnerBalance).
 		Add(report.SystemFunds.OwnerCommission).
 		Add(report.SystemFunds.OwnerMargin).
-		Add(report.SystemFunds.PlatformBalance)
+		Add(report.SystemFunds.PlatformBalance).
+		Add(report.SystemFunds.TournamentPool).
+		Add(report.SystemFunds.JackpotBalance)
 
 	// 6. 对账结果
 	report.Reconciliation.ExpectedTotal = report.ExternalFunds.NetInflow
@@ -794,7 +1172,8 @@ func (r *GameRepo) GetRoundDetail(ctx context.Context, roundID int64) (*model.Ro
 	sql := `SELECT gr.id, gr.room_id, COALESCE(rm.name, 'Room ' || rm.code) as room_name,
 		gr.round_number, gr.bet_amount, gr.pool_amount, COALESCE(gr.prize_per_winner, 0),
 		COALESCE(gr.commit_hash, ''), COALESCE(gr.reveal_seed, ''), gr.status,
-		gr.participant_ids, gr.winner_ids, gr.created_at, gr.settled_at
+		gr.participant_ids, gr.winner_ids, gr.created_at, gr.settled_at,
+		gr.jackpot_odds, gr.jackpot_hit, gr.jackpot_payout
 		FROM game_rounds gr
 		JOIN rooms rm ON gr.room_id = rm.id
 		WHERE gr.id = $1`
@@ -807,6 +1186,7 @@ func (r *GameRepo) GetRoundDetail(ctx context.Context, roundID int64) (*model.Ro
 		&detail.BetAmount, &detail.PoolAmount, &detail.PrizePerWinner,
 		&detail.CommitHash, &detail.RevealSeed, &detail.Status,
 		&participantIDs, &winnerIDs, &detail.CreatedAt, &detail.SettledAt,
+		&detail.JackpotOdds, &detail.JackpotHit, &detail.JackpotPayout,
 	)
 	if errors.Is(err, pgx.ErrNoRows) {
 		return nil, ErrNotFound
@@ -0,0 +1,186 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/fiveseconds/server/internal/model"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/shopspring/decimal"
+)
+
+// JackpotRepo 累进奖池仓库
+// 奖池余额的注入与派发在回合结算事务内完成，派发给赢家的金额由结算流程记入玩家余额与交易流水
+type JackpotRepo struct{}
+
+// NewJackpotRepo 创建累进奖池仓库
+func NewJackpotRepo() *JackpotRepo {
+	return &JackpotRepo{}
+}
+
+const jackpotColumns = `id, owner_id, room_id, currency, contribution_rate, trigger_odds, balance,
+	total_contributed, total_paid, hit_count, enabled, last_hit_at, created_at, updated_at`
+
+func scanJackpot(row pgx.Row) (*model.Jackpot, error) {
+	j := &model.Jackpot{}
+	err := row.Scan(
+		&j.ID, &j.OwnerID, &j.RoomID, &j.Currency, &j.ContributionRate, &j.TriggerOdds, &j.Balance,
+		&j.TotalContributed, &j.TotalPaid, &j.HitCount, &j.Enabled, &j.LastHitAt, &j.CreatedAt, &j.UpdatedAt,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return j, nil
+}
+
+// Create 创建累进奖池
+func (r *JackpotRepo) Create(ctx context.Context, j *model.Jackpot) error {
+	sql := `INSERT INTO jackpots (owner_id, room_id, currency, contribution_rate, trigger_odds, enabled)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, balance, total_contributed, total_paid, hit_count, created_at, updated_at`
+	return DB.QueryRow(ctx, sql,
+		j.OwnerID, j.RoomID, j.Currency, j.ContributionRate, j.TriggerOdds, j.Enabled,
+	).Scan(&j.ID, &j.Balance, &j.TotalContributed, &j.TotalPaid, &j.HitCount, &j.CreatedAt, &j.UpdatedAt)
+}
+
+// UpdateSettings 修改抽取比例、触发概率与启用状态（余额不变）
+func (r *JackpotRepo) UpdateSettings(ctx context.Context, j *model.Jackpot) error {
+	sql := `UPDATE jackpots SET contribution_rate = $1, trigger_odds = $2, enabled = $3, updated_at = NOW()
+		WHERE id = $4
+		RETURNING updated_at`
+	err := DB.QueryRow(ctx, sql, j.ContributionRate, j.TriggerOdds, j.Enabled, j.ID).Scan(&j.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrNotFound
+	}
+	return err
+}
+
+// GetByID 根据ID获取累进奖池
+func (r *JackpotRepo) GetByID(ctx context.Context, id int64) (*model.Jackpot, error) {
+	return scanJackpot(DB.QueryRow(ctx, `SELECT `+jackpotColumns+` FROM jackpots WHERE id = $1`, id))
+}
+
+// GetByRoom 获取房间级累进奖池
+func (r *JackpotRepo) GetByRoom(ctx context.Context, roomID int64) (*model.Jackpot, error) {
+	return scanJackpot(DB.QueryRow(ctx, `SELECT `+jackpotColumns+` FROM jackpots WHERE room_id = $1`, roomID))
+}
+
+// GetByOwner 获取房主该币种的房主级累进奖池
+func (r *JackpotRepo) GetByOwner(ctx context.Context, ownerID int64, currency string) (*model.Jackpot, error) {
+	return scanJackpot(DB.QueryRow(ctx, `SELECT `+jackpotColumns+`
+		FROM jackpots WHERE owner_id = $1 AND currency = $2 AND room_id IS NULL`, ownerID, currency))
+}
+
+// FindActiveForRoom 获取房间生效的累进奖池：优先房间级，其次房主同币种的房主级奖池（均须已启用）
+func (r *JackpotRepo) FindActiveForRoom(ctx context.Context, roomID, ownerID int64, currency string) (*model.Jackpot, error) {
+	sql := `SELECT ` + jackpotColumns + ` FROM jackpots
+		WHERE enabled AND (room_id = $1 OR (room_id IS NULL AND owner_id = $2 AND currency = $3))
+		ORDER BY room_id IS NULL
+		LIMIT 1`
+	return scanJackpot(DB.QueryRow(ctx, sql, roomID, ownerID, currency))
+}
+
+// GetForUpdateTx 锁定累进奖池（串行化共享奖池的多个房间同时结算）
+func (r *JackpotRepo) GetForUpdateTx(ctx context.Context, tx pgx.Tx, id int64) (*model.Jackpot, error) {
+	return scanJackpot(tx.QueryRow(ctx, `SELECT `+jackpotColumns+` FROM jackpots WHERE id = $1 FOR UPDATE`, id))
+}
+
+// SettleTx 注入本回合抽取金额并扣除派发金额，返回新的奖池余额（支持事务）
+func (r *JackpotRepo) SettleTx(ctx context.Context, tx pgx.Tx, id int64, contributed, paid decimal.Decimal, hit bool) (decimal.Decimal, error) {
+	sql := `UPDATE jackpots SET
+		balance = balance + $1 - $2,
+		total_contributed = total_contributed + $1,
+		total_paid = total_paid + $2,
+		hit_count = hit_count + CASE WHEN $3 THEN 1 ELSE 0 END,
+		last_hit_at = CASE WHEN $3 THEN NOW() ELSE last_hit_at END,
+		updated_at = NOW()
+		WHERE id = $4
+		RETURNING balance`
+	var balance decimal.Decimal
+	err := GetExecutor(tx).QueryRow(ctx, sql, contributed, paid, hit, id).Scan(&balance)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return decimal.Zero, ErrNotFound
+	}
+	return balance, err
+}
+
+// CreateHitTx 记录累进奖池中奖（支持事务）
+func (r *JackpotRepo) CreateHitTx(ctx context.Context, tx pgx.Tx, hit *model.JackpotHit) error {
+	sql := `INSERT INTO jackpot_hits (jackpot_id, room_id, round_id, winner_ids, prize_per_winner, amount)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at`
+	return GetExecutor(tx).QueryRow(ctx, sql,
+		hit.JackpotID, hit.RoomID, hit.RoundID, hit.WinnerIDs, hit.PrizePerWinner, hit.Amount,
+	).Scan(&hit.ID, &hit.CreatedAt)
+}
+
+// List 分页获取累进奖池列表
+func (r *JackpotRepo) List(ctx context.Context, query *model.JackpotListQuery) ([]*model.Jackpot, int64, error) {
+	countSQL := `SELECT COUNT(*) FROM jackpots WHERE 1=1`
+	listSQL := `SELECT ` + jackpotColumns + ` FROM jackpots WHERE 1=1`
+
+	args := []interface{}{}
+	argIdx := 1
+
+	if query.OwnerID != nil {
+		countSQL += fmt.Sprintf(` AND owner_id = $%d`, argIdx)
+		listSQL += fmt.Sprintf(` AND owner_id = $%d`, argIdx)
+		args = append(args, *query.OwnerID)
+		argIdx++
+	}
+
+	var total int64
+	if err := DB.QueryRow(ctx, countSQL, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	listSQL += fmt.Sprintf(` ORDER BY id DESC LIMIT $%d OFFSET $%d`, argIdx, argIdx+1)
+	args = append(args, query.PageSize, (query.Page-1)*query.PageSize)
+
+	rows, err := DB.Query(ctx, listSQL, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var jackpots []*model.Jackpot
+	for rows.Next() {
+		j, err := scanJackpot(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		jackpots = append(jackpots, j)
+	}
+	return jackpots, total, rows.Err()
+}
+
+// ListHits 分页获取累进奖池中奖记录
+func (r *JackpotRepo) ListHits(ctx context.Context, jackpotID int64, page, pageSize int) ([]*model.JackpotHit, int64, error) {
+	var total int64
+	if err := DB.QueryRow(ctx, `SELECT COUNT(*) FROM jackpot_hits WHERE jackpot_id = $1`, jackpotID).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := DB.Query(ctx, `SELECT id, jackpot_id, room_id, round_id, winner_ids, prize_per_winner, amount, created_at
+		FROM jackpot_hits WHERE jackpot_id = $1
+		ORDER BY created_at DESC, id DESC LIMIT $2 OFFSET $3`, jackpotID, pageSize, (page-1)*pageSize)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	hits := []*model.JackpotHit{}
+	for rows.Next() {
+		h := &model.JackpotHit{}
+		if err := rows.Scan(&h.ID, &h.JackpotID, &h.RoomID, &h.RoundID, &h.WinnerIDs, &h.PrizePerWinner, &h.Amount, &h.CreatedAt); err != nil {
+			return nil, 0, err
+		}
+		hits = append(hits, h)
+	}
+	return hits, total, rows.Err()
+}
@@ -0,0 +1,170 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/fiveseconds/server/internal/model"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// LoginEventRepo 登录事件仓库
+type LoginEventRepo struct{}
+
+// NewLoginEventRepo 创建登录事件仓库
+func NewLoginEventRepo() *LoginEventRepo {
+	return &LoginEventRepo{}
+}
+
+const loginEventColumns = `id, user_id, username, success, failure_reason, ip, user_agent, device_fingerprint,
+	device_key, new_device, geo_label, created_at`
+
+func scanLoginEvent(row pgx.Row) (*model.LoginEvent, error) {
+	e := &model.LoginEvent{}
+	err := row.Scan(
+		&e.ID, &e.UserID, &e.Username, &e.Success, &e.FailureReason, &e.IP, &e.UserAgent, &e.DeviceFingerprint,
+		&e.DeviceKey, &e.NewDevice, &e.GeoLabel, &e.CreatedAt,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// Create 记录登录事件
+// 登录成功时在同一语句内判断该用户此前是否以同一设备登录成功过，写入 new_device
+func (r *LoginEventRepo) Create(ctx context.Context, e *model.LoginEvent) error {
+	sql := `INSERT INTO login_events
+		(user_id, username, success, failure_reason, ip, user_agent, device_fingerprint, device_key, new_device, geo_label)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8,
+			$3 AND NOT EXISTS (SELECT 1 FROM login_events
+				WHERE user_id = $1 AND device_key = $8 AND success),
+			$9)
+		RETURNING id, new_device, created_at`
+	return DB.QueryRow(ctx, sql,
+		e.UserID, e.Username, e.Success, e.FailureReason, e.IP, e.UserAgent, e.DeviceFingerprint, e.DeviceKey, e.GeoLabel,
+	).Scan(&e.ID, &e.NewDevice, &e.CreatedAt)
+}
+
+// ListByUser 分页获取用户的登录历史（新的在前）
+func (r *LoginEventRepo) ListByUser(ctx context.Context, userID int64, query *model.LoginEventListQuery) ([]*model.LoginEvent, int64, error) {
+	countSQL := `SELECT COUNT(*) FROM login_events WHERE user_id = $1`
+	listSQL := `SELECT ` + loginEventColumns + ` FROM login_events WHERE user_id = $1`
+
+	args := []interface{}{userID}
+	argIdx := 2
+
+	if query.Success != nil {
+		countSQL += fmt.Sprintf(` AND success = $%d`, argIdx)
+		listSQL += fmt.Sprintf(` AND success = $%d`, argIdx)
+		args = append(args, *query.Success)
+		argIdx++
+	}
+
+	var total int64
+	if err := DB.QueryRow(ctx, countSQL, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	listSQL += fmt.Sprintf(` ORDER BY created_at DESC, id DESC LIMIT $%d OFFSET $%d`, argIdx, argIdx+1)
+	args = append(args, query.PageSize, (query.Page-1)*query.PageSize)
+
+	rows, err := DB.Query(ctx, listSQL, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	events := []*model.LoginEvent{}
+	for rows.Next() {
+		e, err := scanLoginEvent(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		events = append(events, e)
+	}
+	return events, total, rows.Err()
+}
+
+// ListKnownDevices 获取用户登录成功过的设备（最近使用的在前）
+func (r *LoginEventRepo) ListKnownDevices(ctx context.Context, userID int64) ([]*model.KnownDevice, error) {
+	sql := `SELECT DISTINCT ON (device_key)
+			device_key, device_fingerprint, user_agent, ip, geo_label,
+			COUNT(*) OVER (PARTITION BY device_key),
+			MIN(created_at) OVER (PARTITION BY device_key),
+			created_at
+		FROM login_events
+		WHERE user_id = $1 AND success
+		ORDER BY device_key, created_at DESC, id DESC`
+	rows, err := DB.Query(ctx, `SELECT * FROM (`+sql+`) d ORDER BY created_at DESC`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	devices := []*model.KnownDevice{}
+	for rows.Next() {
+		d := &model.KnownDevice{}
+		if err := rows.Scan(&d.DeviceKey, &d.DeviceFingerprint, &d.UserAgent, &d.LastIP, &d.LastGeoLabel,
+			&d.LoginCount, &d.FirstSeenAt, &d.LastSeenAt); err != nil {
+			return nil, err
+		}
+		devices = append(devices, d)
+	}
+	return devices, rows.Err()
+}
+
+// GetLastSuccess 获取用户在 before 之前最近一次登录成功的事件
+func (r *LoginEventRepo) GetLastSuccess(ctx context.Context, userID int64, before time.Time) (*model.LoginEvent, error) {
+	return scanLoginEvent(DB.QueryRow(ctx, `SELECT `+loginEventColumns+`
+		FROM login_events
+		WHERE user_id = $1 AND success AND created_at <= $2
+		ORDER BY created_at DESC, id DESC
+		LIMIT 1`, userID, before))
+}
+
+// ForEachSuccessSince 按时间顺序遍历窗口内登录成功的事件，附登录时刻的用户统计（试运行重放）
+func (r *LoginEventRepo) ForEachSuccessSince(ctx context.Context, since, until time.Time, winRateWindow int, fn func(*model.RiskReplayLogin) error) error {
+	sql := `SELECT le.id, le.user_id, le.created_at, le.device_fingerprint, le.new_device,
+			CASE WHEN le.device_fingerprint = '' THEN 0 ELSE (
+				SELECT COUNT(DISTINCT o.user_id) FROM login_events o
+				WHERE o.success AND o.device_fingerprint = le.device_fingerprint AND o.created_at <= le.created_at
+			) END,
+			recent.total, recent.wins, u.created_at
+		FROM login_events le
+		JOIN users u ON u.id = le.user_id
+		CROSS JOIN LATERAL (
+			SELECT COUNT(*) AS total, COUNT(*) FILTER (WHERE le.user_id = ANY(gr.winner_ids)) AS wins
+			FROM (
+				SELECT winner_ids FROM game_rounds
+				WHERE le.user_id = ANY(participant_ids) AND status = 'settled' AND created_at < le.created_at
+				ORDER BY created_at DESC
+				LIMIT $3
+			) gr
+		) recent
+		WHERE le.success AND le.created_at >= $1 AND le.created_at < $2
+		ORDER BY le.created_at, le.id`
+	rows, err := DB.Query(ctx, sql, since, until, winRateWindow)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		l := &model.RiskReplayLogin{}
+		if err := rows.Scan(&l.ID, &l.UserID, &l.CreatedAt, &l.DeviceFingerprint, &l.NewDevice,
+			&l.DeviceAccounts, &l.RecentRounds, &l.RecentWins, &l.AccountCreatedAt); err != nil {
+			return err
+		}
+		if err := fn(l); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
@@ -112,9 +112,9 @@ func (r *MetricsRepo) GetDailyVolume(ctx context.Context) (decimal.Decimal, erro
 }
 
 // GetPlatformRevenue 获取平台收入（今日）
-// 从 game_rounds 表汇总已结算回合的平台抽成
+// 从 game_rounds 表汇总已结算回合的平台抽成（开启累进奖池的回合正残值注入奖池，不计入平台收入）
 func (r *MetricsRepo) GetPlatformRevenue(ctx context.Context) (decimal.Decimal, error) {
-	sql := `SELECT COALESCE(SUM(platform_earning), 0) + COALESCE(SUM(residual_amount), 0)
+	sql := `SELECT COALESCE(SUM(platform_earning), 0) + COALESCE(SUM(CASE WHEN jackpot_id IS NULL OR residual_amount < 0 THEN residual_amount ELSE 0 END), 0)
 		FROM game_rounds 
 		WHERE status = 'settled' 
 		AND settled_at > NOW() - INTERVAL '24 hours'`
@@ -0,0 +1,381 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/fiveseconds/server/internal/model"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// NotificationRepo 告警通知渠道、路由与投递记录仓库
+type NotificationRepo struct{}
+
+// NewNotificationRepo 创建告警通知仓库
+func NewNotificationRepo() *NotificationRepo {
+	return &NotificationRepo{}
+}
+
+const notificationChannelColumns = `id, name, channel_type, config, enabled, created_by, created_at, updated_at`
+
+const notificationRouteColumns = `id, channel_id, alert_types, min_severity, enabled, created_at, updated_at`
+
+const alertDeliveryColumns = `d.id, d.alert_id, d.channel_id, c.name, c.channel_type, d.status, d.attempts, d.last_error,
+	d.next_attempt_at, d.delivered_at, d.created_at, d.updated_at`
+
+// scanNotificationChannel 扫描通知渠道
+func scanNotificationChannel(row pgx.Row) (*model.NotificationChannel, error) {
+	ch := &model.NotificationChannel{}
+	var config []byte
+	err := row.Scan(&ch.ID, &ch.Name, &ch.Type, &config, &ch.Enabled, &ch.CreatedBy, &ch.CreatedAt, &ch.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(config, &ch.Config); err != nil {
+		return nil, err
+	}
+	return ch, nil
+}
+
+// scanNotificationRoute 扫描通知路由
+func scanNotificationRoute(row pgx.Row) (*model.NotificationRoute, error) {
+	rt := &model.NotificationRoute{}
+	var alertTypes []string
+	err := row.Scan(&rt.ID, &rt.ChannelID, &alertTypes, &rt.MinSeverity, &rt.Enabled, &rt.CreatedAt, &rt.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	rt.AlertTypes = make([]model.AlertType, 0, len(alertTypes))
+	for _, t := range alertTypes {
+		rt.AlertTypes = append(rt.AlertTypes, model.AlertType(t))
+	}
+	return rt, nil
+}
+
+// scanAlertDelivery 扫描投递记录（含渠道名称与类型）
+func scanAlertDelivery(row pgx.Row) (*model.AlertDelivery, error) {
+	d := &model.AlertDelivery{}
+	err := row.Scan(&d.ID, &d.AlertID, &d.ChannelID, &d.ChannelName, &d.ChannelType, &d.Status, &d.Attempts, &d.LastError,
+		&d.NextAttemptAt, &d.DeliveredAt, &d.CreatedAt, &d.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// alertTypeStrings 告警类型转为字符串数组（写入 VARCHAR[]）
+func alertTypeStrings(types []model.AlertType) []string {
+	out := make([]string, 0, len(types))
+	for _, t := range types {
+		out = append(out, string(t))
+	}
+	return out
+}
+
+// ChannelNameExists 渠道名称是否已被其他渠道使用（excludeID 为 0 表示不排除）
+func (r *NotificationRepo) ChannelNameExists(ctx context.Context, name string, excludeID int64) (bool, error) {
+	var exists bool
+	err := DB.QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM notification_channels WHERE name = $1 AND id <> $2)`,
+		name, excludeID).Scan(&exists)
+	return exists, err
+}
+
+// CreateChannel 创建通知渠道
+func (r *NotificationRepo) CreateChannel(ctx context.Context, ch *model.NotificationChannel) error {
+	config, err := json.Marshal(ch.Config)
+	if err != nil {
+		return err
+	}
+	return DB.QueryRow(ctx, `INSERT INTO notification_channels (name, channel_type, config, enabled, created_by)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at, updated_at`,
+		ch.Name, ch.Type, config, ch.Enabled, ch.CreatedBy,
+	).Scan(&ch.ID, &ch.CreatedAt, &ch.UpdatedAt)
+}
+
+// GetChannel 获取通知渠道
+func (r *NotificationRepo) GetChannel(ctx context.Context, id int64) (*model.NotificationChannel, error) {
+	ch, err := scanNotificationChannel(DB.QueryRow(ctx,
+		`SELECT `+notificationChannelColumns+` FROM notification_channels WHERE id = $1`, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return ch, err
+}
+
+// ListChannels 获取全部通知渠道
+func (r *NotificationRepo) ListChannels(ctx context.Context) ([]*model.NotificationChannel, error) {
+	rows, err := DB.Query(ctx, `SELECT `+notificationChannelColumns+` FROM notification_channels ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var channels []*model.NotificationChannel
+	for rows.Next() {
+		ch, err := scanNotificationChannel(rows)
+		if err != nil {
+			return nil, err
+		}
+		channels = append(channels, ch)
+	}
+	return channels, rows.Err()
+}
+
+// UpdateChannel 更新通知渠道的名称、配置与启用状态
+func (r *NotificationRepo) UpdateChannel(ctx context.Context, ch *model.NotificationChannel) error {
+	config, err := json.Marshal(ch.Config)
+	if err != nil {
+		return err
+	}
+	err = DB.QueryRow(ctx, `UPDATE notification_channels
+		SET name = $1, config = $2, enabled = $3, updated_at = NOW()
+		WHERE id = $4
+		RETURNING updated_at`,
+		ch.Name, config, ch.Enabled, ch.ID,
+	).Scan(&ch.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrNotFound
+	}
+	return err
+}
+
+// CreateRoute 创建通知路由
+func (r *NotificationRepo) CreateRoute(ctx context.Context, rt *model.NotificationRoute) error {
+	return DB.QueryRow(ctx, `INSERT INTO notification_routes (channel_id, alert_types, min_severity, enabled)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at, updated_at`,
+		rt.ChannelID, alertTypeStrings(rt.AlertTypes), rt.MinSeverity, rt.Enabled,
+	).Scan(&rt.ID, &rt.CreatedAt, &rt.UpdatedAt)
+}
+
+// GetRoute 获取通知路由
+func (r *NotificationRepo) GetRoute(ctx context.Context, id int64) (*model.NotificationRoute, error) {
+	rt, err := scanNotificationRoute(DB.QueryRow(ctx,
+		`SELECT `+notificationRouteColumns+` FROM notification_routes WHERE id = $1`, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return rt, err
+}
+
+// ListRoutes 获取通知路由（channelID 为 0 表示全部渠道）
+func (r *NotificationRepo) ListRoutes(ctx context.Context, channelID int64) ([]*model.NotificationRoute, error) {
+	sql := `SELECT ` + notificationRouteColumns + ` FROM notification_routes`
+	args := []interface{}{}
+	if channelID > 0 {
+		sql += ` WHERE channel_id = $1`
+		args = append(args, channelID)
+	}
+	sql += ` ORDER BY id`
+
+	rows, err := DB.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var routes []*model.NotificationRoute
+	for rows.Next() {
+		rt, err := scanNotificationRoute(rows)
+		if err != nil {
+			return nil, err
+		}
+		routes = append(routes, rt)
+	}
+	return routes, rows.Err()
+}
+
+// UpdateRoute 更新通知路由的告警类型、最低严重程度与启用状态
+func (r *NotificationRepo) UpdateRoute(ctx context.Context, rt *model.NotificationRoute) error {
+	err := DB.QueryRow(ctx, `UPDATE notification_routes
+		SET alert_types = $1, min_severity = $2, enabled = $3, updated_at = NOW()
+		WHERE id = $4
+		RETURNING updated_at`,
+		alertTypeStrings(rt.AlertTypes), rt.MinSeverity, rt.Enabled, rt.ID,
+	).Scan(&rt.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrNotFound
+	}
+	return err
+}
+
+// DeleteRoute 删除通知路由
+func (r *NotificationRepo) DeleteRoute(ctx context.Context, id int64) error {
+	tag, err := DB.Exec(ctx, `DELETE FROM notification_routes WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// ListActiveRoutes 获取已启用渠道上的已启用路由
+func (r *NotificationRepo) ListActiveRoutes(ctx context.Context) ([]*model.NotificationRoute, error) {
+	rows, err := DB.Query(ctx, `SELECT r.id, r.channel_id, r.alert_types, r.min_severity, r.enabled, r.created_at, r.updated_at
+		FROM notification_routes r
+		JOIN notification_channels c ON c.id = r.channel_id
+		WHERE r.enabled AND c.enabled
+		ORDER BY r.id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var routes []*model.NotificationRoute
+	for rows.Next() {
+		rt, err := scanNotificationRoute(rows)
+		if err != nil {
+			return nil, err
+		}
+		routes = append(routes, rt)
+	}
+	return routes, rows.Err()
+}
+
+// EnqueueDeliveries 为告警登记各渠道的待投递记录（同一告警同一渠道只登记一次），返回新登记的记录数
+func (r *NotificationRepo) EnqueueDeliveries(ctx context.Context, alertID int64, channelIDs []int64) (int64, error) {
+	if len(channelIDs) == 0 {
+		return 0, nil
+	}
+	tag, err := DB.Exec(ctx, `INSERT INTO alert_deliveries (alert_id, channel_id)
+		SELECT $1, unnest($2::BIGINT[])
+		ON CONFLICT (alert_id, channel_id) DO NOTHING`,
+		alertID, channelIDs)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+// ClaimDueDeliveries 领取到期的待投递记录，并将其下次发送时间顺延 lease，避免多个实例重复发送
+func (r *NotificationRepo) ClaimDueDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*model.AlertDelivery, error) {
+	rows, err := DB.Query(ctx, `WITH due AS (
+			SELECT id FROM alert_deliveries
+			WHERE status = 'pending' AND next_attempt_at <= $1
+			ORDER BY next_attempt_at
+			LIMIT $3
+			FOR UPDATE SKIP LOCKED
+		)
+		UPDATE alert_deliveries d SET next_attempt_at = $2, updated_at = NOW()
+		FROM due, notification_channels c
+		WHERE d.id = due.id AND c.id = d.channel_id
+		RETURNING `+alertDeliveryColumns,
+		now, now.Add(lease), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deliveries []*model.AlertDelivery
+	for rows.Next() {
+		d, err := scanAlertDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, d)
+	}
+	return deliveries, rows.Err()
+}
+
+// MarkDeliverySent 标记投递成功
+func (r *NotificationRepo) MarkDeliverySent(ctx context.Context, id int64, attempts int) error {
+	_, err := DB.Exec(ctx, `UPDATE alert_deliveries
+		SET status = 'sent', attempts = $1, last_error = NULL, delivered_at = NOW(), updated_at = NOW()
+		WHERE id = $2`, attempts, id)
+	return err
+}
+
+// MarkDeliveryRetry 记录发送失败并安排下次重试
+func (r *NotificationRepo) MarkDeliveryRetry(ctx context.Context, id int64, attempts int, lastError string, next time.Time) error {
+	_, err := DB.Exec(ctx, `UPDATE alert_deliveries
+		SET attempts = $1, last_error = $2, next_attempt_at = $3, updated_at = NOW()
+		WHERE id = $4`, attempts, lastError, next, id)
+	return err
+}
+
+// MarkDeliveryFailed 标记投递失败（不再自动重试）
+func (r *NotificationRepo) MarkDeliveryFailed(ctx context.Context, id int64, attempts int, lastError string) error {
+	_, err := DB.Exec(ctx, `UPDATE alert_deliveries
+		SET status = 'failed', attempts = $1, last_error = $2, updated_at = NOW()
+		WHERE id = $3`, attempts, lastError, id)
+	return err
+}
+
+// RequeueDelivery 将失败的投递重新置为待发送（重新计算发送次数），返回是否有记录被更新
+func (r *NotificationRepo) RequeueDelivery(ctx context.Context, id int64) (bool, error) {
+	tag, err := DB.Exec(ctx, `UPDATE alert_deliveries
+		SET status = 'pending', attempts = 0, next_attempt_at = NOW(), updated_at = NOW()
+		WHERE id = $1 AND status = 'failed'`, id)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// GetDelivery 获取投递记录
+func (r *NotificationRepo) GetDelivery(ctx context.Context, id int64) (*model.AlertDelivery, error) {
+	d, err := scanAlertDelivery(DB.QueryRow(ctx, `SELECT `+alertDeliveryColumns+`
+		FROM alert_deliveries d JOIN notification_channels c ON c.id = d.channel_id
+		WHERE d.id = $1`, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return d, err
+}
+
+// ListDeliveries 查询投递记录
+func (r *NotificationRepo) ListDeliveries(ctx context.Context, query *model.AlertDeliveryQuery) ([]*model.AlertDelivery, int64, error) {
+	where := ` WHERE 1=1`
+	args := []interface{}{}
+	argIdx := 1
+
+	if query.AlertID != nil {
+		where += fmt.Sprintf(` AND d.alert_id = $%d`, argIdx)
+		args = append(args, *query.AlertID)
+		argIdx++
+	}
+	if query.ChannelID != nil {
+		where += fmt.Sprintf(` AND d.channel_id = $%d`, argIdx)
+		args = append(args, *query.ChannelID)
+		argIdx++
+	}
+	if query.Status != nil {
+		where += fmt.Sprintf(` AND d.status = $%d`, argIdx)
+		args = append(args, *query.Status)
+		argIdx++
+	}
+
+	from := ` FROM alert_deliveries d JOIN notification_channels c ON c.id = d.channel_id`
+
+	var total int64
+	if err := DB.QueryRow(ctx, `SELECT COUNT(*)`+from+where, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	listSQL := `SELECT ` + alertDeliveryColumns + from + where +
+		fmt.Sprintf(` ORDER BY d.created_at DESC, d.id DESC LIMIT $%d OFFSET $%d`, argIdx, argIdx+1)
+	args = append(args, query.PageSize, (query.Page-1)*query.PageSize)
+
+	rows, err := DB.Query(ctx, listSQL, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var deliveries []*model.AlertDelivery
+	for rows.Next() {
+		d, err := scanAlertDelivery(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		deliveries = append(deliveries, d)
+	}
+	return deliveries, total, rows.Err()
+}
@@ -0,0 +1,147 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/fiveseconds/server/internal/model"
+)
+
+// OwnerRiskRepo 房主维度风控仓库
+type OwnerRiskRepo struct{}
+
+// NewOwnerRiskRepo 创建房主维度风控仓库
+func NewOwnerRiskRepo() *OwnerRiskRepo {
+	return &OwnerRiskRepo{}
+}
+
+const ownerRiskDayColumns = `owner_id, day, currency, room_volume, commission, margin_balance, conservation_difference,
+	player_rounds, player_wins, expected_wins, win_variance, created_at`
+
+// CollectDay 按房主汇总 [dayStart, dayEnd) 内已结算回合的房间流水、抽成收入与玩家获胜统计
+// 每回合每名参与者的获胜概率 p = 赢家数 / 参与人数
+func (r *OwnerRiskRepo) CollectDay(ctx context.Context, dayStart, dayEnd time.Time) (map[int64]*model.OwnerRiskDay, error) {
+	days := make(map[int64]*model.OwnerRiskDay)
+	get := func(ownerID int64) *model.OwnerRiskDay {
+		d, ok := days[ownerID]
+		if !ok {
+			d = &model.OwnerRiskDay{OwnerID: ownerID}
+			days[ownerID] = d
+		}
+		return d
+	}
+
+	rows, err := DB.Query(ctx, `SELECT r.owner_id, COALESCE(SUM(gr.pool_amount), 0), COALESCE(SUM(gr.owner_earning), 0)
+		FROM game_rounds gr
+		JOIN rooms r ON gr.room_id = r.id
+		WHERE gr.status = 'settled' AND gr.created_at >= $1 AND gr.created_at < $2
+		GROUP BY r.owner_id`, dayStart, dayEnd)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var ownerID int64
+		var d model.OwnerRiskDay
+		if err := rows.Scan(&ownerID, &d.RoomVolume, &d.Commission); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		day := get(ownerID)
+		day.RoomVolume, day.Commission = d.RoomVolume, d.Commission
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = DB.Query(ctx, `SELECT r.owner_id, COUNT(*),
+			COUNT(*) FILTER (WHERE p.user_id = ANY(gr.winner_ids)),
+			COALESCE(SUM(cardinality(gr.winner_ids)::float8 / cardinality(gr.participant_ids)), 0),
+			COALESCE(SUM((cardinality(gr.winner_ids)::float8 / cardinality(gr.participant_ids))
+				* (1 - cardinality(gr.winner_ids)::float8 / cardinality(gr.participant_ids))), 0)
+		FROM game_rounds gr
+		JOIN rooms r ON gr.room_id = r.id
+		CROSS JOIN LATERAL unnest(gr.participant_ids) AS p(user_id)
+		WHERE gr.status = 'settled' AND gr.created_at >= $1 AND gr.created_at < $2
+		GROUP BY r.owner_id`, dayStart, dayEnd)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var ownerID int64
+		var d model.OwnerRiskDay
+		if err := rows.Scan(&ownerID, &d.PlayerRounds, &d.PlayerWins, &d.ExpectedWins, &d.WinVariance); err != nil {
+			return nil, err
+		}
+		day := get(ownerID)
+		day.PlayerRounds, day.PlayerWins, day.ExpectedWins, day.WinVariance = d.PlayerRounds, d.PlayerWins, d.ExpectedWins, d.WinVariance
+	}
+	return days, rows.Err()
+}
+
+// Insert 记录房主当日指标，当日已记录时返回 false（每日对账可能被多个任务触发）
+func (r *OwnerRiskRepo) Insert(ctx context.Context, d *model.OwnerRiskDay) (bool, error) {
+	tag, err := DB.Exec(ctx, `INSERT INTO owner_risk_daily (owner_id, day, currency, room_volume, commission, margin_balance,
+			conservation_difference, player_rounds, player_wins, expected_wins, win_variance)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		ON CONFLICT (owner_id, day) DO NOTHING`,
+		d.OwnerID, d.Day, d.Currency, d.RoomVolume, d.Commission, d.MarginBalance,
+		d.ConservationDifference, d.PlayerRounds, d.PlayerWins, d.ExpectedWins, d.WinVariance)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// ListHistory 获取房主 [since, before) 内的每日指标（按日期倒序）
+func (r *OwnerRiskRepo) ListHistory(ctx context.Context, ownerID int64, since, before time.Time) ([]*model.OwnerRiskDay, error) {
+	rows, err := DB.Query(ctx, `SELECT `+ownerRiskDayColumns+`
+		FROM owner_risk_daily
+		WHERE owner_id = $1 AND day >= $2 AND day < $3
+		ORDER BY day DESC`, ownerID, since, before)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var days []*model.OwnerRiskDay
+	for rows.Next() {
+		d := &model.OwnerRiskDay{}
+		if err := rows.Scan(&d.OwnerID, &d.Day, &d.Currency, &d.RoomVolume, &d.Commission, &d.MarginBalance,
+			&d.ConservationDifference, &d.PlayerRounds, &d.PlayerWins, &d.ExpectedWins, &d.WinVariance, &d.CreatedAt); err != nil {
+			return nil, err
+		}
+		days = append(days, d)
+	}
+	return days, rows.Err()
+}
+
+// ListWithdrawDeposits 获取 [dayStart, dayEnd) 内审批通过的房主提现，
+// 以及每笔提现申请前 window 内由该房主审批通过的玩家充值笔数与金额
+func (r *OwnerRiskRepo) ListWithdrawDeposits(ctx context.Context, dayStart, dayEnd time.Time, window time.Duration) ([]*model.OwnerWithdrawDeposits, error) {
+	rows, err := DB.Query(ctx, `SELECT w.user_id, w.id, w.amount, w.currency, w.created_at,
+			COUNT(d.id), COALESCE(SUM(d.amount), 0)
+		FROM fund_requests w
+		LEFT JOIN fund_requests d ON d.operator_id = w.user_id
+			AND d.request_type = 'deposit' AND d.status = 'approved'
+			AND d.updated_at >= w.created_at - make_interval(secs => $3) AND d.updated_at <= w.created_at
+		WHERE w.request_type = 'owner_withdraw' AND w.status = 'approved'
+			AND w.updated_at >= $1 AND w.updated_at < $2
+		GROUP BY w.id
+		ORDER BY w.id`, dayStart, dayEnd, window.Seconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []*model.OwnerWithdrawDeposits
+	for rows.Next() {
+		w := &model.OwnerWithdrawDeposits{}
+		if err := rows.Scan(&w.OwnerID, &w.RequestID, &w.Amount, &w.Currency, &w.RequestedAt, &w.Deposits, &w.DepositAmount); err != nil {
+			return nil, err
+		}
+		result = append(result, w)
+	}
+	return result, rows.Err()
+}
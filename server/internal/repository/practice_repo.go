@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/fiveseconds/server/internal/model"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/shopspring/decimal"
+)
+
+// PracticeRepo 练习房游戏币余额
+// 游戏币与真实余额完全隔离，变动不写 balance_transactions
+type PracticeRepo struct{}
+
+func NewPracticeRepo() *PracticeRepo {
+	return &PracticeRepo{}
+}
+
+// GetOrCreate 获取用户游戏币余额，不存在时按初始数量创建
+func (r *PracticeRepo) GetOrCreate(ctx context.Context, userID int64, initialChips decimal.Decimal) (*model.PracticeBalance, error) {
+	_, err := DB.Exec(ctx, `INSERT INTO practice_balances (user_id, chips, refilled_at) VALUES ($1, $2, NOW())
+		ON CONFLICT (user_id) DO NOTHING`, userID, initialChips)
+	if err != nil {
+		return nil, err
+	}
+
+	b := &model.PracticeBalance{}
+	err = DB.QueryRow(ctx, `SELECT user_id, chips, refilled_at, updated_at FROM practice_balances WHERE user_id = $1`,
+		userID).Scan(&b.UserID, &b.Chips, &b.RefilledAt, &b.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return b, err
+}
+
+// Refill 将游戏币补充到目标数量（当前余额不低于目标数量时不补充）
+// 返回补充后的余额及是否实际补充
+func (r *PracticeRepo) Refill(ctx context.Context, userID int64, target decimal.Decimal) (*model.PracticeBalance, bool, error) {
+	sql := `INSERT INTO practice_balances (user_id, chips, refilled_at) VALUES ($1, $2, NOW())
+		ON CONFLICT (user_id) DO UPDATE
+		SET chips = EXCLUDED.chips, refilled_at = NOW(), updated_at = NOW()
+		WHERE practice_balances.chips < EXCLUDED.chips
+		RETURNING user_id, chips, refilled_at, updated_at`
+	b := &model.PracticeBalance{}
+	err := DB.QueryRow(ctx, sql, userID, target).Scan(&b.UserID, &b.Chips, &b.RefilledAt, &b.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		current, err := r.GetOrCreate(ctx, userID, target)
+		return current, false, err
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return b, true, nil
+}
+
+// BatchDeduct 批量扣除下注游戏币（单条 SQL），只有余额足够的用户才会被扣除
+// 返回 key 为用户ID、value 为扣除后余额的 map
+func (r *PracticeRepo) BatchDeduct(ctx context.Context, userIDs []int64, amount decimal.Decimal) (map[int64]decimal.Decimal, error) {
+	if len(userIDs) == 0 {
+		return nil, nil
+	}
+
+	sql := `UPDATE practice_balances SET chips = chips - $1, updated_at = NOW()
+		WHERE user_id = ANY($2) AND chips >= $1
+		RETURNING user_id, chips`
+	rows, err := DB.Query(ctx, sql, amount, userIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanPracticeChips(rows)
+}
+
+// BatchAdd 批量增加游戏币（单条 SQL，用于派奖与退回下注）
+// 返回 key 为用户ID、value 为增加后余额的 map
+func (r *PracticeRepo) BatchAdd(ctx context.Context, amounts map[int64]decimal.Decimal) (map[int64]decimal.Decimal, error) {
+	if len(amounts) == 0 {
+		return nil, nil
+	}
+
+	userIDs := make([]int64, 0, len(amounts))
+	values := make([]string, 0, len(amounts))
+	for userID, amount := range amounts {
+		userIDs = append(userIDs, userID)
+		values = append(values, amount.String())
+	}
+
+	sql := `UPDATE practice_balances p SET chips = p.chips + v.amount, updated_at = NOW()
+		FROM unnest($1::BIGINT[], $2::NUMERIC[]) AS v(user_id, amount)
+		WHERE p.user_id = v.user_id
+		RETURNING p.user_id, p.chips`
+	rows, err := DB.Query(ctx, sql, userIDs, values)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanPracticeChips(rows)
+}
+
+func scanPracticeChips(rows pgx.Rows) (map[int64]decimal.Decimal, error) {
+	result := make(map[int64]decimal.Decimal)
+	for rows.Next() {
+		var userID int64
+		var chips decimal.Decimal
+		if err := rows.Scan(&userID, &chips); err != nil {
+			return nil, err
+		}
+		result[userID] = chips
+	}
+	return result, rows.Err()
+}
@@ -0,0 +1,201 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/fiveseconds/server/internal/model"
+	"github.com/jackc/pgx/v5"
+)
+
+// RebateRepo 返水仓库
+type RebateRepo struct{}
+
+// NewRebateRepo 创建返水仓库
+func NewRebateRepo() *RebateRepo {
+	return &RebateRepo{}
+}
+
+// GetRules 获取房主返水规则（未配置时 Tiers 为空）
+func (r *RebateRepo) GetRules(ctx context.Context, ownerID int64) (*model.OwnerRebateRules, error) {
+	sql := `SELECT basis, min_amount, rate FROM owner_rebate_tiers WHERE owner_id = $1 ORDER BY min_amount`
+	rows, err := DB.Query(ctx, sql, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rules := &model.OwnerRebateRules{OwnerID: ownerID, Basis: model.RebateBasisVolume, Tiers: []*model.RebateTier{}}
+	for rows.Next() {
+		tier := &model.RebateTier{}
+		if err := rows.Scan(&rules.Basis, &tier.MinAmount, &tier.Rate); err != nil {
+			return nil, err
+		}
+		rules.Tiers = append(rules.Tiers, tier)
+	}
+	return rules, rows.Err()
+}
+
+// ReplaceRulesTx 整体替换房主返水规则（支持事务）
+func (r *RebateRepo) ReplaceRulesTx(ctx context.Context, tx pgx.Tx, rules *model.OwnerRebateRules) error {
+	exec := GetExecutor(tx)
+	if _, err := exec.Exec(ctx, `DELETE FROM owner_rebate_tiers WHERE owner_id = $1`, rules.OwnerID); err != nil {
+		return err
+	}
+	sql := `INSERT INTO owner_rebate_tiers (owner_id, basis, min_amount, rate) VALUES ($1, $2, $3, $4)`
+	for _, tier := range rules.Tiers {
+		if _, err := exec.Exec(ctx, sql, rules.OwnerID, rules.Basis, tier.MinAmount, tier.Rate); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AggregatePlayerWagers 汇总房主名下玩家在周期内的真实余额游戏流水 [start, end)
+// 只统计 balance 账户及房主经营币种，奖励余额下注不参与返水；累进奖池奖金计入赢得金额
+func (r *RebateRepo) AggregatePlayerWagers(ctx context.Context, ownerID int64, start, end time.Time) ([]*model.PlayerWagerStat, error) {
+	sql := `SELECT u.id, u.username,
+			COALESCE(SUM(CASE WHEN bt.tx_type = 'game_bet' THEN -bt.amount ELSE 0 END), 0),
+			COALESCE(SUM(CASE WHEN bt.tx_type IN ('game_win', 'jackpot_win') THEN bt.amount ELSE 0 END), 0),
+			COALESCE(SUM(CASE WHEN bt.tx_type = 'game_refund' THEN bt.amount ELSE 0 END), 0)
+		FROM balance_transactions bt
+		JOIN users u ON bt.user_id = u.id
+		WHERE u.role = 'player' AND u.invited_by = $1
+			AND bt.tx_type IN ('game_bet', 'game_win', 'jackpot_win', 'game_refund')
+			AND bt.balance_field = 'balance'
+			AND bt.currency = (SELECT currency FROM users WHERE id = $1)
+			AND bt.created_at >= $2 AND bt.created_at < $3
+		GROUP BY u.id, u.username
+		ORDER BY u.id`
+	rows, err := DB.Query(ctx, sql, ownerID, start, end)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var stats []*model.PlayerWagerStat
+	for rows.Next() {
+		s := &model.PlayerWagerStat{}
+		if err := rows.Scan(&s.UserID, &s.Username, &s.Bet, &s.Win, &s.Refund); err != nil {
+			return nil, err
+		}
+		stats = append(stats, s)
+	}
+	return stats, rows.Err()
+}
+
+// HasOverlappingPayout 检查房主在 [start, end) 内是否已有返水发放记录（tx 为空时使用连接池）
+func (r *RebateRepo) HasOverlappingPayout(ctx context.Context, tx pgx.Tx, ownerID int64, start, end time.Time) (bool, error) {
+	sql := `SELECT EXISTS(SELECT 1 FROM rebate_payouts
+		WHERE owner_id = $1 AND period_start < $3 AND period_end > $2)`
+	var exists bool
+	err := GetExecutor(tx).QueryRow(ctx, sql, ownerID, start, end).Scan(&exists)
+	return exists, err
+}
+
+// CreatePayoutTx 创建返水发放记录及明细（支持事务）
+func (r *RebateRepo) CreatePayoutTx(ctx context.Context, tx pgx.Tx, p *model.RebatePayout) error {
+	exec := GetExecutor(tx)
+	sql := `INSERT INTO rebate_payouts (owner_id, basis, period_start, period_end, player_count, total_amount, operator_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, created_at`
+	if err := exec.QueryRow(ctx, sql,
+		p.OwnerID, p.Basis, p.PeriodStart, p.PeriodEnd, p.PlayerCount, p.TotalAmount, p.OperatorID,
+	).Scan(&p.ID, &p.CreatedAt); err != nil {
+		return err
+	}
+
+	itemSQL := `INSERT INTO rebate_payout_items (payout_id, user_id, volume, net_loss, eligible_amount, rate, amount)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id`
+	for _, item := range p.Items {
+		item.PayoutID = p.ID
+		if err := exec.QueryRow(ctx, itemSQL,
+			item.PayoutID, item.UserID, item.Volume, item.NetLoss, item.EligibleAmount, item.Rate, item.Amount,
+		).Scan(&item.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetPayout 根据ID获取返水发放记录（含明细）
+func (r *RebateRepo) GetPayout(ctx context.Context, id int64) (*model.RebatePayout, error) {
+	sql := `SELECT id, owner_id, basis, period_start, period_end, player_count, total_amount, operator_id, created_at
+		FROM rebate_payouts WHERE id = $1`
+	p := &model.RebatePayout{}
+	err := DB.QueryRow(ctx, sql, id).Scan(
+		&p.ID, &p.OwnerID, &p.Basis, &p.PeriodStart, &p.PeriodEnd, &p.PlayerCount, &p.TotalAmount, &p.OperatorID, &p.CreatedAt,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	itemSQL := `SELECT i.id, i.payout_id, i.user_id, COALESCE(u.username, ''), i.volume, i.net_loss, i.eligible_amount, i.rate, i.amount
+		FROM rebate_payout_items i
+		LEFT JOIN users u ON i.user_id = u.id
+		WHERE i.payout_id = $1
+		ORDER BY i.amount DESC, i.user_id`
+	rows, err := DB.Query(ctx, itemSQL, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		item := &model.RebatePayoutItem{}
+		if err := rows.Scan(&item.ID, &item.PayoutID, &item.UserID, &item.Username,
+			&item.Volume, &item.NetLoss, &item.EligibleAmount, &item.Rate, &item.Amount); err != nil {
+			return nil, err
+		}
+		p.Items = append(p.Items, item)
+	}
+	return p, rows.Err()
+}
+
+// ListPayouts 分页获取返水发放记录（不含明细）
+func (r *RebateRepo) ListPayouts(ctx context.Context, query *model.RebatePayoutListQuery) ([]*model.RebatePayout, int64, error) {
+	countSQL := `SELECT COUNT(*) FROM rebate_payouts WHERE 1=1`
+	listSQL := `SELECT id, owner_id, basis, period_start, period_end, player_count, total_amount, operator_id, created_at
+		FROM rebate_payouts WHERE 1=1`
+
+	args := []interface{}{}
+	argIdx := 1
+
+	if query.OwnerID != nil {
+		countSQL += fmt.Sprintf(` AND owner_id = $%d`, argIdx)
+		listSQL += fmt.Sprintf(` AND owner_id = $%d`, argIdx)
+		args = append(args, *query.OwnerID)
+		argIdx++
+	}
+
+	var total int64
+	if err := DB.QueryRow(ctx, countSQL, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	listSQL += fmt.Sprintf(` ORDER BY period_start DESC, id DESC LIMIT $%d OFFSET $%d`, argIdx, argIdx+1)
+	args = append(args, query.PageSize, (query.Page-1)*query.PageSize)
+
+	rows, err := DB.Query(ctx, listSQL, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var payouts []*model.RebatePayout
+	for rows.Next() {
+		p := &model.RebatePayout{}
+		if err := rows.Scan(
+			&p.ID, &p.OwnerID, &p.Basis, &p.PeriodStart, &p.PeriodEnd, &p.PlayerCount, &p.TotalAmount, &p.OperatorID, &p.CreatedAt,
+		); err != nil {
+			return nil, 0, err
+		}
+		payouts = append(payouts, p)
+	}
+	return payouts, total, nil
+}
@@ -0,0 +1,236 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/fiveseconds/server/internal/model"
+	"github.com/jackc/pgx/v5"
+)
+
+var ErrReferralRewardExists = errors.New("referral reward already exists")
+
+// ReferralRepo 推荐奖励仓库
+type ReferralRepo struct{}
+
+// NewReferralRepo 创建推荐奖励仓库
+func NewReferralRepo() *ReferralRepo {
+	return &ReferralRepo{}
+}
+
+// Create 记录推荐关系
+func (r *ReferralRepo) Create(ctx context.Context, ref *model.Referral) error {
+	return r.CreateTx(ctx, nil, ref)
+}
+
+// CreateTx 创建推荐关系(支持事务)
+func (r *ReferralRepo) CreateTx(ctx context.Context, tx pgx.Tx, ref *model.Referral) error {
+	sql := `INSERT INTO referrals (referee_id, referrer_id, owner_id, status)
+		VALUES ($1, $2, $3, $4)
+		RETURNING created_at`
+	return GetExecutor(tx).QueryRow(ctx, sql, ref.RefereeID, ref.ReferrerID, ref.OwnerID, ref.Status).Scan(&ref.CreatedAt)
+}
+
+// BlockTx 停止向该被推荐人的推荐人发放奖励（支持事务）
+func (r *ReferralRepo) BlockTx(ctx context.Context, tx pgx.Tx, refereeID int64, reason string) error {
+	sql := `UPDATE referrals SET status = 'blocked', block_reason = $2 WHERE referee_id = $1`
+	_, err := GetExecutor(tx).Exec(ctx, sql, refereeID, reason)
+	return err
+}
+
+// ListRefereeSummaries 获取推荐人名下的被推荐人概况（含注册后已结算局数与已发放奖励）
+func (r *ReferralRepo) ListRefereeSummaries(ctx context.Context, referrerID int64) ([]*model.RefereeSummary, error) {
+	sql := `SELECT rf.referee_id, u.username, rf.status, rf.created_at,
+			(SELECT COUNT(*) FROM game_rounds gr
+				WHERE gr.status = 'settled' AND rf.referee_id = ANY(gr.participant_ids) AND gr.settled_at >= rf.created_at),
+			(SELECT COALESCE(SUM(rr.amount), 0) FROM referral_rewards rr
+				WHERE rr.referee_id = rf.referee_id AND rr.status = 'paid')
+		FROM referrals rf
+		JOIN users u ON rf.referee_id = u.id
+		WHERE rf.referrer_id = $1
+		ORDER BY rf.created_at DESC`
+	rows, err := DB.Query(ctx, sql, referrerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var summaries []*model.RefereeSummary
+	for rows.Next() {
+		s := &model.RefereeSummary{}
+		if err := rows.Scan(&s.UserID, &s.Username, &s.Status, &s.JoinedAt, &s.RoundsPlayed, &s.RewardTotal); err != nil {
+			return nil, err
+		}
+		summaries = append(summaries, s)
+	}
+	return summaries, rows.Err()
+}
+
+// EarliestUnsharedRoundAt 获取尚未进行佣金分成的最早已结算回合时间（没有时返回 nil）
+// 以每个有效推荐关系最近一次分成周期的结束时间（从未分成时为推荐关系创建时间）为起点
+func (r *ReferralRepo) EarliestUnsharedRoundAt(ctx context.Context) (*time.Time, error) {
+	sql := `SELECT MIN(gr.settled_at)
+		FROM referrals rf
+		JOIN game_rounds gr ON rf.referee_id = ANY(gr.participant_ids)
+		WHERE rf.status = 'active' AND gr.status = 'settled'
+			AND gr.settled_at >= COALESCE((
+				SELECT MAX(rr.period_end) FROM referral_rewards rr
+				WHERE rr.referee_id = rf.referee_id AND rr.reward_type = 'commission_share'
+			), rf.created_at)`
+	var earliest *time.Time
+	err := DB.QueryRow(ctx, sql).Scan(&earliest)
+	return earliest, err
+}
+
+// ListCommissionShareCandidates 汇总周期 [start, end) 内尚未分成的被推荐人佣金
+// 每局佣金按参与人数平分，source 决定统计房主佣金还是平台抽成；只统计房主经营币种的房间
+func (r *ReferralRepo) ListCommissionShareCandidates(ctx context.Context, source model.ReferralFundingSource, start, end time.Time) ([]*model.ReferralReward, error) {
+	sql := `SELECT rf.referrer_id, rf.referee_id, rf.owner_id,
+			COALESCE(SUM(
+				CASE WHEN $3 = 'platform' THEN COALESCE(gr.platform_earning, 0) ELSE COALESCE(gr.owner_earning, 0) END
+				/ cardinality(gr.participant_ids)
+			), 0)
+		FROM referrals rf
+		JOIN game_rounds gr ON rf.referee_id = ANY(gr.participant_ids)
+		JOIN rooms rm ON gr.room_id = rm.id
+		JOIN users o ON rf.owner_id = o.id
+		WHERE rf.status = 'active' AND gr.status = 'settled' AND rm.currency = o.currency
+			AND gr.settled_at >= $1 AND gr.settled_at < $2 AND gr.settled_at >= rf.created_at
+			AND NOT EXISTS (
+				SELECT 1 FROM referral_rewards rr
+				WHERE rr.referee_id = rf.referee_id AND rr.reward_type = 'commission_share' AND rr.period_start = $1
+			)
+		GROUP BY rf.referrer_id, rf.referee_id, rf.owner_id
+		ORDER BY rf.referee_id`
+	rows, err := DB.Query(ctx, sql, start, end, string(source))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rewards []*model.ReferralReward
+	for rows.Next() {
+		rw := &model.ReferralReward{
+			RewardType:    model.ReferralRewardCommissionShare,
+			FundingSource: source,
+			PeriodStart:   &start,
+			PeriodEnd:     &end,
+		}
+		if err := rows.Scan(&rw.ReferrerID, &rw.RefereeID, &rw.OwnerID, &rw.BaseAmount); err != nil {
+			return nil, err
+		}
+		rewards = append(rewards, rw)
+	}
+	return rewards, rows.Err()
+}
+
+// ListFirstRoundsCandidates 获取注册后已完成 rounds 局、尚未发放首 N 局奖励的被推荐人
+func (r *ReferralRepo) ListFirstRoundsCandidates(ctx context.Context, rounds int) ([]*model.ReferralReward, error) {
+	sql := `SELECT rf.referrer_id, rf.referee_id, rf.owner_id
+		FROM referrals rf
+		WHERE rf.status = 'active'
+			AND NOT EXISTS (
+				SELECT 1 FROM referral_rewards rr
+				WHERE rr.referee_id = rf.referee_id AND rr.reward_type = 'first_rounds'
+			)
+			AND (SELECT COUNT(*) FROM game_rounds gr
+				WHERE gr.status = 'settled' AND rf.referee_id = ANY(gr.participant_ids) AND gr.settled_at >= rf.created_at) >= $1
+		ORDER BY rf.referee_id`
+	rows, err := DB.Query(ctx, sql, rounds)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rewards []*model.ReferralReward
+	for rows.Next() {
+		rw := &model.ReferralReward{RewardType: model.ReferralRewardFirstRounds}
+		if err := rows.Scan(&rw.ReferrerID, &rw.RefereeID, &rw.OwnerID); err != nil {
+			return nil, err
+		}
+		rewards = append(rewards, rw)
+	}
+	return rewards, rows.Err()
+}
+
+// CreateRewardTx 创建推荐奖励记录（支持事务）
+// 同一被推荐人同一周期已分成或首 N 局奖励已发放时返回 ErrReferralRewardExists
+func (r *ReferralRepo) CreateRewardTx(ctx context.Context, tx pgx.Tx, rw *model.ReferralReward) error {
+	sql := `INSERT INTO referral_rewards (referrer_id, referee_id, owner_id, reward_type, funding_source,
+			period_start, period_end, base_amount, rate, amount, status, block_reason)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		ON CONFLICT DO NOTHING
+		RETURNING id, created_at`
+	err := GetExecutor(tx).QueryRow(ctx, sql,
+		rw.ReferrerID, rw.RefereeID, rw.OwnerID, rw.RewardType, rw.FundingSource,
+		rw.PeriodStart, rw.PeriodEnd, rw.BaseAmount, rw.Rate, rw.Amount, rw.Status, rw.BlockReason,
+	).Scan(&rw.ID, &rw.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrReferralRewardExists
+	}
+	return err
+}
+
+// ListRewards 分页获取推荐奖励记录
+func (r *ReferralRepo) ListRewards(ctx context.Context, query *model.ReferralRewardListQuery) ([]*model.ReferralReward, int64, error) {
+	countSQL := `SELECT COUNT(*) FROM referral_rewards rr WHERE 1=1`
+	listSQL := `SELECT rr.id, rr.referrer_id, COALESCE(u1.username, ''), rr.referee_id, COALESCE(u2.username, ''),
+			rr.owner_id, rr.reward_type, rr.funding_source, rr.period_start, rr.period_end,
+			rr.base_amount, rr.rate, rr.amount, rr.status, rr.block_reason, rr.created_at
+		FROM referral_rewards rr
+		LEFT JOIN users u1 ON rr.referrer_id = u1.id
+		LEFT JOIN users u2 ON rr.referee_id = u2.id
+		WHERE 1=1`
+
+	args := []interface{}{}
+	argIdx := 1
+
+	if query.OwnerID != nil {
+		countSQL += fmt.Sprintf(` AND rr.owner_id = $%d`, argIdx)
+		listSQL += fmt.Sprintf(` AND rr.owner_id = $%d`, argIdx)
+		args = append(args, *query.OwnerID)
+		argIdx++
+	}
+	if query.ReferrerID != nil {
+		countSQL += fmt.Sprintf(` AND rr.referrer_id = $%d`, argIdx)
+		listSQL += fmt.Sprintf(` AND rr.referrer_id = $%d`, argIdx)
+		args = append(args, *query.ReferrerID)
+		argIdx++
+	}
+	if query.Status != nil {
+		countSQL += fmt.Sprintf(` AND rr.status = $%d`, argIdx)
+		listSQL += fmt.Sprintf(` AND rr.status = $%d`, argIdx)
+		args = append(args, *query.Status)
+		argIdx++
+	}
+
+	var total int64
+	if err := DB.QueryRow(ctx, countSQL, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	listSQL += fmt.Sprintf(` ORDER BY rr.created_at DESC, rr.id DESC LIMIT $%d OFFSET $%d`, argIdx, argIdx+1)
+	args = append(args, query.PageSize, (query.Page-1)*query.PageSize)
+
+	rows, err := DB.Query(ctx, listSQL, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var rewards []*model.ReferralReward
+	for rows.Next() {
+		rw := &model.ReferralReward{}
+		if err := rows.Scan(
+			&rw.ID, &rw.ReferrerID, &rw.ReferrerName, &rw.RefereeID, &rw.RefereeName,
+			&rw.OwnerID, &rw.RewardType, &rw.FundingSource, &rw.PeriodStart, &rw.PeriodEnd,
+			&rw.BaseAmount, &rw.Rate, &rw.Amount, &rw.Status, &rw.BlockReason, &rw.CreatedAt,
+		); err != nil {
+			return nil, 0, err
+		}
+		rewards = append(rewards, rw)
+	}
+	return rewards, total, nil
+}
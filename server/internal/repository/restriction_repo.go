@@ -0,0 +1,160 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/fiveseconds/server/internal/model"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// RestrictionRepo 账户限制仓库
+type RestrictionRepo struct{}
+
+// NewRestrictionRepo 创建账户限制仓库
+func NewRestrictionRepo() *RestrictionRepo {
+	return &RestrictionRepo{}
+}
+
+const restrictionColumns = `id, user_id, restriction_type, status, reason_code, source, flag_id, rule_id, remark,
+	created_by, expires_at, ended_at, ended_by, created_at`
+
+func scanRestriction(row pgx.Row) (*model.AccountRestriction, error) {
+	r := &model.AccountRestriction{}
+	err := row.Scan(
+		&r.ID, &r.UserID, &r.Type, &r.Status, &r.Reason, &r.Source, &r.FlagID, &r.RuleID, &r.Remark,
+		&r.CreatedBy, &r.ExpiresAt, &r.EndedAt, &r.EndedBy, &r.CreatedAt,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func collectRestrictions(rows pgx.Rows) ([]*model.AccountRestriction, error) {
+	defer rows.Close()
+	restrictions := []*model.AccountRestriction{}
+	for rows.Next() {
+		r, err := scanRestriction(rows)
+		if err != nil {
+			return nil, err
+		}
+		restrictions = append(restrictions, r)
+	}
+	return restrictions, rows.Err()
+}
+
+// Create 施加账户限制，并在同一语句内写入审计记录
+func (r *RestrictionRepo) Create(ctx context.Context, restriction *model.AccountRestriction) error {
+	sql := `WITH created AS (
+			INSERT INTO account_restrictions
+				(user_id, restriction_type, reason_code, source, flag_id, rule_id, remark, created_by, expires_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+			RETURNING id, user_id, status, created_by, remark, created_at
+		), audit AS (
+			INSERT INTO account_restriction_audit (restriction_id, user_id, action, operator_id, remark)
+			SELECT id, user_id, 'applied', created_by, remark FROM created
+		)
+		SELECT id, status, created_at FROM created`
+	return DB.QueryRow(ctx, sql,
+		restriction.UserID, restriction.Type, restriction.Reason, restriction.Source, restriction.FlagID,
+		restriction.RuleID, restriction.Remark, restriction.CreatedBy, restriction.ExpiresAt,
+	).Scan(&restriction.ID, &restriction.Status, &restriction.CreatedAt)
+}
+
+// GetByID 获取账户限制
+func (r *RestrictionRepo) GetByID(ctx context.Context, id int64) (*model.AccountRestriction, error) {
+	return scanRestriction(DB.QueryRow(ctx, `SELECT `+restrictionColumns+` FROM account_restrictions WHERE id = $1`, id))
+}
+
+// ListActiveByUsers 获取用户在 at 时刻生效的限制（未解除且未到期）
+func (r *RestrictionRepo) ListActiveByUsers(ctx context.Context, userIDs []int64, at time.Time) ([]*model.AccountRestriction, error) {
+	rows, err := DB.Query(ctx, `SELECT `+restrictionColumns+`
+		FROM account_restrictions
+		WHERE user_id = ANY($1) AND status = 'active' AND expires_at > $2
+		ORDER BY expires_at DESC, id DESC`, userIDs, at)
+	if err != nil {
+		return nil, err
+	}
+	return collectRestrictions(rows)
+}
+
+// ListByUser 获取用户的全部限制（新的在前）
+func (r *RestrictionRepo) ListByUser(ctx context.Context, userID int64) ([]*model.AccountRestriction, error) {
+	rows, err := DB.Query(ctx, `SELECT `+restrictionColumns+`
+		FROM account_restrictions
+		WHERE user_id = $1
+		ORDER BY created_at DESC, id DESC`, userID)
+	if err != nil {
+		return nil, err
+	}
+	return collectRestrictions(rows)
+}
+
+// Revoke 解除生效中的限制并写入审计记录；限制不存在或已结束时返回 ErrNotFound
+func (r *RestrictionRepo) Revoke(ctx context.Context, id, operatorID int64, remark string) (*model.AccountRestriction, error) {
+	sql := `WITH revoked AS (
+			UPDATE account_restrictions SET status = 'revoked', ended_at = NOW(), ended_by = $2
+			WHERE id = $1 AND status = 'active'
+			RETURNING ` + restrictionColumns + `
+		), audit AS (
+			INSERT INTO account_restriction_audit (restriction_id, user_id, action, operator_id, remark)
+			SELECT id, user_id, 'revoked', $2, $3 FROM revoked
+		)
+		SELECT ` + restrictionColumns + ` FROM revoked`
+	return scanRestriction(DB.QueryRow(ctx, sql, id, operatorID, remark))
+}
+
+// ExpireDue 将 now 之前到期的限制标记为已到期并写入审计记录，返回本次处理的限制
+func (r *RestrictionRepo) ExpireDue(ctx context.Context, now time.Time) ([]*model.AccountRestriction, error) {
+	sql := `WITH expired AS (
+			UPDATE account_restrictions SET status = 'expired', ended_at = $1
+			WHERE status = 'active' AND expires_at <= $1
+			RETURNING ` + restrictionColumns + `
+		), audit AS (
+			INSERT INTO account_restriction_audit (restriction_id, user_id, action)
+			SELECT id, user_id, 'expired' FROM expired
+		)
+		SELECT ` + restrictionColumns + ` FROM expired`
+	rows, err := DB.Query(ctx, sql, now)
+	if err != nil {
+		return nil, err
+	}
+	return collectRestrictions(rows)
+}
+
+// ListAudit 分页获取用户的限制审计记录（新的在前）
+func (r *RestrictionRepo) ListAudit(ctx context.Context, userID int64, query *model.RestrictionAuditQuery) ([]*model.RestrictionAuditEntry, int64, error) {
+	var total int64
+	if err := DB.QueryRow(ctx, `SELECT COUNT(*) FROM account_restriction_audit WHERE user_id = $1`, userID).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := DB.Query(ctx, `SELECT a.id, a.restriction_id, a.user_id, r.restriction_type, a.action,
+			a.operator_id, a.remark, a.created_at
+		FROM account_restriction_audit a
+		JOIN account_restrictions r ON r.id = a.restriction_id
+		WHERE a.user_id = $1
+		ORDER BY a.created_at DESC, a.id DESC
+		LIMIT $2 OFFSET $3`, userID, query.PageSize, (query.Page-1)*query.PageSize)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	entries := []*model.RestrictionAuditEntry{}
+	for rows.Next() {
+		e := &model.RestrictionAuditEntry{}
+		if err := rows.Scan(&e.ID, &e.RestrictionID, &e.UserID, &e.Type, &e.Action,
+			&e.OperatorID, &e.Remark, &e.CreatedAt); err != nil {
+			return nil, 0, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, total, rows.Err()
+}
@@ -0,0 +1,393 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/fiveseconds/server/internal/model"
+
+	"github.com/jackc/pgx/v5"
+)
+
+var (
+	ErrRiskCaseFlagLinked       = errors.New("flag already belongs to a case")
+	ErrRiskCaseConflict         = errors.New("case was modified concurrently")
+	ErrRiskCaseEvidenceAttached = errors.New("evidence already attached to case")
+)
+
+// RiskCaseRepo 风控案件仓库
+type RiskCaseRepo struct{}
+
+// NewRiskCaseRepo 创建风控案件仓库
+func NewRiskCaseRepo() *RiskCaseRepo {
+	return &RiskCaseRepo{}
+}
+
+const riskCaseColumns = `c.id, c.title, c.user_ids, c.status, c.assignee_id, c.resolution, c.created_by,
+	c.created_at, c.updated_at, c.resolved_at,
+	(SELECT COUNT(*) FROM risk_case_flags cf WHERE cf.case_id = c.id)`
+
+func scanRiskCase(row pgx.Row) (*model.RiskCase, error) {
+	rc := &model.RiskCase{}
+	err := row.Scan(
+		&rc.ID, &rc.Title, &rc.UserIDs, &rc.Status, &rc.AssigneeID, &rc.Resolution, &rc.CreatedBy,
+		&rc.CreatedAt, &rc.UpdatedAt, &rc.ResolvedAt, &rc.FlagCount,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return rc, nil
+}
+
+// riskCaseEvidenceQueries 各类证据的快照查询：$1 为记录ID，$2 为案件涉及的用户，记录须与其中至少一人相关
+var riskCaseEvidenceQueries = map[model.RiskCaseEvidenceType]string{
+	model.RiskCaseEvidenceRound:       `SELECT to_jsonb(r) FROM game_rounds r WHERE r.id = $1 AND r.participant_ids && $2::bigint[]`,
+	model.RiskCaseEvidenceTransaction: `SELECT to_jsonb(t) FROM balance_transactions t WHERE t.id = $1 AND t.user_id = ANY($2)`,
+	model.RiskCaseEvidenceLoginEvent:  `SELECT to_jsonb(e) FROM login_events e WHERE e.id = $1 AND e.user_id = ANY($2)`,
+}
+
+// insertAuditTx 在事务中写入案件审计记录，并刷新案件更新时间
+func (r *RiskCaseRepo) insertAuditTx(ctx context.Context, tx pgx.Tx, caseID int64, action model.RiskCaseAuditAction, operatorID *int64, details interface{}) error {
+	detailsJSON, err := json.Marshal(details)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, `UPDATE risk_cases SET updated_at = NOW() WHERE id = $1`, caseID); err != nil {
+		return err
+	}
+	_, err = tx.Exec(ctx, `INSERT INTO risk_case_audit (case_id, action, operator_id, details) VALUES ($1, $2, $3, $4)`,
+		caseID, action, operatorID, detailsJSON)
+	return err
+}
+
+// linkFlagsTx 在事务中将标记加入案件；任一标记已属于其他案件时返回 ErrRiskCaseFlagLinked
+func (r *RiskCaseRepo) linkFlagsTx(ctx context.Context, tx pgx.Tx, caseID int64, flagIDs []int64, operatorID int64) error {
+	var linked bool
+	if err := tx.QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM risk_case_flags WHERE flag_id = ANY($1))`, flagIDs).Scan(&linked); err != nil {
+		return err
+	}
+	if linked {
+		return ErrRiskCaseFlagLinked
+	}
+	_, err := tx.Exec(ctx, `INSERT INTO risk_case_flags (case_id, flag_id, added_by)
+		SELECT $1, unnest($2::bigint[]), $3`, caseID, flagIDs, operatorID)
+	return err
+}
+
+// Create 创建案件并加入标记、写入首条备注与审计记录
+func (r *RiskCaseRepo) Create(ctx context.Context, rc *model.RiskCase, flagIDs []int64, note string) error {
+	return Tx(ctx, func(tx pgx.Tx) error {
+		err := tx.QueryRow(ctx, `INSERT INTO risk_cases (title, user_ids, assignee_id, created_by)
+			VALUES ($1, $2, $3, $4)
+			RETURNING id, status, created_at, updated_at`,
+			rc.Title, rc.UserIDs, rc.AssigneeID, rc.CreatedBy,
+		).Scan(&rc.ID, &rc.Status, &rc.CreatedAt, &rc.UpdatedAt)
+		if err != nil {
+			return err
+		}
+		if err := r.linkFlagsTx(ctx, tx, rc.ID, flagIDs, rc.CreatedBy); err != nil {
+			return err
+		}
+		rc.FlagCount = len(flagIDs)
+
+		operatorID := rc.CreatedBy
+		details := map[string]interface{}{"flag_ids": flagIDs, "user_ids": rc.UserIDs}
+		if rc.AssigneeID != nil {
+			details["assignee_id"] = *rc.AssigneeID
+		}
+		if err := r.insertAuditTx(ctx, tx, rc.ID, model.RiskCaseAuditCreated, &operatorID, details); err != nil {
+			return err
+		}
+		if note == "" {
+			return nil
+		}
+		var noteID int64
+		if err := tx.QueryRow(ctx, `INSERT INTO risk_case_notes (case_id, author_id, body) VALUES ($1, $2, $3) RETURNING id`,
+			rc.ID, operatorID, note).Scan(&noteID); err != nil {
+			return err
+		}
+		return r.insertAuditTx(ctx, tx, rc.ID, model.RiskCaseAuditNoteAdded, &operatorID, map[string]interface{}{"note_id": noteID})
+	})
+}
+
+// GetByID 获取案件
+func (r *RiskCaseRepo) GetByID(ctx context.Context, id int64) (*model.RiskCase, error) {
+	return scanRiskCase(DB.QueryRow(ctx, `SELECT `+riskCaseColumns+` FROM risk_cases c WHERE c.id = $1`, id))
+}
+
+// GetByFlag 获取标记所属的案件，标记未归入案件时返回 ErrNotFound
+func (r *RiskCaseRepo) GetByFlag(ctx context.Context, flagID int64) (*model.RiskCase, error) {
+	return scanRiskCase(DB.QueryRow(ctx, `SELECT `+riskCaseColumns+`
+		FROM risk_cases c
+		JOIN risk_case_flags f ON f.case_id = c.id
+		WHERE f.flag_id = $1`, flagID))
+}
+
+// List 列表案件（最近更新的在前）
+func (r *RiskCaseRepo) List(ctx context.Context, query *model.RiskCaseListQuery) ([]*model.RiskCase, int64, error) {
+	where := ` WHERE 1=1`
+	args := []interface{}{}
+	argIdx := 1
+
+	if query.Status != nil {
+		where += fmt.Sprintf(` AND c.status = $%d`, argIdx)
+		args = append(args, *query.Status)
+		argIdx++
+	}
+	if query.AssigneeID != nil {
+		where += fmt.Sprintf(` AND c.assignee_id = $%d`, argIdx)
+		args = append(args, *query.AssigneeID)
+		argIdx++
+	}
+	if query.UserID != nil {
+		where += fmt.Sprintf(` AND $%d = ANY(c.user_ids)`, argIdx)
+		args = append(args, *query.UserID)
+		argIdx++
+	}
+
+	var total int64
+	if err := DB.QueryRow(ctx, `SELECT COUNT(*) FROM risk_cases c`+where, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	listSQL := `SELECT ` + riskCaseColumns + ` FROM risk_cases c` + where +
+		fmt.Sprintf(` ORDER BY c.updated_at DESC, c.id DESC LIMIT $%d OFFSET $%d`, argIdx, argIdx+1)
+	args = append(args, query.PageSize, (query.Page-1)*query.PageSize)
+
+	rows, err := DB.Query(ctx, listSQL, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	cases := []*model.RiskCase{}
+	for rows.Next() {
+		rc, err := scanRiskCase(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		cases = append(cases, rc)
+	}
+	return cases, total, rows.Err()
+}
+
+// AddFlags 向案件追加标记并更新涉及的用户
+func (r *RiskCaseRepo) AddFlags(ctx context.Context, caseID int64, flagIDs, userIDs []int64, operatorID int64) error {
+	return Tx(ctx, func(tx pgx.Tx) error {
+		if err := r.linkFlagsTx(ctx, tx, caseID, flagIDs, operatorID); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(ctx, `UPDATE risk_cases SET user_ids = $2 WHERE id = $1`, caseID, userIDs); err != nil {
+			return err
+		}
+		return r.insertAuditTx(ctx, tx, caseID, model.RiskCaseAuditFlagsAdded, &operatorID,
+			map[string]interface{}{"flag_ids": flagIDs, "user_ids": userIDs})
+	})
+}
+
+// SetAssignee 指派（或取消指派）案件处理人
+func (r *RiskCaseRepo) SetAssignee(ctx context.Context, caseID int64, from, to *int64, operatorID int64) error {
+	return Tx(ctx, func(tx pgx.Tx) error {
+		tag, err := tx.Exec(ctx, `UPDATE risk_cases SET assignee_id = $2
+			WHERE id = $1 AND assignee_id IS NOT DISTINCT FROM $3 AND status <> 'resolved'`, caseID, to, from)
+		if err != nil {
+			return err
+		}
+		if tag.RowsAffected() == 0 {
+			return ErrRiskCaseConflict
+		}
+		return r.insertAuditTx(ctx, tx, caseID, model.RiskCaseAuditAssigned, &operatorID,
+			map[string]interface{}{"from": from, "to": to})
+	})
+}
+
+// UpdateStatus 变更案件状态（乐观校验当前状态）；结案时记录结论与时间，重新打开时清空
+func (r *RiskCaseRepo) UpdateStatus(ctx context.Context, caseID int64, from, to model.RiskCaseStatus, resolution string, operatorID int64) error {
+	return Tx(ctx, func(tx pgx.Tx) error {
+		tag, err := tx.Exec(ctx, `UPDATE risk_cases SET status = $2, resolution = $4,
+				resolved_at = CASE WHEN $2 = 'resolved' THEN NOW() END
+			WHERE id = $1 AND status = $3`, caseID, to, from, resolution)
+		if err != nil {
+			return err
+		}
+		if tag.RowsAffected() == 0 {
+			return ErrRiskCaseConflict
+		}
+		details := map[string]interface{}{"from": from, "to": to}
+		if resolution != "" {
+			details["resolution"] = resolution
+		}
+		return r.insertAuditTx(ctx, tx, caseID, model.RiskCaseAuditStatusChanged, &operatorID, details)
+	})
+}
+
+// AddNote 添加案件备注；回复的备注不属于该案件时返回 ErrNotFound
+func (r *RiskCaseRepo) AddNote(ctx context.Context, note *model.RiskCaseNote) error {
+	return Tx(ctx, func(tx pgx.Tx) error {
+		err := tx.QueryRow(ctx, `INSERT INTO risk_case_notes (case_id, parent_id, author_id, body)
+			SELECT $1, $2, $3, $4
+			WHERE $2::bigint IS NULL OR EXISTS (SELECT 1 FROM risk_case_notes WHERE id = $2 AND case_id = $1)
+			RETURNING id, created_at`,
+			note.CaseID, note.ParentID, note.AuthorID, note.Body,
+		).Scan(&note.ID, &note.CreatedAt)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrNotFound
+		}
+		if err != nil {
+			return err
+		}
+		details := map[string]interface{}{"note_id": note.ID}
+		if note.ParentID != nil {
+			details["parent_id"] = *note.ParentID
+		}
+		return r.insertAuditTx(ctx, tx, note.CaseID, model.RiskCaseAuditNoteAdded, &note.AuthorID, details)
+	})
+}
+
+// AddEvidence 添加案件证据并保存记录快照；记录不存在或与案件用户无关时返回 ErrNotFound
+func (r *RiskCaseRepo) AddEvidence(ctx context.Context, ev *model.RiskCaseEvidence, userIDs []int64) error {
+	query, ok := riskCaseEvidenceQueries[ev.Type]
+	if !ok {
+		return ErrNotFound
+	}
+	return Tx(ctx, func(tx pgx.Tx) error {
+		var snapshot []byte
+		if err := tx.QueryRow(ctx, query, ev.RefID, userIDs).Scan(&snapshot); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return ErrNotFound
+			}
+			return err
+		}
+		ev.Snapshot = snapshot
+
+		err := tx.QueryRow(ctx, `INSERT INTO risk_case_evidence (case_id, evidence_type, ref_id, snapshot, remark, added_by)
+			VALUES ($1, $2, $3, $4, $5, $6)
+			ON CONFLICT (case_id, evidence_type, ref_id) DO NOTHING
+			RETURNING id, created_at`,
+			ev.CaseID, ev.Type, ev.RefID, snapshot, ev.Remark, ev.AddedBy,
+		).Scan(&ev.ID, &ev.CreatedAt)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrRiskCaseEvidenceAttached
+		}
+		if err != nil {
+			return err
+		}
+		return r.insertAuditTx(ctx, tx, ev.CaseID, model.RiskCaseAuditEvidenceAdded, &ev.AddedBy,
+			map[string]interface{}{"evidence_id": ev.ID, "type": ev.Type, "ref_id": ev.RefID})
+	})
+}
+
+// AddAudit 写入案件审计记录（案件外部发生的变化，如案件内标记被审核）
+func (r *RiskCaseRepo) AddAudit(ctx context.Context, caseID int64, action model.RiskCaseAuditAction, operatorID *int64, details interface{}) error {
+	return Tx(ctx, func(tx pgx.Tx) error {
+		return r.insertAuditTx(ctx, tx, caseID, action, operatorID, details)
+	})
+}
+
+// ListFlags 获取案件内的风控标记（按创建时间）
+func (r *RiskCaseRepo) ListFlags(ctx context.Context, caseID int64) ([]*model.RiskFlag, error) {
+	rows, err := DB.Query(ctx, `SELECT f.id, f.user_id, f.flag_type, f.details, f.status, f.severity, f.rule_id,
+			f.reviewed_by, f.reviewed_at, f.review_remark, f.created_at
+		FROM risk_flags f
+		JOIN risk_case_flags cf ON cf.flag_id = f.id
+		WHERE cf.case_id = $1
+		ORDER BY f.created_at, f.id`, caseID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	flags := []*model.RiskFlag{}
+	for rows.Next() {
+		flag := &model.RiskFlag{}
+		if err := rows.Scan(
+			&flag.ID, &flag.UserID, &flag.FlagType, &flag.Details, &flag.Status, &flag.Severity, &flag.RuleID,
+			&flag.ReviewedBy, &flag.ReviewedAt, &flag.ReviewRemark, &flag.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		flags = append(flags, flag)
+	}
+	return flags, rows.Err()
+}
+
+// ListNotes 获取案件备注（按时间先后，客户端按 parent_id 组织线程）
+func (r *RiskCaseRepo) ListNotes(ctx context.Context, caseID int64) ([]*model.RiskCaseNote, error) {
+	rows, err := DB.Query(ctx, `SELECT id, case_id, parent_id, author_id, body, created_at
+		FROM risk_case_notes
+		WHERE case_id = $1
+		ORDER BY created_at, id`, caseID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	notes := []*model.RiskCaseNote{}
+	for rows.Next() {
+		n := &model.RiskCaseNote{}
+		if err := rows.Scan(&n.ID, &n.CaseID, &n.ParentID, &n.AuthorID, &n.Body, &n.CreatedAt); err != nil {
+			return nil, err
+		}
+		notes = append(notes, n)
+	}
+	return notes, rows.Err()
+}
+
+// ListEvidence 获取案件证据（按添加时间）
+func (r *RiskCaseRepo) ListEvidence(ctx context.Context, caseID int64) ([]*model.RiskCaseEvidence, error) {
+	rows, err := DB.Query(ctx, `SELECT id, case_id, evidence_type, ref_id, snapshot, remark, added_by, created_at
+		FROM risk_case_evidence
+		WHERE case_id = $1
+		ORDER BY created_at, id`, caseID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	evidence := []*model.RiskCaseEvidence{}
+	for rows.Next() {
+		ev := &model.RiskCaseEvidence{}
+		var snapshot []byte
+		if err := rows.Scan(&ev.ID, &ev.CaseID, &ev.Type, &ev.RefID, &snapshot, &ev.Remark, &ev.AddedBy, &ev.CreatedAt); err != nil {
+			return nil, err
+		}
+		ev.Snapshot = snapshot
+		evidence = append(evidence, ev)
+	}
+	return evidence, rows.Err()
+}
+
+// ListAudit 分页获取案件审计记录（新的在前）
+func (r *RiskCaseRepo) ListAudit(ctx context.Context, caseID int64, query *model.RiskCaseAuditQuery) ([]*model.RiskCaseAuditEntry, int64, error) {
+	var total int64
+	if err := DB.QueryRow(ctx, `SELECT COUNT(*) FROM risk_case_audit WHERE case_id = $1`, caseID).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := DB.Query(ctx, `SELECT id, case_id, action, operator_id, details, created_at
+		FROM risk_case_audit
+		WHERE case_id = $1
+		ORDER BY created_at DESC, id DESC
+		LIMIT $2 OFFSET $3`, caseID, query.PageSize, (query.Page-1)*query.PageSize)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	entries := []*model.RiskCaseAuditEntry{}
+	for rows.Next() {
+		e := &model.RiskCaseAuditEntry{}
+		var details []byte
+		if err := rows.Scan(&e.ID, &e.CaseID, &e.Action, &e.OperatorID, &details, &e.CreatedAt); err != nil {
+			return nil, 0, err
+		}
+		e.Details = details
+		entries = append(entries, e)
+	}
+	return entries, total, rows.Err()
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/fiveseconds/server/internal/model"
 	"github.com/jackc/pgx/v5"
@@ -20,22 +21,25 @@ func NewRiskRepo() *RiskRepo {
 
 // CreateFlag 创建风控标记
 func (r *RiskRepo) CreateFlag(ctx context.Context, flag *model.RiskFlag) error {
-	sql := `INSERT INTO risk_flags (user_id, flag_type, details, status)
-		VALUES ($1, $2, $3, $4)
+	if flag.Severity == "" {
+		flag.Severity = model.RiskSeverityMedium
+	}
+	sql := `INSERT INTO risk_flags (user_id, flag_type, details, status, severity, rule_id)
+		VALUES ($1, $2, $3, $4, $5, $6)
 		RETURNING id, created_at`
 	return DB.QueryRow(ctx, sql,
-		flag.UserID, flag.FlagType, flag.Details, flag.Status,
+		flag.UserID, flag.FlagType, flag.Details, flag.Status, flag.Severity, flag.RuleID,
 	).Scan(&flag.ID, &flag.CreatedAt)
 }
 
 // GetFlagByID 根据ID获取风控标记
 func (r *RiskRepo) GetFlagByID(ctx context.Context, id int64) (*model.RiskFlag, error) {
-	sql := `SELECT id, user_id, flag_type, details, status, reviewed_by, reviewed_at, created_at
+	sql := `SELECT id, user_id, flag_type, details, status, severity, rule_id, reviewed_by, reviewed_at, review_remark, created_at
 		FROM risk_flags WHERE id = $1`
 	flag := &model.RiskFlag{}
 	err := DB.QueryRow(ctx, sql, id).Scan(
 		&flag.ID, &flag.UserID, &flag.FlagType, &flag.Details,
-		&flag.Status, &flag.ReviewedBy, &flag.ReviewedAt, &flag.CreatedAt,
+		&flag.Status, &flag.Severity, &flag.RuleID, &flag.ReviewedBy, &flag.ReviewedAt, &flag.ReviewRemark, &flag.CreatedAt,
 	)
 	if errors.Is(err, pgx.ErrNoRows) {
 		return nil, ErrNotFound
@@ -45,38 +49,58 @@ func (r *RiskRepo) GetFlagByID(ctx context.Context, id int64) (*model.RiskFlag,
 
 // ListFlags 列表风控标记
 func (r *RiskRepo) ListFlags(ctx context.Context, query *model.RiskFlagListQuery) ([]*model.RiskFlag, int64, error) {
-	countSQL := `SELECT COUNT(*) FROM risk_flags WHERE 1=1`
-	listSQL := `SELECT id, user_id, flag_type, details, status, reviewed_by, reviewed_at, created_at
-		FROM risk_flags WHERE 1=1`
+	countSQL := `SELECT COUNT(*) FROM risk_flags f WHERE 1=1`
+	listSQL := `SELECT f.id, f.user_id, f.flag_type, f.details, f.status, f.severity, f.rule_id, f.reviewed_by, f.reviewed_at, f.review_remark, f.created_at, s.score
+		FROM risk_flags f
+		LEFT JOIN user_risk_scores s ON s.user_id = f.user_id
+		WHERE 1=1`
 
 	args := []interface{}{}
 	argIdx := 1
 
 	if query.UserID != nil {
-		countSQL += fmt.Sprintf(` AND user_id = $%d`, argIdx)
-		listSQL += fmt.Sprintf(` AND user_id = $%d`, argIdx)
+		countSQL += fmt.Sprintf(` AND f.user_id = $%d`, argIdx)
+		listSQL += fmt.Sprintf(` AND f.user_id = $%d`, argIdx)
 		args = append(args, *query.UserID)
 		argIdx++
 	}
 	if query.FlagType != nil {
-		countSQL += fmt.Sprintf(` AND flag_type = $%d`, argIdx)
-		listSQL += fmt.Sprintf(` AND flag_type = $%d`, argIdx)
+		countSQL += fmt.Sprintf(` AND f.flag_type = $%d`, argIdx)
+		listSQL += fmt.Sprintf(` AND f.flag_type = $%d`, argIdx)
 		args = append(args, *query.FlagType)
 		argIdx++
 	}
 	if query.Status != nil {
-		countSQL += fmt.Sprintf(` AND status = $%d`, argIdx)
-		listSQL += fmt.Sprintf(` AND status = $%d`, argIdx)
+		countSQL += fmt.Sprintf(` AND f.status = $%d`, argIdx)
+		listSQL += fmt.Sprintf(` AND f.status = $%d`, argIdx)
 		args = append(args, *query.Status)
 		argIdx++
 	}
+	if query.Scope != "" {
+		op := `= ANY`
+		if query.Scope == "user" {
+			op = `<> ALL`
+		}
+		countSQL += fmt.Sprintf(` AND f.flag_type %s($%d)`, op, argIdx)
+		listSQL += fmt.Sprintf(` AND f.flag_type %s($%d)`, op, argIdx)
+		ownerTypes := make([]string, len(model.OwnerRiskFlagTypes))
+		for i, t := range model.OwnerRiskFlagTypes {
+			ownerTypes[i] = string(t)
+		}
+		args = append(args, ownerTypes)
+		argIdx++
+	}
 
 	var total int64
 	if err := DB.QueryRow(ctx, countSQL, args...).Scan(&total); err != nil {
 		return nil, 0, err
 	}
 
-	listSQL += fmt.Sprintf(` ORDER BY created_at DESC LIMIT $%d OFFSET $%d`, argIdx, argIdx+1)
+	orderBy := `f.created_at DESC`
+	if query.SortBy == "risk_score" {
+		orderBy = `COALESCE(s.score, 0) DESC, f.created_at DESC`
+	}
+	listSQL += fmt.Sprintf(` ORDER BY %s, f.id DESC LIMIT $%d OFFSET $%d`, orderBy, argIdx, argIdx+1)
 	args = append(args, query.PageSize, (query.Page-1)*query.PageSize)
 
 	rows, err := DB.Query(ctx, listSQL, args...)
@@ -90,7 +114,8 @@ func (r *RiskRepo) ListFlags(ctx context.Context, query *model.RiskFlagListQuery
 		flag := &model.RiskFlag{}
 		if err := rows.Scan(
 			&flag.ID, &flag.UserID, &flag.FlagType, &flag.Details,
-			&flag.Status, &flag.ReviewedBy, &flag.ReviewedAt, &flag.CreatedAt,
+			&flag.Status, &flag.Severity, &flag.RuleID, &flag.ReviewedBy, &flag.ReviewedAt, &flag.ReviewRemark, &flag.CreatedAt,
+			&flag.RiskScore,
 		); err != nil {
 			return nil, 0, err
 		}
@@ -99,11 +124,11 @@ func (r *RiskRepo) ListFlags(ctx context.Context, query *model.RiskFlagListQuery
 	return flags, total, nil
 }
 
-// ReviewFlag 审核风控标记
-func (r *RiskRepo) ReviewFlag(ctx context.Context, id int64, status model.RiskFlagStatus, reviewedBy int64) error {
-	sql := `UPDATE risk_flags SET status = $1, reviewed_by = $2, reviewed_at = NOW()
+// ReviewFlag 审核风控标记（保存审核备注）
+func (r *RiskRepo) ReviewFlag(ctx context.Context, id int64, status model.RiskFlagStatus, reviewedBy int64, remark string) error {
+	sql := `UPDATE risk_flags SET status = $1, reviewed_by = $2, reviewed_at = NOW(), review_remark = $4
 		WHERE id = $3 AND status = 'pending'`
-	tag, err := DB.Exec(ctx, sql, status, reviewedBy, id)
+	tag, err := DB.Exec(ctx, sql, status, reviewedBy, id, remark)
 	if err != nil {
 		return err
 	}
@@ -122,6 +147,26 @@ func (r *RiskRepo) HasPendingFlag(ctx context.Context, userID int64, flagType mo
 	return exists, err
 }
 
+// HasPendingRuleFlag 检查用户是否有该规则产生的待处理标记
+// 兼容规则引擎上线前由固定检查产生的同类型标记（rule_id 为空）
+func (r *RiskRepo) HasPendingRuleFlag(ctx context.Context, userID, ruleID int64, flagType model.RiskFlagType) (bool, error) {
+	sql := `SELECT EXISTS(SELECT 1 FROM risk_flags
+		WHERE user_id = $1 AND status = 'pending' AND (rule_id = $2 OR (rule_id IS NULL AND flag_type = $3)))`
+	var exists bool
+	err := DB.QueryRow(ctx, sql, userID, ruleID, flagType).Scan(&exists)
+	return exists, err
+}
+
+// GetUserCreatedAt 获取用户注册时间
+func (r *RiskRepo) GetUserCreatedAt(ctx context.Context, userID int64) (time.Time, error) {
+	var createdAt time.Time
+	err := DB.QueryRow(ctx, `SELECT created_at FROM users WHERE id = $1`, userID).Scan(&createdAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return time.Time{}, ErrNotFound
+	}
+	return createdAt, err
+}
+
 // GetUserConsecutiveWins 获取用户连续获胜次数
 func (r *RiskRepo) GetUserConsecutiveWins(ctx context.Context, userID int64) (int, error) {
 	sql := `SELECT COALESCE(consecutive_wins, 0) FROM users WHERE id = $1`
@@ -192,6 +237,17 @@ func (r *RiskRepo) GetUsersByDeviceFingerprint(ctx context.Context, fingerprint
 	return userIDs, nil
 }
 
+// GetUserDeviceFingerprint 获取用户最近登录的设备指纹（未记录时为空）
+func (r *RiskRepo) GetUserDeviceFingerprint(ctx context.Context, userID int64) (string, error) {
+	sql := `SELECT COALESCE(device_fingerprint, '') FROM users WHERE id = $1`
+	var fingerprint string
+	err := DB.QueryRow(ctx, sql, userID).Scan(&fingerprint)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", nil
+	}
+	return fingerprint, err
+}
+
 // GetUserDailyVolume 获取用户当日交易量
 func (r *RiskRepo) GetUserDailyVolume(ctx context.Context, userID int64) (string, error) {
 	sql := `
@@ -205,6 +261,42 @@ func (r *RiskRepo) GetUserDailyVolume(ctx context.Context, userID int64) (string
 	return volume, err
 }
 
+// CountApprovedFundRequests 统计用户 since 之后审批通过的充值与提现申请笔数
+func (r *RiskRepo) CountApprovedFundRequests(ctx context.Context, userID int64, since time.Time) (deposits, withdrawals int, err error) {
+	sql := `
+		SELECT COUNT(*) FILTER (WHERE request_type IN ('deposit', 'owner_deposit')),
+			COUNT(*) FILTER (WHERE request_type IN ('withdraw', 'owner_withdraw'))
+		FROM fund_requests
+		WHERE user_id = $1 AND status = 'approved' AND updated_at >= $2
+	`
+	err = DB.QueryRow(ctx, sql, userID, since).Scan(&deposits, &withdrawals)
+	return deposits, withdrawals, err
+}
+
+// GetDepositPlaySince 统计玩家 since 之后审批通过的充值金额、最近一笔充值的通过时间，
+// 以及窗口内首笔充值之后的下注金额（含奖励余额下注）
+func (r *RiskRepo) GetDepositPlaySince(ctx context.Context, userID int64, since time.Time) (*model.FundVelocity, error) {
+	sql := `
+		WITH d AS (
+			SELECT COALESCE(SUM(amount), 0) AS amount, MIN(updated_at) AS first_at, MAX(updated_at) AS last_at
+			FROM fund_requests
+			WHERE user_id = $1 AND request_type = 'deposit' AND status = 'approved' AND updated_at >= $2
+		)
+		SELECT d.amount, d.last_at,
+			COALESCE((
+				SELECT SUM(ABS(t.amount))
+				FROM balance_transactions t
+				WHERE t.user_id = $1 AND t.tx_type IN ('game_bet', 'bonus_bet') AND t.created_at >= d.first_at
+			), 0)
+		FROM d
+	`
+	v := &model.FundVelocity{}
+	if err := DB.QueryRow(ctx, sql, userID, since).Scan(&v.DepositAmount, &v.LastDepositAt, &v.Wagered); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
 // CreateFlagWithDetails 创建带详情的风控标记
 func (r *RiskRepo) CreateFlagWithDetails(ctx context.Context, flag *model.RiskFlag, details *model.RiskFlagDetails) error {
 	detailsJSON, err := json.Marshal(details)
@@ -214,3 +306,29 @@ func (r *RiskRepo) CreateFlagWithDetails(ctx context.Context, flag *model.RiskFl
 	flag.Details = string(detailsJSON)
 	return r.CreateFlag(ctx, flag)
 }
+
+// FindTransferCycle 查找刚完成的转账 from -> to 是否构成循环转账
+// 从 to 出发沿时间窗口内已完成的转账向下游搜索，若在 maxHops 跳内回到 from 则返回循环路径（首尾均为 from）
+func (r *RiskRepo) FindTransferCycle(ctx context.Context, fromUserID, toUserID int64, since time.Time, maxHops int) ([]int64, error) {
+	sql := `
+		WITH RECURSIVE paths(node, path, depth) AS (
+			SELECT DISTINCT t.to_user_id, ARRAY[$1::BIGINT, $2::BIGINT, t.to_user_id], 1
+			FROM player_transfers t
+			WHERE t.from_user_id = $2 AND t.status = 'completed' AND t.created_at >= $3
+			UNION ALL
+			SELECT t.to_user_id, p.path || t.to_user_id, p.depth + 1
+			FROM paths p
+			JOIN player_transfers t ON t.from_user_id = p.node
+			WHERE p.node <> $1 AND p.depth < $4
+			  AND t.status = 'completed' AND t.created_at >= $3
+			  AND NOT (t.to_user_id = ANY(p.path[2:]))
+		)
+		SELECT path FROM paths WHERE node = $1 ORDER BY depth LIMIT 1
+	`
+	var path []int64
+	err := DB.QueryRow(ctx, sql, fromUserID, toUserID, since, maxHops).Scan(&path)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	return path, err
+}
@@ -0,0 +1,288 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/fiveseconds/server/internal/model"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// RiskRuleRepo 风控规则仓库
+// 规则每次创建或修改都在同一事务内写入一份版本快照
+type RiskRuleRepo struct{}
+
+// NewRiskRuleRepo 创建风控规则仓库
+func NewRiskRuleRepo() *RiskRuleRepo {
+	return &RiskRuleRepo{}
+}
+
+const riskRuleColumns = `id, name, description, event, condition, severity, action, flag_type, enabled,
+	version, updated_by, created_at, updated_at`
+
+func scanRiskRule(row pgx.Row) (*model.RiskRule, error) {
+	rule := &model.RiskRule{}
+	err := row.Scan(
+		&rule.ID, &rule.Name, &rule.Description, &rule.Event, &rule.Condition, &rule.Severity, &rule.Action,
+		&rule.FlagType, &rule.Enabled, &rule.Version, &rule.UpdatedBy, &rule.CreatedAt, &rule.UpdatedAt,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return rule, nil
+}
+
+// Create 创建风控规则（版本 1）并保存版本快照
+func (r *RiskRuleRepo) Create(ctx context.Context, rule *model.RiskRule) error {
+	return Tx(ctx, func(tx pgx.Tx) error {
+		sql := `INSERT INTO risk_rules (name, description, event, condition, severity, action, flag_type, enabled, updated_by)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+			RETURNING id, version, created_at, updated_at`
+		if err := tx.QueryRow(ctx, sql,
+			rule.Name, rule.Description, rule.Event, rule.Condition, rule.Severity, rule.Action,
+			rule.FlagType, rule.Enabled, rule.UpdatedBy,
+		).Scan(&rule.ID, &rule.Version, &rule.CreatedAt, &rule.UpdatedAt); err != nil {
+			return err
+		}
+		return r.createVersionTx(ctx, tx, rule)
+	})
+}
+
+// Update 修改风控规则（版本号加一）并保存版本快照
+func (r *RiskRuleRepo) Update(ctx context.Context, rule *model.RiskRule) error {
+	return Tx(ctx, func(tx pgx.Tx) error {
+		sql := `UPDATE risk_rules SET
+			name = $1, description = $2, event = $3, condition = $4, severity = $5, action = $6,
+			flag_type = $7, enabled = $8, updated_by = $9, version = version + 1, updated_at = NOW()
+			WHERE id = $10
+			RETURNING version, created_at, updated_at`
+		err := tx.QueryRow(ctx, sql,
+			rule.Name, rule.Description, rule.Event, rule.Condition, rule.Severity, rule.Action,
+			rule.FlagType, rule.Enabled, rule.UpdatedBy, rule.ID,
+		).Scan(&rule.Version, &rule.CreatedAt, &rule.UpdatedAt)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrNotFound
+		}
+		if err != nil {
+			return err
+		}
+		return r.createVersionTx(ctx, tx, rule)
+	})
+}
+
+// createVersionTx 保存规则当前内容的版本快照
+func (r *RiskRuleRepo) createVersionTx(ctx context.Context, tx pgx.Tx, rule *model.RiskRule) error {
+	sql := `INSERT INTO risk_rule_versions
+		(rule_id, version, name, description, event, condition, severity, action, flag_type, enabled, changed_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`
+	_, err := tx.Exec(ctx, sql,
+		rule.ID, rule.Version, rule.Name, rule.Description, rule.Event, rule.Condition, rule.Severity,
+		rule.Action, rule.FlagType, rule.Enabled, rule.UpdatedBy,
+	)
+	return err
+}
+
+// GetByID 根据ID获取风控规则
+func (r *RiskRuleRepo) GetByID(ctx context.Context, id int64) (*model.RiskRule, error) {
+	return scanRiskRule(DB.QueryRow(ctx, `SELECT `+riskRuleColumns+` FROM risk_rules WHERE id = $1`, id))
+}
+
+// NameExists 检查规则名称是否已被其他规则使用
+func (r *RiskRuleRepo) NameExists(ctx context.Context, name string, excludeID int64) (bool, error) {
+	var exists bool
+	err := DB.QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM risk_rules WHERE name = $1 AND id <> $2)`, name, excludeID).Scan(&exists)
+	return exists, err
+}
+
+// ListEnabled 获取全部已启用的风控规则
+func (r *RiskRuleRepo) ListEnabled(ctx context.Context) ([]*model.RiskRule, error) {
+	rows, err := DB.Query(ctx, `SELECT `+riskRuleColumns+` FROM risk_rules WHERE enabled ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rules []*model.RiskRule
+	for rows.Next() {
+		rule, err := scanRiskRule(rows)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	return rules, rows.Err()
+}
+
+// List 分页获取风控规则
+func (r *RiskRuleRepo) List(ctx context.Context, query *model.RiskRuleListQuery) ([]*model.RiskRule, int64, error) {
+	countSQL := `SELECT COUNT(*) FROM risk_rules WHERE 1=1`
+	listSQL := `SELECT ` + riskRuleColumns + ` FROM risk_rules WHERE 1=1`
+
+	args := []interface{}{}
+	argIdx := 1
+
+	if query.Event != nil {
+		countSQL += fmt.Sprintf(` AND event = $%d`, argIdx)
+		listSQL += fmt.Sprintf(` AND event = $%d`, argIdx)
+		args = append(args, *query.Event)
+		argIdx++
+	}
+	if query.Enabled != nil {
+		countSQL += fmt.Sprintf(` AND enabled = $%d`, argIdx)
+		listSQL += fmt.Sprintf(` AND enabled = $%d`, argIdx)
+		args = append(args, *query.Enabled)
+		argIdx++
+	}
+
+	var total int64
+	if err := DB.QueryRow(ctx, countSQL, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	listSQL += fmt.Sprintf(` ORDER BY id LIMIT $%d OFFSET $%d`, argIdx, argIdx+1)
+	args = append(args, query.PageSize, (query.Page-1)*query.PageSize)
+
+	rows, err := DB.Query(ctx, listSQL, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	rules := []*model.RiskRule{}
+	for rows.Next() {
+		rule, err := scanRiskRule(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		rules = append(rules, rule)
+	}
+	return rules, total, rows.Err()
+}
+
+const riskRuleVersionColumns = `id, rule_id, version, name, description, event, condition, severity, action,
+	flag_type, enabled, changed_by, created_at`
+
+func scanRiskRuleVersion(row pgx.Row) (*model.RiskRuleVersion, error) {
+	v := &model.RiskRuleVersion{}
+	err := row.Scan(
+		&v.ID, &v.RuleID, &v.Version, &v.Name, &v.Description, &v.Event, &v.Condition, &v.Severity, &v.Action,
+		&v.FlagType, &v.Enabled, &v.ChangedBy, &v.CreatedAt,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// ListVersions 获取规则的全部历史版本（新版本在前）
+func (r *RiskRuleRepo) ListVersions(ctx context.Context, ruleID int64) ([]*model.RiskRuleVersion, error) {
+	rows, err := DB.Query(ctx, `SELECT `+riskRuleVersionColumns+`
+		FROM risk_rule_versions WHERE rule_id = $1 ORDER BY version DESC`, ruleID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	versions := []*model.RiskRuleVersion{}
+	for rows.Next() {
+		v, err := scanRiskRuleVersion(rows)
+		if err != nil {
+			return nil, err
+		}
+		versions = append(versions, v)
+	}
+	return versions, rows.Err()
+}
+
+// GetVersion 获取规则的指定历史版本
+func (r *RiskRuleRepo) GetVersion(ctx context.Context, ruleID int64, version int) (*model.RiskRuleVersion, error) {
+	return scanRiskRuleVersion(DB.QueryRow(ctx, `SELECT `+riskRuleVersionColumns+`
+		FROM risk_rule_versions WHERE rule_id = $1 AND version = $2`, ruleID, version))
+}
+
+// ForEachSettledRound 按结算时间顺序遍历时间窗口内已结算的回合（试运行重放，逐行回调避免一次性加载）
+func (r *RiskRuleRepo) ForEachSettledRound(ctx context.Context, since, until time.Time, fn func(*model.RiskReplayRound) error) error {
+	sql := `SELECT id, participant_ids, COALESCE(winner_ids, '{}'), COALESCE(settled_at, created_at)
+		FROM game_rounds
+		WHERE status = 'settled' AND created_at >= $1 AND created_at < $2
+		ORDER BY COALESCE(settled_at, created_at), id`
+	rows, err := DB.Query(ctx, sql, since, until)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		round := &model.RiskReplayRound{}
+		if err := rows.Scan(&round.ID, &round.ParticipantIDs, &round.WinnerIDs, &round.SettledAt); err != nil {
+			return err
+		}
+		if err := fn(round); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// GetUsersCreatedAt 批量获取用户注册时间
+func (r *RiskRuleRepo) GetUsersCreatedAt(ctx context.Context, userIDs []int64) (map[int64]time.Time, error) {
+	result := make(map[int64]time.Time, len(userIDs))
+	if len(userIDs) == 0 {
+		return result, nil
+	}
+	rows, err := DB.Query(ctx, `SELECT id, created_at FROM users WHERE id = ANY($1)`, userIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int64
+		var createdAt time.Time
+		if err := rows.Scan(&id, &createdAt); err != nil {
+			return nil, err
+		}
+		result[id] = createdAt
+	}
+	return result, rows.Err()
+}
+
+// ListFundRequestsSince 获取时间窗口内审批通过的资金申请（按审批时间），附带审批后当日流水与用户注册时间（试运行重放）
+// 审批入账流水与申请状态在同一事务内写入，时间相同，当日流水包含本次申请
+func (r *RiskRuleRepo) ListFundRequestsSince(ctx context.Context, since, until time.Time) ([]*model.RiskReplayFundRequest, error) {
+	sql := `SELECT fr.id, fr.user_id, fr.request_type, fr.amount, fr.updated_at,
+			COALESCE((SELECT SUM(ABS(bt.amount)) FROM balance_transactions bt
+				WHERE bt.user_id = fr.user_id
+				  AND bt.created_at >= date_trunc('day', fr.updated_at)
+				  AND bt.created_at <= fr.updated_at), 0),
+			u.created_at
+		FROM fund_requests fr
+		JOIN users u ON u.id = fr.user_id
+		WHERE fr.status = 'approved' AND fr.updated_at >= $1 AND fr.updated_at < $2
+		ORDER BY fr.updated_at, fr.id`
+	rows, err := DB.Query(ctx, sql, since, until)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var requests []*model.RiskReplayFundRequest
+	for rows.Next() {
+		req := &model.RiskReplayFundRequest{}
+		if err := rows.Scan(&req.ID, &req.UserID, &req.Type, &req.Amount, &req.CreatedAt,
+			&req.DailyVolume, &req.AccountCreatedAt); err != nil {
+			return nil, err
+		}
+		requests = append(requests, req)
+	}
+	return requests, rows.Err()
+}
@@ -0,0 +1,163 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/fiveseconds/server/internal/model"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// RiskScoreRepo 用户风险评分仓库
+type RiskScoreRepo struct{}
+
+// NewRiskScoreRepo 创建用户风险评分仓库
+func NewRiskScoreRepo() *RiskScoreRepo {
+	return &RiskScoreRepo{}
+}
+
+// GetInput 读取用户的评分输入：flagSince 之后的风控标记，以及 since 之后的回合与资金统计
+func (r *RiskScoreRepo) GetInput(ctx context.Context, userID int64, flagSince, since time.Time) (*model.RiskScoreInput, error) {
+	in := &model.RiskScoreInput{}
+	if err := DB.QueryRow(ctx, `SELECT created_at FROM users WHERE id = $1`, userID).Scan(&in.AccountCreatedAt); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	rows, err := DB.Query(ctx, `SELECT flag_type, severity, status, created_at, reviewed_at
+		FROM risk_flags
+		WHERE user_id = $1 AND created_at >= $2`, userID, flagSince)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var f model.RiskScoreFlag
+		if err := rows.Scan(&f.FlagType, &f.Severity, &f.Status, &f.CreatedAt, &f.ReviewedAt); err != nil {
+			return nil, err
+		}
+		in.Flags = append(in.Flags, f)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// 每回合获胜概率 p = 赢家数 / 参与人数
+	err = DB.QueryRow(ctx, `SELECT COUNT(*),
+			COUNT(*) FILTER (WHERE $1 = ANY(winner_ids)),
+			COALESCE(SUM(p), 0),
+			COALESCE(SUM(p * (1 - p)), 0)
+		FROM (
+			SELECT winner_ids, cardinality(winner_ids)::float8 / cardinality(participant_ids) AS p
+			FROM game_rounds
+			WHERE $1 = ANY(participant_ids) AND status = 'settled' AND created_at >= $2
+		) r`, userID, since).Scan(&in.Rounds, &in.Wins, &in.ExpectedWins, &in.WinVariance)
+	if err != nil {
+		return nil, err
+	}
+
+	err = DB.QueryRow(ctx, `SELECT
+			COALESCE(SUM(amount) FILTER (WHERE tx_type = 'deposit'), 0),
+			COALESCE(SUM(ABS(amount)) FILTER (WHERE tx_type IN ('game_bet', 'bonus_bet')), 0)
+		FROM balance_transactions
+		WHERE user_id = $1 AND created_at >= $2`, userID, since).Scan(&in.Deposits, &in.Wagered)
+	if err != nil {
+		return nil, err
+	}
+	return in, nil
+}
+
+// Get 获取用户当前风险评分
+func (r *RiskScoreRepo) Get(ctx context.Context, userID int64) (*model.UserRiskScore, error) {
+	s := &model.UserRiskScore{}
+	var components []byte
+	err := DB.QueryRow(ctx, `SELECT user_id, score, level, components, alerted_level, computed_at
+		FROM user_risk_scores WHERE user_id = $1`, userID).
+		Scan(&s.UserID, &s.Score, &s.Level, &components, &s.AlertedLevel, &s.ComputedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(components, &s.Components); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Save 保存用户当前风险评分；withHistory 为真时同时写入评分历史
+func (r *RiskScoreRepo) Save(ctx context.Context, s *model.UserRiskScore, trigger model.RiskScoreTrigger, withHistory bool) error {
+	components, err := json.Marshal(s.Components)
+	if err != nil {
+		return err
+	}
+	return Tx(ctx, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, `INSERT INTO user_risk_scores (user_id, score, level, components, alerted_level, computed_at)
+			VALUES ($1, $2, $3, $4, $5, $6)
+			ON CONFLICT (user_id) DO UPDATE SET
+				score = EXCLUDED.score, level = EXCLUDED.level, components = EXCLUDED.components,
+				alerted_level = EXCLUDED.alerted_level, computed_at = EXCLUDED.computed_at`,
+			s.UserID, s.Score, s.Level, components, s.AlertedLevel, s.ComputedAt); err != nil {
+			return err
+		}
+		if !withHistory {
+			return nil
+		}
+		_, err := tx.Exec(ctx, `INSERT INTO risk_score_history (user_id, score, level, components, trigger_event, created_at)
+			VALUES ($1, $2, $3, $4, $5, $6)`,
+			s.UserID, s.Score, s.Level, components, trigger, s.ComputedAt)
+		return err
+	})
+}
+
+// ListHistory 获取用户最近的评分历史（新的在前）
+func (r *RiskScoreRepo) ListHistory(ctx context.Context, userID int64, limit int) ([]*model.RiskScoreHistory, error) {
+	rows, err := DB.Query(ctx, `SELECT id, user_id, score, level, components, trigger_event, created_at
+		FROM risk_score_history
+		WHERE user_id = $1
+		ORDER BY created_at DESC, id DESC
+		LIMIT $2`, userID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	history := []*model.RiskScoreHistory{}
+	for rows.Next() {
+		h := &model.RiskScoreHistory{}
+		var components []byte
+		if err := rows.Scan(&h.ID, &h.UserID, &h.Score, &h.Level, &components, &h.Trigger, &h.CreatedAt); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(components, &h.Components); err != nil {
+			return nil, err
+		}
+		history = append(history, h)
+	}
+	return history, rows.Err()
+}
+
+// ListScoredUserIDs 获取评分大于 0 的用户（定时重算使分值随时间衰减）
+func (r *RiskScoreRepo) ListScoredUserIDs(ctx context.Context) ([]int64, error) {
+	rows, err := DB.Query(ctx, `SELECT user_id FROM user_risk_scores WHERE score > 0 ORDER BY user_id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	userIDs := []int64{}
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		userIDs = append(userIDs, id)
+	}
+	return userIDs, rows.Err()
+}
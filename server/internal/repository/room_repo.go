@@ -19,23 +19,23 @@ func NewRoomRepo() *RoomRepo {
 // Create 创建房间
 func (r *RoomRepo) Create(ctx context.Context, room *model.Room) error {
 	sql := `INSERT INTO rooms (owner_id, name, code, bet_amount, winner_count, max_players,
-		owner_commission, platform_commission, status, password)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		owner_commission, platform_commission, status, password, currency, room_type, tournament_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
 		RETURNING id, created_at, updated_at`
 	return DB.QueryRow(ctx, sql,
 		room.OwnerID, room.Name, room.InviteCode, room.BetAmount, room.WinnerCount, room.MaxPlayers,
-		room.OwnerCommissionRate, room.PlatformCommissionRate, room.Status, room.Password,
+		room.OwnerCommissionRate, room.PlatformCommissionRate, room.Status, room.Password, room.Currency, room.RoomType, room.TournamentID,
 	).Scan(&room.ID, &room.CreatedAt, &room.UpdatedAt)
 }
 
 // GetByID 根据ID获取房间
 func (r *RoomRepo) GetByID(ctx context.Context, id int64) (*model.Room, error) {
-	sql := `SELECT id, owner_id, name, code, bet_amount, winner_count, max_players,
+	sql := `SELECT id, owner_id, name, code, room_type, tournament_id, bet_amount, currency, winner_count, max_players,
 		owner_commission, platform_commission, status, password, created_at, updated_at
 		FROM rooms WHERE id = $1`
 	room := &model.Room{}
 	err := DB.QueryRow(ctx, sql, id).Scan(
-		&room.ID, &room.OwnerID, &room.Name, &room.InviteCode, &room.BetAmount, &room.WinnerCount, &room.MaxPlayers,
+		&room.ID, &room.OwnerID, &room.Name, &room.InviteCode, &room.RoomType, &room.TournamentID, &room.BetAmount, &room.Currency, &room.WinnerCount, &room.MaxPlayers,
 		&room.OwnerCommissionRate, &room.PlatformCommissionRate, &room.Status, &room.Password, &room.CreatedAt, &room.UpdatedAt,
 	)
 	if errors.Is(err, pgx.ErrNoRows) {
@@ -46,12 +46,12 @@ func (r *RoomRepo) GetByID(ctx context.Context, id int64) (*model.Room, error) {
 
 // GetByInviteCode 根据邀请码获取房间
 func (r *RoomRepo) GetByInviteCode(ctx context.Context, code string) (*model.Room, error) {
-	sql := `SELECT id, owner_id, name, code, bet_amount, winner_count, max_players,
+	sql := `SELECT id, owner_id, name, code, room_type, tournament_id, bet_amount, currency, winner_count, max_players,
 		owner_commission, platform_commission, status, created_at, updated_at
 		FROM rooms WHERE code = $1`
 	room := &model.Room{}
 	err := DB.QueryRow(ctx, sql, code).Scan(
-		&room.ID, &room.OwnerID, &room.Name, &room.InviteCode, &room.BetAmount, &room.WinnerCount, &room.MaxPlayers,
+		&room.ID, &room.OwnerID, &room.Name, &room.InviteCode, &room.RoomType, &room.TournamentID, &room.BetAmount, &room.Currency, &room.WinnerCount, &room.MaxPlayers,
 		&room.OwnerCommissionRate, &room.PlatformCommissionRate, &room.Status, &room.CreatedAt, &room.UpdatedAt,
 	)
 	if errors.Is(err, pgx.ErrNoRows) {
@@ -62,7 +62,7 @@ func (r *RoomRepo) GetByInviteCode(ctx context.Context, code string) (*model.Roo
 
 // ListByOwner 获取房主的房间列表
 func (r *RoomRepo) ListByOwner(ctx context.Context, ownerID int64) ([]*model.Room, error) {
-	sql := `SELECT id, owner_id, name, code, bet_amount, winner_count, max_players,
+	sql := `SELECT id, owner_id, name, code, room_type, tournament_id, bet_amount, currency, winner_count, max_players,
 		owner_commission, platform_commission, status, created_at, updated_at
 		FROM rooms WHERE owner_id = $1 ORDER BY created_at DESC`
 	rows, err := DB.Query(ctx, sql, ownerID)
@@ -75,7 +75,32 @@ func (r *RoomRepo) ListByOwner(ctx context.Context, ownerID int64) ([]*model.Roo
 	for rows.Next() {
 		room := &model.Room{}
 		if err := rows.Scan(
-			&room.ID, &room.OwnerID, &room.Name, &room.InviteCode, &room.BetAmount, &room.WinnerCount, &room.MaxPlayers,
+			&room.ID, &room.OwnerID, &room.Name, &room.InviteCode, &room.RoomType, &room.TournamentID, &room.BetAmount, &room.Currency, &room.WinnerCount, &room.MaxPlayers,
+			&room.OwnerCommissionRate, &room.PlatformCommissionRate, &room.Status, &room.CreatedAt, &room.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		rooms = append(rooms, room)
+	}
+	return rooms, nil
+}
+
+// ListByTournament 获取锦标赛的房间列表
+func (r *RoomRepo) ListByTournament(ctx context.Context, tournamentID int64) ([]*model.Room, error) {
+	sql := `SELECT id, owner_id, name, code, room_type, tournament_id, bet_amount, currency, winner_count, max_players,
+		owner_commission, platform_commission, status, created_at, updated_at
+		FROM rooms WHERE tournament_id = $1 ORDER BY id`
+	rows, err := DB.Query(ctx, sql, tournamentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rooms []*model.Room
+	for rows.Next() {
+		room := &model.Room{}
+		if err := rows.Scan(
+			&room.ID, &room.OwnerID, &room.Name, &room.InviteCode, &room.RoomType, &room.TournamentID, &room.BetAmount, &room.Currency, &room.WinnerCount, &room.MaxPlayers,
 			&room.OwnerCommissionRate, &room.PlatformCommissionRate, &room.Status, &room.CreatedAt, &room.UpdatedAt,
 		); err != nil {
 			return nil, err
@@ -88,7 +113,7 @@ func (r *RoomRepo) ListByOwner(ctx context.Context, ownerID int64) ([]*model.Roo
 // List 分页列表
 func (r *RoomRepo) List(ctx context.Context, query *model.RoomListQuery) ([]*model.Room, int64, error) {
 	countSQL := `SELECT COUNT(*) FROM rooms WHERE 1=1`
-	listSQL := `SELECT r.id, r.owner_id, r.name, r.code, r.bet_amount, r.winner_count, r.max_players,
+	listSQL := `SELECT r.id, r.owner_id, r.name, r.code, r.room_type, r.tournament_id, r.bet_amount, r.currency, r.winner_count, r.max_players,
 		r.owner_commission, r.platform_commission, r.status, r.password, r.created_at, r.updated_at,
 		COALESCE(u.username, '') as owner_name
 		FROM rooms r LEFT JOIN users u ON r.owner_id = u.id WHERE 1=1`
@@ -117,6 +142,13 @@ func (r *RoomRepo) List(ctx context.Context, query *model.RoomListQuery) ([]*mod
 		argIdx++
 	}
 
+	if query.RoomType != nil {
+		countSQL += fmt.Sprintf(` AND room_type = $%d`, argIdx)
+		listSQL += fmt.Sprintf(` AND r.room_type = $%d`, argIdx)
+		args = append(args, *query.RoomType)
+		argIdx++
+	}
+
 	var total int64
 	if err := DB.QueryRow(ctx, countSQL, args...).Scan(&total); err != nil {
 		return nil, 0, err
@@ -136,7 +168,7 @@ func (r *RoomRepo) List(ctx context.Context, query *model.RoomListQuery) ([]*mod
 		room := &model.Room{}
 		var ownerName string
 		if err := rows.Scan(
-			&room.ID, &room.OwnerID, &room.Name, &room.InviteCode, &room.BetAmount, &room.WinnerCount, &room.MaxPlayers,
+			&room.ID, &room.OwnerID, &room.Name, &room.InviteCode, &room.RoomType, &room.TournamentID, &room.BetAmount, &room.Currency, &room.WinnerCount, &room.MaxPlayers,
 			&room.OwnerCommissionRate, &room.PlatformCommissionRate, &room.Status, &room.Password, &room.CreatedAt, &room.UpdatedAt,
 			&ownerName,
 		); err != nil {
@@ -0,0 +1,193 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/fiveseconds/server/internal/model"
+	"github.com/jackc/pgx/v5"
+)
+
+var ErrSettlementExists = errors.New("settlement already exists for this period")
+
+// SettlementRepo 房主佣金结算仓库
+type SettlementRepo struct{}
+
+// NewSettlementRepo 创建房主佣金结算仓库
+func NewSettlementRepo() *SettlementRepo {
+	return &SettlementRepo{}
+}
+
+// AggregateRoomEarnings 汇总房主各房间在周期内已结算回合的佣金与平台抽成 [start, end)
+// 以 game_rounds.settled_at 归属周期
+func (r *SettlementRepo) AggregateRoomEarnings(ctx context.Context, ownerID int64, start, end time.Time) ([]*model.OwnerSettlementItem, error) {
+	sql := `SELECT gr.room_id, COUNT(*),
+			COALESCE(SUM(gr.pool_amount), 0),
+			COALESCE(SUM(gr.owner_earning), 0),
+			COALESCE(SUM(gr.platform_earning), 0)
+		FROM game_rounds gr
+		JOIN rooms r ON gr.room_id = r.id
+		WHERE r.owner_id = $1 AND gr.status = 'settled'
+			AND gr.settled_at >= $2 AND gr.settled_at < $3
+		GROUP BY gr.room_id
+		ORDER BY gr.room_id`
+	rows, err := DB.Query(ctx, sql, ownerID, start, end)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []*model.OwnerSettlementItem
+	for rows.Next() {
+		item := &model.OwnerSettlementItem{}
+		if err := rows.Scan(&item.RoomID, &item.RoundCount, &item.PoolAmount, &item.OwnerEarning, &item.PlatformEarning); err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+// ListOwnersToSettle 获取周期内有已结算回合、且尚未生成该周期结算单的房主
+func (r *SettlementRepo) ListOwnersToSettle(ctx context.Context, periodType model.SettlementPeriodType, start, end time.Time) ([]int64, error) {
+	sql := `SELECT DISTINCT r.owner_id
+		FROM game_rounds gr
+		JOIN rooms r ON gr.room_id = r.id
+		WHERE gr.status = 'settled' AND gr.settled_at >= $1 AND gr.settled_at < $2
+			AND NOT EXISTS (
+				SELECT 1 FROM owner_settlements s
+				WHERE s.owner_id = r.owner_id AND s.period_type = $3 AND s.period_start = $1
+			)
+		ORDER BY r.owner_id`
+	rows, err := DB.Query(ctx, sql, start, end, periodType)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ownerIDs []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ownerIDs = append(ownerIDs, id)
+	}
+	return ownerIDs, rows.Err()
+}
+
+// CreateTx 创建结算单及明细（支持事务）
+// 同一房主同一周期已存在结算单时返回 ErrSettlementExists
+func (r *SettlementRepo) CreateTx(ctx context.Context, tx pgx.Tx, s *model.OwnerSettlement) error {
+	exec := GetExecutor(tx)
+	sql := `INSERT INTO owner_settlements (owner_id, period_type, period_start, period_end, round_count,
+			pool_amount, owner_earning, platform_earning, transferred_amount)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT (owner_id, period_type, period_start) DO NOTHING
+		RETURNING id, created_at`
+	err := exec.QueryRow(ctx, sql,
+		s.OwnerID, s.PeriodType, s.PeriodStart, s.PeriodEnd, s.RoundCount,
+		s.PoolAmount, s.OwnerEarning, s.PlatformEarning, s.TransferredAmount,
+	).Scan(&s.ID, &s.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrSettlementExists
+	}
+	if err != nil {
+		return err
+	}
+
+	itemSQL := `INSERT INTO owner_settlement_items (settlement_id, room_id, round_count, pool_amount, owner_earning, platform_earning)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id`
+	for _, item := range s.Items {
+		item.SettlementID = s.ID
+		if err := exec.QueryRow(ctx, itemSQL,
+			item.SettlementID, item.RoomID, item.RoundCount, item.PoolAmount, item.OwnerEarning, item.PlatformEarning,
+		).Scan(&item.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetByID 根据ID获取结算单（含明细）
+func (r *SettlementRepo) GetByID(ctx context.Context, id int64) (*model.OwnerSettlement, error) {
+	sql := `SELECT id, owner_id, period_type, period_start, period_end, round_count,
+			pool_amount, owner_earning, platform_earning, transferred_amount, created_at
+		FROM owner_settlements WHERE id = $1`
+	s := &model.OwnerSettlement{}
+	err := DB.QueryRow(ctx, sql, id).Scan(
+		&s.ID, &s.OwnerID, &s.PeriodType, &s.PeriodStart, &s.PeriodEnd, &s.RoundCount,
+		&s.PoolAmount, &s.OwnerEarning, &s.PlatformEarning, &s.TransferredAmount, &s.CreatedAt,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	itemSQL := `SELECT id, settlement_id, room_id, round_count, pool_amount, owner_earning, platform_earning
+		FROM owner_settlement_items WHERE settlement_id = $1 ORDER BY room_id`
+	rows, err := DB.Query(ctx, itemSQL, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		item := &model.OwnerSettlementItem{}
+		if err := rows.Scan(&item.ID, &item.SettlementID, &item.RoomID, &item.RoundCount,
+			&item.PoolAmount, &item.OwnerEarning, &item.PlatformEarning); err != nil {
+			return nil, err
+		}
+		s.Items = append(s.Items, item)
+	}
+	return s, rows.Err()
+}
+
+// List 分页获取结算单（不含明细）
+func (r *SettlementRepo) List(ctx context.Context, query *model.SettlementListQuery) ([]*model.OwnerSettlement, int64, error) {
+	countSQL := `SELECT COUNT(*) FROM owner_settlements WHERE 1=1`
+	listSQL := `SELECT id, owner_id, period_type, period_start, period_end, round_count,
+			pool_amount, owner_earning, platform_earning, transferred_amount, created_at
+		FROM owner_settlements WHERE 1=1`
+
+	args := []interface{}{}
+	argIdx := 1
+
+	if query.OwnerID != nil {
+		countSQL += fmt.Sprintf(` AND owner_id = $%d`, argIdx)
+		listSQL += fmt.Sprintf(` AND owner_id = $%d`, argIdx)
+		args = append(args, *query.OwnerID)
+		argIdx++
+	}
+
+	var total int64
+	if err := DB.QueryRow(ctx, countSQL, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	listSQL += fmt.Sprintf(` ORDER BY period_start DESC, id DESC LIMIT $%d OFFSET $%d`, argIdx, argIdx+1)
+	args = append(args, query.PageSize, (query.Page-1)*query.PageSize)
+
+	rows, err := DB.Query(ctx, listSQL, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var settlements []*model.OwnerSettlement
+	for rows.Next() {
+		s := &model.OwnerSettlement{}
+		if err := rows.Scan(
+			&s.ID, &s.OwnerID, &s.PeriodType, &s.PeriodStart, &s.PeriodEnd, &s.RoundCount,
+			&s.PoolAmount, &s.OwnerEarning, &s.PlatformEarning, &s.TransferredAmount, &s.CreatedAt,
+		); err != nil {
+			return nil, 0, err
+		}
+		settlements = append(settlements, s)
+	}
+	return settlements, total, nil
+}
@@ -0,0 +1,180 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/fiveseconds/server/internal/model"
+	"github.com/jackc/pgx/v5"
+	"github.com/shopspring/decimal"
+)
+
+// StatementRepo 账单仓库
+// 负责读取账单周期内的交易流水，以及读写 statement_jobs 表
+type StatementRepo struct{}
+
+// NewStatementRepo 创建账单仓库
+func NewStatementRepo() *StatementRepo {
+	return &StatementRepo{}
+}
+
+// CountTransactions 统计周期内的交易笔数 [start, end)
+func (r *StatementRepo) CountTransactions(ctx context.Context, userID int64, start, end time.Time) (int, error) {
+	sql := `SELECT COUNT(*) FROM balance_transactions WHERE user_id = $1 AND created_at >= $2 AND created_at < $3`
+	var count int
+	err := DB.QueryRow(ctx, sql, userID, start, end).Scan(&count)
+	return count, err
+}
+
+// ListTransactions 获取周期内的全部交易流水（按时间升序）[start, end)
+func (r *StatementRepo) ListTransactions(ctx context.Context, userID int64, start, end time.Time) ([]*model.BalanceTransaction, error) {
+	sql := `SELECT id, user_id, room_id, round_id, tx_type, amount, balance_before, balance_after, balance_field, currency, remark, created_at
+		FROM balance_transactions
+		WHERE user_id = $1 AND created_at >= $2 AND created_at < $3
+		ORDER BY created_at, id`
+	rows, err := DB.Query(ctx, sql, userID, start, end)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var txs []*model.BalanceTransaction
+	for rows.Next() {
+		tx := &model.BalanceTransaction{}
+		if err := rows.Scan(
+			&tx.ID, &tx.UserID, &tx.RoomID, &tx.RoundID, &tx.Type, &tx.Amount,
+			&tx.BalanceBefore, &tx.BalanceAfter, &tx.BalanceField, &tx.Currency, &tx.Remark, &tx.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		txs = append(txs, tx)
+	}
+	return txs, rows.Err()
+}
+
+// GetOpeningBalances 获取各余额账户在 start 时刻的余额
+// 优先取 start 之前最后一笔流水的变动后余额，否则取 start 之后第一笔流水的变动前余额；
+// 两者都没有的账户不在结果中（调用方使用当前余额）
+func (r *StatementRepo) GetOpeningBalances(ctx context.Context, userID int64, start time.Time) (map[string]decimal.Decimal, error) {
+	sql := `SELECT DISTINCT ON (balance_field) balance_field, opening FROM (
+			SELECT balance_field, opening, priority FROM (
+				SELECT DISTINCT ON (balance_field) balance_field, balance_after AS opening, 0 AS priority
+				FROM balance_transactions WHERE user_id = $1 AND created_at < $2
+				ORDER BY balance_field, created_at DESC, id DESC
+			) before_start
+			UNION ALL
+			SELECT balance_field, opening, priority FROM (
+				SELECT DISTINCT ON (balance_field) balance_field, balance_before AS opening, 1 AS priority
+				FROM balance_transactions WHERE user_id = $1 AND created_at >= $2
+				ORDER BY balance_field, created_at, id
+			) after_start
+		) candidates
+		ORDER BY balance_field, priority`
+	rows, err := DB.Query(ctx, sql, userID, start)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make(map[string]decimal.Decimal)
+	for rows.Next() {
+		var field string
+		var opening decimal.Decimal
+		if err := rows.Scan(&field, &opening); err != nil {
+			return nil, err
+		}
+		result[field] = opening
+	}
+	return result, rows.Err()
+}
+
+// CreateJob 创建账单导出任务
+func (r *StatementRepo) CreateJob(ctx context.Context, job *model.StatementJob) error {
+	sql := `INSERT INTO statement_jobs (user_id, format, period_start, period_end, status)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at`
+	return DB.QueryRow(ctx, sql, job.UserID, job.Format, job.PeriodStart, job.PeriodEnd, job.Status).Scan(&job.ID, &job.CreatedAt)
+}
+
+// GetJob 根据ID获取账单导出任务
+func (r *StatementRepo) GetJob(ctx context.Context, id int64) (*model.StatementJob, error) {
+	sql := `SELECT id, user_id, format, period_start, period_end, status, file_path, row_count, error_message, created_at, completed_at
+		FROM statement_jobs WHERE id = $1`
+	job := &model.StatementJob{}
+	err := DB.QueryRow(ctx, sql, id).Scan(
+		&job.ID, &job.UserID, &job.Format, &job.PeriodStart, &job.PeriodEnd, &job.Status,
+		&job.FilePath, &job.RowCount, &job.ErrorMessage, &job.CreatedAt, &job.CompletedAt,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return job, err
+}
+
+// ListJobs 获取用户最近的账单导出任务
+func (r *StatementRepo) ListJobs(ctx context.Context, userID int64, limit int) ([]*model.StatementJob, error) {
+	sql := `SELECT id, user_id, format, period_start, period_end, status, file_path, row_count, error_message, created_at, completed_at
+		FROM statement_jobs WHERE user_id = $1 ORDER BY created_at DESC LIMIT $2`
+	rows, err := DB.Query(ctx, sql, userID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var jobs []*model.StatementJob
+	for rows.Next() {
+		job := &model.StatementJob{}
+		if err := rows.Scan(
+			&job.ID, &job.UserID, &job.Format, &job.PeriodStart, &job.PeriodEnd, &job.Status,
+			&job.FilePath, &job.RowCount, &job.ErrorMessage, &job.CreatedAt, &job.CompletedAt,
+		); err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, rows.Err()
+}
+
+// MarkJobRunning 标记任务开始执行
+func (r *StatementRepo) MarkJobRunning(ctx context.Context, id int64) error {
+	_, err := DB.Exec(ctx, `UPDATE statement_jobs SET status = 'running' WHERE id = $1 AND status = 'pending'`, id)
+	return err
+}
+
+// CompleteJob 标记任务完成
+func (r *StatementRepo) CompleteJob(ctx context.Context, id int64, filePath string, rowCount int) error {
+	sql := `UPDATE statement_jobs SET status = 'completed', file_path = $1, row_count = $2, completed_at = NOW() WHERE id = $3`
+	_, err := DB.Exec(ctx, sql, filePath, rowCount, id)
+	return err
+}
+
+// FailJob 标记任务失败
+func (r *StatementRepo) FailJob(ctx context.Context, id int64, errMsg string) error {
+	sql := `UPDATE statement_jobs SET status = 'failed', error_message = $1, completed_at = NOW() WHERE id = $2`
+	_, err := DB.Exec(ctx, sql, errMsg, id)
+	return err
+}
+
+// RequeueUnfinishedJobs 服务重启后将未完成的任务重置为待执行并返回，由调用方重新执行
+// 任务参数保存在 statement_jobs 表中，执行中断不会丢失任务
+func (r *StatementRepo) RequeueUnfinishedJobs(ctx context.Context) ([]*model.StatementJob, error) {
+	sql := `UPDATE statement_jobs SET status = 'pending', file_path = NULL, row_count = NULL
+		WHERE status IN ('pending', 'running')
+		RETURNING id, user_id, format, period_start, period_end, status, created_at`
+	rows, err := DB.Query(ctx, sql)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var jobs []*model.StatementJob
+	for rows.Next() {
+		job := &model.StatementJob{}
+		if err := rows.Scan(&job.ID, &job.UserID, &job.Format, &job.PeriodStart, &job.PeriodEnd, &job.Status, &job.CreatedAt); err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, rows.Err()
+}
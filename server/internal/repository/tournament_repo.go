@@ -0,0 +1,375 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/fiveseconds/server/internal/model"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/shopspring/decimal"
+)
+
+// TournamentRepo 锦标赛仓库
+// 锦标赛筹码只记录在 tournament_entries，变动不写 balance_transactions；
+// 报名费、退款与奖金由服务层通过钱包与交易流水入账
+type TournamentRepo struct{}
+
+// NewTournamentRepo 创建锦标赛仓库
+func NewTournamentRepo() *TournamentRepo {
+	return &TournamentRepo{}
+}
+
+const tournamentColumns = `id, owner_id, name, currency, buy_in, starting_chips, max_rounds, rounds_started, rounds_played,
+	starts_at, ends_at, status, prize_pool, pool_balance, created_at, updated_at, finished_at`
+
+func scanTournament(row pgx.Row) (*model.Tournament, error) {
+	t := &model.Tournament{}
+	err := row.Scan(
+		&t.ID, &t.OwnerID, &t.Name, &t.Currency, &t.BuyIn, &t.StartingChips, &t.MaxRounds, &t.RoundsStarted, &t.RoundsPlayed,
+		&t.StartsAt, &t.EndsAt, &t.Status, &t.PrizePool, &t.PoolBalance, &t.CreatedAt, &t.UpdatedAt, &t.FinishedAt,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// CreateTx 创建锦标赛及奖励分配表（支持事务）
+func (r *TournamentRepo) CreateTx(ctx context.Context, tx pgx.Tx, t *model.Tournament) error {
+	exec := GetExecutor(tx)
+	sql := `INSERT INTO tournaments (owner_id, name, currency, buy_in, starting_chips, max_rounds, starts_at, ends_at, status)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING id, created_at, updated_at`
+	if err := exec.QueryRow(ctx, sql,
+		t.OwnerID, t.Name, t.Currency, t.BuyIn, t.StartingChips, t.MaxRounds, t.StartsAt, t.EndsAt, t.Status,
+	).Scan(&t.ID, &t.CreatedAt, &t.UpdatedAt); err != nil {
+		return err
+	}
+
+	payoutSQL := `INSERT INTO tournament_payouts (tournament_id, rank_no, share) VALUES ($1, $2, $3)`
+	for _, p := range t.Payouts {
+		if _, err := exec.Exec(ctx, payoutSQL, t.ID, p.Rank, p.Share); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetByID 根据ID获取锦标赛（含奖励分配表与报名人数）
+func (r *TournamentRepo) GetByID(ctx context.Context, id int64) (*model.Tournament, error) {
+	t, err := scanTournament(DB.QueryRow(ctx, `SELECT `+tournamentColumns+` FROM tournaments WHERE id = $1`, id))
+	if err != nil {
+		return nil, err
+	}
+	if t.Payouts, err = r.GetPayoutsTx(ctx, nil, id); err != nil {
+		return nil, err
+	}
+	err = DB.QueryRow(ctx, `SELECT COUNT(*) FROM tournament_entries WHERE tournament_id = $1 AND status <> 'refunded'`,
+		id).Scan(&t.EntrantCount)
+	return t, err
+}
+
+// GetForUpdateTx 锁定锦标赛（串行化报名、回合扣筹码与结束派奖）
+func (r *TournamentRepo) GetForUpdateTx(ctx context.Context, tx pgx.Tx, id int64) (*model.Tournament, error) {
+	return scanTournament(tx.QueryRow(ctx, `SELECT `+tournamentColumns+` FROM tournaments WHERE id = $1 FOR UPDATE`, id))
+}
+
+// GetPayoutsTx 获取奖励分配表（按名次升序，支持事务）
+func (r *TournamentRepo) GetPayoutsTx(ctx context.Context, tx pgx.Tx, id int64) ([]*model.TournamentPayoutShare, error) {
+	rows, err := GetExecutor(tx).Query(ctx,
+		`SELECT rank_no, share FROM tournament_payouts WHERE tournament_id = $1 ORDER BY rank_no`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	payouts := []*model.TournamentPayoutShare{}
+	for rows.Next() {
+		p := &model.TournamentPayoutShare{}
+		if err := rows.Scan(&p.Rank, &p.Share); err != nil {
+			return nil, err
+		}
+		payouts = append(payouts, p)
+	}
+	return payouts, rows.Err()
+}
+
+// List 分页获取锦标赛列表（不含奖励分配表）
+func (r *TournamentRepo) List(ctx context.Context, query *model.TournamentListQuery) ([]*model.Tournament, int64, error) {
+	countSQL := `SELECT COUNT(*) FROM tournaments WHERE 1=1`
+	listSQL := `SELECT ` + tournamentColumns + `,
+		(SELECT COUNT(*) FROM tournament_entries e WHERE e.tournament_id = tournaments.id AND e.status <> 'refunded')
+		FROM tournaments WHERE 1=1`
+
+	args := []interface{}{}
+	argIdx := 1
+
+	if query.OwnerID != nil {
+		countSQL += fmt.Sprintf(` AND owner_id = $%d`, argIdx)
+		listSQL += fmt.Sprintf(` AND owner_id = $%d`, argIdx)
+		args = append(args, *query.OwnerID)
+		argIdx++
+	}
+
+	if query.Status != nil {
+		countSQL += fmt.Sprintf(` AND status = $%d`, argIdx)
+		listSQL += fmt.Sprintf(` AND status = $%d`, argIdx)
+		args = append(args, *query.Status)
+		argIdx++
+	}
+
+	var total int64
+	if err := DB.QueryRow(ctx, countSQL, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	listSQL += fmt.Sprintf(` ORDER BY starts_at DESC, id DESC LIMIT $%d OFFSET $%d`, argIdx, argIdx+1)
+	args = append(args, query.PageSize, (query.Page-1)*query.PageSize)
+
+	rows, err := DB.Query(ctx, listSQL, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var tournaments []*model.Tournament
+	for rows.Next() {
+		t := &model.Tournament{}
+		if err := rows.Scan(
+			&t.ID, &t.OwnerID, &t.Name, &t.Currency, &t.BuyIn, &t.StartingChips, &t.MaxRounds, &t.RoundsStarted, &t.RoundsPlayed,
+			&t.StartsAt, &t.EndsAt, &t.Status, &t.PrizePool, &t.PoolBalance, &t.CreatedAt, &t.UpdatedAt, &t.FinishedAt,
+			&t.EntrantCount,
+		); err != nil {
+			return nil, 0, err
+		}
+		tournaments = append(tournaments, t)
+	}
+	return tournaments, total, nil
+}
+
+// StartDue 将已到开始时间的锦标赛置为进行中，返回被开始的锦标赛ID
+func (r *TournamentRepo) StartDue(ctx context.Context) ([]int64, error) {
+	rows, err := DB.Query(ctx, `UPDATE tournaments SET status = 'running', updated_at = NOW()
+		WHERE status = 'scheduled' AND starts_at <= NOW()
+		RETURNING id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// ListRunning 获取所有进行中的锦标赛
+func (r *TournamentRepo) ListRunning(ctx context.Context) ([]*model.Tournament, error) {
+	rows, err := DB.Query(ctx, `SELECT `+tournamentColumns+` FROM tournaments WHERE status = 'running' ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tournaments []*model.Tournament
+	for rows.Next() {
+		t, err := scanTournament(rows)
+		if err != nil {
+			return nil, err
+		}
+		tournaments = append(tournaments, t)
+	}
+	return tournaments, rows.Err()
+}
+
+// AddRoundsTx 调整已开始与已结算的回合数（支持事务）
+func (r *TournamentRepo) AddRoundsTx(ctx context.Context, tx pgx.Tx, id int64, started, played int) error {
+	_, err := GetExecutor(tx).Exec(ctx, `UPDATE tournaments
+		SET rounds_started = GREATEST(rounds_started + $2, 0), rounds_played = rounds_played + $3, updated_at = NOW()
+		WHERE id = $1`, id, started, played)
+	return err
+}
+
+// AddPoolTx 报名费计入奖池（支持事务）
+func (r *TournamentRepo) AddPoolTx(ctx context.Context, tx pgx.Tx, id int64, amount decimal.Decimal) error {
+	_, err := GetExecutor(tx).Exec(ctx, `UPDATE tournaments
+		SET prize_pool = prize_pool + $2, pool_balance = pool_balance + $2, updated_at = NOW()
+		WHERE id = $1`, id, amount)
+	return err
+}
+
+// CloseTx 结束或取消锦标赛，并从奖池余额中扣除已派发（退回）的金额（支持事务）
+func (r *TournamentRepo) CloseTx(ctx context.Context, tx pgx.Tx, id int64, status model.TournamentStatus, paid decimal.Decimal) error {
+	_, err := GetExecutor(tx).Exec(ctx, `UPDATE tournaments
+		SET status = $2, pool_balance = pool_balance - $3, finished_at = NOW(), updated_at = NOW()
+		WHERE id = $1`, id, status, paid)
+	return err
+}
+
+// CreateEntryTx 创建报名记录（支持事务），已报名时返回 false
+func (r *TournamentRepo) CreateEntryTx(ctx context.Context, tx pgx.Tx, e *model.TournamentEntry) (bool, error) {
+	sql := `INSERT INTO tournament_entries (tournament_id, user_id, chips, buy_in, status)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (tournament_id, user_id) DO NOTHING
+		RETURNING joined_at`
+	err := GetExecutor(tx).QueryRow(ctx, sql, e.TournamentID, e.UserID, e.Chips, e.BuyIn, e.Status).Scan(&e.JoinedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// GetEntry 获取玩家报名记录
+func (r *TournamentRepo) GetEntry(ctx context.Context, tournamentID, userID int64) (*model.TournamentEntry, error) {
+	sql := `SELECT tournament_id, user_id, chips, buy_in, status, final_rank, prize, joined_at
+		FROM tournament_entries WHERE tournament_id = $1 AND user_id = $2`
+	e := &model.TournamentEntry{}
+	err := DB.QueryRow(ctx, sql, tournamentID, userID).Scan(
+		&e.TournamentID, &e.UserID, &e.Chips, &e.BuyIn, &e.Status, &e.FinalRank, &e.Prize, &e.JoinedAt,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return e, err
+}
+
+// ListEntriesTx 获取报名记录，按排名顺序（筹码降序，同筹码先报名者在前，支持事务）
+func (r *TournamentRepo) ListEntriesTx(ctx context.Context, tx pgx.Tx, tournamentID int64) ([]*model.TournamentEntry, error) {
+	sql := `SELECT e.tournament_id, e.user_id, COALESCE(u.username, ''), e.chips, e.buy_in, e.status, e.final_rank, e.prize, e.joined_at
+		FROM tournament_entries e
+		LEFT JOIN users u ON e.user_id = u.id
+		WHERE e.tournament_id = $1
+		ORDER BY e.chips DESC, e.joined_at, e.user_id`
+	rows, err := GetExecutor(tx).Query(ctx, sql, tournamentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []*model.TournamentEntry
+	for rows.Next() {
+		e := &model.TournamentEntry{}
+		if err := rows.Scan(&e.TournamentID, &e.UserID, &e.Username, &e.Chips, &e.BuyIn, &e.Status,
+			&e.FinalRank, &e.Prize, &e.JoinedAt); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+// UpdateEntryResultTx 更新报名记录的最终状态、名次与奖金（支持事务）
+func (r *TournamentRepo) UpdateEntryResultTx(ctx context.Context, tx pgx.Tx, e *model.TournamentEntry) error {
+	_, err := GetExecutor(tx).Exec(ctx, `UPDATE tournament_entries
+		SET status = $3, final_rank = $4, prize = $5, updated_at = NOW()
+		WHERE tournament_id = $1 AND user_id = $2`,
+		e.TournamentID, e.UserID, e.Status, e.FinalRank, e.Prize)
+	return err
+}
+
+// BatchDeductChipsTx 批量扣除下注筹码（单条 SQL），只有有效报名且筹码足够的玩家才会被扣除
+// 扣除的筹码记入在途下注（staked），直至回合结算或退回；返回 key 为用户ID、value 为扣除后筹码的 map
+func (r *TournamentRepo) BatchDeductChipsTx(ctx context.Context, tx pgx.Tx, tournamentID int64, userIDs []int64, amount decimal.Decimal) (map[int64]decimal.Decimal, error) {
+	if len(userIDs) == 0 {
+		return nil, nil
+	}
+
+	sql := `UPDATE tournament_entries SET chips = chips - $1, staked = staked + $1, updated_at = NOW()
+		WHERE tournament_id = $2 AND user_id = ANY($3) AND status = 'active' AND chips >= $1
+		RETURNING user_id, chips`
+	rows, err := GetExecutor(tx).Query(ctx, sql, amount, tournamentID, userIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanPracticeChips(rows)
+}
+
+// BatchAddChipsTx 批量增加筹码（单条 SQL，用于派奖），只更新有效报名
+// 返回 key 为用户ID、value 为增加后筹码的 map
+func (r *TournamentRepo) BatchAddChipsTx(ctx context.Context, tx pgx.Tx, tournamentID int64, amounts map[int64]decimal.Decimal) (map[int64]decimal.Decimal, error) {
+	if len(amounts) == 0 {
+		return nil, nil
+	}
+
+	userIDs := make([]int64, 0, len(amounts))
+	values := make([]string, 0, len(amounts))
+	for userID, amount := range amounts {
+		userIDs = append(userIDs, userID)
+		values = append(values, amount.String())
+	}
+
+	sql := `UPDATE tournament_entries e SET chips = e.chips + v.amount, updated_at = NOW()
+		FROM unnest($2::BIGINT[], $3::NUMERIC[]) AS v(user_id, amount)
+		WHERE e.tournament_id = $1 AND e.user_id = v.user_id AND e.status = 'active'
+		RETURNING e.user_id, e.chips`
+	rows, err := GetExecutor(tx).Query(ctx, sql, tournamentID, userIDs, values)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanPracticeChips(rows)
+}
+
+// RefundStakesTx 批量退回在途下注筹码（单条 SQL），每人最多退回 amount 且不超过其在途下注
+// 锦标赛已结束（报名记录不再有效）时不退回；返回 key 为用户ID、value 为退回后筹码的 map
+func (r *TournamentRepo) RefundStakesTx(ctx context.Context, tx pgx.Tx, tournamentID int64, userIDs []int64, amount decimal.Decimal) (map[int64]decimal.Decimal, error) {
+	if len(userIDs) == 0 {
+		return nil, nil
+	}
+
+	sql := `UPDATE tournament_entries
+		SET chips = chips + LEAST(staked, $1), staked = staked - LEAST(staked, $1), updated_at = NOW()
+		WHERE tournament_id = $2 AND user_id = ANY($3) AND status = 'active'
+		RETURNING user_id, chips`
+	rows, err := GetExecutor(tx).Query(ctx, sql, amount, tournamentID, userIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanPracticeChips(rows)
+}
+
+// ReleaseStakesTx 回合结算后扣减参与者的在途下注筹码（支持事务）
+func (r *TournamentRepo) ReleaseStakesTx(ctx context.Context, tx pgx.Tx, tournamentID int64, userIDs []int64, amount decimal.Decimal) error {
+	if len(userIDs) == 0 {
+		return nil
+	}
+	_, err := GetExecutor(tx).Exec(ctx, `UPDATE tournament_entries
+		SET staked = GREATEST(staked - $1, 0), updated_at = NOW()
+		WHERE tournament_id = $2 AND user_id = ANY($3)`, amount, tournamentID, userIDs)
+	return err
+}
+
+// RefundAllStakesTx 退回全部在途下注筹码（锦标赛结束时仍未结算的回合，支持事务），返回退回的总筹码
+func (r *TournamentRepo) RefundAllStakesTx(ctx context.Context, tx pgx.Tx, tournamentID int64) (decimal.Decimal, error) {
+	var total decimal.Decimal
+	err := GetExecutor(tx).QueryRow(ctx, `WITH pending AS (
+			SELECT user_id, staked FROM tournament_entries
+			WHERE tournament_id = $1 AND status = 'active' AND staked > 0
+			FOR UPDATE
+		), refunded AS (
+			UPDATE tournament_entries e SET chips = e.chips + p.staked, staked = 0, updated_at = NOW()
+			FROM pending p
+			WHERE e.tournament_id = $1 AND e.user_id = p.user_id
+			RETURNING p.staked
+		)
+		SELECT COALESCE(SUM(staked), 0) FROM refunded`, tournamentID).Scan(&total)
+	return total, err
+}
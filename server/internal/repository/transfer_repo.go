@@ -0,0 +1,167 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/fiveseconds/server/internal/model"
+	"github.com/jackc/pgx/v5"
+	"github.com/shopspring/decimal"
+)
+
+// TransferRepo 玩家转账仓库
+type TransferRepo struct{}
+
+// NewTransferRepo 创建玩家转账仓库
+func NewTransferRepo() *TransferRepo {
+	return &TransferRepo{}
+}
+
+// GetSettings 获取房主转账设置（未设置时返回默认关闭的设置）
+func (r *TransferRepo) GetSettings(ctx context.Context, ownerID int64) (*model.OwnerTransferSettings, error) {
+	sql := `SELECT owner_id, enabled, per_transfer_limit, daily_limit, require_approval, updated_at
+		FROM owner_transfer_settings WHERE owner_id = $1`
+	s := &model.OwnerTransferSettings{}
+	err := DB.QueryRow(ctx, sql, ownerID).Scan(
+		&s.OwnerID, &s.Enabled, &s.PerTransferLimit, &s.DailyLimit, &s.RequireApproval, &s.UpdatedAt,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return &model.OwnerTransferSettings{OwnerID: ownerID}, nil
+	}
+	return s, err
+}
+
+// UpsertSettings 保存房主转账设置
+func (r *TransferRepo) UpsertSettings(ctx context.Context, s *model.OwnerTransferSettings) error {
+	sql := `INSERT INTO owner_transfer_settings (owner_id, enabled, per_transfer_limit, daily_limit, require_approval, updated_at)
+		VALUES ($1, $2, $3, $4, $5, NOW())
+		ON CONFLICT (owner_id) DO UPDATE SET
+			enabled = EXCLUDED.enabled,
+			per_transfer_limit = EXCLUDED.per_transfer_limit,
+			daily_limit = EXCLUDED.daily_limit,
+			require_approval = EXCLUDED.require_approval,
+			updated_at = NOW()
+		RETURNING updated_at`
+	return DB.QueryRow(ctx, sql,
+		s.OwnerID, s.Enabled, s.PerTransferLimit, s.DailyLimit, s.RequireApproval,
+	).Scan(&s.UpdatedAt)
+}
+
+// CreateTx 创建转账记录(支持事务)
+func (r *TransferRepo) CreateTx(ctx context.Context, tx pgx.Tx, t *model.PlayerTransfer) error {
+	sql := `INSERT INTO player_transfers (owner_id, from_user_id, to_user_id, amount, currency, status, remark, operator_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id, created_at, updated_at`
+	exec := GetExecutor(tx)
+	return exec.QueryRow(ctx, sql,
+		t.OwnerID, t.FromUserID, t.ToUserID, t.Amount, t.Currency, t.Status, t.Remark, t.OperatorID,
+	).Scan(&t.ID, &t.CreatedAt, &t.UpdatedAt)
+}
+
+// GetByID 根据ID获取转账记录
+func (r *TransferRepo) GetByID(ctx context.Context, id int64) (*model.PlayerTransfer, error) {
+	sql := `SELECT id, owner_id, from_user_id, to_user_id, amount, currency, status, remark, operator_id, created_at, updated_at
+		FROM player_transfers WHERE id = $1`
+	t := &model.PlayerTransfer{}
+	err := DB.QueryRow(ctx, sql, id).Scan(
+		&t.ID, &t.OwnerID, &t.FromUserID, &t.ToUserID, &t.Amount, &t.Currency, &t.Status, &t.Remark, &t.OperatorID, &t.CreatedAt, &t.UpdatedAt,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return t, err
+}
+
+// UpdateStatusTx 处理待审批转账(支持事务)，仅 pending 状态可处理
+func (r *TransferRepo) UpdateStatusTx(ctx context.Context, tx pgx.Tx, id int64, status model.TransferStatus, operatorID int64, remark string) error {
+	sql := `UPDATE player_transfers SET status = $1, operator_id = $2, remark = COALESCE(remark, '') || $3, updated_at = NOW()
+		WHERE id = $4 AND status = 'pending'`
+	exec := GetExecutor(tx)
+	tag, err := exec.Exec(ctx, sql, status, operatorID, remark, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return errors.New("transfer not found or already processed")
+	}
+	return nil
+}
+
+// GetOutgoingAmountSince 统计玩家自某时刻起的转出总额（含待审批，用于每日限额）
+func (r *TransferRepo) GetOutgoingAmountSince(ctx context.Context, userID int64, since time.Time) (decimal.Decimal, error) {
+	return r.GetOutgoingAmountSinceTx(ctx, nil, userID, since)
+}
+
+// GetOutgoingAmountSinceTx 统计玩家自某时刻起的转出总额(支持事务)
+// 调用方须先锁定转出方用户行，同一玩家的并发转账才会串行校验每日限额
+func (r *TransferRepo) GetOutgoingAmountSinceTx(ctx context.Context, tx pgx.Tx, userID int64, since time.Time) (decimal.Decimal, error) {
+	sql := `SELECT COALESCE(SUM(amount), 0) FROM player_transfers
+		WHERE from_user_id = $1 AND status IN ('pending', 'completed') AND created_at >= $2`
+	var total decimal.Decimal
+	err := GetExecutor(tx).QueryRow(ctx, sql, userID, since).Scan(&total)
+	return total, err
+}
+
+// List 分页获取转账记录
+func (r *TransferRepo) List(ctx context.Context, query *model.TransferListQuery) ([]*model.PlayerTransfer, int64, error) {
+	countSQL := `SELECT COUNT(*) FROM player_transfers t WHERE 1=1`
+	listSQL := `SELECT t.id, t.owner_id, t.from_user_id, COALESCE(fu.username, ''), t.to_user_id, COALESCE(tu.username, ''),
+		t.amount, t.currency, t.status, t.remark, t.operator_id, t.created_at, t.updated_at
+		FROM player_transfers t
+		LEFT JOIN users fu ON t.from_user_id = fu.id
+		LEFT JOIN users tu ON t.to_user_id = tu.id
+		WHERE 1=1`
+
+	args := []interface{}{}
+	argIdx := 1
+
+	if query.UserID != nil {
+		countSQL += fmt.Sprintf(` AND (t.from_user_id = $%d OR t.to_user_id = $%d)`, argIdx, argIdx)
+		listSQL += fmt.Sprintf(` AND (t.from_user_id = $%d OR t.to_user_id = $%d)`, argIdx, argIdx)
+		args = append(args, *query.UserID)
+		argIdx++
+	}
+
+	if query.OwnerID != nil {
+		countSQL += fmt.Sprintf(` AND t.owner_id = $%d`, argIdx)
+		listSQL += fmt.Sprintf(` AND t.owner_id = $%d`, argIdx)
+		args = append(args, *query.OwnerID)
+		argIdx++
+	}
+
+	if query.Status != nil {
+		countSQL += fmt.Sprintf(` AND t.status = $%d`, argIdx)
+		listSQL += fmt.Sprintf(` AND t.status = $%d`, argIdx)
+		args = append(args, *query.Status)
+		argIdx++
+	}
+
+	var total int64
+	if err := DB.QueryRow(ctx, countSQL, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	listSQL += fmt.Sprintf(` ORDER BY t.created_at DESC LIMIT $%d OFFSET $%d`, argIdx, argIdx+1)
+	args = append(args, query.PageSize, (query.Page-1)*query.PageSize)
+
+	rows, err := DB.Query(ctx, listSQL, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var transfers []*model.PlayerTransfer
+	for rows.Next() {
+		t := &model.PlayerTransfer{}
+		if err := rows.Scan(
+			&t.ID, &t.OwnerID, &t.FromUserID, &t.FromUsername, &t.ToUserID, &t.ToUsername,
+			&t.Amount, &t.Currency, &t.Status, &t.Remark, &t.OperatorID, &t.CreatedAt, &t.UpdatedAt,
+		); err != nil {
+			return nil, 0, err
+		}
+		transfers = append(transfers, t)
+	}
+	return transfers, total, nil
+}
@@ -26,25 +26,30 @@ func NewUserRepo() *UserRepo {
 
 // Create 创建用户
 func (r *UserRepo) Create(ctx context.Context, user *model.User) error {
+	return r.CreateTx(ctx, nil, user)
+}
+
+// CreateTx 创建用户(支持事务)
+func (r *UserRepo) CreateTx(ctx context.Context, tx pgx.Tx, user *model.User) error {
 	sql := `INSERT INTO users (username, password_hash, role, invite_code, invited_by, balance, frozen_balance,
-		owner_room_balance, owner_margin_balance)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		owner_room_balance, owner_margin_balance, currency)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
 		RETURNING id, created_at, updated_at`
-	return DB.QueryRow(ctx, sql,
+	return GetExecutor(tx).QueryRow(ctx, sql,
 		user.Username, user.PasswordHash, user.Role, user.InviteCode, user.InvitedBy,
-		user.Balance, user.FrozenBalance, user.OwnerRoomBalance, user.OwnerMarginBalance,
+		user.Balance, user.FrozenBalance, user.OwnerRoomBalance, user.OwnerMarginBalance, user.Currency,
 	).Scan(&user.ID, &user.CreatedAt, &user.UpdatedAt)
 }
 
 // GetByID 根据ID获取用户
 func (r *UserRepo) GetByID(ctx context.Context, id int64) (*model.User, error) {
 	sql := `SELECT id, username, password_hash, role, invite_code, invited_by, balance, frozen_balance, balance_version,
-		owner_room_balance, owner_margin_balance, created_at, updated_at FROM users WHERE id = $1`
+		owner_room_balance, owner_margin_balance, bonus_balance, currency, created_at, updated_at FROM users WHERE id = $1`
 	user := &model.User{}
 	err := DB.QueryRow(ctx, sql, id).Scan(
 		&user.ID, &user.Username, &user.PasswordHash, &user.Role, &user.InviteCode, &user.InvitedBy,
 		&user.Balance, &user.FrozenBalance, &user.BalanceVersion, &user.OwnerRoomBalance, &user.OwnerMarginBalance,
-		&user.CreatedAt, &user.UpdatedAt,
+		&user.BonusBalance, &user.Currency, &user.CreatedAt, &user.UpdatedAt,
 	)
 	if errors.Is(err, pgx.ErrNoRows) {
 		return nil, ErrNotFound
@@ -55,12 +60,12 @@ func (r *UserRepo) GetByID(ctx context.Context, id int64) (*model.User, error) {
 // GetByUsername 根据用户名获取用户
 func (r *UserRepo) GetByUsername(ctx context.Context, username string) (*model.User, error) {
 	sql := `SELECT id, username, password_hash, role, invite_code, invited_by, balance, frozen_balance, balance_version,
-		owner_room_balance, owner_margin_balance, created_at, updated_at FROM users WHERE username = $1`
+		owner_room_balance, owner_margin_balance, bonus_balance, currency, created_at, updated_at FROM users WHERE username = $1`
 	user := &model.User{}
 	err := DB.QueryRow(ctx, sql, username).Scan(
 		&user.ID, &user.Username, &user.PasswordHash, &user.Role, &user.InviteCode, &user.InvitedBy,
 		&user.Balance, &user.FrozenBalance, &user.BalanceVersion, &user.OwnerRoomBalance, &user.OwnerMarginBalance,
-		&user.CreatedAt, &user.UpdatedAt,
+		&user.BonusBalance, &user.Currency, &user.CreatedAt, &user.UpdatedAt,
 	)
 	if errors.Is(err, pgx.ErrNoRows) {
 		return nil, ErrNotFound
@@ -71,12 +76,12 @@ func (r *UserRepo) GetByUsername(ctx context.Context, username string) (*model.U
 // GetByInviteCodeAllRoles 根据邀请码获取用户（不分角色）
 func (r *UserRepo) GetByInviteCodeAllRoles(ctx context.Context, code string) (*model.User, error) {
 	sql := `SELECT id, username, password_hash, role, invite_code, invited_by, balance, frozen_balance, balance_version,
-		owner_room_balance, owner_margin_balance, created_at, updated_at FROM users WHERE invite_code = $1`
+		owner_room_balance, owner_margin_balance, currency, created_at, updated_at FROM users WHERE invite_code = $1`
 	user := &model.User{}
 	err := DB.QueryRow(ctx, sql, code).Scan(
 		&user.ID, &user.Username, &user.PasswordHash, &user.Role, &user.InviteCode, &user.InvitedBy,
 		&user.Balance, &user.FrozenBalance, &user.BalanceVersion, &user.OwnerRoomBalance, &user.OwnerMarginBalance,
-		&user.CreatedAt, &user.UpdatedAt,
+		&user.Currency, &user.CreatedAt, &user.UpdatedAt,
 	)
 	if errors.Is(err, pgx.ErrNoRows) {
 		return nil, ErrNotFound
@@ -87,12 +92,12 @@ func (r *UserRepo) GetByInviteCodeAllRoles(ctx context.Context, code string) (*m
 // GetByInviteCode 根据邀请码获取房主
 func (r *UserRepo) GetByInviteCode(ctx context.Context, code string) (*model.User, error) {
 	sql := `SELECT id, username, password_hash, role, invite_code, invited_by, balance, frozen_balance, balance_version,
-		owner_room_balance, owner_margin_balance, created_at, updated_at FROM users WHERE invite_code = $1 AND role = 'owner'`
+		owner_room_balance, owner_margin_balance, currency, created_at, updated_at FROM users WHERE invite_code = $1 AND role = 'owner'`
 	user := &model.User{}
 	err := DB.QueryRow(ctx, sql, code).Scan(
 		&user.ID, &user.Username, &user.PasswordHash, &user.Role, &user.InviteCode, &user.InvitedBy,
 		&user.Balance, &user.FrozenBalance, &user.BalanceVersion, &user.OwnerRoomBalance, &user.OwnerMarginBalance,
-		&user.CreatedAt, &user.UpdatedAt,
+		&user.Currency, &user.CreatedAt, &user.UpdatedAt,
 	)
 	if errors.Is(err, pgx.ErrNoRows) {
 		return nil, ErrNotFound
@@ -102,23 +107,26 @@ func (r *UserRepo) GetByInviteCode(ctx context.Context, code string) (*model.Use
 
 // List 分页列表
 func (r *UserRepo) List(ctx context.Context, query *model.UserListQuery) ([]*model.User, int64, error) {
-	countSQL := `SELECT COUNT(*) FROM users WHERE 1=1`
-	listSQL := `SELECT id, username, password_hash, role, invite_code, invited_by, balance, frozen_balance, balance_version,
-		owner_room_balance, owner_margin_balance, created_at, updated_at FROM users WHERE 1=1`
+	countSQL := `SELECT COUNT(*) FROM users u WHERE 1=1`
+	listSQL := `SELECT u.id, u.username, u.password_hash, u.role, u.invite_code, u.invited_by, u.balance, u.frozen_balance, u.balance_version,
+		u.owner_room_balance, u.owner_margin_balance, u.currency, u.created_at, u.updated_at, s.score, s.level
+		FROM users u
+		LEFT JOIN user_risk_scores s ON s.user_id = u.id
+		WHERE 1=1`
 
 	args := []interface{}{}
 	argIdx := 1
 
 	if query.Role != nil {
-		countSQL += ` AND role = $` + string(rune('0'+argIdx))
-		listSQL += ` AND role = $` + string(rune('0'+argIdx))
+		countSQL += ` AND u.role = $` + string(rune('0'+argIdx))
+		listSQL += ` AND u.role = $` + string(rune('0'+argIdx))
 		args = append(args, *query.Role)
 		argIdx++
 	}
 
 	if query.Search != nil && *query.Search != "" {
-		countSQL += ` AND username ILIKE $` + string(rune('0'+argIdx))
-		listSQL += ` AND username ILIKE $` + string(rune('0'+argIdx))
+		countSQL += ` AND u.username ILIKE $` + string(rune('0'+argIdx))
+		listSQL += ` AND u.username ILIKE $` + string(rune('0'+argIdx))
 		args = append(args, "%"+*query.Search+"%")
 		argIdx++
 	}
@@ -128,7 +136,12 @@ func (r *UserRepo) List(ctx context.Context, query *model.UserListQuery) ([]*mod
 		return nil, 0, err
 	}
 
-	listSQL += ` ORDER BY created_at DESC LIMIT $` + string(rune('0'+argIdx)) + ` OFFSET $` + string(rune('0'+argIdx+1))
+	if query.SortBy == "risk_score" {
+		listSQL += ` ORDER BY COALESCE(s.score, 0) DESC, u.created_at DESC`
+	} else {
+		listSQL += ` ORDER BY u.created_at DESC`
+	}
+	listSQL += ` LIMIT $` + string(rune('0'+argIdx)) + ` OFFSET $` + string(rune('0'+argIdx+1))
 	args = append(args, query.PageSize, (query.Page-1)*query.PageSize)
 
 	rows, err := DB.Query(ctx, listSQL, args...)
@@ -143,7 +156,7 @@ func (r *UserRepo) List(ctx context.Context, query *model.UserListQuery) ([]*mod
 		if err := rows.Scan(
 			&user.ID, &user.Username, &user.PasswordHash, &user.Role, &user.InviteCode, &user.InvitedBy,
 			&user.Balance, &user.FrozenBalance, &user.BalanceVersion, &user.OwnerRoomBalance, &user.OwnerMarginBalance,
-			&user.CreatedAt, &user.UpdatedAt,
+			&user.Currency, &user.CreatedAt, &user.UpdatedAt, &user.RiskScore, &user.RiskLevel,
 		); err != nil {
 			return nil, 0, err
 		}
@@ -267,6 +280,16 @@ func (r *UserRepo) InviteCodeExists(ctx context.Context, code string) (bool, err
 	return exists, err
 }
 
+// SetInviteCodeIfEmpty 为尚无邀请码的用户设置邀请码（已有邀请码时返回 false）
+func (r *UserRepo) SetInviteCodeIfEmpty(ctx context.Context, userID int64, code string) (bool, error) {
+	sql := `UPDATE users SET invite_code = $1, updated_at = NOW() WHERE id = $2 AND invite_code IS NULL`
+	tag, err := DB.Exec(ctx, sql, code, userID)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
 // UpdateBalanceWithVersion 使用乐观锁更新余额
 // 返回新余额和新版本号
 func (r *UserRepo) UpdateBalanceWithVersion(ctx context.Context, userID int64, delta decimal.Decimal, expectedVersion int64) (decimal.Decimal, int64, error) {
@@ -315,6 +338,36 @@ func (r *UserRepo) GetBalanceVersion(ctx context.Context, userID int64) (int64,
 	return version, err
 }
 
+// BalanceSnapshot 余额快照（余额与版本号）
+type BalanceSnapshot struct {
+	Balance decimal.Decimal
+	Version int64
+}
+
+// GetBalanceSnapshots 批量获取用户余额与版本号
+func (r *UserRepo) GetBalanceSnapshots(ctx context.Context, userIDs []int64) (map[int64]BalanceSnapshot, error) {
+	snapshots := make(map[int64]BalanceSnapshot, len(userIDs))
+	if len(userIDs) == 0 {
+		return snapshots, nil
+	}
+	sql := `SELECT id, balance, balance_version FROM users WHERE id = ANY($1)`
+	rows, err := DB.Query(ctx, sql, userIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int64
+		var snap BalanceSnapshot
+		if err := rows.Scan(&id, &snap.Balance, &snap.Version); err != nil {
+			return nil, err
+		}
+		snapshots[id] = snap
+	}
+	return snapshots, rows.Err()
+}
+
 // UpdateDeviceFingerprint 更新用户设备指纹
 func (r *UserRepo) UpdateDeviceFingerprint(ctx context.Context, userID int64, fingerprint string) error {
 	sql := `UPDATE users SET device_fingerprint = $1, updated_at = NOW() WHERE id = $2`
@@ -401,6 +454,7 @@ type BatchAddBalanceResult struct {
 
 // BatchAddBalanceTx 批量加款（单条 SQL）
 // amounts 是一个 map，key 是用户ID，value 是要增加的金额
+// 变动后余额为负的用户不更新，也不在返回结果中
 func (r *UserRepo) BatchAddBalanceTx(ctx context.Context, tx pgx.Tx, amounts map[int64]decimal.Decimal) ([]BatchAddBalanceResult, error) {
 	if len(amounts) == 0 {
 		return nil, nil
@@ -421,13 +475,14 @@ func (r *UserRepo) BatchAddBalanceTx(ctx context.Context, tx pgx.Tx, amounts map
 		argIdx += 2
 	}
 
+	delta := fmt.Sprintf("CASE id %s END", strings.Join(caseStmts, " "))
 	sql := fmt.Sprintf(`UPDATE users 
-		SET balance = balance + CASE id %s END,
+		SET balance = balance + %s,
 		    balance_version = balance_version + 1,
 		    updated_at = NOW()
-		WHERE id = ANY($%d)
+		WHERE id = ANY($%d) AND balance + %s >= 0
 		RETURNING id, balance`, 
-		strings.Join(caseStmts, " "), argIdx)
+		delta, argIdx, delta)
 	args = append(args, userIDs)
 
 	exec := GetExecutor(tx)
@@ -448,3 +503,192 @@ func (r *UserRepo) BatchAddBalanceTx(ctx context.Context, tx pgx.Tx, amounts map
 
 	return results, rows.Err()
 }
+
+// BatchDeductStakeResult 批量扣除下注结果（真实余额优先，不足部分使用奖励余额）
+type BatchDeductStakeResult struct {
+	UserID          int64
+	NewBalance      decimal.Decimal
+	NewBonusBalance decimal.Decimal
+	BonusPart       decimal.Decimal // 下注中由奖励余额支付的部分
+}
+
+// BatchDeductStakeTx 批量扣除下注金额（单条 SQL）
+// 先扣真实余额，不足部分扣奖励余额；只有真实余额 + 奖励余额足够的用户才会被扣款
+func (r *UserRepo) BatchDeductStakeTx(ctx context.Context, tx pgx.Tx, userIDs []int64, amount decimal.Decimal) ([]BatchDeductStakeResult, error) {
+	if len(userIDs) == 0 {
+		return nil, nil
+	}
+
+	sql := `WITH old AS (
+			SELECT id, balance, bonus_balance FROM users
+			WHERE id = ANY($2) AND balance + bonus_balance >= $1
+			FOR UPDATE
+		)
+		UPDATE users u
+		SET balance = u.balance - LEAST(old.balance, $1),
+		    bonus_balance = u.bonus_balance - ($1 - LEAST(old.balance, $1)),
+		    balance_version = u.balance_version + 1,
+		    updated_at = NOW()
+		FROM old
+		WHERE u.id = old.id
+		RETURNING u.id, u.balance, u.bonus_balance, $1 - LEAST(old.balance, $1)`
+
+	exec := GetExecutor(tx)
+	rows, err := exec.Query(ctx, sql, amount, userIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []BatchDeductStakeResult
+	for rows.Next() {
+		var r BatchDeductStakeResult
+		if err := rows.Scan(&r.UserID, &r.NewBalance, &r.NewBonusBalance, &r.BonusPart); err != nil {
+			return nil, err
+		}
+		results = append(results, r)
+	}
+
+	return results, rows.Err()
+}
+
+// BatchAddBonusBalanceTx 批量增加奖励余额（单条 SQL）
+// 返回 key 为用户ID、value 为新奖励余额的 map
+func (r *UserRepo) BatchAddBonusBalanceTx(ctx context.Context, tx pgx.Tx, amounts map[int64]decimal.Decimal) (map[int64]decimal.Decimal, error) {
+	if len(amounts) == 0 {
+		return nil, nil
+	}
+
+	userIDs := make([]int64, 0, len(amounts))
+	caseStmts := make([]string, 0, len(amounts))
+	args := []interface{}{}
+	argIdx := 1
+
+	for userID, amount := range amounts {
+		userIDs = append(userIDs, userID)
+		caseStmts = append(caseStmts, fmt.Sprintf("WHEN $%d THEN $%d::NUMERIC", argIdx, argIdx+1))
+		args = append(args, userID, amount.String())
+		argIdx += 2
+	}
+
+	sql := fmt.Sprintf(`UPDATE users
+		SET bonus_balance = bonus_balance + CASE id %s END,
+		    updated_at = NOW()
+		WHERE id = ANY($%d)
+		RETURNING id, bonus_balance`,
+		strings.Join(caseStmts, " "), argIdx)
+	args = append(args, userIDs)
+
+	exec := GetExecutor(tx)
+	rows, err := exec.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := make(map[int64]decimal.Decimal, len(amounts))
+	for rows.Next() {
+		var userID int64
+		var bonus decimal.Decimal
+		if err := rows.Scan(&userID, &bonus); err != nil {
+			return nil, err
+		}
+		results[userID] = bonus
+	}
+
+	return results, rows.Err()
+}
+
+// UpdateBonusBalanceTx 更新奖励余额（支持事务，余额不能为负），返回新的奖励余额
+func (r *UserRepo) UpdateBonusBalanceTx(ctx context.Context, tx pgx.Tx, userID int64, delta decimal.Decimal) (decimal.Decimal, error) {
+	sql := `UPDATE users SET bonus_balance = bonus_balance + $1, updated_at = NOW()
+		WHERE id = $2 AND bonus_balance + $1 >= 0
+		RETURNING bonus_balance`
+	var bonus decimal.Decimal
+	err := GetExecutor(tx).QueryRow(ctx, sql, delta, userID).Scan(&bonus)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return bonus, ErrInsufficientBalance
+	}
+	return bonus, err
+}
+
+// ConvertBonusBalanceTx 将用户全部奖励余额转为真实余额（支持事务）
+// 返回转换金额及转换后的真实余额
+func (r *UserRepo) ConvertBonusBalanceTx(ctx context.Context, tx pgx.Tx, userID int64) (decimal.Decimal, decimal.Decimal, error) {
+	sql := `WITH old AS (
+			SELECT id, bonus_balance FROM users WHERE id = $1 FOR UPDATE
+		)
+		UPDATE users u
+		SET balance = u.balance + old.bonus_balance,
+		    bonus_balance = 0,
+		    balance_version = u.balance_version + 1,
+		    updated_at = NOW()
+		FROM old
+		WHERE u.id = old.id
+		RETURNING old.bonus_balance, u.balance`
+	var amount, newBalance decimal.Decimal
+	err := GetExecutor(tx).QueryRow(ctx, sql, userID).Scan(&amount, &newBalance)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return amount, newBalance, ErrNotFound
+	}
+	return amount, newBalance, err
+}
+
+// GetBonusBalanceForUpdateTx 获取并锁定用户奖励余额（支持事务）
+func (r *UserRepo) GetBonusBalanceForUpdateTx(ctx context.Context, tx pgx.Tx, userID int64) (decimal.Decimal, error) {
+	var bonus decimal.Decimal
+	err := GetExecutor(tx).QueryRow(ctx, `SELECT bonus_balance FROM users WHERE id = $1 FOR UPDATE`, userID).Scan(&bonus)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return bonus, ErrNotFound
+	}
+	return bonus, err
+}
+
+// GetOwnerMarginForUpdateTx 锁定用户行并获取房主保证金余额（须在事务中调用）
+func (r *UserRepo) GetOwnerMarginForUpdateTx(ctx context.Context, tx pgx.Tx, userID int64) (decimal.Decimal, error) {
+	var margin decimal.Decimal
+	err := GetExecutor(tx).QueryRow(ctx, `SELECT owner_margin_balance FROM users WHERE id = $1 FOR UPDATE`, userID).Scan(&margin)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return decimal.Zero, ErrNotFound
+	}
+	return margin, err
+}
+
+// GetOwnerRoomBalanceForUpdateTx 获取并锁定房主佣金余额（支持事务）
+func (r *UserRepo) GetOwnerRoomBalanceForUpdateTx(ctx context.Context, tx pgx.Tx, userID int64) (decimal.Decimal, error) {
+	var balance decimal.Decimal
+	err := GetExecutor(tx).QueryRow(ctx, `SELECT owner_room_balance FROM users WHERE id = $1 FOR UPDATE`, userID).Scan(&balance)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return balance, ErrNotFound
+	}
+	return balance, err
+}
+
+// RecordLoginIP 记录用户登录 IP（同一 IP 累计登录次数并刷新最近出现时间）
+func (r *UserRepo) RecordLoginIP(ctx context.Context, userID int64, ip string) error {
+	sql := `INSERT INTO user_login_ips (user_id, ip) VALUES ($1, $2)
+		ON CONFLICT (user_id, ip) DO UPDATE SET
+			login_count = user_login_ips.login_count + 1,
+			last_seen_at = NOW()`
+	_, err := DB.Exec(ctx, sql, userID, ip)
+	return err
+}
+
+// ListIDsByRole 获取指定角色的全部用户ID
+func (r *UserRepo) ListIDsByRole(ctx context.Context, role model.UserRole) ([]int64, error) {
+	rows, err := DB.Query(ctx, `SELECT id FROM users WHERE role = $1 ORDER BY id`, role)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
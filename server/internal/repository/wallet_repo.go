@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/fiveseconds/server/internal/model"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/shopspring/decimal"
+)
+
+// WalletRepo 多币种钱包
+// 活跃币种余额存放在 users.balance，非活跃币种余额存放在 user_wallets
+type WalletRepo struct{}
+
+func NewWalletRepo() *WalletRepo {
+	return &WalletRepo{}
+}
+
+// GetActiveForUpdateTx 获取并锁定用户活跃钱包（支持事务）
+func (r *WalletRepo) GetActiveForUpdateTx(ctx context.Context, tx pgx.Tx, userID int64) (*model.CurrencyWallet, error) {
+	sql := `SELECT currency, balance, frozen_balance, bonus_balance FROM users WHERE id = $1 FOR UPDATE`
+	w := &model.CurrencyWallet{Active: true}
+	err := GetExecutor(tx).QueryRow(ctx, sql, userID).Scan(&w.Currency, &w.Balance, &w.FrozenBalance, &w.BonusBalance)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return w, err
+}
+
+// AdjustTx 调整用户指定币种钱包余额（支持事务，余额不能为负）
+// 币种为活跃币种时更新 users.balance，否则更新 user_wallets（入账时自动创建钱包）
+// 返回调整后的余额及该币种是否为活跃币种
+func (r *WalletRepo) AdjustTx(ctx context.Context, tx pgx.Tx, userID int64, currency string, delta decimal.Decimal) (decimal.Decimal, bool, error) {
+	active, err := r.GetActiveForUpdateTx(ctx, tx, userID)
+	if err != nil {
+		return decimal.Zero, false, err
+	}
+	exec := GetExecutor(tx)
+
+	var balance decimal.Decimal
+	if active.Currency == currency {
+		sql := `UPDATE users SET balance = balance + $1, balance_version = balance_version + 1, updated_at = NOW()
+			WHERE id = $2 AND balance + $1 >= 0
+			RETURNING balance`
+		err = exec.QueryRow(ctx, sql, delta, userID).Scan(&balance)
+	} else if delta.IsNegative() {
+		sql := `UPDATE user_wallets SET balance = balance + $1, updated_at = NOW()
+			WHERE user_id = $2 AND currency = $3 AND balance + $1 >= 0
+			RETURNING balance`
+		err = exec.QueryRow(ctx, sql, delta, userID, currency).Scan(&balance)
+	} else {
+		sql := `INSERT INTO user_wallets (user_id, currency, balance) VALUES ($1, $2, $3)
+			ON CONFLICT (user_id, currency) DO UPDATE
+			SET balance = user_wallets.balance + EXCLUDED.balance, updated_at = NOW()
+			RETURNING balance`
+		err = exec.QueryRow(ctx, sql, userID, currency, delta).Scan(&balance)
+	}
+	if errors.Is(err, pgx.ErrNoRows) {
+		return balance, active.Currency == currency, ErrInsufficientBalance
+	}
+	return balance, active.Currency == currency, err
+}
+
+// SwitchActiveTx 切换活跃币种（调用方需已通过 GetActiveForUpdateTx 锁定用户）
+// 当前活跃余额转入 user_wallets，目标币种钱包余额转入 users.balance
+// 返回目标币种转入的余额
+func (r *WalletRepo) SwitchActiveTx(ctx context.Context, tx pgx.Tx, userID int64, from *model.CurrencyWallet, to string) (decimal.Decimal, error) {
+	exec := GetExecutor(tx)
+
+	if from.Balance.IsPositive() {
+		_, err := exec.Exec(ctx, `INSERT INTO user_wallets (user_id, currency, balance) VALUES ($1, $2, $3)
+			ON CONFLICT (user_id, currency) DO UPDATE
+			SET balance = user_wallets.balance + EXCLUDED.balance, updated_at = NOW()`,
+			userID, from.Currency, from.Balance)
+		if err != nil {
+			return decimal.Zero, err
+		}
+	}
+
+	var taken decimal.Decimal
+	err := exec.QueryRow(ctx, `DELETE FROM user_wallets WHERE user_id = $1 AND currency = $2 RETURNING balance`,
+		userID, to).Scan(&taken)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return decimal.Zero, err
+	}
+
+	_, err = exec.Exec(ctx, `UPDATE users SET currency = $1, balance = $2, balance_version = balance_version + 1, updated_at = NOW()
+		WHERE id = $3`, to, taken, userID)
+	return taken, err
+}
+
+// List 获取用户全部币种钱包（活跃钱包在前，其余按币种排序）
+func (r *WalletRepo) List(ctx context.Context, userID int64) ([]*model.CurrencyWallet, error) {
+	sql := `SELECT currency, balance, frozen_balance, bonus_balance, TRUE FROM users WHERE id = $1
+		UNION ALL
+		SELECT w.currency, w.balance, 0, 0, FALSE FROM user_wallets w
+		JOIN users u ON u.id = w.user_id
+		WHERE w.user_id = $1 AND w.currency <> u.currency
+		ORDER BY 5 DESC, 1`
+	rows, err := DB.Query(ctx, sql, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var wallets []*model.CurrencyWallet
+	for rows.Next() {
+		w := &model.CurrencyWallet{}
+		if err := rows.Scan(&w.Currency, &w.Balance, &w.FrozenBalance, &w.BonusBalance, &w.Active); err != nil {
+			return nil, err
+		}
+		wallets = append(wallets, w)
+	}
+	return wallets, rows.Err()
+}
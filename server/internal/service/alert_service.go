@@ -16,10 +16,16 @@ type AlertBroadcaster interface {
 	BroadcastToAdmins(msg *model.WSMessage)
 }
 
+// AlertNotifier 告警外部通知（按路由规则投递到通知渠道）
+type AlertNotifier interface {
+	Notify(ctx context.Context, alert *model.Alert)
+}
+
 // AlertManager 告警管理器
 type AlertManager struct {
 	alertRepo   *repository.AlertRepo
 	broadcaster AlertBroadcaster
+	notifier    AlertNotifier
 	logger      *zap.Logger
 }
 
@@ -36,6 +42,11 @@ func NewAlertManager(
 	}
 }
 
+// SetNotifier 设置告警外部通知
+func (m *AlertManager) SetNotifier(notifier AlertNotifier) {
+	m.notifier = notifier
+}
+
 // createAlert 创建告警
 func (m *AlertManager) createAlert(ctx context.Context, alertType model.AlertType, severity model.AlertSeverity, title string, details *model.AlertDetails) (*model.Alert, error) {
 	detailsJSON, err := json.Marshal(details)
@@ -58,6 +69,10 @@ func (m *AlertManager) createAlert(ctx context.Context, alertType model.AlertTyp
 
 	// 广播给管理员
 	m.broadcastAlert(alert)
+	// 投递到通知渠道（不随调用方请求取消）
+	if m.notifier != nil {
+		m.notifier.Notify(context.WithoutCancel(ctx), alert)
+	}
 
 	m.logger.Info("Alert created",
 		zap.Int64("alert_id", alert.ID),
@@ -138,12 +153,13 @@ func (m *AlertManager) TriggerSettlementFailedAlert(ctx context.Context, roomID
 	m.createAlert(ctx, model.AlertTypeSettlementFailed, model.AlertSeverityCritical, title, details)
 }
 
-// TriggerConservationFailedAlert 触发资金守恒检查失败告警
-func (m *AlertManager) TriggerConservationFailedAlert(ctx context.Context, difference decimal.Decimal) {
+// TriggerConservationFailedAlert 触发资金守恒检查失败告警（按币种）
+func (m *AlertManager) TriggerConservationFailedAlert(ctx context.Context, currency string, difference decimal.Decimal) {
 	details := &model.AlertDetails{
+		Currency:   currency,
 		Difference: difference,
 	}
-	title := fmt.Sprintf("资金守恒检查失败，差额: %s", difference.String())
+	title := fmt.Sprintf("资金守恒检查失败 [%s]，差额: %s", currency, difference.String())
 	m.createAlert(ctx, model.AlertTypeConservationFailed, model.AlertSeverityCritical, title, details)
 }
 
@@ -158,6 +174,102 @@ func (m *AlertManager) TriggerRiskFlagAlert(ctx context.Context, flag *model.Ris
 	m.createAlert(ctx, model.AlertTypeRiskFlagCreated, model.AlertSeverityWarning, title, details)
 }
 
+// TriggerRiskRuleAlert 触发风控规则命中告警（flagID 为空表示规则动作仅告警），告警级别由规则严重程度决定
+func (m *AlertManager) TriggerRiskRuleAlert(ctx context.Context, rule *model.RiskRule, userID int64, flagID *int64, variables map[string]interface{}) {
+	info, _ := json.Marshal(variables)
+	details := &model.AlertDetails{
+		UserID:          &userID,
+		RiskFlagID:      flagID,
+		RiskFlagType:    string(rule.FlagType),
+		RiskRuleID:      &rule.ID,
+		RiskRuleVersion: rule.Version,
+		AdditionalInfo:  string(info),
+	}
+	title := fmt.Sprintf("用户 %d 命中风控规则: %s", userID, rule.Name)
+	m.createAlert(ctx, model.AlertTypeRiskRuleHit, riskAlertSeverity(rule.Severity), title, details)
+}
+
+// riskAlertSeverity 风控严重程度对应的告警级别
+func riskAlertSeverity(severity model.RiskSeverity) model.AlertSeverity {
+	switch severity {
+	case model.RiskSeverityLow:
+		return model.AlertSeverityInfo
+	case model.RiskSeverityCritical:
+		return model.AlertSeverityCritical
+	default:
+		return model.AlertSeverityWarning
+	}
+}
+
+// TriggerRiskScoreAlert 触发用户风险评分告警（评分升至告警等级），告警级别由评分等级决定
+func (m *AlertManager) TriggerRiskScoreAlert(ctx context.Context, score *model.UserRiskScore) {
+	info, _ := json.Marshal(score.Components)
+	details := &model.AlertDetails{
+		UserID:         &score.UserID,
+		AdditionalInfo: string(info),
+	}
+	title := fmt.Sprintf("用户 %d 风险评分升至 %.2f（%s）", score.UserID, score.Score, score.Level)
+	m.createAlert(ctx, model.AlertTypeRiskScoreHigh, riskAlertSeverity(score.Level), title, details)
+}
+
+// TriggerCreditLimitBreachAlert 触发房主信用额度超限告警（房间已自动暂停）
+func (m *AlertManager) TriggerCreditLimitBreachAlert(ctx context.Context, exposure *model.OwnerExposure, pausedRooms int) {
+	details := &model.AlertDetails{
+		UserID:         &exposure.OwnerID,
+		Username:       exposure.Username,
+		Amount:         exposure.PlayerBalance,
+		Balance:        exposure.MarginBalance,
+		Difference:     exposure.PlayerBalance.Sub(exposure.CreditLimit),
+		Currency:       exposure.Currency,
+		AdditionalInfo: fmt.Sprintf("credit_limit=%s, multiplier=%s, paused_rooms=%d", exposure.CreditLimit, exposure.MarginMultiplier, pausedRooms),
+	}
+	title := fmt.Sprintf("房主 %d 玩家余额 %s 超出信用额度 %s [%s]，已暂停 %d 个房间",
+		exposure.OwnerID, exposure.PlayerBalance.String(), exposure.CreditLimit.String(), exposure.Currency, pausedRooms)
+	m.createAlert(ctx, model.AlertTypeCreditLimitBreach, model.AlertSeverityCritical, title, details)
+}
+
+// TriggerOwnerAnomalyAlert 触发房主维度异常告警（已创建房主维度风控标记），告警级别由标记严重程度决定
+func (m *AlertManager) TriggerOwnerAnomalyAlert(ctx context.Context, flag *model.RiskFlag, signal *model.OwnerRiskSignal) {
+	info, _ := json.Marshal(signal)
+	details := &model.AlertDetails{
+		UserID:         &flag.UserID,
+		Currency:       signal.Currency,
+		RiskFlagID:     &flag.ID,
+		RiskFlagType:   string(flag.FlagType),
+		AdditionalInfo: string(info),
+	}
+	title := fmt.Sprintf("房主 %d 经营异常: %s", flag.UserID, flag.FlagType)
+	m.createAlert(ctx, model.AlertTypeOwnerAnomaly, riskAlertSeverity(flag.Severity), title, details)
+}
+
+// TriggerFairnessAlert 触发回合结果公平性偏离告警（全局或房间统计显著）
+func (m *AlertManager) TriggerFairnessAlert(ctx context.Context, report *model.FairnessReport) {
+	info, _ := json.Marshal(map[string]interface{}{
+		"report_id":          report.ID,
+		"rounds":             report.Rounds,
+		"chi_square_p_value": report.ChiSquarePValue,
+		"runs_deviations":    len(report.RunsDeviations),
+		"outliers":           len(report.Outliers),
+		"p_value_threshold":  report.PValueThreshold,
+		"p_value_cutoff":     report.PValueCutoff,
+		"family_size":        report.FamilySize,
+	})
+	details := &model.AlertDetails{
+		RoomID:         report.RoomID,
+		AdditionalInfo: string(info),
+	}
+	title := "全局回合结果公平性统计显著偏离"
+	if report.RoomID != nil {
+		title = fmt.Sprintf("房间 %d 回合结果公平性统计显著偏离", *report.RoomID)
+	}
+	severity := model.AlertSeverityWarning
+	// 卡方检验经多重检验校正后仍显著时为严重
+	if report.ChiSquarePValue != nil && report.PValueCutoff != nil && *report.ChiSquarePValue <= *report.PValueCutoff {
+		severity = model.AlertSeverityCritical
+	}
+	m.createAlert(ctx, model.AlertTypeFairnessDeviation, severity, title, details)
+}
+
 // AcknowledgeAlert 确认告警
 func (m *AlertManager) AcknowledgeAlert(ctx context.Context, alertID int64, acknowledgedBy int64) error {
 	return m.alertRepo.Acknowledge(ctx, alertID, acknowledgedBy)
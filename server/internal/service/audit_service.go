@@ -37,35 +37,39 @@ func NewAuditService(
 	}
 }
 
-// RunGlobalConservationCheck 执行全局资金守恒检查
-func (s *AuditService) RunGlobalConservationCheck(ctx context.Context) (*model.ConservationCheck, error) {
+// RunGlobalConservationCheck 执行全局资金守恒检查（各币种分别对账）
+func (s *AuditService) RunGlobalConservationCheck(ctx context.Context) ([]*model.ConservationCheck, error) {
 	s.logger.WithContext(ctx).Info("Starting global conservation check")
 
-	check, err := s.platformRepo.CheckConservation(ctx)
+	checks, err := s.fundService.CheckAllConservation(ctx)
 	if err != nil {
 		s.logger.WithContext(ctx).Error("Global conservation check failed", zap.Error(err))
 		return nil, err
 	}
 
-	// 记录检查结果
-	s.logger.WithContext(ctx).Info("Global conservation check completed",
-		zap.Bool("is_balanced", check.IsBalanced),
-		zap.String("system_total", check.SystemTotalFunds.String()),
-		zap.String("expected_total", check.ExpectedTotal.String()),
-		zap.String("difference", check.Difference.String()),
-	)
-
-	// 如果不平衡，触发告警
-	if !check.IsBalanced {
-		s.logger.WithContext(ctx).Error("CRITICAL: Global conservation check FAILED",
+	for _, check := range checks {
+		// 记录检查结果
+		s.logger.WithContext(ctx).Info("Global conservation check completed",
+			zap.String("currency", check.Currency),
+			zap.Bool("is_balanced", check.IsBalanced),
+			zap.String("system_total", check.SystemTotalFunds.String()),
+			zap.String("expected_total", check.ExpectedTotal.String()),
 			zap.String("difference", check.Difference.String()),
 		)
-		if s.alertManager != nil {
-			s.alertManager.TriggerConservationFailedAlert(ctx, check.Difference)
+
+		// 如果不平衡，触发告警
+		if !check.IsBalanced {
+			s.logger.WithContext(ctx).Error("CRITICAL: Global conservation check FAILED",
+				zap.String("currency", check.Currency),
+				zap.String("difference", check.Difference.String()),
+			)
+			if s.alertManager != nil {
+				s.alertManager.TriggerConservationFailedAlert(ctx, check.Currency, check.Difference)
+			}
 		}
 	}
 
-	return check, nil
+	return checks, nil
 }
 
 // RunPeriodicAudit 执行定期审计（2小时/每日）
@@ -93,14 +97,19 @@ func (s *AuditService) RunPeriodicAudit(ctx context.Context, periodType string)
 	)
 
 	// 1. 执行全局对账
-	check, err := s.RunGlobalConservationCheck(ctx)
+	checks, err := s.RunGlobalConservationCheck(ctx)
 	if err != nil {
 		return err
 	}
 
-	// 2. 记录全局对账历史
-	if err := s.fundService.RecordGlobalConservation(ctx, periodType, periodStart, periodEnd, check); err != nil {
-		s.logger.WithContext(ctx).Error("Failed to record global conservation", zap.Error(err))
+	// 2. 记录全局对账历史（每个币种一条）
+	isBalanced := true
+	for _, check := range checks {
+		if err := s.fundService.RecordGlobalConservation(ctx, periodType, periodStart, periodEnd, check); err != nil {
+			s.logger.WithContext(ctx).Error("Failed to record global conservation",
+				zap.String("currency", check.Currency), zap.Error(err))
+		}
+		isBalanced = isBalanced && check.IsBalanced
 	}
 
 	// 3. 执行按房主维度的对账
@@ -116,7 +125,7 @@ func (s *AuditService) RunPeriodicAudit(ctx context.Context, periodType string)
 
 	s.logger.WithContext(ctx).Info("Periodic audit completed",
 		zap.String("period_type", periodType),
-		zap.Bool("is_balanced", check.IsBalanced),
+		zap.Bool("is_balanced", isBalanced),
 	)
 
 	return nil
@@ -125,10 +134,10 @@ func (s *AuditService) RunPeriodicAudit(ctx context.Context, periodType string)
 // AuditResult 审计结果
 type AuditResult struct {
 	Timestamp          time.Time                 `json:"timestamp"`
-	GlobalCheck        *model.ConservationCheck  `json:"global_check"`
-	OwnerChecks        []*OwnerAuditResult       `json:"owner_checks,omitempty"`
-	TransactionSummary *TransactionAuditSummary  `json:"transaction_summary"`
-	Anomalies          []string                  `json:"anomalies,omitempty"`
+	GlobalChecks         []*model.ConservationCheck `json:"global_checks"` // 每个币种一条
+	OwnerChecks          []*OwnerAuditResult        `json:"owner_checks,omitempty"`
+	TransactionSummaries []*TransactionAuditSummary `json:"transaction_summaries"` // 每个币种一条
+	Anomalies            []string                   `json:"anomalies,omitempty"`
 }
 
 // OwnerAuditResult 房主审计结果
@@ -142,6 +151,7 @@ type OwnerAuditResult struct {
 
 // TransactionAuditSummary 交易审计摘要
 type TransactionAuditSummary struct {
+	Currency            string          `json:"currency"`
 	TotalTransactions   int64           `json:"total_transactions"`
 	TotalDeposits       decimal.Decimal `json:"total_deposits"`
 	TotalWithdrawals    decimal.Decimal `json:"total_withdrawals"`
@@ -160,38 +170,39 @@ func (s *AuditService) RunFullAudit(ctx context.Context) (*AuditResult, error) {
 	}
 
 	// 1. 全局对账
-	globalCheck, err := s.RunGlobalConservationCheck(ctx)
+	globalChecks, err := s.RunGlobalConservationCheck(ctx)
 	if err != nil {
 		return nil, err
 	}
-	result.GlobalCheck = globalCheck
+	result.GlobalChecks = globalChecks
 
-	if !globalCheck.IsBalanced {
-		result.Anomalies = append(result.Anomalies,
-			"全局资金不平衡，差额: "+globalCheck.Difference.String())
+	for _, check := range globalChecks {
+		if !check.IsBalanced {
+			result.Anomalies = append(result.Anomalies,
+				"全局资金不平衡["+check.Currency+"]，差额: "+check.Difference.String())
+		}
 	}
 
 	// 2. 交易摘要
-	summary, err := s.getTransactionSummary(ctx)
+	summaries, err := s.getTransactionSummaries(ctx)
 	if err != nil {
 		s.logger.WithContext(ctx).Warn("Failed to get transaction summary", zap.Error(err))
 	} else {
-		result.TransactionSummary = summary
+		result.TransactionSummaries = summaries
 	}
 
 	// 3. 检查异常情况
-	anomalies := s.checkAnomalies(ctx, globalCheck, summary)
+	anomalies := s.checkAnomalies(ctx, summaries)
 	result.Anomalies = append(result.Anomalies, anomalies...)
 
 	return result, nil
 }
 
-// getTransactionSummary 获取交易摘要
-func (s *AuditService) getTransactionSummary(ctx context.Context) (*TransactionAuditSummary, error) {
-	summary := &TransactionAuditSummary{}
-
-	// 从数据库聚合交易数据
+// getTransactionSummaries 获取各币种的交易摘要
+func (s *AuditService) getTransactionSummaries(ctx context.Context) ([]*TransactionAuditSummary, error) {
+	// 从数据库按币种聚合交易数据
 	sql := `SELECT 
+		currency,
 		COUNT(*) as total,
 		COALESCE(SUM(CASE WHEN tx_type = 'deposit' AND amount > 0 THEN amount ELSE 0 END), 0) as deposits,
 		COALESCE(SUM(CASE WHEN tx_type = 'withdraw' AND amount < 0 THEN ABS(amount) ELSE 0 END), 0) as withdrawals,
@@ -199,29 +210,42 @@ func (s *AuditService) getTransactionSummary(ctx context.Context) (*TransactionA
 		COALESCE(SUM(CASE WHEN tx_type = 'game_win' THEN amount ELSE 0 END), 0) as winnings,
 		COALESCE(SUM(CASE WHEN tx_type = 'owner_commission' THEN amount ELSE 0 END), 0) as commissions,
 		COALESCE(SUM(CASE WHEN tx_type = 'platform_share' THEN amount ELSE 0 END), 0) as platform_share
-		FROM balance_transactions`
-
-	err := repository.DB.QueryRow(ctx, sql).Scan(
-		&summary.TotalTransactions,
-		&summary.TotalDeposits,
-		&summary.TotalWithdrawals,
-		&summary.TotalBets,
-		&summary.TotalWinnings,
-		&summary.TotalCommissions,
-		&summary.TotalPlatformShare,
-	)
+		FROM balance_transactions
+		GROUP BY currency
+		ORDER BY currency`
+
+	rows, err := repository.DB.Query(ctx, sql)
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
+
+	var summaries []*TransactionAuditSummary
+	for rows.Next() {
+		summary := &TransactionAuditSummary{}
+		if err := rows.Scan(
+			&summary.Currency,
+			&summary.TotalTransactions,
+			&summary.TotalDeposits,
+			&summary.TotalWithdrawals,
+			&summary.TotalBets,
+			&summary.TotalWinnings,
+			&summary.TotalCommissions,
+			&summary.TotalPlatformShare,
+		); err != nil {
+			return nil, err
+		}
 
-	// 计算净流入
-	summary.NetFlow = summary.TotalDeposits.Sub(summary.TotalWithdrawals)
+		// 计算净流入
+		summary.NetFlow = summary.TotalDeposits.Sub(summary.TotalWithdrawals)
+		summaries = append(summaries, summary)
+	}
 
-	return summary, nil
+	return summaries, rows.Err()
 }
 
 // checkAnomalies 检查异常情况
-func (s *AuditService) checkAnomalies(ctx context.Context, check *model.ConservationCheck, summary *TransactionAuditSummary) []string {
+func (s *AuditService) checkAnomalies(ctx context.Context, summaries []*TransactionAuditSummary) []string {
 	anomalies := []string{}
 
 	// 1. 检查负余额用户
@@ -231,15 +255,15 @@ func (s *AuditService) checkAnomalies(ctx context.Context, check *model.Conserva
 		anomalies = append(anomalies, "存在负余额用户: "+string(rune(negativeCount))+"个")
 	}
 
-	// 2. 检查交易记录与余额是否匹配
-	if summary != nil {
+	// 2. 检查交易记录与余额是否匹配（按币种）
+	for _, summary := range summaries {
 		// 游戏下注应该等于游戏获胜+佣金+平台抽成（在完美情况下）
 		// 实际上由于退款等情况，这个等式不一定成立，但差额不应该太大
 		gameIncome := summary.TotalWinnings.Add(summary.TotalCommissions).Add(summary.TotalPlatformShare)
 		gameDiff := summary.TotalBets.Sub(gameIncome)
 		// 允许一定误差（退款等情况）
 		if gameDiff.Abs().GreaterThan(decimal.NewFromInt(100)) {
-			anomalies = append(anomalies, "游戏资金流向异常，差额: "+gameDiff.String())
+			anomalies = append(anomalies, "游戏资金流向异常["+summary.Currency+"]，差额: "+gameDiff.String())
 		}
 	}
 
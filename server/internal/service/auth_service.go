@@ -11,6 +11,7 @@ import (
 	"github.com/fiveseconds/server/internal/repository"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/jackc/pgx/v5"
 	"github.com/shopspring/decimal"
 	"golang.org/x/crypto/bcrypt"
 )
@@ -22,10 +23,22 @@ var (
 	ErrInvalidToken       = errors.New("invalid token")
 )
 
+// ReferralRecorder 推荐关系记录（玩家使用其他玩家的邀请码注册时在注册事务内调用）
+type ReferralRecorder interface {
+	RecordReferralTx(ctx context.Context, tx pgx.Tx, referrerID, refereeID, ownerID int64) error
+}
+
+// LoginRecorder 登录事件记录（成功与失败的登录尝试均记录）
+type LoginRecorder interface {
+	RecordLogin(ctx context.Context, event *model.LoginEvent)
+}
+
 type AuthService struct {
-	userRepo    *repository.UserRepo
-	cfg         *config.Config
-	riskService *RiskControlService
+	userRepo         *repository.UserRepo
+	cfg              *config.Config
+	riskService      *RiskControlService
+	referralRecorder ReferralRecorder
+	loginRecorder    LoginRecorder
 }
 
 func NewAuthService(userRepo *repository.UserRepo, cfg *config.Config) *AuthService {
@@ -40,6 +53,33 @@ func (s *AuthService) SetRiskService(riskService *RiskControlService) {
 	s.riskService = riskService
 }
 
+// SetReferralRecorder 设置推荐关系记录（用于玩家推荐奖励）
+func (s *AuthService) SetReferralRecorder(recorder ReferralRecorder) {
+	s.referralRecorder = recorder
+}
+
+// SetLoginRecorder 设置登录事件记录（用于登录历史与已知设备）
+func (s *AuthService) SetLoginRecorder(recorder LoginRecorder) {
+	s.loginRecorder = recorder
+}
+
+// recordLogin 记录登录尝试
+func (s *AuthService) recordLogin(ctx context.Context, req *model.LoginReq, userID *int64, reason model.LoginFailureReason) *model.LoginEvent {
+	event := &model.LoginEvent{
+		UserID:            userID,
+		Username:          req.Username,
+		Success:           reason == "",
+		FailureReason:     reason,
+		IP:                req.ClientIP,
+		UserAgent:         req.UserAgent,
+		DeviceFingerprint: req.DeviceFingerprint,
+	}
+	if s.loginRecorder != nil {
+		s.loginRecorder.RecordLogin(ctx, event)
+	}
+	return event
+}
+
 // Claims JWT claims
 type Claims struct {
 	UserID   int64      `json:"user_id"`
@@ -67,7 +107,9 @@ func (s *AuthService) Register(ctx context.Context, req *model.RegisterReq) (*mo
 
 	// 验证邀请码
 	var invitedBy *int64
+	var referrerID *int64
 	var userInviteCode *string
+	currency := DefaultCurrency
 
 	// 玩家必须有邀请码（绑定房主）
 	// 房主必须有邀请码（绑定上级/Admin）
@@ -87,11 +129,36 @@ func (s *AuthService) Register(ctx context.Context, req *model.RegisterReq) (*mo
 
 	// 校验绑定逻辑
 	if targetRole == model.RolePlayer {
-		// 玩家只能被房主邀请 (或 Admin?)
-		if inviter.Role != model.RoleOwner && inviter.Role != model.RoleAdmin {
-			return nil, ErrInvalidInviteCode // 只能填房主/管理员的码
+		switch inviter.Role {
+		case model.RoleOwner:
+			invitedBy = &inviter.ID
+			currency = inviter.Currency
+		case model.RoleAdmin:
+			invitedBy = &inviter.ID
+		case model.RolePlayer:
+			// 玩家邀请码即推荐码：归属推荐人的房主，并记录推荐关系
+			if inviter.InvitedBy == nil {
+				return nil, ErrInvalidInviteCode
+			}
+			invitedBy = inviter.InvitedBy
+			referrerID = &inviter.ID
+			owner, err := s.userRepo.GetByID(ctx, *inviter.InvitedBy)
+			if err != nil {
+				return nil, err
+			}
+			if owner.IsOwner() {
+				currency = owner.Currency
+			}
+		default:
+			return nil, ErrInvalidInviteCode
 		}
-		invitedBy = &inviter.ID
+
+		// 新玩家生成自己的推荐码
+		code, err := generateUniqueInviteCode(ctx, s.userRepo)
+		if err != nil {
+			return nil, err
+		}
+		userInviteCode = &code
 	} else if targetRole == model.RoleOwner {
 		// 房主只能被 Admin (或 上级房主/代理) 邀请
 		if inviter.Role != model.RoleAdmin {
@@ -101,20 +168,11 @@ func (s *AuthService) Register(ctx context.Context, req *model.RegisterReq) (*mo
 		invitedBy = &inviter.ID
 
 		// 新房主自动生成自己的邀请码
-		for {
-			code, err := game.GenerateInviteCode()
-			if err != nil {
-				return nil, err
-			}
-			exists, err := s.userRepo.InviteCodeExists(ctx, code)
-			if err != nil {
-				return nil, err
-			}
-			if !exists {
-				userInviteCode = &code
-				break
-			}
+		code, err := generateUniqueInviteCode(ctx, s.userRepo)
+		if err != nil {
+			return nil, err
 		}
+		userInviteCode = &code
 	}
 
 	// 密码加密
@@ -133,20 +191,49 @@ func (s *AuthService) Register(ctx context.Context, req *model.RegisterReq) (*mo
 		FrozenBalance:      decimal.Zero,
 		OwnerRoomBalance:   decimal.Zero,
 		OwnerMarginBalance: decimal.Zero,
+		Currency:           currency, // 玩家继承所属房主的经营币种
 	}
 
-	if err := s.userRepo.Create(ctx, user); err != nil {
+	// 用户与推荐关系在同一事务中创建，推荐关系写入失败时注册整体回滚
+	err = repository.Tx(ctx, func(tx pgx.Tx) error {
+		if err := s.userRepo.CreateTx(ctx, tx, user); err != nil {
+			return err
+		}
+		if referrerID != nil && s.referralRecorder != nil {
+			return s.referralRecorder.RecordReferralTx(ctx, tx, *referrerID, user.ID, *invitedBy)
+		}
+		return nil
+	})
+	if err != nil {
 		return nil, err
 	}
 
 	return user, nil
 }
 
+// generateUniqueInviteCode 生成未被占用的邀请码（房主邀请码与玩家推荐码共用）
+func generateUniqueInviteCode(ctx context.Context, userRepo *repository.UserRepo) (string, error) {
+	for {
+		code, err := game.GenerateInviteCode()
+		if err != nil {
+			return "", err
+		}
+		exists, err := userRepo.InviteCodeExists(ctx, code)
+		if err != nil {
+			return "", err
+		}
+		if !exists {
+			return code, nil
+		}
+	}
+}
+
 // Login 用户登录
 func (s *AuthService) Login(ctx context.Context, req *model.LoginReq) (*model.LoginResp, error) {
 	user, err := s.userRepo.GetByUsername(ctx, req.Username)
 	if err != nil {
 		if errors.Is(err, repository.ErrNotFound) {
+			s.recordLogin(ctx, req, nil, model.LoginFailureUserNotFound)
 			return nil, ErrInvalidCredentials
 		}
 		return nil, err
@@ -154,9 +241,13 @@ func (s *AuthService) Login(ctx context.Context, req *model.LoginReq) (*model.Lo
 
 	// 验证密码
 	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.Password)); err != nil {
+		s.recordLogin(ctx, req, &user.ID, model.LoginFailureInvalidPassword)
 		return nil, ErrInvalidCredentials
 	}
 
+	// 记录登录历史（新设备判定供下方风控检查使用）
+	loginEvent := s.recordLogin(ctx, req, &user.ID, "")
+
 	// 更新设备指纹并进行风控检测
 	if req.DeviceFingerprint != "" {
 		// 更新用户的设备指纹
@@ -169,12 +260,29 @@ func (s *AuthService) Login(ctx context.Context, req *model.LoginReq) (*model.Lo
 		if s.riskService != nil {
 			// 异步执行风控检测，不阻塞登录流程
 			go func() {
-				checkCtx := context.Background()
+				checkCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+				defer cancel()
 				s.riskService.CheckDeviceFingerprint(checkCtx, user.ID, req.DeviceFingerprint)
 			}()
 		}
 	}
 
+	// 记录登录 IP（串通检测的共用 IP 关联）
+	if req.ClientIP != "" {
+		if err := s.userRepo.RecordLoginIP(ctx, user.ID, req.ClientIP); err != nil {
+			// 记录错误但不阻止登录
+		}
+	}
+
+	// 登录事件风控规则检查（异步执行，限时避免数据库阻塞时协程堆积）
+	if s.riskService != nil {
+		go func() {
+			checkCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			s.riskService.OnLogin(checkCtx, loginEvent)
+		}()
+	}
+
 	// 生成 JWT
 	token, err := s.generateToken(user)
 	if err != nil {
@@ -223,6 +331,15 @@ func (s *AuthService) ValidateToken(tokenString string) (*Claims, error) {
 
 // CreateOwner 创建房主(仅管理员可用)
 func (s *AuthService) CreateOwner(ctx context.Context, req *model.CreateOwnerReq) (*model.User, error) {
+	// 经营币种（为空使用默认币种）
+	currency := DefaultCurrency
+	if req.Currency != "" {
+		currency = NormalizeCurrency(req.Currency)
+		if !IsSupportedCurrency(currency) {
+			return nil, ErrUnsupportedCurrency
+		}
+	}
+
 	// 检查用户名
 	exists, err := s.userRepo.UsernameExists(ctx, req.Username)
 	if err != nil {
@@ -264,6 +381,7 @@ func (s *AuthService) CreateOwner(ctx context.Context, req *model.CreateOwnerReq
 		FrozenBalance:      decimal.Zero,
 		OwnerRoomBalance:   decimal.Zero,
 		OwnerMarginBalance: decimal.Zero,
+		Currency:           currency,
 	}
 
 	if err := s.userRepo.Create(ctx, user); err != nil {
@@ -0,0 +1,155 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/fiveseconds/server/internal/config"
+	"github.com/fiveseconds/server/internal/model"
+	"github.com/fiveseconds/server/internal/repository"
+
+	"go.uber.org/zap"
+)
+
+var (
+	ErrBalanceHistoryUserNotFound = errors.New("user not found")
+)
+
+// snapshotFrozenGrace 日终后多长时间内采集的快照记录冻结余额
+// 冻结余额没有流水无法按日终时刻回推，超过该时长补采的快照记为未知，避免把当前值记到过去的日期
+const snapshotFrozenGrace = 10 * time.Minute
+
+// DailyReconciler 日终快照完成后执行的每日对账
+type DailyReconciler interface {
+	RecordOwnerConservationDaily(ctx context.Context, dayStart, dayEnd time.Time) error
+}
+
+// BalanceSnapshotService 日终余额快照服务
+// 每个自然日结束时（按配置时区）记录所有账户的余额，用于历史余额查询与每日房主对账
+type BalanceSnapshotService struct {
+	userRepo     *repository.UserRepo
+	snapshotRepo *repository.BalanceSnapshotRepo
+	reconciler   DailyReconciler
+	loc          *time.Location
+	logger       *zap.Logger
+}
+
+// NewBalanceSnapshotService 创建日终余额快照服务
+func NewBalanceSnapshotService(
+	userRepo *repository.UserRepo,
+	snapshotRepo *repository.BalanceSnapshotRepo,
+	cfg *config.Config,
+	logger *zap.Logger,
+) *BalanceSnapshotService {
+	s := &BalanceSnapshotService{
+		userRepo:     userRepo,
+		snapshotRepo: snapshotRepo,
+		loc:          time.Local,
+		logger:       logger.With(zap.String("service", "balance_snapshot")),
+	}
+	if cfg != nil && cfg.BalanceSnapshot.Timezone != "" {
+		loc, err := time.LoadLocation(cfg.BalanceSnapshot.Timezone)
+		if err != nil {
+			s.logger.Warn("invalid balance snapshot timezone, using local time",
+				zap.String("timezone", cfg.BalanceSnapshot.Timezone), zap.Error(err))
+		} else {
+			s.loc = loc
+		}
+	}
+	return s
+}
+
+// SetReconciler 设置每日对账（首次生成某日快照后基于快照对账）
+func (s *BalanceSnapshotService) SetReconciler(reconciler DailyReconciler) {
+	s.reconciler = reconciler
+}
+
+// NextBoundary 计算 now 之后的下一个日终时刻（配置时区的 00:00）
+func (s *BalanceSnapshotService) NextBoundary(now time.Time) time.Time {
+	_, end := settlementPeriodContaining(model.SettlementPeriodDaily, now.In(s.loc))
+	return end
+}
+
+// RunDueSnapshots 补齐最近一次快照之后所有已结束自然日的快照（定时任务调用，可重复执行），返回新增条数
+// 快照余额按日终时刻从流水回推，停机后补采的日期同样准确（冻结余额除外，记为未知）；
+// 每个日期首次生成快照后执行该日的房主对账
+func (s *BalanceSnapshotService) RunDueSnapshots(ctx context.Context, now time.Time) (int64, error) {
+	latest, err := s.snapshotRepo.GetLatestDate(ctx, now)
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		return 0, fmt.Errorf("get latest snapshot date: %w", err)
+	}
+
+	var total int64
+	for _, day := range snapshotDaysToCapture(latest, now.In(s.loc)) {
+		n, err := s.captureDay(ctx, day[0], day[1], now.Sub(day[1]) <= snapshotFrozenGrace)
+		total += n
+		if err != nil {
+			return total, err
+		}
+	}
+	return total, nil
+}
+
+// captureDay 生成指定自然日的快照，首次生成时执行该日的房主对账
+func (s *BalanceSnapshotService) captureDay(ctx context.Context, dayStart, dayEnd time.Time, frozenKnown bool) (int64, error) {
+	date := snapshotDate(dayStart)
+	existed, err := s.snapshotRepo.HasDay(ctx, date)
+	if err != nil {
+		return 0, fmt.Errorf("check snapshot day: %w", err)
+	}
+	n, err := s.snapshotRepo.CaptureDay(ctx, date, dayEnd, frozenKnown)
+	if err != nil {
+		return 0, fmt.Errorf("capture snapshots: %w", err)
+	}
+	if n > 0 {
+		s.logger.Info("balance snapshots captured",
+			zap.String("date", date.Format(statementDateLayout)),
+			zap.Int64("count", n))
+	}
+
+	if !existed && s.reconciler != nil {
+		if err := s.reconciler.RecordOwnerConservationDaily(ctx, dayStart, dayEnd); err != nil {
+			s.logger.Error("daily owner conservation check failed", zap.Error(err))
+		}
+	}
+	return n, nil
+}
+
+// snapshotDaysToCapture 计算需要生成快照的自然日（now 所在时区）
+// 从最近一次快照的日期（重新执行以补齐新增账户）到最近一个已结束的自然日；
+// 尚无快照时只生成最近一个已结束的自然日
+func snapshotDaysToCapture(latest, now time.Time) [][2]time.Time {
+	lastStart, lastEnd := lastCompletedPeriod(model.SettlementPeriodDaily, now)
+	if latest.IsZero() {
+		return [][2]time.Time{{lastStart, lastEnd}}
+	}
+	start := time.Date(latest.Year(), latest.Month(), latest.Day(), 0, 0, 0, 0, now.Location())
+	if start.After(lastStart) {
+		start = lastStart
+	}
+	var days [][2]time.Time
+	for !start.After(lastStart) {
+		dayStart, dayEnd := settlementPeriodContaining(model.SettlementPeriodDaily, start)
+		days = append(days, [2]time.Time{dayStart, dayEnd})
+		start = dayEnd
+	}
+	return days
+}
+
+// GetBalanceHistory 查询用户的历史日终余额
+func (s *BalanceSnapshotService) GetBalanceHistory(ctx context.Context, query *model.BalanceHistoryQuery) ([]*model.DailyBalanceSnapshot, int64, error) {
+	if _, err := s.userRepo.GetByID(ctx, query.UserID); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, 0, ErrBalanceHistoryUserNotFound
+		}
+		return nil, 0, err
+	}
+	return s.snapshotRepo.ListByUser(ctx, query)
+}
+
+// snapshotDate 将配置时区的自然日起点转换为 DATE 值（避免时区换算改变日期）
+func snapshotDate(dayStart time.Time) time.Time {
+	return time.Date(dayStart.Year(), dayStart.Month(), dayStart.Day(), 0, 0, 0, 0, time.UTC)
+}
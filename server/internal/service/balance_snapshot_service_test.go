@@ -0,0 +1,69 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// TestBalanceSnapshotBoundary 测试日终时刻为配置时区的下一个 00:00，快照日期保留本地自然日
+func TestBalanceSnapshotBoundary(t *testing.T) {
+	loc := time.FixedZone("UTC+8", 8*3600)
+	svc := &BalanceSnapshotService{loc: loc, logger: zap.NewNop()}
+	tests := []struct {
+		name string
+		now  time.Time
+		next time.Time
+	}{
+		{"local midday", time.Date(2024, 3, 13, 4, 0, 0, 0, time.UTC), time.Date(2024, 3, 14, 0, 0, 0, 0, loc)},
+		{"utc date still the previous day", time.Date(2024, 3, 12, 18, 0, 0, 0, time.UTC), time.Date(2024, 3, 14, 0, 0, 0, 0, loc)},
+		{"exactly at local midnight", time.Date(2024, 3, 13, 0, 0, 0, 0, loc), time.Date(2024, 3, 14, 0, 0, 0, 0, loc)},
+		{"just before local midnight", time.Date(2024, 3, 13, 23, 59, 59, 0, loc), time.Date(2024, 3, 14, 0, 0, 0, 0, loc)},
+		{"across year end", time.Date(2024, 12, 31, 20, 0, 0, 0, loc), time.Date(2025, 1, 1, 0, 0, 0, 0, loc)},
+	}
+	for _, tt := range tests {
+		if got := svc.NextBoundary(tt.now); !got.Equal(tt.next) {
+			t.Errorf("%s: Expected next boundary %s, got %s", tt.name, tt.next, got)
+		}
+	}
+
+	// 本地 3 月 13 日凌晨对应 UTC 3 月 12 日，快照日期仍为 3 月 13 日
+	date := snapshotDate(time.Date(2024, 3, 13, 0, 0, 0, 0, loc))
+	if want := time.Date(2024, 3, 13, 0, 0, 0, 0, time.UTC); !date.Equal(want) || date.Location() != time.UTC {
+		t.Errorf("Expected snapshot date %s, got %s", want, date)
+	}
+}
+
+// TestSnapshotDaysToCapture 测试补采范围：从最近一次快照日期到最近一个已结束的自然日
+func TestSnapshotDaysToCapture(t *testing.T) {
+	loc := time.FixedZone("UTC+8", 8*3600)
+	now := time.Date(2024, 3, 13, 0, 5, 0, 0, loc)
+	day := func(d int) time.Time { return time.Date(2024, 3, d, 0, 0, 0, 0, loc) }
+	date := func(d int) time.Time { return time.Date(2024, 3, d, 0, 0, 0, 0, time.UTC) }
+	tests := []struct {
+		name   string
+		latest time.Time
+		first  int // 第一个补采日（3 月）
+		count  int
+	}{
+		{"no snapshots yet", time.Time{}, 12, 1},
+		{"yesterday already captured is re-run", date(12), 12, 1},
+		{"one day missed", date(11), 11, 2},
+		{"several days missed", date(8), 8, 5},
+		{"latest after the last completed day", date(13), 12, 1},
+	}
+	for _, tt := range tests {
+		days := snapshotDaysToCapture(tt.latest, now)
+		if len(days) != tt.count {
+			t.Errorf("%s: Expected %d days, got %d", tt.name, tt.count, len(days))
+			continue
+		}
+		for i, d := range days {
+			if !d[0].Equal(day(tt.first+i)) || !d[1].Equal(day(tt.first+i+1)) {
+				t.Errorf("%s: Expected day %d to be [%s, %s), got [%s, %s)",
+					tt.name, i, day(tt.first+i), day(tt.first+i+1), d[0], d[1])
+			}
+		}
+	}
+}
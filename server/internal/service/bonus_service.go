@@ -0,0 +1,410 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/fiveseconds/server/internal/config"
+	"github.com/fiveseconds/server/internal/model"
+	"github.com/fiveseconds/server/internal/repository"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrBonusInvalidAmount     = errors.New("bonus amount must be positive")
+	ErrBonusExceedsMaxGrant   = errors.New("bonus amount exceeds max grant amount")
+	ErrBonusInvalidMultiplier = errors.New("invalid wagering multiplier")
+	ErrBonusUserNotFound      = errors.New("user not found")
+	ErrBonusNotPlayer         = errors.New("bonus can only be granted to players")
+	ErrBonusForbidden         = errors.New("this player does not belong to you")
+	ErrBonusInsufficientFunds = errors.New("insufficient commission balance to fund the bonus")
+	ErrBonusCurrencyMismatch  = errors.New("player currency must match the owner's operating currency")
+)
+
+// BonusBalanceListener 奖励余额变更监听（用于同步游戏房间内存中的奖励余额）
+type BonusBalanceListener interface {
+	UpdatePlayerBonusBalance(userID int64, bonusBalance decimal.Decimal)
+}
+
+// BonusService 奖励余额服务
+// 奖励余额与真实余额分开记账：下注时先扣真实余额，结算后累计流水，
+// 所有进行中的奖励流水达标后，剩余奖励余额一次性转为真实余额。
+// 奖励在发放时从资金来源划出（房主发放扣房主佣金余额，管理员发放扣平台余额），
+// 奖励余额属于系统内资金，不产生未入账的资金。
+type BonusService struct {
+	userRepo     *repository.UserRepo
+	bonusRepo    *repository.BonusRepo
+	txRepo       *repository.TransactionRepo
+	platformRepo *repository.PlatformRepo
+	listener     BonusBalanceListener
+	restrictions AccountRestrictionChecker
+	cfg          *config.Config
+	logger       *zap.Logger
+}
+
+// NewBonusService 创建奖励余额服务
+func NewBonusService(
+	userRepo *repository.UserRepo,
+	bonusRepo *repository.BonusRepo,
+	txRepo *repository.TransactionRepo,
+	platformRepo *repository.PlatformRepo,
+	cfg *config.Config,
+	logger *zap.Logger,
+) *BonusService {
+	return &BonusService{
+		userRepo:     userRepo,
+		bonusRepo:    bonusRepo,
+		txRepo:       txRepo,
+		platformRepo: platformRepo,
+		cfg:          cfg,
+		logger:       logger.With(zap.String("service", "bonus")),
+	}
+}
+
+// SetListener 设置奖励余额变更监听
+func (s *BonusService) SetListener(listener BonusBalanceListener) {
+	s.listener = listener
+}
+
+// SetRestrictionChecker 设置账户限制检查（被禁止提现或封停期间奖励余额暂不转为真实余额）
+func (s *BonusService) SetRestrictionChecker(restrictions AccountRestrictionChecker) {
+	s.restrictions = restrictions
+}
+
+// resolveMultiplier 校验流水倍数，为 0 时使用默认倍数
+func (s *BonusService) resolveMultiplier(multiplier decimal.Decimal) (decimal.Decimal, error) {
+	if multiplier.IsZero() {
+		multiplier = decimal.NewFromFloat(s.cfg.Bonus.DefaultWageringMultiplier)
+	}
+	if !multiplier.IsPositive() {
+		return decimal.Zero, ErrBonusInvalidMultiplier
+	}
+	if limit := decimal.NewFromFloat(s.cfg.Bonus.MaxWageringMultiplier); limit.IsPositive() && multiplier.GreaterThan(limit) {
+		return decimal.Zero, ErrBonusInvalidMultiplier
+	}
+	return multiplier, nil
+}
+
+// GrantBonus 发放奖励（房主只能发放给自己名下的玩家，管理员不限）
+// 房主发放的奖励从房主佣金余额扣除，管理员发放的奖励从平台余额扣除，与奖励入账在同一事务
+func (s *BonusService) GrantBonus(ctx context.Context, operatorID int64, operatorRole model.Role, req *model.GrantBonusReq) (*model.BonusGrant, error) {
+	if !req.Amount.IsPositive() {
+		return nil, ErrBonusInvalidAmount
+	}
+	if limit := decimal.NewFromFloat(s.cfg.Bonus.MaxGrantAmount); limit.IsPositive() && req.Amount.GreaterThan(limit) {
+		return nil, ErrBonusExceedsMaxGrant
+	}
+	multiplier, err := s.resolveMultiplier(req.WageringMultiplier)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.userRepo.GetByUsername(ctx, req.Username)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrBonusUserNotFound
+		}
+		return nil, err
+	}
+	if !user.IsPlayer() {
+		return nil, ErrBonusNotPlayer
+	}
+	ownerFunded := operatorRole != model.RoleAdmin
+	if ownerFunded && (user.InvitedBy == nil || *user.InvitedBy != operatorID) {
+		return nil, ErrBonusForbidden
+	}
+	if ownerFunded {
+		owner, err := s.userRepo.GetByID(ctx, operatorID)
+		if err != nil {
+			return nil, err
+		}
+		if owner.Currency != user.Currency {
+			return nil, ErrBonusCurrencyMismatch
+		}
+	}
+
+	grant := &model.BonusGrant{
+		UserID:             user.ID,
+		Username:           user.Username,
+		OwnerID:            user.InvitedBy,
+		GrantedBy:          operatorID,
+		Amount:             req.Amount,
+		WageringMultiplier: multiplier,
+		WageringRequired:   req.Amount.Mul(multiplier).Round(2),
+		Status:             model.BonusGrantActive,
+	}
+	if req.Remark != "" {
+		grant.Remark = &req.Remark
+	}
+
+	var newBonus decimal.Decimal
+	err = repository.Tx(ctx, func(tx pgx.Tx) error {
+		// 先锁定房主行再锁定玩家行，与其它资金流程的加锁顺序一致
+		var fundingRecord *model.BalanceTransaction
+		if ownerFunded {
+			commission, err := s.userRepo.GetOwnerRoomBalanceForUpdateTx(ctx, tx, operatorID)
+			if err != nil {
+				return err
+			}
+			if commission.LessThan(req.Amount) {
+				return ErrBonusInsufficientFunds
+			}
+			if err := s.userRepo.UpdateOwnerBalancesTx(ctx, tx, operatorID, "owner_room_balance", req.Amount.Neg()); err != nil {
+				return fmt.Errorf("deduct owner commission: %w", err)
+			}
+			fundingRecord = &model.BalanceTransaction{
+				UserID:        operatorID,
+				Type:          model.TxBonusGrant,
+				Amount:        req.Amount.Neg(),
+				BalanceBefore: commission,
+				BalanceAfter:  commission.Sub(req.Amount),
+				BalanceField:  "owner_room_balance",
+				Currency:      user.Currency,
+			}
+		} else if err := s.platformRepo.UpdateBalanceTx(ctx, tx, user.Currency, req.Amount.Neg()); err != nil {
+			return fmt.Errorf("deduct platform balance: %w", err)
+		}
+
+		current, err := s.userRepo.GetBonusBalanceForUpdateTx(ctx, tx, user.ID)
+		if err != nil {
+			return err
+		}
+		// 奖励余额已输光，之前未完成的奖励作废
+		if current.IsZero() {
+			if _, err := s.bonusRepo.ForfeitActiveTx(ctx, tx, user.ID); err != nil {
+				return fmt.Errorf("forfeit active grants: %w", err)
+			}
+		}
+
+		newBonus, err = s.userRepo.UpdateBonusBalanceTx(ctx, tx, user.ID, req.Amount)
+		if err != nil {
+			return fmt.Errorf("add bonus balance: %w", err)
+		}
+		if err := s.bonusRepo.CreateTx(ctx, tx, grant); err != nil {
+			return fmt.Errorf("create bonus grant: %w", err)
+		}
+
+		remark := fmt.Sprintf("奖励发放(ID:%d)", grant.ID)
+		records := []*model.BalanceTransaction{{
+			UserID:        user.ID,
+			Type:          model.TxBonusGrant,
+			Amount:        req.Amount,
+			BalanceBefore: current,
+			BalanceAfter:  newBonus,
+			BalanceField:  "bonus_balance",
+			Currency:      user.Currency,
+			Remark:        &remark,
+		}}
+		if fundingRecord != nil {
+			fundingRemark := fmt.Sprintf("奖励发放(玩家ID:%d,ID:%d)", user.ID, grant.ID)
+			fundingRecord.Remark = &fundingRemark
+			records = append(records, fundingRecord)
+		}
+		return s.txRepo.BatchCreateTx(ctx, tx, records)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if s.listener != nil {
+		s.listener.UpdatePlayerBonusBalance(user.ID, newBonus)
+	}
+
+	s.logger.Info("Bonus granted",
+		zap.Int64("grant_id", grant.ID),
+		zap.Int64("user_id", user.ID),
+		zap.Int64("granted_by", operatorID),
+		zap.String("amount", req.Amount.String()),
+		zap.String("wagering_required", grant.WageringRequired.String()))
+
+	return grant, nil
+}
+
+// ListGrants 分页获取奖励发放记录
+func (s *BonusService) ListGrants(ctx context.Context, query *model.BonusGrantListQuery) ([]*model.BonusGrant, int64, error) {
+	return s.bonusRepo.List(ctx, query)
+}
+
+// GetSummary 获取玩家奖励余额及流水进度
+func (s *BonusService) GetSummary(ctx context.Context, userID int64) (*model.BonusSummary, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	grants, err := s.bonusRepo.ListActive(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &model.BonusSummary{
+		BonusBalance: user.BonusBalance,
+		ActiveGrants: grants,
+	}
+	if summary.ActiveGrants == nil {
+		summary.ActiveGrants = []*model.BonusGrant{}
+	}
+	for _, g := range grants {
+		summary.WageringRequired = summary.WageringRequired.Add(g.WageringRequired)
+		summary.Wagered = summary.Wagered.Add(g.Wagered)
+	}
+	summary.WageringRemaining = summary.WageringRequired.Sub(summary.Wagered)
+	return summary, nil
+}
+
+// RecordWagersTx 在回合结算事务内累计玩家下注流水（实现 game.BonusTracker）
+// 所有进行中的奖励达标后将奖励余额转为真实余额；奖励余额已输光的奖励作废
+func (s *BonusService) RecordWagersTx(ctx context.Context, tx pgx.Tx, wagers map[int64]decimal.Decimal) ([]model.BonusConversion, error) {
+	// 按用户ID顺序加锁，避免并发结算死锁
+	userIDs := make([]int64, 0, len(wagers))
+	for userID := range wagers {
+		userIDs = append(userIDs, userID)
+	}
+	sort.Slice(userIDs, func(i, j int) bool { return userIDs[i] < userIDs[j] })
+
+	var conversions []model.BonusConversion
+	for _, userID := range userIDs {
+		grants, err := s.bonusRepo.ListActiveForUpdateTx(ctx, tx, userID)
+		if err != nil {
+			return nil, err
+		}
+		if len(grants) == 0 {
+			continue
+		}
+
+		changed := applyWager(grants, wagers[userID])
+		// 转换后奖励即可提现：被限制期间达标的奖励保持进行中，解除限制后的下一次结算再转换
+		if allGrantsCompleted(grants) && s.conversionBlocked(ctx, userID) {
+			for _, g := range changed {
+				if g.Status == model.BonusGrantCompleted {
+					g.Status = model.BonusGrantActive
+				}
+			}
+		}
+		for _, g := range changed {
+			if err := s.bonusRepo.UpdateProgressTx(ctx, tx, g.ID, g.Wagered, g.Status); err != nil {
+				return nil, err
+			}
+		}
+
+		if !allGrantsCompleted(grants) {
+			bonus, err := s.userRepo.GetBonusBalanceForUpdateTx(ctx, tx, userID)
+			if err != nil {
+				return nil, err
+			}
+			if bonus.IsZero() {
+				if _, err := s.bonusRepo.ForfeitActiveTx(ctx, tx, userID); err != nil {
+					return nil, err
+				}
+			}
+			continue
+		}
+
+		conversion, err := s.convertTx(ctx, tx, userID)
+		if err != nil {
+			return nil, err
+		}
+		if conversion != nil {
+			conversions = append(conversions, *conversion)
+		}
+	}
+	return conversions, nil
+}
+
+// conversionBlocked 用户是否被禁止提现（查询失败时同样视为被限制，延后转换）
+func (s *BonusService) conversionBlocked(ctx context.Context, userID int64) bool {
+	if s.restrictions == nil {
+		return false
+	}
+	err := s.restrictions.Check(ctx, userID, model.RestrictionNoWithdrawal)
+	if err != nil && !errors.Is(err, ErrAccountRestricted) {
+		s.logger.Error("Check bonus conversion restriction failed", zap.Int64("user_id", userID), zap.Error(err))
+	}
+	return err != nil
+}
+
+// convertTx 将用户全部奖励余额转为真实余额并记账（奖励余额为 0 时返回 nil）
+func (s *BonusService) convertTx(ctx context.Context, tx pgx.Tx, userID int64) (*model.BonusConversion, error) {
+	amount, newBalance, err := s.userRepo.ConvertBonusBalanceTx(ctx, tx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("convert bonus balance: %w", err)
+	}
+	if !amount.IsPositive() {
+		return nil, nil
+	}
+
+	remark := "奖励流水达标转入余额"
+	err = s.txRepo.BatchCreateTx(ctx, tx, []*model.BalanceTransaction{
+		{
+			UserID:        userID,
+			Type:          model.TxBonusConvert,
+			Amount:        amount.Neg(),
+			BalanceBefore: amount,
+			BalanceAfter:  decimal.Zero,
+			BalanceField:  "bonus_balance",
+			Remark:        &remark,
+		},
+		{
+			UserID:        userID,
+			Type:          model.TxBonusConvert,
+			Amount:        amount,
+			BalanceBefore: newBalance.Sub(amount),
+			BalanceAfter:  newBalance,
+			Remark:        &remark,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Bonus converted to real balance",
+		zap.Int64("user_id", userID),
+		zap.String("amount", amount.String()))
+
+	return &model.BonusConversion{
+		UserID:          userID,
+		Amount:          amount,
+		NewBalance:      newBalance,
+		NewBonusBalance: decimal.Zero,
+	}, nil
+}
+
+// applyWager 按发放顺序将下注流水累计到进行中的奖励上（先填满最早的奖励）
+// 直接修改 grants，返回有变化的奖励
+func applyWager(grants []*model.BonusGrant, wager decimal.Decimal) []*model.BonusGrant {
+	var changed []*model.BonusGrant
+	remaining := wager
+	for _, g := range grants {
+		if !remaining.IsPositive() {
+			break
+		}
+		if g.Status != model.BonusGrantActive {
+			continue
+		}
+		need := g.WageringRequired.Sub(g.Wagered)
+		fill := decimal.Min(need, remaining)
+		if fill.IsPositive() {
+			g.Wagered = g.Wagered.Add(fill)
+			remaining = remaining.Sub(fill)
+		}
+		if g.Wagered.GreaterThanOrEqual(g.WageringRequired) {
+			g.Status = model.BonusGrantCompleted
+		}
+		changed = append(changed, g)
+	}
+	return changed
+}
+
+// allGrantsCompleted 所有奖励的流水是否均已达标
+func allGrantsCompleted(grants []*model.BonusGrant) bool {
+	for _, g := range grants {
+		if g.Status != model.BonusGrantCompleted {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,145 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/fiveseconds/server/internal/config"
+	"github.com/fiveseconds/server/internal/model"
+	"github.com/shopspring/decimal"
+)
+
+// TestApplyWager 测试下注流水按发放顺序累计到进行中的奖励上
+func TestApplyWager(t *testing.T) {
+	d := decimal.RequireFromString
+	type grant struct {
+		required, wagered string
+		status            model.BonusGrantStatus
+	}
+	active, done := model.BonusGrantActive, model.BonusGrantCompleted
+	tests := []struct {
+		name    string
+		grants  []grant
+		wager   string
+		want    []grant
+		changed int
+	}{
+		{
+			name:    "fills the oldest grant first",
+			grants:  []grant{{"100", "0", active}, {"50", "0", active}},
+			wager:   "30",
+			want:    []grant{{"100", "30", active}, {"50", "0", active}},
+			changed: 1,
+		},
+		{
+			name:    "overflow moves to the next grant",
+			grants:  []grant{{"100", "90", active}, {"50", "0", active}},
+			wager:   "25.50",
+			want:    []grant{{"100", "100", done}, {"50", "15.50", active}},
+			changed: 2,
+		},
+		{
+			name:    "exact fill completes",
+			grants:  []grant{{"100", "40", active}},
+			wager:   "60",
+			want:    []grant{{"100", "100", done}},
+			changed: 1,
+		},
+		{
+			name:    "wager beyond every requirement is dropped",
+			grants:  []grant{{"10", "0", active}, {"20", "5", active}},
+			wager:   "1000",
+			want:    []grant{{"10", "10", done}, {"20", "20", done}},
+			changed: 2,
+		},
+		{
+			name:    "grants that are not active are skipped",
+			grants:  []grant{{"10", "10", done}, {"30", "0", model.BonusGrantForfeited}, {"20", "0", active}},
+			wager:   "5",
+			want:    []grant{{"10", "10", done}, {"30", "0", model.BonusGrantForfeited}, {"20", "5", active}},
+			changed: 1,
+		},
+		{
+			name:    "zero wager changes nothing",
+			grants:  []grant{{"10", "0", active}},
+			wager:   "0",
+			want:    []grant{{"10", "0", active}},
+			changed: 0,
+		},
+		{
+			name:    "an active grant already at its requirement is completed",
+			grants:  []grant{{"10", "10", active}, {"10", "0", active}},
+			wager:   "1",
+			want:    []grant{{"10", "10", done}, {"10", "1", active}},
+			changed: 2,
+		},
+	}
+	for _, tt := range tests {
+		grants := make([]*model.BonusGrant, len(tt.grants))
+		for i, g := range tt.grants {
+			grants[i] = &model.BonusGrant{ID: int64(i + 1), WageringRequired: d(g.required), Wagered: d(g.wagered), Status: g.status}
+		}
+		changed := applyWager(grants, d(tt.wager))
+		if len(changed) != tt.changed {
+			t.Errorf("%s: Expected %d changed grants, got %d", tt.name, tt.changed, len(changed))
+		}
+		for i, want := range tt.want {
+			if g := grants[i]; !g.Wagered.Equal(d(want.wagered)) || g.Status != want.status {
+				t.Errorf("%s: grant %d expected %s/%s, got %s/%s", tt.name, i+1, want.wagered, want.status, g.Wagered, g.Status)
+			}
+		}
+	}
+}
+
+// TestAllGrantsCompleted 测试所有奖励流水达标判断
+func TestAllGrantsCompleted(t *testing.T) {
+	completed := &model.BonusGrant{Status: model.BonusGrantCompleted}
+	active := &model.BonusGrant{Status: model.BonusGrantActive}
+	tests := []struct {
+		grants []*model.BonusGrant
+		want   bool
+	}{
+		{nil, true},
+		{[]*model.BonusGrant{completed}, true},
+		{[]*model.BonusGrant{completed, active}, false},
+		{[]*model.BonusGrant{active}, false},
+	}
+	for i, tt := range tests {
+		if got := allGrantsCompleted(tt.grants); got != tt.want {
+			t.Errorf("case %d: Expected %v, got %v", i, tt.want, got)
+		}
+	}
+}
+
+// TestResolveMultiplier 测试流水倍数校验：为 0 时取默认倍数，不能为负或超过上限
+func TestResolveMultiplier(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Bonus.DefaultWageringMultiplier = 20
+	cfg.Bonus.MaxWageringMultiplier = 50
+	s := &BonusService{cfg: cfg}
+
+	tests := []struct {
+		in   string
+		want string
+		err  error
+	}{
+		{"0", "20", nil},
+		{"1", "1", nil},
+		{"35.5", "35.5", nil},
+		{"50", "50", nil},
+		{"50.01", "0", ErrBonusInvalidMultiplier},
+		{"-1", "0", ErrBonusInvalidMultiplier},
+	}
+	for _, tt := range tests {
+		got, err := s.resolveMultiplier(decimal.RequireFromString(tt.in))
+		if !errors.Is(err, tt.err) || !got.Equal(decimal.RequireFromString(tt.want)) {
+			t.Errorf("resolveMultiplier(%s): Expected (%s, %v), got (%s, %v)", tt.in, tt.want, tt.err, got, err)
+		}
+	}
+
+	// 未配置上限时不限制
+	cfg.Bonus.MaxWageringMultiplier = 0
+	if got, err := s.resolveMultiplier(decimal.NewFromInt(1000)); err != nil || !got.Equal(decimal.NewFromInt(1000)) {
+		t.Errorf("Expected 1000 without a limit, got (%s, %v)", got, err)
+	}
+}
@@ -148,6 +148,7 @@ type ChatService struct {
 	filter          *ContentFilter
 	chatRateLimiter *RateLimiter
 	emojiRateLimiter *RateLimiter
+	restrictions    AccountRestrictionChecker
 	logger          *zap.Logger
 }
 
@@ -162,9 +163,21 @@ func NewChatService(repo *repository.ChatRepo, logger *zap.Logger) *ChatService
 	}
 }
 
+// SetRestrictionChecker 设置账户限制检查（被禁言或封停的玩家不能发送聊天消息）
+func (s *ChatService) SetRestrictionChecker(restrictions AccountRestrictionChecker) {
+	s.restrictions = restrictions
+}
+
 
 // SendMessage 发送聊天消息
 func (s *ChatService) SendMessage(ctx context.Context, roomID, userID int64, username, content string) (*model.ChatMessage, error) {
+	// 检查禁言
+	if s.restrictions != nil {
+		if err := s.restrictions.Check(ctx, userID, model.RestrictionChatMuted); err != nil {
+			return nil, err
+		}
+	}
+
 	// 检查限流
 	key := fmt.Sprintf("chat:%d", userID)
 	if !s.chatRateLimiter.Allow(key) {
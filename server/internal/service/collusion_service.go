@@ -0,0 +1,460 @@
+package service
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/fiveseconds/server/internal/config"
+	"github.com/fiveseconds/server/internal/model"
+	"github.com/fiveseconds/server/internal/repository"
+
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+const (
+	// DefaultCollusionWindowDays 未配置时分析的时间窗口（天）
+	DefaultCollusionWindowDays = 7
+	// DefaultCollusionBatchHour 未配置时夜间全量分析的执行时刻
+	DefaultCollusionBatchHour = 3
+	// DefaultCollusionIncrementalMaxRounds 未配置时增量分析最多读取的历史回合数
+	DefaultCollusionIncrementalMaxRounds = 500
+)
+
+// collusionParams 串通判定阈值
+type collusionParams struct {
+	MinCoPlayRounds  int
+	MinJointWins     int
+	JointWinRatio    float64
+	FunnelMinSources int
+	FunnelMinAmount  decimal.Decimal
+}
+
+// collusionGraph 串通分析的输入：同场回合、身份关联与转账
+type collusionGraph struct {
+	rounds    []*model.CollusionRound
+	links     []*model.CollusionLink
+	transfers []*model.CollusionTransfer
+}
+
+// CollusionService 串通分析
+// 以账户为节点构建关联图：两两同场且联合胜率异常、共用设备指纹或登录 IP、相互转账均构成边，
+// 连通分量即候选账户簇；簇内成员同场时联合胜率显著高于随机期望，或多个成员向同一账户归集资金时判定为疑似串通。
+// 判定结果由 RiskControlService 创建风控标记
+type CollusionService struct {
+	repo   *repository.CollusionRepo
+	cfg    *config.Config
+	logger *zap.Logger
+}
+
+// NewCollusionService 创建串通分析服务
+func NewCollusionService(repo *repository.CollusionRepo, cfg *config.Config, logger *zap.Logger) *CollusionService {
+	return &CollusionService{
+		repo:   repo,
+		cfg:    cfg,
+		logger: logger.With(zap.String("service", "collusion")),
+	}
+}
+
+// window 分析的时间窗口
+func (s *CollusionService) window() time.Duration {
+	days := s.cfg.Collusion.WindowDays
+	if days <= 0 {
+		days = DefaultCollusionWindowDays
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// params 串通判定阈值（未配置的项使用默认值）
+func (s *CollusionService) params() collusionParams {
+	c := s.cfg.Collusion
+	p := collusionParams{
+		MinCoPlayRounds:  c.MinCoPlayRounds,
+		MinJointWins:     c.MinJointWins,
+		JointWinRatio:    c.JointWinRatio,
+		FunnelMinSources: c.FunnelMinSources,
+		FunnelMinAmount:  decimal.NewFromFloat(c.FunnelMinAmount),
+	}
+	if p.MinCoPlayRounds <= 0 {
+		p.MinCoPlayRounds = 20
+	}
+	if p.MinJointWins <= 0 {
+		p.MinJointWins = 5
+	}
+	if p.JointWinRatio <= 1 {
+		p.JointWinRatio = 1.5
+	}
+	if p.FunnelMinSources <= 1 {
+		p.FunnelMinSources = 3
+	}
+	if !p.FunnelMinAmount.IsPositive() {
+		p.FunnelMinAmount = decimal.NewFromInt(1000)
+	}
+	return p
+}
+
+// NextBatchTime 下一次夜间全量分析的时间
+func (s *CollusionService) NextBatchTime(now time.Time) time.Time {
+	hour := s.cfg.Collusion.BatchHour
+	if hour < 0 || hour > 23 {
+		hour = DefaultCollusionBatchHour
+	}
+	next := time.Date(now.Year(), now.Month(), now.Day(), hour, 0, 0, 0, now.Location())
+	if !next.After(now) {
+		next = next.AddDate(0, 0, 1)
+	}
+	return next
+}
+
+// AnalyzeAll 全量分析窗口内的全部账户
+func (s *CollusionService) AnalyzeAll(ctx context.Context, now time.Time) ([]*model.CollusionCluster, error) {
+	graph, err := s.load(ctx, now.Add(-s.window()), nil, 0)
+	if err != nil {
+		return nil, err
+	}
+	clusters := detectCollusion(graph, s.params())
+	s.logger.Info("Collusion batch analyzed",
+		zap.Int("rounds", len(graph.rounds)),
+		zap.Int("links", len(graph.links)),
+		zap.Int("transfers", len(graph.transfers)),
+		zap.Int("clusters", len(clusters)))
+	return clusters, nil
+}
+
+// AnalyzeParticipants 回合结算后的增量分析：只读取与本回合参与者相关的回合、关联与转账，
+// 返回包含本回合参与者的疑似串通账户簇（完整结论以夜间全量分析为准）
+func (s *CollusionService) AnalyzeParticipants(ctx context.Context, participants []int64) ([]*model.CollusionCluster, error) {
+	if len(participants) < 2 {
+		return nil, nil
+	}
+	maxRounds := s.cfg.Collusion.IncrementalMaxRounds
+	if maxRounds <= 0 {
+		maxRounds = DefaultCollusionIncrementalMaxRounds
+	}
+	graph, err := s.load(ctx, time.Now().Add(-s.window()), participants, maxRounds)
+	if err != nil {
+		return nil, err
+	}
+
+	focus := make(map[int64]bool, len(participants))
+	for _, id := range participants {
+		focus[id] = true
+	}
+	var result []*model.CollusionCluster
+	for _, cluster := range detectCollusion(graph, s.params()) {
+		for _, id := range cluster.UserIDs {
+			if focus[id] {
+				result = append(result, cluster)
+				break
+			}
+		}
+	}
+	return result, nil
+}
+
+// load 读取分析所需的数据
+func (s *CollusionService) load(ctx context.Context, since time.Time, userIDs []int64, maxRounds int) (*collusionGraph, error) {
+	graph := &collusionGraph{}
+	err := s.repo.ForEachRound(ctx, since, userIDs, maxRounds, func(round *model.CollusionRound) error {
+		graph.rounds = append(graph.rounds, round)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if graph.links, err = s.repo.ListIdentityLinks(ctx, since, userIDs); err != nil {
+		return nil, err
+	}
+	if graph.transfers, err = s.repo.ListTransfers(ctx, since, userIDs); err != nil {
+		return nil, err
+	}
+	return graph, nil
+}
+
+// collusionStats 同场统计
+type collusionStats struct {
+	rounds   int
+	wins     int
+	expected float64
+}
+
+// abnormal 联合胜率是否显著高于随机期望
+func (st *collusionStats) abnormal(p collusionParams) bool {
+	return st.rounds >= p.MinCoPlayRounds &&
+		st.wins >= p.MinJointWins &&
+		float64(st.wins) >= p.JointWinRatio*st.expected
+}
+
+// collusionNoneWinProb 随机开奖时指定 k 名参与者均未获胜的概率（n 人中抽取 w 名赢家）
+func collusionNoneWinProb(n, w, k int) float64 {
+	if n-w < k {
+		return 0
+	}
+	p := 1.0
+	for i := 0; i < k; i++ {
+		p *= float64(n-w-i) / float64(n-i)
+	}
+	return p
+}
+
+// collusionUnionFind 账户并查集
+type collusionUnionFind map[int64]int64
+
+func (uf collusionUnionFind) find(x int64) int64 {
+	parent, ok := uf[x]
+	if !ok {
+		uf[x] = x
+		return x
+	}
+	if parent == x {
+		return x
+	}
+	root := uf.find(parent)
+	uf[x] = root
+	return root
+}
+
+func (uf collusionUnionFind) union(a, b int64) {
+	ra, rb := uf.find(a), uf.find(b)
+	if ra == rb {
+		return
+	}
+	// 以较小的账户ID为根，使结果与输入顺序无关
+	if ra < rb {
+		uf[rb] = ra
+	} else {
+		uf[ra] = rb
+	}
+}
+
+// collusionRoundSets 回合的参与者集合与赢家集合（只计参与者中的赢家）
+func collusionRoundSets(round *model.CollusionRound) (map[int64]bool, map[int64]bool) {
+	participants := make(map[int64]bool, len(round.ParticipantIDs))
+	for _, id := range round.ParticipantIDs {
+		participants[id] = true
+	}
+	winners := make(map[int64]bool, len(round.WinnerIDs))
+	for _, id := range round.WinnerIDs {
+		if participants[id] {
+			winners[id] = true
+		}
+	}
+	return participants, winners
+}
+
+// detectCollusion 在关联图上识别疑似串通的账户簇
+// 1. 两两同场且联合胜率异常、共用设备指纹或 IP、相互转账的账户合并为候选簇；
+// 2. 统计簇内至少两名成员同场的回合中有成员获胜的次数，与随机开奖期望比较；
+// 3. 统计同一房主名下多个成员向同一成员转账的归集情况；
+// 只返回联合胜率异常或存在资金归集的簇，成员按账户ID升序
+func detectCollusion(g *collusionGraph, p collusionParams) []*model.CollusionCluster {
+	uf := make(collusionUnionFind)
+
+	// 两两同场统计
+	pairs := make(map[[2]int64]*collusionStats)
+	for _, round := range g.rounds {
+		participants, winners := collusionRoundSets(round)
+		n, w := len(participants), len(winners)
+		if n < 2 || w == 0 || w >= n {
+			continue
+		}
+		ids := make([]int64, 0, n)
+		for id := range participants {
+			ids = append(ids, id)
+		}
+		expected := 1 - collusionNoneWinProb(n, w, 2)
+		for i := 0; i < len(ids); i++ {
+			for j := i + 1; j < len(ids); j++ {
+				a, b := ids[i], ids[j]
+				if a > b {
+					a, b = b, a
+				}
+				st := pairs[[2]int64{a, b}]
+				if st == nil {
+					st = &collusionStats{}
+					pairs[[2]int64{a, b}] = st
+				}
+				st.rounds++
+				st.expected += expected
+				if winners[a] || winners[b] {
+					st.wins++
+				}
+			}
+		}
+	}
+	for key, st := range pairs {
+		if st.abnormal(p) {
+			uf.union(key[0], key[1])
+		}
+	}
+	for _, link := range g.links {
+		if link.UserA != link.UserB {
+			uf.union(link.UserA, link.UserB)
+		}
+	}
+	for _, t := range g.transfers {
+		if t.FromUserID != t.ToUserID {
+			uf.union(t.FromUserID, t.ToUserID)
+		}
+	}
+
+	// 候选簇
+	members := make(map[int64][]int64)
+	for id := range uf {
+		root := uf.find(id)
+		members[root] = append(members[root], id)
+	}
+	clusters := make(map[int64]*model.CollusionCluster)
+	stats := make(map[int64]*collusionStats)
+	for root, ids := range members {
+		if len(ids) < 2 {
+			continue
+		}
+		sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+		clusters[root] = &model.CollusionCluster{UserIDs: ids}
+		stats[root] = &collusionStats{}
+	}
+	inCluster := func(id int64) (int64, bool) {
+		if _, ok := uf[id]; !ok {
+			return 0, false
+		}
+		root := uf.find(id)
+		_, ok := clusters[root]
+		return root, ok
+	}
+
+	// 簇内成员同场统计
+	for _, round := range g.rounds {
+		participants, winners := collusionRoundSets(round)
+		n, w := len(participants), len(winners)
+		if n < 2 || w == 0 || w >= n {
+			continue
+		}
+		present := make(map[int64]int)
+		won := make(map[int64]bool)
+		for id := range participants {
+			if root, ok := inCluster(id); ok {
+				present[root]++
+				if winners[id] {
+					won[root] = true
+				}
+			}
+		}
+		for root, k := range present {
+			if k < 2 {
+				continue
+			}
+			st := stats[root]
+			st.rounds++
+			st.expected += 1 - collusionNoneWinProb(n, w, k)
+			if won[root] {
+				st.wins++
+			}
+		}
+	}
+
+	// 身份关联
+	devices := make(map[int64]map[string]bool)
+	ips := make(map[int64]map[string]bool)
+	for _, link := range g.links {
+		root, ok := inCluster(link.UserA)
+		if !ok {
+			continue
+		}
+		target := ips
+		if link.Kind == model.CollusionLinkDevice {
+			target = devices
+		}
+		if target[root] == nil {
+			target[root] = make(map[string]bool)
+		}
+		target[root][link.Value] = true
+	}
+
+	// 资金归集：同一房主名下各成员向同一成员的转账
+	type funnelKey struct {
+		root, owner, to int64
+	}
+	type funnelAgg struct {
+		sources map[int64]bool
+		amount  decimal.Decimal
+	}
+	funnels := make(map[funnelKey]*funnelAgg)
+	transferred := make(map[int64]bool)
+	for _, t := range g.transfers {
+		root, ok := inCluster(t.ToUserID)
+		if !ok || t.FromUserID == t.ToUserID {
+			continue
+		}
+		transferred[root] = true
+		key := funnelKey{root: root, owner: t.OwnerID, to: t.ToUserID}
+		agg := funnels[key]
+		if agg == nil {
+			agg = &funnelAgg{sources: make(map[int64]bool), amount: decimal.Zero}
+			funnels[key] = agg
+		}
+		agg.sources[t.FromUserID] = true
+		agg.amount = agg.amount.Add(t.Amount)
+	}
+	for key, agg := range funnels {
+		if len(agg.sources) < p.FunnelMinSources || agg.amount.LessThan(p.FunnelMinAmount) {
+			continue
+		}
+		c := clusters[key.root]
+		// 同一簇有多个归集目标时保留金额最大者（金额相同取账户ID较小者）
+		if c.FunnelUserID != nil {
+			cmp := agg.amount.Cmp(c.FunnelAmount)
+			if cmp < 0 || (cmp == 0 && (key.to > *c.FunnelUserID || (key.to == *c.FunnelUserID && key.owner > *c.FunnelOwnerID))) {
+				continue
+			}
+		}
+		to, owner := key.to, key.owner
+		c.FunnelUserID = &to
+		c.FunnelOwnerID = &owner
+		c.FunnelSources = len(agg.sources)
+		c.FunnelAmount = agg.amount
+	}
+
+	var result []*model.CollusionCluster
+	for root, c := range clusters {
+		st := stats[root]
+		c.CoPlayRounds = st.rounds
+		c.JointWins = st.wins
+		c.ExpectedJointWins = st.expected
+
+		if st.abnormal(p) {
+			c.Signals = append(c.Signals, model.CollusionSignalJointWin)
+		}
+		if c.FunnelUserID != nil {
+			c.Signals = append(c.Signals, model.CollusionSignalFunnel)
+		}
+		if len(c.Signals) == 0 {
+			continue
+		}
+		if len(devices[root]) > 0 {
+			c.Signals = append(c.Signals, model.CollusionSignalSharedDevice)
+			c.SharedDevices = sortedKeys(devices[root])
+		}
+		if len(ips[root]) > 0 {
+			c.Signals = append(c.Signals, model.CollusionSignalSharedIP)
+			c.SharedIPs = sortedKeys(ips[root])
+		}
+		if transferred[root] {
+			c.Signals = append(c.Signals, model.CollusionSignalTransfer)
+		}
+		result = append(result, c)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].UserIDs[0] < result[j].UserIDs[0] })
+	return result
+}
+
+// sortedKeys 集合的有序元素
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for k := range set {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
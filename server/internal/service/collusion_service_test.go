@@ -0,0 +1,196 @@
+package service
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/fiveseconds/server/internal/model"
+	"github.com/shopspring/decimal"
+)
+
+func testCollusionParams() collusionParams {
+	return collusionParams{
+		MinCoPlayRounds:  20,
+		MinJointWins:     5,
+		JointWinRatio:    1.5,
+		FunnelMinSources: 3,
+		FunnelMinAmount:  decimal.NewFromInt(1000),
+	}
+}
+
+// sharedRounds 账户 1、2 与三名每回合不同的玩家同场 count 回合，winner 返回第 i 回合的唯一赢家
+func sharedRounds(count int, winner func(i int) int64) []*model.CollusionRound {
+	rounds := make([]*model.CollusionRound, count)
+	for i := range rounds {
+		base := int64(100 * (i + 1))
+		rounds[i] = &model.CollusionRound{
+			ID:             int64(i + 1),
+			ParticipantIDs: []int64{1, 2, base + 1, base + 2, base + 3},
+			WinnerIDs:      []int64{winner(i)},
+		}
+	}
+	return rounds
+}
+
+// funnelTransfers sources 个账户（10 起）各向账户 1 转账 amount
+func funnelTransfers(sources int, amount int64) []*model.CollusionTransfer {
+	transfers := make([]*model.CollusionTransfer, sources)
+	for i := range transfers {
+		transfers[i] = &model.CollusionTransfer{
+			FromUserID: int64(10 + i), ToUserID: 1, OwnerID: 500, Amount: decimal.NewFromInt(amount),
+		}
+	}
+	return transfers
+}
+
+// TestCollusionNoneWinProb 测试随机开奖时 k 名参与者均未获胜的概率
+func TestCollusionNoneWinProb(t *testing.T) {
+	tests := []struct {
+		n, w, k int
+		want    float64
+	}{
+		{4, 1, 2, 0.5},
+		{10, 2, 2, 56.0 / 90},
+		{10, 3, 3, 210.0 / 720},
+		{6, 3, 1, 0.5},
+		{3, 1, 0, 1},
+		{5, 4, 2, 0}, // 未获胜者不足 k 人
+		{3, 1, 3, 0},
+	}
+	for _, tt := range tests {
+		if got := collusionNoneWinProb(tt.n, tt.w, tt.k); math.Abs(got-tt.want) > 1e-12 {
+			t.Errorf("collusionNoneWinProb(%d, %d, %d): Expected %v, got %v", tt.n, tt.w, tt.k, tt.want, got)
+		}
+	}
+}
+
+// TestDetectCollusion 测试串通判定：持续联合获胜与资金归集被识别，仅有身份关联或随机范围内的胜率不判定
+func TestDetectCollusion(t *testing.T) {
+	alternating := func(i int) int64 { return int64(1 + i%2) }
+	// 8 回合由账户 1 获胜，其余由同场的其他玩家获胜（期望 0.4 × 20 = 8）
+	atChance := func(i int) int64 {
+		if i < 8 {
+			return 1
+		}
+		return int64(100*(i+1) + 1)
+	}
+	tests := []struct {
+		name      string
+		graph     *collusionGraph
+		userIDs   []int64
+		signals   []model.CollusionSignal
+		coPlay    int
+		jointWins int
+	}{
+		{"pair wins every shared round", &collusionGraph{rounds: sharedRounds(25, alternating)},
+			[]int64{1, 2}, []model.CollusionSignal{model.CollusionSignalJointWin}, 25, 25},
+		{"too few shared rounds", &collusionGraph{rounds: sharedRounds(19, alternating)}, nil, nil, 0, 0},
+		{"wins at chance", &collusionGraph{rounds: sharedRounds(20, atChance)}, nil, nil, 0, 0},
+		{"identity links alone", &collusionGraph{links: []*model.CollusionLink{
+			{UserA: 1, UserB: 2, Kind: model.CollusionLinkDevice, Value: "d1"},
+			{UserA: 2, UserB: 3, Kind: model.CollusionLinkIP, Value: "10.0.0.1"},
+		}}, nil, nil, 0, 0},
+		{"joint wins with shared identity", &collusionGraph{
+			rounds: sharedRounds(25, alternating),
+			links: []*model.CollusionLink{
+				{UserA: 1, UserB: 2, Kind: model.CollusionLinkDevice, Value: "d1"},
+				{UserA: 2, UserB: 1, Kind: model.CollusionLinkIP, Value: "10.0.0.1"},
+				{UserA: 1, UserB: 1, Kind: model.CollusionLinkIP, Value: "10.0.0.9"},
+			},
+		}, []int64{1, 2}, []model.CollusionSignal{
+			model.CollusionSignalJointWin, model.CollusionSignalSharedDevice, model.CollusionSignalSharedIP,
+		}, 25, 25},
+		{"funnel", &collusionGraph{transfers: funnelTransfers(3, 400)},
+			[]int64{1, 10, 11, 12}, []model.CollusionSignal{model.CollusionSignalFunnel, model.CollusionSignalTransfer}, 0, 0},
+		{"funnel below amount", &collusionGraph{transfers: funnelTransfers(3, 300)}, nil, nil, 0, 0},
+		{"funnel with too few sources", &collusionGraph{transfers: funnelTransfers(2, 600)}, nil, nil, 0, 0},
+	}
+	for _, tt := range tests {
+		clusters := detectCollusion(tt.graph, testCollusionParams())
+		if tt.userIDs == nil {
+			if len(clusters) != 0 {
+				t.Errorf("%s: Expected no clusters, got %+v", tt.name, clusters[0])
+			}
+			continue
+		}
+		if len(clusters) != 1 {
+			t.Errorf("%s: Expected 1 cluster, got %d", tt.name, len(clusters))
+			continue
+		}
+		c := clusters[0]
+		if !reflect.DeepEqual(c.UserIDs, tt.userIDs) || !reflect.DeepEqual(c.Signals, tt.signals) {
+			t.Errorf("%s: Expected users %v signals %v, got %v %v", tt.name, tt.userIDs, tt.signals, c.UserIDs, c.Signals)
+		}
+		if c.CoPlayRounds != tt.coPlay || c.JointWins != tt.jointWins {
+			t.Errorf("%s: Expected %d shared rounds and %d joint wins, got %d and %d",
+				tt.name, tt.coPlay, tt.jointWins, c.CoPlayRounds, c.JointWins)
+		}
+	}
+}
+
+// TestDetectCollusionDetails 测试簇的期望胜场、共用身份与归集目标明细
+func TestDetectCollusionDetails(t *testing.T) {
+	g := &collusionGraph{
+		rounds: sharedRounds(25, func(i int) int64 { return 1 }),
+		links: []*model.CollusionLink{
+			{UserA: 2, UserB: 1, Kind: model.CollusionLinkDevice, Value: "d2"},
+			{UserA: 1, UserB: 2, Kind: model.CollusionLinkDevice, Value: "d1"},
+		},
+		// 同一簇内两个归集目标，保留金额较大的账户 1
+		transfers: append(funnelTransfers(3, 400), []*model.CollusionTransfer{
+			{FromUserID: 20, ToUserID: 2, OwnerID: 500, Amount: decimal.NewFromInt(500)},
+			{FromUserID: 21, ToUserID: 2, OwnerID: 500, Amount: decimal.NewFromInt(500)},
+			{FromUserID: 22, ToUserID: 2, OwnerID: 500, Amount: decimal.NewFromInt(100)},
+		}...),
+	}
+	clusters := detectCollusion(g, testCollusionParams())
+	if len(clusters) != 1 {
+		t.Fatalf("Expected 1 cluster, got %d", len(clusters))
+	}
+	c := clusters[0]
+	if want := []int64{1, 2, 10, 11, 12, 20, 21, 22}; !reflect.DeepEqual(c.UserIDs, want) {
+		t.Errorf("Expected users %v, got %v", want, c.UserIDs)
+	}
+	// 5 人 1 名赢家，两人中有人获胜的概率为 1 - 4/5 × 3/4 = 0.4
+	if math.Abs(c.ExpectedJointWins-10) > 1e-9 {
+		t.Errorf("Expected 10 expected joint wins, got %v", c.ExpectedJointWins)
+	}
+	if want := []string{"d1", "d2"}; !reflect.DeepEqual(c.SharedDevices, want) || c.SharedIPs != nil {
+		t.Errorf("Expected devices %v and no IPs, got %v %v", want, c.SharedDevices, c.SharedIPs)
+	}
+	if c.FunnelUserID == nil || *c.FunnelUserID != 1 || *c.FunnelOwnerID != 500 ||
+		c.FunnelSources != 3 || c.FunnelAmount.String() != "1200" {
+		t.Errorf("Expected funnel of 1200 from 3 sources into user 1, got %+v", c)
+	}
+}
+
+// TestDetectCollusionIgnoresInputOrder 测试判定结果与回合、关联、转账的输入顺序无关
+func TestDetectCollusionIgnoresInputOrder(t *testing.T) {
+	g := &collusionGraph{
+		rounds: append(sharedRounds(25, func(i int) int64 { return int64(1 + i%2) }),
+			&model.CollusionRound{ID: 99, ParticipantIDs: []int64{30, 31, 32}, WinnerIDs: []int64{30, 99}}),
+		links: []*model.CollusionLink{
+			{UserA: 2, UserB: 1, Kind: model.CollusionLinkIP, Value: "10.0.0.2"},
+			{UserA: 1, UserB: 2, Kind: model.CollusionLinkIP, Value: "10.0.0.1"},
+			{UserA: 40, UserB: 41, Kind: model.CollusionLinkDevice, Value: "d4"},
+		},
+		transfers: append(funnelTransfers(4, 300), &model.CollusionTransfer{FromUserID: 2, ToUserID: 50, OwnerID: 500, Amount: decimal.NewFromInt(5)}),
+	}
+	reversed := &collusionGraph{}
+	for i := len(g.rounds) - 1; i >= 0; i-- {
+		reversed.rounds = append(reversed.rounds, g.rounds[i])
+	}
+	for i := len(g.links) - 1; i >= 0; i-- {
+		reversed.links = append(reversed.links, g.links[i])
+	}
+	for i := len(g.transfers) - 1; i >= 0; i-- {
+		reversed.transfers = append(reversed.transfers, g.transfers[i])
+	}
+
+	want := detectCollusion(g, testCollusionParams())
+	got := detectCollusion(reversed, testCollusionParams())
+	if len(want) != 1 || !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected the same single cluster, got %+v and %+v", want, got)
+	}
+}
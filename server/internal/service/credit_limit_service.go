@@ -0,0 +1,253 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/fiveseconds/server/internal/config"
+	"github.com/fiveseconds/server/internal/model"
+	"github.com/fiveseconds/server/internal/repository"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrCreditLimitExceeded     = errors.New("deposit would exceed the owner's credit limit, margin must be topped up first")
+	ErrCreditLimitBreached     = errors.New("owner exceeds credit limit, rooms stay paused until margin is topped up or player balances drop")
+	ErrInvalidCreditMultiplier = errors.New("margin multiplier must not be negative")
+	ErrCreditLimitNotOwner     = errors.New("user is not an owner")
+)
+
+// CreditLimitService 房主信用额度服务
+// 名下玩家余额总额（敞口）不得超过 K × 保证金：玩家充值超限时拒绝，
+// 巡检发现超限（返水、推荐奖励、奖励转化等使玩家余额增加）时暂停房主全部房间并告警
+type CreditLimitService struct {
+	creditRepo   *repository.CreditLimitRepo
+	roomRepo     *repository.RoomRepo
+	roomService  *RoomService
+	alertManager *AlertManager
+	cfg          *config.Config
+	logger       *zap.Logger
+}
+
+// NewCreditLimitService 创建房主信用额度服务
+func NewCreditLimitService(
+	creditRepo *repository.CreditLimitRepo,
+	roomRepo *repository.RoomRepo,
+	roomService *RoomService,
+	alertManager *AlertManager,
+	cfg *config.Config,
+	logger *zap.Logger,
+) *CreditLimitService {
+	return &CreditLimitService{
+		creditRepo:   creditRepo,
+		roomRepo:     roomRepo,
+		roomService:  roomService,
+		alertManager: alertManager,
+		cfg:          cfg,
+		logger:       logger.With(zap.String("service", "credit_limit")),
+	}
+}
+
+// evaluate 按生效倍数计算额度与剩余额度
+func (s *CreditLimitService) evaluate(exposure *model.OwnerExposure) *model.OwnerExposure {
+	multiplier := decimal.NewFromFloat(s.cfg.CreditLimit.MarginMultiplier)
+	if exposure.CustomMultiplier != nil {
+		multiplier = *exposure.CustomMultiplier
+	}
+	applyCreditLimit(exposure, multiplier)
+	return exposure
+}
+
+// GetExposure 获取房主当前敞口与信用额度
+func (s *CreditLimitService) GetExposure(ctx context.Context, ownerID int64) (*model.OwnerExposure, error) {
+	exposure, err := s.creditRepo.GetExposureTx(ctx, nil, ownerID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrCreditLimitNotOwner
+		}
+		return nil, err
+	}
+	return s.evaluate(exposure), nil
+}
+
+// ListExposures 获取全部房主的敞口与信用额度
+func (s *CreditLimitService) ListExposures(ctx context.Context) ([]*model.OwnerExposure, error) {
+	exposures, err := s.creditRepo.ListExposures(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, exposure := range exposures {
+		s.evaluate(exposure)
+	}
+	return exposures, nil
+}
+
+// CheckDepositTx 校验玩家充值后房主敞口不超出信用额度（在充值事务中调用）
+func (s *CreditLimitService) CheckDepositTx(ctx context.Context, tx pgx.Tx, ownerID int64, amount decimal.Decimal) error {
+	if !s.cfg.CreditLimit.Enabled {
+		return nil
+	}
+	exposure, err := s.creditRepo.GetExposureTx(ctx, tx, ownerID)
+	if err != nil {
+		return fmt.Errorf("get owner exposure: %w", err)
+	}
+	s.evaluate(exposure)
+	if !exposure.Unlimited && exposure.PlayerBalance.Add(amount).GreaterThan(exposure.CreditLimit) {
+		s.logger.Warn("Deposit rejected by credit limit",
+			zap.Int64("owner_id", ownerID),
+			zap.String("player_balance", exposure.PlayerBalance.String()),
+			zap.String("amount", amount.String()),
+			zap.String("credit_limit", exposure.CreditLimit.String()))
+		return ErrCreditLimitExceeded
+	}
+	return nil
+}
+
+// CheckOwnerCredit 房主当前是否未超出信用额度（超限房主不能新建或恢复房间）
+func (s *CreditLimitService) CheckOwnerCredit(ctx context.Context, ownerID int64) error {
+	if !s.cfg.CreditLimit.Enabled {
+		return nil
+	}
+	exposure, err := s.GetExposure(ctx, ownerID)
+	if err != nil {
+		return err
+	}
+	if exposure.Breached {
+		return ErrCreditLimitBreached
+	}
+	return nil
+}
+
+// UpdateMultiplier 管理员设置房主信用额度倍数（nil 恢复默认倍数），设置后立即重新评估
+func (s *CreditLimitService) UpdateMultiplier(ctx context.Context, ownerID, adminID int64, req *model.UpdateOwnerCreditLimitReq) (*model.OwnerExposure, error) {
+	if req.MarginMultiplier != nil && req.MarginMultiplier.IsNegative() {
+		return nil, ErrInvalidCreditMultiplier
+	}
+	if _, err := s.GetExposure(ctx, ownerID); err != nil {
+		return nil, err
+	}
+	if err := s.creditRepo.UpsertMultiplier(ctx, ownerID, req.MarginMultiplier, adminID); err != nil {
+		return nil, err
+	}
+	return s.EnforceOwner(ctx, ownerID)
+}
+
+// EnforceOwner 重新评估单个房主的超限状态
+func (s *CreditLimitService) EnforceOwner(ctx context.Context, ownerID int64) (*model.OwnerExposure, error) {
+	exposure, err := s.GetExposure(ctx, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.enforce(ctx, exposure); err != nil {
+		return nil, err
+	}
+	return exposure, nil
+}
+
+// EnforceAll 巡检全部房主，返回新进入超限状态的房主数
+func (s *CreditLimitService) EnforceAll(ctx context.Context) (int, error) {
+	if !s.cfg.CreditLimit.Enabled {
+		return 0, nil
+	}
+	exposures, err := s.ListExposures(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	breached := 0
+	for _, exposure := range exposures {
+		wasBreached := exposure.BreachedAt != nil
+		if err := s.enforce(ctx, exposure); err != nil {
+			s.logger.Error("Failed to enforce credit limit", zap.Int64("owner_id", exposure.OwnerID), zap.Error(err))
+			continue
+		}
+		if exposure.Breached && !wasBreached {
+			breached++
+		}
+	}
+	return breached, nil
+}
+
+// enforce 处理超限状态变化：新超限时暂停房主全部房间并告警，恢复后清除超限记录
+// 房间只在进入超限时暂停一次，管理员手动恢复的房间不会被重复暂停
+func (s *CreditLimitService) enforce(ctx context.Context, exposure *model.OwnerExposure) error {
+	if !s.cfg.CreditLimit.Enabled {
+		return nil
+	}
+
+	if !exposure.Breached {
+		if exposure.BreachedAt == nil {
+			return nil
+		}
+		if err := s.creditRepo.SetBreachedAt(ctx, exposure.OwnerID, nil); err != nil {
+			return err
+		}
+		s.logger.Info("Owner back within credit limit",
+			zap.Int64("owner_id", exposure.OwnerID),
+			zap.Time("breached_at", *exposure.BreachedAt))
+		exposure.BreachedAt = nil
+		return nil
+	}
+	if exposure.BreachedAt != nil {
+		return nil
+	}
+
+	now := time.Now()
+	if err := s.creditRepo.SetBreachedAt(ctx, exposure.OwnerID, &now); err != nil {
+		return err
+	}
+	exposure.BreachedAt = &now
+
+	paused, err := s.pauseOwnerRooms(ctx, exposure.OwnerID)
+	if err != nil {
+		s.logger.Error("Failed to pause rooms of owner over credit limit", zap.Int64("owner_id", exposure.OwnerID), zap.Error(err))
+	}
+	if s.alertManager != nil {
+		s.alertManager.TriggerCreditLimitBreachAlert(ctx, exposure, paused)
+	}
+	s.logger.Warn("Owner exceeds credit limit, rooms paused",
+		zap.Int64("owner_id", exposure.OwnerID),
+		zap.String("player_balance", exposure.PlayerBalance.String()),
+		zap.String("credit_limit", exposure.CreditLimit.String()),
+		zap.Int("paused_rooms", paused))
+	return nil
+}
+
+// pauseOwnerRooms 暂停房主全部进行中的房间（练习房、锦标赛房间除外），返回暂停的房间数
+func (s *CreditLimitService) pauseOwnerRooms(ctx context.Context, ownerID int64) (int, error) {
+	rooms, err := s.roomRepo.ListByOwner(ctx, ownerID)
+	if err != nil {
+		return 0, err
+	}
+	paused := 0
+	for _, room := range rooms {
+		if room.Status != model.RoomStatusActive || room.UsesChips() {
+			continue
+		}
+		if err := s.roomService.UpdateRoomStatus(ctx, room.ID, ownerID, model.RoomStatusPaused); err != nil {
+			return paused, fmt.Errorf("pause room %d: %w", room.ID, err)
+		}
+		paused++
+	}
+	return paused, nil
+}
+
+// applyCreditLimit 计算额度（K × 保证金，向下取整到分）、剩余额度及是否超限，倍数为 0 表示不限
+func applyCreditLimit(exposure *model.OwnerExposure, multiplier decimal.Decimal) {
+	exposure.MarginMultiplier = multiplier
+	exposure.Unlimited = !multiplier.IsPositive()
+	if exposure.Unlimited {
+		exposure.CreditLimit = decimal.Zero
+		exposure.Available = decimal.Zero
+		exposure.Breached = false
+		return
+	}
+	exposure.CreditLimit = exposure.MarginBalance.Mul(multiplier).RoundFloor(2)
+	exposure.Breached = exposure.PlayerBalance.GreaterThan(exposure.CreditLimit)
+	exposure.Available = decimal.Max(exposure.CreditLimit.Sub(exposure.PlayerBalance), decimal.Zero)
+}
@@ -0,0 +1,76 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/fiveseconds/server/internal/config"
+	"github.com/fiveseconds/server/internal/model"
+	"github.com/shopspring/decimal"
+)
+
+// TestApplyCreditLimit 测试信用额度 = K × 保证金（向下取整到分），敞口超出额度即超限，倍数为 0 时不限
+func TestApplyCreditLimit(t *testing.T) {
+	tests := []struct {
+		name       string
+		margin     string
+		player     string
+		multiplier string
+		limit      string
+		available  string
+		breached   bool
+		unlimited  bool
+	}{
+		{"within limit", "1000", "2500", "3", "3000", "500", false, false},
+		{"exactly at limit", "1000", "3000", "3", "3000", "0", false, false},
+		{"one cent over", "1000", "3000.01", "3", "3000", "0", true, false},
+		{"limit floored to cents", "333.33", "0", "1.5", "499.99", "499.99", false, false},
+		{"fractional multiplier floors before comparing", "0.07", "0.1", "1.5", "0.1", "0", false, false},
+		{"no margin", "0", "0.01", "2", "0", "0", true, false},
+		{"zero multiplier is unlimited", "0", "1000000", "0", "0", "0", false, true},
+		{"negative multiplier is unlimited", "100", "1000000", "-1", "0", "0", false, true},
+	}
+	for _, tt := range tests {
+		exposure := &model.OwnerExposure{
+			MarginBalance: decimal.RequireFromString(tt.margin),
+			PlayerBalance: decimal.RequireFromString(tt.player),
+			Breached:      true,
+		}
+		applyCreditLimit(exposure, decimal.RequireFromString(tt.multiplier))
+		if exposure.CreditLimit.String() != tt.limit || exposure.Available.String() != tt.available ||
+			exposure.Breached != tt.breached || exposure.Unlimited != tt.unlimited {
+			t.Errorf("%s: Expected limit %s, available %s, breached %v, unlimited %v, got %s, %s, %v, %v",
+				tt.name, tt.limit, tt.available, tt.breached, tt.unlimited,
+				exposure.CreditLimit, exposure.Available, exposure.Breached, exposure.Unlimited)
+		}
+		if !exposure.MarginMultiplier.Equal(decimal.RequireFromString(tt.multiplier)) {
+			t.Errorf("%s: Expected multiplier %s, got %s", tt.name, tt.multiplier, exposure.MarginMultiplier)
+		}
+	}
+}
+
+// TestCreditLimitCustomMultiplier 测试单独设置的倍数优先于默认倍数
+func TestCreditLimitCustomMultiplier(t *testing.T) {
+	svc := &CreditLimitService{cfg: &config.Config{CreditLimit: config.CreditLimitConfig{MarginMultiplier: 2}}}
+	custom := decimal.NewFromInt(5)
+	zero := decimal.Zero
+	tests := []struct {
+		name     string
+		custom   *decimal.Decimal
+		limit    string
+		breached bool
+	}{
+		{"default multiplier", nil, "200", true},
+		{"custom multiplier", &custom, "500", false},
+		{"custom zero lifts the limit", &zero, "0", false},
+	}
+	for _, tt := range tests {
+		exposure := svc.evaluate(&model.OwnerExposure{
+			MarginBalance:    decimal.NewFromInt(100),
+			PlayerBalance:    decimal.NewFromInt(300),
+			CustomMultiplier: tt.custom,
+		})
+		if exposure.CreditLimit.String() != tt.limit || exposure.Breached != tt.breached {
+			t.Errorf("%s: Expected limit %s, breached %v, got %s, %v", tt.name, tt.limit, tt.breached, exposure.CreditLimit, exposure.Breached)
+		}
+	}
+}
@@ -0,0 +1,377 @@
+package service
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/fiveseconds/server/internal/config"
+	"github.com/fiveseconds/server/internal/model"
+	"github.com/fiveseconds/server/internal/repository"
+	"github.com/fiveseconds/server/pkg/stats"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// fairnessMaxListed 每份报告最多列出的显著玩家数（按 p 值升序）
+	fairnessMaxListed = 50
+	// fairnessDefaultFDR 默认错误发现率
+	// 同一次统计的全部检验（各范围的卡方检验及每名玩家的游程与获胜次数检验）按 Benjamini–Hochberg 校正；
+	// 所有回合都公平时，BH 控制的错误发现率等于出现任何误报的概率，按每日统计约百日一次误报
+	fairnessDefaultFDR = 0.01
+)
+
+// fairnessParams 公平性统计参数
+type fairnessParams struct {
+	Window          time.Duration
+	PValue          float64 // 错误发现率（多重检验校正的水平）
+	MinRounds       int
+	MinPlayerRounds int
+	MinExpected     float64
+	MaxRounds       int
+}
+
+// fairnessPlayer 玩家在统计范围内的参与与获胜情况
+type fairnessPlayer struct {
+	rounds   int
+	wins     int
+	expected float64
+	probs    map[float64]int // 各回合获胜概率 -> 回合数（获胜次数服从泊松二项分布）
+	seq      []bool          // 按回合顺序的输赢序列
+}
+
+// winsPValue 获胜次数不低于实际值的概率（按每回合各自的获胜概率）
+func (pl *fairnessPlayer) winsPValue() float64 {
+	probs := make([]float64, 0, len(pl.probs))
+	for prob := range pl.probs {
+		probs = append(probs, prob)
+	}
+	sort.Float64s(probs)
+	counts := make([]int, len(probs))
+	for i, prob := range probs {
+		counts[i] = pl.probs[prob]
+	}
+	return stats.PoissonBinomialUpperTail(pl.wins, probs, counts)
+}
+
+// fairnessAccumulator 累计一个统计范围（全局或单个房间）的回合
+type fairnessAccumulator struct {
+	rounds  int
+	players map[int64]*fairnessPlayer
+}
+
+func newFairnessAccumulator() *fairnessAccumulator {
+	return &fairnessAccumulator{players: make(map[int64]*fairnessPlayer)}
+}
+
+// add 累计一个回合：每名参与者的获胜概率为赢家数 / 参与人数
+func (a *fairnessAccumulator) add(round *model.FairnessRound) {
+	if len(round.ParticipantIDs) == 0 {
+		return
+	}
+	a.rounds++
+	prob := float64(len(round.WinnerIDs)) / float64(len(round.ParticipantIDs))
+	winners := make(map[int64]bool, len(round.WinnerIDs))
+	for _, id := range round.WinnerIDs {
+		winners[id] = true
+	}
+	for _, id := range round.ParticipantIDs {
+		pl, ok := a.players[id]
+		if !ok {
+			pl = &fairnessPlayer{probs: make(map[float64]int)}
+			a.players[id] = pl
+		}
+		pl.rounds++
+		pl.expected += prob
+		pl.probs[prob]++
+		won := winners[id]
+		if won {
+			pl.wins++
+		}
+		pl.seq = append(pl.seq, won)
+	}
+}
+
+// chiSquare 各玩家获胜次数与期望的卡方检验；期望低于 MinExpected 的玩家合并为一组，回合数不足时不检验
+func (a *fairnessAccumulator) chiSquare(p fairnessParams) (stat float64, df int, pValue float64, ok bool) {
+	if a.rounds < p.MinRounds {
+		return 0, 0, 0, false
+	}
+	var observed, expected []float64
+	var pooledObserved, pooledExpected float64
+	for _, pl := range a.players {
+		if pl.expected >= p.MinExpected {
+			observed = append(observed, float64(pl.wins))
+			expected = append(expected, pl.expected)
+		} else {
+			pooledObserved += float64(pl.wins)
+			pooledExpected += pl.expected
+		}
+	}
+	if pooledExpected > 0 {
+		observed = append(observed, pooledObserved)
+		expected = append(expected, pooledExpected)
+	}
+	stat, df = stats.ChiSquare(observed, expected)
+	pValue, ok = stats.ChiSquareSurvival(stat, df)
+	return stat, df, pValue, ok
+}
+
+// fairnessTests 一个统计范围内全部检验的结果（多重检验校正前）
+type fairnessTests struct {
+	report   *model.FairnessReport
+	runs     []*model.FairnessRunsResult
+	outliers []*model.FairnessOutlier
+}
+
+// test 对统计范围进行卡方检验，并对参与回合足够的玩家进行游程检验与获胜次数检验
+func (a *fairnessAccumulator) test(p fairnessParams) *fairnessTests {
+	t := &fairnessTests{report: &model.FairnessReport{
+		Rounds:          a.rounds,
+		Players:         len(a.players),
+		RunsDeviations:  []*model.FairnessRunsResult{},
+		Outliers:        []*model.FairnessOutlier{},
+		PValueThreshold: p.PValue,
+	}}
+	if stat, df, pValue, ok := a.chiSquare(p); ok {
+		t.report.ChiSquare, t.report.DegreesOfFreedom, t.report.ChiSquarePValue = &stat, df, &pValue
+	}
+
+	for userID, pl := range a.players {
+		if pl.rounds < p.MinPlayerRounds {
+			continue
+		}
+		if runs, z, pValue, ok := stats.RunsTest(pl.seq); ok {
+			t.report.RunsTested++
+			t.runs = append(t.runs, &model.FairnessRunsResult{
+				UserID: userID, Rounds: pl.rounds, Wins: pl.wins, Runs: runs, ZScore: z, PValue: pValue,
+			})
+		}
+		t.outliers = append(t.outliers, &model.FairnessOutlier{
+			UserID: userID, Rounds: pl.rounds, Wins: pl.wins, ExpectedWins: pl.expected, PValue: pl.winsPValue(),
+		})
+	}
+	return t
+}
+
+// pValues 全部检验的 p 值
+func (t *fairnessTests) pValues() []float64 {
+	values := make([]float64, 0, len(t.runs)+len(t.outliers)+1)
+	if t.report.ChiSquarePValue != nil {
+		values = append(values, *t.report.ChiSquarePValue)
+	}
+	for _, r := range t.runs {
+		values = append(values, r.PValue)
+	}
+	for _, o := range t.outliers {
+		values = append(values, o.PValue)
+	}
+	return values
+}
+
+// fairnessReports 对同一次统计的全部检验按 Benjamini–Hochberg 校正（错误发现率 fdr），
+// 生成报告：只列出校正后显著的玩家，任一检验显著即标记报告显著
+func fairnessReports(tests []*fairnessTests, fdr float64) []*model.FairnessReport {
+	var family []float64
+	for _, t := range tests {
+		family = append(family, t.pValues()...)
+	}
+	cutoff, rejected := stats.BenjaminiHochberg(family, fdr)
+	significant := func(pValue float64) bool {
+		return rejected > 0 && pValue <= cutoff
+	}
+
+	reports := make([]*model.FairnessReport, 0, len(tests))
+	for _, t := range tests {
+		rp := t.report
+		rp.FamilySize = len(family)
+		if rejected > 0 {
+			c := cutoff
+			rp.PValueCutoff = &c
+		}
+		rp.Significant = rp.ChiSquarePValue != nil && significant(*rp.ChiSquarePValue)
+		for _, r := range t.runs {
+			if significant(r.PValue) {
+				rp.RunsDeviations = append(rp.RunsDeviations, r)
+			}
+		}
+		for _, o := range t.outliers {
+			if significant(o.PValue) {
+				rp.Outliers = append(rp.Outliers, o)
+			}
+		}
+
+		sort.Slice(rp.RunsDeviations, func(i, j int) bool {
+			return fairnessLess(rp.RunsDeviations[i].PValue, rp.RunsDeviations[j].PValue, rp.RunsDeviations[i].UserID, rp.RunsDeviations[j].UserID)
+		})
+		sort.Slice(rp.Outliers, func(i, j int) bool {
+			return fairnessLess(rp.Outliers[i].PValue, rp.Outliers[j].PValue, rp.Outliers[i].UserID, rp.Outliers[j].UserID)
+		})
+		rp.Significant = rp.Significant || len(rp.RunsDeviations) > 0 || len(rp.Outliers) > 0
+		if len(rp.RunsDeviations) > fairnessMaxListed {
+			rp.RunsDeviations = rp.RunsDeviations[:fairnessMaxListed]
+		}
+		if len(rp.Outliers) > fairnessMaxListed {
+			rp.Outliers = rp.Outliers[:fairnessMaxListed]
+		}
+		reports = append(reports, rp)
+	}
+	return reports
+}
+
+// fairnessLess 按 p 值升序，p 值相同时按用户ID升序
+func fairnessLess(pi, pj float64, ui, uj int64) bool {
+	if pi != pj {
+		return pi < pj
+	}
+	return ui < uj
+}
+
+// FairnessService 回合结果公平性统计
+// 定时统计窗口内已结算的多人回合，按全局与房间分别检验赢家是否均匀分布：
+// 卡方检验比较各玩家获胜次数与按参与回合计算的期望，游程检验检查玩家输赢序列的随机性，
+// 泊松二项检验（按玩家每回合各自的获胜概率）找出获胜次数显著超出期望的玩家。
+// 同一次统计的全部检验按 Benjamini–Hochberg 控制错误发现率。报告落库供看板展示，显著偏离时告警
+type FairnessService struct {
+	repo         *repository.FairnessRepo
+	alertManager *AlertManager
+	cfg          *config.Config
+	logger       *zap.Logger
+}
+
+// NewFairnessService 创建公平性统计服务
+func NewFairnessService(repo *repository.FairnessRepo, alertManager *AlertManager, cfg *config.Config, logger *zap.Logger) *FairnessService {
+	return &FairnessService{
+		repo:         repo,
+		alertManager: alertManager,
+		cfg:          cfg,
+		logger:       logger.With(zap.String("service", "fairness")),
+	}
+}
+
+// params 统计参数（未配置的项使用默认值）
+func (s *FairnessService) params() fairnessParams {
+	c := s.cfg.Fairness
+	p := fairnessParams{
+		Window:          time.Duration(c.WindowDays) * 24 * time.Hour,
+		PValue:          c.PValue,
+		MinRounds:       c.MinRounds,
+		MinPlayerRounds: c.MinPlayerRounds,
+		MinExpected:     c.MinExpected,
+		MaxRounds:       c.MaxRounds,
+	}
+	if p.Window <= 0 {
+		p.Window = 7 * 24 * time.Hour
+	}
+	if p.PValue <= 0 || p.PValue >= 1 {
+		p.PValue = fairnessDefaultFDR
+	}
+	if p.MinRounds <= 0 {
+		p.MinRounds = 100
+	}
+	if p.MinPlayerRounds <= 0 {
+		p.MinPlayerRounds = 50
+	}
+	if p.MinExpected <= 0 {
+		p.MinExpected = 5
+	}
+	if p.MaxRounds <= 0 {
+		p.MaxRounds = 200000
+	}
+	return p
+}
+
+// Interval 定时统计的间隔
+func (s *FairnessService) Interval() time.Duration {
+	if s.cfg.Fairness.IntervalHours <= 0 {
+		return 24 * time.Hour
+	}
+	return time.Duration(s.cfg.Fairness.IntervalHours) * time.Hour
+}
+
+// Run 统计窗口内的回合并保存全局与各房间报告，显著偏离的报告触发告警
+func (s *FairnessService) Run(ctx context.Context, now time.Time) ([]*model.FairnessReport, error) {
+	p := s.params()
+	since := now.Add(-p.Window)
+
+	global := newFairnessAccumulator()
+	rooms := make(map[int64]*fairnessAccumulator)
+	err := s.repo.ForEachRound(ctx, since, now, p.MaxRounds, func(round *model.FairnessRound) error {
+		global.add(round)
+		acc, ok := rooms[round.RoomID]
+		if !ok {
+			acc = newFairnessAccumulator()
+			rooms[round.RoomID] = acc
+		}
+		acc.add(round)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	runID := now.UnixMilli()
+	globalTests := global.test(p)
+	globalTests.report.Scope = model.FairnessScopeGlobal
+	tests := []*fairnessTests{globalTests}
+
+	roomIDs := make([]int64, 0, len(rooms))
+	for roomID := range rooms {
+		roomIDs = append(roomIDs, roomID)
+	}
+	sort.Slice(roomIDs, func(i, j int) bool { return roomIDs[i] < roomIDs[j] })
+	for _, roomID := range roomIDs {
+		t := rooms[roomID].test(p)
+		id := roomID
+		t.report.Scope, t.report.RoomID = model.FairnessScopeRoom, &id
+		tests = append(tests, t)
+	}
+	reports := fairnessReports(tests, p.PValue)
+
+	significant := 0
+	for _, rp := range reports {
+		rp.RunID, rp.WindowStart, rp.WindowEnd = runID, since, now
+		if rp.Significant {
+			significant++
+		}
+	}
+	if err := s.repo.InsertReports(ctx, reports); err != nil {
+		return nil, err
+	}
+
+	if s.alertManager != nil {
+		for _, rp := range reports {
+			if rp.Significant {
+				s.alertManager.TriggerFairnessAlert(ctx, rp)
+			}
+		}
+	}
+	s.logger.Info("Fairness statistics computed",
+		zap.Int("rounds", global.rounds),
+		zap.Int("rooms", len(rooms)),
+		zap.Int("significant", significant))
+	return reports, nil
+}
+
+// Dashboard 公平性看板：最近一次统计的全局与各房间报告
+func (s *FairnessService) Dashboard(ctx context.Context) (*model.FairnessDashboard, error) {
+	reports, err := s.repo.ListLatestRun(ctx)
+	if err != nil {
+		return nil, err
+	}
+	dashboard := &model.FairnessDashboard{Rooms: []*model.FairnessReport{}}
+	for _, rp := range reports {
+		if rp.Scope == model.FairnessScopeGlobal {
+			dashboard.Global = rp
+		} else {
+			dashboard.Rooms = append(dashboard.Rooms, rp)
+		}
+	}
+	return dashboard, nil
+}
+
+// ListReports 查询公平性报告历史
+func (s *FairnessService) ListReports(ctx context.Context, query *model.FairnessReportQuery) ([]*model.FairnessReport, int64, error) {
+	return s.repo.List(ctx, query)
+}
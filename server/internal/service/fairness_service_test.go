@@ -0,0 +1,269 @@
+package service
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/fiveseconds/server/internal/model"
+)
+
+var testFairnessParams = fairnessParams{
+	PValue:          0.001,
+	MinRounds:       100,
+	MinPlayerRounds: 20,
+	MinExpected:     5,
+}
+
+// testFairnessRounds 生成 count 个回合，winner 返回第 i 回合的赢家
+func testFairnessRounds(count int, participants []int64, winner func(i int) int64) []*model.FairnessRound {
+	rounds := make([]*model.FairnessRound, 0, count)
+	for i := 0; i < count; i++ {
+		rounds = append(rounds, &model.FairnessRound{ID: int64(i + 1), RoomID: 1, ParticipantIDs: participants, WinnerIDs: []int64{winner(i)}})
+	}
+	return rounds
+}
+
+// testFairnessAccumulator 累计全部回合
+func testFairnessAccumulator(rounds ...[]*model.FairnessRound) *fairnessAccumulator {
+	acc := newFairnessAccumulator()
+	for _, group := range rounds {
+		for _, round := range group {
+			acc.add(round)
+		}
+	}
+	return acc
+}
+
+// TestFairnessAccumulatorAdd 测试每名参与者的获胜概率为赢家数 / 参与人数，无参与者的回合不计
+func TestFairnessAccumulatorAdd(t *testing.T) {
+	acc := testFairnessAccumulator([]*model.FairnessRound{
+		{ID: 1, ParticipantIDs: []int64{1, 2, 3, 4}, WinnerIDs: []int64{1}},
+		{ID: 2, ParticipantIDs: []int64{1, 2}, WinnerIDs: []int64{2}},
+		{ID: 3},
+		{ID: 4, ParticipantIDs: []int64{1, 3}, WinnerIDs: []int64{1, 3}},
+	})
+	if acc.rounds != 3 {
+		t.Errorf("Expected 3 rounds, got %d", acc.rounds)
+	}
+
+	tests := []struct {
+		userID   int64
+		rounds   int
+		wins     int
+		expected float64
+		probs    map[float64]int
+		seq      []bool
+	}{
+		{1, 3, 2, 1.75, map[float64]int{0.25: 1, 0.5: 1, 1: 1}, []bool{true, false, true}},
+		{2, 2, 1, 0.75, map[float64]int{0.25: 1, 0.5: 1}, []bool{false, true}},
+		{3, 2, 1, 1.25, map[float64]int{0.25: 1, 1: 1}, []bool{false, true}},
+		{4, 1, 0, 0.25, map[float64]int{0.25: 1}, []bool{false}},
+	}
+	if len(acc.players) != len(tests) {
+		t.Fatalf("Expected %d players, got %d", len(tests), len(acc.players))
+	}
+	for _, tt := range tests {
+		pl := acc.players[tt.userID]
+		if pl.rounds != tt.rounds || pl.wins != tt.wins || math.Abs(pl.expected-tt.expected) > 1e-12 {
+			t.Errorf("User %d: expected %d rounds, %d wins, %v expected wins, got %d, %d, %v",
+				tt.userID, tt.rounds, tt.wins, tt.expected, pl.rounds, pl.wins, pl.expected)
+		}
+		if !reflect.DeepEqual(pl.probs, tt.probs) || !reflect.DeepEqual(pl.seq, tt.seq) {
+			t.Errorf("User %d: expected probs %v and sequence %v, got %v and %v", tt.userID, tt.probs, tt.seq, pl.probs, pl.seq)
+		}
+	}
+}
+
+// TestFairnessPlayerWinsPValue 测试获胜次数检验按每回合各自的获胜概率计算
+func TestFairnessPlayerWinsPValue(t *testing.T) {
+	tests := []struct {
+		name string
+		wins int
+		want float64
+	}{
+		{"no wins", 0, 1},
+		{"at least one", 1, 0.625},
+		{"both", 2, 0.125},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pl := &fairnessPlayer{wins: tt.wins, probs: map[float64]int{0.5: 1, 0.25: 1}}
+			if got := pl.winsPValue(); math.Abs(got-tt.want) > 1e-12 {
+				t.Errorf("Expected p-value %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+// TestFairnessChiSquare 测试卡方检验仅在回合数达到下限时进行，期望过低的玩家合并为一组
+func TestFairnessChiSquare(t *testing.T) {
+	alternate := func(i int) int64 { return int64(i%2 + 1) }
+	tests := []struct {
+		name      string
+		acc       *fairnessAccumulator
+		wantOK    bool
+		wantStat  float64
+		wantDF    int
+		wantBelow float64 // p 值上界
+	}{
+		{"too few rounds", testFairnessAccumulator(testFairnessRounds(99, []int64{1, 2}, alternate)), false, 0, 0, 0},
+		{
+			"uniform winners",
+			testFairnessAccumulator(testFairnessRounds(100, []int64{1, 2, 3, 4}, func(i int) int64 { return int64(i%4 + 1) })),
+			true, 0, 3, 1,
+		},
+		{
+			"low expectations pooled",
+			testFairnessAccumulator(
+				testFairnessRounds(96, []int64{1, 2}, alternate),
+				testFairnessRounds(4, []int64{3, 4}, func(int) int64 { return 3 }),
+			),
+			true, 0, 2, 1,
+		},
+		{"one player always wins", testFairnessAccumulator(testFairnessRounds(100, []int64{1, 2}, func(int) int64 { return 1 })), true, 100, 1, 1e-20},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stat, df, p, ok := tt.acc.chiSquare(testFairnessParams)
+			if ok != tt.wantOK {
+				t.Fatalf("Expected ok=%v, got %v", tt.wantOK, ok)
+			}
+			if !ok {
+				return
+			}
+			if math.Abs(stat-tt.wantStat) > 1e-9 || df != tt.wantDF {
+				t.Errorf("Expected statistic %v with %d degrees of freedom, got %v with %d", tt.wantStat, tt.wantDF, stat, df)
+			}
+			if p < 0 || p > tt.wantBelow {
+				t.Errorf("Expected p-value within 0..%v, got %v", tt.wantBelow, p)
+			}
+		})
+	}
+}
+
+// TestFairnessAccumulatorTest 测试只对参与回合足够的玩家进行游程与获胜次数检验
+func TestFairnessAccumulatorTest(t *testing.T) {
+	acc := testFairnessAccumulator(
+		testFairnessRounds(96, []int64{1, 2}, func(i int) int64 { return int64(i%2 + 1) }),
+		testFairnessRounds(4, []int64{3, 4}, func(int) int64 { return 3 }),
+	)
+	result := acc.test(testFairnessParams)
+
+	rp := result.report
+	if rp.Rounds != 100 || rp.Players != 4 || rp.PValueThreshold != testFairnessParams.PValue {
+		t.Errorf("Expected 100 rounds, 4 players and threshold %v, got %d, %d, %v", testFairnessParams.PValue, rp.Rounds, rp.Players, rp.PValueThreshold)
+	}
+	if rp.ChiSquare == nil || rp.ChiSquarePValue == nil || rp.DegreesOfFreedom != 2 {
+		t.Errorf("Expected a chi-square test with 2 degrees of freedom, got %+v", rp)
+	}
+	if rp.RunsTested != 2 || len(result.runs) != 2 || len(result.outliers) != 2 {
+		t.Errorf("Expected runs and win tests for the 2 regular players, got %d runs tested, %d runs, %d outliers",
+			rp.RunsTested, len(result.runs), len(result.outliers))
+	}
+	for _, o := range result.outliers {
+		if o.UserID != 1 && o.UserID != 2 {
+			t.Errorf("Expected only users 1 and 2 tested, got user %d", o.UserID)
+		}
+	}
+	// 严格交替的输赢序列游程过多
+	for _, r := range result.runs {
+		if r.Runs != 96 || r.PValue >= testFairnessParams.PValue {
+			t.Errorf("Expected user %d to alternate over 96 runs significantly, got %d runs with p-value %v", r.UserID, r.Runs, r.PValue)
+		}
+	}
+	if got := len(result.pValues()); got != 5 {
+		t.Errorf("Expected 5 p-values, got %d", got)
+	}
+}
+
+// TestFairnessReports 测试全部检验统一按 Benjamini–Hochberg 校正，只列出校正后显著的玩家并按 p 值升序
+func TestFairnessReports(t *testing.T) {
+	pValue := func(v float64) *float64 { return &v }
+	newTests := func(chi *float64, runs []*model.FairnessRunsResult, outliers []*model.FairnessOutlier) *fairnessTests {
+		return &fairnessTests{
+			report:   &model.FairnessReport{ChiSquarePValue: chi, RunsDeviations: []*model.FairnessRunsResult{}, Outliers: []*model.FairnessOutlier{}},
+			runs:     runs,
+			outliers: outliers,
+		}
+	}
+	// 8 个检验，按 0.01 校正后界限为 0.002（前 4 个显著）
+	global := newTests(pValue(0.0001),
+		[]*model.FairnessRunsResult{{UserID: 7, PValue: 0.3}},
+		[]*model.FairnessOutlier{{UserID: 6, PValue: 0.0004}, {UserID: 8, PValue: 0.9}, {UserID: 5, PValue: 0.0004}})
+	room := newTests(nil,
+		[]*model.FairnessRunsResult{{UserID: 5, PValue: 0.002}},
+		[]*model.FairnessOutlier{{UserID: 5, PValue: 0.6}})
+	quiet := newTests(pValue(0.05), nil, nil)
+
+	reports := fairnessReports([]*fairnessTests{global, room, quiet}, 0.01)
+	if len(reports) != 3 || reports[0] != global.report || reports[1] != room.report || reports[2] != quiet.report {
+		t.Fatalf("Expected the reports of the given tests in order, got %v", reports)
+	}
+
+	tests := []struct {
+		name        string
+		report      *model.FairnessReport
+		significant bool
+		runs        []int64
+		outliers    []int64
+	}{
+		{"chi-square and tied outliers", reports[0], true, []int64{}, []int64{5, 6}},
+		{"runs deviation only", reports[1], true, []int64{5}, []int64{}},
+		{"nothing significant", reports[2], false, []int64{}, []int64{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rp := tt.report
+			if rp.FamilySize != 8 || rp.PValueCutoff == nil || *rp.PValueCutoff != 0.002 {
+				t.Errorf("Expected family of 8 with cutoff 0.002, got %d and %v", rp.FamilySize, rp.PValueCutoff)
+			}
+			if rp.Significant != tt.significant {
+				t.Errorf("Expected significant=%v, got %v", tt.significant, rp.Significant)
+			}
+			runs := []int64{}
+			for _, r := range rp.RunsDeviations {
+				runs = append(runs, r.UserID)
+			}
+			outliers := []int64{}
+			for _, o := range rp.Outliers {
+				outliers = append(outliers, o.UserID)
+			}
+			if !reflect.DeepEqual(runs, tt.runs) || !reflect.DeepEqual(outliers, tt.outliers) {
+				t.Errorf("Expected runs deviations %v and outliers %v, got %v and %v", tt.runs, tt.outliers, runs, outliers)
+			}
+		})
+	}
+}
+
+// TestFairnessReportsNoneRejected 测试没有显著检验时不设界限
+func TestFairnessReportsNoneRejected(t *testing.T) {
+	chi := 0.5
+	reports := fairnessReports([]*fairnessTests{{
+		report:   &model.FairnessReport{ChiSquarePValue: &chi, RunsDeviations: []*model.FairnessRunsResult{}, Outliers: []*model.FairnessOutlier{}},
+		outliers: []*model.FairnessOutlier{{UserID: 1, PValue: 0.7}},
+	}}, 0.01)
+	rp := reports[0]
+	if rp.FamilySize != 2 || rp.PValueCutoff != nil || rp.Significant || len(rp.Outliers) != 0 {
+		t.Errorf("Expected an insignificant report without cutoff, got %+v", rp)
+	}
+}
+
+// TestFairnessReportsListLimit 测试每份报告最多列出 fairnessMaxListed 名显著玩家
+func TestFairnessReportsListLimit(t *testing.T) {
+	outliers := make([]*model.FairnessOutlier, 0, fairnessMaxListed+10)
+	for i := fairnessMaxListed + 10; i > 0; i-- {
+		outliers = append(outliers, &model.FairnessOutlier{UserID: int64(i), PValue: float64(i) * 1e-9})
+	}
+	reports := fairnessReports([]*fairnessTests{{
+		report:   &model.FairnessReport{RunsDeviations: []*model.FairnessRunsResult{}, Outliers: []*model.FairnessOutlier{}},
+		outliers: outliers,
+	}}, 0.01)
+	listed := reports[0].Outliers
+	if len(listed) != fairnessMaxListed {
+		t.Fatalf("Expected %d listed outliers, got %d", fairnessMaxListed, len(listed))
+	}
+	if listed[0].UserID != 1 || listed[len(listed)-1].UserID != fairnessMaxListed {
+		t.Errorf("Expected the smallest p-values listed first, got users %d..%d", listed[0].UserID, listed[len(listed)-1].UserID)
+	}
+}
@@ -61,6 +61,39 @@ func (f *FundAnomalyLogger) LogLargeTransaction(ctx context.Context, userID int6
 	metrics.RecordFundAnomaly("large_transaction")
 }
 
+// LogDailyVolumeExceeded logs a warning when a user's daily volume exceeds the threshold
+func (f *FundAnomalyLogger) LogDailyVolumeExceeded(ctx context.Context, userID int64, volume decimal.Decimal, threshold decimal.Decimal) {
+	f.log.WithContext(ctx).Warn("daily volume threshold exceeded",
+		zap.Int64("user_id", userID),
+		zap.String("volume", volume.String()),
+		zap.String("threshold", threshold.String()),
+	)
+	metrics.RecordFundAnomaly("daily_volume")
+}
+
+// LogFundVelocity logs a warning for too many deposits or withdrawals within the window
+func (f *FundAnomalyLogger) LogFundVelocity(ctx context.Context, userID int64, deposits, withdrawals int, windowMinutes int) {
+	f.log.WithContext(ctx).Warn("fund velocity exceeded",
+		zap.Int64("user_id", userID),
+		zap.Int("deposits", deposits),
+		zap.Int("withdrawals", withdrawals),
+		zap.Int("window_minutes", windowMinutes),
+	)
+	metrics.RecordFundAnomaly("fund_velocity")
+}
+
+// LogQuickWithdrawal logs a warning for a withdrawal shortly after a deposit with little play in between
+func (f *FundAnomalyLogger) LogQuickWithdrawal(ctx context.Context, userID int64, amount, depositAmount, wagered decimal.Decimal, requestID int64) {
+	f.log.WithContext(ctx).Warn("withdrawal shortly after deposit with little play",
+		zap.Int64("user_id", userID),
+		zap.String("amount", amount.String()),
+		zap.String("deposit_amount", depositAmount.String()),
+		zap.String("wagered", wagered.String()),
+		zap.Int64("fund_request_id", requestID),
+	)
+	metrics.RecordFundAnomaly("quick_withdrawal")
+}
+
 // LogConsecutiveWins logs a warning for consecutive wins
 func (f *FundAnomalyLogger) LogConsecutiveWins(ctx context.Context, userID int64, winStreak int, roomID int64) {
 	f.log.WithContext(ctx).Warn("consecutive wins detected",
@@ -6,10 +6,12 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/fiveseconds/server/internal/cache"
 	"github.com/fiveseconds/server/internal/config"
 	"github.com/fiveseconds/server/internal/model"
 	"github.com/fiveseconds/server/internal/repository"
 	"github.com/fiveseconds/server/internal/ws"
+	"github.com/fiveseconds/server/pkg/metrics"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/shopspring/decimal"
@@ -20,32 +22,86 @@ var (
 	ErrRequestAlreadyProcessed = errors.New("request already processed")
 	ErrInsufficientCustody     = errors.New("insufficient custody quota")
 	ErrInsufficientMargin      = errors.New("insufficient margin balance")
+	ErrDuplicateApprover       = errors.New("approver has already approved this request")
+	ErrAdminApprovalRequired   = errors.New("request is escalated and requires platform admin approval")
+	ErrFundCurrencyMismatch    = errors.New("fund request currency must match the owner's operating currency")
+	ErrRequestExpired          = errors.New("fund request has expired")
 )
 
+// OwnerCreditLimiter 房主信用额度（玩家充值前校验敞口，保证金变动后重新评估超限状态）
+type OwnerCreditLimiter interface {
+	CheckDepositTx(ctx context.Context, tx pgx.Tx, ownerID int64, amount decimal.Decimal) error
+	EnforceOwner(ctx context.Context, ownerID int64) (*model.OwnerExposure, error)
+}
+
+// FundRiskChecker 资金申请风控检查
+type FundRiskChecker interface {
+	OnFundRequest(ctx context.Context, req *model.FundRequest)
+}
+
+// FundEventHook 资金变动事件钩子（资金变动事务提交后异步调用）
+type FundEventHook interface {
+	OnFundEvent(ctx context.Context, event *model.FundEvent)
+}
+
+// OwnerRiskChecker 房主维度风控检查（每日房主对账记录完成后调用）
+type OwnerRiskChecker interface {
+	CheckOwners(ctx context.Context, dayStart, dayEnd time.Time, summaries []*model.OwnerSnapshotSummary)
+}
+
+// dispatchFundEvent 异步分发资金变动事件，不阻塞资金流程
+func dispatchFundEvent(hook FundEventHook, event *model.FundEvent) {
+	if hook == nil {
+		return
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		hook.OnFundEvent(ctx, event)
+	}()
+}
+
+// AccountRestrictionChecker 账户限制检查（被禁止时返回 *RestrictedError）
+type AccountRestrictionChecker interface {
+	Check(ctx context.Context, userID int64, action model.RestrictionType) error
+}
+
 type FundService struct {
 	userRepo         *repository.UserRepo
+	walletRepo       *repository.WalletRepo
 	fundRepo         *repository.FundRequestRepo
 	txRepo           *repository.TransactionRepo
 	platformRepo     *repository.PlatformRepo
 	conservationRepo *repository.ConservationRepo
+	snapshotRepo     *repository.BalanceSnapshotRepo
 	cfg              *config.Config
 	hub              *ws.Hub // WebSocket Hub 用于发送通知
+	creditLimiter    OwnerCreditLimiter
+	riskChecker      FundRiskChecker
+	restrictions     AccountRestrictionChecker
+	fundEvents       FundEventHook
+	ownerRisk        OwnerRiskChecker
+	balanceCache     *cache.BalanceCache
 }
 
 func NewFundService(
 	userRepo *repository.UserRepo,
+	walletRepo *repository.WalletRepo,
 	fundRepo *repository.FundRequestRepo,
 	txRepo *repository.TransactionRepo,
 	platformRepo *repository.PlatformRepo,
 	conservationRepo *repository.ConservationRepo,
+	snapshotRepo *repository.BalanceSnapshotRepo,
 	cfg *config.Config,
 ) *FundService {
 	return &FundService{
 		userRepo:         userRepo,
+		walletRepo:       walletRepo,
 		fundRepo:         fundRepo,
 		txRepo:           txRepo,
 		platformRepo:     platformRepo,
 		conservationRepo: conservationRepo,
+		snapshotRepo:     snapshotRepo,
 		cfg:              cfg,
 	}
 }
@@ -55,6 +111,36 @@ func (s *FundService) SetHub(hub *ws.Hub) {
 	s.hub = hub
 }
 
+// SetCreditLimiter 设置房主信用额度（玩家充值受保证金倍数限制）
+func (s *FundService) SetCreditLimiter(limiter OwnerCreditLimiter) {
+	s.creditLimiter = limiter
+}
+
+// SetRiskChecker 设置资金申请风控检查
+func (s *FundService) SetRiskChecker(riskChecker FundRiskChecker) {
+	s.riskChecker = riskChecker
+}
+
+// SetRestrictionChecker 设置账户限制检查（提现受“禁止提现”限制，全面封停时禁止一切资金申请）
+func (s *FundService) SetRestrictionChecker(restrictions AccountRestrictionChecker) {
+	s.restrictions = restrictions
+}
+
+// SetFundEventHook 设置资金变动事件钩子（资金申请审批通过后触发）
+func (s *FundService) SetFundEventHook(hook FundEventHook) {
+	s.fundEvents = hook
+}
+
+// SetOwnerRiskChecker 设置房主维度风控检查（每日房主对账后执行）
+func (s *FundService) SetOwnerRiskChecker(checker OwnerRiskChecker) {
+	s.ownerRisk = checker
+}
+
+// SetBalanceCache 设置余额缓存（写回模式下审批变动余额前需暂停相关用户的缓存扣款并落库）
+func (s *FundService) SetBalanceCache(balanceCache *cache.BalanceCache) {
+	s.balanceCache = balanceCache
+}
+
 // notifyBalanceUpdate 通知用户余额更新
 func (s *FundService) notifyBalanceUpdate(userID int64, balance, frozenBalance decimal.Decimal) {
 	if s.hub == nil {
@@ -70,286 +156,549 @@ func (s *FundService) notifyBalanceUpdate(userID int64, balance, frozenBalance d
 }
 
 // CreateFundRequest 创建资金申请
+// 资金申请以房主经营币种结算：玩家申请使用所属房主的币种，房主申请使用自身币种
 func (s *FundService) CreateFundRequest(ctx context.Context, userID int64, req *model.CreateFundRequestReq) (*model.FundRequest, error) {
+	if s.restrictions != nil {
+		if err := s.restrictions.Check(ctx, userID, fundRequestRestriction(req.Type)); err != nil {
+			return nil, err
+		}
+	}
+
+	currency, err := s.fundRequestCurrency(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if req.Currency != "" && NormalizeCurrency(req.Currency) != currency {
+		return nil, ErrFundCurrencyMismatch
+	}
+
 	remark := req.Remark
 	fundReq := &model.FundRequest{
-		UserID: userID,
-		Type:   req.Type,
-		Amount: req.Amount,
-		Remark: &remark,
+		UserID:   userID,
+		Type:     req.Type,
+		Amount:   req.Amount,
+		Currency: currency,
+		Remark:   &remark,
 	}
 
 	if err := s.fundRepo.Create(ctx, fundReq); err != nil {
 		return nil, err
 	}
 
+	// 异步执行风控规则检查，不阻塞申请流程
+	if s.riskChecker != nil {
+		checked := *fundReq
+		go func() {
+			checkCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			s.riskChecker.OnFundRequest(checkCtx, &checked)
+		}()
+	}
+
 	return fundReq, nil
 }
 
-// ProcessFundRequest 处理资金申请(审批)
-func (s *FundService) ProcessFundRequest(ctx context.Context, requestID, processedBy int64, req *model.ProcessFundRequestReq) error {
-	fundReq, err := s.fundRepo.GetByID(ctx, requestID)
-	if err != nil {
-		if errors.Is(err, repository.ErrNotFound) {
-			return ErrRequestNotFound
-		}
-		return err
+// fundRequestRestriction 资金申请受限的操作类型：提现受禁止提现限制，其余受冻结限制
+func fundRequestRestriction(reqType model.FundRequestType) model.RestrictionType {
+	if reqType == model.FundRequestWithdraw || reqType == model.FundRequestOwnerWithdraw {
+		return model.RestrictionNoWithdrawal
 	}
+	return model.RestrictionSuspended
+}
 
-	if fundReq.Status != model.FundStatusPending {
-		return ErrRequestAlreadyProcessed
+// fundRequestCurrency 资金申请的结算币种：房主为自身经营币种，玩家为所属房主（或邀请人）的币种
+func (s *FundService) fundRequestCurrency(ctx context.Context, userID int64) (string, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return "", err
 	}
-
-	var status model.FundRequestStatus
-	if req.Approved {
-		status = model.FundStatusApproved
-		// 执行实际的余额变动
-		if err := s.executeBalanceChange(ctx, fundReq); err != nil {
-			return err
-		}
-	} else {
-		status = model.FundStatusRejected
+	if user.IsOwner() || user.InvitedBy == nil {
+		return user.Currency, nil
 	}
-
-	return s.fundRepo.Process(ctx, requestID, status, processedBy, req.Remark)
+	owner, err := s.userRepo.GetByID(ctx, *user.InvitedBy)
+	if err != nil {
+		return "", err
+	}
+	return owner.Currency, nil
 }
 
-// executeBalanceChange 执行余额变动（使用事务保护，并记录交易流水）
-func (s *FundService) executeBalanceChange(ctx context.Context, fundReq *model.FundRequest) error {
-	user, err := s.userRepo.GetByID(ctx, fundReq.UserID)
+// ProcessFundRequest 处理资金申请(审批)
+// 按审批策略推进状态：金额达到双人审批阈值需两名不同审批人，
+// 达到升级阈值或房主超出每日审批额度时升级至平台管理员。返回处理后的申请状态。
+// 申请行在事务内加锁，余额变动与状态流转在同一事务提交，并发审批或过期任务不会重复处理同一申请；
+// 申请人账户被限制时最终审批返回 *RestrictedError，申请保持待审批
+func (s *FundService) ProcessFundRequest(ctx context.Context, requestID, processedBy int64, req *model.ProcessFundRequestReq) (model.FundRequestStatus, error) {
+	approver, err := s.userRepo.GetByID(ctx, processedBy)
 	if err != nil {
-		return err
+		return "", err
 	}
+	policy := s.approvalPolicy()
 
-	switch fundReq.Type {
-	case model.FundRequestDeposit:
-		// 玩家充值: 房主余额减少，玩家余额增加（线下转账后的确认操作）
-		// 资金守恒：房主余额 - X = 玩家余额 + X
-		if user.InvitedBy == nil {
-			return errors.New("player must have an owner")
+	// 写回模式下审批通过会变动申请人及其房主的余额，事务前暂停其缓存扣款并落库
+	if req.Approved {
+		release, err := s.prepareFundBalanceWrite(ctx, requestID)
+		if err != nil {
+			return "", err
 		}
+		defer release()
+	}
 
-		// 获取房主信息
-		owner, err := s.userRepo.GetByID(ctx, *user.InvitedBy)
+	var (
+		fundReq *model.FundRequest
+		status  model.FundRequestStatus
+		notify  func()
+	)
+	err = repository.Tx(ctx, func(tx pgx.Tx) error {
+		var err error
+		fundReq, err = s.fundRepo.GetForUpdateTx(ctx, tx, requestID)
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return ErrRequestNotFound
+			}
+			return err
+		}
+		approvals, err := s.fundRepo.ListApprovalsTx(ctx, tx, requestID)
 		if err != nil {
 			return err
 		}
 
-		// 检查房主余额是否足够（不是检查保证金，保证金永远不动）
-		if owner.Balance.LessThan(fundReq.Amount) {
-			return errors.New("owner has insufficient balance")
+		// 房主每日审批额度：锁定审批人后在事务内统计，同一房主的并发审批不会同时通过额度检查
+		approvedToday := decimal.Zero
+		if req.Approved && approver.Role == model.RoleOwner && policy.OwnerDailyApprovalCap > 0 {
+			if err := s.fundRepo.LockApproverTx(ctx, tx, processedBy); err != nil {
+				return err
+			}
+			now := time.Now()
+			dayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+			if approvedToday, err = s.fundRepo.GetApprovedAmountSinceTx(ctx, tx, processedBy, dayStart); err != nil {
+				return err
+			}
 		}
 
-		ownerNewBalance := owner.Balance.Sub(fundReq.Amount)
-		playerNewBalance := user.Balance.Add(fundReq.Amount)
+		status, err = planFundApproval(policy, fundReq, approver.Role, processedBy, req.Approved, approvals, approvedToday, s.expiresBefore(time.Now()))
+		if err != nil {
+			return err
+		}
+		approval := &model.FundRequestApproval{
+			RequestID:    requestID,
+			ApproverID:   processedBy,
+			ApproverRole: approver.Role,
+			Action:       model.FundApprovalApprove,
+			StatusBefore: fundReq.Status,
+			StatusAfter:  status,
+			Amount:       fundReq.Amount,
+			Remark:       strPtr(req.Remark),
+		}
 
-		// 使用事务确保原子性
-		err = repository.Tx(ctx, func(tx pgx.Tx) error {
-			// 房主余额减少
-			if err := s.userRepo.UpdateBalanceTx(ctx, tx, *user.InvitedBy, fundReq.Amount.Neg()); err != nil {
-				return fmt.Errorf("deduct owner balance: %w", err)
-			}
-			// 记录房主交易流水（转出给玩家）
-			ownerTx := &model.BalanceTransaction{
-				UserID:        *user.InvitedBy,
-				Type:          model.TxDeposit, // 从房主角度是转出
-				Amount:        fundReq.Amount.Neg(),
-				BalanceBefore: owner.Balance,
-				BalanceAfter:  ownerNewBalance,
-				BalanceField:  "balance",
-				Remark:        strPtr(fmt.Sprintf("玩家充值转出(玩家ID:%d,申请ID:%d)", fundReq.UserID, fundReq.ID)),
+		switch status {
+		case model.FundStatusRejected:
+			approval.Action = model.FundApprovalReject
+			if err := s.fundRepo.ProcessTx(ctx, tx, requestID, status, processedBy, req.Remark); err != nil {
+				return err
 			}
-			if err := s.txRepo.CreateTx(ctx, tx, ownerTx); err != nil {
-				return fmt.Errorf("create owner transaction: %w", err)
+		case model.FundStatusApproved:
+			// 申请创建后才被限制的账户：审批失败，申请保持待审批直至限制解除或过期
+			if s.restrictions != nil {
+				if err := s.restrictions.Check(ctx, fundReq.UserID, fundRequestRestriction(fundReq.Type)); err != nil {
+					return err
+				}
 			}
-
-			// 玩家余额增加
-			if err := s.userRepo.UpdateBalanceTx(ctx, tx, fundReq.UserID, fundReq.Amount); err != nil {
-				return fmt.Errorf("add player balance: %w", err)
+			// 执行实际的余额变动（与状态流转同一事务）
+			if notify, err = s.executeBalanceChangeTx(ctx, tx, fundReq); err != nil {
+				return err
 			}
-			// 记录玩家交易流水（充值）
-			playerTx := &model.BalanceTransaction{
-				UserID:        fundReq.UserID,
-				Type:          model.TxDeposit,
-				Amount:        fundReq.Amount,
-				BalanceBefore: user.Balance,
-				BalanceAfter:  playerNewBalance,
-				BalanceField:  "balance",
-				Remark:        strPtr(fmt.Sprintf("玩家充值(申请ID:%d)", fundReq.ID)),
+			if err := s.fundRepo.ProcessTx(ctx, tx, requestID, status, processedBy, req.Remark); err != nil {
+				return err
 			}
-			if err := s.txRepo.CreateTx(ctx, tx, playerTx); err != nil {
-				return fmt.Errorf("create player transaction: %w", err)
+		default:
+			// 中间状态：仅记录审批步骤并推进状态
+			if err := s.fundRepo.UpdateStatusTx(ctx, tx, requestID, fundReq.Status, status); err != nil {
+				return err
 			}
-			return nil
+		}
+		return s.fundRepo.CreateApprovalTx(ctx, tx, approval)
+	})
+	if err != nil {
+		return "", err
+	}
+
+	switch status {
+	case model.FundStatusRejected:
+		recordFundRequestSLA(fundReq, status)
+	case model.FundStatusApproved:
+		recordFundRequestSLA(fundReq, status)
+		if notify != nil {
+			notify()
+		}
+		dispatchFundEvent(s.fundEvents, &model.FundEvent{
+			Type:        model.FundEventRequestApproved,
+			UserID:      fundReq.UserID,
+			Amount:      fundReq.Amount,
+			Currency:    fundReq.Currency,
+			RequestType: fundReq.Type,
+			RefID:       fundReq.ID,
+			OccurredAt:  time.Now(),
 		})
-		if err != nil {
-			return err
+	}
+	return status, nil
+}
+
+// prepareFundBalanceWrite 暂停资金申请涉及用户（申请人及其房主）的缓存扣款并落库
+func (s *FundService) prepareFundBalanceWrite(ctx context.Context, requestID int64) (func(), error) {
+	if !s.balanceCache.WriteBehindEnabled() {
+		return func() {}, nil
+	}
+	fundReq, err := s.fundRepo.GetByID(ctx, requestID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrRequestNotFound
 		}
-		// 事务成功后发送 WebSocket 通知
-		s.notifyBalanceUpdate(fundReq.UserID, playerNewBalance, user.FrozenBalance)
-		s.notifyBalanceUpdate(*user.InvitedBy, ownerNewBalance, owner.FrozenBalance)
-		return nil
+		return nil, err
+	}
+	user, err := s.userRepo.GetByID(ctx, fundReq.UserID)
+	if err != nil {
+		return nil, err
+	}
+	userIDs := []int64{user.ID}
+	if user.InvitedBy != nil {
+		userIDs = append(userIDs, *user.InvitedBy)
+	}
+	return prepareBalanceWrite(ctx, s.balanceCache, userIDs...)
+}
 
-	case model.FundRequestWithdraw:
-		// 玩家提现: 玩家余额减少，房主余额增加（线下转账前的确认操作）
-		// 资金守恒：玩家余额 - X = 房主余额 + X
-		if user.InvitedBy == nil {
-			return errors.New("player must have an owner")
+// planFundApproval 根据已加锁的申请与既有审批记录计算本次审批后的状态
+// 申请须处于待审批状态且未超过有效期（expiresBefore 为零值时不限制）；拒绝直接进入终态；
+// 升级后的申请须由管理员审批；同一审批人不能重复审批
+func planFundApproval(policy config.FundApprovalConfig, fundReq *model.FundRequest, approverRole model.Role, approverID int64,
+	approved bool, approvals []*model.FundRequestApproval, approvedToday decimal.Decimal, expiresBefore time.Time) (model.FundRequestStatus, error) {
+	if !fundReq.Status.IsAwaitingApproval() {
+		return "", ErrRequestAlreadyProcessed
+	}
+	// 已超过有效期但过期任务尚未处理（或因本次审批持有行锁而跳过）的申请不再审批
+	if !expiresBefore.IsZero() && fundReq.CreatedAt.Before(expiresBefore) {
+		return "", ErrRequestExpired
+	}
+	if !approved {
+		return model.FundStatusRejected, nil
+	}
+	if fundReq.Status == model.FundStatusEscalated && approverRole != model.RoleAdmin {
+		return "", ErrAdminApprovalRequired
+	}
+
+	// 收集已通过的审批人，同一审批人不能重复审批
+	approverRoles := []model.Role{approverRole}
+	for _, a := range approvals {
+		if a.Action != model.FundApprovalApprove {
+			continue
 		}
+		if a.ApproverID == approverID {
+			return "", ErrDuplicateApprover
+		}
+		approverRoles = append(approverRoles, a.ApproverRole)
+	}
+
+	capExceeded := approverRole == model.RoleOwner && policy.OwnerDailyApprovalCap > 0 &&
+		approvedToday.Add(fundReq.Amount).GreaterThan(decimal.NewFromFloat(policy.OwnerDailyApprovalCap))
+	return nextApprovalStatus(policy, fundReq.Amount, approverRoles, capExceeded), nil
+}
 
-		// 检查玩家余额是否足够
-		if user.Balance.LessThan(fundReq.Amount) {
-			return errors.New("player has insufficient balance")
+// approvalPolicy 获取审批策略（未配置时不启用任何限制）
+func (s *FundService) approvalPolicy() config.FundApprovalConfig {
+	if s.cfg == nil {
+		return config.FundApprovalConfig{}
+	}
+	return s.cfg.Fund.Approval
+}
+
+// nextApprovalStatus 根据审批策略计算本次审批通过后的申请状态
+// approverRoles 为包含本次在内的全部通过审批人的角色（审批人互不相同）
+func nextApprovalStatus(policy config.FundApprovalConfig, amount decimal.Decimal, approverRoles []model.Role, capExceeded bool) model.FundRequestStatus {
+	hasAdmin := false
+	for _, role := range approverRoles {
+		if role == model.RoleAdmin {
+			hasAdmin = true
+			break
 		}
+	}
 
-		// 获取房主信息
-		owner, err := s.userRepo.GetByID(ctx, *user.InvitedBy)
-		if err != nil {
-			return err
+	needAdmin := capExceeded ||
+		(policy.EscalationThreshold > 0 && amount.GreaterThanOrEqual(decimal.NewFromFloat(policy.EscalationThreshold)))
+	if needAdmin && !hasAdmin {
+		return model.FundStatusEscalated
+	}
+
+	required := 1
+	if policy.DualApprovalThreshold > 0 && amount.GreaterThanOrEqual(decimal.NewFromFloat(policy.DualApprovalThreshold)) {
+		required = 2
+	}
+	if len(approverRoles) < required {
+		return model.FundStatusPendingSecondApproval
+	}
+	return model.FundStatusApproved
+}
+
+// fundRequestScopeLabel 资金申请 SLA 指标的范围标签：玩家申请记为 owner，房主/管理员自身的申请记为 platform
+// 不以房主ID作标签，避免指标基数随房主数量无限增长（按房主的明细可从 fund_requests 表查询）
+func fundRequestScopeLabel(ownerID *int64) string {
+	if ownerID == nil {
+		return "platform"
+	}
+	return "owner"
+}
+
+// recordFundRequestSLA 记录资金申请从创建到终态的耗时
+func recordFundRequestSLA(fundReq *model.FundRequest, outcome model.FundRequestStatus) {
+	metrics.RecordFundRequestResolution(
+		fundRequestScopeLabel(fundReq.OwnerID), string(fundReq.Type), string(outcome), time.Since(fundReq.CreatedAt),
+	)
+}
+
+// expiresBefore 申请有效期的截止创建时间：早于该时间创建的待审批申请已过期（未配置有效期时返回零值）
+func (s *FundService) expiresBefore(now time.Time) time.Time {
+	if s.cfg == nil || s.cfg.Fund.RequestTTL <= 0 {
+		return time.Time{}
+	}
+	return now.Add(-s.cfg.Fund.RequestTTL)
+}
+
+// ExpireStaleRequests 将超过有效期仍未处理的申请标记为过期，并通知申请人
+// 正在审批（申请行已被锁定）的申请会被跳过，由审批流程按有效期拒绝，过期与审批不会同时生效
+// 注：资金申请创建时不冻结余额（余额在审批通过时才变动），因此过期无需释放冻结资金
+func (s *FundService) ExpireStaleRequests(ctx context.Context) (int, error) {
+	cutoff := s.expiresBefore(time.Now())
+	if cutoff.IsZero() {
+		return 0, nil
+	}
+
+	expired, err := s.fundRepo.ExpireStale(ctx, cutoff)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, fundReq := range expired {
+		recordFundRequestSLA(fundReq, model.FundStatusExpired)
+		if s.hub != nil {
+			s.hub.SendToUser(fundReq.UserID, &model.WSMessage{
+				Type: model.WSTypeFundRequestExpired,
+				Payload: &model.WSFundRequestExpired{
+					RequestID: fundReq.ID,
+					Type:      string(fundReq.Type),
+					Amount:    fundReq.Amount.String(),
+				},
+			})
 		}
+	}
+	return len(expired), nil
+}
 
-		playerNewBalance := user.Balance.Sub(fundReq.Amount)
-		ownerNewBalance := owner.Balance.Add(fundReq.Amount)
+// SendPendingReminders 提醒房主处理等待时间超过提醒间隔的申请（每个房主合并为一条通知）
+func (s *FundService) SendPendingReminders(ctx context.Context) (int, error) {
+	if s.cfg == nil || s.cfg.Fund.ReminderInterval <= 0 {
+		return 0, nil
+	}
 
-		// 使用事务确保原子性
-		err = repository.Tx(ctx, func(tx pgx.Tx) error {
-			// 玩家余额减少
-			if err := s.userRepo.UpdateBalanceTx(ctx, tx, fundReq.UserID, fundReq.Amount.Neg()); err != nil {
-				return fmt.Errorf("deduct player balance: %w", err)
-			}
-			// 记录玩家交易流水（提现）
-			playerTx := &model.BalanceTransaction{
-				UserID:        fundReq.UserID,
-				Type:          model.TxWithdraw,
-				Amount:        fundReq.Amount.Neg(),
-				BalanceBefore: user.Balance,
-				BalanceAfter:  playerNewBalance,
-				BalanceField:  "balance",
-				Remark:        strPtr(fmt.Sprintf("玩家提现(申请ID:%d)", fundReq.ID)),
-			}
-			if err := s.txRepo.CreateTx(ctx, tx, playerTx); err != nil {
-				return fmt.Errorf("create player transaction: %w", err)
-			}
+	due, err := s.fundRepo.ListDueReminders(ctx, time.Now().Add(-s.cfg.Fund.ReminderInterval))
+	if err != nil || len(due) == 0 {
+		return 0, err
+	}
 
-			// 房主余额增加
-			if err := s.userRepo.UpdateBalanceTx(ctx, tx, *user.InvitedBy, fundReq.Amount); err != nil {
-				return fmt.Errorf("add owner balance: %w", err)
-			}
-			// 记录房主交易流水（收回玩家提现）
-			ownerTx := &model.BalanceTransaction{
-				UserID:        *user.InvitedBy,
-				Type:          model.TxWithdraw, // 从房主角度是收回
-				Amount:        fundReq.Amount,
-				BalanceBefore: owner.Balance,
-				BalanceAfter:  ownerNewBalance,
-				BalanceField:  "balance",
-				Remark:        strPtr(fmt.Sprintf("玩家提现收回(玩家ID:%d,申请ID:%d)", fundReq.UserID, fundReq.ID)),
-			}
-			if err := s.txRepo.CreateTx(ctx, tx, ownerTx); err != nil {
-				return fmt.Errorf("create owner transaction: %w", err)
-			}
-			return nil
-		})
+	byOwner := make(map[int64][]*model.FundRequest)
+	for _, fundReq := range due {
+		byOwner[*fundReq.OwnerID] = append(byOwner[*fundReq.OwnerID], fundReq)
+	}
+
+	ids := make([]int64, 0, len(due))
+	for ownerID, reqs := range byOwner {
+		reminder := &model.WSFundRequestReminder{
+			PendingCount:    len(reqs),
+			RequestIDs:      make([]int64, 0, len(reqs)),
+			OldestCreatedAt: reqs[0].CreatedAt.UnixMilli(), // 已按创建时间升序
+		}
+		for _, fundReq := range reqs {
+			reminder.RequestIDs = append(reminder.RequestIDs, fundReq.ID)
+		}
+		if s.hub != nil {
+			s.hub.SendToUser(ownerID, &model.WSMessage{
+				Type:    model.WSTypeFundRequestReminder,
+				Payload: reminder,
+			})
+		}
+		metrics.RecordFundRequestReminder()
+		ids = append(ids, reminder.RequestIDs...)
+	}
+
+	if err := s.fundRepo.MarkReminded(ctx, ids); err != nil {
+		return 0, err
+	}
+	return len(byOwner), nil
+}
+
+// executeBalanceChangeTx 在调用方事务内执行余额变动并记录交易流水，返回事务提交后发送通知的函数
+// 涉及房主与玩家两行时先锁定房主行再锁定玩家行，余额变动前后的值均在锁内读取
+func (s *FundService) executeBalanceChangeTx(ctx context.Context, tx pgx.Tx, fundReq *model.FundRequest) (func(), error) {
+	user, err := s.userRepo.GetByID(ctx, fundReq.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	switch fundReq.Type {
+	case model.FundRequestDeposit, model.FundRequestWithdraw:
+		// 玩家充值: 房主余额减少，玩家该币种钱包增加（线下转账后的确认操作）
+		// 玩家提现: 玩家该币种钱包减少，房主余额增加（线下转账前的确认操作）
+		// 资金守恒：房主余额变动与玩家余额变动互为相反数
+		if user.InvitedBy == nil {
+			return nil, errors.New("player must have an owner")
+		}
+		ownerID := *user.InvitedBy
+		owner, err := s.walletRepo.GetActiveForUpdateTx(ctx, tx, ownerID)
 		if err != nil {
-			return err
+			return nil, err
 		}
-		// 事务成功后发送 WebSocket 通知
-		s.notifyBalanceUpdate(fundReq.UserID, playerNewBalance, user.FrozenBalance)
-		s.notifyBalanceUpdate(*user.InvitedBy, ownerNewBalance, owner.FrozenBalance)
-		return nil
-
-	case model.FundRequestOwnerDeposit:
-		// 房主充值：增加房主可用余额
-		newBalance := user.Balance.Add(fundReq.Amount)
-		err = repository.Tx(ctx, func(tx pgx.Tx) error {
-			if err := s.userRepo.UpdateBalanceTx(ctx, tx, fundReq.UserID, fundReq.Amount); err != nil {
-				return fmt.Errorf("add owner balance: %w", err)
-			}
-			// 记录房主充值交易流水
-			ownerTx := &model.BalanceTransaction{
-				UserID:        fundReq.UserID,
-				Type:          model.TxDeposit,
-				Amount:        fundReq.Amount,
-				BalanceBefore: user.Balance,
-				BalanceAfter:  newBalance,
-				BalanceField:  "balance",
-				Remark:        strPtr(fmt.Sprintf("房主充值(申请ID:%d)", fundReq.ID)),
-			}
-			if err := s.txRepo.CreateTx(ctx, tx, ownerTx); err != nil {
-				return fmt.Errorf("create owner deposit transaction: %w", err)
+		if owner.Currency != fundReq.Currency {
+			return nil, ErrFundCurrencyMismatch
+		}
+
+		deposit := fundReq.Type == model.FundRequestDeposit
+		ownerDelta, playerDelta := fundReq.Amount.Neg(), fundReq.Amount
+		if !deposit {
+			ownerDelta, playerDelta = fundReq.Amount, fundReq.Amount.Neg()
+		}
+		// 检查房主余额是否足够（不是检查保证金，保证金永远不动）
+		if deposit && owner.Balance.LessThan(fundReq.Amount) {
+			return nil, errors.New("owner has insufficient balance")
+		}
+		if err := s.userRepo.UpdateBalanceTx(ctx, tx, ownerID, ownerDelta); err != nil {
+			return nil, fmt.Errorf("update owner balance: %w", err)
+		}
+		ownerNewBalance := owner.Balance.Add(ownerDelta)
+
+		// 玩家余额总额不得超出房主信用额度（房主行已锁定，同一房主的充值串行校验）
+		if deposit && s.creditLimiter != nil {
+			if err := s.creditLimiter.CheckDepositTx(ctx, tx, ownerID, fundReq.Amount); err != nil {
+				return nil, err
 			}
-			return nil
-		})
+		}
+
+		// 玩家该币种钱包变动（提现余额不足时失败）
+		playerNewBalance, playerActive, err := s.walletRepo.AdjustTx(ctx, tx, fundReq.UserID, fundReq.Currency, playerDelta)
+		if errors.Is(err, repository.ErrInsufficientBalance) {
+			return nil, errors.New("player has insufficient balance")
+		}
 		if err != nil {
-			return err
+			return nil, fmt.Errorf("update player balance: %w", err)
 		}
-		// 事务成功后发送 WebSocket 通知
-		s.notifyBalanceUpdate(fundReq.UserID, newBalance, user.FrozenBalance)
-		return nil
 
-	case model.FundRequestOwnerWithdraw:
-		// 房主提现：减少房主可用余额
-		if user.Balance.LessThan(fundReq.Amount) {
-			return errors.New("owner has insufficient balance")
+		txType, ownerRemark, playerRemark := model.TxDeposit, "玩家充值转出(玩家ID:%d,申请ID:%d)", "玩家充值(申请ID:%d)"
+		if !deposit {
+			txType, ownerRemark, playerRemark = model.TxWithdraw, "玩家提现收回(玩家ID:%d,申请ID:%d)", "玩家提现(申请ID:%d)"
 		}
-		newBalance := user.Balance.Sub(fundReq.Amount)
-		err = repository.Tx(ctx, func(tx pgx.Tx) error {
-			if err := s.userRepo.UpdateBalanceTx(ctx, tx, fundReq.UserID, fundReq.Amount.Neg()); err != nil {
-				return fmt.Errorf("deduct owner balance: %w", err)
-			}
-			// 记录房主提现交易流水
-			ownerTx := &model.BalanceTransaction{
-				UserID:        fundReq.UserID,
-				Type:          model.TxWithdraw,
-				Amount:        fundReq.Amount.Neg(),
-				BalanceBefore: user.Balance,
-				BalanceAfter:  newBalance,
-				BalanceField:  "balance",
-				Remark:        strPtr(fmt.Sprintf("房主提现(申请ID:%d)", fundReq.ID)),
-			}
-			if err := s.txRepo.CreateTx(ctx, tx, ownerTx); err != nil {
-				return fmt.Errorf("create owner withdraw transaction: %w", err)
+		ownerTx := &model.BalanceTransaction{
+			UserID:        ownerID,
+			Type:          txType, // 从房主角度是转出（充值）或收回（提现）
+			Amount:        ownerDelta,
+			BalanceBefore: owner.Balance,
+			BalanceAfter:  ownerNewBalance,
+			BalanceField:  "balance",
+			Currency:      fundReq.Currency,
+			Remark:        strPtr(fmt.Sprintf(ownerRemark, fundReq.UserID, fundReq.ID)),
+		}
+		if err := s.txRepo.CreateTx(ctx, tx, ownerTx); err != nil {
+			return nil, fmt.Errorf("create owner transaction: %w", err)
+		}
+		playerTx := &model.BalanceTransaction{
+			UserID:        fundReq.UserID,
+			Type:          txType,
+			Amount:        playerDelta,
+			BalanceBefore: playerNewBalance.Sub(playerDelta),
+			BalanceAfter:  playerNewBalance,
+			BalanceField:  walletBalanceField(playerActive, fundReq.Currency),
+			Currency:      fundReq.Currency,
+			Remark:        strPtr(fmt.Sprintf(playerRemark, fundReq.ID)),
+		}
+		if err := s.txRepo.CreateTx(ctx, tx, playerTx); err != nil {
+			return nil, fmt.Errorf("create player transaction: %w", err)
+		}
+
+		return func() {
+			if playerActive {
+				s.notifyBalanceUpdate(fundReq.UserID, playerNewBalance, user.FrozenBalance)
 			}
-			return nil
-		})
+			s.notifyBalanceUpdate(ownerID, ownerNewBalance, owner.FrozenBalance)
+		}, nil
+
+	case model.FundRequestOwnerDeposit, model.FundRequestOwnerWithdraw:
+		// 房主资金申请必须以房主经营币种结算
+		wallet, err := s.walletRepo.GetActiveForUpdateTx(ctx, tx, fundReq.UserID)
 		if err != nil {
-			return err
+			return nil, err
+		}
+		if wallet.Currency != fundReq.Currency {
+			return nil, ErrFundCurrencyMismatch
 		}
-		// 事务成功后发送 WebSocket 通知
-		s.notifyBalanceUpdate(fundReq.UserID, newBalance, user.FrozenBalance)
-		return nil
+
+		delta, txType, remark := fundReq.Amount, model.TxDeposit, "房主充值(申请ID:%d)"
+		if fundReq.Type == model.FundRequestOwnerWithdraw {
+			if wallet.Balance.LessThan(fundReq.Amount) {
+				return nil, errors.New("owner has insufficient balance")
+			}
+			delta, txType, remark = fundReq.Amount.Neg(), model.TxWithdraw, "房主提现(申请ID:%d)"
+		}
+		if err := s.userRepo.UpdateBalanceTx(ctx, tx, fundReq.UserID, delta); err != nil {
+			return nil, fmt.Errorf("update owner balance: %w", err)
+		}
+		newBalance := wallet.Balance.Add(delta)
+		ownerTx := &model.BalanceTransaction{
+			UserID:        fundReq.UserID,
+			Type:          txType,
+			Amount:        delta,
+			BalanceBefore: wallet.Balance,
+			BalanceAfter:  newBalance,
+			BalanceField:  "balance",
+			Remark:        strPtr(fmt.Sprintf(remark, fundReq.ID)),
+		}
+		if err := s.txRepo.CreateTx(ctx, tx, ownerTx); err != nil {
+			return nil, fmt.Errorf("create owner transaction: %w", err)
+		}
+		return func() {
+			s.notifyBalanceUpdate(fundReq.UserID, newBalance, wallet.FrozenBalance)
+		}, nil
 
 	case model.FundRequestMarginDeposit:
 		// 房主充值保证金（仅初始设置，保证金固定不变）
-		newMargin := user.OwnerMarginBalance.Add(fundReq.Amount)
-		err = repository.Tx(ctx, func(tx pgx.Tx) error {
-			if err := s.userRepo.UpdateOwnerBalancesTx(ctx, tx, fundReq.UserID, "owner_margin_balance", fundReq.Amount); err != nil {
-				return fmt.Errorf("add margin balance: %w", err)
-			}
-			// 记录保证金充值交易流水
-			marginTx := &model.BalanceTransaction{
-				UserID:        fundReq.UserID,
-				Type:          model.TxMarginDeposit,
-				Amount:        fundReq.Amount,
-				BalanceBefore: user.OwnerMarginBalance,
-				BalanceAfter:  newMargin,
-				BalanceField:  "owner_margin_balance",
-				Remark:        strPtr(fmt.Sprintf("保证金充值(申请ID:%d)", fundReq.ID)),
-			}
-			if err := s.txRepo.CreateTx(ctx, tx, marginTx); err != nil {
-				return fmt.Errorf("create margin deposit transaction: %w", err)
-			}
-			return nil
-		})
+		if user.Currency != fundReq.Currency {
+			return nil, ErrFundCurrencyMismatch
+		}
+		marginBefore, err := s.userRepo.GetOwnerMarginForUpdateTx(ctx, tx, fundReq.UserID)
 		if err != nil {
-			return err
+			return nil, err
+		}
+		if err := s.userRepo.UpdateOwnerBalancesTx(ctx, tx, fundReq.UserID, "owner_margin_balance", fundReq.Amount); err != nil {
+			return nil, fmt.Errorf("add margin balance: %w", err)
 		}
-		// 事务成功后发送 WebSocket 通知（保证金更新，发送余额通知让前端刷新）
-		s.notifyBalanceUpdate(fundReq.UserID, user.Balance, user.FrozenBalance)
-		return nil
+		// 记录保证金充值交易流水
+		marginTx := &model.BalanceTransaction{
+			UserID:        fundReq.UserID,
+			Type:          model.TxMarginDeposit,
+			Amount:        fundReq.Amount,
+			BalanceBefore: marginBefore,
+			BalanceAfter:  marginBefore.Add(fundReq.Amount),
+			BalanceField:  "owner_margin_balance",
+			Remark:        strPtr(fmt.Sprintf("保证金充值(申请ID:%d)", fundReq.ID)),
+		}
+		if err := s.txRepo.CreateTx(ctx, tx, marginTx); err != nil {
+			return nil, fmt.Errorf("create margin deposit transaction: %w", err)
+		}
+		return func() {
+			// 保证金更新，发送余额通知让前端刷新
+			s.notifyBalanceUpdate(fundReq.UserID, user.Balance, user.FrozenBalance)
+			// 保证金增加后重新评估信用额度，解除超限状态
+			if s.creditLimiter != nil {
+				_, _ = s.creditLimiter.EnforceOwner(context.Background(), fundReq.UserID)
+			}
+		}, nil
 	}
 
-	return nil
+	return nil, nil
 }
 
 // strPtr 辅助函数：将字符串转为指针
@@ -362,6 +711,11 @@ func (s *FundService) ListFundRequests(ctx context.Context, query *model.FundReq
 	return s.fundRepo.List(ctx, query)
 }
 
+// ListFundRequestApprovals 获取资金申请的审批记录
+func (s *FundService) ListFundRequestApprovals(ctx context.Context, requestID int64) ([]*model.FundRequestApproval, error) {
+	return s.fundRepo.ListApprovals(ctx, requestID)
+}
+
 // GetFundRequest 获取单个申请
 func (s *FundService) GetFundRequest(ctx context.Context, id int64) (*model.FundRequest, error) {
 	return s.fundRepo.GetByID(ctx, id)
@@ -393,28 +747,73 @@ func (s *FundService) ListTransactions(ctx context.Context, query *model.Transac
 	return s.txRepo.List(ctx, query)
 }
 
-// GetPlatformAccount 获取平台账户
-func (s *FundService) GetPlatformAccount(ctx context.Context) (*model.PlatformAccount, error) {
-	return s.platformRepo.GetAccount(ctx)
+// GetPlatformAccount 获取指定币种的平台账户
+func (s *FundService) GetPlatformAccount(ctx context.Context, currency string) (*model.PlatformAccount, error) {
+	return s.platformRepo.GetAccount(ctx, currency)
+}
+
+// CheckConservation 检查指定币种的资金守恒
+func (s *FundService) CheckConservation(ctx context.Context, currency string) (*model.ConservationCheck, error) {
+	return s.platformRepo.CheckConservation(ctx, currency)
+}
+
+// ListCurrencies 需要对账的全部币种（已配置币种 + 系统中出现过的币种）
+func (s *FundService) ListCurrencies(ctx context.Context) ([]string, error) {
+	used, err := s.platformRepo.ListCurrencies(ctx)
+	if err != nil {
+		return nil, err
+	}
+	currencies := SupportedCurrencies()
+	for _, c := range used {
+		if !IsSupportedCurrency(c) {
+			currencies = append(currencies, c)
+		}
+	}
+	return currencies, nil
 }
 
-// CheckConservation 检查资金守恒
-func (s *FundService) CheckConservation(ctx context.Context) (*model.ConservationCheck, error) {
-	return s.platformRepo.CheckConservation(ctx)
+// CheckAllConservation 按币种逐一检查资金守恒
+func (s *FundService) CheckAllConservation(ctx context.Context) ([]*model.ConservationCheck, error) {
+	currencies, err := s.ListCurrencies(ctx)
+	if err != nil {
+		return nil, err
+	}
+	checks := make([]*model.ConservationCheck, 0, len(currencies))
+	for _, currency := range currencies {
+		check, err := s.platformRepo.CheckConservation(ctx, currency)
+		if err != nil {
+			return nil, fmt.Errorf("check conservation %s: %w", currency, err)
+		}
+		checks = append(checks, check)
+	}
+	return checks, nil
 }
 
-// GetReconciliationReport 获取详细的资金对账报告
-func (s *FundService) GetReconciliationReport(ctx context.Context) (*model.FundReconciliationReport, error) {
-	return s.platformRepo.GetReconciliationReport(ctx)
+// GetReconciliationReport 获取指定币种的详细资金对账报告
+func (s *FundService) GetReconciliationReport(ctx context.Context, currency string) (*model.FundReconciliationReport, error) {
+	return s.platformRepo.GetReconciliationReport(ctx, currency)
 }
 
-// GetFundSummary 获取资金统计摘要
-func (s *FundService) GetFundSummary(ctx context.Context, userID *int64) (*model.FundSummary, error) {
+// GetFundSummary 获取指定币种的资金统计摘要
+// currency 为空时：指定用户使用其账户当前币种，全局使用默认币种
+func (s *FundService) GetFundSummary(ctx context.Context, userID *int64, currency string) (*model.FundSummary, error) {
 	summary := &model.FundSummary{}
 
+	if currency == "" {
+		currency = DefaultCurrency
+		if userID != nil {
+			user, err := s.userRepo.GetByID(ctx, *userID)
+			if err != nil {
+				return nil, err
+			}
+			currency = user.Currency
+		}
+	}
+
 	// 简化实现: 从交易记录聚合
 	query := &model.TransactionListQuery{
 		UserID:   userID,
+		Currency: &currency,
 		Page:     1,
 		PageSize: 10000, // 获取全部
 	}
@@ -435,11 +834,15 @@ func (s *FundService) GetFundSummary(ctx context.Context, userID *int64) (*model
 			summary.TotalWin = summary.TotalWin.Add(tx.Amount)
 		case model.TxOwnerCommission:
 			summary.TotalCommission = summary.TotalCommission.Add(tx.Amount)
+		case model.TxTransferIn:
+			summary.TotalTransferIn = summary.TotalTransferIn.Add(tx.Amount)
+		case model.TxTransferOut:
+			summary.TotalTransferOut = summary.TotalTransferOut.Add(tx.Amount.Abs())
 		}
 	}
 
 	// 获取平台余额
-	acc, err := s.platformRepo.GetAccount(ctx)
+	acc, err := s.platformRepo.GetAccount(ctx, currency)
 	if err == nil {
 		summary.PlatformBalance = acc.PlatformBalance
 	}
@@ -461,6 +864,7 @@ func (s *FundService) RecordGlobalConservation(ctx context.Context, periodType s
 	h := &model.FundConservationHistory{
 		Scope:              "global",
 		OwnerID:            nil,
+		Currency:           check.Currency,
 		PeriodType:         periodType,
 		PeriodStart:        periodStart,
 		PeriodEnd:          periodEnd,
@@ -487,8 +891,11 @@ func (s *FundService) RecordOwnerConservation2h(ctx context.Context, periodStart
 	)
 	SELECT
 	    o.id AS owner_id,
-	    COALESCE(SUM(CASE WHEN u.role = 'player' THEN u.balance + u.frozen_balance ELSE 0 END), 0) AS total_player,
-	    COALESCE(SUM(CASE WHEN u.role = 'player' THEN u.frozen_balance ELSE 0 END), 0) AS total_player_frozen,
+	    o.currency,
+	    COALESCE(SUM(CASE WHEN u.role = 'player' AND u.currency = o.currency THEN u.balance + u.frozen_balance ELSE 0 END), 0)
+	        + COALESCE((SELECT SUM(w.balance) FROM user_wallets w JOIN users p ON w.user_id = p.id
+	            WHERE p.role = 'player' AND p.invited_by = o.id AND w.currency = o.currency AND p.currency <> o.currency), 0) AS total_player,
+	    COALESCE(SUM(CASE WHEN u.role = 'player' AND u.currency = o.currency THEN u.frozen_balance ELSE 0 END), 0) AS total_player_frozen,
 	    o.balance AS owner_balance,
 	    o.owner_margin_balance,
 	    o.owner_room_balance
@@ -496,7 +903,7 @@ func (s *FundService) RecordOwnerConservation2h(ctx context.Context, periodStart
 	JOIN active_owners ao ON ao.owner_id = o.id
 	LEFT JOIN users u ON u.invited_by = o.id
 	WHERE o.role = 'owner'
-	GROUP BY o.id, o.balance, o.owner_margin_balance, o.owner_room_balance`
+	GROUP BY o.id, o.currency, o.balance, o.owner_margin_balance, o.owner_room_balance`
 
 	rows, err := repository.DB.Query(ctx, sql)
 	if err != nil {
@@ -506,8 +913,9 @@ func (s *FundService) RecordOwnerConservation2h(ctx context.Context, periodStart
 
 	for rows.Next() {
 		var ownerID int64
+		var currency string
 		var totalPlayer, totalPlayerFrozen, ownerBalance, margin, roomBal decimal.Decimal
-		if err := rows.Scan(&ownerID, &totalPlayer, &totalPlayerFrozen, &ownerBalance, &margin, &roomBal); err != nil {
+		if err := rows.Scan(&ownerID, &currency, &totalPlayer, &totalPlayerFrozen, &ownerBalance, &margin, &roomBal); err != nil {
 			return err
 		}
 
@@ -521,6 +929,7 @@ func (s *FundService) RecordOwnerConservation2h(ctx context.Context, periodStart
 		h := &model.FundConservationHistory{
 			Scope:              "owner",
 			OwnerID:            &ownerIDCopy,
+			Currency:           currency,
 			PeriodType:         "2h",
 			PeriodStart:        periodStart,
 			PeriodEnd:          periodEnd,
@@ -545,52 +954,31 @@ func (s *FundService) RecordOwnerConservation2h(ctx context.Context, periodStart
 }
 
 // RecordOwnerConservationDaily 记录按房主维度的每日对账快照
+// 优先使用日终时刻不晚于 dayEnd 的最近一次余额快照，尚无快照时读取实时余额
 func (s *FundService) RecordOwnerConservationDaily(ctx context.Context, dayStart, dayEnd time.Time) error {
-	rows, err := repository.DB.Query(ctx, `WITH active_owners AS (
-	    SELECT DISTINCT r.owner_id
-	    FROM game_rounds gr
-	    JOIN rooms r ON gr.room_id = r.id
-	)
-	SELECT
-	    o.id AS owner_id,
-	    COALESCE(SUM(CASE WHEN u.role = 'player' THEN u.balance + u.frozen_balance ELSE 0 END), 0) AS total_player,
-	    COALESCE(SUM(CASE WHEN u.role = 'player' THEN u.frozen_balance ELSE 0 END), 0) AS total_player_frozen,
-	    o.balance AS owner_balance,
-	    o.owner_margin_balance,
-	    o.owner_room_balance
-	FROM users o
-	JOIN active_owners ao ON ao.owner_id = o.id
-	LEFT JOIN users u ON u.invited_by = o.id
-	WHERE o.role = 'owner'
-	GROUP BY o.id, o.balance, o.owner_margin_balance, o.owner_room_balance`)
+	summaries, err := s.ownerDailySummaries(ctx, dayEnd)
 	if err != nil {
 		return err
 	}
-	defer rows.Close()
 
-	for rows.Next() {
-		var ownerID int64
-		var totalPlayer, totalPlayerFrozen, ownerBalance, margin, roomBal decimal.Decimal
-		if err := rows.Scan(&ownerID, &totalPlayer, &totalPlayerFrozen, &ownerBalance, &margin, &roomBal); err != nil {
-			return err
-		}
-
-		totalInSystem := ownerBalance.Add(totalPlayer).Add(roomBal)
+	for _, sum := range summaries {
+		totalInSystem := sum.OwnerBalance.Add(sum.TotalPlayer).Add(sum.CommissionBalance)
 		diff := decimal.Zero
 		isBalanced := true
 
-		ownerIDCopy := ownerID
+		ownerIDCopy := sum.OwnerID
 		h := &model.FundConservationHistory{
 			Scope:              "owner",
 			OwnerID:            &ownerIDCopy,
+			Currency:           sum.Currency,
 			PeriodType:         "daily",
 			PeriodStart:        dayStart,
 			PeriodEnd:          dayEnd,
-			TotalPlayerBalance: totalPlayer,
-			TotalPlayerFrozen:  totalPlayerFrozen,
-			TotalCustodyQuota:  ownerBalance, // 复用字段存储房主可用余额
-			TotalMargin:        margin,
-			OwnerRoomBalance:   roomBal,
+			TotalPlayerBalance: sum.TotalPlayer,
+			TotalPlayerFrozen:  sum.TotalPlayerFrozen,
+			TotalCustodyQuota:  sum.OwnerBalance, // 复用字段存储房主可用余额
+			TotalMargin:        sum.MarginBalance,
+			OwnerRoomBalance:   sum.CommissionBalance,
 			PlatformBalance:    totalInSystem,
 			Difference:         diff,
 			IsBalanced:         isBalanced,
@@ -601,7 +989,59 @@ func (s *FundService) RecordOwnerConservationDaily(ctx context.Context, dayStart
 		}
 	}
 
-	return rows.Err()
+	if s.ownerRisk != nil {
+		s.ownerRisk.CheckOwners(ctx, dayStart, dayEnd, summaries)
+	}
+	return nil
+}
+
+// ownerDailySummaries 按房主汇总每日对账所需余额：优先读取日终快照，无快照时读取实时余额
+func (s *FundService) ownerDailySummaries(ctx context.Context, dayEnd time.Time) ([]*model.OwnerSnapshotSummary, error) {
+	if s.snapshotRepo != nil {
+		date, err := s.snapshotRepo.GetLatestDate(ctx, dayEnd)
+		if err == nil {
+			return s.snapshotRepo.ListOwnerSummaries(ctx, date)
+		}
+		if !errors.Is(err, repository.ErrNotFound) {
+			return nil, err
+		}
+	}
+
+	rows, err := repository.DB.Query(ctx, `WITH active_owners AS (
+	    SELECT DISTINCT r.owner_id
+	    FROM game_rounds gr
+	    JOIN rooms r ON gr.room_id = r.id
+	)
+	SELECT
+	    o.id AS owner_id,
+	    o.currency,
+	    COALESCE(SUM(CASE WHEN u.role = 'player' AND u.currency = o.currency THEN u.balance + u.frozen_balance ELSE 0 END), 0)
+	        + COALESCE((SELECT SUM(w.balance) FROM user_wallets w JOIN users p ON w.user_id = p.id
+	            WHERE p.role = 'player' AND p.invited_by = o.id AND w.currency = o.currency AND p.currency <> o.currency), 0) AS total_player,
+	    COALESCE(SUM(CASE WHEN u.role = 'player' AND u.currency = o.currency THEN u.frozen_balance ELSE 0 END), 0) AS total_player_frozen,
+	    o.balance AS owner_balance,
+	    o.owner_margin_balance,
+	    o.owner_room_balance
+	FROM users o
+	JOIN active_owners ao ON ao.owner_id = o.id
+	LEFT JOIN users u ON u.invited_by = o.id
+	WHERE o.role = 'owner'
+	GROUP BY o.id, o.currency, o.balance, o.owner_margin_balance, o.owner_room_balance`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var summaries []*model.OwnerSnapshotSummary
+	for rows.Next() {
+		sum := &model.OwnerSnapshotSummary{}
+		if err := rows.Scan(&sum.OwnerID, &sum.Currency, &sum.TotalPlayer, &sum.TotalPlayerFrozen,
+			&sum.OwnerBalance, &sum.MarginBalance, &sum.CommissionBalance); err != nil {
+			return nil, err
+		}
+		summaries = append(summaries, sum)
+	}
+	return summaries, rows.Err()
 }
 
 // ListConservationHistory 查询对账历史（全局 + 房主）
@@ -0,0 +1,263 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/fiveseconds/server/internal/config"
+	"github.com/fiveseconds/server/internal/model"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/shopspring/decimal"
+)
+
+// fundEventRecorder 记录收到的资金变动事件
+type fundEventRecorder struct {
+	events chan *model.FundEvent
+}
+
+func (r *fundEventRecorder) OnFundEvent(ctx context.Context, event *model.FundEvent) {
+	r.events <- event
+}
+
+// TestDispatchFundEvent 测试资金变动事件恰好分发一次，未配置钩子时忽略
+func TestDispatchFundEvent(t *testing.T) {
+	dispatchFundEvent(nil, &model.FundEvent{Type: model.FundEventRequestApproved, UserID: 1})
+
+	tests := []model.FundEventType{
+		model.FundEventRequestApproved,
+		model.FundEventEarningsTransfer,
+		model.FundEventSettlementCredit,
+	}
+	for _, eventType := range tests {
+		t.Run(string(eventType), func(t *testing.T) {
+			event := &model.FundEvent{Type: eventType, UserID: 42, Amount: decimal.NewFromInt(100)}
+			recorder := &fundEventRecorder{events: make(chan *model.FundEvent, 2)}
+			dispatchFundEvent(recorder, event)
+
+			select {
+			case got := <-recorder.events:
+				if got != event {
+					t.Errorf("Expected the dispatched event, got %+v", got)
+				}
+			case <-time.After(time.Second):
+				t.Fatal("Expected the event to be dispatched")
+			}
+			select {
+			case got := <-recorder.events:
+				t.Errorf("Expected a single dispatch, got another %+v", got)
+			case <-time.After(10 * time.Millisecond):
+			}
+		})
+	}
+}
+
+// testApprovalPolicy 测试用审批策略：5000 起双人审批，20000 起需管理员，房主每日审批额度 50000
+var testApprovalPolicy = config.FundApprovalConfig{
+	DualApprovalThreshold: 5000,
+	EscalationThreshold:   20000,
+	OwnerDailyApprovalCap: 50000,
+}
+
+// TestNextApprovalStatus 测试审批策略：双人审批阈值、升级阈值与房主每日额度
+func TestNextApprovalStatus(t *testing.T) {
+	owner, admin := model.RoleOwner, model.RoleAdmin
+	tests := []struct {
+		name        string
+		policy      config.FundApprovalConfig
+		amount      string
+		roles       []model.Role
+		capExceeded bool
+		want        model.FundRequestStatus
+	}{
+		{"owner below dual threshold", testApprovalPolicy, "4999.99", []model.Role{owner}, false, model.FundStatusApproved},
+		{"admin below dual threshold", testApprovalPolicy, "0.01", []model.Role{admin}, false, model.FundStatusApproved},
+		{"first of two approvers", testApprovalPolicy, "5000", []model.Role{admin}, false, model.FundStatusPendingSecondApproval},
+		{"second approver", testApprovalPolicy, "5000", []model.Role{admin, owner}, false, model.FundStatusApproved},
+		{"escalated amount by owner", testApprovalPolicy, "20000", []model.Role{owner}, false, model.FundStatusEscalated},
+		{"escalated amount by two owners", testApprovalPolicy, "20000", []model.Role{owner, owner}, false, model.FundStatusEscalated},
+		{"escalated amount by one admin", testApprovalPolicy, "20000", []model.Role{admin}, false, model.FundStatusPendingSecondApproval},
+		{"escalated amount by owner and admin", testApprovalPolicy, "20000", []model.Role{owner, admin}, false, model.FundStatusApproved},
+		{"owner over daily cap", testApprovalPolicy, "100", []model.Role{owner}, true, model.FundStatusEscalated},
+		{"admin after owner over daily cap", testApprovalPolicy, "100", []model.Role{owner, admin}, true, model.FundStatusApproved},
+		{"policy disabled", config.FundApprovalConfig{}, "1000000", []model.Role{owner}, false, model.FundStatusApproved},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := nextApprovalStatus(tt.policy, decimal.RequireFromString(tt.amount), tt.roles, tt.capExceeded)
+			if got != tt.want {
+				t.Errorf("Expected status %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
+
+// TestPlanFundApproval 测试加锁后的审批决策：已处理申请不可再审批、拒绝直达终态、重复审批人被拒绝、
+// 升级申请仅管理员可审批、房主每日额度按当日已通过金额累计
+func TestPlanFundApproval(t *testing.T) {
+	approval := func(approverID int64, role model.Role, action model.FundApprovalAction) *model.FundRequestApproval {
+		return &model.FundRequestApproval{RequestID: 1, ApproverID: approverID, ApproverRole: role, Action: action}
+	}
+	tests := []struct {
+		name          string
+		status        model.FundRequestStatus
+		amount        string
+		role          model.Role
+		approverID    int64
+		approved      bool
+		approvals     []*model.FundRequestApproval
+		approvedToday string
+		want          model.FundRequestStatus
+		wantErr       error
+	}{
+		{"already approved", model.FundStatusApproved, "100", model.RoleAdmin, 10, true, nil, "0", "", ErrRequestAlreadyProcessed},
+		{"already rejected", model.FundStatusRejected, "100", model.RoleAdmin, 10, false, nil, "0", "", ErrRequestAlreadyProcessed},
+		{"already expired", model.FundStatusExpired, "100", model.RoleAdmin, 10, true, nil, "0", "", ErrRequestAlreadyProcessed},
+		{"owner rejects", model.FundStatusPending, "100", model.RoleOwner, 10, false, nil, "0", model.FundStatusRejected, nil},
+		{"owner rejects escalated", model.FundStatusEscalated, "30000", model.RoleOwner, 10, false, nil, "0", model.FundStatusRejected, nil},
+		{"single approval", model.FundStatusPending, "100", model.RoleOwner, 10, true, nil, "0", model.FundStatusApproved, nil},
+		{
+			"first approver again", model.FundStatusPendingSecondApproval, "6000", model.RoleAdmin, 10, true,
+			[]*model.FundRequestApproval{approval(10, model.RoleAdmin, model.FundApprovalApprove)}, "0", "", ErrDuplicateApprover,
+		},
+		{
+			"distinct second approver", model.FundStatusPendingSecondApproval, "6000", model.RoleOwner, 11, true,
+			[]*model.FundRequestApproval{approval(10, model.RoleAdmin, model.FundApprovalApprove)}, "0", model.FundStatusApproved, nil,
+		},
+		{
+			"earlier rejection does not count", model.FundStatusPending, "6000", model.RoleAdmin, 10, true,
+			[]*model.FundRequestApproval{approval(10, model.RoleAdmin, model.FundApprovalReject)}, "0", model.FundStatusPendingSecondApproval, nil,
+		},
+		{
+			"owner approves escalated", model.FundStatusEscalated, "30000", model.RoleOwner, 11, true,
+			[]*model.FundRequestApproval{approval(10, model.RoleOwner, model.FundApprovalApprove)}, "0", "", ErrAdminApprovalRequired,
+		},
+		{
+			"admin approves escalated", model.FundStatusEscalated, "30000", model.RoleAdmin, 12, true,
+			[]*model.FundRequestApproval{approval(10, model.RoleOwner, model.FundApprovalApprove)}, "0", model.FundStatusApproved, nil,
+		},
+		{"owner reaches daily cap", model.FundStatusPending, "1000", model.RoleOwner, 10, true, nil, "49000", model.FundStatusApproved, nil},
+		{"owner exceeds daily cap", model.FundStatusPending, "1000", model.RoleOwner, 10, true, nil, "49000.01", model.FundStatusEscalated, nil},
+		{"admin not bound by owner cap", model.FundStatusPending, "1000", model.RoleAdmin, 10, true, nil, "49000.01", model.FundStatusApproved, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := &model.FundRequest{ID: 1, Amount: decimal.RequireFromString(tt.amount), Status: tt.status}
+			got, err := planFundApproval(testApprovalPolicy, req, tt.role, tt.approverID, tt.approved, tt.approvals,
+				decimal.RequireFromString(tt.approvedToday), time.Time{})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Expected error %v, got %v", tt.wantErr, err)
+			}
+			if got != tt.want {
+				t.Errorf("Expected status %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
+
+// TestFundRequestExpiresBefore 测试申请有效期截止时间为当前时间减去有效期，未配置有效期时不过期
+func TestFundRequestExpiresBefore(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name string
+		cfg  *config.Config
+		want time.Time
+	}{
+		{"no config", nil, time.Time{}},
+		{"no ttl", &config.Config{}, time.Time{}},
+		{"negative ttl", &config.Config{Fund: config.FundConfig{RequestTTL: -time.Minute}}, time.Time{}},
+		{"one day ttl", &config.Config{Fund: config.FundConfig{RequestTTL: 24 * time.Hour}}, now.Add(-24 * time.Hour)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &FundService{cfg: tt.cfg}
+			if got := svc.expiresBefore(now); !got.Equal(tt.want) {
+				t.Errorf("Expected cutoff %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
+
+// TestPlanFundApprovalExpiry 测试超过有效期的待审批申请不能再审批或拒绝，已过期的申请按已处理拒绝
+func TestPlanFundApprovalExpiry(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	cutoff := now.Add(-24 * time.Hour)
+	tests := []struct {
+		name     string
+		status   model.FundRequestStatus
+		age      time.Duration
+		approved bool
+		cutoff   time.Time
+		wantErr  error
+	}{
+		{"fresh approval", model.FundStatusPending, time.Hour, true, cutoff, nil},
+		{"created at the cutoff", model.FundStatusPending, 24 * time.Hour, true, cutoff, nil},
+		{"approval past ttl", model.FundStatusPending, 24*time.Hour + time.Minute, true, cutoff, ErrRequestExpired},
+		{"rejection past ttl", model.FundStatusPending, 48 * time.Hour, false, cutoff, ErrRequestExpired},
+		{"second approval past ttl", model.FundStatusPendingSecondApproval, 48 * time.Hour, true, cutoff, ErrRequestExpired},
+		{"escalated past ttl", model.FundStatusEscalated, 48 * time.Hour, true, cutoff, ErrRequestExpired},
+		{"already expired", model.FundStatusExpired, 48 * time.Hour, true, cutoff, ErrRequestAlreadyProcessed},
+		{"ttl disabled", model.FundStatusPending, 30 * 24 * time.Hour, true, time.Time{}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := &model.FundRequest{ID: 1, Amount: decimal.NewFromInt(100), Status: tt.status, CreatedAt: now.Add(-tt.age)}
+			_, err := planFundApproval(testApprovalPolicy, req, model.RoleAdmin, 10, tt.approved, nil, decimal.Zero, tt.cutoff)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Expected error %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+// TestFundRequestSLAScopeLabel 测试 SLA 指标按范围而不是房主ID打标签
+func TestFundRequestSLAScopeLabel(t *testing.T) {
+	ownerID := int64(1 << 40)
+	tests := []struct {
+		name    string
+		ownerID *int64
+		want    string
+	}{
+		{"player request", &ownerID, "owner"},
+		{"owner or admin request", nil, "platform"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := fundRequestScopeLabel(tt.ownerID); got != tt.want {
+				t.Errorf("Expected scope %s, got %s", tt.want, got)
+			}
+			recordFundRequestSLA(&model.FundRequest{
+				OwnerID:   tt.ownerID,
+				Type:      model.FundRequestDeposit,
+				CreatedAt: time.Now().Add(-time.Minute),
+			}, model.FundStatusExpired)
+		})
+	}
+
+	families, err := prometheus.DefaultGatherer.Gather()
+	if err != nil {
+		t.Fatalf("gather metrics: %v", err)
+	}
+	found := false
+	for _, mf := range families {
+		if mf.GetName() != "fund_request_resolution_seconds" {
+			continue
+		}
+		found = true
+		for _, m := range mf.GetMetric() {
+			for _, lp := range m.GetLabel() {
+				if lp.GetName() == "owner_id" {
+					t.Errorf("Expected no owner_id label, got %s", lp.GetValue())
+				}
+				if lp.GetName() == "scope" && lp.GetValue() != "owner" && lp.GetValue() != "platform" {
+					t.Errorf("Expected scope owner or platform, got %s", lp.GetValue())
+				}
+			}
+		}
+	}
+	if !found {
+		t.Error("Expected fund_request_resolution_seconds to be recorded")
+	}
+}
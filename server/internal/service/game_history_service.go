@@ -7,6 +7,7 @@ import (
 	"errors"
 	"sort"
 
+	"github.com/fiveseconds/server/internal/game"
 	"github.com/fiveseconds/server/internal/model"
 	"github.com/fiveseconds/server/internal/repository"
 
@@ -123,6 +124,16 @@ func (s *GameHistoryService) VerifyRound(ctx context.Context, roundID int64) (*V
 
 	winnersMatch := compareInt64Slices(computedWinners, actualWinnerIDs)
 
+	// 开启累进奖池的回合：由同一种子复核是否触发
+	jackpotMatch := true
+	var computedJackpotHit *bool
+	if detail.JackpotOdds != nil {
+		hit := game.NewCommitReveal().JackpotTriggered(detail.RevealSeed, *detail.JackpotOdds)
+		computedJackpotHit = &hit
+		// 触发但奖池不足每人 0.01 时不派发，回合记录为未中奖
+		jackpotMatch = hit == detail.JackpotHit || (hit && !detail.JackpotPayout.IsPositive())
+	}
+
 	return &VerificationResult{
 		RoundID:         roundID,
 		CommitHash:      detail.CommitHash,
@@ -132,7 +143,10 @@ func (s *GameHistoryService) VerifyRound(ctx context.Context, roundID int64) (*V
 		ActualWinners:   actualWinnerIDs,
 		ComputedWinners: computedWinners,
 		WinnersMatch:    winnersMatch,
-		IsValid:         hashMatch && winnersMatch,
+		JackpotHit:      detail.JackpotHit,
+		ComputedJackpot: computedJackpotHit,
+		JackpotMatch:    jackpotMatch,
+		IsValid:         hashMatch && winnersMatch && jackpotMatch,
 	}, nil
 }
 
@@ -146,6 +160,9 @@ type VerificationResult struct {
 	ActualWinners   []int64 `json:"actual_winners"`
 	ComputedWinners []int64 `json:"computed_winners"`
 	WinnersMatch    bool    `json:"winners_match"`
+	JackpotHit      bool    `json:"jackpot_hit"`
+	ComputedJackpot *bool   `json:"computed_jackpot_hit,omitempty"` // 未开启累进奖池的回合为空
+	JackpotMatch    bool    `json:"jackpot_match"`
 	IsValid         bool    `json:"is_valid"`
 }
 
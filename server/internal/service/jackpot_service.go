@@ -0,0 +1,230 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/fiveseconds/server/internal/config"
+	"github.com/fiveseconds/server/internal/model"
+	"github.com/fiveseconds/server/internal/repository"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrJackpotNotFound     = errors.New("jackpot not found")
+	ErrJackpotForbidden    = errors.New("this jackpot does not belong to you")
+	ErrJackpotInvalidRate  = errors.New("contribution_rate must be between 0 and the configured maximum")
+	ErrJackpotInvalidOdds  = errors.New("trigger_odds is below the configured minimum")
+	ErrJackpotRoomNotOwned = errors.New("room does not belong to you")
+	ErrJackpotRoomType     = errors.New("jackpots are only available for standard rooms")
+)
+
+// DefaultJackpotMaxContributionRate 未配置时每回合从奖池抽取比例的上限
+const DefaultJackpotMaxContributionRate = 0.05
+
+// JackpotService 累进奖池服务
+// 房主为单个房间或名下同币种全部房间开设累进奖池；每个结算回合从奖池抽取一定比例，
+// 连同平分奖金后的舍入残值一起注入奖池。触发条件由回合公开种子确定性推导（见 game.CommitReveal.JackpotTriggered），
+// 触发时奖池余额平分给本回合赢家，由结算流程计入余额并记录 jackpot_win 流水。奖池余额计入资金守恒
+type JackpotService struct {
+	jackpotRepo *repository.JackpotRepo
+	roomRepo    *repository.RoomRepo
+	userRepo    *repository.UserRepo
+	cfg         *config.Config
+	logger      *zap.Logger
+}
+
+// NewJackpotService 创建累进奖池服务
+func NewJackpotService(
+	jackpotRepo *repository.JackpotRepo,
+	roomRepo *repository.RoomRepo,
+	userRepo *repository.UserRepo,
+	cfg *config.Config,
+	logger *zap.Logger,
+) *JackpotService {
+	return &JackpotService{
+		jackpotRepo: jackpotRepo,
+		roomRepo:    roomRepo,
+		userRepo:    userRepo,
+		cfg:         cfg,
+		logger:      logger.With(zap.String("service", "jackpot")),
+	}
+}
+
+// maxContributionRate 每回合抽取比例上限
+func (s *JackpotService) maxContributionRate() decimal.Decimal {
+	if s.cfg.Jackpot.MaxContributionRate > 0 {
+		return decimal.NewFromFloat(s.cfg.Jackpot.MaxContributionRate)
+	}
+	return decimal.NewFromFloat(DefaultJackpotMaxContributionRate)
+}
+
+// Save 房主开设或修改累进奖池（房间级或房主级），修改不影响已累积的余额
+func (s *JackpotService) Save(ctx context.Context, ownerID int64, req *model.SaveJackpotReq) (*model.Jackpot, error) {
+	rate := req.ContributionRate
+	if err := validateJackpotSettings(rate, req.TriggerOdds, s.maxContributionRate(), s.cfg.Jackpot.MinTriggerOdds); err != nil {
+		return nil, err
+	}
+
+	var existing *model.Jackpot
+	var currency string
+	if req.RoomID != nil {
+		room, err := s.roomRepo.GetByID(ctx, *req.RoomID)
+		if err != nil {
+			return nil, err
+		}
+		if room.OwnerID != ownerID {
+			return nil, ErrJackpotRoomNotOwned
+		}
+		if room.UsesChips() {
+			return nil, ErrJackpotRoomType
+		}
+		currency = room.Currency
+		if existing, err = s.jackpotRepo.GetByRoom(ctx, room.ID); err != nil && !errors.Is(err, repository.ErrNotFound) {
+			return nil, err
+		}
+	} else {
+		owner, err := s.userRepo.GetByID(ctx, ownerID)
+		if err != nil {
+			return nil, err
+		}
+		currency = owner.Currency
+		if existing, err = s.jackpotRepo.GetByOwner(ctx, ownerID, currency); err != nil && !errors.Is(err, repository.ErrNotFound) {
+			return nil, err
+		}
+	}
+
+	if existing != nil {
+		existing.ContributionRate = rate
+		existing.TriggerOdds = req.TriggerOdds
+		if req.Enabled != nil {
+			existing.Enabled = *req.Enabled
+		}
+		if err := s.jackpotRepo.UpdateSettings(ctx, existing); err != nil {
+			return nil, err
+		}
+		s.logger.Info("Jackpot updated",
+			zap.Int64("jackpot_id", existing.ID),
+			zap.String("contribution_rate", rate.String()),
+			zap.Int("trigger_odds", req.TriggerOdds),
+			zap.Bool("enabled", existing.Enabled))
+		return existing, nil
+	}
+
+	jackpot := &model.Jackpot{
+		OwnerID:          ownerID,
+		RoomID:           req.RoomID,
+		Currency:         currency,
+		ContributionRate: rate,
+		TriggerOdds:      req.TriggerOdds,
+		Enabled:          req.Enabled == nil || *req.Enabled,
+	}
+	if err := s.jackpotRepo.Create(ctx, jackpot); err != nil {
+		return nil, err
+	}
+	s.logger.Info("Jackpot created",
+		zap.Int64("jackpot_id", jackpot.ID),
+		zap.Int64("owner_id", ownerID),
+		zap.String("currency", currency),
+		zap.String("contribution_rate", rate.String()),
+		zap.Int("trigger_odds", req.TriggerOdds))
+	return jackpot, nil
+}
+
+// List 分页获取累进奖池
+func (s *JackpotService) List(ctx context.Context, query *model.JackpotListQuery) ([]*model.Jackpot, int64, error) {
+	return s.jackpotRepo.List(ctx, query)
+}
+
+// ListHits 分页获取累进奖池中奖记录（ownerID 非空时只能查看自己的奖池）
+func (s *JackpotService) ListHits(ctx context.Context, jackpotID int64, ownerID *int64, page, pageSize int) ([]*model.JackpotHit, int64, error) {
+	jackpot, err := s.jackpotRepo.GetByID(ctx, jackpotID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, 0, ErrJackpotNotFound
+		}
+		return nil, 0, err
+	}
+	if ownerID != nil && jackpot.OwnerID != *ownerID {
+		return nil, 0, ErrJackpotForbidden
+	}
+	return s.jackpotRepo.ListHits(ctx, jackpotID, page, pageSize)
+}
+
+// ActiveJackpot 房间当前生效的累进奖池（实现 game.JackpotPool），未开启时返回 nil
+func (s *JackpotService) ActiveJackpot(ctx context.Context, room *model.Room) (*model.Jackpot, error) {
+	if room.UsesChips() {
+		return nil, nil
+	}
+	jackpot, err := s.jackpotRepo.FindActiveForRoom(ctx, room.ID, room.OwnerID, room.Currency)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, nil
+	}
+	return jackpot, err
+}
+
+// SettleRoundTx 在结算事务内注入本回合金额，触发时将奖池余额平分给赢家（实现 game.JackpotPool）
+// 派发金额按分向下取整，零头留在奖池；返回的 Payouts 由结算流程计入赢家余额
+func (s *JackpotService) SettleRoundTx(ctx context.Context, tx pgx.Tx, jackpotID, roomID, roundID int64, amount decimal.Decimal, winners []int64, triggered bool) (*model.JackpotSettlement, error) {
+	jackpot, err := s.jackpotRepo.GetForUpdateTx(ctx, tx, jackpotID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &model.JackpotSettlement{JackpotID: jackpotID, Contribution: amount}
+	if triggered {
+		perWinner, paid := splitJackpot(jackpot.Balance.Add(amount), len(winners))
+		if paid.IsPositive() {
+			result.Hit = true
+			result.PrizePerWinner = perWinner
+			result.Paid = paid
+			result.Payouts = make(map[int64]decimal.Decimal, len(winners))
+			for _, winnerID := range winners {
+				result.Payouts[winnerID] = perWinner
+			}
+			if err := s.jackpotRepo.CreateHitTx(ctx, tx, &model.JackpotHit{
+				JackpotID:      jackpotID,
+				RoomID:         roomID,
+				RoundID:        roundID,
+				WinnerIDs:      winners,
+				PrizePerWinner: perWinner,
+				Amount:         paid,
+			}); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	result.Balance, err = s.jackpotRepo.SettleTx(ctx, tx, jackpotID, amount, result.Paid, result.Hit)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// validateJackpotSettings 校验抽取比例与触发概率（minOdds 未配置时至少为 1）
+func validateJackpotSettings(rate decimal.Decimal, odds int, maxRate decimal.Decimal, minOdds int) error {
+	if rate.IsNegative() || rate.GreaterThan(maxRate) || !rate.Equal(rate.Round(4)) {
+		return ErrJackpotInvalidRate
+	}
+	if minOdds < 1 {
+		minOdds = 1
+	}
+	if odds < minOdds {
+		return ErrJackpotInvalidOdds
+	}
+	return nil
+}
+
+// splitJackpot 将奖池余额平分给赢家，返回（每人金额, 派发总额），每人金额按分向下取整
+func splitJackpot(balance decimal.Decimal, winners int) (decimal.Decimal, decimal.Decimal) {
+	if winners <= 0 || !balance.IsPositive() {
+		return decimal.Zero, decimal.Zero
+	}
+	n := decimal.NewFromInt(int64(winners))
+	perWinner := balance.Div(n).RoundFloor(2)
+	return perWinner, perWinner.Mul(n)
+}
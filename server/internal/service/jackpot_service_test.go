@@ -0,0 +1,86 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/fiveseconds/server/internal/model"
+	"github.com/shopspring/decimal"
+)
+
+// TestSplitJackpot 测试累进奖池平分给赢家：每人按分向下取整，派发总额不超过奖池余额
+func TestSplitJackpot(t *testing.T) {
+	tests := []struct {
+		name      string
+		balance   string
+		winners   int
+		perWinner string
+		paid      string
+	}{
+		{"even split", "90", 3, "30", "90"},
+		{"cents left in the pool", "100", 3, "33.33", "99.99"},
+		{"single winner takes all", "12.34", 1, "12.34", "12.34"},
+		{"less than a cent each", "0.02", 3, "0", "0"},
+		{"empty pool", "0", 3, "0", "0"},
+		{"no winners", "100", 0, "0", "0"},
+	}
+	for _, tt := range tests {
+		perWinner, paid := splitJackpot(decimal.RequireFromString(tt.balance), tt.winners)
+		if perWinner.String() != tt.perWinner || paid.String() != tt.paid {
+			t.Errorf("%s: Expected (%s, %s), got (%s, %s)", tt.name, tt.perWinner, tt.paid, perWinner, paid)
+		}
+	}
+}
+
+// TestJackpotContribution 测试每回合抽取金额：按奖池比例四舍五入到分，不超过平分前的奖金池
+func TestJackpotContribution(t *testing.T) {
+	tests := []struct {
+		name      string
+		rate      string
+		pool      string
+		prizePool string
+		want      string
+	}{
+		{"rate of the pool", "0.01", "100", "95", "1"},
+		{"rounded to cents", "0.0125", "30", "28.5", "0.38"},
+		{"capped at the prize pool", "0.5", "100", "40", "40"},
+		{"zero rate", "0", "100", "95", "0"},
+		{"negative prize pool", "0.01", "100", "-1", "0"},
+	}
+	for _, tt := range tests {
+		jackpot := &model.Jackpot{ContributionRate: decimal.RequireFromString(tt.rate)}
+		got := jackpot.Contribution(decimal.RequireFromString(tt.pool), decimal.RequireFromString(tt.prizePool))
+		if got.String() != tt.want {
+			t.Errorf("%s: Expected %s, got %s", tt.name, tt.want, got)
+		}
+	}
+}
+
+// TestValidateJackpotSettings 测试抽取比例须在 [0, 上限] 内且最多四位小数，触发概率不低于下限（未配置时为 1）
+func TestValidateJackpotSettings(t *testing.T) {
+	maxRate := decimal.RequireFromString("0.05")
+	tests := []struct {
+		name    string
+		rate    string
+		odds    int
+		minOdds int
+		wantErr error
+	}{
+		{"within range", "0.01", 1000, 100, nil},
+		{"zero rate", "0", 100, 100, nil},
+		{"maximum rate", "0.05", 100, 100, nil},
+		{"four decimals", "0.0125", 100, 100, nil},
+		{"above maximum", "0.0501", 100, 100, ErrJackpotInvalidRate},
+		{"negative rate", "-0.01", 100, 100, ErrJackpotInvalidRate},
+		{"five decimals", "0.01251", 100, 100, ErrJackpotInvalidRate},
+		{"odds below minimum", "0.01", 99, 100, ErrJackpotInvalidOdds},
+		{"odds of 1 without a minimum", "0.01", 1, 0, nil},
+		{"zero odds without a minimum", "0.01", 0, 0, ErrJackpotInvalidOdds},
+	}
+	for _, tt := range tests {
+		err := validateJackpotSettings(decimal.RequireFromString(tt.rate), tt.odds, maxRate, tt.minOdds)
+		if !errors.Is(err, tt.wantErr) {
+			t.Errorf("%s: Expected %v, got %v", tt.name, tt.wantErr, err)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/fiveseconds/server/internal/config"
+	"github.com/fiveseconds/server/internal/model"
+	"github.com/fiveseconds/server/internal/repository"
+	"github.com/fiveseconds/server/pkg/geoip"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// DefaultNewDeviceWithdrawWindow 未配置时“新设备登录后即提现”检测的时间窗口
+	DefaultNewDeviceWithdrawWindow = time.Hour
+	// loginUserAgentMaxLen User-Agent 保存的最大长度
+	loginUserAgentMaxLen = 500
+	// loginUsernameMaxLen 失败登录尝试的用户名保存的最大长度
+	loginUsernameMaxLen = 50
+)
+
+// LoginHistoryService 登录历史
+// 记录每次登录尝试（含失败）的 IP、User-Agent、设备指纹与地区标签，
+// 并维护每个用户登录成功过的设备（有设备指纹时按指纹区分，否则按 User-Agent 区分）
+type LoginHistoryService struct {
+	repo   *repository.LoginEventRepo
+	geo    *geoip.DB
+	cfg    *config.Config
+	logger *zap.Logger
+}
+
+// NewLoginHistoryService 创建登录历史服务（geo 为空时不标注地区）
+func NewLoginHistoryService(repo *repository.LoginEventRepo, geo *geoip.DB, cfg *config.Config, logger *zap.Logger) *LoginHistoryService {
+	return &LoginHistoryService{
+		repo:   repo,
+		geo:    geo,
+		cfg:    cfg,
+		logger: logger.With(zap.String("service", "login_history")),
+	}
+}
+
+// newDeviceWithdrawWindow 新设备登录后多长时间内申请提现需要标记
+func (s *LoginHistoryService) newDeviceWithdrawWindow() time.Duration {
+	if s.cfg.LoginHistory.NewDeviceWithdrawMinutes > 0 {
+		return time.Duration(s.cfg.LoginHistory.NewDeviceWithdrawMinutes) * time.Minute
+	}
+	return DefaultNewDeviceWithdrawWindow
+}
+
+// RecordLogin 记录登录事件，补齐设备标识与地区标签；记录失败只写日志，不影响登录
+func (s *LoginHistoryService) RecordLogin(ctx context.Context, event *model.LoginEvent) {
+	if runes := []rune(event.Username); len(runes) > loginUsernameMaxLen {
+		event.Username = string(runes[:loginUsernameMaxLen])
+	}
+	if runes := []rune(event.UserAgent); len(runes) > loginUserAgentMaxLen {
+		event.UserAgent = string(runes[:loginUserAgentMaxLen])
+	}
+	event.DeviceKey = loginDeviceKey(event.DeviceFingerprint, event.UserAgent)
+	event.GeoLabel = s.geo.Lookup(event.IP)
+
+	if err := s.repo.Create(ctx, event); err != nil {
+		s.logger.Error("Failed to record login event",
+			zap.String("username", event.Username),
+			zap.Bool("success", event.Success),
+			zap.Error(err))
+	}
+}
+
+// ListByUser 分页获取用户的登录历史
+func (s *LoginHistoryService) ListByUser(ctx context.Context, userID int64, query *model.LoginEventListQuery) ([]*model.LoginEvent, int64, error) {
+	return s.repo.ListByUser(ctx, userID, query)
+}
+
+// ListKnownDevices 获取用户登录成功过的设备
+func (s *LoginHistoryService) ListKnownDevices(ctx context.Context, userID int64) ([]*model.KnownDevice, error) {
+	return s.repo.ListKnownDevices(ctx, userID)
+}
+
+// RecentNewDeviceLogin 用户在 at 之前最近一次登录成功若是新设备且在检测窗口内，返回该登录事件，否则返回 nil
+func (s *LoginHistoryService) RecentNewDeviceLogin(ctx context.Context, userID int64, at time.Time) (*model.LoginEvent, error) {
+	last, err := s.repo.GetLastSuccess(ctx, userID, at)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !isRecentNewDeviceLogin(last, at, s.newDeviceWithdrawWindow()) {
+		return nil, nil
+	}
+	return last, nil
+}
+
+// isRecentNewDeviceLogin 登录是否为新设备且距 at 不超过 window
+func isRecentNewDeviceLogin(login *model.LoginEvent, at time.Time, window time.Duration) bool {
+	if login == nil || !login.Success || !login.NewDevice || login.CreatedAt.After(at) {
+		return false
+	}
+	return at.Sub(login.CreatedAt) <= window
+}
+
+// loginDeviceKey 设备标识：有设备指纹时取指纹，否则取 User-Agent 摘要（两者均为空时为空串）
+func loginDeviceKey(fingerprint, userAgent string) string {
+	if fingerprint != "" {
+		return fingerprint
+	}
+	if userAgent == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(userAgent))
+	return "ua:" + hex.EncodeToString(sum[:16])
+}
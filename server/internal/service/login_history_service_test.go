@@ -0,0 +1,66 @@
+package service
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fiveseconds/server/internal/model"
+)
+
+// TestLoginDeviceKey 测试设备标识优先取设备指纹，否则取 User-Agent 摘要
+func TestLoginDeviceKey(t *testing.T) {
+	longUA := strings.Repeat("Mozilla/5.0 ", 100)
+	tests := []struct {
+		name        string
+		fingerprint string
+		userAgent   string
+		want        string
+	}{
+		{"fingerprint wins", "fp-123", "Mozilla/5.0", "fp-123"},
+		{"fingerprint without user agent", "fp-123", "", "fp-123"},
+		{"nothing to identify", "", "", ""},
+		{"user agent digest", "", "Mozilla/5.0", "ua:1066b48224bb188ceb955605f4fcff98"},
+	}
+	for _, tt := range tests {
+		if got := loginDeviceKey(tt.fingerprint, tt.userAgent); got != tt.want {
+			t.Errorf("%s: Expected %q, got %q", tt.name, tt.want, got)
+		}
+	}
+
+	key := loginDeviceKey("", longUA)
+	if !strings.HasPrefix(key, "ua:") || len(key) != 35 {
+		t.Errorf("Expected a bounded ua: digest, got %q", key)
+	}
+	if key != loginDeviceKey("", longUA) {
+		t.Error("Expected the same user agent to give the same key")
+	}
+	if key == loginDeviceKey("", longUA+" ") {
+		t.Error("Expected different user agents to give different keys")
+	}
+}
+
+// TestIsRecentNewDeviceLogin 测试新设备登录后提现检测窗口
+func TestIsRecentNewDeviceLogin(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	window := time.Hour
+	tests := []struct {
+		name  string
+		login *model.LoginEvent
+		want  bool
+	}{
+		{"no login", nil, false},
+		{"new device inside window", &model.LoginEvent{Success: true, NewDevice: true, CreatedAt: now.Add(-10 * time.Minute)}, true},
+		{"exactly at window edge", &model.LoginEvent{Success: true, NewDevice: true, CreatedAt: now.Add(-window)}, true},
+		{"just outside window", &model.LoginEvent{Success: true, NewDevice: true, CreatedAt: now.Add(-window - time.Second)}, false},
+		{"login at the same instant", &model.LoginEvent{Success: true, NewDevice: true, CreatedAt: now}, true},
+		{"login in the future", &model.LoginEvent{Success: true, NewDevice: true, CreatedAt: now.Add(time.Minute)}, false},
+		{"known device", &model.LoginEvent{Success: true, NewDevice: false, CreatedAt: now.Add(-time.Minute)}, false},
+		{"failed login", &model.LoginEvent{Success: false, NewDevice: true, CreatedAt: now.Add(-time.Minute)}, false},
+	}
+	for _, tt := range tests {
+		if got := isRecentNewDeviceLogin(tt.login, now, window); got != tt.want {
+			t.Errorf("%s: Expected %v, got %v", tt.name, tt.want, got)
+		}
+	}
+}
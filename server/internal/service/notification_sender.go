@@ -0,0 +1,292 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/smtp"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fiveseconds/server/internal/model"
+	"github.com/fiveseconds/server/pkg/httpclient"
+)
+
+// notificationSender 按渠道类型发送告警通知
+type notificationSender interface {
+	Send(ctx context.Context, ch *model.NotificationChannel, alert *model.Alert) error
+}
+
+// notificationSenders 各渠道类型的发送器
+func notificationSenders(timeout time.Duration) map[model.NotificationChannelType]notificationSender {
+	client := httpclient.New(timeout)
+	return map[model.NotificationChannelType]notificationSender{
+		model.NotificationChannelWebhook:  &webhookSender{client: client},
+		model.NotificationChannelSlack:    &slackSender{client: client},
+		model.NotificationChannelDingTalk: &dingTalkSender{client: client},
+		model.NotificationChannelEmail:    &emailSender{timeout: timeout},
+	}
+}
+
+// validateChannelConfig 校验渠道配置：webhook 类渠道须为 http(s) 地址，邮件渠道须有 SMTP 服务器、发件人与收件人；
+// 地址与请求头中不能残留无法还原的掩码
+func validateChannelConfig(channelType model.NotificationChannelType, cfg *model.NotificationChannelConfig) error {
+	switch channelType {
+	case model.NotificationChannelWebhook, model.NotificationChannelSlack, model.NotificationChannelDingTalk:
+		u, err := url.Parse(cfg.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("%w: url must be an http(s) address", ErrNotificationChannelInvalid)
+		}
+		if strings.Contains(cfg.URL, model.NotificationSecretMask) {
+			return fmt.Errorf("%w: url is masked, submit the full address to change it", ErrNotificationChannelInvalid)
+		}
+		for name, value := range cfg.Headers {
+			if value == model.NotificationSecretMask {
+				return fmt.Errorf("%w: header %q is masked and has no value to keep", ErrNotificationChannelInvalid, name)
+			}
+		}
+	case model.NotificationChannelEmail:
+		if cfg.SMTPHost == "" || cfg.SMTPPort <= 0 || cfg.SMTPPort > 65535 {
+			return fmt.Errorf("%w: smtp_host and smtp_port are required", ErrNotificationChannelInvalid)
+		}
+		if cfg.From == "" || len(cfg.To) == 0 {
+			return fmt.Errorf("%w: from and to are required", ErrNotificationChannelInvalid)
+		}
+		for _, addr := range append([]string{cfg.From}, cfg.To...) {
+			if !strings.Contains(addr, "@") || strings.ContainsAny(addr, "\r\n") {
+				return fmt.Errorf("%w: invalid email address %q", ErrNotificationChannelInvalid, addr)
+			}
+		}
+	default:
+		return fmt.Errorf("%w: unknown channel type %q", ErrNotificationChannelInvalid, channelType)
+	}
+	return nil
+}
+
+// alertText 告警的纯文本内容（用于聊天机器人与邮件）
+func alertText(alert *model.Alert) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "[%s] %s\n", strings.ToUpper(string(alert.Severity)), alert.Title)
+	fmt.Fprintf(&b, "类型: %s\n", alert.AlertType)
+	fmt.Fprintf(&b, "告警ID: %d\n", alert.ID)
+	fmt.Fprintf(&b, "时间: %s\n", alert.CreatedAt.Format(time.RFC3339))
+	if alert.Details != "" && alert.Details != "{}" {
+		fmt.Fprintf(&b, "详情: %s\n", alert.Details)
+	}
+	return b.String()
+}
+
+// postJSON 发送 JSON 请求，非 2xx 响应视为失败，返回响应内容
+// 请求错误中的地址带有访问令牌，隐藏后再返回（错误会写入投递记录）
+func postJSON(ctx context.Context, client *httpclient.Client, target string, body []byte, headers map[string]string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			urlErr.URL = model.MaskNotificationURL(urlErr.URL)
+		}
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return respBody, fmt.Errorf("unexpected status %d: %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+	return respBody, nil
+}
+
+// webhookSender 通用 webhook：POST 告警 JSON，配置了密钥时附带 HMAC-SHA256 签名
+type webhookSender struct {
+	client *httpclient.Client
+}
+
+// webhookSignatureHeader 通用 webhook 的签名请求头（请求体的 HMAC-SHA256 十六进制值）
+const webhookSignatureHeader = "X-Alert-Signature"
+
+func (s *webhookSender) Send(ctx context.Context, ch *model.NotificationChannel, alert *model.Alert) error {
+	body, err := json.Marshal(map[string]interface{}{
+		"event": "alert",
+		"alert": alert,
+	})
+	if err != nil {
+		return err
+	}
+	headers := make(map[string]string, len(ch.Config.Headers)+1)
+	for k, v := range ch.Config.Headers {
+		headers[k] = v
+	}
+	if ch.Config.Secret != "" {
+		mac := hmac.New(sha256.New, []byte(ch.Config.Secret))
+		mac.Write(body)
+		headers[webhookSignatureHeader] = hex.EncodeToString(mac.Sum(nil))
+	}
+	_, err = postJSON(ctx, s.client, ch.Config.URL, body, headers)
+	return err
+}
+
+// slackSender Slack 兼容的 incoming webhook
+type slackSender struct {
+	client *httpclient.Client
+}
+
+func (s *slackSender) Send(ctx context.Context, ch *model.NotificationChannel, alert *model.Alert) error {
+	body, err := json.Marshal(map[string]string{"text": alertText(alert)})
+	if err != nil {
+		return err
+	}
+	_, err = postJSON(ctx, s.client, ch.Config.URL, body, nil)
+	return err
+}
+
+// dingTalkSender 钉钉机器人 webhook，配置了密钥时按加签方式附带 timestamp 与 sign 参数
+type dingTalkSender struct {
+	client *httpclient.Client
+}
+
+// dingTalkSign 钉钉加签：base64(HMAC-SHA256(secret, timestamp + "\n" + secret))
+func dingTalkSign(secret string, timestamp int64) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10) + "\n" + secret))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func (s *dingTalkSender) Send(ctx context.Context, ch *model.NotificationChannel, alert *model.Alert) error {
+	target := ch.Config.URL
+	if ch.Config.Secret != "" {
+		u, err := url.Parse(target)
+		if err != nil {
+			return err
+		}
+		ts := time.Now().UnixMilli()
+		q := u.Query()
+		q.Set("timestamp", strconv.FormatInt(ts, 10))
+		q.Set("sign", dingTalkSign(ch.Config.Secret, ts))
+		u.RawQuery = q.Encode()
+		target = u.String()
+	}
+
+	body, err := json.Marshal(map[string]interface{}{
+		"msgtype": "text",
+		"text":    map[string]string{"content": alertText(alert)},
+	})
+	if err != nil {
+		return err
+	}
+	respBody, err := postJSON(ctx, s.client, target, body, nil)
+	if err != nil {
+		return err
+	}
+	// 钉钉以 HTTP 200 + errcode 返回业务错误
+	var result struct {
+		ErrCode int    `json:"errcode"`
+		ErrMsg  string `json:"errmsg"`
+	}
+	if json.Unmarshal(respBody, &result) == nil && result.ErrCode != 0 {
+		return fmt.Errorf("dingtalk error %d: %s", result.ErrCode, result.ErrMsg)
+	}
+	return nil
+}
+
+// emailSender SMTP 邮件（服务器支持时使用 STARTTLS，配置了用户名时使用 PLAIN 认证）
+type emailSender struct {
+	timeout time.Duration
+}
+
+func (s *emailSender) Send(ctx context.Context, ch *model.NotificationChannel, alert *model.Alert) error {
+	cfg := ch.Config
+	subject := fmt.Sprintf("[%s] %s", strings.ToUpper(string(alert.Severity)), alert.Title)
+
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", cfg.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(cfg.To, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	msg.WriteString("Content-Transfer-Encoding: base64\r\n\r\n")
+	msg.WriteString(base64.StdEncoding.EncodeToString([]byte(alertText(alert))))
+	msg.WriteString("\r\n")
+
+	var auth smtp.Auth
+	if cfg.Username != "" {
+		auth = smtp.PlainAuth("", cfg.Username, cfg.Password, cfg.SMTPHost)
+	}
+	return s.sendMail(ctx, cfg, auth, msg.Bytes())
+}
+
+// sendMail 建立 SMTP 会话并发送邮件，整个会话受超时限制
+func (s *emailSender) sendMail(ctx context.Context, cfg model.NotificationChannelConfig, auth smtp.Auth, msg []byte) error {
+	dialer := &net.Dialer{Timeout: s.timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(cfg.SMTPHost, strconv.Itoa(cfg.SMTPPort)))
+	if err != nil {
+		return err
+	}
+	deadline := time.Now().Add(s.timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	if err := conn.SetDeadline(deadline); err != nil {
+		conn.Close()
+		return err
+	}
+
+	c, err := smtp.NewClient(conn, cfg.SMTPHost)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: cfg.SMTPHost}); err != nil {
+			return err
+		}
+	}
+	if auth != nil {
+		if err := c.Auth(auth); err != nil {
+			return err
+		}
+	}
+	if err := c.Mail(cfg.From); err != nil {
+		return err
+	}
+	for _, to := range cfg.To {
+		if err := c.Rcpt(to); err != nil {
+			return err
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
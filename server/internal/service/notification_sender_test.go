@@ -0,0 +1,117 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/fiveseconds/server/internal/model"
+)
+
+// TestValidateChannelConfig 测试渠道配置校验拒绝非 http(s) 地址、掩码取值与缺少收件人的邮件渠道
+func TestValidateChannelConfig(t *testing.T) {
+	email := func(to ...string) *model.NotificationChannelConfig {
+		return &model.NotificationChannelConfig{SMTPHost: "smtp.example.com", SMTPPort: 587, From: "alerts@example.com", To: to}
+	}
+	tests := []struct {
+		name        string
+		channelType model.NotificationChannelType
+		cfg         *model.NotificationChannelConfig
+		valid       bool
+	}{
+		{"https webhook", model.NotificationChannelWebhook, &model.NotificationChannelConfig{URL: "https://hooks.example.com/hook"}, true},
+		{"http dingtalk", model.NotificationChannelDingTalk, &model.NotificationChannelConfig{URL: "http://oapi.dingtalk.com/robot/send"}, true},
+		{"ftp url", model.NotificationChannelSlack, &model.NotificationChannelConfig{URL: "ftp://hooks.example.com/hook"}, false},
+		{"javascript url", model.NotificationChannelWebhook, &model.NotificationChannelConfig{URL: "javascript://hooks.example.com/hook"}, false},
+		{"missing host", model.NotificationChannelWebhook, &model.NotificationChannelConfig{URL: "https:///hook"}, false},
+		{"masked url", model.NotificationChannelWebhook, &model.NotificationChannelConfig{URL: "https://hooks.example.com/" + model.NotificationSecretMask}, false},
+		{"masked header", model.NotificationChannelWebhook, &model.NotificationChannelConfig{
+			URL: "https://hooks.example.com/hook", Headers: map[string]string{"X-Token": model.NotificationSecretMask},
+		}, false},
+		{"email with recipients", model.NotificationChannelEmail, email("oncall@example.com", "ops@example.com"), true},
+		{"email without recipients", model.NotificationChannelEmail, email(), false},
+		{"email header injection", model.NotificationChannelEmail, email("oncall@example.com\r\nBcc: x@example.com"), false},
+		{"email without smtp port", model.NotificationChannelEmail, &model.NotificationChannelConfig{SMTPHost: "smtp.example.com", From: "alerts@example.com", To: []string{"oncall@example.com"}}, false},
+		{"unknown channel type", "sms", &model.NotificationChannelConfig{URL: "https://hooks.example.com/hook"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateChannelConfig(tt.channelType, tt.cfg)
+			if tt.valid && err != nil {
+				t.Errorf("Expected valid config, got %v", err)
+			}
+			if !tt.valid && !errors.Is(err, ErrNotificationChannelInvalid) {
+				t.Errorf("Expected ErrNotificationChannelInvalid, got %v", err)
+			}
+		})
+	}
+}
+
+// TestNotificationChannelMasking 测试返回的渠道配置隐藏访问令牌、请求头取值与密钥，原样提交后还原为原配置
+func TestNotificationChannelMasking(t *testing.T) {
+	tests := []struct {
+		name   string
+		config model.NotificationChannelConfig
+	}{
+		{"dingtalk with secret", model.NotificationChannelConfig{
+			URL:    "https://oapi.dingtalk.com/robot/send?access_token=tok-123",
+			Secret: "SECtok-123",
+		}},
+		{"webhook with headers", model.NotificationChannelConfig{
+			URL:     "https://hooks.example.com/services/tok-456",
+			Headers: map[string]string{"Authorization": "Bearer tok-789"},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			masked := tt.config.Masked()
+			if strings.Contains(masked.URL, "tok-") || !strings.HasPrefix(masked.URL, "https://") {
+				t.Errorf("Expected the token hidden and the host kept, got %s", masked.URL)
+			}
+			if tt.config.Secret != "" && masked.Secret != model.NotificationSecretMask {
+				t.Errorf("Expected secret masked, got %s", masked.Secret)
+			}
+			for name, value := range masked.Headers {
+				if value != model.NotificationSecretMask || tt.config.Headers[name] == model.NotificationSecretMask {
+					t.Errorf("Expected header %s masked without changing the original, got %s", name, value)
+				}
+			}
+			if err := validateChannelConfig(model.NotificationChannelWebhook, &masked); err == nil {
+				t.Error("Expected the masked config to be rejected")
+			}
+
+			restored := masked.Unmask(tt.config)
+			if restored.URL != tt.config.URL || restored.Secret != tt.config.Secret {
+				t.Errorf("Expected %s / %s restored, got %s / %s", tt.config.URL, tt.config.Secret, restored.URL, restored.Secret)
+			}
+			for name, value := range tt.config.Headers {
+				if restored.Headers[name] != value {
+					t.Errorf("Expected header %s restored to %s, got %s", name, value, restored.Headers[name])
+				}
+			}
+			if err := validateChannelConfig(model.NotificationChannelWebhook, &restored); err != nil {
+				t.Errorf("Expected the restored config to be valid, got %v", err)
+			}
+		})
+	}
+}
+
+// TestNotificationChannelUnmaskChanges 测试修改的地址与请求头取值替换原配置，新增请求头提交掩码时校验拒绝
+func TestNotificationChannelUnmaskChanges(t *testing.T) {
+	original := model.NotificationChannelConfig{
+		URL:     "https://hooks.example.com/old",
+		Headers: map[string]string{"X-Token": "old"},
+	}
+	submitted := original.Masked()
+	submitted.URL = "https://hooks.example.com/new"
+	submitted.Headers["X-Token"] = "new"
+	submitted.Headers["X-New"] = model.NotificationSecretMask
+
+	restored := submitted.Unmask(original)
+	if restored.URL != submitted.URL || restored.Headers["X-Token"] != "new" {
+		t.Errorf("Expected the changed url and header kept, got %s and %s", restored.URL, restored.Headers["X-Token"])
+	}
+	if err := validateChannelConfig(model.NotificationChannelWebhook, &restored); !errors.Is(err, ErrNotificationChannelInvalid) {
+		t.Errorf("Expected the unrestorable masked header to be rejected, got %v", err)
+	}
+}
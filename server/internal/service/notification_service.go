@@ -0,0 +1,519 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/fiveseconds/server/internal/config"
+	"github.com/fiveseconds/server/internal/model"
+	"github.com/fiveseconds/server/internal/repository"
+
+	"go.uber.org/zap"
+)
+
+var (
+	ErrNotificationChannelNotFound  = errors.New("notification channel not found")
+	ErrNotificationChannelInvalid   = errors.New("invalid notification channel config")
+	ErrNotificationChannelNameTaken = errors.New("notification channel name already exists")
+	ErrNotificationRouteNotFound    = errors.New("notification route not found")
+	ErrAlertDeliveryNotFound        = errors.New("alert delivery not found")
+	ErrAlertDeliveryNotFailed       = errors.New("only failed deliveries can be retried")
+)
+
+const (
+	// notificationErrorMaxLen 投递记录中保存的错误信息最大长度
+	notificationErrorMaxLen = 500
+)
+
+// notificationParams 告警通知投递参数
+type notificationParams struct {
+	PollInterval time.Duration
+	BatchSize    int
+	Timeout      time.Duration
+	MaxAttempts  int
+	Backoff      time.Duration
+	MaxBackoff   time.Duration
+}
+
+// routeMatches 路由是否匹配告警：告警类型在路由类型列表中（列表为空表示全部），且严重程度不低于路由的最低严重程度
+func routeMatches(route *model.NotificationRoute, alert *model.Alert) bool {
+	if !route.Enabled || alert.Severity.Rank() < route.MinSeverity.Rank() {
+		return false
+	}
+	if len(route.AlertTypes) == 0 {
+		return true
+	}
+	for _, t := range route.AlertTypes {
+		if t == alert.AlertType {
+			return true
+		}
+	}
+	return false
+}
+
+// matchChannels 告警命中的渠道ID（去重、升序）
+func matchChannels(routes []*model.NotificationRoute, alert *model.Alert) []int64 {
+	seen := make(map[int64]bool)
+	var ids []int64
+	for _, rt := range routes {
+		if !seen[rt.ChannelID] && routeMatches(rt, alert) {
+			seen[rt.ChannelID] = true
+			ids = append(ids, rt.ChannelID)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+// deliveryBackoff 第 attempts 次发送失败后的重试等待时间：base * 2^(attempts-1)，不超过 max
+func deliveryBackoff(attempts int, base, max time.Duration) time.Duration {
+	if attempts < 1 {
+		attempts = 1
+	}
+	d := base
+	for i := 1; i < attempts; i++ {
+		if d >= max/2 {
+			return max
+		}
+		d *= 2
+	}
+	if d > max {
+		return max
+	}
+	return d
+}
+
+// truncateError 截断错误信息以便落库
+func truncateError(err error) string {
+	msg := []rune(err.Error())
+	if len(msg) > notificationErrorMaxLen {
+		msg = msg[:notificationErrorMaxLen]
+	}
+	return string(msg)
+}
+
+// NotificationService 告警通知
+// 告警创建后按路由规则（告警类型 + 最低严重程度）匹配数据库中配置的通知渠道，为每个渠道登记投递记录；
+// 后台任务发送到期的投递记录，失败后按指数退避重试，达到最大次数后标记为失败，可由管理员手动重试
+type NotificationService struct {
+	repo      *repository.NotificationRepo
+	alertRepo *repository.AlertRepo
+	senders   map[model.NotificationChannelType]notificationSender
+	cfg       *config.Config
+	logger    *zap.Logger
+
+	pending chan struct{} // 有新登记的投递记录时通知后台任务立即发送
+}
+
+// NewNotificationService 创建告警通知服务
+func NewNotificationService(repo *repository.NotificationRepo, alertRepo *repository.AlertRepo, cfg *config.Config, logger *zap.Logger) *NotificationService {
+	s := &NotificationService{
+		repo:      repo,
+		alertRepo: alertRepo,
+		cfg:       cfg,
+		logger:    logger.With(zap.String("service", "notification")),
+		pending:   make(chan struct{}, 1),
+	}
+	s.senders = notificationSenders(s.params().Timeout)
+	return s
+}
+
+// params 投递参数（未配置的项使用默认值）
+func (s *NotificationService) params() notificationParams {
+	c := s.cfg.Notification
+	p := notificationParams{
+		PollInterval: time.Duration(c.PollSeconds) * time.Second,
+		BatchSize:    c.BatchSize,
+		Timeout:      time.Duration(c.TimeoutSeconds) * time.Second,
+		MaxAttempts:  c.MaxAttempts,
+		Backoff:      time.Duration(c.BackoffSeconds) * time.Second,
+		MaxBackoff:   time.Duration(c.MaxBackoffSeconds) * time.Second,
+	}
+	if p.PollInterval <= 0 {
+		p.PollInterval = 10 * time.Second
+	}
+	if p.BatchSize <= 0 {
+		p.BatchSize = 50
+	}
+	if p.Timeout <= 0 {
+		p.Timeout = 10 * time.Second
+	}
+	if p.MaxAttempts <= 0 {
+		p.MaxAttempts = 8
+	}
+	if p.Backoff <= 0 {
+		p.Backoff = 30 * time.Second
+	}
+	if p.MaxBackoff <= 0 {
+		p.MaxBackoff = time.Hour
+	}
+	if p.MaxBackoff < p.Backoff {
+		p.MaxBackoff = p.Backoff
+	}
+	return p
+}
+
+// PollInterval 后台任务检查待投递记录的间隔
+func (s *NotificationService) PollInterval() time.Duration {
+	return s.params().PollInterval
+}
+
+// Pending 有新登记的投递记录时可读
+func (s *NotificationService) Pending() <-chan struct{} {
+	return s.pending
+}
+
+// Notify 按路由规则为告警登记投递记录（实现 AlertNotifier）
+func (s *NotificationService) Notify(ctx context.Context, alert *model.Alert) {
+	routes, err := s.repo.ListActiveRoutes(ctx)
+	if err != nil {
+		s.logger.Error("Failed to load notification routes", zap.Int64("alert_id", alert.ID), zap.Error(err))
+		return
+	}
+	channelIDs := matchChannels(routes, alert)
+	if len(channelIDs) == 0 {
+		return
+	}
+	n, err := s.repo.EnqueueDeliveries(ctx, alert.ID, channelIDs)
+	if err != nil {
+		s.logger.Error("Failed to enqueue alert deliveries", zap.Int64("alert_id", alert.ID), zap.Error(err))
+		return
+	}
+	if n > 0 {
+		select {
+		case s.pending <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// DeliverDue 发送到期的投递记录，返回本次处理的记录数
+func (s *NotificationService) DeliverDue(ctx context.Context, now time.Time) (int, error) {
+	p := s.params()
+	// 租约覆盖一次发送的超时并留出余量，期间其他实例不会领取同一记录
+	deliveries, err := s.repo.ClaimDueDeliveries(ctx, now, p.Timeout+time.Minute, p.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	var wg sync.WaitGroup
+	for _, d := range deliveries {
+		wg.Add(1)
+		go func(d *model.AlertDelivery) {
+			defer wg.Done()
+			s.deliver(ctx, d, p)
+		}(d)
+	}
+	wg.Wait()
+	return len(deliveries), nil
+}
+
+// deliver 发送一条投递记录并记录结果
+func (s *NotificationService) deliver(ctx context.Context, d *model.AlertDelivery, p notificationParams) {
+	attempts := d.Attempts + 1
+	err := s.send(ctx, d, p)
+	if err == nil {
+		if err := s.repo.MarkDeliverySent(ctx, d.ID, attempts); err != nil {
+			s.logger.Error("Failed to mark delivery sent", zap.Int64("delivery_id", d.ID), zap.Error(err))
+		}
+		return
+	}
+
+	logger := s.logger.With(
+		zap.Int64("delivery_id", d.ID),
+		zap.Int64("alert_id", d.AlertID),
+		zap.Int64("channel_id", d.ChannelID),
+		zap.Int("attempts", attempts),
+		zap.Error(err))
+	if attempts >= p.MaxAttempts {
+		logger.Error("Alert delivery failed permanently")
+		if err := s.repo.MarkDeliveryFailed(ctx, d.ID, attempts, truncateError(err)); err != nil {
+			s.logger.Error("Failed to mark delivery failed", zap.Int64("delivery_id", d.ID), zap.Error(err))
+		}
+		return
+	}
+	next := time.Now().Add(deliveryBackoff(attempts, p.Backoff, p.MaxBackoff))
+	logger.Warn("Alert delivery failed, will retry", zap.Time("next_attempt_at", next))
+	if err := s.repo.MarkDeliveryRetry(ctx, d.ID, attempts, truncateError(err), next); err != nil {
+		s.logger.Error("Failed to schedule delivery retry", zap.Int64("delivery_id", d.ID), zap.Error(err))
+	}
+}
+
+// send 加载告警与渠道并发送（渠道已停用时视为失败）
+func (s *NotificationService) send(ctx context.Context, d *model.AlertDelivery, p notificationParams) error {
+	alert, err := s.alertRepo.GetByID(ctx, d.AlertID)
+	if err != nil {
+		return err
+	}
+	ch, err := s.repo.GetChannel(ctx, d.ChannelID)
+	if err != nil {
+		return err
+	}
+	if !ch.Enabled {
+		return errors.New("channel is disabled")
+	}
+	return s.sendTo(ctx, ch, alert, p)
+}
+
+// sendTo 通过渠道发送告警
+func (s *NotificationService) sendTo(ctx context.Context, ch *model.NotificationChannel, alert *model.Alert, p notificationParams) error {
+	sender, ok := s.senders[ch.Type]
+	if !ok {
+		return ErrNotificationChannelInvalid
+	}
+	sendCtx, cancel := context.WithTimeout(ctx, p.Timeout)
+	defer cancel()
+	return sender.Send(sendCtx, ch, alert)
+}
+
+// mapNotificationError 仓库未找到错误转换为对应的业务错误
+func mapNotificationError(err, notFound error) error {
+	if errors.Is(err, repository.ErrNotFound) {
+		return notFound
+	}
+	return err
+}
+
+// ListChannels 通知渠道列表（密钥与密码已隐藏）
+func (s *NotificationService) ListChannels(ctx context.Context) ([]*model.NotificationChannel, error) {
+	channels, err := s.repo.ListChannels(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, ch := range channels {
+		ch.Config = ch.Config.Masked()
+	}
+	return channels, nil
+}
+
+// GetChannel 获取通知渠道（密钥与密码已隐藏）
+func (s *NotificationService) GetChannel(ctx context.Context, id int64) (*model.NotificationChannel, error) {
+	ch, err := s.repo.GetChannel(ctx, id)
+	if err != nil {
+		return nil, mapNotificationError(err, ErrNotificationChannelNotFound)
+	}
+	ch.Config = ch.Config.Masked()
+	return ch, nil
+}
+
+// CreateChannel 创建通知渠道
+func (s *NotificationService) CreateChannel(ctx context.Context, req *model.CreateNotificationChannelReq, operatorID int64) (*model.NotificationChannel, error) {
+	if err := validateChannelConfig(req.Type, &req.Config); err != nil {
+		return nil, err
+	}
+	exists, err := s.repo.ChannelNameExists(ctx, req.Name, 0)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, ErrNotificationChannelNameTaken
+	}
+
+	ch := &model.NotificationChannel{
+		Name:      req.Name,
+		Type:      req.Type,
+		Config:    req.Config,
+		Enabled:   req.Enabled == nil || *req.Enabled,
+		CreatedBy: &operatorID,
+	}
+	if err := s.repo.CreateChannel(ctx, ch); err != nil {
+		return nil, err
+	}
+	s.logger.Info("Notification channel created",
+		zap.Int64("channel_id", ch.ID),
+		zap.String("type", string(ch.Type)),
+		zap.Int64("operator_id", operatorID))
+	ch.Config = ch.Config.Masked()
+	return ch, nil
+}
+
+// UpdateChannel 更新通知渠道；配置中的地址、请求头、密钥或密码提交掩码时保留原值
+func (s *NotificationService) UpdateChannel(ctx context.Context, id int64, req *model.UpdateNotificationChannelReq, operatorID int64) (*model.NotificationChannel, error) {
+	ch, err := s.repo.GetChannel(ctx, id)
+	if err != nil {
+		return nil, mapNotificationError(err, ErrNotificationChannelNotFound)
+	}
+
+	if req.Name != nil && *req.Name != ch.Name {
+		exists, err := s.repo.ChannelNameExists(ctx, *req.Name, id)
+		if err != nil {
+			return nil, err
+		}
+		if exists {
+			return nil, ErrNotificationChannelNameTaken
+		}
+		ch.Name = *req.Name
+	}
+	if req.Config != nil {
+		cfg := req.Config.Unmask(ch.Config)
+		if err := validateChannelConfig(ch.Type, &cfg); err != nil {
+			return nil, err
+		}
+		ch.Config = cfg
+	}
+	if req.Enabled != nil {
+		ch.Enabled = *req.Enabled
+	}
+
+	if err := s.repo.UpdateChannel(ctx, ch); err != nil {
+		return nil, mapNotificationError(err, ErrNotificationChannelNotFound)
+	}
+	s.logger.Info("Notification channel updated",
+		zap.Int64("channel_id", ch.ID),
+		zap.Bool("enabled", ch.Enabled),
+		zap.Int64("operator_id", operatorID))
+	ch.Config = ch.Config.Masked()
+	return ch, nil
+}
+
+// TestChannel 通过渠道立即发送一条测试告警（不落库、不重试）
+func (s *NotificationService) TestChannel(ctx context.Context, id int64, operatorID int64) error {
+	ch, err := s.repo.GetChannel(ctx, id)
+	if err != nil {
+		return mapNotificationError(err, ErrNotificationChannelNotFound)
+	}
+	alert := &model.Alert{
+		AlertType: "test",
+		Severity:  model.AlertSeverityInfo,
+		Title:     "告警通知渠道测试: " + ch.Name,
+		Details:   "{}",
+		Status:    model.AlertStatusActive,
+		CreatedAt: time.Now(),
+	}
+	if err := s.sendTo(ctx, ch, alert, s.params()); err != nil {
+		s.logger.Warn("Notification channel test failed",
+			zap.Int64("channel_id", id), zap.Int64("operator_id", operatorID), zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// ListRoutes 通知路由列表（channelID 为 0 表示全部渠道）
+func (s *NotificationService) ListRoutes(ctx context.Context, channelID int64) ([]*model.NotificationRoute, error) {
+	return s.repo.ListRoutes(ctx, channelID)
+}
+
+// CreateRoute 创建通知路由
+func (s *NotificationService) CreateRoute(ctx context.Context, req *model.CreateNotificationRouteReq, operatorID int64) (*model.NotificationRoute, error) {
+	if _, err := s.repo.GetChannel(ctx, req.ChannelID); err != nil {
+		return nil, mapNotificationError(err, ErrNotificationChannelNotFound)
+	}
+	rt := &model.NotificationRoute{
+		ChannelID:   req.ChannelID,
+		AlertTypes:  req.AlertTypes,
+		MinSeverity: req.MinSeverity,
+		Enabled:     req.Enabled == nil || *req.Enabled,
+	}
+	if rt.AlertTypes == nil {
+		rt.AlertTypes = []model.AlertType{}
+	}
+	if err := s.repo.CreateRoute(ctx, rt); err != nil {
+		return nil, err
+	}
+	s.logger.Info("Notification route created",
+		zap.Int64("route_id", rt.ID),
+		zap.Int64("channel_id", rt.ChannelID),
+		zap.Int64("operator_id", operatorID))
+	return rt, nil
+}
+
+// UpdateRoute 更新通知路由
+func (s *NotificationService) UpdateRoute(ctx context.Context, id int64, req *model.UpdateNotificationRouteReq, operatorID int64) (*model.NotificationRoute, error) {
+	rt, err := s.repo.GetRoute(ctx, id)
+	if err != nil {
+		return nil, mapNotificationError(err, ErrNotificationRouteNotFound)
+	}
+	if req.AlertTypes != nil {
+		rt.AlertTypes = *req.AlertTypes
+		if rt.AlertTypes == nil {
+			rt.AlertTypes = []model.AlertType{}
+		}
+	}
+	if req.MinSeverity != nil {
+		rt.MinSeverity = *req.MinSeverity
+	}
+	if req.Enabled != nil {
+		rt.Enabled = *req.Enabled
+	}
+	if err := s.repo.UpdateRoute(ctx, rt); err != nil {
+		return nil, mapNotificationError(err, ErrNotificationRouteNotFound)
+	}
+	s.logger.Info("Notification route updated", zap.Int64("route_id", id), zap.Int64("operator_id", operatorID))
+	return rt, nil
+}
+
+// DeleteRoute 删除通知路由
+func (s *NotificationService) DeleteRoute(ctx context.Context, id int64, operatorID int64) error {
+	if err := s.repo.DeleteRoute(ctx, id); err != nil {
+		return mapNotificationError(err, ErrNotificationRouteNotFound)
+	}
+	s.logger.Info("Notification route deleted", zap.Int64("route_id", id), zap.Int64("operator_id", operatorID))
+	return nil
+}
+
+// ListDeliveries 查询告警投递记录
+func (s *NotificationService) ListDeliveries(ctx context.Context, query *model.AlertDeliveryQuery) ([]*model.AlertDelivery, int64, error) {
+	return s.repo.ListDeliveries(ctx, query)
+}
+
+// RetryDelivery 将失败的投递重新置为待发送
+func (s *NotificationService) RetryDelivery(ctx context.Context, id int64, operatorID int64) (*model.AlertDelivery, error) {
+	ok, err := s.repo.RequeueDelivery(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		if _, err := s.repo.GetDelivery(ctx, id); err != nil {
+			return nil, mapNotificationError(err, ErrAlertDeliveryNotFound)
+		}
+		return nil, ErrAlertDeliveryNotFailed
+	}
+	s.logger.Info("Alert delivery requeued", zap.Int64("delivery_id", id), zap.Int64("operator_id", operatorID))
+	select {
+	case s.pending <- struct{}{}:
+	default:
+	}
+	d, err := s.repo.GetDelivery(ctx, id)
+	if err != nil {
+		return nil, mapNotificationError(err, ErrAlertDeliveryNotFound)
+	}
+	return d, nil
+}
+
+// AdminNotifier 向在线管理员私发 WebSocket 消息
+type AdminNotifier interface {
+	SendToUser(userID int64, msg *model.WSMessage)
+}
+
+// AdminAlertBroadcaster 通过 WebSocket 向在线管理员推送告警（实现 AlertBroadcaster）
+type AdminAlertBroadcaster struct {
+	notifier AdminNotifier
+	userRepo *repository.UserRepo
+	logger   *zap.Logger
+}
+
+// NewAdminAlertBroadcaster 创建管理员告警广播器
+func NewAdminAlertBroadcaster(notifier AdminNotifier, userRepo *repository.UserRepo, logger *zap.Logger) *AdminAlertBroadcaster {
+	return &AdminAlertBroadcaster{
+		notifier: notifier,
+		userRepo: userRepo,
+		logger:   logger.With(zap.String("service", "admin_alert_broadcaster")),
+	}
+}
+
+// BroadcastToAdmins 向全部管理员私发消息（未在线的管理员由 Hub 忽略）
+func (b *AdminAlertBroadcaster) BroadcastToAdmins(msg *model.WSMessage) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	ids, err := b.userRepo.ListIDsByRole(ctx, model.RoleAdmin)
+	if err != nil {
+		b.logger.Error("Failed to list admins for alert broadcast", zap.Error(err))
+		return
+	}
+	for _, id := range ids {
+		b.notifier.SendToUser(id, msg)
+	}
+}
@@ -0,0 +1,96 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/fiveseconds/server/internal/model"
+)
+
+// TestRouteMatches 测试路由仅在启用、告警类型匹配（或未限定类型）且严重程度不低于最低严重程度时命中
+func TestRouteMatches(t *testing.T) {
+	alert := &model.Alert{AlertType: model.AlertTypeLargeTransaction, Severity: model.AlertSeverityWarning}
+	tests := []struct {
+		name  string
+		route *model.NotificationRoute
+		want  bool
+	}{
+		{"any type at a lower minimum", &model.NotificationRoute{Enabled: true, MinSeverity: model.AlertSeverityInfo}, true},
+		{"any type at the same minimum", &model.NotificationRoute{Enabled: true, MinSeverity: model.AlertSeverityWarning}, true},
+		{"severity below the minimum", &model.NotificationRoute{Enabled: true, MinSeverity: model.AlertSeverityCritical}, false},
+		{"disabled", &model.NotificationRoute{Enabled: false, MinSeverity: model.AlertSeverityInfo}, false},
+		{"listed type", &model.NotificationRoute{
+			Enabled: true, MinSeverity: model.AlertSeverityInfo,
+			AlertTypes: []model.AlertType{model.AlertTypeSettlementFailed, model.AlertTypeLargeTransaction},
+		}, true},
+		{"type not listed", &model.NotificationRoute{
+			Enabled: true, MinSeverity: model.AlertSeverityInfo,
+			AlertTypes: []model.AlertType{model.AlertTypeSettlementFailed, model.AlertTypeRiskScoreHigh},
+		}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := routeMatches(tt.route, alert); got != tt.want {
+				t.Errorf("Expected match=%v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+// TestMatchChannels 测试命中的渠道去重且升序
+func TestMatchChannels(t *testing.T) {
+	route := func(channelID int64, enabled bool, minSeverity model.AlertSeverity, types ...model.AlertType) *model.NotificationRoute {
+		return &model.NotificationRoute{ChannelID: channelID, Enabled: enabled, MinSeverity: minSeverity, AlertTypes: types}
+	}
+	routes := []*model.NotificationRoute{
+		route(3, true, model.AlertSeverityInfo),
+		route(1, true, model.AlertSeverityCritical),
+		route(2, true, model.AlertSeverityInfo, model.AlertTypeConservationFailed),
+		route(3, true, model.AlertSeverityWarning, model.AlertTypeConservationFailed),
+		route(4, false, model.AlertSeverityInfo),
+		route(1, true, model.AlertSeverityWarning, model.AlertTypeSettlementFailed),
+	}
+	tests := []struct {
+		name   string
+		routes []*model.NotificationRoute
+		alert  *model.Alert
+		want   []int64
+	}{
+		{"critical conservation failure", routes, &model.Alert{AlertType: model.AlertTypeConservationFailed, Severity: model.AlertSeverityCritical}, []int64{1, 2, 3}},
+		{"warning settlement failure", routes, &model.Alert{AlertType: model.AlertTypeSettlementFailed, Severity: model.AlertSeverityWarning}, []int64{1, 3}},
+		{"info large transaction", routes, &model.Alert{AlertType: model.AlertTypeLargeTransaction, Severity: model.AlertSeverityInfo}, []int64{3}},
+		{"no routes", nil, &model.Alert{AlertType: model.AlertTypeLargeTransaction, Severity: model.AlertSeverityInfo}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := matchChannels(tt.routes, tt.alert); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Expected channels %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+// TestDeliveryBackoff 测试重试等待时间从初始值开始逐次翻倍且不超过上限
+func TestDeliveryBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int
+		base     time.Duration
+		max      time.Duration
+		want     time.Duration
+	}{
+		{0, 30 * time.Second, time.Hour, 30 * time.Second},
+		{1, 30 * time.Second, time.Hour, 30 * time.Second},
+		{2, 30 * time.Second, time.Hour, time.Minute},
+		{4, 30 * time.Second, time.Hour, 4 * time.Minute},
+		{7, 30 * time.Second, time.Hour, 32 * time.Minute},
+		{8, 30 * time.Second, time.Hour, time.Hour},
+		{1000, 30 * time.Second, time.Hour, time.Hour},
+		{1, 2 * time.Hour, time.Hour, time.Hour},
+	}
+	for _, tt := range tests {
+		if got := deliveryBackoff(tt.attempts, tt.base, tt.max); got != tt.want {
+			t.Errorf("Expected backoff %s after %d attempts (base %s, max %s), got %s", tt.want, tt.attempts, tt.base, tt.max, got)
+		}
+	}
+}
@@ -0,0 +1,301 @@
+package service
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/fiveseconds/server/internal/config"
+	"github.com/fiveseconds/server/internal/model"
+	"github.com/fiveseconds/server/internal/repository"
+
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+// ownerRiskSeverities 房主维度标记的严重程度
+var ownerRiskSeverities = map[model.RiskFlagType]model.RiskSeverity{
+	model.RiskFlagOwnerCommission:      model.RiskSeverityHigh,
+	model.RiskFlagOwnerDepositWithdraw: model.RiskSeverityHigh,
+	model.RiskFlagOwnerMarginDrawdown:  model.RiskSeverityMedium,
+	model.RiskFlagOwnerPlayerWinRate:   model.RiskSeverityHigh,
+	model.RiskFlagOwnerConservation:    model.RiskSeverityCritical,
+}
+
+// ownerRiskParams 房主维度检测阈值
+type ownerRiskParams struct {
+	LookbackDays         int
+	CommissionMinAmount  decimal.Decimal
+	MaxCommissionRate    decimal.Decimal
+	CommissionSpikeRatio decimal.Decimal
+	DepositWindow        time.Duration
+	MinDeposits          int
+	MarginDrawdownRatio  float64
+	WinRateMinRounds     int
+	WinRateZScore        float64
+	SwingAmount          decimal.Decimal
+}
+
+// commissionBaselineRate 历史窗口内的平均抽成比例（抽成收入合计 / 房间流水合计），无流水时为 0
+func commissionBaselineRate(history []*model.OwnerRiskDay) decimal.Decimal {
+	volume, commission := decimal.Zero, decimal.Zero
+	for _, d := range history {
+		volume = volume.Add(d.RoomVolume)
+		commission = commission.Add(d.Commission)
+	}
+	if !volume.IsPositive() {
+		return decimal.Zero
+	}
+	return commission.Div(volume)
+}
+
+// commissionAnomalous 当日抽成收入是否与房间流水不符：收入达到下限时，无流水、抽成比例超过上限，
+// 或抽成比例达到历史基线的倍数均视为异常
+func commissionAnomalous(volume, commission, baseline decimal.Decimal, p ownerRiskParams) bool {
+	if commission.LessThan(p.CommissionMinAmount) || !commission.IsPositive() {
+		return false
+	}
+	if !volume.IsPositive() {
+		return true
+	}
+	rate := commission.Div(volume)
+	if rate.GreaterThan(p.MaxCommissionRate) {
+		return true
+	}
+	return baseline.IsPositive() && rate.GreaterThanOrEqual(baseline.Mul(p.CommissionSpikeRatio))
+}
+
+// marginDrawdown 当前保证金较历史峰值（含当前）的回撤比例
+func marginDrawdown(current decimal.Decimal, history []*model.OwnerRiskDay) (decimal.Decimal, float64) {
+	peak := current
+	for _, d := range history {
+		if d.MarginBalance.GreaterThan(peak) {
+			peak = d.MarginBalance
+		}
+	}
+	if !peak.IsPositive() {
+		return peak, 0
+	}
+	drawdown, _ := peak.Sub(current).Div(peak).Float64()
+	return peak, drawdown
+}
+
+// ownerWinZScore 玩家整体获胜数偏离期望的标准差倍数（方差为 0 时为 0）
+func ownerWinZScore(d *model.OwnerRiskDay) float64 {
+	if d.WinVariance <= 0 {
+		return 0
+	}
+	return (float64(d.PlayerWins) - d.ExpectedWins) / math.Sqrt(d.WinVariance)
+}
+
+// conservationSwing 资金守恒差额较上一日的变化是否达到阈值
+func conservationSwing(current, prev, amount decimal.Decimal) bool {
+	return amount.IsPositive() && current.Sub(prev).Abs().GreaterThanOrEqual(amount)
+}
+
+// detectOwnerRisk 对比房主当日指标与历史（按日期倒序）检测异常；history 为空时只检测不依赖基线的信号
+func detectOwnerRisk(day *model.OwnerRiskDay, history []*model.OwnerRiskDay, p ownerRiskParams) []*model.OwnerRiskFinding {
+	var findings []*model.OwnerRiskFinding
+	add := func(flagType model.RiskFlagType, signal *model.OwnerRiskSignal) {
+		signal.Day = day.Day.Format(statementDateLayout)
+		signal.Currency = day.Currency
+		findings = append(findings, &model.OwnerRiskFinding{
+			OwnerID:  day.OwnerID,
+			FlagType: flagType,
+			Severity: ownerRiskSeverities[flagType],
+			Signal:   signal,
+		})
+	}
+
+	baseline := commissionBaselineRate(history)
+	if commissionAnomalous(day.RoomVolume, day.Commission, baseline, p) {
+		volume, commission := day.RoomVolume, day.Commission
+		signal := &model.OwnerRiskSignal{RoomVolume: &volume, Commission: &commission}
+		if volume.IsPositive() {
+			rate := commission.Div(volume)
+			signal.CommissionRate = &rate
+		}
+		if baseline.IsPositive() {
+			signal.BaselineRate = &baseline
+		}
+		add(model.RiskFlagOwnerCommission, signal)
+	}
+
+	if peak, drawdown := marginDrawdown(day.MarginBalance, history); p.MarginDrawdownRatio > 0 && drawdown >= p.MarginDrawdownRatio {
+		margin := day.MarginBalance
+		add(model.RiskFlagOwnerMarginDrawdown, &model.OwnerRiskSignal{MarginBalance: &margin, MarginPeak: &peak, Drawdown: drawdown})
+	}
+
+	if day.PlayerRounds >= p.WinRateMinRounds {
+		if z := ownerWinZScore(day); math.Abs(z) >= p.WinRateZScore {
+			add(model.RiskFlagOwnerPlayerWinRate, &model.OwnerRiskSignal{
+				PlayerRounds: day.PlayerRounds,
+				PlayerWins:   day.PlayerWins,
+				ExpectedWins: day.ExpectedWins,
+				WinZScore:    z,
+			})
+		}
+	}
+
+	if len(history) > 0 {
+		prev := history[0].ConservationDifference
+		if conservationSwing(day.ConservationDifference, prev, p.SwingAmount) {
+			diff := day.ConservationDifference
+			add(model.RiskFlagOwnerConservation, &model.OwnerRiskSignal{Difference: &diff, PrevDifference: &prev})
+		}
+	}
+	return findings
+}
+
+// depositBurstBeforeWithdraw 房主提现前窗口内审批的玩家充值笔数是否达到阈值
+func depositBurstBeforeWithdraw(w *model.OwnerWithdrawDeposits, p ownerRiskParams) bool {
+	return p.MinDeposits > 0 && w.Deposits >= p.MinDeposits
+}
+
+// OwnerRiskService 房主维度风控分析
+// 每日房主对账后汇总房主当日的房间流水、抽成收入、保证金、资金守恒差额与名下房间玩家获胜统计并落库，
+// 与历史记录对比检测：抽成收入与流水不符、保证金回撤、玩家整体胜率偏离期望、守恒差额大幅波动；
+// 并检测房主提现前短时间内集中审批玩家充值。检测结果由 RiskControlService 创建房主维度风控标记
+type OwnerRiskService struct {
+	repo         *repository.OwnerRiskRepo
+	platformRepo *repository.PlatformRepo
+	cfg          *config.Config
+	logger       *zap.Logger
+}
+
+// NewOwnerRiskService 创建房主维度风控分析服务
+func NewOwnerRiskService(repo *repository.OwnerRiskRepo, platformRepo *repository.PlatformRepo, cfg *config.Config, logger *zap.Logger) *OwnerRiskService {
+	return &OwnerRiskService{
+		repo:         repo,
+		platformRepo: platformRepo,
+		cfg:          cfg,
+		logger:       logger.With(zap.String("service", "owner_risk")),
+	}
+}
+
+// params 检测阈值（未配置的项使用默认值）
+func (s *OwnerRiskService) params() ownerRiskParams {
+	c := s.cfg.OwnerRisk
+	p := ownerRiskParams{
+		LookbackDays:         c.LookbackDays,
+		CommissionMinAmount:  decimal.NewFromFloat(c.CommissionMinAmount),
+		MaxCommissionRate:    decimal.NewFromFloat(c.MaxCommissionRate),
+		CommissionSpikeRatio: decimal.NewFromFloat(c.CommissionSpikeRatio),
+		DepositWindow:        time.Duration(c.DepositWindowMinutes) * time.Minute,
+		MinDeposits:          c.MinDepositsBeforeWithdraw,
+		MarginDrawdownRatio:  c.MarginDrawdownRatio,
+		WinRateMinRounds:     c.WinRateMinRounds,
+		WinRateZScore:        c.WinRateZScore,
+		SwingAmount:          decimal.NewFromFloat(c.ConservationSwingAmount),
+	}
+	if p.LookbackDays <= 0 {
+		p.LookbackDays = 7
+	}
+	if !p.CommissionMinAmount.IsPositive() {
+		p.CommissionMinAmount = decimal.NewFromInt(100)
+	}
+	if !p.MaxCommissionRate.IsPositive() {
+		p.MaxCommissionRate = decimal.NewFromFloat(s.cfg.Game.MaxTotalCommission)
+		if !p.MaxCommissionRate.IsPositive() {
+			p.MaxCommissionRate = decimal.NewFromFloat(0.2)
+		}
+	}
+	if p.CommissionSpikeRatio.LessThanOrEqual(decimal.NewFromInt(1)) {
+		p.CommissionSpikeRatio = decimal.NewFromInt(2)
+	}
+	if p.DepositWindow <= 0 {
+		p.DepositWindow = 2 * time.Hour
+	}
+	if p.MinDeposits <= 0 {
+		p.MinDeposits = 5
+	}
+	if p.MarginDrawdownRatio <= 0 {
+		p.MarginDrawdownRatio = 0.3
+	}
+	if p.WinRateMinRounds <= 0 {
+		p.WinRateMinRounds = 200
+	}
+	if p.WinRateZScore <= 0 {
+		p.WinRateZScore = 4
+	}
+	if !p.SwingAmount.IsPositive() {
+		p.SwingAmount = decimal.NewFromInt(1000)
+	}
+	return p
+}
+
+// record 补充房主当日的资金守恒差额并落库，返回是否新写入及此前窗口内的历史指标
+func (s *OwnerRiskService) record(ctx context.Context, day *model.OwnerRiskDay, p ownerRiskParams) (bool, []*model.OwnerRiskDay, error) {
+	check, err := s.platformRepo.CheckConservationByOwner(ctx, day.OwnerID)
+	if err != nil {
+		return false, nil, err
+	}
+	day.ConservationDifference = check.Difference
+
+	history, err := s.repo.ListHistory(ctx, day.OwnerID, day.Day.AddDate(0, 0, -p.LookbackDays), day.Day)
+	if err != nil {
+		return false, nil, err
+	}
+	inserted, err := s.repo.Insert(ctx, day)
+	return inserted, history, err
+}
+
+// Analyze 汇总房主 [dayStart, dayEnd) 的指标并检测异常；当日已分析过的房主跳过（每日对账可能被多个任务触发）
+func (s *OwnerRiskService) Analyze(ctx context.Context, dayStart, dayEnd time.Time, summaries []*model.OwnerSnapshotSummary) ([]*model.OwnerRiskFinding, error) {
+	p := s.params()
+	date := snapshotDate(dayStart)
+
+	collected, err := s.repo.CollectDay(ctx, dayStart, dayEnd)
+	if err != nil {
+		return nil, err
+	}
+
+	var findings []*model.OwnerRiskFinding
+	analyzed := make(map[int64]bool, len(summaries))
+	for _, sum := range summaries {
+		day, ok := collected[sum.OwnerID]
+		if !ok {
+			day = &model.OwnerRiskDay{OwnerID: sum.OwnerID}
+		}
+		day.Day = date
+		day.Currency = sum.Currency
+		day.MarginBalance = sum.MarginBalance
+
+		inserted, history, err := s.record(ctx, day, p)
+		if err != nil {
+			s.logger.Error("Failed to record owner risk day", zap.Int64("owner_id", sum.OwnerID), zap.Error(err))
+			continue
+		}
+		if !inserted {
+			continue
+		}
+		analyzed[sum.OwnerID] = true
+		findings = append(findings, detectOwnerRisk(day, history, p)...)
+	}
+
+	withdrawals, err := s.repo.ListWithdrawDeposits(ctx, dayStart, dayEnd, p.DepositWindow)
+	if err != nil {
+		return findings, err
+	}
+	for _, w := range withdrawals {
+		if !analyzed[w.OwnerID] || !depositBurstBeforeWithdraw(w, p) {
+			continue
+		}
+		findings = append(findings, &model.OwnerRiskFinding{
+			OwnerID:  w.OwnerID,
+			FlagType: model.RiskFlagOwnerDepositWithdraw,
+			Severity: ownerRiskSeverities[model.RiskFlagOwnerDepositWithdraw],
+			Signal: &model.OwnerRiskSignal{
+				Day:      date.Format(statementDateLayout),
+				Currency: w.Currency,
+				Withdraw: w,
+			},
+		})
+	}
+
+	s.logger.Info("Owner risk analyzed",
+		zap.String("day", date.Format(statementDateLayout)),
+		zap.Int("owners", len(analyzed)),
+		zap.Int("findings", len(findings)))
+	return findings, nil
+}
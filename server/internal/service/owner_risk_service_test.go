@@ -0,0 +1,214 @@
+package service
+
+import (
+	"math"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/fiveseconds/server/internal/model"
+
+	"github.com/shopspring/decimal"
+)
+
+// testOwnerRiskParams 测试用房主维度检测阈值（与默认值一致）
+var testOwnerRiskParams = ownerRiskParams{
+	LookbackDays:         7,
+	CommissionMinAmount:  decimal.NewFromInt(100),
+	MaxCommissionRate:    decimal.NewFromFloat(0.2),
+	CommissionSpikeRatio: decimal.NewFromInt(2),
+	DepositWindow:        2 * time.Hour,
+	MinDeposits:          5,
+	MarginDrawdownRatio:  0.3,
+	WinRateMinRounds:     200,
+	WinRateZScore:        4,
+	SwingAmount:          decimal.NewFromInt(1000),
+}
+
+// TestCommissionBaselineRate 测试历史平均抽成比例为抽成合计除以流水合计
+func TestCommissionBaselineRate(t *testing.T) {
+	day := func(volume, commission int64) *model.OwnerRiskDay {
+		return &model.OwnerRiskDay{RoomVolume: decimal.NewFromInt(volume), Commission: decimal.NewFromInt(commission)}
+	}
+	tests := []struct {
+		name    string
+		history []*model.OwnerRiskDay
+		want    string
+	}{
+		{"no history", nil, "0"},
+		{"weighted by volume", []*model.OwnerRiskDay{day(1000, 50), day(3000, 50)}, "0.025"},
+		{"no volume", []*model.OwnerRiskDay{day(0, 50)}, "0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := commissionBaselineRate(tt.history); got.String() != tt.want {
+				t.Errorf("Expected baseline %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
+
+// TestCommissionAnomalous 测试抽成收入低于下限时不标记，无流水、超出抽成上限或达到基线倍数时标记
+func TestCommissionAnomalous(t *testing.T) {
+	tests := []struct {
+		name       string
+		volume     int64
+		commission int64
+		baseline   string
+		want       bool
+	}{
+		{"below the minimum amount", 0, 99, "0", false},
+		{"income without volume", 0, 100, "0", true},
+		{"rate above the cap", 400, 100, "0", true},
+		{"rate at the baseline", 10000, 500, "0.05", false},
+		{"rate at twice the baseline", 10000, 1000, "0.05", true},
+		{"no baseline yet", 10000, 1000, "0", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := commissionAnomalous(decimal.NewFromInt(tt.volume), decimal.NewFromInt(tt.commission),
+				decimal.RequireFromString(tt.baseline), testOwnerRiskParams)
+			if got != tt.want {
+				t.Errorf("Expected anomalous=%v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+// TestMarginDrawdown 测试保证金回撤按含当前的历史峰值计算
+func TestMarginDrawdown(t *testing.T) {
+	tests := []struct {
+		name         string
+		current      int64
+		history      []int64
+		wantPeak     string
+		wantDrawdown float64
+	}{
+		{"drawdown from an earlier peak", 700, []int64{800, 1000}, "1000", 0.3},
+		{"current is the peak", 1200, []int64{800, 1000}, "1200", 0},
+		{"margin drained", 0, []int64{500}, "500", 1},
+		{"never funded", 0, []int64{0}, "0", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			history := make([]*model.OwnerRiskDay, 0, len(tt.history))
+			for _, m := range tt.history {
+				history = append(history, &model.OwnerRiskDay{MarginBalance: decimal.NewFromInt(m)})
+			}
+			peak, drawdown := marginDrawdown(decimal.NewFromInt(tt.current), history)
+			if peak.String() != tt.wantPeak {
+				t.Errorf("Expected peak %s, got %s", tt.wantPeak, peak)
+			}
+			if math.Abs(drawdown-tt.wantDrawdown) > 1e-9 {
+				t.Errorf("Expected drawdown %v, got %v", tt.wantDrawdown, drawdown)
+			}
+		})
+	}
+}
+
+// TestConservationSwing 测试守恒差额较上一日的变化达到阈值才标记
+func TestConservationSwing(t *testing.T) {
+	tests := []struct {
+		current, prev, amount int64
+		want                  bool
+	}{
+		{1500, 500, 1000, true},
+		{-200, 700, 1000, false},
+		{-500, 500, 1000, true},
+		{5000, 0, 0, false},
+	}
+	for _, tt := range tests {
+		got := conservationSwing(decimal.NewFromInt(tt.current), decimal.NewFromInt(tt.prev), decimal.NewFromInt(tt.amount))
+		if got != tt.want {
+			t.Errorf("Expected swing %d -> %d (threshold %d) to be %v, got %v", tt.prev, tt.current, tt.amount, tt.want, got)
+		}
+	}
+}
+
+// TestDepositBurstBeforeWithdraw 测试提现前审批的充值笔数达到阈值才标记
+func TestDepositBurstBeforeWithdraw(t *testing.T) {
+	disabled := testOwnerRiskParams
+	disabled.MinDeposits = 0
+	tests := []struct {
+		name     string
+		deposits int
+		params   ownerRiskParams
+		want     bool
+	}{
+		{"below the threshold", 4, testOwnerRiskParams, false},
+		{"at the threshold", 5, testOwnerRiskParams, true},
+		{"threshold disabled", 100, disabled, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := depositBurstBeforeWithdraw(&model.OwnerWithdrawDeposits{Deposits: tt.deposits}, tt.params); got != tt.want {
+				t.Errorf("Expected burst=%v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+// TestDetectOwnerRisk 测试房主当日指标与历史对比得出的房主维度标记
+func TestDetectOwnerRisk(t *testing.T) {
+	previous := []*model.OwnerRiskDay{{
+		RoomVolume:    decimal.NewFromInt(10000),
+		Commission:    decimal.NewFromInt(500),
+		MarginBalance: decimal.NewFromInt(1000),
+	}}
+	tests := []struct {
+		name    string
+		modify  func(d *model.OwnerRiskDay)
+		history []*model.OwnerRiskDay
+		want    []model.RiskFlagType
+	}{
+		{"quiet day", func(d *model.OwnerRiskDay) {}, previous, nil},
+		{"commission spike", func(d *model.OwnerRiskDay) { d.Commission = decimal.NewFromInt(1000) }, previous,
+			[]model.RiskFlagType{model.RiskFlagOwnerCommission}},
+		{"margin drawdown", func(d *model.OwnerRiskDay) { d.MarginBalance = decimal.NewFromInt(700) }, previous,
+			[]model.RiskFlagType{model.RiskFlagOwnerMarginDrawdown}},
+		{"player win rate", func(d *model.OwnerRiskDay) {
+			d.PlayerRounds, d.PlayerWins, d.ExpectedWins, d.WinVariance = 400, 135, 100, 75
+		}, previous, []model.RiskFlagType{model.RiskFlagOwnerPlayerWinRate}},
+		{"win rate below minimum rounds", func(d *model.OwnerRiskDay) {
+			d.PlayerRounds, d.PlayerWins, d.ExpectedWins, d.WinVariance = 100, 100, 25, 18.75
+		}, previous, nil},
+		{"conservation swing", func(d *model.OwnerRiskDay) { d.ConservationDifference = decimal.NewFromInt(-1000) }, previous,
+			[]model.RiskFlagType{model.RiskFlagOwnerConservation}},
+		{"no history skips baseline signals", func(d *model.OwnerRiskDay) {
+			d.Commission = decimal.NewFromInt(1000)
+			d.ConservationDifference = decimal.NewFromInt(5000)
+		}, nil, nil},
+		{"several signals", func(d *model.OwnerRiskDay) {
+			d.Commission = decimal.NewFromInt(1000)
+			d.MarginBalance = decimal.NewFromInt(500)
+			d.ConservationDifference = decimal.NewFromInt(2000)
+		}, previous, []model.RiskFlagType{model.RiskFlagOwnerCommission, model.RiskFlagOwnerMarginDrawdown, model.RiskFlagOwnerConservation}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			day := &model.OwnerRiskDay{
+				OwnerID:       42,
+				Day:           time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC),
+				Currency:      "CNY",
+				RoomVolume:    decimal.NewFromInt(10000),
+				Commission:    decimal.NewFromInt(500),
+				MarginBalance: decimal.NewFromInt(1000),
+			}
+			tt.modify(day)
+
+			var got []model.RiskFlagType
+			for _, f := range detectOwnerRisk(day, tt.history, testOwnerRiskParams) {
+				got = append(got, f.FlagType)
+				if f.OwnerID != 42 || !f.FlagType.IsOwnerScoped() || f.Severity != ownerRiskSeverities[f.FlagType] {
+					t.Errorf("Expected an owner-scoped %s finding for owner 42, got %+v", f.FlagType, f)
+				}
+				if f.Signal.Day != "2026-03-01" || f.Signal.Currency != "CNY" {
+					t.Errorf("Expected signal of 2026-03-01 CNY, got %s %s", f.Signal.Day, f.Signal.Currency)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Expected findings %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
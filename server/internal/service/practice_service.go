@@ -0,0 +1,144 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/fiveseconds/server/internal/config"
+	"github.com/fiveseconds/server/internal/model"
+	"github.com/fiveseconds/server/internal/repository"
+
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrPracticeRefillNotNeeded = errors.New("practice chips are not below the refill amount")
+)
+
+// DefaultPracticeChips 未配置初始游戏币时使用的数量
+var DefaultPracticeChips = decimal.NewFromInt(10000)
+
+// PracticeChipsListener 游戏币变更监听（用于同步练习房内存中的游戏币余额）
+type PracticeChipsListener interface {
+	UpdatePracticeChips(userID int64, chips decimal.Decimal)
+}
+
+// PracticeService 练习房游戏币服务
+// 游戏币与真实余额完全隔离：下注、派奖与退款只变动 practice_balances，
+// 不写交易流水，不产生房主抽成与平台收入，不参与风控与资金守恒检查
+type PracticeService struct {
+	practiceRepo *repository.PracticeRepo
+	listener     PracticeChipsListener
+	cfg          *config.Config
+	logger       *zap.Logger
+}
+
+// NewPracticeService 创建练习房游戏币服务
+func NewPracticeService(practiceRepo *repository.PracticeRepo, cfg *config.Config, logger *zap.Logger) *PracticeService {
+	return &PracticeService{
+		practiceRepo: practiceRepo,
+		cfg:          cfg,
+		logger:       logger.With(zap.String("service", "practice")),
+	}
+}
+
+// SetListener 设置游戏币变更监听
+func (s *PracticeService) SetListener(listener PracticeChipsListener) {
+	s.listener = listener
+}
+
+// initialChips 初始（补充）游戏币数量
+func (s *PracticeService) initialChips() decimal.Decimal {
+	chips := decimal.NewFromFloat(s.cfg.Practice.InitialChips).Round(2)
+	if !chips.IsPositive() {
+		return DefaultPracticeChips
+	}
+	return chips
+}
+
+// GetChips 获取玩家游戏币余额（首次获取时按初始数量发放）
+func (s *PracticeService) GetChips(ctx context.Context, userID int64) (decimal.Decimal, error) {
+	balance, err := s.practiceRepo.GetOrCreate(ctx, userID, s.initialChips())
+	if err != nil {
+		return decimal.Zero, err
+	}
+	return balance.Chips, nil
+}
+
+// GetInfo 获取玩家游戏币信息
+func (s *PracticeService) GetInfo(ctx context.Context, userID int64) (*model.PracticeChipsInfo, error) {
+	refillAmount := s.initialChips()
+	balance, err := s.practiceRepo.GetOrCreate(ctx, userID, refillAmount)
+	if err != nil {
+		return nil, err
+	}
+	return practiceChipsInfo(balance, refillAmount), nil
+}
+
+// Refill 将游戏币补充到初始数量（余额不低于初始数量时不可补充）
+func (s *PracticeService) Refill(ctx context.Context, userID int64) (*model.PracticeChipsInfo, error) {
+	refillAmount := s.initialChips()
+	balance, refilled, err := s.practiceRepo.Refill(ctx, userID, refillAmount)
+	if err != nil {
+		return nil, err
+	}
+	if !refilled {
+		return nil, ErrPracticeRefillNotNeeded
+	}
+
+	if s.listener != nil {
+		s.listener.UpdatePracticeChips(userID, balance.Chips)
+	}
+	s.logger.Info("Practice chips refilled", zap.Int64("user_id", userID), zap.String("chips", balance.Chips.String()))
+	return practiceChipsInfo(balance, refillAmount), nil
+}
+
+// DeductStakes 扣除练习房下注游戏币，返回扣除成功的玩家及其新余额
+func (s *PracticeService) DeductStakes(ctx context.Context, userIDs []int64, stake decimal.Decimal) (map[int64]decimal.Decimal, error) {
+	return s.practiceRepo.BatchDeduct(ctx, userIDs, stake)
+}
+
+// SettleRound 练习房派奖：奖池按赢家人数均分（向下取整到分），返回每位赢家奖金及赢家新余额
+// 游戏币不记录在途下注，忽略参与者列表
+func (s *PracticeService) SettleRound(ctx context.Context, _, winners []int64, pool decimal.Decimal) (decimal.Decimal, map[int64]decimal.Decimal, error) {
+	prize := splitPracticePool(pool, len(winners))
+	if !prize.IsPositive() {
+		return prize, nil, nil
+	}
+	amounts := make(map[int64]decimal.Decimal, len(winners))
+	for _, winnerID := range winners {
+		amounts[winnerID] = prize
+	}
+	balances, err := s.practiceRepo.BatchAdd(ctx, amounts)
+	if err != nil {
+		return decimal.Zero, nil, err
+	}
+	return prize, balances, nil
+}
+
+// RefundStakes 退回练习房下注游戏币
+func (s *PracticeService) RefundStakes(ctx context.Context, userIDs []int64, stake decimal.Decimal) (map[int64]decimal.Decimal, error) {
+	amounts := make(map[int64]decimal.Decimal, len(userIDs))
+	for _, userID := range userIDs {
+		amounts[userID] = stake
+	}
+	return s.practiceRepo.BatchAdd(ctx, amounts)
+}
+
+// splitPracticePool 奖池按赢家人数均分，向下取整到分（游戏币无平台账户承接残值，不足一分的部分直接作废）
+func splitPracticePool(pool decimal.Decimal, winners int) decimal.Decimal {
+	if winners <= 0 || !pool.IsPositive() {
+		return decimal.Zero
+	}
+	return pool.Div(decimal.NewFromInt(int64(winners))).RoundFloor(2)
+}
+
+func practiceChipsInfo(balance *model.PracticeBalance, refillAmount decimal.Decimal) *model.PracticeChipsInfo {
+	return &model.PracticeChipsInfo{
+		Chips:        balance.Chips,
+		RefillAmount: refillAmount,
+		CanRefill:    balance.Chips.LessThan(refillAmount),
+		RefilledAt:   balance.RefilledAt,
+	}
+}
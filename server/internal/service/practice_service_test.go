@@ -0,0 +1,62 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/fiveseconds/server/internal/model"
+	"github.com/shopspring/decimal"
+)
+
+// TestSplitPracticePool 测试练习房奖池按赢家均分并向下取整到分，不会凭空产生游戏币
+func TestSplitPracticePool(t *testing.T) {
+	tests := []struct {
+		name    string
+		pool    string
+		winners int
+		want    string
+	}{
+		{"even split", "300", 3, "100"},
+		{"remainder discarded", "100", 3, "33.33"},
+		{"sub-cent share", "0.02", 3, "0"},
+		{"single winner takes all", "12.34", 1, "12.34"},
+		{"pool with cents", "0.99", 2, "0.49"},
+		{"no winners", "100", 0, "0"},
+		{"negative winners", "100", -1, "0"},
+		{"empty pool", "0", 4, "0"},
+	}
+	for _, tt := range tests {
+		pool := decimal.RequireFromString(tt.pool)
+		prize := splitPracticePool(pool, tt.winners)
+		if prize.String() != tt.want {
+			t.Errorf("%s: Expected prize %s, got %s", tt.name, tt.want, prize)
+		}
+		if tt.winners > 0 && prize.Mul(decimal.NewFromInt(int64(tt.winners))).GreaterThan(pool) {
+			t.Errorf("%s: Expected payout within pool %s, got %s each", tt.name, pool, prize)
+		}
+	}
+}
+
+// TestPracticeChipsInfo 测试游戏币低于补充数量时才可补充
+func TestPracticeChipsInfo(t *testing.T) {
+	refilledAt := time.Date(2024, 3, 13, 8, 0, 0, 0, time.UTC)
+	tests := []struct {
+		chips     string
+		canRefill bool
+	}{
+		{"0", true},
+		{"999.99", true},
+		{"1000", false},
+		{"1500", false},
+	}
+	for _, tt := range tests {
+		balance := &model.PracticeBalance{Chips: decimal.RequireFromString(tt.chips), RefilledAt: &refilledAt}
+		info := practiceChipsInfo(balance, decimal.NewFromInt(1000))
+		if info.CanRefill != tt.canRefill {
+			t.Errorf("chips %s: Expected can refill %v, got %v", tt.chips, tt.canRefill, info.CanRefill)
+		}
+		if info.Chips.String() != tt.chips || info.RefillAmount.String() != "1000" || info.RefilledAt != &refilledAt {
+			t.Errorf("chips %s: Unexpected info %+v", tt.chips, info)
+		}
+	}
+}
@@ -0,0 +1,374 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/fiveseconds/server/internal/config"
+	"github.com/fiveseconds/server/internal/model"
+	"github.com/fiveseconds/server/internal/repository"
+	"github.com/fiveseconds/server/internal/ws"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrRebateInvalidBasis      = errors.New("invalid rebate basis, use volume or net_loss")
+	ErrRebateInvalidTier       = errors.New("invalid rebate tier: min_amount must not be negative, rate must be within the allowed range and thresholds must be unique")
+	ErrRebateInvalidPeriod     = errors.New("invalid rebate period, use start_date/end_date=YYYY-MM-DD")
+	ErrRebatePeriodTooLong     = errors.New("rebate period is too long")
+	ErrRebatePeriodOpen        = errors.New("rebate period has not ended yet")
+	ErrRebateNoRules           = errors.New("rebate rules are not configured")
+	ErrRebateNothingToPay      = errors.New("no rebate to pay for this period")
+	ErrRebateAlreadyPaid       = errors.New("rebate already paid for an overlapping period")
+	ErrRebateInsufficientFunds = errors.New("insufficient commission balance to pay rebates")
+	ErrRebateNotFound          = errors.New("rebate payout not found")
+	ErrRebateForbidden         = errors.New("this rebate payout does not belong to you")
+)
+
+// RebateService 玩家返水服务
+// 按房主配置的阶梯规则，以周期内玩家真实余额游戏流水（或净输额）计算返水，
+// 从房主佣金余额支付给玩家，双方各记一笔 rebate 流水，保证房主维度资金守恒
+type RebateService struct {
+	userRepo   *repository.UserRepo
+	walletRepo *repository.WalletRepo
+	rebateRepo *repository.RebateRepo
+	txRepo     *repository.TransactionRepo
+	hub        *ws.Hub
+	cfg        *config.Config
+	logger     *zap.Logger
+}
+
+// NewRebateService 创建玩家返水服务
+func NewRebateService(
+	userRepo *repository.UserRepo,
+	walletRepo *repository.WalletRepo,
+	rebateRepo *repository.RebateRepo,
+	txRepo *repository.TransactionRepo,
+	cfg *config.Config,
+	logger *zap.Logger,
+) *RebateService {
+	return &RebateService{
+		userRepo:   userRepo,
+		walletRepo: walletRepo,
+		rebateRepo: rebateRepo,
+		txRepo:     txRepo,
+		cfg:        cfg,
+		logger:     logger.With(zap.String("service", "rebate")),
+	}
+}
+
+// SetHub 设置 WebSocket Hub（用于发送余额更新通知）
+func (s *RebateService) SetHub(hub *ws.Hub) {
+	s.hub = hub
+}
+
+// GetRules 获取房主返水规则
+func (s *RebateService) GetRules(ctx context.Context, ownerID int64) (*model.OwnerRebateRules, error) {
+	return s.rebateRepo.GetRules(ctx, ownerID)
+}
+
+// UpdateRules 整体替换房主返水规则
+func (s *RebateService) UpdateRules(ctx context.Context, ownerID int64, req *model.UpdateRebateRulesReq) (*model.OwnerRebateRules, error) {
+	if req.Basis != model.RebateBasisVolume && req.Basis != model.RebateBasisNetLoss {
+		return nil, ErrRebateInvalidBasis
+	}
+	tiers, err := normalizeRebateTiers(req.Tiers, decimal.NewFromFloat(s.cfg.Rebate.MaxRate))
+	if err != nil {
+		return nil, err
+	}
+
+	rules := &model.OwnerRebateRules{OwnerID: ownerID, Basis: req.Basis, Tiers: tiers}
+	err = repository.Tx(ctx, func(tx pgx.Tx) error {
+		return s.rebateRepo.ReplaceRulesTx(ctx, tx, rules)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// ParsePeriod 解析返水周期（含首尾日期），返回 [start, end)
+func (s *RebateService) ParsePeriod(req *model.RebatePeriodReq) (time.Time, time.Time, error) {
+	var start, end time.Time
+	from, err := time.ParseInLocation(statementDateLayout, req.StartDate, time.Local)
+	if err != nil {
+		return start, end, ErrRebateInvalidPeriod
+	}
+	to, err := time.ParseInLocation(statementDateLayout, req.EndDate, time.Local)
+	if err != nil || to.Before(from) {
+		return start, end, ErrRebateInvalidPeriod
+	}
+	start, end = from, to.AddDate(0, 0, 1)
+
+	if maxDays := s.cfg.Rebate.MaxPeriodDays; maxDays > 0 && end.Sub(start) > time.Duration(maxDays)*24*time.Hour {
+		return start, end, ErrRebatePeriodTooLong
+	}
+	return start, end, nil
+}
+
+// Preview 预览房主在周期内应发放的返水（不落库）
+func (s *RebateService) Preview(ctx context.Context, ownerID int64, req *model.RebatePeriodReq) (*model.RebatePreview, error) {
+	start, end, err := s.ParsePeriod(req)
+	if err != nil {
+		return nil, err
+	}
+	rules, err := s.rebateRepo.GetRules(ctx, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	owner, err := s.userRepo.GetByID(ctx, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	stats, err := s.rebateRepo.AggregatePlayerWagers(ctx, ownerID, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("aggregate player wagers: %w", err)
+	}
+	paid, err := s.rebateRepo.HasOverlappingPayout(ctx, nil, ownerID, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	items := computeRebateItems(stats, rules)
+	preview := &model.RebatePreview{
+		OwnerID:        ownerID,
+		Basis:          rules.Basis,
+		PeriodStart:    start,
+		PeriodEnd:      end,
+		Items:          items,
+		AvailableFunds: owner.OwnerRoomBalance,
+		AlreadyPaid:    paid,
+	}
+	if preview.Items == nil {
+		preview.Items = []*model.RebatePayoutItem{}
+	}
+	for _, item := range items {
+		preview.TotalAmount = preview.TotalAmount.Add(item.Amount)
+	}
+	return preview, nil
+}
+
+// Payout 发放周期返水：从房主佣金余额扣除，计入玩家房主经营币种的钱包（周期必须已结束且未发放过）
+func (s *RebateService) Payout(ctx context.Context, ownerID, operatorID int64, req *model.RebatePeriodReq) (*model.RebatePayout, error) {
+	start, end, err := s.ParsePeriod(req)
+	if err != nil {
+		return nil, err
+	}
+	if end.After(time.Now()) {
+		return nil, ErrRebatePeriodOpen
+	}
+	rules, err := s.rebateRepo.GetRules(ctx, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	if len(rules.Tiers) == 0 {
+		return nil, ErrRebateNoRules
+	}
+	stats, err := s.rebateRepo.AggregatePlayerWagers(ctx, ownerID, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("aggregate player wagers: %w", err)
+	}
+	items := computeRebateItems(stats, rules)
+	if len(items) == 0 {
+		return nil, ErrRebateNothingToPay
+	}
+	owner, err := s.userRepo.GetByID(ctx, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	currency := owner.Currency
+
+	payout := &model.RebatePayout{
+		OwnerID:     ownerID,
+		Basis:       rules.Basis,
+		PeriodStart: start,
+		PeriodEnd:   end,
+		PlayerCount: len(items),
+		OperatorID:  operatorID,
+		Items:       items,
+	}
+	for _, item := range items {
+		payout.TotalAmount = payout.TotalAmount.Add(item.Amount)
+	}
+
+	newBalances := make(map[int64]decimal.Decimal)
+	err = repository.Tx(ctx, func(tx pgx.Tx) error {
+		// 锁定房主佣金余额，同时串行化同一房主的并发发放
+		commission, err := s.userRepo.GetOwnerRoomBalanceForUpdateTx(ctx, tx, ownerID)
+		if err != nil {
+			return err
+		}
+		paid, err := s.rebateRepo.HasOverlappingPayout(ctx, tx, ownerID, start, end)
+		if err != nil {
+			return err
+		}
+		if paid {
+			return ErrRebateAlreadyPaid
+		}
+		if commission.LessThan(payout.TotalAmount) {
+			return ErrRebateInsufficientFunds
+		}
+
+		if err := s.rebateRepo.CreatePayoutTx(ctx, tx, payout); err != nil {
+			return fmt.Errorf("create rebate payout: %w", err)
+		}
+		if err := s.userRepo.UpdateOwnerBalancesTx(ctx, tx, ownerID, "owner_room_balance", payout.TotalAmount.Neg()); err != nil {
+			return fmt.Errorf("deduct owner commission: %w", err)
+		}
+
+		remark := fmt.Sprintf("返水发放(ID:%d)", payout.ID)
+		txRecords := make([]*model.BalanceTransaction, 0, len(items)+1)
+		txRecords = append(txRecords, &model.BalanceTransaction{
+			UserID:        ownerID,
+			Type:          model.TxRebate,
+			Amount:        payout.TotalAmount.Neg(),
+			BalanceBefore: commission,
+			BalanceAfter:  commission.Sub(payout.TotalAmount),
+			BalanceField:  "owner_room_balance",
+			Remark:        &remark,
+		})
+		// 明细按玩家 ID 升序，逐个加锁入账
+		for _, item := range items {
+			balance, active, err := s.walletRepo.AdjustTx(ctx, tx, item.UserID, currency, item.Amount)
+			if err != nil {
+				return fmt.Errorf("add player rebate: %w", err)
+			}
+			// 玩家当前使用其他币种时计入对应钱包，无需推送余额
+			if active {
+				newBalances[item.UserID] = balance
+			}
+			txRecords = append(txRecords, &model.BalanceTransaction{
+				UserID:        item.UserID,
+				Type:          model.TxRebate,
+				Amount:        item.Amount,
+				BalanceBefore: balance.Sub(item.Amount),
+				BalanceAfter:  balance,
+				BalanceField:  walletBalanceField(active, currency),
+				Currency:      currency,
+				Remark:        &remark,
+			})
+		}
+		return s.txRepo.BatchCreateTx(ctx, tx, txRecords)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for userID, balance := range newBalances {
+		s.notifyBalanceUpdate(userID, balance)
+	}
+
+	s.logger.Info("Rebate paid",
+		zap.Int64("payout_id", payout.ID),
+		zap.Int64("owner_id", ownerID),
+		zap.Time("period_start", start),
+		zap.Int("players", payout.PlayerCount),
+		zap.String("total", payout.TotalAmount.String()))
+
+	return payout, nil
+}
+
+// notifyBalanceUpdate 通知玩家余额更新
+func (s *RebateService) notifyBalanceUpdate(userID int64, balance decimal.Decimal) {
+	if s.hub == nil {
+		return
+	}
+	s.hub.SendToUser(userID, &model.WSMessage{
+		Type: model.WSTypeBalanceUpdate,
+		Payload: &model.WSBalanceUpdate{
+			Balance:       balance.String(),
+			FrozenBalance: "0",
+		},
+	})
+}
+
+// GetPayout 获取返水发放详情（房主只能查看自己的记录）
+func (s *RebateService) GetPayout(ctx context.Context, id, operatorID int64, operatorRole model.Role) (*model.RebatePayout, error) {
+	payout, err := s.rebateRepo.GetPayout(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrRebateNotFound
+		}
+		return nil, err
+	}
+	if operatorRole != model.RoleAdmin && payout.OwnerID != operatorID {
+		return nil, ErrRebateForbidden
+	}
+	return payout, nil
+}
+
+// ListPayouts 分页获取返水发放记录
+func (s *RebateService) ListPayouts(ctx context.Context, query *model.RebatePayoutListQuery) ([]*model.RebatePayout, int64, error) {
+	return s.rebateRepo.ListPayouts(ctx, query)
+}
+
+// normalizeRebateTiers 校验返水档位并按门槛升序排列
+func normalizeRebateTiers(tiers []*model.RebateTier, maxRate decimal.Decimal) ([]*model.RebateTier, error) {
+	sorted := make([]*model.RebateTier, 0, len(tiers))
+	for _, tier := range tiers {
+		if tier == nil || tier.MinAmount.IsNegative() || tier.Rate.IsNegative() {
+			return nil, ErrRebateInvalidTier
+		}
+		if maxRate.IsPositive() && tier.Rate.GreaterThan(maxRate) {
+			return nil, ErrRebateInvalidTier
+		}
+		sorted = append(sorted, tier)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].MinAmount.LessThan(sorted[j].MinAmount) })
+	for i := 1; i < len(sorted); i++ {
+		if sorted[i].MinAmount.Equal(sorted[i-1].MinAmount) {
+			return nil, ErrRebateInvalidTier
+		}
+	}
+	return sorted, nil
+}
+
+// selectRebateRate 选择金额所在档位的返水比例（门槛升序，取满足门槛的最高档）
+func selectRebateRate(tiers []*model.RebateTier, amount decimal.Decimal) decimal.Decimal {
+	rate := decimal.Zero
+	for _, tier := range tiers {
+		if amount.LessThan(tier.MinAmount) {
+			break
+		}
+		rate = tier.Rate
+	}
+	return rate
+}
+
+// computeRebateItems 根据玩家流水与返水规则计算返水明细（只返回金额大于 0 的玩家）
+// 有效流水 = 下注 - 退款；净输额 = 有效流水 - 赢得奖金（最低为 0）；返水金额向下取整到分
+func computeRebateItems(stats []*model.PlayerWagerStat, rules *model.OwnerRebateRules) []*model.RebatePayoutItem {
+	var items []*model.RebatePayoutItem
+	for _, stat := range stats {
+		volume := decimal.Max(stat.Bet.Sub(stat.Refund), decimal.Zero)
+		netLoss := decimal.Max(volume.Sub(stat.Win), decimal.Zero)
+		eligible := volume
+		if rules.Basis == model.RebateBasisNetLoss {
+			eligible = netLoss
+		}
+		if !eligible.IsPositive() {
+			continue
+		}
+		rate := selectRebateRate(rules.Tiers, eligible)
+		amount := eligible.Mul(rate).RoundFloor(2)
+		if !amount.IsPositive() {
+			continue
+		}
+		items = append(items, &model.RebatePayoutItem{
+			UserID:         stat.UserID,
+			Username:       stat.Username,
+			Volume:         volume,
+			NetLoss:        netLoss,
+			EligibleAmount: eligible,
+			Rate:           rate,
+			Amount:         amount,
+		})
+	}
+	return items
+}
@@ -0,0 +1,165 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/fiveseconds/server/internal/config"
+	"github.com/fiveseconds/server/internal/model"
+	"github.com/shopspring/decimal"
+)
+
+// rebateTiers 按 "门槛:比例" 构造返水档位
+func rebateTiers(pairs ...string) []*model.RebateTier {
+	tiers := make([]*model.RebateTier, 0, len(pairs)/2)
+	for i := 0; i+1 < len(pairs); i += 2 {
+		tiers = append(tiers, &model.RebateTier{
+			MinAmount: decimal.RequireFromString(pairs[i]),
+			Rate:      decimal.RequireFromString(pairs[i+1]),
+		})
+	}
+	return tiers
+}
+
+// TestNormalizeRebateTiers 测试返水档位校验与排序
+func TestNormalizeRebateTiers(t *testing.T) {
+	maxRate := decimal.RequireFromString("0.05")
+	tests := []struct {
+		name    string
+		tiers   []*model.RebateTier
+		wantMin []string
+		err     error
+	}{
+		{"sorted by threshold", rebateTiers("1000", "0.01", "0", "0.005", "5000", "0.02"), []string{"0", "1000", "5000"}, nil},
+		{"rate at the limit", rebateTiers("0", "0.05"), []string{"0"}, nil},
+		{"no tiers", nil, nil, nil},
+		{"duplicate threshold", rebateTiers("100", "0.01", "100", "0.02"), nil, ErrRebateInvalidTier},
+		{"negative threshold", rebateTiers("-1", "0.01"), nil, ErrRebateInvalidTier},
+		{"negative rate", rebateTiers("0", "-0.01"), nil, ErrRebateInvalidTier},
+		{"rate above the limit", rebateTiers("0", "0.0501"), nil, ErrRebateInvalidTier},
+		{"nil tier", []*model.RebateTier{nil}, nil, ErrRebateInvalidTier},
+	}
+	for _, tt := range tests {
+		got, err := normalizeRebateTiers(tt.tiers, maxRate)
+		if !errors.Is(err, tt.err) {
+			t.Errorf("%s: Expected error %v, got %v", tt.name, tt.err, err)
+			continue
+		}
+		if len(got) != len(tt.wantMin) {
+			t.Errorf("%s: Expected %d tiers, got %d", tt.name, len(tt.wantMin), len(got))
+			continue
+		}
+		for i, min := range tt.wantMin {
+			if !got[i].MinAmount.Equal(decimal.RequireFromString(min)) {
+				t.Errorf("%s: tier %d expected min %s, got %s", tt.name, i, min, got[i].MinAmount)
+			}
+		}
+	}
+
+	// 未配置上限时不限制比例
+	if _, err := normalizeRebateTiers(rebateTiers("0", "0.5"), decimal.Zero); err != nil {
+		t.Errorf("Expected any rate without a limit, got %v", err)
+	}
+}
+
+// TestSelectRebateRate 测试按金额选择满足门槛的最高档
+func TestSelectRebateRate(t *testing.T) {
+	tiers := rebateTiers("100", "0.005", "1000", "0.01", "5000", "0.02")
+	tests := []struct {
+		amount string
+		want   string
+	}{
+		{"0", "0"},
+		{"99.99", "0"},
+		{"100", "0.005"},
+		{"999.99", "0.005"},
+		{"1000", "0.01"},
+		{"5000", "0.02"},
+		{"1000000", "0.02"},
+	}
+	for _, tt := range tests {
+		if got := selectRebateRate(tiers, decimal.RequireFromString(tt.amount)); !got.Equal(decimal.RequireFromString(tt.want)) {
+			t.Errorf("amount %s: Expected rate %s, got %s", tt.amount, tt.want, got)
+		}
+	}
+	if got := selectRebateRate(nil, decimal.NewFromInt(100)); !got.IsZero() {
+		t.Errorf("Expected rate 0 without tiers, got %s", got)
+	}
+}
+
+// TestComputeRebateItems 测试返水明细：有效流水扣除退款，净输额最低为 0，金额向下取整到分
+func TestComputeRebateItems(t *testing.T) {
+	d := decimal.RequireFromString
+	tiers := rebateTiers("0", "0.005", "1000", "0.01")
+	stat := func(userID int64, bet, win, refund string) *model.PlayerWagerStat {
+		return &model.PlayerWagerStat{UserID: userID, Bet: d(bet), Win: d(win), Refund: d(refund)}
+	}
+	tests := []struct {
+		name    string
+		basis   model.RebateBasis
+		stat    *model.PlayerWagerStat
+		volume  string
+		netLoss string
+		rate    string
+		amount  string // 空串表示不返水
+	}{
+		{"volume basis", model.RebateBasisVolume, stat(1, "500", "800", "0"), "500", "0", "0.005", "2.5"},
+		{"volume basis reaches the higher tier", model.RebateBasisVolume, stat(1, "1200", "0", "200"), "1000", "1000", "0.01", "10"},
+		{"amount floored to cents", model.RebateBasisVolume, stat(1, "333.33", "0", "0"), "333.33", "333.33", "0.005", "1.66"},
+		{"net loss basis", model.RebateBasisNetLoss, stat(1, "1500", "300", "100"), "1400", "1100", "0.01", "11"},
+		{"net loss basis uses the net loss tier", model.RebateBasisNetLoss, stat(1, "1500", "600", "0"), "1500", "900", "0.005", "4.5"},
+		{"winning player gets nothing on net loss", model.RebateBasisNetLoss, stat(1, "500", "800", "0"), "", "", "", ""},
+		{"fully refunded", model.RebateBasisVolume, stat(1, "100", "0", "100"), "", "", "", ""},
+		{"refund above bets", model.RebateBasisVolume, stat(1, "100", "0", "150"), "", "", "", ""},
+		{"below one cent", model.RebateBasisVolume, stat(1, "1.99", "0", "0"), "", "", "", ""},
+	}
+	for _, tt := range tests {
+		items := computeRebateItems([]*model.PlayerWagerStat{tt.stat}, &model.OwnerRebateRules{Basis: tt.basis, Tiers: tiers})
+		if tt.amount == "" {
+			if len(items) != 0 {
+				t.Errorf("%s: Expected no rebate, got %s", tt.name, items[0].Amount)
+			}
+			continue
+		}
+		if len(items) != 1 {
+			t.Errorf("%s: Expected one item, got %d", tt.name, len(items))
+			continue
+		}
+		it := items[0]
+		if !it.Volume.Equal(d(tt.volume)) || !it.NetLoss.Equal(d(tt.netLoss)) || !it.Rate.Equal(d(tt.rate)) || !it.Amount.Equal(d(tt.amount)) {
+			t.Errorf("%s: Expected volume %s net loss %s rate %s amount %s, got %s %s %s %s",
+				tt.name, tt.volume, tt.netLoss, tt.rate, tt.amount, it.Volume, it.NetLoss, it.Rate, it.Amount)
+		}
+	}
+}
+
+// TestParseRebatePeriod 测试返水周期解析（含首尾日期）与最长天数
+func TestParseRebatePeriod(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Rebate.MaxPeriodDays = 28
+	s := &RebateService{cfg: cfg}
+
+	tests := []struct {
+		start, end string
+		days       int
+		err        error
+	}{
+		{"2025-02-01", "2025-02-01", 1, nil},
+		{"2025-02-01", "2025-02-28", 28, nil},
+		{"2025-02-01", "2025-03-01", 0, ErrRebatePeriodTooLong},
+		{"2025-02-02", "2025-02-01", 0, ErrRebateInvalidPeriod},
+		{"2025/02/01", "2025-02-01", 0, ErrRebateInvalidPeriod},
+		{"2025-02-01", "", 0, ErrRebateInvalidPeriod},
+	}
+	for _, tt := range tests {
+		start, end, err := s.ParsePeriod(&model.RebatePeriodReq{StartDate: tt.start, EndDate: tt.end})
+		if !errors.Is(err, tt.err) {
+			t.Errorf("%s..%s: Expected error %v, got %v", tt.start, tt.end, tt.err, err)
+			continue
+		}
+		if err == nil && end.Sub(start) != time.Duration(tt.days)*24*time.Hour {
+			t.Errorf("%s..%s: Expected %d days, got %s", tt.start, tt.end, tt.days, end.Sub(start))
+		}
+	}
+}
@@ -27,6 +27,7 @@ func NewReconciliationLogger(log *logger.Logger, alertManager *AlertManager) *Re
 // ReconciliationResult holds the result of a reconciliation run
 type ReconciliationResult struct {
 	PeriodType         string          // "2h" or "daily"
+	Currency           string
 	PeriodStart        time.Time
 	PeriodEnd          time.Time
 	TotalPlayerBalance decimal.Decimal
@@ -52,6 +53,7 @@ func (r *ReconciliationLogger) LogReconciliationStarted(ctx context.Context, per
 func (r *ReconciliationLogger) LogReconciliationResult(ctx context.Context, result *ReconciliationResult) {
 	fields := []zap.Field{
 		zap.String("period_type", result.PeriodType),
+		zap.String("currency", result.Currency),
 		zap.Time("period_start", result.PeriodStart),
 		zap.Time("period_end", result.PeriodEnd),
 		zap.String("total_player_balance", result.TotalPlayerBalance.String()),
@@ -72,7 +74,7 @@ func (r *ReconciliationLogger) LogReconciliationResult(ctx context.Context, resu
 
 		// Trigger alert if alert manager is available
 		if r.alertManager != nil {
-			r.alertManager.TriggerConservationFailedAlert(ctx, result.Difference)
+			r.alertManager.TriggerConservationFailedAlert(ctx, result.Currency, result.Difference)
 		}
 	}
 }
@@ -0,0 +1,403 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/fiveseconds/server/internal/config"
+	"github.com/fiveseconds/server/internal/model"
+	"github.com/fiveseconds/server/internal/repository"
+	"github.com/fiveseconds/server/internal/ws"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrReferralNotPlayer         = errors.New("only players can refer other players")
+	ErrReferralInsufficientFunds = errors.New("insufficient funds to pay referral reward")
+)
+
+// referralBlockReason 推荐人与被推荐人共用设备时的拦截原因
+const referralBlockReason = "推荐人与被推荐人使用相同设备"
+
+// ReferralRiskChecker 推荐奖励风控检查（设备指纹自我推荐检测）
+type ReferralRiskChecker interface {
+	CheckReferralFraud(ctx context.Context, referrerID, refereeID int64) (bool, error)
+}
+
+// ReferralService 玩家推荐奖励服务
+// 玩家的邀请码即推荐码：被推荐人仍归属推荐人的房主，推荐人按配置获得
+// 被推荐人所产生佣金的分成，或在被推荐人完成前 N 局后获得一次性奖励。
+// 奖励由房主佣金余额或平台余额支付，属于系统内部流转，不影响资金守恒
+type ReferralService struct {
+	userRepo     *repository.UserRepo
+	walletRepo   *repository.WalletRepo
+	referralRepo *repository.ReferralRepo
+	txRepo       *repository.TransactionRepo
+	platformRepo *repository.PlatformRepo
+	riskChecker  ReferralRiskChecker
+	hub          *ws.Hub
+	cfg          *config.Config
+	logger       *zap.Logger
+}
+
+// NewReferralService 创建玩家推荐奖励服务
+func NewReferralService(
+	userRepo *repository.UserRepo,
+	walletRepo *repository.WalletRepo,
+	referralRepo *repository.ReferralRepo,
+	txRepo *repository.TransactionRepo,
+	platformRepo *repository.PlatformRepo,
+	cfg *config.Config,
+	logger *zap.Logger,
+) *ReferralService {
+	return &ReferralService{
+		userRepo:     userRepo,
+		walletRepo:   walletRepo,
+		referralRepo: referralRepo,
+		txRepo:       txRepo,
+		platformRepo: platformRepo,
+		cfg:          cfg,
+		logger:       logger.With(zap.String("service", "referral")),
+	}
+}
+
+// SetHub 设置 WebSocket Hub（用于发送余额更新通知）
+func (s *ReferralService) SetHub(hub *ws.Hub) {
+	s.hub = hub
+}
+
+// SetRiskChecker 设置风控检查（发放前检测自我推荐）
+func (s *ReferralService) SetRiskChecker(riskChecker ReferralRiskChecker) {
+	s.riskChecker = riskChecker
+}
+
+// fundingSource 获取配置的奖励资金来源，默认由房主佣金支付
+func (s *ReferralService) fundingSource() model.ReferralFundingSource {
+	if s.cfg.Referral.FundingSource == string(model.ReferralFundingPlatform) {
+		return model.ReferralFundingPlatform
+	}
+	return model.ReferralFundingOwner
+}
+
+// RecordReferralTx 在注册事务内记录推荐关系（由 AuthService 调用）
+func (s *ReferralService) RecordReferralTx(ctx context.Context, tx pgx.Tx, referrerID, refereeID, ownerID int64) error {
+	return s.referralRepo.CreateTx(ctx, tx, &model.Referral{
+		RefereeID:  refereeID,
+		ReferrerID: referrerID,
+		OwnerID:    ownerID,
+		Status:     model.ReferralActive,
+	})
+}
+
+// GetDashboard 获取玩家推荐面板（推荐码、被推荐人及奖励记录）
+// 早于推荐功能注册的玩家没有邀请码，首次查看时补发
+func (s *ReferralService) GetDashboard(ctx context.Context, userID int64) (*model.ReferralDashboard, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !user.IsPlayer() {
+		return nil, ErrReferralNotPlayer
+	}
+	code, err := s.ensureReferralCode(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	referees, err := s.referralRepo.ListRefereeSummaries(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	rewards, _, err := s.referralRepo.ListRewards(ctx, &model.ReferralRewardListQuery{
+		ReferrerID: &userID,
+		Page:       1,
+		PageSize:   20,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	dashboard := &model.ReferralDashboard{
+		ReferralCode:        code,
+		CommissionShareRate: decimal.NewFromFloat(s.cfg.Referral.CommissionShareRate),
+		QualifyingRounds:    s.cfg.Referral.QualifyingRounds,
+		FirstRoundsReward:   decimal.NewFromFloat(s.cfg.Referral.FirstRoundsReward),
+		RefereeCount:        len(referees),
+		Referees:            referees,
+		RecentRewards:       rewards,
+	}
+	if dashboard.Referees == nil {
+		dashboard.Referees = []*model.RefereeSummary{}
+	}
+	if dashboard.RecentRewards == nil {
+		dashboard.RecentRewards = []*model.ReferralReward{}
+	}
+	for _, r := range referees {
+		r.Qualified = s.cfg.Referral.QualifyingRounds > 0 && r.RoundsPlayed >= s.cfg.Referral.QualifyingRounds
+		dashboard.TotalRewards = dashboard.TotalRewards.Add(r.RewardTotal)
+	}
+	return dashboard, nil
+}
+
+// ensureReferralCode 返回玩家的推荐码，没有时生成一个
+func (s *ReferralService) ensureReferralCode(ctx context.Context, user *model.User) (string, error) {
+	if user.InviteCode != nil {
+		return *user.InviteCode, nil
+	}
+	code, err := generateUniqueInviteCode(ctx, s.userRepo)
+	if err != nil {
+		return "", err
+	}
+	updated, err := s.userRepo.SetInviteCodeIfEmpty(ctx, user.ID, code)
+	if err != nil {
+		return "", err
+	}
+	if updated {
+		return code, nil
+	}
+	// 并发请求已生成，读取已保存的推荐码
+	latest, err := s.userRepo.GetByID(ctx, user.ID)
+	if err != nil {
+		return "", err
+	}
+	if latest.InviteCode == nil {
+		return "", fmt.Errorf("referral code not generated for user %d", user.ID)
+	}
+	return *latest.InviteCode, nil
+}
+
+// ListRewards 分页获取推荐奖励记录
+func (s *ReferralService) ListRewards(ctx context.Context, query *model.ReferralRewardListQuery) ([]*model.ReferralReward, int64, error) {
+	return s.referralRepo.ListRewards(ctx, query)
+}
+
+// RunDueRewards 发放到期的推荐奖励（定时任务调用，可重复执行）
+// 佣金分成按自然日统计，从最早尚未分成的一天起逐日补发到最近一个已结束的自然日（任务停运后可追补）；
+// 首 N 局奖励在被推荐人达标后发放一次。
+// 单笔发放失败（如房主佣金不足）只记录日志，下次执行时重试
+func (s *ReferralService) RunDueRewards(ctx context.Context, now time.Time) (int, error) {
+	var candidates []*model.ReferralReward
+	source := s.fundingSource()
+
+	if rate := decimal.NewFromFloat(s.cfg.Referral.CommissionShareRate); rate.IsPositive() {
+		earliest, err := s.referralRepo.EarliestUnsharedRoundAt(ctx)
+		if err != nil {
+			return 0, fmt.Errorf("find earliest unshared round: %w", err)
+		}
+		if earliest != nil {
+			for _, period := range pendingSharePeriods(earliest.In(now.Location()), now) {
+				shares, err := s.referralRepo.ListCommissionShareCandidates(ctx, source, period[0], period[1])
+				if err != nil {
+					return 0, fmt.Errorf("list commission share candidates: %w", err)
+				}
+				for _, rw := range shares {
+					rw.Rate = rate
+					rw.Amount = computeCommissionShare(rw.BaseAmount, rate)
+					if rw.Amount.IsPositive() {
+						candidates = append(candidates, rw)
+					}
+				}
+			}
+		}
+	}
+
+	reward := decimal.NewFromFloat(s.cfg.Referral.FirstRoundsReward).Round(2)
+	if s.cfg.Referral.QualifyingRounds > 0 && reward.IsPositive() {
+		qualified, err := s.referralRepo.ListFirstRoundsCandidates(ctx, s.cfg.Referral.QualifyingRounds)
+		if err != nil {
+			return 0, fmt.Errorf("list first rounds candidates: %w", err)
+		}
+		for _, rw := range qualified {
+			rw.FundingSource = source
+			rw.Amount = reward
+			candidates = append(candidates, rw)
+		}
+	}
+
+	// 同一被推荐人某天的分成发放失败后，本次不再发放其后续日期的分成，避免跳过失败的日期
+	failed := make(map[int64]bool)
+	paid := 0
+	for _, rw := range candidates {
+		if rw.RewardType == model.ReferralRewardCommissionShare && failed[rw.RefereeID] {
+			continue
+		}
+		ok, err := s.payReward(ctx, rw)
+		if err != nil {
+			if rw.RewardType == model.ReferralRewardCommissionShare {
+				failed[rw.RefereeID] = true
+			}
+			s.logger.Warn("Referral reward not paid",
+				zap.Int64("referrer_id", rw.ReferrerID),
+				zap.Int64("referee_id", rw.RefereeID),
+				zap.String("reward_type", string(rw.RewardType)),
+				zap.Error(err))
+			continue
+		}
+		if ok {
+			paid++
+		}
+	}
+	return paid, nil
+}
+
+// payReward 发放单笔推荐奖励；疑似自我推荐时记录拦截并停止该推荐关系的后续奖励
+// 返回 true 表示已实际发放
+func (s *ReferralService) payReward(ctx context.Context, rw *model.ReferralReward) (bool, error) {
+	if s.riskChecker != nil {
+		blocked, err := s.riskChecker.CheckReferralFraud(ctx, rw.ReferrerID, rw.RefereeID)
+		if err != nil {
+			return false, fmt.Errorf("check referral fraud: %w", err)
+		}
+		if blocked {
+			return false, s.blockReward(ctx, rw)
+		}
+	}
+
+	// 奖励以房主经营币种发放
+	owner, err := s.userRepo.GetByID(ctx, rw.OwnerID)
+	if err != nil {
+		return false, err
+	}
+	currency := owner.Currency
+
+	rw.Status = model.ReferralRewardPaid
+	var newBalance, frozen decimal.Decimal
+	var active bool
+	err = repository.Tx(ctx, func(tx pgx.Tx) error {
+		var ownerRecord *model.BalanceTransaction
+		if rw.FundingSource == model.ReferralFundingOwner {
+			// 锁定房主佣金余额
+			commission, err := s.userRepo.GetOwnerRoomBalanceForUpdateTx(ctx, tx, rw.OwnerID)
+			if err != nil {
+				return err
+			}
+			if commission.LessThan(rw.Amount) {
+				return ErrReferralInsufficientFunds
+			}
+			if err := s.userRepo.UpdateOwnerBalancesTx(ctx, tx, rw.OwnerID, "owner_room_balance", rw.Amount.Neg()); err != nil {
+				return fmt.Errorf("deduct owner commission: %w", err)
+			}
+			ownerRecord = &model.BalanceTransaction{
+				UserID:        rw.OwnerID,
+				Type:          model.TxReferralReward,
+				Amount:        rw.Amount.Neg(),
+				BalanceBefore: commission,
+				BalanceAfter:  commission.Sub(rw.Amount),
+				BalanceField:  "owner_room_balance",
+			}
+		} else if err := s.platformRepo.UpdateBalanceTx(ctx, tx, currency, rw.Amount.Neg()); err != nil {
+			return fmt.Errorf("deduct platform balance: %w", err)
+		}
+
+		if err := s.referralRepo.CreateRewardTx(ctx, tx, rw); err != nil {
+			return err
+		}
+		var err error
+		referrer, err := s.walletRepo.GetActiveForUpdateTx(ctx, tx, rw.ReferrerID)
+		if err != nil {
+			return err
+		}
+		frozen = referrer.FrozenBalance
+		newBalance, active, err = s.walletRepo.AdjustTx(ctx, tx, rw.ReferrerID, currency, rw.Amount)
+		if err != nil {
+			return fmt.Errorf("add referral reward: %w", err)
+		}
+
+		remark := fmt.Sprintf("推荐奖励(ID:%d)", rw.ID)
+		records := []*model.BalanceTransaction{{
+			UserID:        rw.ReferrerID,
+			Type:          model.TxReferralReward,
+			Amount:        rw.Amount,
+			BalanceBefore: newBalance.Sub(rw.Amount),
+			BalanceAfter:  newBalance,
+			BalanceField:  walletBalanceField(active, currency),
+			Currency:      currency,
+			Remark:        &remark,
+		}}
+		if ownerRecord != nil {
+			ownerRecord.Remark = &remark
+			records = append(records, ownerRecord)
+		}
+		return s.txRepo.BatchCreateTx(ctx, tx, records)
+	})
+	if err != nil {
+		return false, err
+	}
+
+	if active {
+		s.notifyBalanceUpdate(rw.ReferrerID, newBalance, frozen)
+	}
+
+	s.logger.Info("Referral reward paid",
+		zap.Int64("reward_id", rw.ID),
+		zap.Int64("referrer_id", rw.ReferrerID),
+		zap.Int64("referee_id", rw.RefereeID),
+		zap.String("reward_type", string(rw.RewardType)),
+		zap.String("funding_source", string(rw.FundingSource)),
+		zap.String("amount", rw.Amount.String()))
+
+	return true, nil
+}
+
+// blockReward 记录被拦截的奖励并停止该推荐关系的后续奖励
+func (s *ReferralService) blockReward(ctx context.Context, rw *model.ReferralReward) error {
+	reason := referralBlockReason
+	rw.Status = model.ReferralRewardBlocked
+	rw.BlockReason = &reason
+	err := repository.Tx(ctx, func(tx pgx.Tx) error {
+		if err := s.referralRepo.CreateRewardTx(ctx, tx, rw); err != nil {
+			return err
+		}
+		return s.referralRepo.BlockTx(ctx, tx, rw.RefereeID, reason)
+	})
+	if err != nil {
+		return err
+	}
+
+	s.logger.Warn("Referral reward blocked",
+		zap.Int64("referrer_id", rw.ReferrerID),
+		zap.Int64("referee_id", rw.RefereeID),
+		zap.String("reward_type", string(rw.RewardType)),
+		zap.String("amount", rw.Amount.String()))
+	return nil
+}
+
+// notifyBalanceUpdate 通知推荐人余额更新
+func (s *ReferralService) notifyBalanceUpdate(userID int64, balance, frozenBalance decimal.Decimal) {
+	if s.hub == nil {
+		return
+	}
+	s.hub.SendToUser(userID, &model.WSMessage{
+		Type: model.WSTypeBalanceUpdate,
+		Payload: &model.WSBalanceUpdate{
+			Balance:       balance.String(),
+			FrozenBalance: frozenBalance.String(),
+		},
+	})
+}
+
+// pendingSharePeriods 返回从 earliest 所在自然日起至最近一个已结束自然日的全部日周期（按时间升序）
+// earliest 晚于最近一个已结束自然日时返回空
+func pendingSharePeriods(earliest, now time.Time) [][2]time.Time {
+	_, lastEnd := lastCompletedPeriod(model.SettlementPeriodDaily, now)
+	var periods [][2]time.Time
+	start, end := settlementPeriodContaining(model.SettlementPeriodDaily, earliest)
+	for !end.After(lastEnd) {
+		periods = append(periods, [2]time.Time{start, end})
+		start, end = settlementPeriodContaining(model.SettlementPeriodDaily, end)
+	}
+	return periods
+}
+
+// computeCommissionShare 计算佣金分成金额（向下取整到分，不会超过基数 × 比例）
+func computeCommissionShare(base, rate decimal.Decimal) decimal.Decimal {
+	if !base.IsPositive() || !rate.IsPositive() {
+		return decimal.Zero
+	}
+	return base.Mul(rate).RoundFloor(2)
+}
@@ -0,0 +1,91 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/fiveseconds/server/internal/config"
+	"github.com/fiveseconds/server/internal/model"
+	"github.com/shopspring/decimal"
+)
+
+// TestComputeCommissionShare 测试推荐佣金分成向下取整到分
+func TestComputeCommissionShare(t *testing.T) {
+	tests := []struct {
+		base, rate string
+		want       string
+	}{
+		{"100", "0.1", "10"},
+		{"12.345", "0.1", "1.23"},
+		{"0.99", "0.5", "0.49"},
+		{"0.01", "0.5", "0"},
+		{"1000", "0.0001", "0.1"},
+		{"0", "0.1", "0"},
+		{"-50", "0.1", "0"},
+		{"100", "0", "0"},
+		{"100", "-0.1", "0"},
+	}
+	for _, tt := range tests {
+		got := computeCommissionShare(decimal.RequireFromString(tt.base), decimal.RequireFromString(tt.rate))
+		if !got.Equal(decimal.RequireFromString(tt.want)) {
+			t.Errorf("computeCommissionShare(%s, %s): Expected %s, got %s", tt.base, tt.rate, tt.want, got)
+		}
+	}
+}
+
+// TestPendingSharePeriods 测试佣金分成补发周期：从最早未分成日起逐日覆盖到最近一个已结束自然日
+func TestPendingSharePeriods(t *testing.T) {
+	loc := time.FixedZone("UTC+8", 8*3600)
+	at := func(day, hour int) time.Time { return time.Date(2025, 3, day, hour, 0, 0, 0, loc) }
+	now := at(15, 10)
+
+	tests := []struct {
+		name     string
+		earliest time.Time
+		first    time.Time
+		days     int
+	}{
+		{"earliest today", at(15, 1), time.Time{}, 0},
+		{"earliest yesterday morning", at(14, 9), at(14, 0), 1},
+		{"earliest at yesterday midnight", at(14, 0), at(14, 0), 1},
+		{"earliest late three days ago", at(12, 23), at(12, 0), 3},
+		{"earliest in the previous month", time.Date(2025, 2, 27, 12, 0, 0, 0, loc), time.Date(2025, 2, 27, 0, 0, 0, 0, loc), 16},
+		{"earliest in the future", at(16, 0), time.Time{}, 0},
+	}
+	for _, tt := range tests {
+		periods := pendingSharePeriods(tt.earliest, now)
+		if len(periods) != tt.days {
+			t.Errorf("%s: Expected %d periods, got %d", tt.name, tt.days, len(periods))
+			continue
+		}
+		for i, p := range periods {
+			wantStart := tt.first.AddDate(0, 0, i)
+			if !p[0].Equal(wantStart) || !p[1].Equal(wantStart.AddDate(0, 0, 1)) {
+				t.Errorf("%s: period %d expected to start at %s, got [%s, %s)", tt.name, i, wantStart, p[0], p[1])
+			}
+		}
+		if tt.days > 0 && !periods[tt.days-1][1].Equal(at(15, 0)) {
+			t.Errorf("%s: Expected the last period to end at today's midnight, got %s", tt.name, periods[tt.days-1][1])
+		}
+	}
+}
+
+// TestReferralFundingSource 测试奖励资金来源配置，默认由房主佣金支付
+func TestReferralFundingSource(t *testing.T) {
+	tests := []struct {
+		source string
+		want   model.ReferralFundingSource
+	}{
+		{"platform", model.ReferralFundingPlatform},
+		{"owner", model.ReferralFundingOwner},
+		{"", model.ReferralFundingOwner},
+		{"invalid", model.ReferralFundingOwner},
+	}
+	for _, tt := range tests {
+		cfg := &config.Config{}
+		cfg.Referral.FundingSource = tt.source
+		if got := (&ReferralService{cfg: cfg}).fundingSource(); got != tt.want {
+			t.Errorf("funding source %q: Expected %s, got %s", tt.source, tt.want, got)
+		}
+	}
+}
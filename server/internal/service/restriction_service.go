@@ -0,0 +1,337 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/fiveseconds/server/internal/config"
+	"github.com/fiveseconds/server/internal/model"
+	"github.com/fiveseconds/server/internal/repository"
+
+	"go.uber.org/zap"
+)
+
+// DefaultRestrictionDuration 未配置时人工施加限制的默认时长
+const DefaultRestrictionDuration = 72 * time.Hour
+
+var (
+	ErrAccountRestricted      = errors.New("account restricted")
+	ErrRestrictionNotFound    = errors.New("restriction not found or already ended")
+	ErrInvalidRestrictionType = errors.New("invalid restriction type")
+
+	ErrRestrictionRequiresConfirm = errors.New("restrictions can only be applied when confirming a flag")
+	ErrRestrictionUnavailable     = errors.New("account restrictions are not enabled")
+)
+
+// RestrictedError 操作被账户限制拒绝，携带推送给玩家的原因代码与到期时间
+type RestrictedError struct {
+	Restriction *model.AccountRestriction
+}
+
+func (e *RestrictedError) Error() string {
+	return fmt.Sprintf("account restricted: %s (%s)", e.Restriction.Type, e.Restriction.Reason)
+}
+
+func (e *RestrictedError) Unwrap() error {
+	return ErrAccountRestricted
+}
+
+// RestrictionNotifier 账户限制变更通知（WebSocket 私发）
+type RestrictionNotifier interface {
+	SendToUser(userID int64, msg *model.WSMessage)
+}
+
+// RestrictionService 账户限制
+// 限制类型：禁止下注、禁止提现、禁言、全面封停；每条限制都有到期时间，到期后自动失效。
+// 风控规则触发时按规则严重程度自动施加（见 restriction.auto 配置），风控标记确认时可由管理员施加
+type RestrictionService struct {
+	repo     *repository.RestrictionRepo
+	notifier RestrictionNotifier
+	cfg      *config.Config
+	logger   *zap.Logger
+}
+
+// NewRestrictionService 创建账户限制服务
+func NewRestrictionService(repo *repository.RestrictionRepo, cfg *config.Config, logger *zap.Logger) *RestrictionService {
+	return &RestrictionService{
+		repo:   repo,
+		cfg:    cfg,
+		logger: logger.With(zap.String("service", "restriction")),
+	}
+}
+
+// SetNotifier 设置限制变更通知（施加、解除、到期时推送给玩家）
+func (s *RestrictionService) SetNotifier(notifier RestrictionNotifier) {
+	s.notifier = notifier
+}
+
+// defaultDuration 人工施加限制未指定时长时的默认时长
+func (s *RestrictionService) defaultDuration() time.Duration {
+	if s.cfg.Restriction.DefaultHours > 0 {
+		return time.Duration(s.cfg.Restriction.DefaultHours) * time.Hour
+	}
+	return DefaultRestrictionDuration
+}
+
+// restrictionPlan 待施加的限制
+type restrictionPlan struct {
+	Type     model.RestrictionType
+	Duration time.Duration
+}
+
+// autoRestrictionPlans 按规则严重程度匹配自动限制配置；同一类型出现多次时取最长时长，结果按类型排序
+func autoRestrictionPlans(auto []config.AutoRestrictionConfig, severity model.RiskSeverity) []restrictionPlan {
+	durations := make(map[model.RestrictionType]time.Duration)
+	for _, a := range auto {
+		if model.RiskSeverity(a.Severity) != severity || a.Hours <= 0 {
+			continue
+		}
+		for _, t := range a.Types {
+			rt := model.RestrictionType(t)
+			if !rt.Valid() {
+				continue
+			}
+			if d := time.Duration(a.Hours) * time.Hour; d > durations[rt] {
+				durations[rt] = d
+			}
+		}
+	}
+
+	plans := make([]restrictionPlan, 0, len(durations))
+	for t, d := range durations {
+		plans = append(plans, restrictionPlan{Type: t, Duration: d})
+	}
+	sort.Slice(plans, func(i, j int) bool { return plans[i].Type < plans[j].Type })
+	return plans
+}
+
+// restrictionCovered 已有同类型且不早于 expiresAt 到期的生效限制时无需重复施加
+func restrictionCovered(active []*model.AccountRestriction, t model.RestrictionType, expiresAt, at time.Time) bool {
+	for _, r := range active {
+		if r.Type == t && r.Status == model.RestrictionStatusActive && r.ExpiresAt.After(at) && !r.ExpiresAt.Before(expiresAt) {
+			return true
+		}
+	}
+	return false
+}
+
+// blockingRestriction 在 at 时刻禁止 action 的生效限制中最晚到期的一条（同时到期取 ID 较小者），没有时返回 nil
+func blockingRestriction(active []*model.AccountRestriction, action model.RestrictionType, at time.Time) *model.AccountRestriction {
+	var blocking *model.AccountRestriction
+	for _, r := range active {
+		if r.Status != model.RestrictionStatusActive || !r.ExpiresAt.After(at) || !r.Type.Blocks(action) {
+			continue
+		}
+		if blocking == nil || r.ExpiresAt.After(blocking.ExpiresAt) ||
+			(r.ExpiresAt.Equal(blocking.ExpiresAt) && r.ID < blocking.ID) {
+			blocking = r
+		}
+	}
+	return blocking
+}
+
+// apply 逐类型施加限制，已被同类型更长限制覆盖的跳过；返回新施加的限制
+func (s *RestrictionService) apply(ctx context.Context, template model.AccountRestriction, plans []restrictionPlan) ([]*model.AccountRestriction, error) {
+	if len(plans) == 0 {
+		return nil, nil
+	}
+	now := time.Now()
+	active, err := s.repo.ListActiveByUsers(ctx, []int64{template.UserID}, now)
+	if err != nil {
+		return nil, err
+	}
+
+	applied := []*model.AccountRestriction{}
+	for _, plan := range plans {
+		if !plan.Type.Valid() {
+			return applied, ErrInvalidRestrictionType
+		}
+		expiresAt := now.Add(plan.Duration)
+		// 规则重复触发不续期自动限制：同类型限制仍生效即跳过
+		coveredUntil := expiresAt
+		if template.Source == model.RestrictionSourceAuto {
+			coveredUntil = now
+		}
+		if restrictionCovered(active, plan.Type, coveredUntil, now) {
+			continue
+		}
+		restriction := template
+		restriction.Type = plan.Type
+		restriction.ExpiresAt = expiresAt
+		if err := s.repo.Create(ctx, &restriction); err != nil {
+			return applied, err
+		}
+		applied = append(applied, &restriction)
+		s.notify(&restriction, true)
+
+		s.logger.Warn("Account restriction applied",
+			zap.Int64("user_id", restriction.UserID),
+			zap.String("type", string(restriction.Type)),
+			zap.String("source", string(restriction.Source)),
+			zap.String("reason", restriction.Reason),
+			zap.Time("expires_at", restriction.ExpiresAt))
+	}
+	return applied, nil
+}
+
+// manualPlans 人工施加的限制（hours 为 0 时使用默认时长，重复类型只施加一次）
+func (s *RestrictionService) manualPlans(types []model.RestrictionType, hours int) []restrictionPlan {
+	duration := s.defaultDuration()
+	if hours > 0 {
+		duration = time.Duration(hours) * time.Hour
+	}
+	seen := make(map[model.RestrictionType]bool, len(types))
+	plans := make([]restrictionPlan, 0, len(types))
+	for _, t := range types {
+		if seen[t] {
+			continue
+		}
+		seen[t] = true
+		plans = append(plans, restrictionPlan{Type: t, Duration: duration})
+	}
+	return plans
+}
+
+// ApplyForRuleHit 风控规则触发后按规则严重程度自动施加限制
+func (s *RestrictionService) ApplyForRuleHit(ctx context.Context, userID int64, rule *model.RiskRule, flagID *int64) ([]*model.AccountRestriction, error) {
+	ruleID := rule.ID
+	return s.apply(ctx, model.AccountRestriction{
+		UserID: userID,
+		Reason: model.RestrictionReasonRiskControl,
+		Source: model.RestrictionSourceAuto,
+		FlagID: flagID,
+		RuleID: &ruleID,
+		Remark: fmt.Sprintf("rule %q v%d (%s)", rule.Name, rule.Version, rule.Severity),
+	}, autoRestrictionPlans(s.cfg.Restriction.Auto, rule.Severity))
+}
+
+// ApplyForConfirmedFlag 风控标记确认时施加限制
+func (s *RestrictionService) ApplyForConfirmedFlag(ctx context.Context, flag *model.RiskFlag, types []model.RestrictionType, hours int, operatorID int64, remark string) ([]*model.AccountRestriction, error) {
+	flagID := flag.ID
+	return s.apply(ctx, model.AccountRestriction{
+		UserID:    flag.UserID,
+		Reason:    model.RestrictionReasonRiskReview,
+		Source:    model.RestrictionSourceManual,
+		FlagID:    &flagID,
+		RuleID:    flag.RuleID,
+		Remark:    remark,
+		CreatedBy: &operatorID,
+	}, s.manualPlans(types, hours))
+}
+
+// ApplyManual 管理员直接施加限制（关联风控标记时原因代码为审核确认）
+func (s *RestrictionService) ApplyManual(ctx context.Context, userID int64, req *model.ApplyRestrictionReq, operatorID int64) ([]*model.AccountRestriction, error) {
+	reason := model.RestrictionReasonAdminAction
+	if req.FlagID != nil {
+		reason = model.RestrictionReasonRiskReview
+	}
+	return s.apply(ctx, model.AccountRestriction{
+		UserID:    userID,
+		Reason:    reason,
+		Source:    model.RestrictionSourceManual,
+		FlagID:    req.FlagID,
+		Remark:    req.Remark,
+		CreatedBy: &operatorID,
+	}, s.manualPlans(req.Types, req.Hours))
+}
+
+// Revoke 解除限制
+func (s *RestrictionService) Revoke(ctx context.Context, id, operatorID int64, remark string) (*model.AccountRestriction, error) {
+	restriction, err := s.repo.Revoke(ctx, id, operatorID, remark)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrRestrictionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	s.notify(restriction, false)
+	s.logger.Info("Account restriction revoked",
+		zap.Int64("restriction_id", id),
+		zap.Int64("user_id", restriction.UserID),
+		zap.Int64("operator_id", operatorID))
+	return restriction, nil
+}
+
+// ExpireDue 处理已到期的限制（写审计记录并通知玩家），返回处理条数
+// 到期判断在检查时按 expires_at 即时生效，这里只负责状态收尾
+func (s *RestrictionService) ExpireDue(ctx context.Context, now time.Time) (int, error) {
+	expired, err := s.repo.ExpireDue(ctx, now)
+	if err != nil {
+		return 0, err
+	}
+	for _, r := range expired {
+		s.notify(r, false)
+	}
+	return len(expired), nil
+}
+
+// Check 检查用户当前是否被禁止 action 对应的操作，被禁止时返回 *RestrictedError
+func (s *RestrictionService) Check(ctx context.Context, userID int64, action model.RestrictionType) error {
+	now := time.Now()
+	active, err := s.repo.ListActiveByUsers(ctx, []int64{userID}, now)
+	if err != nil {
+		return err
+	}
+	if r := blockingRestriction(active, action, now); r != nil {
+		return &RestrictedError{Restriction: r}
+	}
+	return nil
+}
+
+// BettingRestrictions 批量检查禁止下注的玩家，返回 用户ID -> 原因代码
+func (s *RestrictionService) BettingRestrictions(ctx context.Context, userIDs []int64) (map[int64]string, error) {
+	if len(userIDs) == 0 {
+		return nil, nil
+	}
+	now := time.Now()
+	active, err := s.repo.ListActiveByUsers(ctx, userIDs, now)
+	if err != nil {
+		return nil, err
+	}
+	byUser := make(map[int64][]*model.AccountRestriction)
+	for _, r := range active {
+		byUser[r.UserID] = append(byUser[r.UserID], r)
+	}
+	blocked := make(map[int64]string)
+	for userID, restrictions := range byUser {
+		if r := blockingRestriction(restrictions, model.RestrictionNoBetting, now); r != nil {
+			blocked[userID] = r.Reason
+		}
+	}
+	return blocked, nil
+}
+
+// ListActive 获取用户当前生效的限制
+func (s *RestrictionService) ListActive(ctx context.Context, userID int64) ([]*model.AccountRestriction, error) {
+	return s.repo.ListActiveByUsers(ctx, []int64{userID}, time.Now())
+}
+
+// ListByUser 获取用户的全部限制
+func (s *RestrictionService) ListByUser(ctx context.Context, userID int64) ([]*model.AccountRestriction, error) {
+	return s.repo.ListByUser(ctx, userID)
+}
+
+// ListAudit 分页获取用户的限制审计记录
+func (s *RestrictionService) ListAudit(ctx context.Context, userID int64, query *model.RestrictionAuditQuery) ([]*model.RestrictionAuditEntry, int64, error) {
+	return s.repo.ListAudit(ctx, userID, query)
+}
+
+// notify 推送限制变更给玩家
+func (s *RestrictionService) notify(r *model.AccountRestriction, active bool) {
+	if s.notifier == nil {
+		return
+	}
+	s.notifier.SendToUser(r.UserID, &model.WSMessage{
+		Type: model.WSTypeAccountRestriction,
+		Payload: &model.WSAccountRestriction{
+			RestrictionID: r.ID,
+			Type:          string(r.Type),
+			ReasonCode:    r.Reason,
+			Active:        active,
+			ExpiresAt:     r.ExpiresAt.UnixMilli(),
+		},
+	})
+}
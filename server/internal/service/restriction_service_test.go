@@ -0,0 +1,132 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/fiveseconds/server/internal/config"
+	"github.com/fiveseconds/server/internal/model"
+)
+
+// TestRestrictionBlocks 测试全面封停禁止一切受限操作，其他限制只禁止对应操作
+func TestRestrictionBlocks(t *testing.T) {
+	types := []model.RestrictionType{
+		model.RestrictionNoBetting, model.RestrictionNoWithdrawal, model.RestrictionChatMuted, model.RestrictionSuspended,
+	}
+	for _, r := range types {
+		for _, action := range types {
+			want := r == action || r == model.RestrictionSuspended
+			if got := r.Blocks(action); got != want {
+				t.Errorf("%s blocks %s: Expected %v, got %v", r, action, want, got)
+			}
+		}
+	}
+}
+
+// TestBlockingRestriction 测试拦截时返回最晚到期的生效限制，已解除、已过期或到期的限制不再生效
+func TestBlockingRestriction(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	restriction := func(id int64, rt model.RestrictionType, status model.RestrictionStatus, expiresIn time.Duration) *model.AccountRestriction {
+		return &model.AccountRestriction{ID: id, Type: rt, Status: status, ExpiresAt: now.Add(expiresIn)}
+	}
+	active := model.RestrictionStatusActive
+	tests := []struct {
+		name         string
+		restrictions []*model.AccountRestriction
+		action       model.RestrictionType
+		at           time.Time
+		want         int64 // 0 表示不拦截
+	}{
+		{"no restrictions", nil, model.RestrictionNoBetting, now, 0},
+		{"same type blocks", []*model.AccountRestriction{
+			restriction(1, model.RestrictionNoBetting, active, time.Hour),
+		}, model.RestrictionNoBetting, now, 1},
+		{"other type does not block", []*model.AccountRestriction{
+			restriction(1, model.RestrictionChatMuted, active, time.Hour),
+		}, model.RestrictionNoWithdrawal, now, 0},
+		{"suspension blocks everything", []*model.AccountRestriction{
+			restriction(1, model.RestrictionSuspended, active, time.Hour),
+		}, model.RestrictionChatMuted, now, 1},
+		{"latest expiry wins", []*model.AccountRestriction{
+			restriction(1, model.RestrictionNoBetting, active, time.Hour),
+			restriction(2, model.RestrictionSuspended, active, 48*time.Hour),
+			restriction(3, model.RestrictionNoBetting, active, 24*time.Hour),
+		}, model.RestrictionNoBetting, now, 2},
+		{"same expiry keeps the smaller id", []*model.AccountRestriction{
+			restriction(5, model.RestrictionNoBetting, active, time.Hour),
+			restriction(4, model.RestrictionSuspended, active, time.Hour),
+		}, model.RestrictionNoBetting, now, 4},
+		{"revoked and expired ignored", []*model.AccountRestriction{
+			restriction(1, model.RestrictionNoBetting, model.RestrictionStatusRevoked, 48*time.Hour),
+			restriction(2, model.RestrictionNoBetting, model.RestrictionStatusExpired, 48*time.Hour),
+			restriction(3, model.RestrictionNoBetting, active, time.Hour),
+		}, model.RestrictionNoBetting, now, 3},
+		{"past expiry not yet marked", []*model.AccountRestriction{
+			restriction(1, model.RestrictionSuspended, active, -time.Minute),
+		}, model.RestrictionNoBetting, now, 0},
+		{"stops blocking at the expiry time", []*model.AccountRestriction{
+			restriction(1, model.RestrictionSuspended, active, time.Hour),
+		}, model.RestrictionNoBetting, now.Add(time.Hour), 0},
+	}
+	for _, tt := range tests {
+		got := blockingRestriction(tt.restrictions, tt.action, tt.at)
+		var gotID int64
+		if got != nil {
+			gotID = got.ID
+		}
+		if gotID != tt.want {
+			t.Errorf("%s: Expected restriction %d, got %d", tt.name, tt.want, gotID)
+		}
+	}
+}
+
+// TestAutoRestrictionPlans 测试自动限制按严重程度匹配，忽略无效类型与非正时长，同类型取最长时长并按类型排序
+func TestAutoRestrictionPlans(t *testing.T) {
+	auto := []config.AutoRestrictionConfig{
+		{Severity: "high", Types: []string{"no_withdrawal", "chat_muted"}, Hours: 24},
+		{Severity: "high", Types: []string{"no_withdrawal", "bogus"}, Hours: 72},
+		{Severity: "high", Types: []string{"suspended"}, Hours: 0},
+		{Severity: "critical", Types: []string{"suspended"}, Hours: 168},
+		{Severity: "medium", Types: []string{"no_betting"}, Hours: -1},
+	}
+	tests := []struct {
+		severity model.RiskSeverity
+		want     []restrictionPlan
+	}{
+		{model.RiskSeverityHigh, []restrictionPlan{
+			{model.RestrictionChatMuted, 24 * time.Hour},
+			{model.RestrictionNoWithdrawal, 72 * time.Hour},
+		}},
+		{model.RiskSeverityCritical, []restrictionPlan{{model.RestrictionSuspended, 168 * time.Hour}}},
+		{model.RiskSeverityMedium, []restrictionPlan{}},
+		{model.RiskSeverityLow, []restrictionPlan{}},
+	}
+	for _, tt := range tests {
+		if got := autoRestrictionPlans(auto, tt.severity); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: Expected %v, got %v", tt.severity, tt.want, got)
+		}
+	}
+}
+
+// TestRestrictionCovered 测试只有同类型、生效中且不早于新限制到期的限制才覆盖新限制
+func TestRestrictionCovered(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	expiresAt := now.Add(24 * time.Hour)
+	tests := []struct {
+		name     string
+		existing *model.AccountRestriction
+		want     bool
+	}{
+		{"longer same type", &model.AccountRestriction{Type: model.RestrictionNoBetting, Status: model.RestrictionStatusActive, ExpiresAt: now.Add(48 * time.Hour)}, true},
+		{"same expiry", &model.AccountRestriction{Type: model.RestrictionNoBetting, Status: model.RestrictionStatusActive, ExpiresAt: expiresAt}, true},
+		{"shorter same type", &model.AccountRestriction{Type: model.RestrictionNoBetting, Status: model.RestrictionStatusActive, ExpiresAt: now.Add(time.Hour)}, false},
+		{"suspension is a different type", &model.AccountRestriction{Type: model.RestrictionSuspended, Status: model.RestrictionStatusActive, ExpiresAt: now.Add(48 * time.Hour)}, false},
+		{"revoked", &model.AccountRestriction{Type: model.RestrictionNoBetting, Status: model.RestrictionStatusRevoked, ExpiresAt: now.Add(48 * time.Hour)}, false},
+	}
+	for _, tt := range tests {
+		if got := restrictionCovered([]*model.AccountRestriction{tt.existing}, model.RestrictionNoBetting, expiresAt, now); got != tt.want {
+			t.Errorf("%s: Expected %v, got %v", tt.name, tt.want, got)
+		}
+	}
+}
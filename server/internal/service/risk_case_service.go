@@ -0,0 +1,345 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+
+	"github.com/fiveseconds/server/internal/model"
+	"github.com/fiveseconds/server/internal/repository"
+
+	"go.uber.org/zap"
+)
+
+var (
+	ErrRiskCaseNotFound           = errors.New("risk case not found")
+	ErrRiskCaseFlagNotFound       = errors.New("risk flag not found")
+	ErrRiskCaseFlagLinked         = errors.New("flag already belongs to a case")
+	ErrRiskCaseInvalidTransition  = errors.New("invalid case status transition")
+	ErrRiskCaseResolutionRequired = errors.New("resolution is required to resolve a case")
+	ErrRiskCaseResolved           = errors.New("case is resolved, reopen it first")
+	ErrRiskCaseInvalidAssignee    = errors.New("assignee must be an admin")
+	ErrRiskCaseNoteNotFound       = errors.New("parent note not found in this case")
+	ErrRiskCaseEvidenceNotFound   = errors.New("evidence record not found or unrelated to case users")
+	ErrRiskCaseEvidenceAttached   = errors.New("evidence already attached to case")
+	ErrRiskCaseConflict           = errors.New("case was modified concurrently, please retry")
+)
+
+// riskCaseTransitions 案件状态流转：open -> investigating -> resolved，调查中可退回 open，已结案可重新打开
+var riskCaseTransitions = map[model.RiskCaseStatus][]model.RiskCaseStatus{
+	model.RiskCaseStatusOpen:          {model.RiskCaseStatusInvestigating, model.RiskCaseStatusResolved},
+	model.RiskCaseStatusInvestigating: {model.RiskCaseStatusOpen, model.RiskCaseStatusResolved},
+	model.RiskCaseStatusResolved:      {model.RiskCaseStatusOpen},
+}
+
+// riskCaseTransitionAllowed 案件状态能否从 from 变更为 to
+func riskCaseTransitionAllowed(from, to model.RiskCaseStatus) bool {
+	for _, next := range riskCaseTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// uniqueIDs 去重并保持原有顺序
+func uniqueIDs(ids []int64) []int64 {
+	seen := make(map[int64]bool, len(ids))
+	result := make([]int64, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			result = append(result, id)
+		}
+	}
+	return result
+}
+
+// mergeCaseUsers 合并案件已有用户与标记所属用户（升序、去重）
+func mergeCaseUsers(existing []int64, flags []*model.RiskFlag) []int64 {
+	ids := append([]int64{}, existing...)
+	for _, f := range flags {
+		ids = append(ids, f.UserID)
+	}
+	ids = uniqueIDs(ids)
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+// RiskCaseService 风控案件
+// 案件归集同一用户或同一关联簇的风控标记（每个标记最多属于一个案件），可指派处理人，
+// 按 open -> investigating -> resolved 流转，支持线程式备注与证据快照；所有变更写入案件审计
+type RiskCaseService struct {
+	repo     *repository.RiskCaseRepo
+	riskRepo *repository.RiskRepo
+	userRepo *repository.UserRepo
+	logger   *zap.Logger
+}
+
+// NewRiskCaseService 创建风控案件服务
+func NewRiskCaseService(repo *repository.RiskCaseRepo, riskRepo *repository.RiskRepo, userRepo *repository.UserRepo, logger *zap.Logger) *RiskCaseService {
+	return &RiskCaseService{
+		repo:     repo,
+		riskRepo: riskRepo,
+		userRepo: userRepo,
+		logger:   logger.With(zap.String("service", "risk_case")),
+	}
+}
+
+// getCase 获取案件
+func (s *RiskCaseService) getCase(ctx context.Context, caseID int64) (*model.RiskCase, error) {
+	rc, err := s.repo.GetByID(ctx, caseID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrRiskCaseNotFound
+	}
+	return rc, err
+}
+
+// loadFlags 读取待加入案件的标记
+func (s *RiskCaseService) loadFlags(ctx context.Context, flagIDs []int64) ([]*model.RiskFlag, error) {
+	flags := make([]*model.RiskFlag, 0, len(flagIDs))
+	for _, id := range flagIDs {
+		flag, err := s.riskRepo.GetFlagByID(ctx, id)
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrRiskCaseFlagNotFound
+		}
+		if err != nil {
+			return nil, err
+		}
+		flags = append(flags, flag)
+	}
+	return flags, nil
+}
+
+// checkAssignee 处理人必须是管理员
+func (s *RiskCaseService) checkAssignee(ctx context.Context, assigneeID *int64) error {
+	if assigneeID == nil {
+		return nil
+	}
+	user, err := s.userRepo.GetByID(ctx, *assigneeID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return ErrRiskCaseInvalidAssignee
+	}
+	if err != nil {
+		return err
+	}
+	if user.Role != model.RoleAdmin {
+		return ErrRiskCaseInvalidAssignee
+	}
+	return nil
+}
+
+// mapRiskCaseError 转换仓库层的案件错误
+func mapRiskCaseError(err error) error {
+	switch {
+	case errors.Is(err, repository.ErrRiskCaseFlagLinked):
+		return ErrRiskCaseFlagLinked
+	case errors.Is(err, repository.ErrRiskCaseConflict):
+		return ErrRiskCaseConflict
+	case errors.Is(err, repository.ErrRiskCaseEvidenceAttached):
+		return ErrRiskCaseEvidenceAttached
+	}
+	return err
+}
+
+// Create 创建案件，涉及的用户为所选标记的用户
+func (s *RiskCaseService) Create(ctx context.Context, req *model.CreateRiskCaseReq, operatorID int64) (*model.RiskCase, error) {
+	flagIDs := uniqueIDs(req.FlagIDs)
+	flags, err := s.loadFlags(ctx, flagIDs)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkAssignee(ctx, req.AssigneeID); err != nil {
+		return nil, err
+	}
+
+	rc := &model.RiskCase{
+		Title:      strings.TrimSpace(req.Title),
+		UserIDs:    mergeCaseUsers(nil, flags),
+		AssigneeID: req.AssigneeID,
+		CreatedBy:  operatorID,
+	}
+	if err := s.repo.Create(ctx, rc, flagIDs, strings.TrimSpace(req.Note)); err != nil {
+		return nil, mapRiskCaseError(err)
+	}
+	s.logger.Info("Risk case created",
+		zap.Int64("case_id", rc.ID),
+		zap.Int64s("flag_ids", flagIDs),
+		zap.Int64s("user_ids", rc.UserIDs),
+		zap.Int64("operator_id", operatorID))
+	return rc, nil
+}
+
+// AddFlags 向未结案的案件追加标记
+func (s *RiskCaseService) AddFlags(ctx context.Context, caseID int64, req *model.AddRiskCaseFlagsReq, operatorID int64) (*model.RiskCase, error) {
+	rc, err := s.getCase(ctx, caseID)
+	if err != nil {
+		return nil, err
+	}
+	if rc.Status == model.RiskCaseStatusResolved {
+		return nil, ErrRiskCaseResolved
+	}
+	flagIDs := uniqueIDs(req.FlagIDs)
+	flags, err := s.loadFlags(ctx, flagIDs)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.AddFlags(ctx, caseID, flagIDs, mergeCaseUsers(rc.UserIDs, flags), operatorID); err != nil {
+		return nil, mapRiskCaseError(err)
+	}
+	return s.getCase(ctx, caseID)
+}
+
+// Assign 指派（AssigneeID 为空时取消指派）未结案案件的处理人
+func (s *RiskCaseService) Assign(ctx context.Context, caseID int64, req *model.AssignRiskCaseReq, operatorID int64) (*model.RiskCase, error) {
+	rc, err := s.getCase(ctx, caseID)
+	if err != nil {
+		return nil, err
+	}
+	if rc.Status == model.RiskCaseStatusResolved {
+		return nil, ErrRiskCaseResolved
+	}
+	if err := s.checkAssignee(ctx, req.AssigneeID); err != nil {
+		return nil, err
+	}
+	if sameAssignee(rc.AssigneeID, req.AssigneeID) {
+		return rc, nil
+	}
+	if err := s.repo.SetAssignee(ctx, caseID, rc.AssigneeID, req.AssigneeID, operatorID); err != nil {
+		return nil, mapRiskCaseError(err)
+	}
+	return s.getCase(ctx, caseID)
+}
+
+// sameAssignee 两个处理人是否相同（均为空视为相同）
+func sameAssignee(a, b *int64) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
+
+// UpdateStatus 变更案件状态，结案须填写结论
+func (s *RiskCaseService) UpdateStatus(ctx context.Context, caseID int64, req *model.UpdateRiskCaseStatusReq, operatorID int64) (*model.RiskCase, error) {
+	rc, err := s.getCase(ctx, caseID)
+	if err != nil {
+		return nil, err
+	}
+	if !riskCaseTransitionAllowed(rc.Status, req.Status) {
+		return nil, ErrRiskCaseInvalidTransition
+	}
+	resolution := ""
+	if req.Status == model.RiskCaseStatusResolved {
+		resolution = strings.TrimSpace(req.Resolution)
+		if resolution == "" {
+			return nil, ErrRiskCaseResolutionRequired
+		}
+	}
+	if err := s.repo.UpdateStatus(ctx, caseID, rc.Status, req.Status, resolution, operatorID); err != nil {
+		return nil, mapRiskCaseError(err)
+	}
+	s.logger.Info("Risk case status changed",
+		zap.Int64("case_id", caseID),
+		zap.String("from", string(rc.Status)),
+		zap.String("to", string(req.Status)),
+		zap.Int64("operator_id", operatorID))
+	return s.getCase(ctx, caseID)
+}
+
+// AddNote 添加案件备注（可回复同一案件的某条备注）
+func (s *RiskCaseService) AddNote(ctx context.Context, caseID int64, req *model.AddRiskCaseNoteReq, operatorID int64) (*model.RiskCaseNote, error) {
+	if _, err := s.getCase(ctx, caseID); err != nil {
+		return nil, err
+	}
+	note := &model.RiskCaseNote{
+		CaseID:   caseID,
+		ParentID: req.ParentID,
+		AuthorID: operatorID,
+		Body:     strings.TrimSpace(req.Body),
+	}
+	if err := s.repo.AddNote(ctx, note); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrRiskCaseNoteNotFound
+		}
+		return nil, err
+	}
+	return note, nil
+}
+
+// AddEvidence 为未结案的案件添加证据，保存记录当前的快照（记录须与案件用户相关）
+func (s *RiskCaseService) AddEvidence(ctx context.Context, caseID int64, req *model.AddRiskCaseEvidenceReq, operatorID int64) (*model.RiskCaseEvidence, error) {
+	rc, err := s.getCase(ctx, caseID)
+	if err != nil {
+		return nil, err
+	}
+	if rc.Status == model.RiskCaseStatusResolved {
+		return nil, ErrRiskCaseResolved
+	}
+	ev := &model.RiskCaseEvidence{
+		CaseID:  caseID,
+		Type:    req.Type,
+		RefID:   req.RefID,
+		Remark:  strings.TrimSpace(req.Remark),
+		AddedBy: operatorID,
+	}
+	if err := s.repo.AddEvidence(ctx, ev, rc.UserIDs); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrRiskCaseEvidenceNotFound
+		}
+		return nil, mapRiskCaseError(err)
+	}
+	return ev, nil
+}
+
+// OnFlagReviewed 案件内的风控标记被审核时写入案件审计（标记未归入案件时忽略）
+func (s *RiskCaseService) OnFlagReviewed(ctx context.Context, flag *model.RiskFlag, reviewedBy int64, remark string) {
+	rc, err := s.repo.GetByFlag(ctx, flag.ID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return
+	}
+	if err == nil {
+		err = s.repo.AddAudit(ctx, rc.ID, model.RiskCaseAuditFlagReviewed, &reviewedBy, map[string]interface{}{
+			"flag_id": flag.ID,
+			"status":  flag.Status,
+			"remark":  remark,
+		})
+	}
+	if err != nil {
+		s.logger.Error("Failed to audit flag review on case",
+			zap.Int64("flag_id", flag.ID),
+			zap.Error(err))
+	}
+}
+
+// Get 获取案件详情（标记、备注、证据）
+func (s *RiskCaseService) Get(ctx context.Context, caseID int64) (*model.RiskCaseDetail, error) {
+	rc, err := s.getCase(ctx, caseID)
+	if err != nil {
+		return nil, err
+	}
+	detail := &model.RiskCaseDetail{RiskCase: rc}
+	if detail.Flags, err = s.repo.ListFlags(ctx, caseID); err != nil {
+		return nil, err
+	}
+	if detail.Notes, err = s.repo.ListNotes(ctx, caseID); err != nil {
+		return nil, err
+	}
+	if detail.Evidence, err = s.repo.ListEvidence(ctx, caseID); err != nil {
+		return nil, err
+	}
+	return detail, nil
+}
+
+// List 列表案件
+func (s *RiskCaseService) List(ctx context.Context, query *model.RiskCaseListQuery) ([]*model.RiskCase, int64, error) {
+	return s.repo.List(ctx, query)
+}
+
+// ListAudit 获取案件审计记录
+func (s *RiskCaseService) ListAudit(ctx context.Context, caseID int64, query *model.RiskCaseAuditQuery) ([]*model.RiskCaseAuditEntry, int64, error) {
+	if _, err := s.getCase(ctx, caseID); err != nil {
+		return nil, 0, err
+	}
+	return s.repo.ListAudit(ctx, caseID, query)
+}
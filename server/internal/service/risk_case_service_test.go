@@ -0,0 +1,82 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/fiveseconds/server/internal/model"
+)
+
+// TestRiskCaseTransitionAllowed 测试案件状态按 open -> investigating -> resolved 流转，调查中可退回，已结案只能重新打开
+func TestRiskCaseTransitionAllowed(t *testing.T) {
+	open, investigating, resolved := model.RiskCaseStatusOpen, model.RiskCaseStatusInvestigating, model.RiskCaseStatusResolved
+	tests := []struct {
+		from, to model.RiskCaseStatus
+		want     bool
+	}{
+		{open, investigating, true},
+		{open, resolved, true},
+		{investigating, open, true},
+		{investigating, resolved, true},
+		{resolved, open, true},
+		{resolved, investigating, false},
+		{open, open, false},
+		{investigating, investigating, false},
+		{resolved, resolved, false},
+		{"unknown", open, false},
+	}
+	for _, tt := range tests {
+		if got := riskCaseTransitionAllowed(tt.from, tt.to); got != tt.want {
+			t.Errorf("Expected %s -> %s allowed=%v, got %v", tt.from, tt.to, tt.want, got)
+		}
+	}
+}
+
+// TestUniqueIDs 测试去重保持首次出现的顺序
+func TestUniqueIDs(t *testing.T) {
+	tests := []struct {
+		name string
+		ids  []int64
+		want []int64
+	}{
+		{"empty", nil, []int64{}},
+		{"no duplicates", []int64{3, 1, 2}, []int64{3, 1, 2}},
+		{"duplicates keep first occurrence", []int64{5, 2, 5, 7, 2, 2}, []int64{5, 2, 7}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := uniqueIDs(tt.ids); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+// TestMergeCaseUsers 测试案件用户为已有用户与标记用户的升序无重复并集
+func TestMergeCaseUsers(t *testing.T) {
+	flags := func(userIDs ...int64) []*model.RiskFlag {
+		result := make([]*model.RiskFlag, 0, len(userIDs))
+		for _, id := range userIDs {
+			result = append(result, &model.RiskFlag{UserID: id})
+		}
+		return result
+	}
+	tests := []struct {
+		name     string
+		existing []int64
+		flags    []*model.RiskFlag
+		want     []int64
+	}{
+		{"new case", nil, flags(9, 4, 9), []int64{4, 9}},
+		{"flags of existing users", []int64{4, 9}, flags(9, 4), []int64{4, 9}},
+		{"cluster grows", []int64{7, 3}, flags(5, 1, 7), []int64{1, 3, 5, 7}},
+		{"no flags", []int64{8, 2}, nil, []int64{2, 8}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := mergeCaseUsers(tt.existing, tt.flags); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,605 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/fiveseconds/server/internal/config"
+	"github.com/fiveseconds/server/internal/model"
+	"github.com/fiveseconds/server/internal/repository"
+	"github.com/fiveseconds/server/pkg/ruleexpr"
+
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrRiskRuleNotFound         = errors.New("risk rule not found")
+	ErrRiskRuleVersionNotFound  = errors.New("risk rule version not found")
+	ErrRiskRuleNameTaken        = errors.New("risk rule name already exists")
+	ErrRiskRuleInvalidEvent     = errors.New("invalid risk rule event")
+	ErrRiskRuleInvalidCondition = errors.New("invalid risk rule condition")
+	ErrRiskRuleDryRunDays       = errors.New("days exceeds the configured dry-run limit")
+)
+
+const (
+	// DefaultRiskRuleReloadInterval 未配置时重新加载规则的间隔
+	DefaultRiskRuleReloadInterval = 30 * time.Second
+	// DefaultRiskRuleMaxDryRunDays 未配置时试运行最多回溯的天数
+	DefaultRiskRuleMaxDryRunDays = 30
+	// riskRuleDryRunHitLimit 试运行最多返回的命中明细条数
+	riskRuleDryRunHitLimit = 200
+)
+
+// riskRuleVariable 规则条件可引用的变量定义
+type riskRuleVariable struct {
+	name string
+	kind ruleexpr.Kind
+	desc string
+}
+
+// riskRuleVariables 各触发事件可引用的变量（试运行需能按历史数据重建同名变量）
+var riskRuleVariables = map[model.RiskRuleEvent][]riskRuleVariable{
+	model.RiskEventRoundSettled: {
+		{"event.is_winner", ruleexpr.KindBool, "本回合是否获胜"},
+		{"event.participants", ruleexpr.KindNumber, "本回合参与人数"},
+		{"user.consecutive_wins", ruleexpr.KindNumber, "计入本回合后的连续获胜次数"},
+		{"user.recent_rounds", ruleexpr.KindNumber, "胜率统计窗口内的回合数（不超过 win_rate_window）"},
+		{"user.recent_win_rate", ruleexpr.KindNumber, "最近 win_rate_window 回合的胜率（0~1）"},
+		{"user.account_age_days", ruleexpr.KindNumber, "注册至今的天数"},
+	},
+	model.RiskEventLogin: {
+		{"event.has_fingerprint", ruleexpr.KindBool, "登录是否携带设备指纹"},
+		{"event.device_accounts", ruleexpr.KindNumber, "使用同一设备指纹的账户数（含本人）"},
+		{"event.new_device", ruleexpr.KindBool, "是否首次以此设备登录成功"},
+		{"user.recent_rounds", ruleexpr.KindNumber, "胜率统计窗口内的回合数（不超过 win_rate_window）"},
+		{"user.recent_win_rate", ruleexpr.KindNumber, "最近 win_rate_window 回合的胜率（0~1）"},
+		{"user.account_age_days", ruleexpr.KindNumber, "注册至今的天数"},
+	},
+	model.RiskEventFundRequest: {
+		{"event.amount", ruleexpr.KindNumber, "申请金额"},
+		{"event.type", ruleexpr.KindString, "申请类型（deposit/withdraw/owner_deposit/owner_withdraw/margin_deposit）"},
+		{"user.daily_volume", ruleexpr.KindNumber, "审批通过后的当日流水（绝对值合计，含本次申请）"},
+		{"user.account_age_days", ruleexpr.KindNumber, "注册至今的天数"},
+	},
+}
+
+// compiledRiskRule 已编译的风控规则
+type compiledRiskRule struct {
+	rule *model.RiskRule
+	expr *ruleexpr.Expr
+}
+
+// RiskRuleService 风控规则引擎
+// 规则存储在数据库中，按触发事件编译后缓存在内存；管理后台修改后立即重新加载，
+// 其他实例由定时任务按 reload_interval_seconds 重新加载，无需重启。
+// 求值时按需查询条件引用到的用户统计，命中结果由 RiskControlService 执行动作（创建标记/告警）
+type RiskRuleService struct {
+	ruleRepo  *repository.RiskRuleRepo
+	riskRepo  *repository.RiskRepo
+	loginRepo *repository.LoginEventRepo
+	cfg       *config.Config
+	logger    *zap.Logger
+
+	mu    sync.RWMutex
+	rules map[model.RiskRuleEvent][]*compiledRiskRule
+}
+
+// NewRiskRuleService 创建风控规则引擎
+func NewRiskRuleService(
+	ruleRepo *repository.RiskRuleRepo,
+	riskRepo *repository.RiskRepo,
+	loginRepo *repository.LoginEventRepo,
+	cfg *config.Config,
+	logger *zap.Logger,
+) *RiskRuleService {
+	return &RiskRuleService{
+		ruleRepo:  ruleRepo,
+		riskRepo:  riskRepo,
+		loginRepo: loginRepo,
+		cfg:       cfg,
+		logger:    logger.With(zap.String("service", "risk_rule")),
+		rules:     make(map[model.RiskRuleEvent][]*compiledRiskRule),
+	}
+}
+
+// ReloadInterval 重新加载规则的间隔
+func (s *RiskRuleService) ReloadInterval() time.Duration {
+	if s.cfg.RiskRules.ReloadIntervalSeconds > 0 {
+		return time.Duration(s.cfg.RiskRules.ReloadIntervalSeconds) * time.Second
+	}
+	return DefaultRiskRuleReloadInterval
+}
+
+// winRateWindow user.recent_win_rate 统计的最近回合数
+func (s *RiskRuleService) winRateWindow() int {
+	if s.cfg.RiskRules.WinRateWindow > 0 {
+		return s.cfg.RiskRules.WinRateWindow
+	}
+	return model.DefaultRiskConfig.WinRateMinRounds
+}
+
+// maxDryRunDays 试运行最多回溯的天数
+func (s *RiskRuleService) maxDryRunDays() int {
+	if s.cfg.RiskRules.MaxDryRunDays > 0 {
+		return s.cfg.RiskRules.MaxDryRunDays
+	}
+	return DefaultRiskRuleMaxDryRunDays
+}
+
+// Reload 从数据库重新加载并编译已启用的规则
+// 单条规则编译失败（如变量已下线）时跳过该规则并记录日志，不影响其他规则
+func (s *RiskRuleService) Reload(ctx context.Context) error {
+	rules, err := s.ruleRepo.ListEnabled(ctx)
+	if err != nil {
+		return err
+	}
+
+	byEvent := make(map[model.RiskRuleEvent][]*compiledRiskRule)
+	for _, rule := range rules {
+		expr, err := compileRiskCondition(rule.Event, rule.Condition)
+		if err != nil {
+			s.logger.Error("Skipping invalid risk rule",
+				zap.Int64("rule_id", rule.ID),
+				zap.Int("version", rule.Version),
+				zap.Error(err))
+			continue
+		}
+		byEvent[rule.Event] = append(byEvent[rule.Event], &compiledRiskRule{rule: rule, expr: expr})
+	}
+
+	s.mu.Lock()
+	s.rules = byEvent
+	s.mu.Unlock()
+	return nil
+}
+
+// reloadAfterChange 规则修改后立即在本实例生效
+func (s *RiskRuleService) reloadAfterChange(ctx context.Context) {
+	if err := s.Reload(ctx); err != nil {
+		s.logger.Error("Failed to reload risk rules", zap.Error(err))
+	}
+}
+
+// Variables 各触发事件可引用的变量
+func (s *RiskRuleService) Variables() map[model.RiskRuleEvent][]model.RiskRuleVariable {
+	result := make(map[model.RiskRuleEvent][]model.RiskRuleVariable, len(riskRuleVariables))
+	for event, vars := range riskRuleVariables {
+		items := make([]model.RiskRuleVariable, 0, len(vars))
+		for _, v := range vars {
+			items = append(items, model.RiskRuleVariable{Name: v.name, Type: v.kind.String(), Description: v.desc})
+		}
+		result[event] = items
+	}
+	return result
+}
+
+// Create 创建风控规则
+func (s *RiskRuleService) Create(ctx context.Context, adminID int64, req *model.SaveRiskRuleReq) (*model.RiskRule, error) {
+	if err := s.validate(ctx, 0, req); err != nil {
+		return nil, err
+	}
+
+	rule := &model.RiskRule{Enabled: req.Enabled == nil || *req.Enabled}
+	applyRiskRuleReq(rule, req)
+	rule.UpdatedBy = &adminID
+	if err := s.ruleRepo.Create(ctx, rule); err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Risk rule created",
+		zap.Int64("rule_id", rule.ID),
+		zap.String("event", string(rule.Event)),
+		zap.String("condition", rule.Condition),
+		zap.Int64("admin_id", adminID))
+	s.reloadAfterChange(ctx)
+	return rule, nil
+}
+
+// Update 修改风控规则（版本号加一，旧版本保留在历史中）
+func (s *RiskRuleService) Update(ctx context.Context, id, adminID int64, req *model.SaveRiskRuleReq) (*model.RiskRule, error) {
+	rule, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.validate(ctx, id, req); err != nil {
+		return nil, err
+	}
+	applyRiskRuleReq(rule, req)
+	if req.Enabled != nil {
+		rule.Enabled = *req.Enabled
+	}
+	rule.UpdatedBy = &adminID
+	if err := s.ruleRepo.Update(ctx, rule); err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Risk rule updated",
+		zap.Int64("rule_id", rule.ID),
+		zap.Int("version", rule.Version),
+		zap.String("condition", rule.Condition),
+		zap.Bool("enabled", rule.Enabled),
+		zap.Int64("admin_id", adminID))
+	s.reloadAfterChange(ctx)
+	return rule, nil
+}
+
+// validate 校验规则条件与名称唯一（id 为修改的规则，新建时为 0）
+func (s *RiskRuleService) validate(ctx context.Context, id int64, req *model.SaveRiskRuleReq) error {
+	if _, err := compileRiskCondition(req.Event, req.Condition); err != nil {
+		return err
+	}
+	taken, err := s.ruleRepo.NameExists(ctx, req.Name, id)
+	if err != nil {
+		return err
+	}
+	if taken {
+		return ErrRiskRuleNameTaken
+	}
+	return nil
+}
+
+// Restore 将规则恢复为指定历史版本的内容（作为新版本保存）
+func (s *RiskRuleService) Restore(ctx context.Context, id int64, version int, adminID int64) (*model.RiskRule, error) {
+	v, err := s.ruleRepo.GetVersion(ctx, id, version)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrRiskRuleVersionNotFound
+		}
+		return nil, err
+	}
+	enabled := v.Enabled
+	return s.Update(ctx, id, adminID, &model.SaveRiskRuleReq{
+		Name:        v.Name,
+		Description: v.Description,
+		Event:       v.Event,
+		Condition:   v.Condition,
+		Severity:    v.Severity,
+		Action:      v.Action,
+		FlagType:    v.FlagType,
+		Enabled:     &enabled,
+	})
+}
+
+// Get 获取风控规则
+func (s *RiskRuleService) Get(ctx context.Context, id int64) (*model.RiskRule, error) {
+	rule, err := s.ruleRepo.GetByID(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrRiskRuleNotFound
+	}
+	return rule, err
+}
+
+// List 分页获取风控规则
+func (s *RiskRuleService) List(ctx context.Context, query *model.RiskRuleListQuery) ([]*model.RiskRule, int64, error) {
+	return s.ruleRepo.List(ctx, query)
+}
+
+// ListVersions 获取规则的历史版本
+func (s *RiskRuleService) ListVersions(ctx context.Context, id int64) ([]*model.RiskRuleVersion, error) {
+	if _, err := s.Get(ctx, id); err != nil {
+		return nil, err
+	}
+	return s.ruleRepo.ListVersions(ctx, id)
+}
+
+// Evaluate 对触发事件求值全部已启用规则，返回命中的规则
+// preset 为事件变量及调用方已知的用户统计，其余用户统计在条件引用时按需查询；
+// 单条规则求值出错（如统计查询失败、除零）时记录日志并跳过
+func (s *RiskRuleService) Evaluate(ctx context.Context, event model.RiskRuleEvent, userID int64, preset ruleexpr.Vars) []*model.RiskRuleHit {
+	s.mu.RLock()
+	rules := s.rules[event]
+	s.mu.RUnlock()
+	if len(rules) == 0 {
+		return nil
+	}
+
+	vars := &liveRiskVars{ctx: ctx, s: s, userID: userID, values: make(ruleexpr.Vars, len(preset))}
+	for name, v := range preset {
+		vars.values[name] = v
+	}
+
+	var hits []*model.RiskRuleHit
+	for _, cr := range rules {
+		fired, err := cr.expr.EvalBool(vars)
+		if err != nil {
+			s.logger.Warn("Risk rule evaluation failed",
+				zap.Int64("rule_id", cr.rule.ID),
+				zap.Int64("user_id", userID),
+				zap.Error(err))
+			continue
+		}
+		if fired {
+			hits = append(hits, &model.RiskRuleHit{Rule: cr.rule, Variables: referencedValues(cr.expr, vars.values)})
+		}
+	}
+	return hits
+}
+
+// DryRun 按历史数据重放最近 N 天的事件并统计规则会命中的情况，不产生标记与告警
+// 回合结算事件的连续获胜与近期胜率从窗口内的回合重建（窗口开始前的历史不计入）；
+// 登录事件按登录历史重放，同设备账户数按截至登录时以该指纹登录成功过的账户统计
+func (s *RiskRuleService) DryRun(ctx context.Context, req *model.RiskRuleDryRunReq) (*model.RiskRuleDryRunResult, error) {
+	if req.Days > s.maxDryRunDays() {
+		return nil, ErrRiskRuleDryRunDays
+	}
+
+	event, condition := req.Event, req.Condition
+	if req.RuleID != nil {
+		rule, err := s.Get(ctx, *req.RuleID)
+		if err != nil {
+			return nil, err
+		}
+		event, condition = rule.Event, rule.Condition
+	}
+	expr, err := compileRiskCondition(event, condition)
+	if err != nil {
+		return nil, err
+	}
+
+	to := time.Now()
+	result := &model.RiskRuleDryRunResult{
+		RuleID:    req.RuleID,
+		Event:     event,
+		Condition: condition,
+		From:      to.AddDate(0, 0, -req.Days),
+		To:        to,
+		Hits:      []*model.RiskRuleDryRunHit{},
+	}
+	users := make(map[int64]bool)
+	evaluate := func(userID int64, at time.Time, refType string, refID int64, vars ruleexpr.Vars) {
+		result.Evaluated++
+		fired, err := expr.EvalBool(vars)
+		if err != nil {
+			result.Errors++
+			return
+		}
+		if !fired {
+			return
+		}
+		result.Fired++
+		users[userID] = true
+		if len(result.Hits) >= riskRuleDryRunHitLimit {
+			result.Truncated = true
+			return
+		}
+		result.Hits = append(result.Hits, &model.RiskRuleDryRunHit{
+			UserID:     userID,
+			OccurredAt: at,
+			RefType:    refType,
+			RefID:      refID,
+			Variables:  referencedValues(expr, vars),
+		})
+	}
+
+	switch event {
+	case model.RiskEventRoundSettled:
+		err = s.replayRounds(ctx, result.From, result.To, evaluate)
+	case model.RiskEventLogin:
+		err = s.replayLogins(ctx, result.From, result.To, evaluate)
+	case model.RiskEventFundRequest:
+		err = s.replayFundRequests(ctx, result.From, result.To, evaluate)
+	default:
+		return nil, ErrRiskRuleInvalidEvent
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	result.Users = len(users)
+	return result, nil
+}
+
+// dryRunEvaluator 试运行中对单个事件求值
+type dryRunEvaluator func(userID int64, at time.Time, refType string, refID int64, vars ruleexpr.Vars)
+
+// replayRounds 按结算顺序重放回合，逐个参与者重建回合结算事件的变量
+func (s *RiskRuleService) replayRounds(ctx context.Context, from, to time.Time, evaluate dryRunEvaluator) error {
+	window := s.winRateWindow()
+	type userState struct {
+		streak int
+		recent []bool
+	}
+	states := make(map[int64]*userState)
+	createdAt := make(map[int64]time.Time)
+
+	return s.ruleRepo.ForEachSettledRound(ctx, from, to, func(round *model.RiskReplayRound) error {
+		var unknown []int64
+		for _, uid := range round.ParticipantIDs {
+			if _, ok := createdAt[uid]; !ok {
+				unknown = append(unknown, uid)
+			}
+		}
+		if len(unknown) > 0 {
+			found, err := s.ruleRepo.GetUsersCreatedAt(ctx, unknown)
+			if err != nil {
+				return err
+			}
+			for _, uid := range unknown {
+				createdAt[uid] = found[uid]
+			}
+		}
+
+		winners := make(map[int64]bool, len(round.WinnerIDs))
+		for _, w := range round.WinnerIDs {
+			winners[w] = true
+		}
+		for _, uid := range round.ParticipantIDs {
+			st := states[uid]
+			if st == nil {
+				st = &userState{}
+				states[uid] = st
+			}
+			isWinner := winners[uid]
+			if isWinner {
+				st.streak++
+			} else {
+				st.streak = 0
+			}
+			st.recent = append(st.recent, isWinner)
+			if len(st.recent) > window {
+				st.recent = st.recent[len(st.recent)-window:]
+			}
+			wins := 0
+			for _, w := range st.recent {
+				if w {
+					wins++
+				}
+			}
+
+			evaluate(uid, round.SettledAt, "round", round.ID, ruleexpr.Vars{
+				"event.is_winner":       ruleexpr.Bool(isWinner),
+				"event.participants":    ruleexpr.Number(float64(len(round.ParticipantIDs))),
+				"user.consecutive_wins": ruleexpr.Number(float64(st.streak)),
+				"user.recent_rounds":    ruleexpr.Number(float64(len(st.recent))),
+				"user.recent_win_rate":  ruleexpr.Number(float64(wins) / float64(len(st.recent))),
+				"user.account_age_days": ruleexpr.Number(accountAgeDays(createdAt[uid], round.SettledAt)),
+			})
+		}
+		return nil
+	})
+}
+
+// replayLogins 重放登录成功事件
+func (s *RiskRuleService) replayLogins(ctx context.Context, from, to time.Time, evaluate dryRunEvaluator) error {
+	return s.loginRepo.ForEachSuccessSince(ctx, from, to, s.winRateWindow(), func(login *model.RiskReplayLogin) error {
+		winRate := 0.0
+		if login.RecentRounds > 0 {
+			winRate = float64(login.RecentWins) / float64(login.RecentRounds)
+		}
+		evaluate(login.UserID, login.CreatedAt, "login", login.ID, ruleexpr.Vars{
+			"event.has_fingerprint": ruleexpr.Bool(login.DeviceFingerprint != ""),
+			"event.device_accounts": ruleexpr.Number(float64(login.DeviceAccounts)),
+			"event.new_device":      ruleexpr.Bool(login.NewDevice),
+			"user.recent_rounds":    ruleexpr.Number(float64(login.RecentRounds)),
+			"user.recent_win_rate":  ruleexpr.Number(winRate),
+			"user.account_age_days": ruleexpr.Number(accountAgeDays(login.AccountCreatedAt, login.CreatedAt)),
+		})
+		return nil
+	})
+}
+
+// replayFundRequests 重放资金申请事件
+func (s *RiskRuleService) replayFundRequests(ctx context.Context, from, to time.Time, evaluate dryRunEvaluator) error {
+	requests, err := s.ruleRepo.ListFundRequestsSince(ctx, from, to)
+	if err != nil {
+		return err
+	}
+	for _, req := range requests {
+		evaluate(req.UserID, req.CreatedAt, "fund_request", req.ID, ruleexpr.Vars{
+			"event.amount":          ruleexpr.Number(req.Amount),
+			"event.type":            ruleexpr.String(string(req.Type)),
+			"user.daily_volume":     ruleexpr.Number(req.DailyVolume),
+			"user.account_age_days": ruleexpr.Number(accountAgeDays(req.AccountCreatedAt, req.CreatedAt)),
+		})
+	}
+	return nil
+}
+
+// liveRiskVars 实时求值的变量解析：事件变量预先给定，用户统计在首次引用时查询并缓存
+type liveRiskVars struct {
+	ctx    context.Context
+	s      *RiskRuleService
+	userID int64
+	values ruleexpr.Vars
+}
+
+// Lookup 实现 ruleexpr.Resolver
+func (v *liveRiskVars) Lookup(name string) (ruleexpr.Value, error) {
+	if val, ok := v.values[name]; ok {
+		return val, nil
+	}
+
+	repo := v.s.riskRepo
+	switch name {
+	case "user.consecutive_wins":
+		wins, err := repo.GetUserConsecutiveWins(v.ctx, v.userID)
+		if err != nil {
+			return ruleexpr.Value{}, err
+		}
+		v.values[name] = ruleexpr.Number(float64(wins))
+	case "user.recent_rounds", "user.recent_win_rate":
+		rate, total, err := repo.GetUserWinRate(v.ctx, v.userID, v.s.winRateWindow())
+		if err != nil {
+			return ruleexpr.Value{}, err
+		}
+		v.values["user.recent_rounds"] = ruleexpr.Number(float64(total))
+		v.values["user.recent_win_rate"] = ruleexpr.Number(rate)
+	case "user.daily_volume":
+		volumeStr, err := repo.GetUserDailyVolume(v.ctx, v.userID)
+		if err != nil {
+			return ruleexpr.Value{}, err
+		}
+		volume, err := decimal.NewFromString(volumeStr)
+		if err != nil {
+			return ruleexpr.Value{}, err
+		}
+		f, _ := volume.Float64()
+		v.values[name] = ruleexpr.Number(f)
+	case "user.account_age_days":
+		createdAt, err := repo.GetUserCreatedAt(v.ctx, v.userID)
+		if err != nil {
+			return ruleexpr.Value{}, err
+		}
+		v.values[name] = ruleexpr.Number(accountAgeDays(createdAt, time.Now()))
+	default:
+		return ruleexpr.Value{}, fmt.Errorf("%w: %s", ruleexpr.ErrUnknownVariable, name)
+	}
+	return v.values[name], nil
+}
+
+// compileRiskCondition 编译规则条件并按触发事件的变量表做类型检查（结果必须为布尔值）
+func compileRiskCondition(event model.RiskRuleEvent, condition string) (*ruleexpr.Expr, error) {
+	vars, ok := riskRuleVariables[event]
+	if !ok {
+		return nil, ErrRiskRuleInvalidEvent
+	}
+	expr, err := ruleexpr.Compile(condition)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrRiskRuleInvalidCondition, err)
+	}
+	kinds := make(map[string]ruleexpr.Kind, len(vars))
+	for _, v := range vars {
+		kinds[v.name] = v.kind
+	}
+	if err := expr.Check(kinds); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrRiskRuleInvalidCondition, err)
+	}
+	return expr, nil
+}
+
+// applyRiskRuleReq 将请求内容写入规则（不含启用状态）
+func applyRiskRuleReq(rule *model.RiskRule, req *model.SaveRiskRuleReq) {
+	rule.Name = req.Name
+	rule.Description = req.Description
+	rule.Event = req.Event
+	rule.Condition = req.Condition
+	rule.Severity = req.Severity
+	rule.Action = req.Action
+	rule.FlagType = req.FlagType
+	if rule.FlagType == "" {
+		rule.FlagType = model.RiskFlagRuleHit
+	}
+}
+
+// referencedValues 条件引用且已取得的变量值（短路未求值的变量不包含在内）
+func referencedValues(expr *ruleexpr.Expr, values ruleexpr.Vars) map[string]interface{} {
+	result := make(map[string]interface{}, len(expr.Vars()))
+	for _, name := range expr.Vars() {
+		if v, ok := values[name]; ok {
+			result[name] = v.Interface()
+		}
+	}
+	return result
+}
+
+// accountAgeDays 注册时间到 at 的天数（含小数）
+func accountAgeDays(createdAt, at time.Time) float64 {
+	if createdAt.IsZero() || at.Before(createdAt) {
+		return 0
+	}
+	return at.Sub(createdAt).Hours() / 24
+}
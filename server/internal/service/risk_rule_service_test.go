@@ -0,0 +1,145 @@
+package service
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fiveseconds/server/internal/model"
+	"github.com/fiveseconds/server/pkg/ruleexpr"
+)
+
+// TestCompileRiskCondition 测试规则条件按触发事件的变量表做类型检查，结果必须为布尔值
+func TestCompileRiskCondition(t *testing.T) {
+	nested := func(depth int) string {
+		return strings.Repeat("(", depth) + "event.amount > 1" + strings.Repeat(")", depth)
+	}
+	tests := []struct {
+		name      string
+		event     model.RiskRuleEvent
+		condition string
+		wantErr   error
+	}{
+		{"fund request threshold", model.RiskEventFundRequest, "event.amount >= 10000 && event.type == 'withdraw'", nil},
+		{"round settled streak", model.RiskEventRoundSettled, "event.is_winner && user.consecutive_wins > 10", nil},
+		{"login device sharing", model.RiskEventLogin, "event.has_fingerprint && event.device_accounts >= 3", nil},
+		{"shared user variable", model.RiskEventLogin, "user.account_age_days < 1", nil},
+		{"maximum nesting", model.RiskEventFundRequest, nested(ruleexpr.MaxDepth), nil},
+		{"variable of another event", model.RiskEventFundRequest, "user.consecutive_wins > 3", ErrRiskRuleInvalidCondition},
+		{"unknown variable", model.RiskEventFundRequest, "unknown.var > 1", ErrRiskRuleInvalidCondition},
+		{"number result", model.RiskEventFundRequest, "event.amount + 1", ErrRiskRuleInvalidCondition},
+		{"string compared with number", model.RiskEventFundRequest, "event.type > 1", ErrRiskRuleInvalidCondition},
+		{"syntax error", model.RiskEventFundRequest, "event.amount >", ErrRiskRuleInvalidCondition},
+		{"empty condition", model.RiskEventFundRequest, "", ErrRiskRuleInvalidCondition},
+		{"nesting too deep", model.RiskEventFundRequest, nested(ruleexpr.MaxDepth + 1), ErrRiskRuleInvalidCondition},
+		{"unknown event", model.RiskRuleEvent("unknown"), "true", ErrRiskRuleInvalidEvent},
+	}
+	for _, tt := range tests {
+		expr, err := compileRiskCondition(tt.event, tt.condition)
+		if !errors.Is(err, tt.wantErr) {
+			t.Errorf("%s: Expected %v, got %v", tt.name, tt.wantErr, err)
+		}
+		if (err == nil) != (expr != nil) {
+			t.Errorf("%s: Expected an expression only without error, got %v and %v", tt.name, expr, err)
+		}
+	}
+}
+
+// TestDefaultRiskRulesMatchFixedChecks 测试 021 迁移预置的规则与原固定阈值检查在边界上等价
+func TestDefaultRiskRulesMatchFixedChecks(t *testing.T) {
+	cfg := model.DefaultRiskConfig
+	compile := func(event model.RiskRuleEvent, condition string) *ruleexpr.Expr {
+		expr, err := compileRiskCondition(event, condition)
+		if err != nil {
+			t.Fatalf("compile %q: %v", condition, err)
+		}
+		return expr
+	}
+	streak := compile(model.RiskEventRoundSettled, "event.is_winner && user.consecutive_wins > 10")
+	winRate := compile(model.RiskEventRoundSettled, "event.is_winner && user.recent_rounds >= 50 && user.recent_win_rate > 0.8")
+	largeTx := compile(model.RiskEventFundRequest, "event.amount >= 10000")
+
+	roundTests := []struct {
+		isWinner bool
+		wins     int
+		rounds   int
+		rate     float64
+	}{
+		{true, cfg.ConsecutiveWinThreshold, 60, 0.5},
+		{true, cfg.ConsecutiveWinThreshold + 1, 60, 0.5},
+		{false, cfg.ConsecutiveWinThreshold + 1, 60, 0.9},
+		{true, 0, cfg.WinRateMinRounds - 1, 0.9},
+		{true, 0, cfg.WinRateMinRounds, cfg.WinRateThreshold},
+		{true, 0, cfg.WinRateMinRounds, 0.82},
+	}
+	for _, tt := range roundTests {
+		vars := ruleexpr.Vars{
+			"event.is_winner":       ruleexpr.Bool(tt.isWinner),
+			"user.consecutive_wins": ruleexpr.Number(float64(tt.wins)),
+			"user.recent_rounds":    ruleexpr.Number(float64(tt.rounds)),
+			"user.recent_win_rate":  ruleexpr.Number(tt.rate),
+		}
+		gotStreak, err := streak.EvalBool(vars)
+		if err != nil {
+			t.Fatal(err)
+		}
+		gotRate, err := winRate.EvalBool(vars)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := tt.isWinner && tt.wins > cfg.ConsecutiveWinThreshold; gotStreak != want {
+			t.Errorf("%+v: Expected streak rule %v, got %v", tt, want, gotStreak)
+		}
+		if want := tt.isWinner && tt.rounds >= cfg.WinRateMinRounds && tt.rate > cfg.WinRateThreshold; gotRate != want {
+			t.Errorf("%+v: Expected win rate rule %v, got %v", tt, want, gotRate)
+		}
+	}
+
+	limit, _ := cfg.LargeTransactionAmount.Float64()
+	for _, amount := range []float64{0, limit - 0.01, limit, limit + 0.01} {
+		got, err := largeTx.EvalBool(ruleexpr.Vars{"event.amount": ruleexpr.Number(amount)})
+		if err != nil || got != (amount >= limit) {
+			t.Errorf("amount %v: Expected large transaction rule %v, got %v (%v)", amount, amount >= limit, got, err)
+		}
+	}
+}
+
+// TestReferencedValues 测试命中明细只包含条件引用且已求值的变量
+func TestReferencedValues(t *testing.T) {
+	expr, err := compileRiskCondition(model.RiskEventFundRequest, "event.amount > 100 || user.daily_volume > 1000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	values := ruleexpr.Vars{
+		"event.amount":          ruleexpr.Number(500),
+		"event.type":            ruleexpr.String("deposit"),
+		"user.account_age_days": ruleexpr.Number(3),
+	}
+	// user.daily_volume 被短路未查询，event.type 未被条件引用
+	want := map[string]interface{}{"event.amount": 500.0}
+	if got := referencedValues(expr, values); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
+
+// TestAccountAgeDays 测试注册天数按小时折算，注册时间未知或晚于评估时间时为 0
+func TestAccountAgeDays(t *testing.T) {
+	created := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		createdAt time.Time
+		at        time.Time
+		want      float64
+	}{
+		{created, created.Add(36 * time.Hour), 1.5},
+		{created, created, 0},
+		{created, created.Add(-time.Hour), 0},
+		{time.Time{}, created, 0},
+	}
+	for _, tt := range tests {
+		if got := accountAgeDays(tt.createdAt, tt.at); got != tt.want {
+			t.Errorf("accountAgeDays(%s, %s): Expected %v, got %v", tt.createdAt, tt.at, tt.want, got)
+		}
+	}
+}
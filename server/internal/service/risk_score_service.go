@@ -0,0 +1,363 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/fiveseconds/server/internal/config"
+	"github.com/fiveseconds/server/internal/model"
+	"github.com/fiveseconds/server/internal/repository"
+
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+const (
+	// RiskScoreMax 风险评分上限
+	RiskScoreMax = 100.0
+	// riskScoreHistoryLimit 评分详情返回的历史条数
+	riskScoreHistoryLimit = 50
+	// riskScoreFlagHorizon 超过该半衰期倍数的标记得分已可忽略，不再读取
+	riskScoreFlagHorizon = 10
+
+	// 行为信号满分
+	riskScoreWinRateMax     = 25.0
+	riskScoreDepositPlayMax = 15.0
+	riskScoreAccountAgeMax  = 10.0
+
+	// 胜率偏离：超过期望 riskScoreWinRateZStart 个标准差开始计分，超过 riskScoreWinRateZFull 个时满分
+	riskScoreWinRateZStart = 2.0
+	riskScoreWinRateZFull  = 5.0
+)
+
+// riskSeverityPoints 单个待审核标记按严重程度的基础分
+var riskSeverityPoints = map[model.RiskSeverity]float64{
+	model.RiskSeverityLow:      5,
+	model.RiskSeverityMedium:   10,
+	model.RiskSeverityHigh:     20,
+	model.RiskSeverityCritical: 35,
+}
+
+// riskFlagTypeWeights 标记类型权重（未列出的为 1）
+var riskFlagTypeWeights = map[model.RiskFlagType]float64{
+	model.RiskFlagCollusion:         1.5,
+	model.RiskFlagMultiAccount:      1.3,
+	model.RiskFlagCircularTransfer:  1.3,
+	model.RiskFlagReferralAbuse:     1.2,
+	model.RiskFlagNewDeviceWithdraw: 1.2,
+}
+
+// riskFlagStatusWeights 审核结论权重：确认加倍，驳回不计
+var riskFlagStatusWeights = map[model.RiskFlagStatus]float64{
+	model.RiskFlagStatusPending:   1,
+	model.RiskFlagStatusReviewed:  0.5,
+	model.RiskFlagStatusConfirmed: 2,
+	model.RiskFlagStatusDismissed: 0,
+}
+
+// riskSeverityRank 严重程度排序（未知等级为 0）
+func riskSeverityRank(severity model.RiskSeverity) int {
+	switch severity {
+	case model.RiskSeverityLow:
+		return 1
+	case model.RiskSeverityMedium:
+		return 2
+	case model.RiskSeverityHigh:
+		return 3
+	case model.RiskSeverityCritical:
+		return 4
+	}
+	return 0
+}
+
+// riskScoreParams 评分参数
+type riskScoreParams struct {
+	HalfLife       time.Duration
+	MinRounds      int
+	MinDeposit     decimal.Decimal
+	NewAccountDays float64
+	MediumScore    float64
+	HighScore      float64
+	CriticalScore  float64
+}
+
+// RiskScoreService 用户风险评分
+// 评分由风控标记得分（严重程度 × 类型权重 × 审核结论权重，按半衰期指数衰减）与行为信号
+// （胜率偏离、充值多下注少、新账户）相加，上限 100 分。标记创建/审核、回合结算、资金申请时重算，
+// 并由定时任务重算以体现衰减；评分升至告警等级时触发告警，回落后再次升高会重新告警
+type RiskScoreService struct {
+	repo         *repository.RiskScoreRepo
+	alertManager *AlertManager
+	cfg          *config.Config
+	logger       *zap.Logger
+
+	mu           sync.Mutex
+	lastComputed map[int64]time.Time // 回合结算触发重算的节流
+}
+
+// NewRiskScoreService 创建用户风险评分服务
+func NewRiskScoreService(repo *repository.RiskScoreRepo, alertManager *AlertManager, cfg *config.Config, logger *zap.Logger) *RiskScoreService {
+	return &RiskScoreService{
+		repo:         repo,
+		alertManager: alertManager,
+		cfg:          cfg,
+		logger:       logger.With(zap.String("service", "risk_score")),
+		lastComputed: make(map[int64]time.Time),
+	}
+}
+
+// params 评分参数（未配置时使用默认值）
+func (s *RiskScoreService) params() riskScoreParams {
+	c := s.cfg.RiskScore
+	p := riskScoreParams{
+		HalfLife:       30 * 24 * time.Hour,
+		MinRounds:      30,
+		MinDeposit:     decimal.NewFromInt(500),
+		NewAccountDays: 30,
+		MediumScore:    30,
+		HighScore:      60,
+		CriticalScore:  80,
+	}
+	if c.HalfLifeDays > 0 {
+		p.HalfLife = time.Duration(c.HalfLifeDays) * 24 * time.Hour
+	}
+	if c.MinRounds > 0 {
+		p.MinRounds = c.MinRounds
+	}
+	if c.MinDeposit > 0 {
+		p.MinDeposit = decimal.NewFromFloat(c.MinDeposit)
+	}
+	if c.NewAccountDays > 0 {
+		p.NewAccountDays = float64(c.NewAccountDays)
+	}
+	if c.MediumScore > 0 {
+		p.MediumScore = c.MediumScore
+	}
+	if c.HighScore > 0 {
+		p.HighScore = c.HighScore
+	}
+	if c.CriticalScore > 0 {
+		p.CriticalScore = c.CriticalScore
+	}
+	return p
+}
+
+// window 行为信号统计窗口
+func (s *RiskScoreService) window() time.Duration {
+	if s.cfg.RiskScore.WindowDays > 0 {
+		return time.Duration(s.cfg.RiskScore.WindowDays) * 24 * time.Hour
+	}
+	return 30 * 24 * time.Hour
+}
+
+// alertLevel 评分告警等级
+func (s *RiskScoreService) alertLevel() model.RiskSeverity {
+	if level := model.RiskSeverity(s.cfg.RiskScore.AlertLevel); riskSeverityRank(level) > 0 {
+		return level
+	}
+	return model.RiskSeverityHigh
+}
+
+// roundRecomputeInterval 回合结算触发重算的最小间隔
+func (s *RiskScoreService) roundRecomputeInterval() time.Duration {
+	if s.cfg.RiskScore.RoundRecomputeMinutes > 0 {
+		return time.Duration(s.cfg.RiskScore.RoundRecomputeMinutes) * time.Minute
+	}
+	return 10 * time.Minute
+}
+
+// clamp01 截断到 [0, 1]
+func clamp01(v float64) float64 {
+	return math.Max(0, math.Min(1, v))
+}
+
+// riskFlagPoints 单个标记在 now 时刻的得分；已审核的标记从审核时间开始衰减
+func riskFlagPoints(f model.RiskScoreFlag, now time.Time, halfLife time.Duration) float64 {
+	base := riskSeverityPoints[f.Severity]
+	if base == 0 {
+		base = riskSeverityPoints[model.RiskSeverityMedium]
+	}
+	weight := 1.0
+	if w, ok := riskFlagTypeWeights[f.FlagType]; ok {
+		weight = w
+	}
+	since := f.CreatedAt
+	if f.ReviewedAt != nil && f.ReviewedAt.After(since) {
+		since = *f.ReviewedAt
+	}
+	age := now.Sub(since)
+	if age < 0 {
+		age = 0
+	}
+	return base * weight * riskFlagStatusWeights[f.Status] * math.Pow(0.5, float64(age)/float64(halfLife))
+}
+
+// computeRiskScore 由评分输入计算 now 时刻的评分与分项
+func computeRiskScore(in *model.RiskScoreInput, p riskScoreParams, now time.Time) (float64, model.RiskSeverity, model.RiskScoreComponents) {
+	c := model.RiskScoreComponents{
+		Rounds:       in.Rounds,
+		Wins:         in.Wins,
+		ExpectedWins: in.ExpectedWins,
+		Deposits:     in.Deposits,
+		Wagered:      in.Wagered,
+	}
+
+	for _, f := range in.Flags {
+		if f.Status == model.RiskFlagStatusDismissed {
+			continue
+		}
+		c.Flags++
+		if f.Status == model.RiskFlagStatusConfirmed {
+			c.ConfirmedFlags++
+		}
+		c.FlagPoints += riskFlagPoints(f, now, p.HalfLife)
+	}
+
+	if in.Rounds >= p.MinRounds && in.WinVariance > 0 {
+		c.WinRateZ = (float64(in.Wins) - in.ExpectedWins) / math.Sqrt(in.WinVariance)
+		c.WinRatePoints = riskScoreWinRateMax *
+			clamp01((c.WinRateZ-riskScoreWinRateZStart)/(riskScoreWinRateZFull-riskScoreWinRateZStart))
+	}
+
+	if in.Deposits.IsPositive() && !in.Deposits.LessThan(p.MinDeposit) {
+		ratio, _ := in.Wagered.Div(in.Deposits).Float64()
+		c.DepositPlayPoints = riskScoreDepositPlayMax * clamp01(1-ratio)
+	}
+
+	c.AccountAgeDays = math.Max(0, now.Sub(in.AccountCreatedAt).Hours()/24)
+	if p.NewAccountDays > 0 {
+		c.AccountAgePoints = riskScoreAccountAgeMax * clamp01(1-c.AccountAgeDays/p.NewAccountDays)
+	}
+
+	score := c.FlagPoints + c.WinRatePoints + c.DepositPlayPoints + c.AccountAgePoints
+	score = math.Round(math.Min(score, RiskScoreMax)*100) / 100
+	return score, riskScoreLevel(score, p), c
+}
+
+// riskScoreLevel 分值对应的风险等级
+func riskScoreLevel(score float64, p riskScoreParams) model.RiskSeverity {
+	switch {
+	case score >= p.CriticalScore:
+		return model.RiskSeverityCritical
+	case score >= p.HighScore:
+		return model.RiskSeverityHigh
+	case score >= p.MediumScore:
+		return model.RiskSeverityMedium
+	default:
+		return model.RiskSeverityLow
+	}
+}
+
+// riskScoreAlert 是否需要告警及更新后的已告警等级：
+// 升至告警等级以上且高于已告警等级时告警；回落到告警等级以下时清空，之后再次升高会重新告警
+func riskScoreAlert(level, alerted, alertLevel model.RiskSeverity) (bool, model.RiskSeverity) {
+	if riskSeverityRank(level) < riskSeverityRank(alertLevel) {
+		return false, ""
+	}
+	if riskSeverityRank(level) > riskSeverityRank(alerted) {
+		return true, level
+	}
+	return false, alerted
+}
+
+// riskScoreChanged 评分是否有值得记录历史的变化（首次评分、等级变化或分值变化不少于 1 分）
+func riskScoreChanged(prev *model.UserRiskScore, score float64, level model.RiskSeverity) bool {
+	if prev == nil {
+		return true
+	}
+	return prev.Level != level || math.Abs(prev.Score-score) >= 1
+}
+
+// Recompute 重算用户风险评分并保存，必要时记录历史与告警
+func (s *RiskScoreService) Recompute(ctx context.Context, userID int64, trigger model.RiskScoreTrigger) (*model.UserRiskScore, error) {
+	now := time.Now()
+	p := s.params()
+	in, err := s.repo.GetInput(ctx, userID, now.Add(-p.HalfLife*riskScoreFlagHorizon), now.Add(-s.window()))
+	if err != nil {
+		return nil, err
+	}
+
+	prev, err := s.repo.Get(ctx, userID)
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		return nil, err
+	}
+
+	score, level, components := computeRiskScore(in, p, now)
+	var alerted model.RiskSeverity
+	if prev != nil {
+		alerted = prev.AlertedLevel
+	}
+	alert, alerted := riskScoreAlert(level, alerted, s.alertLevel())
+
+	result := &model.UserRiskScore{
+		UserID:       userID,
+		Score:        score,
+		Level:        level,
+		Components:   components,
+		AlertedLevel: alerted,
+		ComputedAt:   now,
+	}
+	if err := s.repo.Save(ctx, result, trigger, riskScoreChanged(prev, score, level)); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	s.lastComputed[userID] = now
+	s.mu.Unlock()
+
+	if alert && s.alertManager != nil {
+		s.alertManager.TriggerRiskScoreAlert(ctx, result)
+	}
+	return result, nil
+}
+
+// Refresh 事件触发的重算（回合结算按最小间隔节流），失败只记录日志
+func (s *RiskScoreService) Refresh(ctx context.Context, userID int64, trigger model.RiskScoreTrigger) {
+	if trigger == model.RiskScoreTriggerRoundSettled {
+		s.mu.Lock()
+		last, ok := s.lastComputed[userID]
+		s.mu.Unlock()
+		if ok && time.Since(last) < s.roundRecomputeInterval() {
+			return
+		}
+	}
+	if _, err := s.Recompute(ctx, userID, trigger); err != nil {
+		s.logger.Error("Failed to recompute risk score",
+			zap.Int64("user_id", userID),
+			zap.String("trigger", string(trigger)),
+			zap.Error(err))
+	}
+}
+
+// RecomputeAll 重算所有评分大于 0 的用户（体现时间衰减），返回重算人数
+func (s *RiskScoreService) RecomputeAll(ctx context.Context) (int, error) {
+	userIDs, err := s.repo.ListScoredUserIDs(ctx)
+	if err != nil {
+		return 0, err
+	}
+	for i, userID := range userIDs {
+		if _, err := s.Recompute(ctx, userID, model.RiskScoreTriggerDecay); err != nil {
+			return i, err
+		}
+	}
+	return len(userIDs), nil
+}
+
+// Get 获取用户当前风险评分与最近的评分历史（尚无评分时即时计算）
+func (s *RiskScoreService) Get(ctx context.Context, userID int64) (*model.UserRiskScore, []*model.RiskScoreHistory, error) {
+	score, err := s.repo.Get(ctx, userID)
+	if errors.Is(err, repository.ErrNotFound) {
+		score, err = s.Recompute(ctx, userID, model.RiskScoreTriggerDecay)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	history, err := s.repo.ListHistory(ctx, userID, riskScoreHistoryLimit)
+	if err != nil {
+		return nil, nil, err
+	}
+	return score, history, nil
+}
@@ -0,0 +1,212 @@
+package service
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/fiveseconds/server/internal/model"
+
+	"github.com/shopspring/decimal"
+)
+
+// testRiskScoreParams 测试用评分参数：半衰期 1 天，中/高/严重阈值 30/60/80
+func testRiskScoreParams() riskScoreParams {
+	return riskScoreParams{
+		HalfLife:       24 * time.Hour,
+		MinRounds:      20,
+		MinDeposit:     decimal.NewFromInt(100),
+		NewAccountDays: 7,
+		MediumScore:    30,
+		HighScore:      60,
+		CriticalScore:  80,
+	}
+}
+
+// TestRiskFlagPoints 测试单个标记得分：严重程度 × 类型权重 × 审核结论权重，按半衰期衰减
+func TestRiskFlagPoints(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+	reviewedNow := now
+
+	tests := []struct {
+		name string
+		flag model.RiskScoreFlag
+		want float64
+	}{
+		{"fresh pending collusion", model.RiskScoreFlag{FlagType: model.RiskFlagCollusion, Severity: model.RiskSeverityHigh, Status: model.RiskFlagStatusPending, CreatedAt: now}, 30},
+		{"one half-life halves the points", model.RiskScoreFlag{FlagType: model.RiskFlagHighWinRate, Severity: model.RiskSeverityMedium, Status: model.RiskFlagStatusPending, CreatedAt: now.Add(-day)}, 5},
+		{"confirmed doubles after two half-lives", model.RiskScoreFlag{FlagType: model.RiskFlagMultiAccount, Severity: model.RiskSeverityLow, Status: model.RiskFlagStatusConfirmed, CreatedAt: now.Add(-2 * day)}, 3.25},
+		{"review restarts the decay", model.RiskScoreFlag{FlagType: model.RiskFlagLargeTransaction, Severity: model.RiskSeverityCritical, Status: model.RiskFlagStatusReviewed, CreatedAt: now.Add(-10 * day), ReviewedAt: &reviewedNow}, 17.5},
+		{"dismissed adds nothing", model.RiskScoreFlag{FlagType: model.RiskFlagCollusion, Severity: model.RiskSeverityCritical, Status: model.RiskFlagStatusDismissed, CreatedAt: now}, 0},
+		{"unknown severity counts as medium", model.RiskScoreFlag{FlagType: model.RiskFlagRuleHit, Status: model.RiskFlagStatusPending, CreatedAt: now}, 10},
+		{"future timestamp does not grow", model.RiskScoreFlag{FlagType: model.RiskFlagRuleHit, Severity: model.RiskSeverityMedium, Status: model.RiskFlagStatusPending, CreatedAt: now.Add(time.Hour)}, 10},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := riskFlagPoints(tt.flag, now, day); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("Expected %v points, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+// TestComputeRiskScore 测试评分由标记得分与行为信号相加、上限 100 分，并按阈值得出等级
+func TestComputeRiskScore(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	veteran := now.Add(-30 * 24 * time.Hour)
+	collusion := func(severity model.RiskSeverity, status model.RiskFlagStatus) model.RiskScoreFlag {
+		return model.RiskScoreFlag{FlagType: model.RiskFlagCollusion, Severity: severity, Status: status, CreatedAt: now}
+	}
+
+	tests := []struct {
+		name          string
+		input         model.RiskScoreInput
+		wantScore     float64
+		wantLevel     model.RiskSeverity
+		wantFlags     int
+		wantConfirmed int
+	}{
+		{"clean account", model.RiskScoreInput{AccountCreatedAt: veteran}, 0, model.RiskSeverityLow, 0, 0},
+		{"new account", model.RiskScoreInput{AccountCreatedAt: now.Add(-84 * time.Hour)}, 5, model.RiskSeverityLow, 0, 0},
+		{
+			"win rate three deviations above expectation",
+			model.RiskScoreInput{AccountCreatedAt: veteran, Rounds: 100, Wins: 40, ExpectedWins: 25, WinVariance: 25},
+			8.33, model.RiskSeverityLow, 0, 0,
+		},
+		{
+			"win rate ignored below minimum rounds",
+			model.RiskScoreInput{AccountCreatedAt: veteran, Rounds: 10, Wins: 10, ExpectedWins: 2.5, WinVariance: 1.875},
+			0, model.RiskSeverityLow, 0, 0,
+		},
+		{
+			"deposits mostly not wagered",
+			model.RiskScoreInput{AccountCreatedAt: veteran, Deposits: decimal.NewFromInt(1000), Wagered: decimal.NewFromInt(250)},
+			11.25, model.RiskSeverityLow, 0, 0,
+		},
+		{
+			"small deposits ignored",
+			model.RiskScoreInput{AccountCreatedAt: veteran, Deposits: decimal.NewFromInt(50)},
+			0, model.RiskSeverityLow, 0, 0,
+		},
+		{
+			"dismissed flag not counted",
+			model.RiskScoreInput{
+				AccountCreatedAt: veteran,
+				Flags: []model.RiskScoreFlag{
+					collusion(model.RiskSeverityHigh, model.RiskFlagStatusPending),
+					collusion(model.RiskSeverityCritical, model.RiskFlagStatusDismissed),
+				},
+				Rounds: 100, Wins: 60, ExpectedWins: 25, WinVariance: 25,
+			},
+			55, model.RiskSeverityMedium, 1, 0,
+		},
+		{
+			"signals add up to high",
+			model.RiskScoreInput{
+				AccountCreatedAt: now.Add(-84 * time.Hour),
+				Flags:            []model.RiskScoreFlag{collusion(model.RiskSeverityHigh, model.RiskFlagStatusPending)},
+				Rounds:           100, Wins: 60, ExpectedWins: 25, WinVariance: 25,
+			},
+			60, model.RiskSeverityHigh, 1, 0,
+		},
+		{
+			"capped at the maximum",
+			model.RiskScoreInput{
+				AccountCreatedAt: veteran,
+				Flags:            []model.RiskScoreFlag{collusion(model.RiskSeverityCritical, model.RiskFlagStatusConfirmed)},
+			},
+			RiskScoreMax, model.RiskSeverityCritical, 1, 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			score, level, c := computeRiskScore(&tt.input, testRiskScoreParams(), now)
+			if score != tt.wantScore {
+				t.Errorf("Expected score %v, got %v", tt.wantScore, score)
+			}
+			if level != tt.wantLevel {
+				t.Errorf("Expected level %s, got %s", tt.wantLevel, level)
+			}
+			if c.Flags != tt.wantFlags || c.ConfirmedFlags != tt.wantConfirmed {
+				t.Errorf("Expected %d flags (%d confirmed), got %d (%d)", tt.wantFlags, tt.wantConfirmed, c.Flags, c.ConfirmedFlags)
+			}
+		})
+	}
+}
+
+// TestRiskScoreLevel 测试分值达到阈值即进入对应等级
+func TestRiskScoreLevel(t *testing.T) {
+	tests := []struct {
+		score float64
+		want  model.RiskSeverity
+	}{
+		{0, model.RiskSeverityLow},
+		{29.99, model.RiskSeverityLow},
+		{30, model.RiskSeverityMedium},
+		{59.99, model.RiskSeverityMedium},
+		{60, model.RiskSeverityHigh},
+		{79.99, model.RiskSeverityHigh},
+		{80, model.RiskSeverityCritical},
+		{RiskScoreMax, model.RiskSeverityCritical},
+	}
+	for _, tt := range tests {
+		if got := riskScoreLevel(tt.score, testRiskScoreParams()); got != tt.want {
+			t.Errorf("Expected score %v to be %s, got %s", tt.score, tt.want, got)
+		}
+	}
+}
+
+// TestRiskScoreAlert 测试升至告警等级以上时每个等级只告警一次，回落到告警等级以下后重新告警
+func TestRiskScoreAlert(t *testing.T) {
+	steps := []struct {
+		level       model.RiskSeverity
+		wantAlert   bool
+		wantAlerted model.RiskSeverity
+	}{
+		{model.RiskSeverityLow, false, ""},
+		{model.RiskSeverityMedium, false, ""},
+		{model.RiskSeverityHigh, true, model.RiskSeverityHigh},
+		{model.RiskSeverityHigh, false, model.RiskSeverityHigh},
+		{model.RiskSeverityCritical, true, model.RiskSeverityCritical},
+		{model.RiskSeverityHigh, false, model.RiskSeverityCritical},
+		{model.RiskSeverityCritical, false, model.RiskSeverityCritical},
+		{model.RiskSeverityMedium, false, ""},
+		{model.RiskSeverityHigh, true, model.RiskSeverityHigh},
+	}
+	var alerted model.RiskSeverity
+	for i, step := range steps {
+		alert, next := riskScoreAlert(step.level, alerted, model.RiskSeverityHigh)
+		if alert != step.wantAlert || next != step.wantAlerted {
+			t.Errorf("Step %d (%s after %q): expected alert=%v alerted=%q, got alert=%v alerted=%q",
+				i, step.level, alerted, step.wantAlert, step.wantAlerted, alert, next)
+		}
+		alerted = next
+	}
+}
+
+// TestRiskScoreChanged 测试首次评分、等级变化或分值变化不少于 1 分时记录历史
+func TestRiskScoreChanged(t *testing.T) {
+	prev := &model.UserRiskScore{Score: 40, Level: model.RiskSeverityMedium}
+	tests := []struct {
+		name  string
+		prev  *model.UserRiskScore
+		score float64
+		level model.RiskSeverity
+		want  bool
+	}{
+		{"first score", nil, 0, model.RiskSeverityLow, true},
+		{"unchanged", prev, 40, model.RiskSeverityMedium, false},
+		{"small drift", prev, 40.99, model.RiskSeverityMedium, false},
+		{"one point up", prev, 41, model.RiskSeverityMedium, true},
+		{"one and a half points down", prev, 38.5, model.RiskSeverityMedium, true},
+		{"level change", prev, 40.5, model.RiskSeverityHigh, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := riskScoreChanged(tt.prev, tt.score, tt.level); got != tt.want {
+				t.Errorf("Expected changed=%v, got %v", tt.want, got)
+			}
+		})
+	}
+}
@@ -4,19 +4,35 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"sync"
+	"time"
 
 	"github.com/fiveseconds/server/internal/model"
 	"github.com/fiveseconds/server/internal/repository"
+	"github.com/fiveseconds/server/pkg/ruleexpr"
 	"github.com/shopspring/decimal"
 	"go.uber.org/zap"
 )
 
 // RiskControlService 风控服务
+// 设置规则引擎后，回合结算、登录与资金申请事件的阈值检查由数据库中的风控规则决定；
+// 未设置时回退到 DefaultRiskConfig 的固定检查
 type RiskControlService struct {
 	riskRepo     *repository.RiskRepo
 	alertManager *AlertManager
+	rules        *RiskRuleService
+	collusion    *CollusionService
+	owners       *OwnerRiskService
+	loginHistory *LoginHistoryService
+	restrictions *RestrictionService
+	scorer       *RiskScoreService
+	anomalies    *FundAnomalyLogger
+	cases        *RiskCaseService
 	config       model.RiskConfig
 	logger       *zap.Logger
+
+	mu            sync.Mutex
+	volumeAlerted map[int64]time.Time // 日交易量超限告警时间（每个用户每天只告警一次）
 }
 
 // NewRiskControlService 创建风控服务
@@ -28,27 +44,92 @@ func NewRiskControlService(
 	return &RiskControlService{
 		riskRepo:     riskRepo,
 		alertManager: alertManager,
-		config:       model.DefaultRiskConfig,
-		logger:       logger.With(zap.String("service", "risk_control")),
+		config:        model.DefaultRiskConfig,
+		logger:        logger.With(zap.String("service", "risk_control")),
+		volumeAlerted: make(map[int64]time.Time),
 	}
 }
 
-// CheckConsecutiveWins 检查连续获胜
-func (s *RiskControlService) CheckConsecutiveWins(ctx context.Context, userID int64, isWinner bool) error {
-	if isWinner {
-		// 获取当前连续获胜次数
-		wins, err := s.riskRepo.GetUserConsecutiveWins(ctx, userID)
-		if err != nil {
-			s.logger.Error("Failed to get consecutive wins", zap.Int64("user_id", userID), zap.Error(err))
-			return err
-		}
+// SetRuleEngine 设置风控规则引擎
+func (s *RiskControlService) SetRuleEngine(rules *RiskRuleService) {
+	s.rules = rules
+}
 
-		wins++
-		if err := s.riskRepo.UpdateUserConsecutiveWins(ctx, userID, wins); err != nil {
-			s.logger.Error("Failed to update consecutive wins", zap.Int64("user_id", userID), zap.Error(err))
-			return err
+// SetCollusionAnalyzer 设置串通分析服务（回合结算后增量分析）
+func (s *RiskControlService) SetCollusionAnalyzer(collusion *CollusionService) {
+	s.collusion = collusion
+}
+
+// SetOwnerRiskAnalyzer 设置房主维度风控分析服务（每日房主对账后检测）
+func (s *RiskControlService) SetOwnerRiskAnalyzer(owners *OwnerRiskService) {
+	s.owners = owners
+}
+
+// SetLoginHistory 设置登录历史服务（新设备登录后提现检测）
+func (s *RiskControlService) SetLoginHistory(loginHistory *LoginHistoryService) {
+	s.loginHistory = loginHistory
+}
+
+// SetRestrictions 设置账户限制服务（规则触发时按严重程度自动限制，确认标记时可施加限制）
+func (s *RiskControlService) SetRestrictions(restrictions *RestrictionService) {
+	s.restrictions = restrictions
+}
+
+// SetRiskScorer 设置用户风险评分服务（标记创建/审核、回合结算、资金申请后重算评分）
+func (s *RiskControlService) SetRiskScorer(scorer *RiskScoreService) {
+	s.scorer = scorer
+}
+
+// SetFundAnomalyLogger 设置资金异常日志器（资金变动检查命中时输出结构化日志）
+func (s *RiskControlService) SetFundAnomalyLogger(anomalies *FundAnomalyLogger) {
+	s.anomalies = anomalies
+}
+
+// SetCases 设置风控案件服务（案件内标记的审核写入案件审计）
+func (s *RiskControlService) SetCases(cases *RiskCaseService) {
+	s.cases = cases
+}
+
+// refreshScore 事件触发的风险评分重算
+func (s *RiskControlService) refreshScore(ctx context.Context, userID int64, trigger model.RiskScoreTrigger) {
+	if s.scorer != nil {
+		s.scorer.Refresh(ctx, userID, trigger)
+	}
+}
+
+// updateConsecutiveWins 更新连续获胜计数（赢了加一，输了清零），返回更新后的次数
+func (s *RiskControlService) updateConsecutiveWins(ctx context.Context, userID int64, isWinner bool) (int, error) {
+	if !isWinner {
+		if err := s.riskRepo.ResetUserConsecutiveWins(ctx, userID); err != nil {
+			s.logger.Error("Failed to reset consecutive wins", zap.Int64("user_id", userID), zap.Error(err))
+			return 0, err
 		}
+		return 0, nil
+	}
 
+	// 获取当前连续获胜次数
+	wins, err := s.riskRepo.GetUserConsecutiveWins(ctx, userID)
+	if err != nil {
+		s.logger.Error("Failed to get consecutive wins", zap.Int64("user_id", userID), zap.Error(err))
+		return 0, err
+	}
+
+	wins++
+	if err := s.riskRepo.UpdateUserConsecutiveWins(ctx, userID, wins); err != nil {
+		s.logger.Error("Failed to update consecutive wins", zap.Int64("user_id", userID), zap.Error(err))
+		return 0, err
+	}
+	return wins, nil
+}
+
+// CheckConsecutiveWins 检查连续获胜（未设置规则引擎时的固定检查）
+func (s *RiskControlService) CheckConsecutiveWins(ctx context.Context, userID int64, isWinner bool) error {
+	wins, err := s.updateConsecutiveWins(ctx, userID, isWinner)
+	if err != nil {
+		return err
+	}
+
+	if isWinner {
 		// 检查是否超过阈值
 		if wins > s.config.ConsecutiveWinThreshold {
 			// 检查是否已有待处理的标记
@@ -71,18 +152,12 @@ func (s *RiskControlService) CheckConsecutiveWins(ctx context.Context, userID in
 					zap.Int("consecutive_wins", wins))
 			}
 		}
-	} else {
-		// 输了，重置连续获胜次数
-		if err := s.riskRepo.ResetUserConsecutiveWins(ctx, userID); err != nil {
-			s.logger.Error("Failed to reset consecutive wins", zap.Int64("user_id", userID), zap.Error(err))
-			return err
-		}
 	}
 
 	return nil
 }
 
-// CheckWinRate 检查胜率
+// CheckWinRate 检查胜率（未设置规则引擎时的固定检查）
 func (s *RiskControlService) CheckWinRate(ctx context.Context, userID int64) error {
 	winRate, totalRounds, err := s.riskRepo.GetUserWinRate(ctx, userID, s.config.WinRateMinRounds)
 	if err != nil {
@@ -161,32 +236,95 @@ func (s *RiskControlService) CheckDeviceFingerprint(ctx context.Context, userID
 	return nil
 }
 
-// CheckLargeTransaction 检查大额交易
-func (s *RiskControlService) CheckLargeTransaction(ctx context.Context, userID int64, amount decimal.Decimal) error {
-	if amount.Abs().LessThan(s.config.LargeTransactionAmount) {
+// CheckReferralFraud 检查推荐关系是否疑似自我推荐（推荐人与被推荐人使用相同设备）
+// 复用设备指纹多账户检测，命中时为推荐人创建推荐作弊标记，返回 true 表示应拦截奖励
+func (s *RiskControlService) CheckReferralFraud(ctx context.Context, referrerID, refereeID int64) (bool, error) {
+	fingerprint, err := s.riskRepo.GetUserDeviceFingerprint(ctx, refereeID)
+	if err != nil {
+		s.logger.Error("Failed to get device fingerprint", zap.Int64("user_id", refereeID), zap.Error(err))
+		return false, err
+	}
+	if fingerprint == "" {
+		return false, nil
+	}
+
+	userIDs, err := s.riskRepo.GetUsersByDeviceFingerprint(ctx, fingerprint)
+	if err != nil {
+		s.logger.Error("Failed to get users by fingerprint", zap.Error(err))
+		return false, err
+	}
+	shared := false
+	for _, id := range userIDs {
+		if id == referrerID {
+			shared = true
+			break
+		}
+	}
+	if !shared {
+		return false, nil
+	}
+
+	// 被推荐人同时记一条多账户标记
+	if err := s.CheckDeviceFingerprint(ctx, refereeID, fingerprint); err != nil {
+		return true, err
+	}
+
+	hasPending, err := s.riskRepo.HasPendingFlag(ctx, referrerID, model.RiskFlagReferralAbuse)
+	if err != nil {
+		s.logger.Error("Failed to check pending flag", zap.Error(err))
+		return true, err
+	}
+	if !hasPending {
+		details := &model.RiskFlagDetails{
+			DeviceFingerprint: fingerprint,
+			RelatedUserIDs:    []int64{refereeID},
+		}
+		if err := s.createRiskFlag(ctx, referrerID, model.RiskFlagReferralAbuse, details); err != nil {
+			return true, err
+		}
+	}
+	s.logger.Warn("Referral abuse detected",
+		zap.Int64("referrer_id", referrerID),
+		zap.Int64("referee_id", refereeID),
+		zap.String("fingerprint", fingerprint))
+
+	return true, nil
+}
+
+// CheckLargeTransaction 检查大额资金变动
+func (s *RiskControlService) CheckLargeTransaction(ctx context.Context, event *model.FundEvent) error {
+	if event.Amount.Abs().LessThan(s.config.LargeTransactionAmount) {
 		return nil
 	}
 
 	details := &model.RiskFlagDetails{
-		TransactionAmount: amount,
+		TransactionAmount: event.Amount,
 	}
-	if err := s.createRiskFlag(ctx, userID, model.RiskFlagLargeTransaction, details); err != nil {
+	if event.Type == model.FundEventRequestApproved {
+		requestID := event.RefID
+		details.FundRequestID = &requestID
+	}
+	if err := s.createRiskFlag(ctx, event.UserID, model.RiskFlagLargeTransaction, details); err != nil {
 		return err
 	}
 
 	// 同时触发告警
 	if s.alertManager != nil {
-		s.alertManager.TriggerLargeTransactionAlert(ctx, userID, amount)
+		s.alertManager.TriggerLargeTransactionAlert(ctx, event.UserID, event.Amount)
+	}
+	if s.anomalies != nil {
+		s.anomalies.LogLargeTransaction(ctx, event.UserID, event.Amount, string(event.Type), event.RefID)
 	}
 
 	s.logger.Warn("Large transaction detected",
-		zap.Int64("user_id", userID),
-		zap.String("amount", amount.String()))
+		zap.Int64("user_id", event.UserID),
+		zap.String("event", string(event.Type)),
+		zap.String("amount", event.Amount.String()))
 
 	return nil
 }
 
-// CheckDailyVolume 检查日交易量
+// CheckDailyVolume 检查日交易量（超限后每个用户每天只告警一次）
 func (s *RiskControlService) CheckDailyVolume(ctx context.Context, userID int64) error {
 	volumeStr, err := s.riskRepo.GetUserDailyVolume(ctx, userID)
 	if err != nil {
@@ -198,20 +336,222 @@ func (s *RiskControlService) CheckDailyVolume(ctx context.Context, userID int64)
 	if err != nil {
 		return err
 	}
+	if !volume.GreaterThan(s.config.DailyVolumeThreshold) {
+		return nil
+	}
+
+	now := time.Now()
+	s.mu.Lock()
+	due := volumeAlertDue(s.volumeAlerted[userID], now)
+	if due {
+		s.volumeAlerted[userID] = now
+	}
+	s.mu.Unlock()
+	if !due {
+		return nil
+	}
+
+	// 触发告警
+	if s.alertManager != nil {
+		s.alertManager.TriggerDailyVolumeAlert(ctx, userID, volume)
+	}
+	if s.anomalies != nil {
+		s.anomalies.LogDailyVolumeExceeded(ctx, userID, volume, s.config.DailyVolumeThreshold)
+	}
+	s.logger.Warn("Daily volume threshold exceeded",
+		zap.Int64("user_id", userID),
+		zap.String("volume", volume.String()))
+
+	return nil
+}
+
+// volumeAlertDue 当天是否尚未发出日交易量告警
+func volumeAlertDue(lastAlerted, now time.Time) bool {
+	if lastAlerted.IsZero() {
+		return true
+	}
+	y1, m1, d1 := lastAlerted.Date()
+	y2, m2, d2 := now.Date()
+	return y1 != y2 || m1 != m2 || d1 != d2
+}
+
+// fundVelocityExceeded 窗口内审批通过的充值或提现笔数是否超过上限（上限不大于 0 时不检查）
+func fundVelocityExceeded(cfg model.RiskConfig, deposits, withdrawals int) bool {
+	return (cfg.MaxDepositsPerWindow > 0 && deposits > cfg.MaxDepositsPerWindow) ||
+		(cfg.MaxWithdrawalsPerWindow > 0 && withdrawals > cfg.MaxWithdrawalsPerWindow)
+}
+
+// isQuickWithdrawal 窗口内有充值且此后的下注金额低于充值金额的 minPlayRatio 倍
+func isQuickWithdrawal(v *model.FundVelocity, minPlayRatio decimal.Decimal) bool {
+	if !v.DepositAmount.IsPositive() {
+		return false
+	}
+	return v.Wagered.LessThan(v.DepositAmount.Mul(minPlayRatio))
+}
+
+// CheckFundVelocity 检查资金频率：窗口内审批通过的充值或提现笔数过多
+func (s *RiskControlService) CheckFundVelocity(ctx context.Context, event *model.FundEvent) error {
+	if s.config.FundVelocityWindow <= 0 {
+		return nil
+	}
+	deposits, withdrawals, err := s.riskRepo.CountApprovedFundRequests(ctx, event.UserID, event.OccurredAt.Add(-s.config.FundVelocityWindow))
+	if err != nil {
+		s.logger.Error("Failed to count fund requests", zap.Int64("user_id", event.UserID), zap.Error(err))
+		return err
+	}
+	if !fundVelocityExceeded(s.config, deposits, withdrawals) {
+		return nil
+	}
 
-	if volume.GreaterThan(s.config.DailyVolumeThreshold) {
-		// 触发告警
-		if s.alertManager != nil {
-			s.alertManager.TriggerDailyVolumeAlert(ctx, userID, volume)
-		}
-		s.logger.Warn("Daily volume threshold exceeded",
-			zap.Int64("user_id", userID),
-			zap.String("volume", volume.String()))
+	windowMinutes := int(s.config.FundVelocityWindow / time.Minute)
+	if s.anomalies != nil {
+		s.anomalies.LogFundVelocity(ctx, event.UserID, deposits, withdrawals, windowMinutes)
 	}
 
+	hasPending, err := s.riskRepo.HasPendingFlag(ctx, event.UserID, model.RiskFlagFundVelocity)
+	if err != nil {
+		s.logger.Error("Failed to check pending flag", zap.Error(err))
+		return err
+	}
+	if hasPending {
+		return nil
+	}
+
+	requestID := event.RefID
+	details := &model.RiskFlagDetails{
+		TransactionAmount: event.Amount,
+		FundRequestID:     &requestID,
+		FundVelocity: &model.FundVelocity{
+			WindowMinutes: windowMinutes,
+			Deposits:      deposits,
+			Withdrawals:   withdrawals,
+		},
+	}
+	if err := s.createRiskFlag(ctx, event.UserID, model.RiskFlagFundVelocity, details); err != nil {
+		return err
+	}
+	s.logger.Warn("Fund velocity exceeded",
+		zap.Int64("user_id", event.UserID),
+		zap.Int("deposits", deposits),
+		zap.Int("withdrawals", withdrawals),
+		zap.Int("window_minutes", windowMinutes))
+
 	return nil
 }
 
+// CheckQuickWithdrawal 检查玩家提现是否紧随充值且期间很少下注（快进快出洗钱的典型特征）
+func (s *RiskControlService) CheckQuickWithdrawal(ctx context.Context, event *model.FundEvent) error {
+	if event.RequestType != model.FundRequestWithdraw || s.config.QuickWithdrawWindow <= 0 {
+		return nil
+	}
+	v, err := s.riskRepo.GetDepositPlaySince(ctx, event.UserID, event.OccurredAt.Add(-s.config.QuickWithdrawWindow))
+	if err != nil {
+		s.logger.Error("Failed to get deposits and play", zap.Int64("user_id", event.UserID), zap.Error(err))
+		return err
+	}
+	if !isQuickWithdrawal(v, s.config.QuickWithdrawMinPlayRatio) {
+		return nil
+	}
+
+	if s.anomalies != nil {
+		s.anomalies.LogQuickWithdrawal(ctx, event.UserID, event.Amount, v.DepositAmount, v.Wagered, event.RefID)
+	}
+
+	hasPending, err := s.riskRepo.HasPendingFlag(ctx, event.UserID, model.RiskFlagQuickWithdraw)
+	if err != nil {
+		s.logger.Error("Failed to check pending flag", zap.Error(err))
+		return err
+	}
+	if hasPending {
+		return nil
+	}
+
+	requestID := event.RefID
+	v.WindowMinutes = int(s.config.QuickWithdrawWindow / time.Minute)
+	details := &model.RiskFlagDetails{
+		TransactionAmount: event.Amount,
+		FundRequestID:     &requestID,
+		FundVelocity:      v,
+	}
+	if err := s.createRiskFlag(ctx, event.UserID, model.RiskFlagQuickWithdraw, details); err != nil {
+		return err
+	}
+	s.logger.Warn("Withdrawal shortly after deposit with little play",
+		zap.Int64("user_id", event.UserID),
+		zap.Int64("fund_request_id", event.RefID),
+		zap.String("amount", event.Amount.String()),
+		zap.String("deposit_amount", v.DepositAmount.String()),
+		zap.String("wagered", v.Wagered.String()))
+
+	return nil
+}
+
+// OnFundEvent 资金变动提交后的风控检查：大额与日交易量对所有资金变动生效，
+// 设置规则引擎时审批通过的资金申请改为求值资金申请规则（预置规则与固定检查等价）；
+// 资金频率与快进快出仅针对审批通过的资金申请
+func (s *RiskControlService) OnFundEvent(ctx context.Context, event *model.FundEvent) {
+	if s.rules != nil && event.Type == model.FundEventRequestApproved {
+		amount, _ := event.Amount.Float64()
+		s.applyRules(ctx, model.RiskEventFundRequest, event.UserID, ruleexpr.Vars{
+			"event.amount": ruleexpr.Number(amount),
+			"event.type":   ruleexpr.String(string(event.RequestType)),
+		})
+	} else {
+		if err := s.CheckLargeTransaction(ctx, event); err != nil {
+			s.logger.Error("Failed to check large transaction", zap.Int64("user_id", event.UserID), zap.Error(err))
+		}
+		if err := s.CheckDailyVolume(ctx, event.UserID); err != nil {
+			s.logger.Error("Failed to check daily volume", zap.Int64("user_id", event.UserID), zap.Error(err))
+		}
+	}
+	if event.Type != model.FundEventRequestApproved || event.RequestType == model.FundRequestMarginDeposit {
+		return
+	}
+	if err := s.CheckFundVelocity(ctx, event); err != nil {
+		s.logger.Error("Failed to check fund velocity", zap.Int64("user_id", event.UserID), zap.Error(err))
+	}
+	if err := s.CheckQuickWithdrawal(ctx, event); err != nil {
+		s.logger.Error("Failed to check quick withdrawal", zap.Int64("user_id", event.UserID), zap.Error(err))
+	}
+}
+
+// CheckCircularTransfer 检查循环转账（资金经若干玩家转账后回流到转出方）
+// 转账事务提交前同步调用：发现循环时标记风险并返回 ErrCircularTransfer 阻止本次转账
+func (s *RiskControlService) CheckCircularTransfer(ctx context.Context, fromUserID, toUserID int64, amount decimal.Decimal) error {
+	since := time.Now().Add(-s.config.CircularTransferWindow)
+	cycle, err := s.riskRepo.FindTransferCycle(ctx, fromUserID, toUserID, since, s.config.CircularTransferMaxHops)
+	if err != nil {
+		s.logger.Error("Failed to find transfer cycle", zap.Int64("user_id", fromUserID), zap.Error(err))
+		return err
+	}
+	if len(cycle) == 0 {
+		return nil
+	}
+
+	hasPending, err := s.riskRepo.HasPendingFlag(ctx, fromUserID, model.RiskFlagCircularTransfer)
+	if err != nil {
+		s.logger.Error("Failed to check pending flag", zap.Error(err))
+		return err
+	}
+	if hasPending {
+		return ErrCircularTransfer
+	}
+
+	details := &model.RiskFlagDetails{
+		RelatedUserIDs:    cycle,
+		TransactionAmount: amount,
+	}
+	if err := s.createRiskFlag(ctx, fromUserID, model.RiskFlagCircularTransfer, details); err != nil {
+		return err
+	}
+	s.logger.Warn("Circular transfer detected",
+		zap.Int64("user_id", fromUserID),
+		zap.Int64s("cycle", cycle),
+		zap.String("amount", amount.String()))
+
+	return ErrCircularTransfer
+}
+
 // createRiskFlag 创建风控标记
 func (s *RiskControlService) createRiskFlag(ctx context.Context, userID int64, flagType model.RiskFlagType, details *model.RiskFlagDetails) error {
 	flag := &model.RiskFlag{
@@ -230,22 +570,47 @@ func (s *RiskControlService) createRiskFlag(ctx context.Context, userID int64, f
 		s.alertManager.TriggerRiskFlagAlert(ctx, flag)
 	}
 
+	s.refreshScore(ctx, userID, model.RiskScoreTriggerFlagCreated)
 	return nil
 }
 
 // ReviewFlag 审核风控标记
-func (s *RiskControlService) ReviewFlag(ctx context.Context, flagID int64, action string, reviewedBy int64) error {
+func (s *RiskControlService) ReviewFlag(ctx context.Context, flagID int64, req *model.ReviewRiskFlagReq, reviewedBy int64) ([]*model.AccountRestriction, error) {
 	var status model.RiskFlagStatus
-	switch action {
+	switch req.Action {
 	case "confirm":
 		status = model.RiskFlagStatusConfirmed
 	case "dismiss":
 		status = model.RiskFlagStatusDismissed
 	default:
-		return fmt.Errorf("invalid action: %s", action)
+		return nil, fmt.Errorf("invalid action: %s", req.Action)
+	}
+	if len(req.Restrictions) > 0 {
+		if status != model.RiskFlagStatusConfirmed {
+			return nil, ErrRestrictionRequiresConfirm
+		}
+		if s.restrictions == nil {
+			return nil, ErrRestrictionUnavailable
+		}
 	}
 
-	return s.riskRepo.ReviewFlag(ctx, flagID, status, reviewedBy)
+	if err := s.riskRepo.ReviewFlag(ctx, flagID, status, reviewedBy, req.Remark); err != nil {
+		return nil, err
+	}
+	flag, err := s.riskRepo.GetFlagByID(ctx, flagID)
+	if err != nil {
+		return nil, err
+	}
+	s.refreshScore(ctx, flag.UserID, model.RiskScoreTriggerFlagReviewed)
+	if s.cases != nil {
+		s.cases.OnFlagReviewed(ctx, flag, reviewedBy, req.Remark)
+	}
+	if len(req.Restrictions) == 0 {
+		return nil, nil
+	}
+
+	// 确认标记后对账户施加限制
+	return s.restrictions.ApplyForConfirmedFlag(ctx, flag, req.Restrictions, req.RestrictionHours, reviewedBy, req.Remark)
 }
 
 // ListFlags 列表风控标记
@@ -268,6 +633,19 @@ func (s *RiskControlService) OnRoundSettled(ctx context.Context, participants []
 	for _, userID := range participants {
 		isWinner := winnerSet[userID]
 
+		if s.rules != nil {
+			wins, err := s.updateConsecutiveWins(ctx, userID, isWinner)
+			if err != nil {
+				continue
+			}
+			s.applyRules(ctx, model.RiskEventRoundSettled, userID, ruleexpr.Vars{
+				"event.is_winner":       ruleexpr.Bool(isWinner),
+				"event.participants":    ruleexpr.Number(float64(len(participants))),
+				"user.consecutive_wins": ruleexpr.Number(float64(wins)),
+			})
+			continue
+		}
+
 		// 检查连续获胜
 		if err := s.CheckConsecutiveWins(ctx, userID, isWinner); err != nil {
 			s.logger.Error("Failed to check consecutive wins", zap.Int64("user_id", userID), zap.Error(err))
@@ -280,6 +658,286 @@ func (s *RiskControlService) OnRoundSettled(ctx context.Context, participants []
 			}
 		}
 	}
+
+	// 串通增量分析
+	if s.collusion != nil {
+		clusters, err := s.collusion.AnalyzeParticipants(ctx, participants)
+		if err != nil {
+			s.logger.Error("Failed to analyze collusion", zap.Error(err))
+		}
+		for _, cluster := range clusters {
+			if _, err := s.flagCollusionCluster(ctx, cluster); err != nil {
+				s.logger.Error("Failed to flag collusion cluster", zap.Int64s("users", cluster.UserIDs), zap.Error(err))
+			}
+		}
+	}
+
+	// 胜率偏离随回合变化，按最小间隔重算评分
+	for _, userID := range participants {
+		s.refreshScore(ctx, userID, model.RiskScoreTriggerRoundSettled)
+	}
+}
+
+// RunCollusionBatch 夜间全量串通分析，返回新建的风控标记数
+func (s *RiskControlService) RunCollusionBatch(ctx context.Context, now time.Time) (int, error) {
+	if s.collusion == nil {
+		return 0, nil
+	}
+	clusters, err := s.collusion.AnalyzeAll(ctx, now)
+	if err != nil {
+		return 0, err
+	}
+	created := 0
+	for _, cluster := range clusters {
+		n, err := s.flagCollusionCluster(ctx, cluster)
+		created += n
+		if err != nil {
+			return created, err
+		}
+	}
+	return created, nil
+}
+
+// flagCollusionCluster 为疑似串通簇的每个成员创建串通标记（已有待处理串通标记的成员跳过），返回新建标记数
+func (s *RiskControlService) flagCollusionCluster(ctx context.Context, cluster *model.CollusionCluster) (int, error) {
+	created := 0
+	for _, userID := range cluster.UserIDs {
+		hasPending, err := s.riskRepo.HasPendingFlag(ctx, userID, model.RiskFlagCollusion)
+		if err != nil {
+			s.logger.Error("Failed to check pending flag", zap.Error(err))
+			return created, err
+		}
+		if hasPending {
+			continue
+		}
+
+		related := make([]int64, 0, len(cluster.UserIDs)-1)
+		for _, id := range cluster.UserIDs {
+			if id != userID {
+				related = append(related, id)
+			}
+		}
+		details := &model.RiskFlagDetails{
+			RelatedUserIDs: related,
+			Collusion:      cluster,
+		}
+		if err := s.createRiskFlag(ctx, userID, model.RiskFlagCollusion, details); err != nil {
+			return created, err
+		}
+		created++
+	}
+	if created > 0 {
+		s.logger.Warn("Collusion detected",
+			zap.Int64s("users", cluster.UserIDs),
+			zap.Any("signals", cluster.Signals),
+			zap.Int("co_play_rounds", cluster.CoPlayRounds),
+			zap.Int("joint_wins", cluster.JointWins),
+			zap.Float64("expected_joint_wins", cluster.ExpectedJointWins))
+	}
+	return created, nil
+}
+
+// CheckOwners 每日房主对账后的房主维度风控检查，为命中的信号创建房主维度标记并告警
+// （房主已有同类型待处理标记时跳过）
+func (s *RiskControlService) CheckOwners(ctx context.Context, dayStart, dayEnd time.Time, summaries []*model.OwnerSnapshotSummary) {
+	if s.owners == nil {
+		return
+	}
+	findings, err := s.owners.Analyze(ctx, dayStart, dayEnd, summaries)
+	if err != nil {
+		s.logger.Error("Failed to analyze owner risk", zap.Error(err))
+	}
+	for _, finding := range findings {
+		if err := s.flagOwnerFinding(ctx, finding); err != nil {
+			s.logger.Error("Failed to flag owner risk",
+				zap.Int64("owner_id", finding.OwnerID),
+				zap.String("flag_type", string(finding.FlagType)),
+				zap.Error(err))
+		}
+	}
+}
+
+// flagOwnerFinding 为房主维度检测结果创建标记并触发房主异常告警
+func (s *RiskControlService) flagOwnerFinding(ctx context.Context, finding *model.OwnerRiskFinding) error {
+	hasPending, err := s.riskRepo.HasPendingFlag(ctx, finding.OwnerID, finding.FlagType)
+	if err != nil || hasPending {
+		return err
+	}
+
+	flag := &model.RiskFlag{
+		UserID:   finding.OwnerID,
+		FlagType: finding.FlagType,
+		Status:   model.RiskFlagStatusPending,
+		Severity: finding.Severity,
+	}
+	if err := s.riskRepo.CreateFlagWithDetails(ctx, flag, &model.RiskFlagDetails{Owner: finding.Signal}); err != nil {
+		return err
+	}
+	if s.alertManager != nil {
+		s.alertManager.TriggerOwnerAnomalyAlert(ctx, flag, finding.Signal)
+	}
+	s.logger.Warn("Owner anomaly detected",
+		zap.Int64("owner_id", finding.OwnerID),
+		zap.String("flag_type", string(finding.FlagType)),
+		zap.Any("signal", finding.Signal))
+
+	s.refreshScore(ctx, finding.OwnerID, model.RiskScoreTriggerFlagCreated)
+	return nil
+}
+
+// OnLogin 登录成功后的风控规则检查（设备指纹多账户检测见 CheckDeviceFingerprint）
+func (s *RiskControlService) OnLogin(ctx context.Context, event *model.LoginEvent) {
+	if s.rules == nil || event.UserID == nil {
+		return
+	}
+	userID, fingerprint := *event.UserID, event.DeviceFingerprint
+
+	deviceAccounts := 0
+	if fingerprint != "" {
+		userIDs, err := s.riskRepo.GetUsersByDeviceFingerprint(ctx, fingerprint)
+		if err != nil {
+			s.logger.Error("Failed to get users by fingerprint", zap.Error(err))
+			return
+		}
+		deviceAccounts = len(userIDs)
+	}
+	s.applyRules(ctx, model.RiskEventLogin, userID, ruleexpr.Vars{
+		"event.has_fingerprint": ruleexpr.Bool(fingerprint != ""),
+		"event.device_accounts": ruleexpr.Number(float64(deviceAccounts)),
+		"event.new_device":      ruleexpr.Bool(event.NewDevice),
+	})
+}
+
+// CheckNewDeviceWithdrawal 检查提现申请是否紧随新设备登录（账户被盗后转移资金的典型特征）
+func (s *RiskControlService) CheckNewDeviceWithdrawal(ctx context.Context, req *model.FundRequest) error {
+	if s.loginHistory == nil {
+		return nil
+	}
+	if req.Type != model.FundRequestWithdraw && req.Type != model.FundRequestOwnerWithdraw {
+		return nil
+	}
+
+	login, err := s.loginHistory.RecentNewDeviceLogin(ctx, req.UserID, req.CreatedAt)
+	if err != nil {
+		s.logger.Error("Failed to get recent login", zap.Int64("user_id", req.UserID), zap.Error(err))
+		return err
+	}
+	if login == nil {
+		return nil
+	}
+
+	hasPending, err := s.riskRepo.HasPendingFlag(ctx, req.UserID, model.RiskFlagNewDeviceWithdraw)
+	if err != nil {
+		s.logger.Error("Failed to check pending flag", zap.Error(err))
+		return err
+	}
+	if hasPending {
+		return nil
+	}
+
+	requestID := req.ID
+	details := &model.RiskFlagDetails{
+		DeviceFingerprint: login.DeviceFingerprint,
+		TransactionAmount: req.Amount,
+		LoginEventID:      &login.ID,
+		LoginIP:           login.IP,
+		LoginGeo:          login.GeoLabel,
+		LoginAt:           &login.CreatedAt,
+		FundRequestID:     &requestID,
+	}
+	if err := s.createRiskFlag(ctx, req.UserID, model.RiskFlagNewDeviceWithdraw, details); err != nil {
+		return err
+	}
+	s.logger.Warn("Withdrawal right after new device login",
+		zap.Int64("user_id", req.UserID),
+		zap.Int64("fund_request_id", req.ID),
+		zap.String("ip", login.IP),
+		zap.Time("login_at", login.CreatedAt),
+		zap.String("amount", req.Amount.String()))
+
+	return nil
+}
+
+// OnFundRequest 资金申请提交后的风控检查（新设备登录后立即提现）
+// 大额、日交易量与资金申请规则只在审批通过、资金实际变动后检查一次（见 OnFundEvent）
+func (s *RiskControlService) OnFundRequest(ctx context.Context, req *model.FundRequest) {
+	defer s.refreshScore(ctx, req.UserID, model.RiskScoreTriggerFundRequest)
+
+	if err := s.CheckNewDeviceWithdrawal(ctx, req); err != nil {
+		s.logger.Error("Failed to check new device withdrawal", zap.Int64("user_id", req.UserID), zap.Error(err))
+	}
+}
+
+// applyRules 对事件求值风控规则并执行命中规则的动作
+func (s *RiskControlService) applyRules(ctx context.Context, event model.RiskRuleEvent, userID int64, preset ruleexpr.Vars) {
+	for _, hit := range s.rules.Evaluate(ctx, event, userID, preset) {
+		if err := s.applyRuleHit(ctx, event, userID, hit); err != nil {
+			s.logger.Error("Failed to apply risk rule",
+				zap.Int64("rule_id", hit.Rule.ID),
+				zap.Int64("user_id", userID),
+				zap.Error(err))
+		}
+	}
+}
+
+// applyRuleHit 执行命中规则的动作：flag 创建待审核标记（同一规则对同一用户仅保留一个待处理标记）并告警，alert 仅告警
+func (s *RiskControlService) applyRuleHit(ctx context.Context, event model.RiskRuleEvent, userID int64, hit *model.RiskRuleHit) error {
+	rule := hit.Rule
+	var flagID *int64
+
+	if rule.Action == model.RiskActionFlag {
+		hasPending, err := s.riskRepo.HasPendingRuleFlag(ctx, userID, rule.ID, rule.FlagType)
+		if err != nil {
+			return err
+		}
+		if hasPending {
+			return nil
+		}
+
+		ruleID := rule.ID
+		flag := &model.RiskFlag{
+			UserID:   userID,
+			FlagType: rule.FlagType,
+			Status:   model.RiskFlagStatusPending,
+			Severity: rule.Severity,
+			RuleID:   &ruleID,
+		}
+		details := &model.RiskFlagDetails{
+			RuleID:      &ruleID,
+			RuleName:    rule.Name,
+			RuleVersion: rule.Version,
+			Event:       event,
+			Variables:   hit.Variables,
+		}
+		if err := s.riskRepo.CreateFlagWithDetails(ctx, flag, details); err != nil {
+			return err
+		}
+		flagID = &flag.ID
+		s.refreshScore(ctx, userID, model.RiskScoreTriggerFlagCreated)
+	}
+
+	if s.alertManager != nil {
+		s.alertManager.TriggerRiskRuleAlert(ctx, rule, userID, flagID, hit.Variables)
+	}
+
+	// 按规则严重程度自动施加账户限制
+	if s.restrictions != nil {
+		if _, err := s.restrictions.ApplyForRuleHit(ctx, userID, rule, flagID); err != nil {
+			s.logger.Error("Apply automatic restriction failed",
+				zap.Int64("rule_id", rule.ID),
+				zap.Int64("user_id", userID),
+				zap.Error(err))
+		}
+	}
+
+	s.logger.Warn("Risk rule fired",
+		zap.Int64("rule_id", rule.ID),
+		zap.Int("version", rule.Version),
+		zap.String("event", string(event)),
+		zap.String("action", string(rule.Action)),
+		zap.Int64("user_id", userID),
+		zap.Any("variables", hit.Variables))
+	return nil
 }
 
 // GetFlagDetails 解析风控标记详情
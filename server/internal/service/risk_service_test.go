@@ -0,0 +1,90 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/fiveseconds/server/internal/model"
+
+	"github.com/shopspring/decimal"
+)
+
+// TestFundVelocityExceeded 测试充值或提现笔数超过上限时命中，上限不大于 0 时不检查
+func TestFundVelocityExceeded(t *testing.T) {
+	tests := []struct {
+		name           string
+		maxDeposits    int
+		maxWithdrawals int
+		deposits       int
+		withdrawals    int
+		want           bool
+	}{
+		{"within limits", 5, 3, 5, 3, false},
+		{"deposits over limit", 5, 3, 6, 0, true},
+		{"withdrawals over limit", 5, 3, 0, 4, true},
+		{"deposit limit disabled", 0, 3, 100, 3, false},
+		{"withdrawal limit disabled", 5, 0, 5, 100, false},
+		{"both disabled", 0, 0, 100, 100, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := model.DefaultRiskConfig
+			cfg.MaxDepositsPerWindow = tt.maxDeposits
+			cfg.MaxWithdrawalsPerWindow = tt.maxWithdrawals
+			if got := fundVelocityExceeded(cfg, tt.deposits, tt.withdrawals); got != tt.want {
+				t.Errorf("Expected exceeded=%v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+// TestIsQuickWithdrawal 测试窗口内有充值且下注金额低于充值金额的比例时判定为快进快出
+func TestIsQuickWithdrawal(t *testing.T) {
+	half := decimal.RequireFromString("0.5")
+	tests := []struct {
+		name    string
+		deposit int64
+		wagered int64
+		ratio   decimal.Decimal
+		want    bool
+	}{
+		{"no deposit", 0, 0, half, false},
+		{"no play after deposit", 1000, 0, half, true},
+		{"play just below the ratio", 1000, 499, half, true},
+		{"play at the ratio", 1000, 500, half, false},
+		{"play above the deposit", 1000, 1500, half, false},
+		{"zero ratio never hits", 1000, 0, decimal.Zero, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := &model.FundVelocity{DepositAmount: decimal.NewFromInt(tt.deposit), Wagered: decimal.NewFromInt(tt.wagered)}
+			if got := isQuickWithdrawal(v, tt.ratio); got != tt.want {
+				t.Errorf("Expected quick withdrawal=%v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+// TestVolumeAlertDue 测试日交易量告警每个自然日最多一次
+func TestVolumeAlertDue(t *testing.T) {
+	now := time.Date(2026, 3, 1, 23, 30, 0, 0, time.UTC)
+	tests := []struct {
+		name        string
+		lastAlerted time.Time
+		want        bool
+	}{
+		{"never alerted", time.Time{}, true},
+		{"alerted earlier today", time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), false},
+		{"alerted a minute ago", now.Add(-time.Minute), false},
+		{"alerted yesterday", time.Date(2026, 2, 28, 23, 59, 0, 0, time.UTC), true},
+		{"same day of another month", time.Date(2026, 2, 1, 23, 30, 0, 0, time.UTC), true},
+		{"same day of another year", time.Date(2025, 3, 1, 23, 30, 0, 0, time.UTC), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := volumeAlertDue(tt.lastAlerted, now); got != tt.want {
+				t.Errorf("Expected due=%v, got %v", tt.want, got)
+			}
+		})
+	}
+}
@@ -3,7 +3,10 @@ package service
 import (
 	"context"
 	"errors"
+	"sort"
+	"strings"
 
+	"github.com/fiveseconds/server/internal/config"
 	"github.com/fiveseconds/server/internal/game"
 	"github.com/fiveseconds/server/internal/model"
 	"github.com/fiveseconds/server/internal/repository"
@@ -19,6 +22,11 @@ var (
 	ErrNotInRoom        = errors.New("not in room")
 	ErrInvalidBetAmount = errors.New("invalid bet amount")
 	ErrInvalidPassword  = errors.New("invalid room password")
+	ErrInvalidRoomType  = errors.New("invalid room type")
+
+	ErrTournamentRequired = errors.New("tournament_id is required for tournament rooms")
+
+	ErrUnsupportedCurrency = errors.New("unsupported currency")
 )
 
 const (
@@ -26,20 +34,107 @@ const (
 	MinPlayers = 2
 )
 
-// 有效的下注金额
-var ValidBetAmounts = []decimal.Decimal{
-	decimal.NewFromInt(5),
-	decimal.NewFromInt(10),
-	decimal.NewFromInt(20),
-	decimal.NewFromInt(50),
-	decimal.NewFromInt(100),
-	decimal.NewFromInt(200),
+// 有效的下注金额（按币种，启动时由 ConfigureCurrencies 按配置覆盖）
+var ValidBetAmounts = map[string][]decimal.Decimal{
+	model.DefaultCurrency: {
+		decimal.NewFromInt(5),
+		decimal.NewFromInt(10),
+		decimal.NewFromInt(20),
+		decimal.NewFromInt(50),
+		decimal.NewFromInt(100),
+		decimal.NewFromInt(200),
+	},
+}
+
+// DefaultCurrency 默认币种（房主未指定经营币种、管理员邀请的玩家使用）
+var DefaultCurrency = model.DefaultCurrency
+
+// ConfigureCurrencies 按配置设置默认币种与各币种有效下注金额
+// 未配置下注金额的默认币种沿用内置金额
+func ConfigureCurrencies(cfg config.CurrencyConfig) {
+	if def := NormalizeCurrency(cfg.Default); def != "" {
+		DefaultCurrency = def
+	}
+	if len(cfg.BetAmounts) == 0 {
+		return
+	}
+	amounts := parseBetAmounts(cfg.BetAmounts)
+	if _, ok := amounts[DefaultCurrency]; !ok {
+		if builtin, ok := ValidBetAmounts[DefaultCurrency]; ok {
+			amounts[DefaultCurrency] = builtin
+		}
+	}
+	ValidBetAmounts = amounts
+}
+
+// parseBetAmounts 解析配置的下注金额：币种转大写，金额保留两位小数、去重并升序，忽略非正数
+func parseBetAmounts(raw map[string][]float64) map[string][]decimal.Decimal {
+	result := make(map[string][]decimal.Decimal, len(raw))
+	for currency, values := range raw {
+		currency = NormalizeCurrency(currency)
+		if currency == "" {
+			continue
+		}
+		seen := make(map[string]bool)
+		amounts := result[currency]
+		for _, a := range amounts {
+			seen[a.String()] = true
+		}
+		for _, v := range values {
+			amount := decimal.NewFromFloat(v).Round(2)
+			if !amount.IsPositive() || seen[amount.String()] {
+				continue
+			}
+			seen[amount.String()] = true
+			amounts = append(amounts, amount)
+		}
+		if len(amounts) == 0 {
+			continue
+		}
+		sort.Slice(amounts, func(i, j int) bool { return amounts[i].LessThan(amounts[j]) })
+		result[currency] = amounts
+	}
+	return result
+}
+
+// NormalizeCurrency 规范化币种代码（去空白、转大写）
+func NormalizeCurrency(currency string) string {
+	return strings.ToUpper(strings.TrimSpace(currency))
+}
+
+// IsSupportedCurrency 是否为已配置的币种
+func IsSupportedCurrency(currency string) bool {
+	_, ok := ValidBetAmounts[currency]
+	return ok
+}
+
+// SupportedCurrencies 已配置的全部币种（升序）
+func SupportedCurrencies() []string {
+	currencies := make([]string, 0, len(ValidBetAmounts))
+	for currency := range ValidBetAmounts {
+		currencies = append(currencies, currency)
+	}
+	sort.Strings(currencies)
+	return currencies
+}
+
+// OwnerCreditGuard 房主信用额度检查（超限房主不能新建或恢复房间）
+type OwnerCreditGuard interface {
+	CheckOwnerCredit(ctx context.Context, ownerID int64) error
+}
+
+// TournamentRoomGuard 锦标赛房间检查（创建房间时校验锦标赛，加入房间时校验报名）
+type TournamentRoomGuard interface {
+	CheckTournamentRoom(ctx context.Context, ownerID, tournamentID int64, betAmount decimal.Decimal) (*model.Tournament, error)
+	CheckEntrant(ctx context.Context, tournamentID, userID int64) error
 }
 
 type RoomService struct {
-	roomRepo *repository.RoomRepo
-	userRepo *repository.UserRepo
-	manager  *game.Manager
+	roomRepo        *repository.RoomRepo
+	userRepo        *repository.UserRepo
+	manager         *game.Manager
+	creditGuard     OwnerCreditGuard
+	tournamentGuard TournamentRoomGuard
 }
 
 func NewRoomService(roomRepo *repository.RoomRepo, userRepo *repository.UserRepo, manager *game.Manager) *RoomService {
@@ -50,6 +145,16 @@ func NewRoomService(roomRepo *repository.RoomRepo, userRepo *repository.UserRepo
 	}
 }
 
+// SetCreditGuard 设置房主信用额度检查
+func (s *RoomService) SetCreditGuard(guard OwnerCreditGuard) {
+	s.creditGuard = guard
+}
+
+// SetTournamentGuard 设置锦标赛房间检查（未设置时不能创建锦标赛房间）
+func (s *RoomService) SetTournamentGuard(guard TournamentRoomGuard) {
+	s.tournamentGuard = guard
+}
+
 // MinMarginBalanceForRoom 创建房间所需的最低保证金
 var MinMarginBalanceForRoom = decimal.NewFromInt(2000)
 
@@ -58,13 +163,31 @@ var ErrInsufficientMarginForRoom = errors.New("insufficient margin balance, mini
 
 // CreateRoom 创建房间
 func (s *RoomService) CreateRoom(ctx context.Context, ownerID int64, req *model.CreateRoomReq) (*model.Room, error) {
-	// 验证房主保证金余额
+	roomType := model.RoomType(req.RoomType)
+	if roomType == "" {
+		roomType = model.RoomTypeStandard
+	}
+	if roomType != model.RoomTypeStandard && roomType != model.RoomTypePractice && roomType != model.RoomTypeTournament {
+		return nil, ErrInvalidRoomType
+	}
+	if roomType == model.RoomTypeTournament && s.tournamentGuard == nil {
+		return nil, ErrInvalidRoomType
+	}
+
+	// 验证房主保证金余额（练习房、锦标赛房间以筹码下注，无需保证金与信用额度）
 	owner, err := s.userRepo.GetByID(ctx, ownerID)
 	if err != nil {
 		return nil, err
 	}
-	if owner.OwnerMarginBalance.LessThan(MinMarginBalanceForRoom) {
-		return nil, ErrInsufficientMarginForRoom
+	if roomType == model.RoomTypeStandard {
+		if owner.OwnerMarginBalance.LessThan(MinMarginBalanceForRoom) {
+			return nil, ErrInsufficientMarginForRoom
+		}
+		if s.creditGuard != nil {
+			if err := s.creditGuard.CheckOwnerCredit(ctx, ownerID); err != nil {
+				return nil, err
+			}
+		}
 	}
 
 	betAmount := req.GetBetAmountDecimal()
@@ -75,10 +198,25 @@ func (s *RoomService) CreateRoom(ctx context.Context, ownerID int64, req *model.
 	if betAmount.IsZero() {
 		return nil, errors.New("invalid bet amount format")
 	}
-	if !isValidBetAmount(betAmount) {
+	if !isValidBetAmount(owner.Currency, betAmount) {
 		return nil, ErrInvalidBetAmount
 	}
 
+	// 锦标赛房间：锦标赛须属于房主且未结束，下注筹码不超过初始筹码
+	var tournamentID *int64
+	currency := owner.Currency
+	if roomType == model.RoomTypeTournament {
+		if req.TournamentID <= 0 {
+			return nil, ErrTournamentRequired
+		}
+		tournament, err := s.tournamentGuard.CheckTournamentRoom(ctx, ownerID, req.TournamentID, betAmount)
+		if err != nil {
+			return nil, err
+		}
+		tournamentID = &tournament.ID
+		currency = tournament.Currency
+	}
+
 	// 验证赢家数量必须小于最大玩家数
 	if req.WinnerCount >= req.MaxPlayers {
 		return nil, errors.New("winner count must be less than max players")
@@ -109,6 +247,12 @@ func (s *RoomService) CreateRoom(ctx context.Context, ownerID int64, req *model.
 		return nil, errors.New("total commission rate cannot exceed 10%")
 	}
 
+	// 练习房、锦标赛房间无房主抽成与平台抽成
+	if roomType != model.RoomTypeStandard {
+		ownerCommissionRate = decimal.Zero
+		platformCommissionRate = decimal.Zero
+	}
+
 	// 生成房间邀请码
 	var inviteCode string
 	for {
@@ -130,7 +274,10 @@ func (s *RoomService) CreateRoom(ctx context.Context, ownerID int64, req *model.
 		OwnerID:                ownerID,
 		Name:                   req.Name,
 		InviteCode:             inviteCode,
+		RoomType:               roomType,
+		TournamentID:           tournamentID,
 		BetAmount:              betAmount,
+		Currency:               currency,
 		WinnerCount:            req.WinnerCount,
 		MaxPlayers:             req.MaxPlayers,
 		OwnerCommissionRate:    ownerCommissionRate,
@@ -184,6 +331,8 @@ func (s *RoomService) ListRooms(ctx context.Context, query *model.RoomListQuery)
 			Room:           room,
 			CurrentPlayers: count,
 			HasPassword:    room.Password != nil && *room.Password != "",
+			IsPractice:     room.IsPractice(),
+			IsTournament:   room.IsTournament(),
 		})
 	}
 
@@ -210,7 +359,7 @@ func (s *RoomService) UpdateRoom(ctx context.Context, roomID, ownerID int64, req
 		room.Name = req.Name
 	}
 	if !req.BetAmount.IsZero() {
-		if !isValidBetAmount(req.BetAmount) {
+		if !isValidBetAmount(room.Currency, req.BetAmount) {
 			return ErrInvalidBetAmount
 		}
 		room.BetAmount = req.BetAmount
@@ -235,6 +384,12 @@ func (s *RoomService) UpdateRoomStatus(ctx context.Context, roomID, ownerID int6
 	if room.OwnerID != ownerID {
 		return ErrNotRoomOwner
 	}
+	// 信用额度超限的房主不能恢复房间（练习房、锦标赛房间不受限）
+	if status == model.RoomStatusActive && s.creditGuard != nil && !room.UsesChips() {
+		if err := s.creditGuard.CheckOwnerCredit(ctx, ownerID); err != nil {
+			return err
+		}
+	}
 
 	return s.roomRepo.UpdateStatus(ctx, roomID, status)
 }
@@ -262,6 +417,24 @@ func (s *RoomService) JoinRoom(ctx context.Context, userID, roomID int64, passwo
 		}
 	}
 
+	// 活跃币种必须与房间币种一致（不一致时只能观战，练习房使用游戏币不限币种）
+	// 锦标赛房间以锦标赛筹码下注，不限币种，但须已报名
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	switch {
+	case room.IsTournament():
+		if s.tournamentGuard == nil || room.TournamentID == nil {
+			return game.ErrNotTournamentEntrant
+		}
+		if err := s.tournamentGuard.CheckEntrant(ctx, *room.TournamentID, userID); err != nil {
+			return err
+		}
+	case !room.IsPractice() && user.Currency != room.Currency:
+		return game.ErrCurrencyMismatch
+	}
+
 	// 检查是否已在其他房间
 	currentRoom, err := s.roomRepo.GetPlayerRoom(ctx, userID)
 	if err != nil {
@@ -303,8 +476,7 @@ func (s *RoomService) JoinRoom(ctx context.Context, userID, roomID int64, passwo
 	}
 
 	// 通知游戏引擎
-	user, _ := s.userRepo.GetByID(ctx, userID)
-	if processor, err := s.manager.GetOrCreateRoom(ctx, roomID); err == nil && user != nil {
+	if processor, err := s.manager.GetOrCreateRoom(ctx, roomID); err == nil {
 		processor.AddPlayer(user)
 	}
 
@@ -366,8 +538,8 @@ func (s *RoomService) GetPlayerRoom(ctx context.Context, userID int64) (*model.R
 	return s.roomRepo.GetPlayerRoom(ctx, userID)
 }
 
-func isValidBetAmount(amount decimal.Decimal) bool {
-	for _, valid := range ValidBetAmounts {
+func isValidBetAmount(currency string, amount decimal.Decimal) bool {
+	for _, valid := range ValidBetAmounts[currency] {
 		if amount.Equal(valid) {
 			return true
 		}
@@ -0,0 +1,47 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestParseBetAmounts 测试各币种下注金额配置解析：币种规范化、金额保留两位小数、去重、升序，剔除无效金额
+func TestParseBetAmounts(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  map[string][]float64
+		want map[string][]string
+	}{
+		{"sorted and deduplicated", map[string][]float64{"CNY": {100, 10, 50, 10}}, map[string][]string{"CNY": {"10", "50", "100"}}},
+		{"rounded to cents", map[string][]float64{"USD": {1.005, 0.499, 2.5}}, map[string][]string{"USD": {"0.5", "1.01", "2.5"}}},
+		{"duplicates after rounding", map[string][]float64{"USD": {1.001, 1.004, 1}}, map[string][]string{"USD": {"1"}}},
+		{"non-positive dropped", map[string][]float64{"CNY": {-5, 0, 0.004, 20}}, map[string][]string{"CNY": {"20"}}},
+		{"currency without valid amounts omitted", map[string][]float64{"CNY": {10}, "USD": {0, -1}}, map[string][]string{"CNY": {"10"}}},
+		{"currency code normalized", map[string][]float64{" usd ": {5}}, map[string][]string{"USD": {"5"}}},
+		{"blank currency ignored", map[string][]float64{"  ": {5}}, map[string][]string{}},
+	}
+	for _, tt := range tests {
+		parsed := parseBetAmounts(tt.raw)
+		got := make(map[string][]string, len(parsed))
+		for currency, amounts := range parsed {
+			for _, amount := range amounts {
+				got[currency] = append(got[currency], amount.String())
+			}
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: Expected %v, got %v", tt.name, tt.want, got)
+		}
+	}
+}
+
+// TestParseBetAmountsMergesNormalizedCodes 测试规范化后相同的币种代码合并为一组金额
+func TestParseBetAmountsMergesNormalizedCodes(t *testing.T) {
+	parsed := parseBetAmounts(map[string][]float64{"usd": {5, 1}, "USD": {1, 10}})
+	var got []string
+	for _, amount := range parsed["USD"] {
+		got = append(got, amount.String())
+	}
+	if want := []string{"1", "5", "10"}; len(parsed) != 1 || !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected only USD %v, got %v", want, parsed)
+	}
+}
@@ -0,0 +1,266 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/fiveseconds/server/internal/config"
+	"github.com/fiveseconds/server/internal/model"
+	"github.com/fiveseconds/server/internal/repository"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrSettlementNotFound      = errors.New("settlement not found")
+	ErrSettlementForbidden     = errors.New("this settlement does not belong to you")
+	ErrSettlementInvalidPeriod = errors.New("invalid settlement period type, use daily or weekly")
+	ErrSettlementPeriodOpen    = errors.New("settlement period has not ended yet")
+)
+
+// SettlementService 房主佣金结算服务
+// 按周期快照房主各房间的佣金与平台抽成，生成不可变结算单，并可自动将佣金转入房主可用余额
+type SettlementService struct {
+	userRepo       *repository.UserRepo
+	settlementRepo *repository.SettlementRepo
+	walletService  *WalletService
+	fundEvents     FundEventHook
+	cfg            *config.Config
+	logger         *zap.Logger
+}
+
+// NewSettlementService 创建房主佣金结算服务
+func NewSettlementService(
+	userRepo *repository.UserRepo,
+	settlementRepo *repository.SettlementRepo,
+	walletService *WalletService,
+	cfg *config.Config,
+	logger *zap.Logger,
+) *SettlementService {
+	return &SettlementService{
+		userRepo:       userRepo,
+		settlementRepo: settlementRepo,
+		walletService:  walletService,
+		cfg:            cfg,
+		logger:         logger.With(zap.String("service", "settlement")),
+	}
+}
+
+// SetFundEventHook 设置资金变动事件钩子（佣金自动转入可用余额后触发）
+func (s *SettlementService) SetFundEventHook(hook FundEventHook) {
+	s.fundEvents = hook
+}
+
+// periodType 获取配置的结算周期，默认按日
+func (s *SettlementService) periodType() model.SettlementPeriodType {
+	if s.cfg != nil && s.cfg.Settlement.Period == string(model.SettlementPeriodWeekly) {
+		return model.SettlementPeriodWeekly
+	}
+	return model.SettlementPeriodDaily
+}
+
+// settlementPeriodContaining 计算包含 t 的结算周期 [start, end)
+// 日结以自然日划分，周结以周一 00:00 为起点
+func settlementPeriodContaining(periodType model.SettlementPeriodType, t time.Time) (time.Time, time.Time) {
+	dayStart := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	if periodType == model.SettlementPeriodWeekly {
+		offset := (int(dayStart.Weekday()) + 6) % 7 // 周一为 0
+		start := dayStart.AddDate(0, 0, -offset)
+		return start, start.AddDate(0, 0, 7)
+	}
+	return dayStart, dayStart.AddDate(0, 0, 1)
+}
+
+// lastCompletedPeriod 计算 now 之前最近一个已结束的结算周期
+func lastCompletedPeriod(periodType model.SettlementPeriodType, now time.Time) (time.Time, time.Time) {
+	currentStart, _ := settlementPeriodContaining(periodType, now)
+	return settlementPeriodContaining(periodType, currentStart.Add(-time.Nanosecond))
+}
+
+// RunDueSettlements 为最近一个已结束周期生成所有房主的结算单（定时任务调用，可重复执行）
+func (s *SettlementService) RunDueSettlements(ctx context.Context, now time.Time) (int, error) {
+	periodType := s.periodType()
+	start, end := lastCompletedPeriod(periodType, now)
+	return s.SettlePeriod(ctx, periodType, start, end)
+}
+
+// SettlePeriod 为指定周期内有已结算回合的房主生成结算单，已结算的房主跳过
+func (s *SettlementService) SettlePeriod(ctx context.Context, periodType model.SettlementPeriodType, start, end time.Time) (int, error) {
+	ownerIDs, err := s.settlementRepo.ListOwnersToSettle(ctx, periodType, start, end)
+	if err != nil {
+		return 0, fmt.Errorf("list owners to settle: %w", err)
+	}
+
+	created := 0
+	for _, ownerID := range ownerIDs {
+		settlement, err := s.settleOwner(ctx, ownerID, periodType, start, end)
+		if err != nil {
+			if errors.Is(err, repository.ErrSettlementExists) {
+				continue
+			}
+			s.logger.Error("settle owner failed",
+				zap.Int64("owner_id", ownerID),
+				zap.Time("period_start", start),
+				zap.Error(err))
+			continue
+		}
+		created++
+		s.logger.Info("owner settlement created",
+			zap.Int64("settlement_id", settlement.ID),
+			zap.Int64("owner_id", ownerID),
+			zap.String("owner_earning", settlement.OwnerEarning.String()),
+			zap.String("transferred", settlement.TransferredAmount.String()))
+	}
+	return created, nil
+}
+
+// settleOwner 生成单个房主的结算单，开启自动转入时在同一事务中将佣金转入可用余额
+func (s *SettlementService) settleOwner(ctx context.Context, ownerID int64, periodType model.SettlementPeriodType, start, end time.Time) (*model.OwnerSettlement, error) {
+	items, err := s.settlementRepo.AggregateRoomEarnings(ctx, ownerID, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	settlement := &model.OwnerSettlement{
+		OwnerID:     ownerID,
+		PeriodType:  periodType,
+		PeriodStart: start,
+		PeriodEnd:   end,
+		Items:       items,
+	}
+	for _, item := range items {
+		settlement.RoundCount += item.RoundCount
+		settlement.PoolAmount = settlement.PoolAmount.Add(item.PoolAmount)
+		settlement.OwnerEarning = settlement.OwnerEarning.Add(item.OwnerEarning)
+		settlement.PlatformEarning = settlement.PlatformEarning.Add(item.PlatformEarning)
+	}
+
+	var owner *model.User
+	if s.cfg != nil && s.cfg.Settlement.AutoTransfer && s.walletService != nil {
+		owner, err = s.userRepo.GetByID(ctx, ownerID)
+		if err != nil {
+			return nil, err
+		}
+		// 房主可能已手动转出部分佣金，最多转入当前佣金余额
+		settlement.TransferredAmount = decimal.Min(settlement.OwnerEarning, owner.OwnerRoomBalance)
+		if settlement.TransferredAmount.IsNegative() {
+			settlement.TransferredAmount = decimal.Zero
+		}
+	}
+
+	err = repository.Tx(ctx, func(tx pgx.Tx) error {
+		if err := s.settlementRepo.CreateTx(ctx, tx, settlement); err != nil {
+			return err
+		}
+		if owner == nil || !settlement.TransferredAmount.IsPositive() {
+			return nil
+		}
+		remark := fmt.Sprintf("佣金结算自动转入(结算单ID:%d)", settlement.ID)
+		return s.walletService.TransferEarningsToBalanceTx(ctx, tx, owner, settlement.TransferredAmount, remark)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if owner != nil && settlement.TransferredAmount.IsPositive() {
+		dispatchFundEvent(s.fundEvents, &model.FundEvent{
+			Type:       model.FundEventSettlementCredit,
+			UserID:     ownerID,
+			Amount:     settlement.TransferredAmount,
+			Currency:   owner.Currency,
+			RefID:      settlement.ID,
+			OccurredAt: time.Now(),
+		})
+	}
+	return settlement, nil
+}
+
+// SettlePeriodOf 管理员手动补结指定日期所在周期（周期必须已结束）
+func (s *SettlementService) SettlePeriodOf(ctx context.Context, periodType model.SettlementPeriodType, date time.Time) (int, error) {
+	if periodType != model.SettlementPeriodDaily && periodType != model.SettlementPeriodWeekly {
+		return 0, ErrSettlementInvalidPeriod
+	}
+	start, end := settlementPeriodContaining(periodType, date)
+	if end.After(time.Now()) {
+		return 0, ErrSettlementPeriodOpen
+	}
+	return s.SettlePeriod(ctx, periodType, start, end)
+}
+
+// GetSettlement 获取结算单详情（房主只能查看自己的结算单）
+func (s *SettlementService) GetSettlement(ctx context.Context, id, operatorID int64, operatorRole model.Role) (*model.OwnerSettlement, error) {
+	settlement, err := s.settlementRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrSettlementNotFound
+		}
+		return nil, err
+	}
+	if operatorRole != model.RoleAdmin && settlement.OwnerID != operatorID {
+		return nil, ErrSettlementForbidden
+	}
+	return settlement, nil
+}
+
+// ListSettlements 分页获取结算单
+func (s *SettlementService) ListSettlements(ctx context.Context, query *model.SettlementListQuery) ([]*model.OwnerSettlement, int64, error) {
+	return s.settlementRepo.List(ctx, query)
+}
+
+// ReconcileSettlement 将结算单与 game_rounds 当前汇总逐房间比对
+// 用于排查“佣金缺失”争议：差异通常来自结算后才落库的回合或人工修改的回合数据
+func (s *SettlementService) ReconcileSettlement(ctx context.Context, id, operatorID int64, operatorRole model.Role) (*model.SettlementReconcileResult, error) {
+	settlement, err := s.GetSettlement(ctx, id, operatorID, operatorRole)
+	if err != nil {
+		return nil, err
+	}
+	actual, err := s.settlementRepo.AggregateRoomEarnings(ctx, settlement.OwnerID, settlement.PeriodStart, settlement.PeriodEnd)
+	if err != nil {
+		return nil, err
+	}
+	return reconcileSettlement(settlement, actual), nil
+}
+
+// reconcileSettlement 按房间比对结算快照与实际汇总
+func reconcileSettlement(settlement *model.OwnerSettlement, actual []*model.OwnerSettlementItem) *model.SettlementReconcileResult {
+	result := &model.SettlementReconcileResult{Settlement: settlement, IsReconciled: true}
+	byRoom := make(map[int64]*model.SettlementReconcileItem)
+	var order []int64
+	get := func(roomID int64) *model.SettlementReconcileItem {
+		item, ok := byRoom[roomID]
+		if !ok {
+			item = &model.SettlementReconcileItem{RoomID: roomID}
+			byRoom[roomID] = item
+			order = append(order, roomID)
+		}
+		return item
+	}
+
+	for _, settled := range settlement.Items {
+		item := get(settled.RoomID)
+		item.SettledRounds = settled.RoundCount
+		item.SettledOwnerEarning = settled.OwnerEarning
+		item.SettledPlatformShare = settled.PlatformEarning
+	}
+	for _, a := range actual {
+		item := get(a.RoomID)
+		item.ActualRounds = a.RoundCount
+		item.ActualOwnerEarning = a.OwnerEarning
+		item.ActualPlatformShare = a.PlatformEarning
+	}
+
+	for _, roomID := range order {
+		item := byRoom[roomID]
+		item.OwnerEarningDiff = item.ActualOwnerEarning.Sub(item.SettledOwnerEarning)
+		result.TotalDiff = result.TotalDiff.Add(item.OwnerEarningDiff)
+		if !item.OwnerEarningDiff.IsZero() || item.SettledRounds != item.ActualRounds ||
+			!item.ActualPlatformShare.Equal(item.SettledPlatformShare) {
+			result.IsReconciled = false
+		}
+		result.Items = append(result.Items, item)
+	}
+	return result
+}
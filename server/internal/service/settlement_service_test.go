@@ -0,0 +1,116 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/fiveseconds/server/internal/config"
+	"github.com/fiveseconds/server/internal/model"
+	"github.com/shopspring/decimal"
+)
+
+// TestSettlementPeriods 测试结算周期划分：日结按自然日，周结从周一 00:00 开始
+func TestSettlementPeriods(t *testing.T) {
+	cst := time.FixedZone("CST", 8*3600)
+	at := func(s string) time.Time {
+		v, err := time.ParseInLocation("2006-01-02 15:04", s, cst)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return v
+	}
+	tests := []struct {
+		name       string
+		periodType model.SettlementPeriodType
+		now        string
+		start, end string // 包含 now 的周期
+		prevStart  string // 上一个已结束周期的起点（终点为 start）
+	}{
+		{"daily midday", model.SettlementPeriodDaily, "2024-03-13 15:30", "2024-03-13 00:00", "2024-03-14 00:00", "2024-03-12 00:00"},
+		{"daily at midnight", model.SettlementPeriodDaily, "2024-03-13 00:00", "2024-03-13 00:00", "2024-03-14 00:00", "2024-03-12 00:00"},
+		{"daily across month end", model.SettlementPeriodDaily, "2024-03-01 08:00", "2024-03-01 00:00", "2024-03-02 00:00", "2024-02-29 00:00"},
+		{"daily across year end", model.SettlementPeriodDaily, "2025-01-01 23:59", "2025-01-01 00:00", "2025-01-02 00:00", "2024-12-31 00:00"},
+		{"weekly on wednesday", model.SettlementPeriodWeekly, "2024-03-13 15:30", "2024-03-11 00:00", "2024-03-18 00:00", "2024-03-04 00:00"},
+		{"weekly on monday midnight", model.SettlementPeriodWeekly, "2024-03-11 00:00", "2024-03-11 00:00", "2024-03-18 00:00", "2024-03-04 00:00"},
+		{"weekly on sunday night", model.SettlementPeriodWeekly, "2024-03-17 23:59", "2024-03-11 00:00", "2024-03-18 00:00", "2024-03-04 00:00"},
+		{"weekly across year end", model.SettlementPeriodWeekly, "2025-01-01 12:00", "2024-12-30 00:00", "2025-01-06 00:00", "2024-12-23 00:00"},
+	}
+	for _, tt := range tests {
+		start, end := settlementPeriodContaining(tt.periodType, at(tt.now))
+		if !start.Equal(at(tt.start)) || !end.Equal(at(tt.end)) {
+			t.Errorf("%s: Expected period [%s, %s), got [%s, %s)", tt.name, tt.start, tt.end, start, end)
+		}
+		prevStart, prevEnd := lastCompletedPeriod(tt.periodType, at(tt.now))
+		if !prevStart.Equal(at(tt.prevStart)) || !prevEnd.Equal(at(tt.start)) {
+			t.Errorf("%s: Expected last completed period [%s, %s), got [%s, %s)", tt.name, tt.prevStart, tt.start, prevStart, prevEnd)
+		}
+	}
+}
+
+// TestSettlementPeriodType 测试结算周期配置，未配置或无效值按日结算
+func TestSettlementPeriodType(t *testing.T) {
+	tests := []struct {
+		period string
+		want   model.SettlementPeriodType
+	}{
+		{"weekly", model.SettlementPeriodWeekly},
+		{"daily", model.SettlementPeriodDaily},
+		{"", model.SettlementPeriodDaily},
+		{"monthly", model.SettlementPeriodDaily},
+	}
+	for _, tt := range tests {
+		cfg := &config.Config{}
+		cfg.Settlement.Period = tt.period
+		s := &SettlementService{cfg: cfg}
+		if got := s.periodType(); got != tt.want {
+			t.Errorf("period %q: Expected %s, got %s", tt.period, tt.want, got)
+		}
+	}
+	if got := (&SettlementService{}).periodType(); got != model.SettlementPeriodDaily {
+		t.Errorf("Expected daily without config, got %s", got)
+	}
+}
+
+// TestReconcileSettlement 测试结算单与实际回合对账
+func TestReconcileSettlement(t *testing.T) {
+	d := decimal.RequireFromString
+	item := func(roomID int64, rounds int, owner, platform string) *model.OwnerSettlementItem {
+		return &model.OwnerSettlementItem{RoomID: roomID, RoundCount: rounds, OwnerEarning: d(owner), PlatformEarning: d(platform)}
+	}
+	settled := []*model.OwnerSettlementItem{item(1, 10, "12.50", "2.50"), item(2, 4, "3.00", "0.60")}
+
+	tests := []struct {
+		name       string
+		actual     []*model.OwnerSettlementItem
+		reconciled bool
+		totalDiff  string
+		items      int
+	}{
+		{"unchanged", []*model.OwnerSettlementItem{item(1, 10, "12.50", "2.50"), item(2, 4, "3.00", "0.60")}, true, "0", 2},
+		{"late round in a settled room", []*model.OwnerSettlementItem{item(1, 11, "13.75", "2.75"), item(2, 4, "3.00", "0.60")}, false, "1.25", 2},
+		{"late round in a new room", []*model.OwnerSettlementItem{item(1, 10, "12.50", "2.50"), item(2, 4, "3.00", "0.60"), item(3, 1, "0.40", "0.08")}, false, "0.4", 3},
+		{"rounds gone from a room", []*model.OwnerSettlementItem{item(1, 10, "12.50", "2.50")}, false, "-3", 2},
+		{"round count differs with the same earning", []*model.OwnerSettlementItem{item(1, 9, "12.50", "2.50"), item(2, 4, "3.00", "0.60")}, false, "0", 2},
+		{"platform share differs", []*model.OwnerSettlementItem{item(1, 10, "12.50", "2.49"), item(2, 4, "3.00", "0.60")}, false, "0", 2},
+	}
+	for _, tt := range tests {
+		result := reconcileSettlement(&model.OwnerSettlement{Items: settled}, tt.actual)
+		if result.IsReconciled != tt.reconciled || !result.TotalDiff.Equal(d(tt.totalDiff)) || len(result.Items) != tt.items {
+			t.Errorf("%s: Expected reconciled=%v diff=%s items=%d, got reconciled=%v diff=%s items=%d",
+				tt.name, tt.reconciled, tt.totalDiff, tt.items, result.IsReconciled, result.TotalDiff, len(result.Items))
+		}
+	}
+
+	// 明细按结算单房间在前、新出现的房间在后排列
+	result := reconcileSettlement(&model.OwnerSettlement{Items: settled}, []*model.OwnerSettlementItem{item(3, 1, "1", "0"), item(1, 10, "12.50", "2.50")})
+	var rooms []int64
+	for _, it := range result.Items {
+		rooms = append(rooms, it.RoomID)
+	}
+	if len(rooms) != 3 || rooms[0] != 1 || rooms[1] != 2 || rooms[2] != 3 {
+		t.Errorf("Expected rooms ordered [1 2 3], got %v", rooms)
+	}
+	if it := result.Items[1]; it.ActualRounds != 0 || !it.OwnerEarningDiff.Equal(d("-3")) {
+		t.Errorf("Expected room 2 to show all settled rounds missing, got %+v", it)
+	}
+}
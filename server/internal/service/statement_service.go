@@ -0,0 +1,519 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/fiveseconds/server/internal/config"
+	"github.com/fiveseconds/server/internal/model"
+	"github.com/fiveseconds/server/internal/repository"
+	"github.com/fiveseconds/server/pkg/pdf"
+
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrStatementInvalidFormat = errors.New("format must be csv or pdf")
+	ErrStatementInvalidPeriod = errors.New("invalid statement period, use month=YYYY-MM or start_date/end_date=YYYY-MM-DD")
+	ErrStatementPeriodTooLong = errors.New("statement period is too long")
+	ErrStatementTooLarge      = errors.New("statement has too many transactions for direct download, create an export job instead")
+	ErrStatementJobNotFound   = errors.New("statement job not found")
+	ErrStatementJobNotReady   = errors.New("statement job is not completed yet")
+)
+
+const (
+	statementDateLayout  = "2006-01-02"
+	statementMonthLayout = "2006-01"
+	statementTimeLayout  = "2006-01-02 15:04:05"
+
+	// maxConcurrentStatementJobs 同时执行的异步导出任务数
+	maxConcurrentStatementJobs = 2
+	// statementJobListLimit 任务列表返回的最近任务数
+	statementJobListLimit = 20
+)
+
+// statementFieldDisplay 余额账户显示名称（顺序即账单中的展示顺序）
+var statementFieldDisplay = []struct {
+	Field   string
+	Display string
+}{
+	{"balance", "可用余额"},
+	{"frozen_balance", "冻结余额"},
+	{"owner_room_balance", "佣金收益"},
+	{"owner_margin_balance", "保证金"},
+	{"bonus_balance", "奖励余额"},
+}
+
+// StatementService 账单服务
+// 生成指定周期的账户账单（期初/期末余额、分类汇总、交易明细），支持 CSV/PDF 下载与异步导出
+type StatementService struct {
+	userRepo      *repository.UserRepo
+	statementRepo *repository.StatementRepo
+	cfg           *config.Config
+	jobSem        chan struct{}
+	logger        *zap.Logger
+}
+
+// NewStatementService 创建账单服务
+func NewStatementService(
+	userRepo *repository.UserRepo,
+	statementRepo *repository.StatementRepo,
+	cfg *config.Config,
+	logger *zap.Logger,
+) *StatementService {
+	return &StatementService{
+		userRepo:      userRepo,
+		statementRepo: statementRepo,
+		cfg:           cfg,
+		jobSem:        make(chan struct{}, maxConcurrentStatementJobs),
+		logger:        logger.With(zap.String("service", "statement")),
+	}
+}
+
+// ParsePeriod 解析账单周期，返回 [start, end)
+// month 优先；日期范围的结束日期包含在内
+func (s *StatementService) ParsePeriod(query *model.StatementPeriodQuery) (time.Time, time.Time, error) {
+	var start, end time.Time
+	switch {
+	case query.Month != "":
+		month, err := time.ParseInLocation(statementMonthLayout, query.Month, time.Local)
+		if err != nil {
+			return start, end, ErrStatementInvalidPeriod
+		}
+		start, end = month, month.AddDate(0, 1, 0)
+	case query.StartDate != "" && query.EndDate != "":
+		from, err := time.ParseInLocation(statementDateLayout, query.StartDate, time.Local)
+		if err != nil {
+			return start, end, ErrStatementInvalidPeriod
+		}
+		to, err := time.ParseInLocation(statementDateLayout, query.EndDate, time.Local)
+		if err != nil || to.Before(from) {
+			return start, end, ErrStatementInvalidPeriod
+		}
+		start, end = from, to.AddDate(0, 0, 1)
+	default:
+		return start, end, ErrStatementInvalidPeriod
+	}
+
+	if maxDays := s.cfg.Statement.MaxPeriodDays; maxDays > 0 && end.Sub(start) > time.Duration(maxDays)*24*time.Hour {
+		return start, end, ErrStatementPeriodTooLong
+	}
+	return start, end, nil
+}
+
+// parseStatementFormat 校验导出格式，默认 CSV
+func parseStatementFormat(format model.StatementFormat) (model.StatementFormat, error) {
+	switch format {
+	case "":
+		return model.StatementFormatCSV, nil
+	case model.StatementFormatCSV, model.StatementFormatPDF:
+		return format, nil
+	default:
+		return "", ErrStatementInvalidFormat
+	}
+}
+
+// GetStatement 生成账单数据
+func (s *StatementService) GetStatement(ctx context.Context, userID int64, start, end time.Time) (*model.Statement, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	openings, err := s.statementRepo.GetOpeningBalances(ctx, userID, start)
+	if err != nil {
+		return nil, fmt.Errorf("get opening balances: %w", err)
+	}
+	txs, err := s.statementRepo.ListTransactions(ctx, userID, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("list transactions: %w", err)
+	}
+
+	// 从未有过流水的账户，期初余额即当前余额
+	current := map[string]decimal.Decimal{
+		"balance":              user.Balance,
+		"frozen_balance":       user.FrozenBalance,
+		"owner_room_balance":   user.OwnerRoomBalance,
+		"owner_margin_balance": user.OwnerMarginBalance,
+		"bonus_balance":        user.BonusBalance,
+	}
+	fields := []string{"balance"}
+	if user.IsOwner() {
+		fields = append(fields, "owner_room_balance", "owner_margin_balance")
+	}
+	if user.BonusBalance.IsPositive() {
+		fields = append(fields, "bonus_balance")
+	}
+	for _, field := range fields {
+		if _, ok := openings[field]; !ok {
+			openings[field] = current[field]
+		}
+	}
+
+	statement := buildStatement(openings, txs)
+	statement.UserID = user.ID
+	statement.Username = user.Username
+	statement.PeriodStart = start
+	statement.PeriodEnd = end
+	statement.GeneratedAt = time.Now()
+	return statement, nil
+}
+
+// buildStatement 根据期初余额与周期内流水计算期末余额与分类汇总
+// 期末余额 = 期初余额 + 该账户周期内流水金额之和
+func buildStatement(openings map[string]decimal.Decimal, txs []*model.BalanceTransaction) *model.Statement {
+	changes := make(map[string]decimal.Decimal)
+	categories := make(map[model.TransactionType]*model.StatementCategoryTotal)
+	for _, tx := range txs {
+		changes[tx.BalanceField] = changes[tx.BalanceField].Add(tx.Amount)
+		if _, ok := openings[tx.BalanceField]; !ok {
+			openings[tx.BalanceField] = tx.BalanceBefore
+		}
+
+		category, ok := categories[tx.Type]
+		if !ok {
+			category = &model.StatementCategoryTotal{Type: tx.Type, Display: getTransactionTypeDisplay(tx.Type)}
+			categories[tx.Type] = category
+		}
+		category.Count++
+		category.Total = category.Total.Add(tx.Amount)
+	}
+
+	statement := &model.Statement{Transactions: txs}
+	seen := make(map[string]bool)
+	addBalance := func(field, display string) {
+		opening, ok := openings[field]
+		if !ok || seen[field] {
+			return
+		}
+		seen[field] = true
+		change := changes[field]
+		statement.Balances = append(statement.Balances, &model.StatementFieldBalance{
+			Field:   field,
+			Display: display,
+			Opening: opening,
+			Closing: opening.Add(change),
+			Change:  change,
+		})
+	}
+	for _, f := range statementFieldDisplay {
+		addBalance(f.Field, f.Display)
+	}
+	// 未知账户按名称排序追加
+	var others []string
+	for field := range openings {
+		if !seen[field] {
+			others = append(others, field)
+		}
+	}
+	sort.Strings(others)
+	for _, field := range others {
+		addBalance(field, field)
+	}
+
+	for _, category := range categories {
+		statement.Categories = append(statement.Categories, category)
+	}
+	sort.Slice(statement.Categories, func(i, j int) bool {
+		return statement.Categories[i].Type < statement.Categories[j].Type
+	})
+	return statement
+}
+
+// balanceFieldDisplay 获取余额账户显示名称
+func balanceFieldDisplay(field string) string {
+	for _, f := range statementFieldDisplay {
+		if f.Field == field {
+			return f.Display
+		}
+	}
+	return field
+}
+
+// RenderStatement 将账单渲染为指定格式，返回文件内容与文件名
+func (s *StatementService) RenderStatement(ctx context.Context, userID int64, query *model.StatementPeriodQuery) ([]byte, string, error) {
+	format, err := parseStatementFormat(query.Format)
+	if err != nil {
+		return nil, "", err
+	}
+	start, end, err := s.ParsePeriod(query)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// 交易笔数过多时要求使用异步导出，避免阻塞请求
+	if maxRows := s.cfg.Statement.MaxSyncRows; maxRows > 0 {
+		count, err := s.statementRepo.CountTransactions(ctx, userID, start, end)
+		if err != nil {
+			return nil, "", err
+		}
+		if count > maxRows {
+			return nil, "", ErrStatementTooLarge
+		}
+	}
+
+	statement, err := s.GetStatement(ctx, userID, start, end)
+	if err != nil {
+		return nil, "", err
+	}
+	var buf bytes.Buffer
+	if err := writeStatement(&buf, statement, format); err != nil {
+		return nil, "", err
+	}
+	return buf.Bytes(), statementFilename(statement, format), nil
+}
+
+// statementFilename 生成账单文件名
+func statementFilename(statement *model.Statement, format model.StatementFormat) string {
+	return fmt.Sprintf("statement_%s_%s_%s.%s",
+		sanitizeFilenamePart(statement.Username),
+		statement.PeriodStart.Format("20060102"),
+		statement.PeriodEnd.AddDate(0, 0, -1).Format("20060102"),
+		format)
+}
+
+// sanitizeFilenamePart 文件名片段只保留字母、数字、"-" 与 "_"，其余字符替换为 "_"
+// 用户名会出现在导出文件路径与下载文件名中，不能包含路径分隔符、引号或控制字符
+func sanitizeFilenamePart(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-' || r == '_' {
+			return r
+		}
+		return '_'
+	}, s)
+}
+
+// writeStatement 按格式输出账单
+func writeStatement(w io.Writer, statement *model.Statement, format model.StatementFormat) error {
+	if format == model.StatementFormatPDF {
+		return writeStatementPDF(w, statement)
+	}
+	return writeStatementCSV(w, statement)
+}
+
+// writeStatementCSV 输出 CSV 账单（带 UTF-8 BOM，方便 Excel 直接打开）
+func writeStatementCSV(w io.Writer, statement *model.Statement) error {
+	if _, err := w.Write([]byte("\xef\xbb\xbf")); err != nil {
+		return err
+	}
+	cw := csv.NewWriter(w)
+	rows := [][]string{
+		{"账户账单"},
+		{"用户", csvText(statement.Username)},
+		{"账单周期", statement.PeriodStart.Format(statementDateLayout), statement.PeriodEnd.AddDate(0, 0, -1).Format(statementDateLayout)},
+		{"生成时间", statement.GeneratedAt.Format(statementTimeLayout)},
+		{},
+		{"账户", "期初余额", "本期变动", "期末余额"},
+	}
+	for _, b := range statement.Balances {
+		rows = append(rows, []string{b.Display, b.Opening.StringFixed(2), b.Change.StringFixed(2), b.Closing.StringFixed(2)})
+	}
+	rows = append(rows, []string{}, []string{"交易类型", "笔数", "合计金额"})
+	for _, c := range statement.Categories {
+		rows = append(rows, []string{c.Display, fmt.Sprint(c.Count), c.Total.StringFixed(2)})
+	}
+	rows = append(rows, []string{}, []string{"时间", "类型", "账户", "金额", "变动前", "变动后", "备注"})
+	for _, tx := range statement.Transactions {
+		remark := ""
+		if tx.Remark != nil {
+			remark = *tx.Remark
+		}
+		rows = append(rows, []string{
+			tx.CreatedAt.Format(statementTimeLayout),
+			getTransactionTypeDisplay(tx.Type),
+			balanceFieldDisplay(tx.BalanceField),
+			tx.Amount.StringFixed(2),
+			tx.BalanceBefore.StringFixed(2),
+			tx.BalanceAfter.StringFixed(2),
+			csvText(remark),
+		})
+	}
+	if err := cw.WriteAll(rows); err != nil {
+		return err
+	}
+	return cw.Error()
+}
+
+// csvText 转义 CSV 中的自由文本（用户名、备注）：以 = + - @ 或制表符、回车开头的单元格在电子表格中会被当作公式，
+// 前置单引号使其按文本显示；金额等由系统生成的数值列不经过此函数
+func csvText(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
+
+// writeStatementPDF 输出 PDF 账单
+func writeStatementPDF(w io.Writer, statement *model.Statement) error {
+	doc := pdf.New(9)
+	doc.AddLines(
+		"账户账单",
+		fmt.Sprintf("用户: %s", statement.Username),
+		fmt.Sprintf("账单周期: %s 至 %s",
+			statement.PeriodStart.Format(statementDateLayout),
+			statement.PeriodEnd.AddDate(0, 0, -1).Format(statementDateLayout)),
+		fmt.Sprintf("生成时间: %s", statement.GeneratedAt.Format(statementTimeLayout)),
+		"",
+		"[余额汇总]",
+	)
+	for _, b := range statement.Balances {
+		doc.AddLine(fmt.Sprintf("%s  期初 %s  变动 %s  期末 %s",
+			b.Display, b.Opening.StringFixed(2), b.Change.StringFixed(2), b.Closing.StringFixed(2)))
+	}
+	doc.AddLines("", "[分类汇总]")
+	for _, c := range statement.Categories {
+		doc.AddLine(fmt.Sprintf("%s  %d 笔  合计 %s", c.Display, c.Count, c.Total.StringFixed(2)))
+	}
+	doc.AddLines("", "[交易明细]")
+	for _, tx := range statement.Transactions {
+		doc.AddLine(fmt.Sprintf("%s  %s  %s  %s  余额 %s",
+			tx.CreatedAt.Format(statementTimeLayout),
+			getTransactionTypeDisplay(tx.Type),
+			balanceFieldDisplay(tx.BalanceField),
+			tx.Amount.StringFixed(2),
+			tx.BalanceAfter.StringFixed(2)))
+	}
+	_, err := doc.WriteTo(w)
+	return err
+}
+
+// CreateJob 创建异步导出任务（大周期/大流水量使用）
+func (s *StatementService) CreateJob(ctx context.Context, userID int64, query *model.StatementPeriodQuery) (*model.StatementJob, error) {
+	format, err := parseStatementFormat(query.Format)
+	if err != nil {
+		return nil, err
+	}
+	start, end, err := s.ParsePeriod(query)
+	if err != nil {
+		return nil, err
+	}
+
+	job := &model.StatementJob{
+		UserID:      userID,
+		Format:      format,
+		PeriodStart: start,
+		PeriodEnd:   end,
+		Status:      model.StatementJobPending,
+	}
+	if err := s.statementRepo.CreateJob(ctx, job); err != nil {
+		return nil, err
+	}
+
+	go s.runJob(job)
+	return job, nil
+}
+
+// runJob 执行导出任务，结果写入 OutputDir
+func (s *StatementService) runJob(job *model.StatementJob) {
+	s.jobSem <- struct{}{}
+	defer func() { <-s.jobSem }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+
+	if err := s.statementRepo.MarkJobRunning(ctx, job.ID); err != nil {
+		s.logger.Error("mark statement job running failed", zap.Int64("job_id", job.ID), zap.Error(err))
+	}
+
+	path, rowCount, err := s.exportJob(ctx, job)
+	if err != nil {
+		s.logger.Error("statement job failed", zap.Int64("job_id", job.ID), zap.Error(err))
+		if ferr := s.statementRepo.FailJob(ctx, job.ID, err.Error()); ferr != nil {
+			s.logger.Error("mark statement job failed failed", zap.Int64("job_id", job.ID), zap.Error(ferr))
+		}
+		return
+	}
+	if err := s.statementRepo.CompleteJob(ctx, job.ID, path, rowCount); err != nil {
+		s.logger.Error("complete statement job failed", zap.Int64("job_id", job.ID), zap.Error(err))
+		return
+	}
+	s.logger.Info("statement job completed", zap.Int64("job_id", job.ID), zap.Int("rows", rowCount))
+}
+
+// exportJob 生成账单文件，返回文件路径与交易笔数
+func (s *StatementService) exportJob(ctx context.Context, job *model.StatementJob) (string, int, error) {
+	statement, err := s.GetStatement(ctx, job.UserID, job.PeriodStart, job.PeriodEnd)
+	if err != nil {
+		return "", 0, err
+	}
+
+	dir := s.cfg.Statement.OutputDir
+	if dir == "" {
+		dir = os.TempDir()
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", 0, fmt.Errorf("create output dir: %w", err)
+	}
+	path := filepath.Join(dir, fmt.Sprintf("job_%d_%s", job.ID, statementFilename(statement, job.Format)))
+
+	f, err := os.Create(path)
+	if err != nil {
+		return "", 0, fmt.Errorf("create statement file: %w", err)
+	}
+	if err := writeStatement(f, statement, job.Format); err != nil {
+		f.Close()
+		os.Remove(path)
+		return "", 0, err
+	}
+	if err := f.Close(); err != nil {
+		return "", 0, err
+	}
+	return path, len(statement.Transactions), nil
+}
+
+// GetJob 获取导出任务（仅本人可查看）
+func (s *StatementService) GetJob(ctx context.Context, userID, jobID int64) (*model.StatementJob, error) {
+	job, err := s.statementRepo.GetJob(ctx, jobID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrStatementJobNotFound
+		}
+		return nil, err
+	}
+	if job.UserID != userID {
+		return nil, ErrStatementJobNotFound
+	}
+	return job, nil
+}
+
+// ListJobs 获取本人最近的导出任务
+func (s *StatementService) ListJobs(ctx context.Context, userID int64) ([]*model.StatementJob, error) {
+	return s.statementRepo.ListJobs(ctx, userID, statementJobListLimit)
+}
+
+// GetJobFile 获取已完成任务的文件路径与下载文件名
+func (s *StatementService) GetJobFile(ctx context.Context, userID, jobID int64) (string, string, error) {
+	job, err := s.GetJob(ctx, userID, jobID)
+	if err != nil {
+		return "", "", err
+	}
+	if job.Status != model.StatementJobCompleted || job.FilePath == nil {
+		return "", "", ErrStatementJobNotReady
+	}
+	return *job.FilePath, filepath.Base(*job.FilePath), nil
+}
+
+// RecoverJobs 服务启动时重新执行重启前未完成的任务（任务已持久化，执行中断后自动恢复）
+func (s *StatementService) RecoverJobs(ctx context.Context) {
+	jobs, err := s.statementRepo.RequeueUnfinishedJobs(ctx)
+	if err != nil {
+		s.logger.Error("recover statement jobs failed", zap.Error(err))
+		return
+	}
+	for _, job := range jobs {
+		go s.runJob(job)
+	}
+	if len(jobs) > 0 {
+		s.logger.Warn("requeued interrupted statement jobs", zap.Int("count", len(jobs)))
+	}
+}
@@ -0,0 +1,182 @@
+package service
+
+import (
+	"bytes"
+	"encoding/csv"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/fiveseconds/server/internal/model"
+	"github.com/shopspring/decimal"
+)
+
+// TestCSVText 测试 CSV 自由文本的公式转义
+func TestCSVText(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"", ""},
+		{"转账给好友", "转账给好友"},
+		{"=HYPERLINK(\"http://x\")", "'=HYPERLINK(\"http://x\")"},
+		{"+1+1", "'+1+1"},
+		{"-2+3", "'-2+3"},
+		{"@SUM(A1)", "'@SUM(A1)"},
+		{"\t=1", "'\t=1"},
+		{"\r=1", "'\r=1"},
+		{"a=1", "a=1"},
+		{"'quoted", "'quoted"},
+	}
+	for _, tt := range tests {
+		if got := csvText(tt.in); got != tt.want {
+			t.Errorf("csvText(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+// TestWriteStatementCSVEscapesFreeText 测试账单 CSV 转义用户名与备注，金额列保持数值
+func TestWriteStatementCSVEscapesFreeText(t *testing.T) {
+	remark := "=cmd|' /C calc'!A0"
+	statement := &model.Statement{
+		Username:    "@admin",
+		PeriodStart: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+		PeriodEnd:   time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC),
+		GeneratedAt: time.Date(2025, 2, 1, 8, 0, 0, 0, time.UTC),
+		Transactions: []*model.BalanceTransaction{{
+			Type:          model.TxTransferOut,
+			Amount:        decimal.NewFromInt(-50),
+			BalanceBefore: decimal.NewFromInt(100),
+			BalanceAfter:  decimal.NewFromInt(50),
+			BalanceField:  "balance",
+			Remark:        &remark,
+		}},
+	}
+
+	var buf bytes.Buffer
+	if err := writeStatementCSV(&buf, statement); err != nil {
+		t.Fatalf("write csv: %v", err)
+	}
+	r := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(buf.Bytes(), []byte("\xef\xbb\xbf"))))
+	r.FieldsPerRecord = -1
+	rows, err := r.ReadAll()
+	if err != nil {
+		t.Fatalf("read csv: %v", err)
+	}
+
+	if rows[1][1] != "'@admin" {
+		t.Errorf("username cell = %q, want escaped", rows[1][1])
+	}
+	last := rows[len(rows)-1]
+	if last[6] != "'"+remark {
+		t.Errorf("remark cell = %q, want escaped", last[6])
+	}
+	if last[3] != "-50.00" {
+		t.Errorf("amount cell = %q, want the plain number -50.00", last[3])
+	}
+}
+
+// TestBuildStatement 测试期末余额 = 期初余额 + 周期内流水，未知账户按名称追加，分类汇总按类型排序
+func TestBuildStatement(t *testing.T) {
+	tx := func(txType model.TransactionType, field, before, amount string) *model.BalanceTransaction {
+		b, a := decimal.RequireFromString(before), decimal.RequireFromString(amount)
+		return &model.BalanceTransaction{Type: txType, BalanceField: field, BalanceBefore: b, Amount: a, BalanceAfter: b.Add(a)}
+	}
+	type balance struct{ field, display, opening, change, closing string }
+	type category struct {
+		txType model.TransactionType
+		count  int
+		total  string
+	}
+	tests := []struct {
+		name       string
+		openings   map[string]decimal.Decimal
+		txs        []*model.BalanceTransaction
+		balances   []balance
+		categories []category
+	}{
+		{
+			"mixed accounts",
+			map[string]decimal.Decimal{"balance": decimal.NewFromInt(100)},
+			[]*model.BalanceTransaction{
+				tx(model.TxDeposit, "balance", "100", "500"),
+				tx(model.TxGameBet, "balance", "600", "-20"),
+				tx(model.TxGameWin, "balance", "580", "35.5"),
+				tx(model.TxGameBet, "balance", "615.5", "-20"),
+				tx(model.TxFreeze, "frozen_balance", "30", "20"),
+				tx(model.TxTransferOut, "legacy_b", "5", "-5"),
+				tx(model.TxUnfreeze, "legacy_a", "0", "1"),
+			},
+			[]balance{
+				{"balance", "可用余额", "100", "495.5", "595.5"},
+				{"frozen_balance", "冻结余额", "30", "20", "50"},
+				{"legacy_a", "legacy_a", "0", "1", "1"},
+				{"legacy_b", "legacy_b", "5", "-5", "0"},
+			},
+			[]category{
+				{model.TxDeposit, 1, "500"},
+				{model.TxFreeze, 1, "20"},
+				{model.TxGameBet, 2, "-40"},
+				{model.TxGameWin, 1, "35.5"},
+				{model.TxTransferOut, 1, "-5"},
+				{model.TxUnfreeze, 1, "1"},
+			},
+		},
+		{
+			"no transactions",
+			map[string]decimal.Decimal{"bonus_balance": decimal.Zero, "balance": decimal.NewFromInt(100)},
+			nil,
+			[]balance{
+				{"balance", "可用余额", "100", "0", "100"},
+				{"bonus_balance", "奖励余额", "0", "0", "0"},
+			},
+			nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			statement := buildStatement(tt.openings, tt.txs)
+
+			var balances []balance
+			for _, b := range statement.Balances {
+				balances = append(balances, balance{b.Field, b.Display, b.Opening.String(), b.Change.String(), b.Closing.String()})
+			}
+			if !reflect.DeepEqual(balances, tt.balances) {
+				t.Errorf("Expected balances %v, got %v", tt.balances, balances)
+			}
+			var categories []category
+			for _, c := range statement.Categories {
+				categories = append(categories, category{c.Type, c.Count, c.Total.String()})
+			}
+			if !reflect.DeepEqual(categories, tt.categories) {
+				t.Errorf("Expected categories %v, got %v", tt.categories, categories)
+			}
+		})
+	}
+}
+
+// TestStatementFilename 测试账单文件名中用户名的路径分隔符、引号与控制字符被替换
+func TestStatementFilename(t *testing.T) {
+	tests := []struct {
+		username string
+		format   model.StatementFormat
+		want     string
+	}{
+		{"alice", model.StatementFormatCSV, "statement_alice_20250101_20250131.csv"},
+		{"张三", model.StatementFormatPDF, "statement_张三_20250101_20250131.pdf"},
+		{"../../etc/passwd", model.StatementFormatCSV, "statement_______etc_passwd_20250101_20250131.csv"},
+		{`..\win`, model.StatementFormatCSV, "statement____win_20250101_20250131.csv"},
+		{`a"b;c`, model.StatementFormatCSV, "statement_a_b_c_20250101_20250131.csv"},
+		{"x\r\nSet-Cookie: y", model.StatementFormatCSV, "statement_x__Set-Cookie__y_20250101_20250131.csv"},
+	}
+	for _, tt := range tests {
+		statement := &model.Statement{
+			Username:    tt.username,
+			PeriodStart: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+			PeriodEnd:   time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC),
+		}
+		if got := statementFilename(statement, tt.format); got != tt.want {
+			t.Errorf("statementFilename(%q) = %q, want %q", tt.username, got, tt.want)
+		}
+	}
+}
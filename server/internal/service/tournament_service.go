@@ -0,0 +1,839 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/fiveseconds/server/internal/cache"
+	"github.com/fiveseconds/server/internal/config"
+	"github.com/fiveseconds/server/internal/game"
+	"github.com/fiveseconds/server/internal/model"
+	"github.com/fiveseconds/server/internal/repository"
+	"github.com/fiveseconds/server/internal/ws"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrTournamentNotFound          = errors.New("tournament not found")
+	ErrTournamentForbidden         = errors.New("this tournament does not belong to you")
+	ErrTournamentInvalidParams     = errors.New("invalid tournament: buy_in and starting_chips must be positive, max_rounds must not be negative and ends_at must be after starts_at")
+	ErrTournamentNoLimit           = errors.New("tournament needs max_rounds or ends_at")
+	ErrTournamentInvalidPayouts    = errors.New("invalid payout table: shares must be positive, non-increasing by rank and sum to 100")
+	ErrTournamentClosed            = errors.New("tournament has already finished or been cancelled")
+	ErrTournamentNotOpen           = errors.New("tournament registration is closed")
+	ErrTournamentAlreadyRegistered = errors.New("already registered for this tournament")
+	ErrTournamentNotInvited        = errors.New("only players invited by the tournament owner can register")
+	ErrTournamentNotRunning        = errors.New("tournament is not accepting rounds")
+	ErrTournamentBetTooLarge       = errors.New("bet amount must not exceed the tournament starting chips")
+)
+
+// MaxTournamentPayoutRanks 奖励分配表最多名次数
+const MaxTournamentPayoutRanks = 100
+
+// DefaultTournamentSettleGrace 已达回合上限但仍有回合未结算时，最后一次回合变动后等待多久强制结束
+const DefaultTournamentSettleGrace = 2 * time.Minute
+
+// TournamentService 锦标赛服务
+// 报名费从玩家对应币种钱包扣除并计入锦标赛奖池（tournaments.pool_balance），
+// 房间内每轮以锦标赛筹码下注与结算；结束时奖池按名次与奖励分配表派发，取消时全额退回报名费。
+// 报名费、退款与奖金均记 balance_transactions 流水，奖池余额计入资金守恒
+type TournamentService struct {
+	tournamentRepo *repository.TournamentRepo
+	userRepo       *repository.UserRepo
+	walletRepo     *repository.WalletRepo
+	roomRepo       *repository.RoomRepo
+	txRepo         *repository.TransactionRepo
+	balanceCache   *cache.BalanceCache
+	hub            *ws.Hub
+	cfg            *config.Config
+	logger         *zap.Logger
+}
+
+// NewTournamentService 创建锦标赛服务
+func NewTournamentService(
+	tournamentRepo *repository.TournamentRepo,
+	userRepo *repository.UserRepo,
+	walletRepo *repository.WalletRepo,
+	roomRepo *repository.RoomRepo,
+	txRepo *repository.TransactionRepo,
+	cfg *config.Config,
+	logger *zap.Logger,
+) *TournamentService {
+	return &TournamentService{
+		tournamentRepo: tournamentRepo,
+		userRepo:       userRepo,
+		walletRepo:     walletRepo,
+		roomRepo:       roomRepo,
+		txRepo:         txRepo,
+		cfg:            cfg,
+		logger:         logger.With(zap.String("service", "tournament")),
+	}
+}
+
+// SetHub 设置 WebSocket Hub（用于推送实时排名与余额更新）
+func (s *TournamentService) SetHub(hub *ws.Hub) {
+	s.hub = hub
+}
+
+// SetBalanceCache 设置余额缓存（写回模式下扣除报名费前需暂停玩家的缓存扣款并落库）
+func (s *TournamentService) SetBalanceCache(balanceCache *cache.BalanceCache) {
+	s.balanceCache = balanceCache
+}
+
+// settleGrace 强制结束前等待未结算回合的时间
+func (s *TournamentService) settleGrace() time.Duration {
+	if s.cfg.Tournament.SettleGraceSeconds > 0 {
+		return time.Duration(s.cfg.Tournament.SettleGraceSeconds) * time.Second
+	}
+	return DefaultTournamentSettleGrace
+}
+
+// Create 房主创建锦标赛（以房主经营币种收取报名费）
+func (s *TournamentService) Create(ctx context.Context, ownerID int64, req *model.CreateTournamentReq) (*model.Tournament, error) {
+	name := strings.TrimSpace(req.Name)
+	buyIn := req.BuyIn.Round(2)
+	startingChips := req.StartingChips.Round(2)
+	startsAt := req.StartsAt
+	if startsAt.IsZero() {
+		startsAt = time.Now()
+	}
+	if name == "" || !buyIn.IsPositive() || !startingChips.IsPositive() || req.MaxRounds < 0 ||
+		(req.EndsAt != nil && !req.EndsAt.After(startsAt)) {
+		return nil, ErrTournamentInvalidParams
+	}
+	if req.MaxRounds == 0 && req.EndsAt == nil {
+		return nil, ErrTournamentNoLimit
+	}
+	payouts, err := normalizeTournamentPayouts(req.Payouts)
+	if err != nil {
+		return nil, err
+	}
+
+	owner, err := s.userRepo.GetByID(ctx, ownerID)
+	if err != nil {
+		return nil, err
+	}
+
+	t := &model.Tournament{
+		OwnerID:       ownerID,
+		Name:          name,
+		Currency:      owner.Currency,
+		BuyIn:         buyIn,
+		StartingChips: startingChips,
+		MaxRounds:     req.MaxRounds,
+		StartsAt:      startsAt,
+		EndsAt:        req.EndsAt,
+		Status:        model.TournamentStatusScheduled,
+		Payouts:       payouts,
+	}
+	if err := repository.Tx(ctx, func(tx pgx.Tx) error {
+		return s.tournamentRepo.CreateTx(ctx, tx, t)
+	}); err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Tournament created",
+		zap.Int64("tournament_id", t.ID),
+		zap.Int64("owner_id", ownerID),
+		zap.String("buy_in", buyIn.String()),
+		zap.Int("max_rounds", t.MaxRounds))
+	return t, nil
+}
+
+// get 获取锦标赛（不存在时返回 ErrTournamentNotFound）
+func (s *TournamentService) get(ctx context.Context, id int64) (*model.Tournament, error) {
+	t, err := s.tournamentRepo.GetByID(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrTournamentNotFound
+	}
+	return t, err
+}
+
+// GetDetail 获取锦标赛详情、实时排名及房间（玩家只能查看所属房主的锦标赛）
+func (s *TournamentService) GetDetail(ctx context.Context, id, userID int64, role model.Role) (*model.TournamentDetail, error) {
+	t, err := s.get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkVisible(ctx, t, userID, role); err != nil {
+		return nil, err
+	}
+
+	standings, err := s.standings(ctx, t)
+	if err != nil {
+		return nil, err
+	}
+	rooms, err := s.roomRepo.ListByTournament(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if rooms == nil {
+		rooms = []*model.Room{}
+	}
+	return &model.TournamentDetail{Tournament: t, Standings: standings, Rooms: rooms}, nil
+}
+
+// checkVisible 房主只能查看自己的锦标赛，玩家只能查看邀请自己的房主的锦标赛
+func (s *TournamentService) checkVisible(ctx context.Context, t *model.Tournament, userID int64, role model.Role) error {
+	switch role {
+	case model.RoleAdmin:
+		return nil
+	case model.RoleOwner:
+		if t.OwnerID != userID {
+			return ErrTournamentForbidden
+		}
+		return nil
+	}
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user.InvitedBy == nil || *user.InvitedBy != t.OwnerID {
+		return ErrTournamentForbidden
+	}
+	return nil
+}
+
+// List 分页获取锦标赛列表
+func (s *TournamentService) List(ctx context.Context, query *model.TournamentListQuery) ([]*model.Tournament, int64, error) {
+	return s.tournamentRepo.List(ctx, query)
+}
+
+// ListForPlayer 玩家查看邀请自己的房主的锦标赛
+func (s *TournamentService) ListForPlayer(ctx context.Context, userID int64, query *model.TournamentListQuery) ([]*model.Tournament, int64, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, 0, err
+	}
+	if user.InvitedBy == nil {
+		return []*model.Tournament{}, 0, nil
+	}
+	query.OwnerID = user.InvitedBy
+	return s.tournamentRepo.List(ctx, query)
+}
+
+// Register 玩家报名：从锦标赛币种钱包扣除报名费计入奖池，并发放初始筹码（仅报名阶段可报名）
+func (s *TournamentService) Register(ctx context.Context, userID, id int64) (*model.TournamentEntry, error) {
+	t, err := s.get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.Role != model.RolePlayer || user.InvitedBy == nil || *user.InvitedBy != t.OwnerID {
+		return nil, ErrTournamentNotInvited
+	}
+
+	entry := &model.TournamentEntry{
+		TournamentID: id,
+		UserID:       userID,
+		Username:     user.Username,
+		Chips:        t.StartingChips,
+		BuyIn:        t.BuyIn,
+		Status:       model.TournamentEntryActive,
+	}
+	// 写回模式下报名费扣款前暂停玩家的缓存扣款并落库
+	release, err := prepareBalanceWrite(ctx, s.balanceCache, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	var balance decimal.Decimal
+	var active bool
+	err = repository.Tx(ctx, func(tx pgx.Tx) error {
+		locked, err := s.tournamentRepo.GetForUpdateTx(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		if locked.Status != model.TournamentStatusScheduled {
+			return ErrTournamentNotOpen
+		}
+		created, err := s.tournamentRepo.CreateEntryTx(ctx, tx, entry)
+		if err != nil {
+			return fmt.Errorf("create tournament entry: %w", err)
+		}
+		if !created {
+			return ErrTournamentAlreadyRegistered
+		}
+
+		balance, active, err = s.walletRepo.AdjustTx(ctx, tx, userID, locked.Currency, locked.BuyIn.Neg())
+		if err != nil {
+			return err
+		}
+		if err := s.tournamentRepo.AddPoolTx(ctx, tx, id, locked.BuyIn); err != nil {
+			return fmt.Errorf("add tournament pool: %w", err)
+		}
+
+		remark := fmt.Sprintf("锦标赛报名(ID:%d)", id)
+		return s.txRepo.CreateTx(ctx, tx, &model.BalanceTransaction{
+			UserID:        userID,
+			Type:          model.TxTournamentBuyIn,
+			Amount:        locked.BuyIn.Neg(),
+			BalanceBefore: balance.Add(locked.BuyIn),
+			BalanceAfter:  balance,
+			BalanceField:  walletBalanceField(active, locked.Currency),
+			Currency:      locked.Currency,
+			Remark:        &remark,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if active {
+		s.notifyBalanceUpdate(userID, balance)
+	}
+	s.logger.Info("Tournament registered", zap.Int64("tournament_id", id), zap.Int64("user_id", userID))
+	return entry, nil
+}
+
+// Cancel 取消锦标赛并全额退回报名费（ownerID 为空表示管理员操作）
+func (s *TournamentService) Cancel(ctx context.Context, id int64, ownerID *int64) error {
+	t, err := s.get(ctx, id)
+	if err != nil {
+		return err
+	}
+	if ownerID != nil && t.OwnerID != *ownerID {
+		return ErrTournamentForbidden
+	}
+
+	newBalances := make(map[int64]decimal.Decimal)
+	err = repository.Tx(ctx, func(tx pgx.Tx) error {
+		locked, err := s.tournamentRepo.GetForUpdateTx(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		if locked.Status != model.TournamentStatusScheduled && locked.Status != model.TournamentStatusRunning {
+			return ErrTournamentClosed
+		}
+		entries, err := s.tournamentRepo.ListEntriesTx(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+
+		remark := fmt.Sprintf("锦标赛取消退款(ID:%d)", id)
+		refunded := decimal.Zero
+		txRecords := make([]*model.BalanceTransaction, 0, len(entries))
+		for _, e := range entries {
+			if e.Status != model.TournamentEntryActive {
+				continue
+			}
+			balance, active, err := s.walletRepo.AdjustTx(ctx, tx, e.UserID, locked.Currency, e.BuyIn)
+			if err != nil {
+				return fmt.Errorf("refund tournament buy-in: %w", err)
+			}
+			if active {
+				newBalances[e.UserID] = balance
+			}
+			txRecords = append(txRecords, &model.BalanceTransaction{
+				UserID:        e.UserID,
+				Type:          model.TxTournamentRefund,
+				Amount:        e.BuyIn,
+				BalanceBefore: balance.Sub(e.BuyIn),
+				BalanceAfter:  balance,
+				BalanceField:  walletBalanceField(active, locked.Currency),
+				Currency:      locked.Currency,
+				Remark:        &remark,
+			})
+			e.Status = model.TournamentEntryRefunded
+			if err := s.tournamentRepo.UpdateEntryResultTx(ctx, tx, e); err != nil {
+				return err
+			}
+			refunded = refunded.Add(e.BuyIn)
+		}
+		if err := s.tournamentRepo.CloseTx(ctx, tx, id, model.TournamentStatusCancelled, refunded); err != nil {
+			return err
+		}
+		return s.txRepo.BatchCreateTx(ctx, tx, txRecords)
+	})
+	if err != nil {
+		return err
+	}
+
+	for userID, balance := range newBalances {
+		s.notifyBalanceUpdate(userID, balance)
+	}
+	s.closeRooms(ctx, id)
+	s.logger.Info("Tournament cancelled", zap.Int64("tournament_id", id), zap.Int("refunded", len(newBalances)))
+	return nil
+}
+
+// Finish 结束锦标赛：先退回仍未结算回合的下注筹码，再按最终筹码排名，奖池按奖励分配表派发给各名次
+// 筹码相同者名次相同，平分所占名次的奖金
+func (s *TournamentService) Finish(ctx context.Context, id int64) error {
+	newBalances := make(map[int64]decimal.Decimal)
+	var paid, refundedStakes decimal.Decimal
+	err := repository.Tx(ctx, func(tx pgx.Tx) error {
+		t, err := s.tournamentRepo.GetForUpdateTx(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		if t.Status != model.TournamentStatusRunning {
+			return ErrTournamentNotRunning
+		}
+		payouts, err := s.tournamentRepo.GetPayoutsTx(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		// 未结算回合（房间卡住或结束时仍在进行）的下注筹码退回后再排名，之后到达的结算与退回不再改变筹码
+		if refundedStakes, err = s.tournamentRepo.RefundAllStakesTx(ctx, tx, id); err != nil {
+			return err
+		}
+		entries, err := s.tournamentRepo.ListEntriesTx(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		ranked := make([]*model.TournamentEntry, 0, len(entries))
+		for _, e := range entries {
+			if e.Status == model.TournamentEntryActive {
+				ranked = append(ranked, e)
+			}
+		}
+
+		ranks, prizes := rankTournamentEntries(ranked, computeTournamentPayouts(t.PoolBalance, payouts, len(ranked)))
+		remark := fmt.Sprintf("锦标赛奖金(ID:%d)", id)
+		txRecords := make([]*model.BalanceTransaction, 0, len(payouts))
+		for i, e := range ranked {
+			rank := ranks[i]
+			e.FinalRank = &rank
+			e.Prize = prizes[i]
+			e.Status = model.TournamentEntryFinished
+			if err := s.tournamentRepo.UpdateEntryResultTx(ctx, tx, e); err != nil {
+				return err
+			}
+			if !e.Prize.IsPositive() {
+				continue
+			}
+			balance, active, err := s.walletRepo.AdjustTx(ctx, tx, e.UserID, t.Currency, e.Prize)
+			if err != nil {
+				return fmt.Errorf("pay tournament prize: %w", err)
+			}
+			if active {
+				newBalances[e.UserID] = balance
+			}
+			txRecords = append(txRecords, &model.BalanceTransaction{
+				UserID:        e.UserID,
+				Type:          model.TxTournamentPrize,
+				Amount:        e.Prize,
+				BalanceBefore: balance.Sub(e.Prize),
+				BalanceAfter:  balance,
+				BalanceField:  walletBalanceField(active, t.Currency),
+				Currency:      t.Currency,
+				Remark:        &remark,
+			})
+			paid = paid.Add(e.Prize)
+		}
+		if err := s.tournamentRepo.CloseTx(ctx, tx, id, model.TournamentStatusFinished, paid); err != nil {
+			return err
+		}
+		return s.txRepo.BatchCreateTx(ctx, tx, txRecords)
+	})
+	if err != nil {
+		return err
+	}
+
+	for userID, balance := range newBalances {
+		s.notifyBalanceUpdate(userID, balance)
+	}
+	s.closeRooms(ctx, id)
+	s.logger.Info("Tournament finished", zap.Int64("tournament_id", id), zap.String("paid", paid.String()),
+		zap.String("refunded_stakes", refundedStakes.String()))
+	return nil
+}
+
+// RunSchedule 开始到期的锦标赛，结束已完成全部回合（或超过等待时间仍有回合未结算）的锦标赛
+// 返回开始与结束的锦标赛数量
+func (s *TournamentService) RunSchedule(ctx context.Context) (int, int, error) {
+	started, err := s.tournamentRepo.StartDue(ctx)
+	if err != nil {
+		return 0, 0, err
+	}
+	for _, id := range started {
+		s.logger.Info("Tournament started", zap.Int64("tournament_id", id))
+		s.broadcastStandings(ctx, id)
+	}
+
+	running, err := s.tournamentRepo.ListRunning(ctx)
+	if err != nil {
+		return len(started), 0, err
+	}
+	now := time.Now()
+	finished := 0
+	for _, t := range running {
+		stale := t.RoundLimitReached(now) && now.Sub(t.UpdatedAt) > s.settleGrace()
+		if !t.Finishable(now) && !stale {
+			continue
+		}
+		if err := s.Finish(ctx, t.ID); err != nil {
+			s.logger.Error("Failed to finish tournament", zap.Int64("tournament_id", t.ID), zap.Error(err))
+			continue
+		}
+		finished++
+	}
+	return len(started), finished, nil
+}
+
+// CheckTournamentRoom 检查房主能否为锦标赛创建房间，返回锦标赛
+func (s *TournamentService) CheckTournamentRoom(ctx context.Context, ownerID, tournamentID int64, betAmount decimal.Decimal) (*model.Tournament, error) {
+	t, err := s.get(ctx, tournamentID)
+	if err != nil {
+		return nil, err
+	}
+	if t.OwnerID != ownerID {
+		return nil, ErrTournamentForbidden
+	}
+	if t.Status != model.TournamentStatusScheduled && t.Status != model.TournamentStatusRunning {
+		return nil, ErrTournamentClosed
+	}
+	if betAmount.GreaterThan(t.StartingChips) {
+		return nil, ErrTournamentBetTooLarge
+	}
+	return t, nil
+}
+
+// ===== 锦标赛筹码账本（game.TournamentLedger） =====
+
+// GetChips 获取玩家锦标赛筹码（未报名为 0）
+func (s *TournamentService) GetChips(ctx context.Context, tournamentID, userID int64) (decimal.Decimal, error) {
+	entry, err := s.tournamentRepo.GetEntry(ctx, tournamentID, userID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return decimal.Zero, nil
+	}
+	if err != nil {
+		return decimal.Zero, err
+	}
+	return entry.Chips, nil
+}
+
+// CheckEntrant 检查玩家是否为锦标赛的有效报名者
+func (s *TournamentService) CheckEntrant(ctx context.Context, tournamentID, userID int64) error {
+	entry, err := s.tournamentRepo.GetEntry(ctx, tournamentID, userID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return game.ErrNotTournamentEntrant
+	}
+	if err != nil {
+		return err
+	}
+	if entry.Status != model.TournamentEntryActive {
+		return game.ErrNotTournamentEntrant
+	}
+	return nil
+}
+
+// AcceptingRounds 锦标赛是否可以开始新回合
+func (s *TournamentService) AcceptingRounds(ctx context.Context, tournamentID int64) bool {
+	t, err := s.tournamentRepo.GetByID(ctx, tournamentID)
+	if err != nil {
+		return false
+	}
+	return t.Status == model.TournamentStatusRunning && !t.RoundLimitReached(time.Now())
+}
+
+// DeductStakes 开始回合：扣除下注筹码（记入在途下注）并计入已开始回合数，返回扣除成功的玩家及其新筹码
+func (s *TournamentService) DeductStakes(ctx context.Context, tournamentID int64, userIDs []int64, stake decimal.Decimal) (map[int64]decimal.Decimal, error) {
+	var balances map[int64]decimal.Decimal
+	err := repository.Tx(ctx, func(tx pgx.Tx) error {
+		t, err := s.tournamentRepo.GetForUpdateTx(ctx, tx, tournamentID)
+		if err != nil {
+			return err
+		}
+		if t.Status != model.TournamentStatusRunning || t.RoundLimitReached(time.Now()) {
+			return ErrTournamentNotRunning
+		}
+		balances, err = s.tournamentRepo.BatchDeductChipsTx(ctx, tx, tournamentID, userIDs, stake)
+		if err != nil || len(balances) == 0 {
+			return err
+		}
+		return s.tournamentRepo.AddRoundsTx(ctx, tx, tournamentID, 1, 0)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return balances, nil
+}
+
+// RefundStakes 回合取消：退回在途下注筹码并撤销已开始回合数
+// 锦标赛结束时已退回的下注不会重复退回
+func (s *TournamentService) RefundStakes(ctx context.Context, tournamentID int64, userIDs []int64, stake decimal.Decimal) (map[int64]decimal.Decimal, error) {
+	var balances map[int64]decimal.Decimal
+	err := repository.Tx(ctx, func(tx pgx.Tx) error {
+		var err error
+		if balances, err = s.tournamentRepo.RefundStakesTx(ctx, tx, tournamentID, userIDs, stake); err != nil {
+			return err
+		}
+		return s.tournamentRepo.AddRoundsTx(ctx, tx, tournamentID, -1, 0)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return balances, nil
+}
+
+// SettleRound 回合结算：扣减参与者的在途下注，奖池按赢家人数均分为筹码（向下取整到分），推送实时排名，
+// 达到回合上限（或结束时间）且全部回合已结算时结束锦标赛
+func (s *TournamentService) SettleRound(ctx context.Context, tournamentID int64, participants, winners []int64, pool decimal.Decimal) (decimal.Decimal, map[int64]decimal.Decimal, error) {
+	prize := splitPracticePool(pool, len(winners))
+	amounts := make(map[int64]decimal.Decimal, len(winners))
+	if prize.IsPositive() {
+		for _, winnerID := range winners {
+			amounts[winnerID] = prize
+		}
+	}
+	var balances map[int64]decimal.Decimal
+	err := repository.Tx(ctx, func(tx pgx.Tx) error {
+		var err error
+		if len(participants) > 0 {
+			stake := pool.Div(decimal.NewFromInt(int64(len(participants))))
+			if err = s.tournamentRepo.ReleaseStakesTx(ctx, tx, tournamentID, participants, stake); err != nil {
+				return err
+			}
+		}
+		if balances, err = s.tournamentRepo.BatchAddChipsTx(ctx, tx, tournamentID, amounts); err != nil {
+			return err
+		}
+		return s.tournamentRepo.AddRoundsTx(ctx, tx, tournamentID, 0, 1)
+	})
+	if err != nil {
+		return decimal.Zero, nil, err
+	}
+
+	// 排名推送与结束派奖不阻塞房间结算
+	go s.afterRound(tournamentID)
+	return prize, balances, nil
+}
+
+// afterRound 回合结算后推送实时排名，可结束时结束锦标赛
+func (s *TournamentService) afterRound(tournamentID int64) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	t, err := s.tournamentRepo.GetByID(ctx, tournamentID)
+	if err != nil {
+		s.logger.Warn("Failed to load tournament after round", zap.Int64("tournament_id", tournamentID), zap.Error(err))
+		return
+	}
+	if !t.Finishable(time.Now()) {
+		s.broadcastStandings(ctx, tournamentID)
+		return
+	}
+	if err := s.Finish(ctx, tournamentID); err != nil && !errors.Is(err, ErrTournamentNotRunning) {
+		s.logger.Error("Failed to finish tournament", zap.Int64("tournament_id", tournamentID), zap.Error(err))
+	}
+}
+
+// ===== 排名与推送 =====
+
+// standings 计算实时排名（进行中按当前排名预计奖金，结束后为实际奖金）
+func (s *TournamentService) standings(ctx context.Context, t *model.Tournament) ([]*model.TournamentStanding, error) {
+	entries, err := s.tournamentRepo.ListEntriesTx(ctx, nil, t.ID)
+	if err != nil {
+		return nil, err
+	}
+	return buildTournamentStandings(t, entries), nil
+}
+
+// closeRooms 锦标赛结束或取消后暂停其房间，并推送最终排名
+func (s *TournamentService) closeRooms(ctx context.Context, id int64) {
+	rooms, err := s.roomRepo.ListByTournament(ctx, id)
+	if err != nil {
+		s.logger.Warn("Failed to list tournament rooms", zap.Int64("tournament_id", id), zap.Error(err))
+	}
+	for _, room := range rooms {
+		if room.Status != model.RoomStatusActive {
+			continue
+		}
+		if err := s.roomRepo.UpdateStatus(ctx, room.ID, model.RoomStatusPaused); err != nil {
+			s.logger.Warn("Failed to pause tournament room", zap.Int64("room_id", room.ID), zap.Error(err))
+		}
+	}
+	s.broadcastStandings(ctx, id)
+}
+
+// broadcastStandings 向锦标赛的所有房间推送实时排名
+func (s *TournamentService) broadcastStandings(ctx context.Context, id int64) {
+	if s.hub == nil {
+		return
+	}
+	t, err := s.tournamentRepo.GetByID(ctx, id)
+	if err != nil {
+		return
+	}
+	standings, err := s.standings(ctx, t)
+	if err != nil {
+		s.logger.Warn("Failed to compute tournament standings", zap.Int64("tournament_id", id), zap.Error(err))
+		return
+	}
+	rooms, err := s.roomRepo.ListByTournament(ctx, id)
+	if err != nil {
+		return
+	}
+	msg := &model.WSMessage{
+		Type: model.WSTypeTournamentStandings,
+		Payload: &model.WSTournamentStandings{
+			TournamentID: t.ID,
+			Status:       t.Status,
+			RoundsPlayed: t.RoundsPlayed,
+			MaxRounds:    t.MaxRounds,
+			Standings:    standings,
+		},
+	}
+	for _, room := range rooms {
+		s.hub.BroadcastToRoom(room.ID, msg)
+	}
+}
+
+// notifyBalanceUpdate 通知玩家余额更新
+func (s *TournamentService) notifyBalanceUpdate(userID int64, balance decimal.Decimal) {
+	if s.hub == nil {
+		return
+	}
+	s.hub.SendToUser(userID, &model.WSMessage{
+		Type: model.WSTypeBalanceUpdate,
+		Payload: &model.WSBalanceUpdate{
+			Balance:       balance.String(),
+			FrozenBalance: "0",
+		},
+	})
+}
+
+// normalizeTournamentPayouts 校验奖励分配表并按顺序编号名次
+// 比例须为正数、按名次不递增（且最多两位小数），合计 100
+func normalizeTournamentPayouts(shares []*model.TournamentPayoutShare) ([]*model.TournamentPayoutShare, error) {
+	if len(shares) == 0 || len(shares) > MaxTournamentPayoutRanks {
+		return nil, ErrTournamentInvalidPayouts
+	}
+	hundred := decimal.NewFromInt(100)
+	total := decimal.Zero
+	result := make([]*model.TournamentPayoutShare, 0, len(shares))
+	for i, p := range shares {
+		if p == nil || !p.Share.IsPositive() || !p.Share.Equal(p.Share.Round(2)) {
+			return nil, ErrTournamentInvalidPayouts
+		}
+		if i > 0 && p.Share.GreaterThan(shares[i-1].Share) {
+			return nil, ErrTournamentInvalidPayouts
+		}
+		total = total.Add(p.Share)
+		result = append(result, &model.TournamentPayoutShare{Rank: i + 1, Share: p.Share})
+	}
+	if !total.Equal(hundred) {
+		return nil, ErrTournamentInvalidPayouts
+	}
+	return result, nil
+}
+
+// computeTournamentPayouts 按名次计算奖金（返回长度为参赛人数）
+// 参赛人数少于奖励名次时，只按前 entrants 个名次的比例重新归一化，保证奖池全部派发；
+// 各名次奖金向下取整到分，余数归第一名
+func computeTournamentPayouts(pool decimal.Decimal, shares []*model.TournamentPayoutShare, entrants int) []decimal.Decimal {
+	if entrants <= 0 {
+		return nil
+	}
+	prizes := make([]decimal.Decimal, entrants)
+	for i := range prizes {
+		prizes[i] = decimal.Zero
+	}
+	k := len(shares)
+	if entrants < k {
+		k = entrants
+	}
+	if k == 0 || !pool.IsPositive() {
+		return prizes
+	}
+
+	totalShare := decimal.Zero
+	for _, p := range shares[:k] {
+		totalShare = totalShare.Add(p.Share)
+	}
+	if !totalShare.IsPositive() {
+		return prizes
+	}
+	paid := decimal.Zero
+	for i, p := range shares[:k] {
+		prizes[i] = pool.Mul(p.Share).Div(totalShare).RoundFloor(2)
+		paid = paid.Add(prizes[i])
+	}
+	prizes[0] = prizes[0].Add(pool.Sub(paid))
+	return prizes
+}
+
+// rankTournamentEntries 按筹码计算名次并平分并列名次的奖金
+// entries 需已按筹码降序、同筹码按报名先后排列，prizes 为按位置计算的奖金（与 entries 等长）；
+// 筹码相同者名次相同（下一名次顺延），平分所占各位置奖金之和（向下取整到分），余数归其中报名最早者，奖金总额不变
+func rankTournamentEntries(entries []*model.TournamentEntry, prizes []decimal.Decimal) ([]int, []decimal.Decimal) {
+	ranks := make([]int, len(entries))
+	split := make([]decimal.Decimal, len(entries))
+	for start := 0; start < len(entries); {
+		end := start + 1
+		for end < len(entries) && entries[end].Chips.Equal(entries[start].Chips) {
+			end++
+		}
+		total := decimal.Zero
+		for i := start; i < end; i++ {
+			total = total.Add(prizes[i])
+		}
+		tied := decimal.NewFromInt(int64(end - start))
+		share := total.Div(tied).RoundFloor(2)
+		for i := start; i < end; i++ {
+			ranks[i] = start + 1
+			split[i] = share
+		}
+		split[start] = split[start].Add(total.Sub(share.Mul(tied)))
+		start = end
+	}
+	return ranks, split
+}
+
+// buildTournamentStandings 按报名记录（已按排名排序）生成排名
+// 已结束的锦标赛使用最终名次与实际奖金；进行中按当前排名与奖池余额预计奖金；已取消无奖金
+func buildTournamentStandings(t *model.Tournament, entries []*model.TournamentEntry) []*model.TournamentStanding {
+	ranked := make([]*model.TournamentEntry, 0, len(entries))
+	for _, e := range entries {
+		if e.Status != model.TournamentEntryRefunded {
+			ranked = append(ranked, e)
+		}
+	}
+
+	projecting := t.Status == model.TournamentStatusScheduled || t.Status == model.TournamentStatusRunning
+	ranks, projected := rankTournamentEntries(ranked, computeTournamentPayouts(t.PoolBalance, t.Payouts, len(ranked)))
+
+	standings := make([]*model.TournamentStanding, 0, len(entries))
+	for i, e := range ranked {
+		standing := &model.TournamentStanding{
+			Rank:     ranks[i],
+			UserID:   e.UserID,
+			Username: e.Username,
+			Chips:    e.Chips,
+			Prize:    e.Prize,
+		}
+		if e.FinalRank != nil {
+			standing.Rank = *e.FinalRank
+		}
+		if projecting {
+			standing.Prize = projected[i]
+		}
+		standings = append(standings, standing)
+	}
+	// 已取消的锦标赛列出退款的报名者，不排名
+	if t.Status == model.TournamentStatusCancelled {
+		for _, e := range entries {
+			if e.Status == model.TournamentEntryRefunded {
+				standings = append(standings, &model.TournamentStanding{
+					UserID:   e.UserID,
+					Username: e.Username,
+					Chips:    e.Chips,
+					Prize:    decimal.Zero,
+				})
+			}
+		}
+	}
+	return standings
+}
@@ -0,0 +1,171 @@
+package service
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/fiveseconds/server/internal/model"
+	"github.com/shopspring/decimal"
+)
+
+func payoutShares(shares ...string) []*model.TournamentPayoutShare {
+	result := make([]*model.TournamentPayoutShare, len(shares))
+	for i, s := range shares {
+		result[i] = &model.TournamentPayoutShare{Share: decimal.RequireFromString(s)}
+	}
+	return result
+}
+
+func decimalStrings(values []decimal.Decimal) []string {
+	result := make([]string, len(values))
+	for i, v := range values {
+		result[i] = v.String()
+	}
+	return result
+}
+
+// TestNormalizeTournamentPayouts 测试奖励分配表校验：比例为正、最多两位小数、按名次不递增、合计 100
+func TestNormalizeTournamentPayouts(t *testing.T) {
+	hundredRanks := make([]string, MaxTournamentPayoutRanks)
+	for i := range hundredRanks {
+		hundredRanks[i] = "1"
+	}
+	tooManyRanks := append(append([]string{}, hundredRanks[:MaxTournamentPayoutRanks-1]...), "0.5", "0.5")
+
+	tests := []struct {
+		name   string
+		shares []*model.TournamentPayoutShare
+		valid  bool
+	}{
+		{"descending shares", payoutShares("50", "30", "20"), true},
+		{"winner takes all", payoutShares("100"), true},
+		{"equal shares", payoutShares("50", "50"), true},
+		{"two decimals", payoutShares("33.34", "33.33", "33.33"), true},
+		{"maximum ranks", payoutShares(hundredRanks...), true},
+		{"too many ranks", payoutShares(tooManyRanks...), false},
+		{"sum below 100", payoutShares("50", "30"), false},
+		{"sum above 100", payoutShares("60", "50"), false},
+		{"lower rank larger share", payoutShares("30", "70"), false},
+		{"zero share", payoutShares("100", "0"), false},
+		{"negative share", payoutShares("110", "-10"), false},
+		{"three decimals", payoutShares("33.334", "33.333", "33.333"), false},
+		{"nil entry", []*model.TournamentPayoutShare{{Share: decimal.NewFromInt(100)}, nil}, false},
+		{"empty table", nil, false},
+	}
+	for _, tt := range tests {
+		normalized, err := normalizeTournamentPayouts(tt.shares)
+		if !tt.valid {
+			if !errors.Is(err, ErrTournamentInvalidPayouts) {
+				t.Errorf("%s: Expected ErrTournamentInvalidPayouts, got %v", tt.name, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: Expected valid table, got %v", tt.name, err)
+			continue
+		}
+		for i, p := range normalized {
+			if p.Rank != i+1 || !p.Share.Equal(tt.shares[i].Share) {
+				t.Errorf("%s: Expected rank %d share %s, got rank %d share %s", tt.name, i+1, tt.shares[i].Share, p.Rank, p.Share)
+			}
+		}
+	}
+}
+
+// TestComputeTournamentPayouts 测试按名次派奖：奖池恰好派完，人数不足时按已有名次归一化，余数归第一名
+func TestComputeTournamentPayouts(t *testing.T) {
+	tests := []struct {
+		name     string
+		pool     string
+		shares   []*model.TournamentPayoutShare
+		entrants int
+		want     []string
+	}{
+		{"more entrants than paid ranks", "100", payoutShares("50", "30", "20"), 5, []string{"50", "30", "20", "0", "0"}},
+		{"fewer entrants renormalize", "100", payoutShares("50", "30", "20"), 2, []string{"62.5", "37.5"}},
+		{"remainder to first place", "10", payoutShares("33.34", "33.33", "33.33"), 3, []string{"3.34", "3.33", "3.33"}},
+		{"single cent", "0.01", payoutShares("50", "50"), 2, []string{"0.01", "0"}},
+		{"empty pool", "0", payoutShares("100"), 2, []string{"0", "0"}},
+		{"no entrants", "100", payoutShares("100"), 0, nil},
+	}
+	for _, tt := range tests {
+		prizes := computeTournamentPayouts(decimal.RequireFromString(tt.pool), tt.shares, tt.entrants)
+		if got := decimalStrings(prizes); len(got) != len(tt.want) || (len(got) > 0 && !reflect.DeepEqual(got, tt.want)) {
+			t.Errorf("%s: Expected prizes %v, got %v", tt.name, tt.want, got)
+		}
+	}
+}
+
+// TestRankTournamentEntries 测试筹码相同者名次相同并平分所占名次奖金，余数归报名最早者，奖金总额不变
+func TestRankTournamentEntries(t *testing.T) {
+	tests := []struct {
+		name   string
+		chips  []int64
+		prizes []string
+		ranks  []int
+		split  []string
+	}{
+		{"no ties", []int64{3, 2, 1}, []string{"5", "3", "2"}, []int{1, 2, 3}, []string{"5", "3", "2"}},
+		{"tied groups", []int64{10, 10, 5, 5, 5, 1}, []string{"50", "30", "10", "6", "4", "0"},
+			[]int{1, 1, 3, 3, 3, 6}, []string{"40", "40", "6.68", "6.66", "6.66", "0"}},
+		{"all tied", []int64{7, 7, 7}, []string{"0.05", "0.03", "0.02"}, []int{1, 1, 1}, []string{"0.04", "0.03", "0.03"}},
+		{"tie outside paid ranks", []int64{9, 4, 4}, []string{"100", "0", "0"}, []int{1, 2, 2}, []string{"100", "0", "0"}},
+		{"no entries", nil, nil, []int{}, []string{}},
+	}
+	for _, tt := range tests {
+		entries := make([]*model.TournamentEntry, len(tt.chips))
+		prizes := make([]decimal.Decimal, len(tt.prizes))
+		for i, c := range tt.chips {
+			entries[i] = &model.TournamentEntry{UserID: int64(i + 1), Chips: decimal.NewFromInt(c)}
+			prizes[i] = decimal.RequireFromString(tt.prizes[i])
+		}
+		ranks, split := rankTournamentEntries(entries, prizes)
+		if !reflect.DeepEqual(ranks, tt.ranks) || !reflect.DeepEqual(decimalStrings(split), tt.split) {
+			t.Errorf("%s: Expected ranks %v prizes %v, got %v %v", tt.name, tt.ranks, tt.split, ranks, decimalStrings(split))
+		}
+	}
+}
+
+// TestTournamentFinishable 测试锦标赛达到回合上限或结束时间后不再开始新回合，已开始的回合全部结算后才可结束
+func TestTournamentFinishable(t *testing.T) {
+	now := time.Date(2024, 3, 13, 12, 0, 0, 0, time.UTC)
+	past := now.Add(-time.Minute)
+	future := now.Add(time.Minute)
+	tests := []struct {
+		name       string
+		status     model.TournamentStatus
+		maxRounds  int
+		started    int
+		played     int
+		endsAt     *time.Time
+		limit      bool
+		finishable bool
+	}{
+		{"rounds remaining", model.TournamentStatusRunning, 10, 5, 5, nil, false, false},
+		{"round limit reached", model.TournamentStatusRunning, 10, 10, 10, nil, true, true},
+		{"last round in flight", model.TournamentStatusRunning, 10, 10, 9, nil, true, false},
+		{"end time passed", model.TournamentStatusRunning, 0, 3, 3, &past, true, true},
+		{"end time exactly now", model.TournamentStatusRunning, 0, 3, 3, &now, true, true},
+		{"end time passed with round in flight", model.TournamentStatusRunning, 0, 3, 2, &past, true, false},
+		{"end time ahead", model.TournamentStatusRunning, 0, 3, 3, &future, false, false},
+		{"no limits", model.TournamentStatusRunning, 0, 3, 3, nil, false, false},
+		{"not running", model.TournamentStatusFinished, 10, 10, 10, nil, true, false},
+	}
+	for _, tt := range tests {
+		tour := &model.Tournament{
+			Status:        tt.status,
+			MaxRounds:     tt.maxRounds,
+			RoundsStarted: tt.started,
+			RoundsPlayed:  tt.played,
+			EndsAt:        tt.endsAt,
+		}
+		if got := tour.RoundLimitReached(now); got != tt.limit {
+			t.Errorf("%s: Expected round limit reached %v, got %v", tt.name, tt.limit, got)
+		}
+		if got := tour.Finishable(now); got != tt.finishable {
+			t.Errorf("%s: Expected finishable %v, got %v", tt.name, tt.finishable, got)
+		}
+	}
+}
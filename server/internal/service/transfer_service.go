@@ -0,0 +1,390 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/fiveseconds/server/internal/cache"
+	"github.com/fiveseconds/server/internal/model"
+	"github.com/fiveseconds/server/internal/repository"
+	"github.com/fiveseconds/server/internal/ws"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrTransferDisabled         = errors.New("transfers are not enabled by your owner")
+	ErrTransferInvalidAmount    = errors.New("transfer amount must be positive")
+	ErrTransferToSelf           = errors.New("cannot transfer to yourself")
+	ErrTransferDifferentOwner   = errors.New("recipient must belong to the same owner")
+	ErrTransferExceedsLimit     = errors.New("transfer amount exceeds per-transfer limit")
+	ErrTransferDailyLimit       = errors.New("transfer exceeds daily limit")
+	ErrTransferNotFound         = errors.New("transfer not found")
+	ErrTransferAlreadyProcessed = errors.New("transfer already processed")
+	ErrTransferForbidden        = errors.New("this transfer does not belong to your players")
+	ErrTransferInvalidLimit     = errors.New("transfer limits must not be negative")
+	ErrTransferCurrencyMismatch = errors.New("sender and recipient must use the same currency")
+	ErrCircularTransfer         = errors.New("transfer would complete a circular transfer chain and is under risk review")
+)
+
+// TransferRiskChecker 转账风控检查（循环转账检测，发现循环时返回 ErrCircularTransfer）
+type TransferRiskChecker interface {
+	CheckCircularTransfer(ctx context.Context, fromUserID, toUserID int64, amount decimal.Decimal) error
+}
+
+// TransferService 玩家间转账服务
+// 仅允许同一房主（invited_by）名下的玩家互相转账，限额与是否需要审批由房主配置
+type TransferService struct {
+	userRepo     *repository.UserRepo
+	walletRepo   *repository.WalletRepo
+	transferRepo *repository.TransferRepo
+	txRepo       *repository.TransactionRepo
+	riskChecker  TransferRiskChecker
+	restrictions AccountRestrictionChecker
+	balanceCache *cache.BalanceCache
+	hub          *ws.Hub
+	logger       *zap.Logger
+}
+
+// NewTransferService 创建玩家转账服务
+func NewTransferService(
+	userRepo *repository.UserRepo,
+	walletRepo *repository.WalletRepo,
+	transferRepo *repository.TransferRepo,
+	txRepo *repository.TransactionRepo,
+	logger *zap.Logger,
+) *TransferService {
+	return &TransferService{
+		userRepo:     userRepo,
+		walletRepo:   walletRepo,
+		transferRepo: transferRepo,
+		txRepo:       txRepo,
+		logger:       logger.With(zap.String("service", "transfer")),
+	}
+}
+
+// SetHub 设置 WebSocket Hub（用于发送余额更新通知）
+func (s *TransferService) SetHub(hub *ws.Hub) {
+	s.hub = hub
+}
+
+// SetRiskChecker 设置风控检查（用于循环转账检测）
+func (s *TransferService) SetRiskChecker(riskChecker TransferRiskChecker) {
+	s.riskChecker = riskChecker
+}
+
+// SetRestrictionChecker 设置账户限制检查（转出受“禁止提现”限制，全面封停时同样禁止）
+func (s *TransferService) SetRestrictionChecker(restrictions AccountRestrictionChecker) {
+	s.restrictions = restrictions
+}
+
+// SetBalanceCache 设置余额缓存（写回模式下扣款前需暂停转出方的缓存扣款并落库）
+func (s *TransferService) SetBalanceCache(balanceCache *cache.BalanceCache) {
+	s.balanceCache = balanceCache
+}
+
+// CreateTransfer 发起转账
+// 房主未开启审批时立即到账，否则创建待审批记录（审批通过时才扣款）
+// 余额与每日限额在锁定转出方用户行后校验，同一玩家的并发转账串行执行
+func (s *TransferService) CreateTransfer(ctx context.Context, fromUserID int64, req *model.CreateTransferReq) (*model.PlayerTransfer, error) {
+	if !req.Amount.IsPositive() {
+		return nil, ErrTransferInvalidAmount
+	}
+	if err := s.checkSenderRestriction(ctx, fromUserID); err != nil {
+		return nil, err
+	}
+
+	from, err := s.userRepo.GetByID(ctx, fromUserID)
+	if err != nil {
+		return nil, err
+	}
+	if !from.IsPlayer() || from.InvitedBy == nil {
+		return nil, errors.New("player must have an owner")
+	}
+
+	to, err := s.userRepo.GetByUsername(ctx, req.ToUsername)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, errors.New("recipient not found")
+		}
+		return nil, err
+	}
+	if to.ID == from.ID {
+		return nil, ErrTransferToSelf
+	}
+	if !to.IsPlayer() || to.InvitedBy == nil || *to.InvitedBy != *from.InvitedBy {
+		return nil, ErrTransferDifferentOwner
+	}
+
+	ownerID := *from.InvitedBy
+	settings, err := s.transferRepo.GetSettings(ctx, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	if !settings.Enabled {
+		return nil, ErrTransferDisabled
+	}
+	if settings.PerTransferLimit.IsPositive() && req.Amount.GreaterThan(settings.PerTransferLimit) {
+		return nil, ErrTransferExceedsLimit
+	}
+
+	remark := req.Remark
+	transfer := &model.PlayerTransfer{
+		OwnerID:      ownerID,
+		FromUserID:   from.ID,
+		FromUsername: from.Username,
+		ToUserID:     to.ID,
+		ToUsername:   to.Username,
+		Amount:       req.Amount,
+		Remark:       &remark,
+	}
+
+	// 在转出方行锁内统计当日转出额并写入转账记录
+	persist := func(tx pgx.Tx, sender *model.CurrencyWallet) error {
+		transfer.Currency = sender.Currency
+		if settings.DailyLimit.IsPositive() {
+			now := time.Now()
+			dayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+			sent, err := s.transferRepo.GetOutgoingAmountSinceTx(ctx, tx, fromUserID, dayStart)
+			if err != nil {
+				return err
+			}
+			if err := checkTransferDailyLimit(settings.DailyLimit, sent, req.Amount); err != nil {
+				return err
+			}
+		}
+		return s.transferRepo.CreateTx(ctx, tx, transfer)
+	}
+
+	if settings.RequireApproval {
+		transfer.Status = model.TransferStatusPending
+		err = repository.Tx(ctx, func(tx pgx.Tx) error {
+			sender, recipient, err := s.lockTransferParties(ctx, tx, transfer)
+			if err != nil {
+				return err
+			}
+			if err := checkTransferFunds(sender, recipient, req.Amount); err != nil {
+				return err
+			}
+			return persist(tx, sender)
+		})
+		if err != nil {
+			return nil, err
+		}
+		return transfer, nil
+	}
+
+	transfer.Status = model.TransferStatusCompleted
+	if err := s.executeTransfer(ctx, transfer, persist); err != nil {
+		return nil, err
+	}
+	return transfer, nil
+}
+
+// ProcessTransfer 房主审批待处理的转账（管理员可处理任意房主的转账）
+func (s *TransferService) ProcessTransfer(ctx context.Context, transferID, operatorID int64, operatorRole model.Role, req *model.ProcessTransferReq) error {
+	transfer, err := s.transferRepo.GetByID(ctx, transferID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrTransferNotFound
+		}
+		return err
+	}
+	if operatorRole != model.RoleAdmin && transfer.OwnerID != operatorID {
+		return ErrTransferForbidden
+	}
+	if transfer.Status != model.TransferStatusPending {
+		return ErrTransferAlreadyProcessed
+	}
+
+	if !req.Approved {
+		return s.transferRepo.UpdateStatusTx(ctx, nil, transferID, model.TransferStatusRejected, operatorID, req.Remark)
+	}
+
+	return s.executeTransfer(ctx, transfer, func(tx pgx.Tx, _ *model.CurrencyWallet) error {
+		return s.transferRepo.UpdateStatusTx(ctx, tx, transferID, model.TransferStatusCompleted, operatorID, req.Remark)
+	})
+}
+
+// checkTransferDailyLimit 校验当日转出总额（含本次）不超过每日限额（限额为 0 表示不限）
+func checkTransferDailyLimit(dailyLimit, sentToday, amount decimal.Decimal) error {
+	if dailyLimit.IsPositive() && sentToday.Add(amount).GreaterThan(dailyLimit) {
+		return ErrTransferDailyLimit
+	}
+	return nil
+}
+
+// checkTransferFunds 校验已锁定的双方账户：币种一致且转出方可用余额足够
+func checkTransferFunds(sender, recipient *model.CurrencyWallet, amount decimal.Decimal) error {
+	if sender.Currency != recipient.Currency {
+		return ErrTransferCurrencyMismatch
+	}
+	if sender.Balance.LessThan(amount) {
+		return repository.ErrInsufficientBalance
+	}
+	return nil
+}
+
+// checkSenderRestriction 检查转出方是否被禁止转出资金（被限制时返回 *RestrictedError）
+func (s *TransferService) checkSenderRestriction(ctx context.Context, userID int64) error {
+	if s.restrictions == nil {
+		return nil
+	}
+	return s.restrictions.Check(ctx, userID, model.RestrictionNoWithdrawal)
+}
+
+// lockTransferParties 按用户ID升序锁定转账双方的用户行，返回锁内读取的转出方与转入方活跃余额
+// 固定加锁顺序避免双方互相转账时死锁
+func (s *TransferService) lockTransferParties(ctx context.Context, tx pgx.Tx, transfer *model.PlayerTransfer) (*model.CurrencyWallet, *model.CurrencyWallet, error) {
+	firstID, secondID := transfer.FromUserID, transfer.ToUserID
+	if secondID < firstID {
+		firstID, secondID = secondID, firstID
+	}
+	first, err := s.walletRepo.GetActiveForUpdateTx(ctx, tx, firstID)
+	if err != nil {
+		return nil, nil, err
+	}
+	second, err := s.walletRepo.GetActiveForUpdateTx(ctx, tx, secondID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if firstID == transfer.FromUserID {
+		return first, second, nil
+	}
+	return second, first, nil
+}
+
+// executeTransfer 执行转账（使用事务保护，并记录双方交易流水）
+// persist 在锁定双方用户行后于同一事务中校验限额并写入/更新转账记录；
+// 余额校验与流水中的变动前后余额均基于锁内读取的值。
+// 循环转账检测在事务开始前同步执行，检测失败或发现循环时不执行转账。
+// 转出方在待审批期间可能被限制，执行前重新检查账户限制。
+// 写回模式下事务期间暂停转出方的缓存扣款，转出方仍有未落库变动时返回 ErrBalanceBusy。
+func (s *TransferService) executeTransfer(ctx context.Context, transfer *model.PlayerTransfer, persist func(tx pgx.Tx, sender *model.CurrencyWallet) error) error {
+	if err := s.checkSenderRestriction(ctx, transfer.FromUserID); err != nil {
+		return err
+	}
+	if s.riskChecker != nil {
+		if err := s.riskChecker.CheckCircularTransfer(ctx, transfer.FromUserID, transfer.ToUserID, transfer.Amount); err != nil {
+			if !errors.Is(err, ErrCircularTransfer) {
+				s.logger.Error("circular transfer check failed", zap.Int64("transfer_id", transfer.ID), zap.Error(err))
+			}
+			return err
+		}
+	}
+
+	// 写回模式下转出方可能有未落库的游戏扣款，扣款前暂停其缓存扣款并落库
+	release, err := prepareBalanceWrite(ctx, s.balanceCache, transfer.FromUserID)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	var from, to *model.CurrencyWallet
+	err = repository.Tx(ctx, func(tx pgx.Tx) error {
+		var err error
+		from, to, err = s.lockTransferParties(ctx, tx, transfer)
+		if err != nil {
+			return err
+		}
+		// 待审批期间任一方可能切换了活跃币种或余额已变化
+		if err := checkTransferFunds(from, to, transfer.Amount); err != nil {
+			return err
+		}
+		if err := persist(tx, from); err != nil {
+			return err
+		}
+
+		// 转出方余额减少
+		if err := s.userRepo.UpdateBalanceTx(ctx, tx, transfer.FromUserID, transfer.Amount.Neg()); err != nil {
+			return fmt.Errorf("deduct sender balance: %w", err)
+		}
+		outTx := &model.BalanceTransaction{
+			UserID:        transfer.FromUserID,
+			Type:          model.TxTransferOut,
+			Amount:        transfer.Amount.Neg(),
+			BalanceBefore: from.Balance,
+			BalanceAfter:  from.Balance.Sub(transfer.Amount),
+			BalanceField:  "balance",
+			Currency:      from.Currency,
+			Remark:        strPtr(fmt.Sprintf("转账转出(收款人ID:%d,转账ID:%d)", transfer.ToUserID, transfer.ID)),
+		}
+		if err := s.txRepo.CreateTx(ctx, tx, outTx); err != nil {
+			return fmt.Errorf("create sender transaction: %w", err)
+		}
+
+		// 转入方余额增加
+		if err := s.userRepo.UpdateBalanceTx(ctx, tx, transfer.ToUserID, transfer.Amount); err != nil {
+			return fmt.Errorf("add recipient balance: %w", err)
+		}
+		inTx := &model.BalanceTransaction{
+			UserID:        transfer.ToUserID,
+			Type:          model.TxTransferIn,
+			Amount:        transfer.Amount,
+			BalanceBefore: to.Balance,
+			BalanceAfter:  to.Balance.Add(transfer.Amount),
+			BalanceField:  "balance",
+			Currency:      to.Currency,
+			Remark:        strPtr(fmt.Sprintf("转账转入(转出人ID:%d,转账ID:%d)", transfer.FromUserID, transfer.ID)),
+		}
+		if err := s.txRepo.CreateTx(ctx, tx, inTx); err != nil {
+			return fmt.Errorf("create recipient transaction: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	transfer.Status = model.TransferStatusCompleted
+
+	// 事务成功后发送 WebSocket 通知
+	s.notifyBalanceUpdate(transfer.FromUserID, from.Balance.Sub(transfer.Amount), from.FrozenBalance)
+	s.notifyBalanceUpdate(transfer.ToUserID, to.Balance.Add(transfer.Amount), to.FrozenBalance)
+	return nil
+}
+
+// notifyBalanceUpdate 通知用户余额更新
+func (s *TransferService) notifyBalanceUpdate(userID int64, balance, frozenBalance decimal.Decimal) {
+	if s.hub == nil {
+		return
+	}
+	s.hub.SendToUser(userID, &model.WSMessage{
+		Type: model.WSTypeBalanceUpdate,
+		Payload: &model.WSBalanceUpdate{
+			Balance:       balance.String(),
+			FrozenBalance: frozenBalance.String(),
+		},
+	})
+}
+
+// ListTransfers 列表转账记录
+func (s *TransferService) ListTransfers(ctx context.Context, query *model.TransferListQuery) ([]*model.PlayerTransfer, int64, error) {
+	return s.transferRepo.List(ctx, query)
+}
+
+// GetSettings 获取房主转账设置
+func (s *TransferService) GetSettings(ctx context.Context, ownerID int64) (*model.OwnerTransferSettings, error) {
+	return s.transferRepo.GetSettings(ctx, ownerID)
+}
+
+// UpdateSettings 更新房主转账设置
+func (s *TransferService) UpdateSettings(ctx context.Context, ownerID int64, req *model.UpdateTransferSettingsReq) (*model.OwnerTransferSettings, error) {
+	if req.PerTransferLimit.IsNegative() || req.DailyLimit.IsNegative() {
+		return nil, ErrTransferInvalidLimit
+	}
+	settings := &model.OwnerTransferSettings{
+		OwnerID:          ownerID,
+		Enabled:          req.Enabled,
+		PerTransferLimit: req.PerTransferLimit,
+		DailyLimit:       req.DailyLimit,
+		RequireApproval:  req.RequireApproval,
+	}
+	if err := s.transferRepo.UpsertSettings(ctx, settings); err != nil {
+		return nil, err
+	}
+	return settings, nil
+}
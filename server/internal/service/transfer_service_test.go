@@ -0,0 +1,103 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/fiveseconds/server/internal/model"
+	"github.com/fiveseconds/server/internal/repository"
+
+	"github.com/shopspring/decimal"
+)
+
+// TestCheckTransferDailyLimit 测试当日转出总额（含本次）不超过限额时允许转账，限额为 0 表示不限
+func TestCheckTransferDailyLimit(t *testing.T) {
+	tests := []struct {
+		name    string
+		limit   string
+		sent    string
+		amount  string
+		wantErr error
+	}{
+		{"no limit", "0", "1000000", "500", nil},
+		{"within limit", "1000", "300", "500", nil},
+		{"reaches limit", "1000", "500", "500", nil},
+		{"exceeds limit", "1000", "500.01", "500", ErrTransferDailyLimit},
+		{"single transfer above limit", "1000", "0", "1000.01", ErrTransferDailyLimit},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkTransferDailyLimit(decimal.RequireFromString(tt.limit), decimal.RequireFromString(tt.sent), decimal.RequireFromString(tt.amount))
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Expected error %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+// TestCheckTransferDailyLimitSequential 测试在转出方行锁下依次校验的转账累计不超过限额
+func TestCheckTransferDailyLimitSequential(t *testing.T) {
+	limit := decimal.NewFromInt(1000)
+	sent := decimal.Zero
+	var accepted []string
+	for _, amount := range []int64{400, 400, 300, 200, 100} {
+		if checkTransferDailyLimit(limit, sent, decimal.NewFromInt(amount)) == nil {
+			sent = sent.Add(decimal.NewFromInt(amount))
+			accepted = append(accepted, decimal.NewFromInt(amount).String())
+		}
+	}
+	if sent.String() != "1000" || len(accepted) != 3 {
+		t.Errorf("Expected 400, 400 and 200 accepted for 1000 in total, got %v for %s", accepted, sent)
+	}
+}
+
+// TestCheckTransferFunds 测试锁内校验双方币种一致且转出方余额足够
+func TestCheckTransferFunds(t *testing.T) {
+	tests := []struct {
+		name              string
+		balance           string
+		recipientCurrency string
+		amount            string
+		wantErr           error
+	}{
+		{"sufficient balance", "100", "CNY", "99.99", nil},
+		{"exact balance", "100", "CNY", "100", nil},
+		{"insufficient balance", "100", "CNY", "100.01", repository.ErrInsufficientBalance},
+		{"currency mismatch", "100", "USD", "10", ErrTransferCurrencyMismatch},
+		{"currency checked first", "0", "USD", "10", ErrTransferCurrencyMismatch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sender := &model.CurrencyWallet{Currency: "CNY", Balance: decimal.RequireFromString(tt.balance), Active: true}
+			recipient := &model.CurrencyWallet{Currency: tt.recipientCurrency, Active: true}
+			if err := checkTransferFunds(sender, recipient, decimal.RequireFromString(tt.amount)); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Expected error %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+// TestTransferLedgerImbalance 测试转账流水与已完成转账记录的核对：缺失成对流水或单边流水都能发现
+func TestTransferLedgerImbalance(t *testing.T) {
+	tests := []struct {
+		name      string
+		completed string
+		in        string
+		out       string
+		want      string
+	}{
+		{"consistent ledger", "1500.50", "1500.50", "1500.50", "0"},
+		{"no transfers", "0", "0", "0", "0"},
+		{"missing paired rows", "1500", "1400", "1400", "200"},
+		{"extra transfer in", "1500", "1510", "1500", "10"},
+		{"extra transfer out", "1500", "1500", "1500.01", "0.01"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := model.TransferLedgerImbalance(decimal.RequireFromString(tt.completed), decimal.RequireFromString(tt.in), decimal.RequireFromString(tt.out))
+			if got.String() != tt.want {
+				t.Errorf("Expected imbalance %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
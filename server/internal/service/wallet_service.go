@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/fiveseconds/server/internal/cache"
 	"github.com/fiveseconds/server/internal/model"
 	"github.com/fiveseconds/server/internal/repository"
 
@@ -13,25 +14,63 @@ import (
 	"github.com/shopspring/decimal"
 )
 
+var (
+	ErrCurrencySwitchNotAllowed = errors.New("only players can switch wallet currency")
+	ErrCurrencyUnchanged        = errors.New("wallet is already using this currency")
+	ErrCurrencySwitchInRoom     = errors.New("leave the room before switching currency")
+	ErrCurrencySwitchPending    = errors.New("frozen or bonus balance must be settled before switching currency")
+	ErrCurrencySwitchBusy       = errors.New("balance changes are still being saved, please try again later")
+	ErrBalanceBusy              = errors.New("game balance changes are still being saved, please try again later")
+)
+
+// prepareBalanceWrite 写回模式下数据库路径修改余额前暂停这些用户的缓存扣款并落库未落库变动
+// 仍有未落库变动时返回 ErrBalanceBusy；成功时事务提交后须调用返回的释放函数
+func prepareBalanceWrite(ctx context.Context, balanceCache *cache.BalanceCache, userIDs ...int64) (func(), error) {
+	release, blocked := balanceCache.PrepareDBWrite(ctx, userIDs)
+	if len(blocked) > 0 {
+		release()
+		return nil, ErrBalanceBusy
+	}
+	return release, nil
+}
+
 // WalletService 钱包服务
 type WalletService struct {
-	userRepo *repository.UserRepo
-	txRepo   *repository.TransactionRepo
+	userRepo     *repository.UserRepo
+	walletRepo   *repository.WalletRepo
+	roomRepo     *repository.RoomRepo
+	txRepo       *repository.TransactionRepo
+	balanceCache *cache.BalanceCache
+	fundEvents   FundEventHook
 }
 
 // NewWalletService 创建钱包服务
-func NewWalletService(userRepo *repository.UserRepo, txRepo *repository.TransactionRepo) *WalletService {
+func NewWalletService(userRepo *repository.UserRepo, walletRepo *repository.WalletRepo, roomRepo *repository.RoomRepo, txRepo *repository.TransactionRepo) *WalletService {
 	return &WalletService{
-		userRepo: userRepo,
-		txRepo:   txRepo,
+		userRepo:   userRepo,
+		walletRepo: walletRepo,
+		roomRepo:   roomRepo,
+		txRepo:     txRepo,
 	}
 }
 
+// SetBalanceCache 设置余额缓存（写回模式下切换币种前需先落库）
+func (s *WalletService) SetBalanceCache(balanceCache *cache.BalanceCache) {
+	s.balanceCache = balanceCache
+}
+
+// SetFundEventHook 设置资金变动事件钩子（房主手动转入佣金后触发）
+func (s *WalletService) SetFundEventHook(hook FundEventHook) {
+	s.fundEvents = hook
+}
+
 // WalletInfo 钱包信息
 type WalletInfo struct {
+	Currency         string          `json:"currency"`          // 活跃钱包币种
 	AvailableBalance decimal.Decimal `json:"available_balance"` // 可用余额
 	FrozenBalance    decimal.Decimal `json:"frozen_balance"`    // 冻结余额（游戏中）
 	TotalBalance     decimal.Decimal `json:"total_balance"`     // 总余额
+	BonusBalance     decimal.Decimal `json:"bonus_balance"`     // 奖励余额（流水达标后转为可用余额）
 	// 房主专属字段
 	IsOwner              bool            `json:"is_owner"`
 	OwnerMarginBalance   decimal.Decimal `json:"owner_margin_balance,omitempty"`   // 保证金（固定不变）
@@ -47,9 +86,11 @@ func (s *WalletService) GetWallet(ctx context.Context, userID int64) (*WalletInf
 	}
 
 	info := &WalletInfo{
+		Currency:         user.Currency,
 		AvailableBalance: user.Balance,
 		FrozenBalance:    user.FrozenBalance,
 		TotalBalance:     user.Balance.Add(user.FrozenBalance),
+		BonusBalance:     user.BonusBalance,
 		IsOwner:          user.IsOwner(),
 	}
 
@@ -109,47 +150,180 @@ func (s *WalletService) TransferEarningsToBalance(ctx context.Context, userID in
 	}
 
 	// 使用事务确保原子性：从 owner_room_balance 转到 balance（可用余额）
-	return repository.Tx(ctx, func(tx pgx.Tx) error {
-		// 1. 从佣金余额扣除
-		newCommissionBalance := user.OwnerRoomBalance.Sub(amount)
-		if err := s.userRepo.UpdateOwnerBalancesTx(ctx, tx, userID, "owner_room_balance", amount.Neg()); err != nil {
-			return fmt.Errorf("deduct commission balance: %w", err)
-		}
-		// 记录佣金扣除交易
-		commissionTx := &model.BalanceTransaction{
-			UserID:        userID,
-			Type:          model.TxEarningsTransfer,
-			Amount:        amount.Neg(),
-			BalanceBefore: user.OwnerRoomBalance,
-			BalanceAfter:  newCommissionBalance,
-			BalanceField:  "owner_room_balance",
-			Remark:        strPtr("佣金转可用余额(扣除)"),
-		}
-		if err := s.txRepo.CreateTx(ctx, tx, commissionTx); err != nil {
-			return fmt.Errorf("create commission deduct transaction: %w", err)
-		}
+	err = repository.Tx(ctx, func(tx pgx.Tx) error {
+		return s.TransferEarningsToBalanceTx(ctx, tx, user, amount, "佣金转可用余额")
+	})
+	if err != nil {
+		return err
+	}
+	dispatchFundEvent(s.fundEvents, &model.FundEvent{
+		Type:       model.FundEventEarningsTransfer,
+		UserID:     userID,
+		Amount:     amount,
+		Currency:   user.Currency,
+		OccurredAt: time.Now(),
+	})
+	return nil
+}
+
+// TransferEarningsToBalanceTx 在事务中将佣金收益转到可用余额，并记录双边流水
+// user 为转账前的用户快照，remark 为流水备注前缀
+func (s *WalletService) TransferEarningsToBalanceTx(ctx context.Context, tx pgx.Tx, user *model.User, amount decimal.Decimal, remark string) error {
+	userID := user.ID
+
+	// 1. 从佣金余额扣除
+	newCommissionBalance := user.OwnerRoomBalance.Sub(amount)
+	if err := s.userRepo.UpdateOwnerBalancesTx(ctx, tx, userID, "owner_room_balance", amount.Neg()); err != nil {
+		return fmt.Errorf("deduct commission balance: %w", err)
+	}
+	// 记录佣金扣除交易
+	commissionTx := &model.BalanceTransaction{
+		UserID:        userID,
+		Type:          model.TxEarningsTransfer,
+		Amount:        amount.Neg(),
+		BalanceBefore: user.OwnerRoomBalance,
+		BalanceAfter:  newCommissionBalance,
+		BalanceField:  "owner_room_balance",
+		Remark:        strPtr(remark + "(扣除)"),
+	}
+	if err := s.txRepo.CreateTx(ctx, tx, commissionTx); err != nil {
+		return fmt.Errorf("create commission deduct transaction: %w", err)
+	}
+
+	// 2. 增加可用余额
+	newBalance := user.Balance.Add(amount)
+	if err := s.userRepo.UpdateBalanceTx(ctx, tx, userID, amount); err != nil {
+		return fmt.Errorf("add available balance: %w", err)
+	}
+	// 记录可用余额增加交易
+	balanceTx := &model.BalanceTransaction{
+		UserID:        userID,
+		Type:          model.TxEarningsTransfer,
+		Amount:        amount,
+		BalanceBefore: user.Balance,
+		BalanceAfter:  newBalance,
+		BalanceField:  "balance",
+		Remark:        strPtr(remark + "(增加)"),
+	}
+	if err := s.txRepo.CreateTx(ctx, tx, balanceTx); err != nil {
+		return fmt.Errorf("create balance add transaction: %w", err)
+	}
+
+	return nil
+}
 
-		// 2. 增加可用余额
-		newBalance := user.Balance.Add(amount)
-		if err := s.userRepo.UpdateBalanceTx(ctx, tx, userID, amount); err != nil {
-			return fmt.Errorf("add available balance: %w", err)
+// ListWallets 获取用户全部币种钱包（活跃钱包在前）
+func (s *WalletService) ListWallets(ctx context.Context, userID int64) ([]*model.CurrencyWallet, error) {
+	return s.walletRepo.List(ctx, userID)
+}
+
+// SwitchCurrency 切换玩家活跃钱包币种
+// 当前活跃余额转入对应币种钱包，目标币种钱包余额转为可用余额；
+// 玩家须不在房间中，且冻结余额与奖励余额均已结清
+func (s *WalletService) SwitchCurrency(ctx context.Context, userID int64, currency string) ([]*model.CurrencyWallet, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !user.IsPlayer() {
+		return nil, ErrCurrencySwitchNotAllowed
+	}
+	currency = NormalizeCurrency(currency)
+	if !IsSupportedCurrency(currency) {
+		return nil, ErrUnsupportedCurrency
+	}
+	if currency == user.Currency {
+		return nil, ErrCurrencyUnchanged
+	}
+	inRoom, err := s.roomRepo.GetPlayerRoom(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if inRoom != nil {
+		return nil, ErrCurrencySwitchInRoom
+	}
+	// 写回模式下仍有未落库的游戏余额变动时不能走数据库路径
+	release, err := prepareBalanceWrite(ctx, s.balanceCache, userID)
+	if err != nil {
+		return nil, ErrCurrencySwitchBusy
+	}
+	defer release()
+
+	err = repository.Tx(ctx, func(tx pgx.Tx) error {
+		from, err := s.walletRepo.GetActiveForUpdateTx(ctx, tx, userID)
+		if err != nil {
+			return err
 		}
-		// 记录可用余额增加交易
-		balanceTx := &model.BalanceTransaction{
-			UserID:        userID,
-			Type:          model.TxEarningsTransfer,
-			Amount:        amount,
-			BalanceBefore: user.Balance,
-			BalanceAfter:  newBalance,
-			BalanceField:  "balance",
-			Remark:        strPtr("佣金转可用余额(增加)"),
+		if from.Currency == currency {
+			return ErrCurrencyUnchanged
 		}
-		if err := s.txRepo.CreateTx(ctx, tx, balanceTx); err != nil {
-			return fmt.Errorf("create balance add transaction: %w", err)
+		if from.FrozenBalance.IsPositive() || from.BonusBalance.IsPositive() {
+			return ErrCurrencySwitchPending
 		}
-
-		return nil
+		taken, err := s.walletRepo.SwitchActiveTx(ctx, tx, userID, from, currency)
+		if err != nil {
+			return fmt.Errorf("switch active currency: %w", err)
+		}
+		return s.txRepo.BatchCreateTx(ctx, tx, currencySwitchRecords(userID, from, currency, taken))
 	})
+	if err != nil {
+		return nil, err
+	}
+	return s.walletRepo.List(ctx, userID)
+}
+
+// currencySwitchRecords 切换币种的流水：活跃余额转出 → 原币种钱包转入，目标币种钱包转出 → 活跃余额转入
+// 活跃币种不存在于 user_wallets，因此原币种钱包转入前余额为 0；金额为 0 的一侧不记录
+func currencySwitchRecords(userID int64, from *model.CurrencyWallet, to string, taken decimal.Decimal) []*model.BalanceTransaction {
+	remark := fmt.Sprintf("切换币种 %s → %s", from.Currency, to)
+	var records []*model.BalanceTransaction
+	if from.Balance.IsPositive() {
+		records = append(records,
+			&model.BalanceTransaction{
+				UserID:        userID,
+				Type:          model.TxCurrencySwitch,
+				Amount:        from.Balance.Neg(),
+				BalanceBefore: from.Balance,
+				BalanceAfter:  decimal.Zero,
+				BalanceField:  "balance",
+				Currency:      from.Currency,
+				Remark:        &remark,
+			},
+			&model.BalanceTransaction{
+				UserID:        userID,
+				Type:          model.TxCurrencySwitch,
+				Amount:        from.Balance,
+				BalanceBefore: decimal.Zero,
+				BalanceAfter:  from.Balance,
+				BalanceField:  model.WalletBalanceField(from.Currency),
+				Currency:      from.Currency,
+				Remark:        &remark,
+			})
+	}
+	if taken.IsPositive() {
+		records = append(records,
+			&model.BalanceTransaction{
+				UserID:        userID,
+				Type:          model.TxCurrencySwitch,
+				Amount:        taken.Neg(),
+				BalanceBefore: taken,
+				BalanceAfter:  decimal.Zero,
+				BalanceField:  model.WalletBalanceField(to),
+				Currency:      to,
+				Remark:        &remark,
+			},
+			&model.BalanceTransaction{
+				UserID:        userID,
+				Type:          model.TxCurrencySwitch,
+				Amount:        taken,
+				BalanceBefore: decimal.Zero,
+				BalanceAfter:  taken,
+				BalanceField:  "balance",
+				Currency:      to,
+				Remark:        &remark,
+			})
+	}
+	return records
 }
 
 // TransactionRecord 交易记录
@@ -159,6 +333,7 @@ type TransactionRecord struct {
 	TypeDisplay string          `json:"type_display"`
 	Amount      decimal.Decimal `json:"amount"`
 	Balance     decimal.Decimal `json:"balance"`
+	Currency    string          `json:"currency"`
 	Remark      string          `json:"remark,omitempty"`
 	CreatedAt   time.Time       `json:"created_at"`
 }
@@ -184,6 +359,7 @@ func (s *WalletService) GetTransactions(ctx context.Context, userID int64, page,
 			TypeDisplay: getTransactionTypeDisplay(tx.Type),
 			Amount:      tx.Amount,
 			Balance:     tx.BalanceAfter,
+			Currency:    tx.Currency,
 			Remark:      getTransactionRemark(tx),
 			CreatedAt:   tx.CreatedAt,
 		}
@@ -304,6 +480,34 @@ func getTransactionTypeDisplay(txType model.TransactionType) string {
 		return "冻结"
 	case model.TxUnfreeze:
 		return "解冻"
+	case model.TxTransferIn:
+		return "转账转入"
+	case model.TxTransferOut:
+		return "转账转出"
+	case model.TxBonusGrant:
+		return "奖励发放"
+	case model.TxBonusBet:
+		return "奖励下注"
+	case model.TxBonusWin:
+		return "奖励赢取"
+	case model.TxBonusRefund:
+		return "奖励退款"
+	case model.TxBonusConvert:
+		return "奖励转入余额"
+	case model.TxRebate:
+		return "返水"
+	case model.TxReferralReward:
+		return "推荐奖励"
+	case model.TxCurrencySwitch:
+		return "切换币种"
+	case model.TxTournamentBuyIn:
+		return "锦标赛报名"
+	case model.TxTournamentRefund:
+		return "锦标赛退款"
+	case model.TxTournamentPrize:
+		return "锦标赛奖金"
+	case model.TxJackpotWin:
+		return "累进奖池"
 	default:
 		return string(txType)
 	}
@@ -316,3 +520,11 @@ func getTransactionRemark(tx *model.BalanceTransaction) string {
 	}
 	return ""
 }
+
+// walletBalanceField 钱包流水的 balance_field：活跃币种记入 balance，非活跃币种记入对应钱包
+func walletBalanceField(active bool, currency string) string {
+	if active {
+		return "balance"
+	}
+	return model.WalletBalanceField(currency)
+}
@@ -0,0 +1,55 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/fiveseconds/server/internal/model"
+	"github.com/shopspring/decimal"
+)
+
+// TestCurrencySwitchRecords 测试切换币种的流水：原币种余额转入钱包、目标币种钱包转入活跃余额，每组流水净额为 0
+func TestCurrencySwitchRecords(t *testing.T) {
+	type record struct {
+		field, currency       string
+		amount, before, after string
+	}
+	tests := []struct {
+		name  string
+		from  string // CNY 活跃余额
+		taken string // 从 USD 钱包取出的余额
+		want  []record
+	}{
+		{"both sides", "120.5", "30", []record{
+			{"balance", "CNY", "-120.5", "120.5", "0"},
+			{"wallet_cny", "CNY", "120.5", "0", "120.5"},
+			{"wallet_usd", "USD", "-30", "30", "0"},
+			{"balance", "USD", "30", "0", "30"},
+		}},
+		{"empty active balance", "0", "30", []record{
+			{"wallet_usd", "USD", "-30", "30", "0"},
+			{"balance", "USD", "30", "0", "30"},
+		}},
+		{"empty target wallet", "0.01", "0", []record{
+			{"balance", "CNY", "-0.01", "0.01", "0"},
+			{"wallet_cny", "CNY", "0.01", "0", "0.01"},
+		}},
+		{"nothing to move", "0", "0", nil},
+	}
+	for _, tt := range tests {
+		from := &model.CurrencyWallet{Currency: "CNY", Balance: decimal.RequireFromString(tt.from), Active: true}
+		records := currencySwitchRecords(7, from, "USD", decimal.RequireFromString(tt.taken))
+		if len(records) != len(tt.want) {
+			t.Errorf("%s: Expected %d records, got %d", tt.name, len(tt.want), len(records))
+			continue
+		}
+		for i, r := range records {
+			got := record{r.BalanceField, r.Currency, r.Amount.String(), r.BalanceBefore.String(), r.BalanceAfter.String()}
+			if got != tt.want[i] {
+				t.Errorf("%s: Expected record %d to be %+v, got %+v", tt.name, i, tt.want[i], got)
+			}
+			if r.UserID != 7 || r.Type != model.TxCurrencySwitch || r.Remark == nil || *r.Remark != "切换币种 CNY → USD" {
+				t.Errorf("%s: Unexpected record %d metadata: user %d, type %s, remark %v", tt.name, i, r.UserID, r.Type, r.Remark)
+			}
+		}
+	}
+}
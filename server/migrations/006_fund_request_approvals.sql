@@ -0,0 +1,24 @@
+-- 资金申请多级审批（Maker-Checker）
+-- 1. 扩展 fund_requests.status 长度以容纳 pending_second_approval 等中间状态
+-- 2. 新增审批记录表，记录每一个审批步骤
+
+ALTER TABLE fund_requests ALTER COLUMN status TYPE VARCHAR(30);
+
+-- ========================================
+-- 1. 资金申请审批记录表
+-- ========================================
+CREATE TABLE IF NOT EXISTS fund_request_approvals (
+    id              BIGSERIAL PRIMARY KEY,
+    request_id      BIGINT NOT NULL REFERENCES fund_requests(id) ON DELETE CASCADE,
+    approver_id     BIGINT NOT NULL REFERENCES users(id),
+    approver_role   VARCHAR(20) NOT NULL,
+    action          VARCHAR(20) NOT NULL,  -- approve/reject
+    status_before   VARCHAR(30) NOT NULL,
+    status_after    VARCHAR(30) NOT NULL,
+    amount          DECIMAL(18,2) NOT NULL,
+    remark          VARCHAR(500),
+    created_at      TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_fund_approval_request ON fund_request_approvals(request_id);
+CREATE INDEX IF NOT EXISTS idx_fund_approval_approver_time ON fund_request_approvals(approver_id, created_at);
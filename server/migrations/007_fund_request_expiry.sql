@@ -0,0 +1,7 @@
+-- 资金申请超时过期与提醒
+-- 待审批申请超过有效期自动标记为 expired，并定期提醒负责的房主
+
+ALTER TABLE fund_requests ADD COLUMN IF NOT EXISTS last_reminded_at TIMESTAMP;
+ALTER TABLE fund_requests ADD COLUMN IF NOT EXISTS reminder_count INT NOT NULL DEFAULT 0;
+
+CREATE INDEX IF NOT EXISTS idx_fund_status_created ON fund_requests(status, created_at);
@@ -0,0 +1,37 @@
+-- 玩家间转账（同一房主名下）
+-- 1. 房主转账设置：是否开启、单笔限额、每日限额、是否需要房主审批
+-- 2. 转账记录表
+
+-- ========================================
+-- 1. 房主转账设置表
+-- ========================================
+CREATE TABLE IF NOT EXISTS owner_transfer_settings (
+    owner_id            BIGINT PRIMARY KEY REFERENCES users(id),
+    enabled             BOOLEAN NOT NULL DEFAULT FALSE,
+    per_transfer_limit  DECIMAL(18,2) NOT NULL DEFAULT 0,  -- 单笔限额，0 表示不限
+    daily_limit         DECIMAL(18,2) NOT NULL DEFAULT 0,  -- 每名玩家每日转出限额，0 表示不限
+    require_approval    BOOLEAN NOT NULL DEFAULT FALSE,
+    updated_at          TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- ========================================
+-- 2. 玩家转账记录表
+-- ========================================
+CREATE TABLE IF NOT EXISTS player_transfers (
+    id              BIGSERIAL PRIMARY KEY,
+    owner_id        BIGINT NOT NULL REFERENCES users(id),
+    from_user_id    BIGINT NOT NULL REFERENCES users(id),
+    to_user_id      BIGINT NOT NULL REFERENCES users(id),
+    amount          DECIMAL(18,2) NOT NULL,
+    status          VARCHAR(20) NOT NULL DEFAULT 'pending',  -- pending/completed/rejected
+    remark          VARCHAR(500),
+    operator_id     BIGINT REFERENCES users(id),
+    created_at      TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at      TIMESTAMP NOT NULL DEFAULT NOW(),
+    CONSTRAINT chk_transfer_amount CHECK (amount > 0),
+    CONSTRAINT chk_transfer_users CHECK (from_user_id <> to_user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_transfer_from_time ON player_transfers(from_user_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_transfer_to ON player_transfers(to_user_id);
+CREATE INDEX IF NOT EXISTS idx_transfer_owner_status ON player_transfers(owner_id, status);
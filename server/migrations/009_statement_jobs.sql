@@ -0,0 +1,21 @@
+-- 账单导出任务
+-- 大范围账单（CSV/PDF）异步生成，完成后可下载
+
+-- ========================================
+-- 1. 账单导出任务表
+-- ========================================
+CREATE TABLE IF NOT EXISTS statement_jobs (
+    id              BIGSERIAL PRIMARY KEY,
+    user_id         BIGINT NOT NULL REFERENCES users(id),
+    format          VARCHAR(10) NOT NULL,                    -- csv/pdf
+    period_start    TIMESTAMP NOT NULL,
+    period_end      TIMESTAMP NOT NULL,
+    status          VARCHAR(20) NOT NULL DEFAULT 'pending',  -- pending/running/completed/failed
+    file_path       VARCHAR(500),
+    row_count       INT NOT NULL DEFAULT 0,
+    error_message   VARCHAR(500),
+    created_at      TIMESTAMP NOT NULL DEFAULT NOW(),
+    completed_at    TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_statement_jobs_user ON statement_jobs(user_id, created_at DESC);
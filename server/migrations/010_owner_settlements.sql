@@ -0,0 +1,62 @@
+-- 房主佣金结算周期
+-- 1. 结算单：按周期（daily/weekly）快照房主佣金与平台抽成，生成后不可修改
+-- 2. 结算明细：按房间拆分的回合数、流水、佣金与平台抽成
+-- 3. 不可变约束：禁止 UPDATE/DELETE
+
+-- ========================================
+-- 1. 结算单表
+-- ========================================
+CREATE TABLE IF NOT EXISTS owner_settlements (
+    id                  BIGSERIAL PRIMARY KEY,
+    owner_id            BIGINT NOT NULL REFERENCES users(id),
+    period_type         VARCHAR(10) NOT NULL,                  -- daily/weekly
+    period_start        TIMESTAMP NOT NULL,
+    period_end          TIMESTAMP NOT NULL,                    -- 不含
+    round_count         INT NOT NULL DEFAULT 0,
+    pool_amount         DECIMAL(18,2) NOT NULL DEFAULT 0,      -- 周期内奖池总流水
+    owner_earning       DECIMAL(18,2) NOT NULL DEFAULT 0,      -- 房主佣金合计
+    platform_earning    DECIMAL(18,2) NOT NULL DEFAULT 0,      -- 平台抽成合计（不含残值）
+    transferred_amount  DECIMAL(18,2) NOT NULL DEFAULT 0,      -- 自动转入可用余额的金额
+    created_at          TIMESTAMP NOT NULL DEFAULT NOW(),
+
+    UNIQUE(owner_id, period_type, period_start)
+);
+
+CREATE INDEX IF NOT EXISTS idx_owner_settlements_owner ON owner_settlements(owner_id, period_start DESC);
+
+-- ========================================
+-- 2. 结算明细表（按房间）
+-- ========================================
+CREATE TABLE IF NOT EXISTS owner_settlement_items (
+    id                  BIGSERIAL PRIMARY KEY,
+    settlement_id       BIGINT NOT NULL REFERENCES owner_settlements(id),
+    room_id             BIGINT NOT NULL REFERENCES rooms(id),
+    round_count         INT NOT NULL DEFAULT 0,
+    pool_amount         DECIMAL(18,2) NOT NULL DEFAULT 0,
+    owner_earning       DECIMAL(18,2) NOT NULL DEFAULT 0,
+    platform_earning    DECIMAL(18,2) NOT NULL DEFAULT 0,
+
+    UNIQUE(settlement_id, room_id)
+);
+
+-- ========================================
+-- 3. 不可变约束
+-- ========================================
+CREATE OR REPLACE FUNCTION prevent_settlement_mutation()
+RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'settlement records are immutable';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS owner_settlements_immutable ON owner_settlements;
+CREATE TRIGGER owner_settlements_immutable
+    BEFORE UPDATE OR DELETE ON owner_settlements
+    FOR EACH ROW
+    EXECUTE FUNCTION prevent_settlement_mutation();
+
+DROP TRIGGER IF EXISTS owner_settlement_items_immutable ON owner_settlement_items;
+CREATE TRIGGER owner_settlement_items_immutable
+    BEFORE UPDATE OR DELETE ON owner_settlement_items
+    FOR EACH ROW
+    EXECUTE FUNCTION prevent_settlement_mutation();
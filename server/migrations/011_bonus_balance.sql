@@ -0,0 +1,39 @@
+-- 奖励余额与流水要求
+-- 1. users.bonus_balance：促销奖励余额，与真实余额分开记账
+-- 2. 奖励发放记录：每笔奖励的流水要求与完成进度
+--
+-- 奖励余额不属于房主净充值资金，资金守恒检查中单独统计"奖励转真实"的净额
+
+-- ========================================
+-- 1. 用户奖励余额
+-- ========================================
+ALTER TABLE users ADD COLUMN IF NOT EXISTS bonus_balance DECIMAL(18,2) NOT NULL DEFAULT 0;
+
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'chk_bonus_balance') THEN
+        ALTER TABLE users ADD CONSTRAINT chk_bonus_balance CHECK (bonus_balance >= 0);
+    END IF;
+END $$;
+
+-- ========================================
+-- 2. 奖励发放记录表
+-- ========================================
+CREATE TABLE IF NOT EXISTS bonus_grants (
+    id                  BIGSERIAL PRIMARY KEY,
+    user_id             BIGINT NOT NULL REFERENCES users(id),
+    owner_id            BIGINT REFERENCES users(id),           -- 玩家所属房主
+    granted_by          BIGINT NOT NULL REFERENCES users(id),  -- 发放人（房主或管理员）
+    amount              DECIMAL(18,2) NOT NULL,
+    wagering_multiplier DECIMAL(8,2) NOT NULL,                 -- 流水倍数
+    wagering_required   DECIMAL(18,2) NOT NULL,                -- 需完成流水 = amount * multiplier
+    wagered             DECIMAL(18,2) NOT NULL DEFAULT 0,      -- 已完成流水
+    status              VARCHAR(20) NOT NULL DEFAULT 'active', -- active/completed/forfeited
+    remark              VARCHAR(500),
+    created_at          TIMESTAMP NOT NULL DEFAULT NOW(),
+    completed_at        TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_bonus_grants_user ON bonus_grants(user_id, status, created_at);
+CREATE INDEX IF NOT EXISTS idx_bonus_grants_owner ON bonus_grants(owner_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_balance_tx_round_field ON balance_transactions(round_id, balance_field);
@@ -0,0 +1,53 @@
+-- 玩家返水（按流水或净输额返还）
+-- 1. 房主返水阶梯规则：按周期内流水或净输额选择档位与返水比例
+-- 2. 返水发放记录：从房主佣金余额支付给玩家，同一房主的发放周期不可重叠
+-- 3. 返水发放明细：每个玩家的流水、净输额、档位比例与返水金额
+
+-- ========================================
+-- 1. 返水阶梯规则表
+-- ========================================
+CREATE TABLE IF NOT EXISTS owner_rebate_tiers (
+    id          BIGSERIAL PRIMARY KEY,
+    owner_id    BIGINT NOT NULL REFERENCES users(id),
+    basis       VARCHAR(20) NOT NULL,              -- volume: 按流水 / net_loss: 按净输额
+    min_amount  DECIMAL(18,2) NOT NULL DEFAULT 0,  -- 档位门槛（流水或净输额 >= 门槛）
+    rate        DECIMAL(5,4) NOT NULL,             -- 返水比例，如 0.0050 表示 0.5%
+    created_at  TIMESTAMP NOT NULL DEFAULT NOW(),
+
+    UNIQUE(owner_id, min_amount),
+    CONSTRAINT chk_rebate_rate CHECK (rate >= 0 AND rate <= 1)
+);
+
+-- ========================================
+-- 2. 返水发放记录表
+-- ========================================
+CREATE TABLE IF NOT EXISTS rebate_payouts (
+    id            BIGSERIAL PRIMARY KEY,
+    owner_id      BIGINT NOT NULL REFERENCES users(id),
+    basis         VARCHAR(20) NOT NULL,
+    period_start  TIMESTAMP NOT NULL,
+    period_end    TIMESTAMP NOT NULL,                -- 不含
+    player_count  INT NOT NULL DEFAULT 0,
+    total_amount  DECIMAL(18,2) NOT NULL DEFAULT 0,  -- 返水合计（从房主佣金余额扣除）
+    operator_id   BIGINT NOT NULL REFERENCES users(id),
+    created_at    TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_rebate_payouts_owner ON rebate_payouts(owner_id, period_start DESC);
+
+-- ========================================
+-- 3. 返水发放明细表
+-- ========================================
+CREATE TABLE IF NOT EXISTS rebate_payout_items (
+    id               BIGSERIAL PRIMARY KEY,
+    payout_id        BIGINT NOT NULL REFERENCES rebate_payouts(id),
+    user_id          BIGINT NOT NULL REFERENCES users(id),
+    volume           DECIMAL(18,2) NOT NULL DEFAULT 0,  -- 有效流水（下注 - 退款）
+    net_loss         DECIMAL(18,2) NOT NULL DEFAULT 0,  -- 净输额（流水 - 赢得奖金，最低为 0）
+    eligible_amount  DECIMAL(18,2) NOT NULL DEFAULT 0,  -- 按规则计算返水的基数
+    rate             DECIMAL(5,4) NOT NULL,
+    amount           DECIMAL(18,2) NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_rebate_payout_items_payout ON rebate_payout_items(payout_id);
+CREATE INDEX IF NOT EXISTS idx_rebate_payout_items_user ON rebate_payout_items(user_id);
@@ -0,0 +1,49 @@
+-- 玩家推荐奖励
+-- 1. 推荐关系：玩家使用其他玩家的邀请码注册时记录推荐人（仍归属同一房主）
+-- 2. 推荐奖励：按被推荐人产生的佣金分成，或被推荐人完成前 N 局后一次性奖励
+--    奖励由房主佣金余额或平台余额支付；推荐人与被推荐人共用设备时奖励被拦截
+
+-- ========================================
+-- 1. 推荐关系表
+-- ========================================
+CREATE TABLE IF NOT EXISTS referrals (
+    referee_id    BIGINT PRIMARY KEY REFERENCES users(id),  -- 被推荐人
+    referrer_id   BIGINT NOT NULL REFERENCES users(id),     -- 推荐人
+    owner_id      BIGINT NOT NULL REFERENCES users(id),     -- 双方所属房主
+    status        VARCHAR(20) NOT NULL DEFAULT 'active',    -- active: 正常 / blocked: 疑似作弊，停止发放
+    block_reason  VARCHAR(100),
+    created_at    TIMESTAMP NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT chk_referral_not_self CHECK (referee_id <> referrer_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_referrals_referrer ON referrals(referrer_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_referrals_owner ON referrals(owner_id);
+
+-- ========================================
+-- 2. 推荐奖励记录表
+-- ========================================
+CREATE TABLE IF NOT EXISTS referral_rewards (
+    id              BIGSERIAL PRIMARY KEY,
+    referrer_id     BIGINT NOT NULL REFERENCES users(id),
+    referee_id      BIGINT NOT NULL REFERENCES users(id),
+    owner_id        BIGINT NOT NULL REFERENCES users(id),
+    reward_type     VARCHAR(20) NOT NULL,                 -- commission_share: 佣金分成 / first_rounds: 首 N 局奖励
+    funding_source  VARCHAR(20) NOT NULL,                 -- owner: 房主佣金余额 / platform: 平台余额
+    period_start    TIMESTAMP,                            -- 佣金分成统计周期（首 N 局奖励为空）
+    period_end      TIMESTAMP,                            -- 不含
+    base_amount     DECIMAL(18,2) NOT NULL DEFAULT 0,     -- 佣金分成基数（被推荐人分摊的佣金）
+    rate            DECIMAL(5,4) NOT NULL DEFAULT 0,
+    amount          DECIMAL(18,2) NOT NULL,
+    status          VARCHAR(20) NOT NULL,                 -- paid: 已发放 / blocked: 被风控拦截
+    block_reason    VARCHAR(100),
+    created_at      TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_referral_rewards_referrer ON referral_rewards(referrer_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_referral_rewards_owner ON referral_rewards(owner_id, created_at DESC);
+-- 同一被推荐人每个周期只分成一次，首 N 局奖励只发一次
+CREATE UNIQUE INDEX IF NOT EXISTS uq_referral_rewards_share
+    ON referral_rewards(referee_id, period_start) WHERE reward_type = 'commission_share';
+CREATE UNIQUE INDEX IF NOT EXISTS uq_referral_rewards_first_rounds
+    ON referral_rewards(referee_id) WHERE reward_type = 'first_rounds';
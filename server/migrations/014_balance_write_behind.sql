@@ -0,0 +1,41 @@
+-- 余额写回缓存（write-behind）
+-- 1. 已落库的 outbox 操作：落库时在同一事务内登记操作ID，保证每个操作只落库一次
+--    （Redis 写入结果未知时的数据库兜底写入也登记同一操作ID）
+-- 2. 余额变更通知：非 flusher 写入的 users.balance 变更通过 NOTIFY 同步到 Redis
+--    flusher 在事务内设置 app.balance_writer = 'outbox'，其写入不触发通知
+--    每次余额变更都递增 balance_version，Redis 据此跳过加载快照中已包含的变更
+
+-- ========================================
+-- 1. 已落库 outbox 条目表
+-- ========================================
+CREATE TABLE IF NOT EXISTS balance_outbox_applied (
+    entry_id    VARCHAR(64) PRIMARY KEY,  -- outbox 操作ID（如 round:123:win）
+    applied_at  TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_balance_outbox_applied_at ON balance_outbox_applied(applied_at);
+
+-- ========================================
+-- 2. 余额变更通知触发器
+-- ========================================
+CREATE OR REPLACE FUNCTION notify_balance_changed() RETURNS TRIGGER AS $$
+BEGIN
+    IF NEW.balance IS DISTINCT FROM OLD.balance AND NEW.balance_version = OLD.balance_version THEN
+        NEW.balance_version := OLD.balance_version + 1;
+    END IF;
+    IF NEW.balance IS DISTINCT FROM OLD.balance
+        AND current_setting('app.balance_writer', true) IS DISTINCT FROM 'outbox' THEN
+        PERFORM pg_notify('balance_changed', json_build_object(
+            'user_id', NEW.id,
+            'delta', NEW.balance - OLD.balance,
+            'version', NEW.balance_version
+        )::text);
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_users_balance_changed ON users;
+CREATE TRIGGER trg_users_balance_changed
+    BEFORE UPDATE OF balance ON users
+    FOR EACH ROW EXECUTE FUNCTION notify_balance_changed();
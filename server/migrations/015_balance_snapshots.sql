@@ -0,0 +1,25 @@
+-- 日终余额快照
+-- 每个自然日（按配置时区）结束时记录所有账户的余额、冻结、佣金与保证金
+-- 用于历史余额查询与每日房主对账，避免扫描 balance_transactions
+
+-- ========================================
+-- 1. 日终余额快照表
+-- ========================================
+CREATE TABLE IF NOT EXISTS balance_snapshots (
+    user_id             BIGINT NOT NULL REFERENCES users(id),
+    snapshot_date       DATE NOT NULL,                     -- 快照对应的自然日
+    boundary_at         TIMESTAMP NOT NULL,                -- 日终时刻（次日 00:00，按配置时区）
+    role                VARCHAR(20) NOT NULL,
+    owner_id            BIGINT REFERENCES users(id),       -- 快照时所属房主（invited_by）
+    balance             DECIMAL(18,2) NOT NULL DEFAULT 0,
+    frozen_balance      DECIMAL(18,2) NOT NULL DEFAULT 0,
+    bonus_balance       DECIMAL(18,2) NOT NULL DEFAULT 0,
+    commission_balance  DECIMAL(18,2) NOT NULL DEFAULT 0,  -- 房主佣金余额（owner_room_balance）
+    margin_balance      DECIMAL(18,2) NOT NULL DEFAULT 0,  -- 房主保证金（owner_margin_balance）
+    captured_at         TIMESTAMP NOT NULL DEFAULT NOW(),  -- 实际采集时间
+
+    PRIMARY KEY (user_id, snapshot_date)
+);
+
+CREATE INDEX IF NOT EXISTS idx_balance_snapshots_date_owner ON balance_snapshots(snapshot_date, owner_id);
+CREATE INDEX IF NOT EXISTS idx_balance_snapshots_boundary ON balance_snapshots(boundary_at);
@@ -0,0 +1,81 @@
+-- 多币种支持
+-- 1. 账户币种：users.currency 为账户当前活跃钱包的币种，balance/frozen_balance/bonus_balance 均以该币种计
+--    房主的币种即经营币种（可用余额、佣金、保证金及名下房间均以该币种计），玩家注册时继承所属房主的币种
+-- 2. 玩家多币种钱包：非活跃币种的余额存放在 user_wallets，切换活跃币种时与 users.balance 互换
+-- 3. 房间、交易流水、资金申请、平台账户、对账历史与日终快照记录币种，资金守恒按币种分别对账
+
+-- ========================================
+-- 1. 账户币种
+-- ========================================
+ALTER TABLE users ADD COLUMN IF NOT EXISTS currency VARCHAR(3) NOT NULL DEFAULT 'CNY';
+
+-- ========================================
+-- 2. 玩家非活跃币种钱包
+-- ========================================
+CREATE TABLE IF NOT EXISTS user_wallets (
+    user_id     BIGINT NOT NULL REFERENCES users(id),
+    currency    VARCHAR(3) NOT NULL,
+    balance     DECIMAL(18,2) NOT NULL DEFAULT 0,
+    updated_at  TIMESTAMP NOT NULL DEFAULT NOW(),
+
+    PRIMARY KEY (user_id, currency),
+    CONSTRAINT chk_user_wallets_balance CHECK (balance >= 0)
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_wallets_currency ON user_wallets(currency);
+
+-- ========================================
+-- 3. 房间币种（下注金额以房间币种计，与房主币种一致）
+-- ========================================
+ALTER TABLE rooms ADD COLUMN IF NOT EXISTS currency VARCHAR(3) NOT NULL DEFAULT 'CNY';
+
+-- ========================================
+-- 4. 交易流水币种（未显式指定时按账户当前币种填写）
+-- ========================================
+ALTER TABLE balance_transactions ADD COLUMN IF NOT EXISTS currency VARCHAR(3) NOT NULL DEFAULT 'CNY';
+ALTER TABLE balance_transactions ALTER COLUMN currency DROP DEFAULT;
+
+CREATE OR REPLACE FUNCTION fill_transaction_currency() RETURNS TRIGGER AS $$
+BEGIN
+    IF NEW.currency IS NULL THEN
+        SELECT currency INTO NEW.currency FROM users WHERE id = NEW.user_id;
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_balance_transactions_currency ON balance_transactions;
+CREATE TRIGGER trg_balance_transactions_currency
+    BEFORE INSERT ON balance_transactions
+    FOR EACH ROW EXECUTE FUNCTION fill_transaction_currency();
+
+CREATE INDEX IF NOT EXISTS idx_balance_transactions_currency ON balance_transactions(currency, tx_type);
+
+-- ========================================
+-- 5. 资金申请币种
+-- ========================================
+ALTER TABLE fund_requests ADD COLUMN IF NOT EXISTS currency VARCHAR(3) NOT NULL DEFAULT 'CNY';
+
+-- ========================================
+-- 6. 平台账户按币种记账（每个币种一行，首次入账时创建）
+-- ========================================
+ALTER TABLE platform_account ADD COLUMN IF NOT EXISTS currency VARCHAR(3) NOT NULL DEFAULT 'CNY';
+
+CREATE UNIQUE INDEX IF NOT EXISTS uq_platform_account_currency ON platform_account(currency);
+
+-- 初始平台账户以显式 id = 1 插入，同步序列以便新币种自动分配 id
+SELECT setval(pg_get_serial_sequence('platform_account', 'id'), GREATEST((SELECT MAX(id) FROM platform_account), 1));
+
+-- ========================================
+-- 7. 对账历史币种
+-- ========================================
+ALTER TABLE fund_conservation_history ADD COLUMN IF NOT EXISTS currency VARCHAR(3) NOT NULL DEFAULT 'CNY';
+
+CREATE INDEX IF NOT EXISTS idx_fch_currency_created ON fund_conservation_history(currency, created_at);
+
+-- ========================================
+-- 8. 日终快照按币种记录（含玩家非活跃币种钱包）
+-- ========================================
+ALTER TABLE balance_snapshots ADD COLUMN IF NOT EXISTS currency VARCHAR(3) NOT NULL DEFAULT 'CNY';
+ALTER TABLE balance_snapshots DROP CONSTRAINT IF EXISTS balance_snapshots_pkey;
+ALTER TABLE balance_snapshots ADD PRIMARY KEY (user_id, snapshot_date, currency);
@@ -0,0 +1,15 @@
+-- 房主信用额度（玩家资金敞口与保证金挂钩）
+-- 1. 敞口 = 名下玩家在房主经营币种下的余额总额（可用 + 冻结 + 非活跃币种钱包，不含奖励余额）
+-- 2. 额度 = 倍数 K × 保证金，K 默认取配置 credit_limit.margin_multiplier，可按房主单独设置，0 表示不限
+-- 3. 玩家充值使敞口超出额度时拒绝；巡检发现超限时暂停房主全部房间并告警，breached_at 记录超限开始时间
+
+CREATE TABLE IF NOT EXISTS owner_credit_limits (
+    owner_id            BIGINT PRIMARY KEY REFERENCES users(id),
+    margin_multiplier   DECIMAL(10,2),                 -- 单独设置的倍数，NULL 表示使用默认倍数
+    breached_at         TIMESTAMP,                     -- 当前超限开始时间，恢复后清空
+    updated_by          BIGINT REFERENCES users(id),
+    updated_at          TIMESTAMP NOT NULL DEFAULT NOW(),
+    CONSTRAINT chk_credit_limit_multiplier CHECK (margin_multiplier IS NULL OR margin_multiplier >= 0)
+);
+
+CREATE INDEX IF NOT EXISTS idx_owner_credit_limits_breached ON owner_credit_limits(breached_at) WHERE breached_at IS NOT NULL;
@@ -0,0 +1,27 @@
+-- 练习房（游戏币房间）
+-- 1. 房间类型：standard 为真实余额房间，practice 为练习房
+--    练习房沿用相同的阶段引擎与 commit-reveal，但下注与派奖只使用游戏币，
+--    不写 game_rounds / balance_transactions，不产生房主抽成与平台收入，不参与风控与资金守恒检查
+-- 2. 游戏币余额：与真实余额完全隔离，首次进入练习房时按配置初始化，不足时可随时补充
+
+-- ========================================
+-- 1. 房间类型
+-- ========================================
+ALTER TABLE rooms ADD COLUMN IF NOT EXISTS room_type VARCHAR(20) NOT NULL DEFAULT 'standard';
+
+ALTER TABLE rooms DROP CONSTRAINT IF EXISTS chk_rooms_room_type;
+ALTER TABLE rooms ADD CONSTRAINT chk_rooms_room_type CHECK (room_type IN ('standard', 'practice'));
+
+CREATE INDEX IF NOT EXISTS idx_rooms_room_type ON rooms(room_type);
+
+-- ========================================
+-- 2. 游戏币余额
+-- ========================================
+CREATE TABLE IF NOT EXISTS practice_balances (
+    user_id      BIGINT PRIMARY KEY REFERENCES users(id),
+    chips        DECIMAL(18,2) NOT NULL DEFAULT 0,
+    refilled_at  TIMESTAMP,                          -- 最近一次补充时间
+    created_at   TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at   TIMESTAMP NOT NULL DEFAULT NOW(),
+    CONSTRAINT chk_practice_balances_chips CHECK (chips >= 0)
+);
@@ -0,0 +1,84 @@
+-- 锦标赛模式
+-- 1. 锦标赛：房主创建的定期赛事，在固定回合数或时间窗口内跨一个或多个锦标赛房间进行
+--    玩家一次性支付报名费（真实余额，经由正常账本记流水），获得锦标赛筹码
+--    房间内每轮以筹码下注与结算，不写 game_rounds，不产生房主抽成与平台收入
+-- 2. 奖励分配表：按最终名次分配奖池的百分比
+-- 3. 报名记录：玩家在锦标赛内的筹码、最终名次与奖金
+-- 4. 锦标赛房间：rooms 关联所属锦标赛
+--    报名费进入奖池后计入 tournaments.pool_balance，参与资金守恒，直至按名次派奖或取消退款
+
+-- ========================================
+-- 1. 锦标赛
+-- ========================================
+CREATE TABLE IF NOT EXISTS tournaments (
+    id              BIGSERIAL PRIMARY KEY,
+    owner_id        BIGINT NOT NULL REFERENCES users(id),
+    name            VARCHAR(100) NOT NULL,
+    currency        VARCHAR(10) NOT NULL,
+    buy_in          DECIMAL(18,2) NOT NULL,
+    starting_chips  DECIMAL(18,2) NOT NULL,
+    max_rounds      INT NOT NULL DEFAULT 0,             -- 回合上限，0 表示不限（以结束时间为准）
+    rounds_started  INT NOT NULL DEFAULT 0,             -- 已开始（已扣筹码）的回合数
+    rounds_played   INT NOT NULL DEFAULT 0,             -- 已结算的回合数
+    starts_at       TIMESTAMP NOT NULL,
+    ends_at         TIMESTAMP,                          -- 结束时间，为空表示只按回合数结束
+    status          VARCHAR(20) NOT NULL DEFAULT 'scheduled',
+    prize_pool      DECIMAL(18,2) NOT NULL DEFAULT 0,   -- 报名费累计
+    pool_balance    DECIMAL(18,2) NOT NULL DEFAULT 0,   -- 尚未派发或退回的报名费
+    created_at      TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at      TIMESTAMP NOT NULL DEFAULT NOW(),
+    finished_at     TIMESTAMP,
+    CONSTRAINT chk_tournaments_status CHECK (status IN ('scheduled', 'running', 'finished', 'cancelled')),
+    CONSTRAINT chk_tournaments_buy_in CHECK (buy_in > 0),
+    CONSTRAINT chk_tournaments_starting_chips CHECK (starting_chips > 0),
+    CONSTRAINT chk_tournaments_max_rounds CHECK (max_rounds >= 0),
+    CONSTRAINT chk_tournaments_limit CHECK (max_rounds > 0 OR ends_at IS NOT NULL),
+    CONSTRAINT chk_tournaments_pool_balance CHECK (pool_balance >= 0)
+);
+
+CREATE INDEX IF NOT EXISTS idx_tournaments_owner ON tournaments(owner_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_tournaments_status ON tournaments(status, starts_at);
+
+-- ========================================
+-- 2. 奖励分配表
+-- ========================================
+CREATE TABLE IF NOT EXISTS tournament_payouts (
+    tournament_id  BIGINT NOT NULL REFERENCES tournaments(id) ON DELETE CASCADE,
+    rank_no        INT NOT NULL,
+    share          DECIMAL(5,2) NOT NULL,               -- 奖池百分比
+    PRIMARY KEY (tournament_id, rank_no),
+    CONSTRAINT chk_tournament_payouts_share CHECK (share > 0 AND share <= 100)
+);
+
+-- ========================================
+-- 3. 报名记录
+-- ========================================
+CREATE TABLE IF NOT EXISTS tournament_entries (
+    tournament_id  BIGINT NOT NULL REFERENCES tournaments(id),
+    user_id        BIGINT NOT NULL REFERENCES users(id),
+    chips          DECIMAL(18,2) NOT NULL,
+    buy_in         DECIMAL(18,2) NOT NULL,
+    status         VARCHAR(20) NOT NULL DEFAULT 'active',
+    final_rank     INT,
+    prize          DECIMAL(18,2) NOT NULL DEFAULT 0,
+    joined_at      TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at     TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (tournament_id, user_id),
+    CONSTRAINT chk_tournament_entries_status CHECK (status IN ('active', 'refunded', 'finished')),
+    CONSTRAINT chk_tournament_entries_chips CHECK (chips >= 0)
+);
+
+CREATE INDEX IF NOT EXISTS idx_tournament_entries_user ON tournament_entries(user_id);
+
+-- ========================================
+-- 4. 锦标赛房间
+-- ========================================
+ALTER TABLE rooms ADD COLUMN IF NOT EXISTS tournament_id BIGINT REFERENCES tournaments(id);
+
+ALTER TABLE rooms DROP CONSTRAINT IF EXISTS chk_rooms_room_type;
+ALTER TABLE rooms ADD CONSTRAINT chk_rooms_room_type CHECK (room_type IN ('standard', 'practice', 'tournament'));
+
+ALTER TABLE rooms DROP CONSTRAINT IF EXISTS chk_rooms_tournament;
+ALTER TABLE rooms ADD CONSTRAINT chk_rooms_tournament CHECK ((room_type = 'tournament') = (tournament_id IS NOT NULL));
+
+CREATE INDEX IF NOT EXISTS idx_rooms_tournament ON rooms(tournament_id) WHERE tournament_id IS NOT NULL;
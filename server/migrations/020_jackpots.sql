@@ -0,0 +1,59 @@
+-- 累进奖池（Jackpot）
+-- 1. 累进奖池：房主为单个房间或名下同币种全部房间开设，每个结算回合从奖池抽取一定比例，
+--    连同平分奖金后的舍入残值一起注入累进奖池（开启后残值不再计入平台账户）
+--    触发条件由回合公开种子确定性推导：SHA-256(种子 || "jackpot") 前 8 字节（大端）对 trigger_odds 取模为 0，
+--    任何人拿到 reveal_seed 即可复核；触发时奖池余额平分给本回合赢家（按分向下取整，零头留在奖池）
+--    奖池余额参与资金守恒，直至派发给赢家
+-- 2. 中奖记录
+-- 3. 回合记录：本回合注入的金额、使用的触发概率、是否触发与派发金额
+
+-- ========================================
+-- 1. 累进奖池
+-- ========================================
+CREATE TABLE IF NOT EXISTS jackpots (
+    id                 BIGSERIAL PRIMARY KEY,
+    owner_id           BIGINT NOT NULL REFERENCES users(id),
+    room_id            BIGINT REFERENCES rooms(id),         -- 为空表示房主级奖池（房主该币种所有房间共享）
+    currency           VARCHAR(10) NOT NULL,
+    contribution_rate  DECIMAL(5,4) NOT NULL,                -- 每回合从奖池抽取的比例
+    trigger_odds       INT NOT NULL,                         -- 每回合触发概率为 1/trigger_odds
+    balance            DECIMAL(18,2) NOT NULL DEFAULT 0,     -- 当前累进奖池金额
+    total_contributed  DECIMAL(18,2) NOT NULL DEFAULT 0,
+    total_paid         DECIMAL(18,2) NOT NULL DEFAULT 0,
+    hit_count          INT NOT NULL DEFAULT 0,
+    enabled            BOOLEAN NOT NULL DEFAULT TRUE,        -- 停用后不再注入与触发，余额保留至再次启用
+    last_hit_at        TIMESTAMP,
+    created_at         TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at         TIMESTAMP NOT NULL DEFAULT NOW(),
+    CONSTRAINT chk_jackpots_contribution_rate CHECK (contribution_rate >= 0 AND contribution_rate < 1),
+    CONSTRAINT chk_jackpots_trigger_odds CHECK (trigger_odds >= 1),
+    CONSTRAINT chk_jackpots_balance CHECK (balance >= 0)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS uniq_jackpots_room ON jackpots(room_id) WHERE room_id IS NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS uniq_jackpots_owner ON jackpots(owner_id, currency) WHERE room_id IS NULL;
+
+-- ========================================
+-- 2. 中奖记录
+-- ========================================
+CREATE TABLE IF NOT EXISTS jackpot_hits (
+    id                BIGSERIAL PRIMARY KEY,
+    jackpot_id        BIGINT NOT NULL REFERENCES jackpots(id),
+    room_id           BIGINT NOT NULL REFERENCES rooms(id),
+    round_id          BIGINT NOT NULL REFERENCES game_rounds(id),
+    winner_ids        BIGINT[] NOT NULL,
+    prize_per_winner  DECIMAL(18,2) NOT NULL,
+    amount            DECIMAL(18,2) NOT NULL,               -- 派发总额
+    created_at        TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_jackpot_hits_jackpot ON jackpot_hits(jackpot_id, created_at DESC);
+
+-- ========================================
+-- 3. 回合记录
+-- ========================================
+ALTER TABLE game_rounds ADD COLUMN IF NOT EXISTS jackpot_id BIGINT REFERENCES jackpots(id);
+ALTER TABLE game_rounds ADD COLUMN IF NOT EXISTS jackpot_contribution DECIMAL(18,2) NOT NULL DEFAULT 0; -- 含舍入残值
+ALTER TABLE game_rounds ADD COLUMN IF NOT EXISTS jackpot_odds INT;
+ALTER TABLE game_rounds ADD COLUMN IF NOT EXISTS jackpot_hit BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE game_rounds ADD COLUMN IF NOT EXISTS jackpot_payout DECIMAL(18,2) NOT NULL DEFAULT 0;
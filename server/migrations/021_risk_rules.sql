@@ -0,0 +1,78 @@
+-- 可配置风控规则
+-- 1. 风控规则：触发事件（回合结算/登录/资金申请）+ 规则表达式条件 + 严重程度 + 动作（flag/alert）
+--    条件使用受限表达式语言（比较、算术与逻辑运算，无函数调用），引用事件与用户统计变量
+--    规则修改后各实例定期重新加载，无需重启
+-- 2. 规则历史版本：每次创建或修改保存一份快照
+-- 3. 风控标记增加严重程度与来源规则
+-- 4. 预置与原 DefaultRiskConfig 等价的规则
+
+-- ========================================
+-- 1. 风控规则
+-- ========================================
+CREATE TABLE IF NOT EXISTS risk_rules (
+    id           BIGSERIAL PRIMARY KEY,
+    name         VARCHAR(100) NOT NULL UNIQUE,
+    description  VARCHAR(500) NOT NULL DEFAULT '',
+    event        VARCHAR(30) NOT NULL,                      -- round_settled/login/fund_request
+    condition    TEXT NOT NULL,                             -- 规则表达式
+    severity     VARCHAR(20) NOT NULL DEFAULT 'medium',     -- low/medium/high/critical
+    action       VARCHAR(20) NOT NULL DEFAULT 'flag',       -- flag/alert
+    flag_type    VARCHAR(50) NOT NULL DEFAULT 'rule_hit',   -- action 为 flag 时创建的标记类型
+    enabled      BOOLEAN NOT NULL DEFAULT TRUE,
+    version      INT NOT NULL DEFAULT 1,
+    updated_by   BIGINT REFERENCES users(id),
+    created_at   TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at   TIMESTAMP NOT NULL DEFAULT NOW(),
+    CONSTRAINT chk_risk_rules_event CHECK (event IN ('round_settled', 'login', 'fund_request')),
+    CONSTRAINT chk_risk_rules_severity CHECK (severity IN ('low', 'medium', 'high', 'critical')),
+    CONSTRAINT chk_risk_rules_action CHECK (action IN ('flag', 'alert'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_risk_rules_event ON risk_rules(event) WHERE enabled;
+
+-- ========================================
+-- 2. 规则历史版本
+-- ========================================
+CREATE TABLE IF NOT EXISTS risk_rule_versions (
+    id           BIGSERIAL PRIMARY KEY,
+    rule_id      BIGINT NOT NULL REFERENCES risk_rules(id),
+    version      INT NOT NULL,
+    name         VARCHAR(100) NOT NULL,
+    description  VARCHAR(500) NOT NULL DEFAULT '',
+    event        VARCHAR(30) NOT NULL,
+    condition    TEXT NOT NULL,
+    severity     VARCHAR(20) NOT NULL,
+    action       VARCHAR(20) NOT NULL,
+    flag_type    VARCHAR(50) NOT NULL,
+    enabled      BOOLEAN NOT NULL,
+    changed_by   BIGINT REFERENCES users(id),
+    created_at   TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE(rule_id, version)
+);
+
+-- ========================================
+-- 3. 风控标记
+-- ========================================
+ALTER TABLE risk_flags ADD COLUMN IF NOT EXISTS severity VARCHAR(20) NOT NULL DEFAULT 'medium';
+ALTER TABLE risk_flags ADD COLUMN IF NOT EXISTS rule_id BIGINT REFERENCES risk_rules(id);
+
+CREATE INDEX IF NOT EXISTS idx_risk_flags_rule_pending ON risk_flags(user_id, rule_id) WHERE status = 'pending';
+
+-- ========================================
+-- 4. 预置规则（与原 DefaultRiskConfig 阈值一致）
+-- ========================================
+INSERT INTO risk_rules (name, description, event, condition, severity, action, flag_type) VALUES
+    ('连续获胜', '连续获胜超过 10 回合', 'round_settled',
+     'event.is_winner && user.consecutive_wins > 10', 'medium', 'flag', 'consecutive_wins'),
+    ('高胜率', '最近 50 回合胜率超过 80%', 'round_settled',
+     'event.is_winner && user.recent_rounds >= 50 && user.recent_win_rate > 0.8', 'medium', 'flag', 'high_win_rate'),
+    ('大额资金申请', '单笔充值或提现申请不低于 10000', 'fund_request',
+     'event.amount >= 10000', 'high', 'flag', 'large_transaction'),
+    ('日交易量超限', '当日流水超过 100000', 'fund_request',
+     'user.daily_volume > 100000', 'medium', 'alert', 'rule_hit')
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO risk_rule_versions (rule_id, version, name, description, event, condition, severity, action, flag_type, enabled)
+SELECT r.id, r.version, r.name, r.description, r.event, r.condition, r.severity, r.action, r.flag_type, r.enabled
+FROM risk_rules r
+WHERE NOT EXISTS (SELECT 1 FROM risk_rule_versions v WHERE v.rule_id = r.id);
@@ -0,0 +1,28 @@
+-- 串通检测
+-- 1. 用户登录 IP 记录：登录成功时按 (用户, IP) 累计，供共用 IP 关联分析
+-- 2. 回合参与者数组索引：增量分析按本回合参与者查找共同参与的历史回合
+-- 3. 转账接收方时间索引：资金归集（多账户转入同一账户）分析
+
+-- ========================================
+-- 1. 用户登录 IP
+-- ========================================
+CREATE TABLE IF NOT EXISTS user_login_ips (
+    user_id        BIGINT NOT NULL REFERENCES users(id),
+    ip             VARCHAR(45) NOT NULL,
+    login_count    INT NOT NULL DEFAULT 1,
+    first_seen_at  TIMESTAMP NOT NULL DEFAULT NOW(),
+    last_seen_at   TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, ip)
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_login_ips_ip ON user_login_ips(ip, last_seen_at);
+
+-- ========================================
+-- 2. 回合参与者索引
+-- ========================================
+CREATE INDEX IF NOT EXISTS idx_rounds_participants ON game_rounds USING GIN (participant_ids);
+
+-- ========================================
+-- 3. 转账接收方索引
+-- ========================================
+CREATE INDEX IF NOT EXISTS idx_transfer_to_time ON player_transfers(to_user_id, created_at);
@@ -0,0 +1,27 @@
+-- 登录历史
+-- 1. 登录事件：时间、IP（经可信代理解析的客户端地址）、User-Agent、设备指纹、成功/失败及原因、地区标签
+--    device_key 为设备标识：有设备指纹时取指纹，否则取 User-Agent 摘要；new_device 表示该用户首次以此设备登录成功
+-- 2. 登录事件风控规则支持试运行（按登录历史重放）
+
+-- ========================================
+-- 1. 登录事件
+-- ========================================
+CREATE TABLE IF NOT EXISTS login_events (
+    id                  BIGSERIAL PRIMARY KEY,
+    user_id             BIGINT REFERENCES users(id),           -- 用户名不存在时为空
+    username            VARCHAR(50) NOT NULL,
+    success             BOOLEAN NOT NULL,
+    failure_reason      VARCHAR(50) NOT NULL DEFAULT '',       -- user_not_found/invalid_password
+    ip                  VARCHAR(45) NOT NULL DEFAULT '',
+    user_agent          VARCHAR(500) NOT NULL DEFAULT '',
+    device_fingerprint  VARCHAR(255) NOT NULL DEFAULT '',
+    device_key          VARCHAR(255) NOT NULL DEFAULT '',
+    new_device          BOOLEAN NOT NULL DEFAULT FALSE,
+    geo_label           VARCHAR(100) NOT NULL DEFAULT '',
+    created_at          TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_login_events_user ON login_events(user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_login_events_device ON login_events(user_id, device_key) WHERE success;
+CREATE INDEX IF NOT EXISTS idx_login_events_fingerprint ON login_events(device_fingerprint) WHERE success AND device_fingerprint <> '';
+CREATE INDEX IF NOT EXISTS idx_login_events_created ON login_events(created_at);
@@ -0,0 +1,46 @@
+-- 账户限制
+-- 1. 账户限制：禁止下注、禁止提现、禁言、全面封停，均带到期时间
+--    由风控规则按严重程度自动施加，或在风控标记确认时由管理员施加
+-- 2. 限制审计：施加、解除、到期均留痕
+
+-- ========================================
+-- 1. 账户限制
+-- ========================================
+CREATE TABLE IF NOT EXISTS account_restrictions (
+    id                BIGSERIAL PRIMARY KEY,
+    user_id           BIGINT NOT NULL REFERENCES users(id),
+    restriction_type  VARCHAR(20) NOT NULL,                      -- no_betting/no_withdrawal/chat_muted/suspended
+    status            VARCHAR(20) NOT NULL DEFAULT 'active',     -- active/revoked/expired
+    reason_code       VARCHAR(50) NOT NULL,                      -- 推送给玩家的原因代码
+    source            VARCHAR(20) NOT NULL,                      -- auto/manual
+    flag_id           BIGINT REFERENCES risk_flags(id),
+    rule_id           BIGINT REFERENCES risk_rules(id),
+    remark            TEXT NOT NULL DEFAULT '',
+    created_by        BIGINT REFERENCES users(id),               -- 自动施加时为空
+    expires_at        TIMESTAMP NOT NULL,
+    ended_at          TIMESTAMP,                                 -- 解除或到期处理的时间
+    ended_by          BIGINT REFERENCES users(id),
+    created_at        TIMESTAMP NOT NULL DEFAULT NOW(),
+    CONSTRAINT chk_restriction_type CHECK (restriction_type IN ('no_betting', 'no_withdrawal', 'chat_muted', 'suspended')),
+    CONSTRAINT chk_restriction_status CHECK (status IN ('active', 'revoked', 'expired'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_account_restrictions_active ON account_restrictions(user_id, expires_at) WHERE status = 'active';
+CREATE INDEX IF NOT EXISTS idx_account_restrictions_user ON account_restrictions(user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_account_restrictions_expiry ON account_restrictions(expires_at) WHERE status = 'active';
+
+-- ========================================
+-- 2. 限制审计
+-- ========================================
+CREATE TABLE IF NOT EXISTS account_restriction_audit (
+    id              BIGSERIAL PRIMARY KEY,
+    restriction_id  BIGINT NOT NULL REFERENCES account_restrictions(id),
+    user_id         BIGINT NOT NULL REFERENCES users(id),
+    action          VARCHAR(20) NOT NULL,                        -- applied/revoked/expired
+    operator_id     BIGINT REFERENCES users(id),                 -- 自动操作时为空
+    remark          TEXT NOT NULL DEFAULT '',
+    created_at      TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_restriction_audit_user ON account_restriction_audit(user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_restriction_audit_restriction ON account_restriction_audit(restriction_id);
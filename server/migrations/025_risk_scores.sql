@@ -0,0 +1,34 @@
+-- 用户风险评分
+-- 1. 当前评分：综合风控标记（类型、严重程度、审核结论，按半衰期指数衰减）与行为信号
+--    （胜率偏离、充值/下注比、账户年龄），用于标记列表与用户列表排序
+-- 2. 评分历史：分值或等级变化时记录一条
+
+-- ========================================
+-- 1. 当前评分
+-- ========================================
+CREATE TABLE IF NOT EXISTS user_risk_scores (
+    user_id         BIGINT PRIMARY KEY REFERENCES users(id),
+    score           NUMERIC(6,2) NOT NULL DEFAULT 0,                 -- 0~100
+    level           VARCHAR(20) NOT NULL DEFAULT 'low',              -- low/medium/high/critical
+    components      JSONB NOT NULL DEFAULT '{}',                     -- 各分项得分
+    alerted_level   VARCHAR(20) NOT NULL DEFAULT '',                 -- 已告警的最高等级，回落到告警等级以下时清空
+    computed_at     TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_risk_scores_score ON user_risk_scores(score DESC);
+
+-- ========================================
+-- 2. 评分历史
+-- ========================================
+CREATE TABLE IF NOT EXISTS risk_score_history (
+    id              BIGSERIAL PRIMARY KEY,
+    user_id         BIGINT NOT NULL REFERENCES users(id),
+    score           NUMERIC(6,2) NOT NULL,
+    level           VARCHAR(20) NOT NULL,
+    components      JSONB NOT NULL DEFAULT '{}',
+    trigger_event   VARCHAR(30) NOT NULL,                            -- flag_created/flag_reviewed/round_settled/fund_request/decay
+    created_at      TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_risk_score_history_user ON risk_score_history(user_id, created_at DESC);
+
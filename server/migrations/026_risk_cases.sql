@@ -0,0 +1,86 @@
+-- 风控案件
+-- 1. 风控标记审核备注：审核时填写的备注此前被丢弃，现随审核结论保存
+-- 2. 案件：将同一用户或同一关联簇的风控标记归为一个案件，指派处理人，按 open -> investigating -> resolved 流转
+-- 3. 案件备注：审核人员的讨论，可回复某条备注形成线程
+-- 4. 案件证据：关联的回合、资金流水、登录事件，添加时保存当时的记录快照
+-- 5. 案件审计：创建、追加标记、指派、状态变更、备注、证据、案件内标记的审核均留痕
+
+-- ========================================
+-- 1. 风控标记审核备注
+-- ========================================
+ALTER TABLE risk_flags ADD COLUMN IF NOT EXISTS review_remark TEXT NOT NULL DEFAULT '';
+
+-- ========================================
+-- 2. 案件
+-- ========================================
+CREATE TABLE IF NOT EXISTS risk_cases (
+    id              BIGSERIAL PRIMARY KEY,
+    title           VARCHAR(200) NOT NULL,
+    user_ids        BIGINT[] NOT NULL DEFAULT '{}',              -- 案件涉及的用户（案件内标记的用户）
+    status          VARCHAR(20) NOT NULL DEFAULT 'open',          -- open/investigating/resolved
+    assignee_id     BIGINT REFERENCES users(id),
+    resolution      TEXT NOT NULL DEFAULT '',                     -- 结案结论，重新打开时清空
+    created_by      BIGINT NOT NULL REFERENCES users(id),
+    created_at      TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at      TIMESTAMP NOT NULL DEFAULT NOW(),
+    resolved_at     TIMESTAMP,
+    CONSTRAINT chk_risk_case_status CHECK (status IN ('open', 'investigating', 'resolved'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_risk_cases_status ON risk_cases(status, updated_at DESC);
+CREATE INDEX IF NOT EXISTS idx_risk_cases_assignee ON risk_cases(assignee_id, status);
+CREATE INDEX IF NOT EXISTS idx_risk_cases_users ON risk_cases USING GIN (user_ids);
+
+-- 每个风控标记最多属于一个案件
+CREATE TABLE IF NOT EXISTS risk_case_flags (
+    case_id     BIGINT NOT NULL REFERENCES risk_cases(id),
+    flag_id     BIGINT NOT NULL REFERENCES risk_flags(id),
+    added_by    BIGINT NOT NULL REFERENCES users(id),
+    added_at    TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (case_id, flag_id),
+    CONSTRAINT uq_risk_case_flag UNIQUE (flag_id)
+);
+
+-- ========================================
+-- 3. 案件备注
+-- ========================================
+CREATE TABLE IF NOT EXISTS risk_case_notes (
+    id          BIGSERIAL PRIMARY KEY,
+    case_id     BIGINT NOT NULL REFERENCES risk_cases(id),
+    parent_id   BIGINT REFERENCES risk_case_notes(id),            -- 回复的备注，为空时为顶层备注
+    author_id   BIGINT NOT NULL REFERENCES users(id),
+    body        TEXT NOT NULL,
+    created_at  TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_risk_case_notes_case ON risk_case_notes(case_id, created_at);
+
+-- ========================================
+-- 4. 案件证据
+-- ========================================
+CREATE TABLE IF NOT EXISTS risk_case_evidence (
+    id              BIGSERIAL PRIMARY KEY,
+    case_id         BIGINT NOT NULL REFERENCES risk_cases(id),
+    evidence_type   VARCHAR(20) NOT NULL,                         -- round/transaction/login_event
+    ref_id          BIGINT NOT NULL,                              -- 回合ID、流水ID或登录事件ID
+    snapshot        JSONB NOT NULL,                               -- 添加时的记录快照
+    remark          TEXT NOT NULL DEFAULT '',
+    added_by        BIGINT NOT NULL REFERENCES users(id),
+    created_at      TIMESTAMP NOT NULL DEFAULT NOW(),
+    CONSTRAINT chk_risk_case_evidence_type CHECK (evidence_type IN ('round', 'transaction', 'login_event')),
+    CONSTRAINT uq_risk_case_evidence UNIQUE (case_id, evidence_type, ref_id)
+);
+
+-- ========================================
+-- 5. 案件审计
+-- ========================================
+CREATE TABLE IF NOT EXISTS risk_case_audit (
+    id              BIGSERIAL PRIMARY KEY,
+    case_id         BIGINT NOT NULL REFERENCES risk_cases(id),
+    action          VARCHAR(30) NOT NULL,                         -- created/flags_added/assigned/status_changed/note_added/evidence_added/flag_reviewed
+    operator_id     BIGINT REFERENCES users(id),
+    details         JSONB NOT NULL DEFAULT '{}',
+    created_at      TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_risk_case_audit_case ON risk_case_audit(case_id, created_at DESC);
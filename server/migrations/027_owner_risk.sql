@@ -0,0 +1,22 @@
+-- 房主维度风控
+-- 每日房主对账后汇总房主当日指标并写入本表，与此前的记录对比检测异常：
+-- 佣金收入与房间流水不符、保证金回撤、名下房间玩家整体胜率偏离期望、资金守恒差额大幅波动；
+-- 另检测房主提现前短时间内集中审批玩家充值。命中时创建房主维度的风控标记（risk_flags.user_id 为房主）并告警
+
+CREATE TABLE IF NOT EXISTS owner_risk_daily (
+    owner_id                BIGINT NOT NULL REFERENCES users(id),
+    day                     DATE NOT NULL,
+    currency                VARCHAR(10) NOT NULL,
+    room_volume             DECIMAL(18,2) NOT NULL DEFAULT 0,     -- 当日已结算回合的奖池总额
+    commission              DECIMAL(18,2) NOT NULL DEFAULT 0,     -- 当日房主抽成收入
+    margin_balance          DECIMAL(18,2) NOT NULL DEFAULT 0,     -- 日终保证金余额
+    conservation_difference DECIMAL(18,2) NOT NULL DEFAULT 0,     -- 房主维度资金守恒差额
+    player_rounds           INT NOT NULL DEFAULT 0,               -- 玩家参与回合数（人次）
+    player_wins             INT NOT NULL DEFAULT 0,               -- 玩家获胜回合数（人次）
+    expected_wins           DOUBLE PRECISION NOT NULL DEFAULT 0,  -- 按每回合赢家数/参与人数计算的期望获胜数
+    win_variance            DOUBLE PRECISION NOT NULL DEFAULT 0,  -- 获胜数方差
+    created_at              TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (owner_id, day)
+);
+
+CREATE INDEX IF NOT EXISTS idx_owner_risk_daily_day ON owner_risk_daily(day);
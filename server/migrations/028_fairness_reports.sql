@@ -0,0 +1,30 @@
+-- 回合结果公平性统计
+-- 定时统计窗口内已结算回合，按全局与房间分别生成报告：
+-- 1. 卡方检验：各玩家获胜次数与按参与回合计算的期望（每回合赢家数 / 参与人数之和）是否一致
+-- 2. 游程检验：玩家按回合顺序的输赢序列是否随机
+-- 3. 二项检验：玩家获胜次数是否显著超出期望
+-- 任一检验的 p 值低于阈值即为显著并触发告警；看板展示最近一次统计的报告
+
+CREATE TABLE IF NOT EXISTS fairness_reports (
+    id                  BIGSERIAL PRIMARY KEY,
+    run_id              BIGINT NOT NULL,                              -- 同一次统计生成的报告共用（统计开始时刻的 Unix 毫秒）
+    scope               VARCHAR(10) NOT NULL,                         -- global/room
+    room_id             BIGINT REFERENCES rooms(id),
+    window_start        TIMESTAMP NOT NULL,
+    window_end          TIMESTAMP NOT NULL,
+    rounds              INT NOT NULL DEFAULT 0,
+    players             INT NOT NULL DEFAULT 0,
+    chi_square          DOUBLE PRECISION,                             -- 样本不足时为空
+    degrees_of_freedom  INT NOT NULL DEFAULT 0,
+    chi_square_p_value  DOUBLE PRECISION,
+    runs_tested         INT NOT NULL DEFAULT 0,                       -- 参与游程检验的玩家数
+    runs_deviations     JSONB NOT NULL DEFAULT '[]',                  -- 游程检验显著的玩家
+    outliers            JSONB NOT NULL DEFAULT '[]',                  -- 获胜次数显著超出期望的玩家
+    p_value_threshold   DOUBLE PRECISION NOT NULL,
+    significant         BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at          TIMESTAMP NOT NULL DEFAULT NOW(),
+    CONSTRAINT chk_fairness_scope CHECK (scope IN ('global', 'room'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_fairness_reports_run ON fairness_reports(run_id);
+CREATE INDEX IF NOT EXISTS idx_fairness_reports_scope ON fairness_reports(scope, room_id, created_at DESC);
@@ -0,0 +1,58 @@
+-- 告警通知渠道与路由
+-- 告警创建后按路由规则（告警类型 + 最低严重程度）匹配通知渠道，为每个渠道登记一条投递记录：
+-- 1. 通知渠道：通用 webhook、SMTP 邮件、Slack / 钉钉机器人 webhook，配置保存在数据库中
+-- 2. 路由规则：告警类型为空表示全部类型；同一告警命中同一渠道的多条规则只投递一次
+-- 3. 投递记录：后台任务发送待投递记录，失败后按指数退避重试，达到最大次数后标记为失败
+--    投递记录即告警的通知日志，失败的投递可由管理员手动重试
+
+-- ========================================
+-- 1. 通知渠道表
+-- ========================================
+CREATE TABLE IF NOT EXISTS notification_channels (
+    id              BIGSERIAL PRIMARY KEY,
+    name            VARCHAR(100) NOT NULL UNIQUE,
+    channel_type    VARCHAR(20) NOT NULL,                     -- webhook/email/slack/dingtalk
+    config          JSONB NOT NULL DEFAULT '{}',              -- 渠道配置（URL、签名密钥、SMTP 服务器与收件人等）
+    enabled         BOOLEAN NOT NULL DEFAULT TRUE,
+    created_by      BIGINT REFERENCES users(id),
+    created_at      TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at      TIMESTAMP NOT NULL DEFAULT NOW(),
+    CONSTRAINT chk_notification_channel_type CHECK (channel_type IN ('webhook', 'email', 'slack', 'dingtalk'))
+);
+
+-- ========================================
+-- 2. 通知路由规则表
+-- ========================================
+CREATE TABLE IF NOT EXISTS notification_routes (
+    id              BIGSERIAL PRIMARY KEY,
+    channel_id      BIGINT NOT NULL REFERENCES notification_channels(id),
+    alert_types     VARCHAR(50)[] NOT NULL DEFAULT '{}',      -- 为空表示全部告警类型
+    min_severity    VARCHAR(20) NOT NULL DEFAULT 'warning',   -- 告警严重程度不低于该值时投递
+    enabled         BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at      TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at      TIMESTAMP NOT NULL DEFAULT NOW(),
+    CONSTRAINT chk_notification_route_severity CHECK (min_severity IN ('info', 'warning', 'critical'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_notification_routes_channel ON notification_routes(channel_id);
+
+-- ========================================
+-- 3. 告警投递记录表
+-- ========================================
+CREATE TABLE IF NOT EXISTS alert_deliveries (
+    id              BIGSERIAL PRIMARY KEY,
+    alert_id        BIGINT NOT NULL REFERENCES alerts(id),
+    channel_id      BIGINT NOT NULL REFERENCES notification_channels(id),
+    status          VARCHAR(20) NOT NULL DEFAULT 'pending',   -- pending/sent/failed
+    attempts        INT NOT NULL DEFAULT 0,
+    last_error      VARCHAR(500),
+    next_attempt_at TIMESTAMP NOT NULL DEFAULT NOW(),         -- 发送中的记录会顺延一个租约时长，避免重复发送
+    delivered_at    TIMESTAMP,
+    created_at      TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at      TIMESTAMP NOT NULL DEFAULT NOW(),
+    CONSTRAINT uq_alert_delivery UNIQUE (alert_id, channel_id),
+    CONSTRAINT chk_alert_delivery_status CHECK (status IN ('pending', 'sent', 'failed'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_alert_deliveries_due ON alert_deliveries(next_attempt_at) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS idx_alert_deliveries_channel ON alert_deliveries(channel_id, created_at DESC);
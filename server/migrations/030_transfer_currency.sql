@@ -0,0 +1,14 @@
+-- 玩家转账记录币种
+-- 转账以转出方活跃币种结算，记录币种后资金守恒检查可按币种核对
+-- 每笔已完成转账必须恰好对应一条转出流水和一条转入流水
+
+ALTER TABLE player_transfers ADD COLUMN IF NOT EXISTS currency VARCHAR(3);
+
+-- 历史转账按转出方当前币种回填
+UPDATE player_transfers t SET currency = u.currency
+FROM users u
+WHERE t.from_user_id = u.id AND t.currency IS NULL;
+
+ALTER TABLE player_transfers ALTER COLUMN currency SET NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_transfer_currency_status ON player_transfers(currency, status);
@@ -0,0 +1,8 @@
+-- 用户真实余额不能为负
+-- 写回模式下 Redis 中的扣款异步落库，数据库路径修改余额前须暂停缓存扣款并落库（PrepareDBWrite），
+-- 落库时余额不足的条目转入死信队列；约束作为最后一道防线，任何路径把余额扣为负都会失败
+-- 已存在负余额时 VALIDATE 失败，需先人工处理这些账户
+
+ALTER TABLE users DROP CONSTRAINT IF EXISTS chk_users_balance_non_negative;
+ALTER TABLE users ADD CONSTRAINT chk_users_balance_non_negative CHECK (balance >= 0) NOT VALID;
+ALTER TABLE users VALIDATE CONSTRAINT chk_users_balance_non_negative;
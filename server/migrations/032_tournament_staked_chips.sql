@@ -0,0 +1,8 @@
+-- 锦标赛报名记录的在途下注筹码
+-- 筹码房间不写 game_rounds，回合开始扣除的筹码在结算或取消前记入 staked；
+-- 结算或退回时扣减，锦标赛结束时仍未结算的下注筹码先退回再排名
+
+ALTER TABLE tournament_entries ADD COLUMN IF NOT EXISTS staked DECIMAL(18,2) NOT NULL DEFAULT 0;
+
+ALTER TABLE tournament_entries DROP CONSTRAINT IF EXISTS chk_tournament_entries_staked;
+ALTER TABLE tournament_entries ADD CONSTRAINT chk_tournament_entries_staked CHECK (staked >= 0);
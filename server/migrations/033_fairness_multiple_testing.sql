@@ -0,0 +1,8 @@
+-- 公平性统计的多重检验校正
+-- 同一次统计的全部检验（各范围的卡方检验及每名玩家的游程与获胜次数检验）按 Benjamini–Hochberg 控制错误发现率，
+-- p_value_threshold 改为记录错误发现率；获胜次数检验改用按每回合获胜概率的泊松二项分布
+-- 1. family_size：同一次统计的检验总数
+-- 2. p_value_cutoff：校正后的 p 值界限，不超过该值的检验为显著；无显著检验时为空
+
+ALTER TABLE fairness_reports ADD COLUMN IF NOT EXISTS family_size INT NOT NULL DEFAULT 0;
+ALTER TABLE fairness_reports ADD COLUMN IF NOT EXISTS p_value_cutoff DOUBLE PRECISION;
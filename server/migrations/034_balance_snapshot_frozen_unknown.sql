@@ -0,0 +1,6 @@
+-- 日终快照的冻结余额可为空
+-- 冻结余额没有流水，无法像其他余额一样按日终时刻回推；日终后未及时采集（停机补采）的快照记为 NULL（未知），
+-- 不再把采集时的当前值记到过去的日期
+
+ALTER TABLE balance_snapshots ALTER COLUMN frozen_balance DROP NOT NULL;
+ALTER TABLE balance_snapshots ALTER COLUMN frozen_balance DROP DEFAULT;
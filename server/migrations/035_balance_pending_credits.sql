@@ -0,0 +1,15 @@
+-- 写回模式下的待写回加款
+-- 结算事务内登记赢家奖金与累进奖池派发（操作ID 如 round:123:win），事务提交后写入 Redis 或直接落库；
+-- 两条路径都失败或进程在提交后中断时，由启动时与 flusher 按操作ID重放，直到加款落库
+-- 登记 balance_outbox_applied 的同一事务内删除对应记录，因此记录存在即表示该加款尚未落库
+
+CREATE TABLE IF NOT EXISTS balance_pending_credits (
+    op          VARCHAR(64) PRIMARY KEY,   -- outbox 操作ID
+    room_id     BIGINT,
+    round_id    BIGINT,
+    tx_type     VARCHAR(30) NOT NULL,      -- 落库时的交易类型（game_win / jackpot_win）
+    amounts     JSONB NOT NULL,            -- {用户ID: 金额}
+    created_at  TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_balance_pending_credits_created_at ON balance_pending_credits(created_at);
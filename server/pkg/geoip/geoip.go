@@ -0,0 +1,105 @@
+// Package geoip maps IP addresses to coarse location labels using a local
+// CIDR database.
+//
+// The database is a plain CSV file with one "cidr,label" entry per line, for
+// example "203.0.113.0/24,SG-Singapore". Blank lines and lines starting with
+// '#' are ignored. Lookups return the label of the most specific matching
+// network, so a country-level entry can be refined by city-level entries.
+// Private, loopback, link-local and unspecified addresses never get a label,
+// since they say nothing about where a player is.
+package geoip
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/netip"
+	"os"
+	"sort"
+	"strings"
+)
+
+type entry struct {
+	prefix netip.Prefix
+	label  string
+}
+
+// DB is an in-memory CIDR to label database. The zero value and a nil *DB
+// are valid empty databases.
+type DB struct {
+	entries []entry // sorted by prefix length, most specific first
+}
+
+// Open loads a database from a CSV file
+func Open(path string) (*DB, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Load(f)
+}
+
+// Load reads a database from r
+func Load(r io.Reader) (*DB, error) {
+	db := &DB{}
+	scanner := bufio.NewScanner(r)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		cidr, label, ok := strings.Cut(line, ",")
+		if !ok {
+			return nil, fmt.Errorf("geoip: line %d: expected \"cidr,label\"", lineNo)
+		}
+		prefix, err := netip.ParsePrefix(strings.TrimSpace(cidr))
+		if err != nil {
+			return nil, fmt.Errorf("geoip: line %d: %w", lineNo, err)
+		}
+		db.entries = append(db.entries, entry{prefix: prefix.Masked(), label: strings.TrimSpace(label)})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	sort.SliceStable(db.entries, func(i, j int) bool {
+		return db.entries[i].prefix.Bits() > db.entries[j].prefix.Bits()
+	})
+	return db, nil
+}
+
+// Len returns the number of networks in the database
+func (db *DB) Len() int {
+	if db == nil {
+		return 0
+	}
+	return len(db.entries)
+}
+
+// Lookup returns the label of the most specific network containing ip, or ""
+// if ip is invalid, not publicly routable or not covered by the database
+func (db *DB) Lookup(ip string) string {
+	if db == nil || len(db.entries) == 0 {
+		return ""
+	}
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return ""
+	}
+	addr = addr.Unmap()
+	if !isPublic(addr) {
+		return ""
+	}
+	for _, e := range db.entries {
+		if e.prefix.Contains(addr) {
+			return e.label
+		}
+	}
+	return ""
+}
+
+func isPublic(addr netip.Addr) bool {
+	return !addr.IsPrivate() && !addr.IsLoopback() && !addr.IsLinkLocalUnicast() && !addr.IsUnspecified()
+}
@@ -0,0 +1,116 @@
+package geoip
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testDB = `# test database
+203.0.113.0/24,SG-Singapore
+203.0.113.128/25, SG-Singapore-East
+
+198.51.100.7/32,US-Virginia
+2001:db8::/32,JP-Tokyo
+2001:db8:abcd::/48,JP-Osaka
+10.0.0.0/8,LAN
+fd00::/8,LAN
+`
+
+func TestLookup(t *testing.T) {
+	db, err := Load(strings.NewReader(testDB))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if db.Len() != 7 {
+		t.Errorf("Len = %d, want 7", db.Len())
+	}
+
+	tests := []struct {
+		ip   string
+		want string
+	}{
+		{"203.0.113.1", "SG-Singapore"},
+		{"203.0.113.127", "SG-Singapore"},
+		{"203.0.113.128", "SG-Singapore-East"},
+		{"203.0.113.255", "SG-Singapore-East"},
+		{"203.0.112.255", ""},
+		{"203.0.114.0", ""},
+		{"198.51.100.7", "US-Virginia"},
+		{"198.51.100.8", ""},
+		// IPv4-mapped IPv6 matches the IPv4 network
+		{"::ffff:203.0.113.200", "SG-Singapore-East"},
+		{"2001:db8::1", "JP-Tokyo"},
+		{"2001:db8:abcd:1::1", "JP-Osaka"},
+		{"2001:db9::1", ""},
+		// an IPv4 address never matches an IPv6 network and vice versa
+		{"32.1.13.184", ""},
+		{"::cb00:7101", ""},
+		// non-public addresses get no label even when the database covers them
+		{"10.1.2.3", ""},
+		{"fd00::1", ""},
+		{"192.168.1.1", ""},
+		{"127.0.0.1", ""},
+		{"::1", ""},
+		{"169.254.0.1", ""},
+		{"fe80::1", ""},
+		{"0.0.0.0", ""},
+		{"", ""},
+		{"not-an-ip", ""},
+		{"203.0.113.1:443", ""},
+	}
+	for _, tt := range tests {
+		if got := db.Lookup(tt.ip); got != tt.want {
+			t.Errorf("Lookup(%q) = %q, want %q", tt.ip, got, tt.want)
+		}
+	}
+}
+
+func TestEmptyDatabase(t *testing.T) {
+	var nilDB *DB
+	for _, db := range []*DB{nilDB, {}} {
+		if db.Len() != 0 || db.Lookup("203.0.113.1") != "" {
+			t.Errorf("empty database should have no entries and no labels")
+		}
+	}
+}
+
+func TestOpen(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "geo.csv")
+	if err := os.WriteFile(path, []byte(testDB), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	db, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if got := db.Lookup("2001:db8::1"); got != "JP-Tokyo" {
+		t.Errorf("Lookup = %q, want JP-Tokyo", got)
+	}
+
+	if db, err := Open(filepath.Join(dir, "missing.csv")); err == nil || db != nil {
+		t.Errorf("Open of a missing file = (%v, %v), want an error", db, err)
+	}
+}
+
+func TestLoadRejectsCorruptDatabase(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{"missing label", "203.0.113.0/24\n", "line 1: expected"},
+		{"bad prefix", "# header\n203.0.113.0/24,SG\n203.0.113.0/33,SG\n", "line 3:"},
+		{"address without length", "203.0.113.1,SG\n", "line 1:"},
+		{"binary data", "\x00\x01\x02,\xff\n", "line 1:"},
+		{"line too long", strings.Repeat("1", 70000) + ",X\n", "token too long"},
+	}
+	for _, tt := range tests {
+		db, err := Load(strings.NewReader(tt.data))
+		if err == nil || db != nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: Load = (%v, %v), want error containing %q", tt.name, db, err, tt.want)
+		}
+	}
+}
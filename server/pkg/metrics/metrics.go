@@ -104,6 +104,23 @@ var (
 		[]string{"type"},
 	)
 
+	// Fund request SLA metrics
+	fundRequestResolutionDuration = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "fund_request_resolution_seconds",
+			Help:    "Time from fund request creation to final decision in seconds",
+			Buckets: []float64{60, 300, 900, 1800, 3600, 7200, 21600, 43200, 86400, 259200},
+		},
+		[]string{"scope", "type", "outcome"},
+	)
+
+	fundRequestRemindersTotal = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Name: "fund_request_reminders_total",
+			Help: "Total number of pending fund request reminders sent to owners",
+		},
+	)
+
 	// Reconciliation metrics
 	reconciliationTotal = promauto.NewCounterVec(
 		prometheus.CounterOpts{
@@ -169,3 +186,15 @@ func RecordFundAnomaly(anomalyType string) {
 func RecordReconciliation(status, period string) {
 	reconciliationTotal.WithLabelValues(status, period).Inc()
 }
+
+// RecordFundRequestResolution records how long a fund request waited before approval, rejection or expiry.
+// scope is "owner" for player requests and "platform" for owner/admin requests; per-owner ids are
+// deliberately not used as labels to keep series cardinality bounded.
+func RecordFundRequestResolution(scope, requestType, outcome string, waited time.Duration) {
+	fundRequestResolutionDuration.WithLabelValues(scope, requestType, outcome).Observe(waited.Seconds())
+}
+
+// RecordFundRequestReminder records a pending fund request reminder sent to an owner
+func RecordFundRequestReminder() {
+	fundRequestRemindersTotal.Inc()
+}
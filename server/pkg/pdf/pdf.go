@@ -0,0 +1,162 @@
+// Package pdf provides a minimal text-only PDF writer.
+//
+// It only supports left-aligned lines of text on A4 pages, which is enough for
+// statements and reports. Text is rendered with the non-embedded Adobe CJK font
+// STSong-Light so that both Chinese and ASCII characters display correctly in
+// standard PDF viewers without bundling font files.
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"unicode/utf16"
+)
+
+const (
+	pageWidth    = 595 // A4 width in points
+	pageHeight   = 842 // A4 height in points
+	marginLeft   = 40
+	marginTop    = 50
+	marginBottom = 50
+)
+
+// Document is a text-only PDF document
+type Document struct {
+	fontSize   float64
+	lineHeight float64
+	pages      [][]string
+}
+
+// New creates a new document with the given font size
+func New(fontSize float64) *Document {
+	return &Document{
+		fontSize:   fontSize,
+		lineHeight: fontSize * 1.4,
+	}
+}
+
+// linesPerPage returns how many lines fit on a single page
+func (d *Document) linesPerPage() int {
+	return int((pageHeight - marginTop - marginBottom) / d.lineHeight)
+}
+
+// AddLine appends a line of text, starting a new page when the current one is full
+func (d *Document) AddLine(text string) {
+	if len(d.pages) == 0 || len(d.pages[len(d.pages)-1]) >= d.linesPerPage() {
+		d.pages = append(d.pages, nil)
+	}
+	d.pages[len(d.pages)-1] = append(d.pages[len(d.pages)-1], text)
+}
+
+// AddLines appends multiple lines of text
+func (d *Document) AddLines(lines ...string) {
+	for _, line := range lines {
+		d.AddLine(line)
+	}
+}
+
+// WriteTo writes the PDF document to w
+func (d *Document) WriteTo(w io.Writer) (int64, error) {
+	if len(d.pages) == 0 {
+		d.pages = append(d.pages, nil)
+	}
+
+	var buf bytes.Buffer
+	var offsets []int
+
+	beginObj := func() int {
+		offsets = append(offsets, buf.Len())
+		id := len(offsets)
+		fmt.Fprintf(&buf, "%d 0 obj\n", id)
+		return id
+	}
+	endObj := func() {
+		buf.WriteString("endobj\n")
+	}
+
+	buf.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	// Fixed objects: 1 catalog, 2 page tree, 3-5 font. Pages start at 6.
+	pageCount := len(d.pages)
+	pageIDs := make([]int, pageCount)
+	for i := range pageIDs {
+		pageIDs[i] = 6 + i*2
+	}
+
+	beginObj()
+	buf.WriteString("<< /Type /Catalog /Pages 2 0 R >>\n")
+	endObj()
+
+	beginObj()
+	buf.WriteString("<< /Type /Pages /Kids [")
+	for _, id := range pageIDs {
+		fmt.Fprintf(&buf, "%d 0 R ", id)
+	}
+	fmt.Fprintf(&buf, "] /Count %d >>\n", pageCount)
+	endObj()
+
+	beginObj()
+	buf.WriteString("<< /Type /Font /Subtype /Type0 /BaseFont /STSong-Light /Encoding /UniGB-UCS2-H /DescendantFonts [4 0 R] >>\n")
+	endObj()
+
+	beginObj()
+	buf.WriteString("<< /Type /Font /Subtype /CIDFontType0 /BaseFont /STSong-Light " +
+		"/CIDSystemInfo << /Registry (Adobe) /Ordering (GB1) /Supplement 4 >> " +
+		"/FontDescriptor 5 0 R /DW 1000 /W [1 95 500] >>\n")
+	endObj()
+
+	beginObj()
+	buf.WriteString("<< /Type /FontDescriptor /FontName /STSong-Light /Flags 6 " +
+		"/FontBBox [-25 -254 1000 880] /ItalicAngle 0 /Ascent 880 /Descent -120 /CapHeight 880 /StemV 93 >>\n")
+	endObj()
+
+	for i, lines := range d.pages {
+		content := d.pageContent(lines)
+
+		beginObj()
+		fmt.Fprintf(&buf, "<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] "+
+			"/Resources << /Font << /F1 3 0 R >> >> /Contents %d 0 R >>\n", pageWidth, pageHeight, pageIDs[i]+1)
+		endObj()
+
+		beginObj()
+		fmt.Fprintf(&buf, "<< /Length %d >>\nstream\n", len(content))
+		buf.Write(content)
+		buf.WriteString("\nendstream\n")
+		endObj()
+	}
+
+	xrefOffset := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n", len(offsets)+1)
+	buf.WriteString("0000000000 65535 f \n")
+	for _, off := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xrefOffset)
+
+	n, err := w.Write(buf.Bytes())
+	return int64(n), err
+}
+
+// pageContent builds the content stream for a single page
+func (d *Document) pageContent(lines []string) []byte {
+	var c bytes.Buffer
+	fmt.Fprintf(&c, "BT\n/F1 %.1f Tf\n%.1f TL\n%d %.1f Td\n", d.fontSize, d.lineHeight, marginLeft, float64(pageHeight-marginTop))
+	for _, line := range lines {
+		c.WriteString(encodeText(line))
+		c.WriteString(" Tj T*\n")
+	}
+	c.WriteString("ET")
+	return c.Bytes()
+}
+
+// encodeText encodes text as a UCS-2 big-endian hex string for the UniGB-UCS2-H encoding
+func encodeText(s string) string {
+	var b bytes.Buffer
+	b.WriteByte('<')
+	for _, u := range utf16.Encode([]rune(s)) {
+		fmt.Fprintf(&b, "%04X", u)
+	}
+	b.WriteByte('>')
+	return b.String()
+}
@@ -0,0 +1,148 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// render writes the document and returns its bytes
+func render(t *testing.T, d *Document) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	n, err := d.WriteTo(&buf)
+	if err != nil {
+		t.Fatalf("WriteTo: %v", err)
+	}
+	if int(n) != buf.Len() {
+		t.Fatalf("WriteTo reported %d bytes, wrote %d", n, buf.Len())
+	}
+	return buf.Bytes()
+}
+
+var (
+	xrefEntryRe = regexp.MustCompile(`^(\d{10}) (\d{5}) ([nf]) $`)
+	trailerRe   = regexp.MustCompile(`trailer\n<< /Size (\d+) /Root 1 0 R >>\nstartxref\n(\d+)\n%%EOF\n$`)
+	streamRe    = regexp.MustCompile(`<< /Length (\d+) >>\nstream\n`)
+)
+
+// checkStructure verifies the xref table, object offsets, trailer and stream lengths
+func checkStructure(t *testing.T, out []byte) (objects int) {
+	t.Helper()
+	if !bytes.HasPrefix(out, []byte("%PDF-1.4\n")) {
+		t.Fatalf("missing PDF header: %q", out[:16])
+	}
+
+	m := trailerRe.FindSubmatch(out)
+	if m == nil {
+		t.Fatalf("malformed trailer: %q", out[len(out)-80:])
+	}
+	size, _ := strconv.Atoi(string(m[1]))
+	xrefOffset, _ := strconv.Atoi(string(m[2]))
+	if !bytes.HasPrefix(out[xrefOffset:], []byte("xref\n")) {
+		t.Fatalf("startxref %d does not point at the xref table", xrefOffset)
+	}
+
+	lines := strings.Split(string(out[xrefOffset:]), "\n")
+	if lines[1] != fmt.Sprintf("0 %d", size) {
+		t.Fatalf("xref subsection %q, want 0 %d", lines[1], size)
+	}
+	for i := 0; i < size; i++ {
+		e := xrefEntryRe.FindStringSubmatch(lines[2+i])
+		if e == nil {
+			t.Fatalf("malformed xref entry %d: %q", i, lines[2+i])
+		}
+		if i == 0 {
+			if e[3] != "f" || e[2] != "65535" {
+				t.Errorf("entry 0 should be the free list head, got %q", lines[2])
+			}
+			continue
+		}
+		off, _ := strconv.Atoi(e[1])
+		if want := fmt.Sprintf("%d 0 obj\n", i); !bytes.HasPrefix(out[off:], []byte(want)) {
+			t.Errorf("object %d: offset %d points at %q", i, off, out[off:off+12])
+		}
+	}
+
+	for _, loc := range streamRe.FindAllSubmatchIndex(out, -1) {
+		length, _ := strconv.Atoi(string(out[loc[2]:loc[3]]))
+		body := out[loc[1]:]
+		if !bytes.HasPrefix(body[length:], []byte("\nendstream\n")) {
+			t.Errorf("stream at %d: /Length %d does not end at endstream", loc[0], length)
+		}
+	}
+	return size - 1
+}
+
+func TestEmptyDocumentHasOnePage(t *testing.T) {
+	out := render(t, New(9))
+	if objects := checkStructure(t, out); objects != 7 {
+		t.Errorf("objects = %d, want 5 fixed objects plus one page and its content", objects)
+	}
+	if !bytes.Contains(out, []byte("/Kids [6 0 R ] /Count 1")) {
+		t.Errorf("page tree should list a single page")
+	}
+}
+
+func TestPagination(t *testing.T) {
+	d := New(10)
+	perPage := d.linesPerPage()
+	for i := 0; i < perPage*2+1; i++ {
+		d.AddLine(fmt.Sprintf("line %d", i))
+	}
+	out := render(t, d)
+
+	if objects := checkStructure(t, out); objects != 5+3*2 {
+		t.Errorf("objects = %d, want 11 for three pages", objects)
+	}
+	if !bytes.Contains(out, []byte("/Kids [6 0 R 8 0 R 10 0 R ] /Count 3")) {
+		t.Errorf("page tree should list three pages")
+	}
+	for i, want := range []int{perPage, perPage, 1} {
+		if got := len(d.pages[i]); got != want {
+			t.Errorf("page %d has %d lines, want %d", i, got, want)
+		}
+	}
+}
+
+func TestEncodeText(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"", "<>"},
+		{"A", "<0041>"},
+		// parentheses and backslashes are hex-encoded and cannot terminate the string
+		{`(a)\`, "<002800610029005C>"},
+		{"账单", "<8D265355>"},
+		{"余额 -1.00", "<4F59989D0020002D0031002E00300030>"},
+	}
+	for _, tt := range tests {
+		if got := encodeText(tt.in); got != tt.want {
+			t.Errorf("encodeText(%q) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestSpecialCharactersStayInsideHexStrings(t *testing.T) {
+	d := New(9)
+	d.AddLines(`) Tj (injected`, `back\slash`, "中文账单 ¥100")
+	out := render(t, d)
+	checkStructure(t, out)
+
+	if bytes.Contains(out, []byte("injected")) || bytes.Contains(out, []byte(`back\slash`)) {
+		t.Errorf("raw text leaked into the content stream")
+	}
+	for _, want := range []string{
+		encodeText(`) Tj (injected`) + " Tj T*\n",
+		encodeText(`back\slash`) + " Tj T*\n",
+		"<4E2D65878D265355002000A5003100300030> Tj T*\n",
+	} {
+		if !bytes.Contains(out, []byte(want)) {
+			t.Errorf("content stream missing %q", want)
+		}
+	}
+}
@@ -0,0 +1,656 @@
+// Package ruleexpr implements the small expression language used by
+// configurable risk rules.
+//
+// Grammar, from lowest to highest precedence:
+//
+//	or      = and { "||" and }
+//	and     = not { "&&" not }
+//	not     = "!" not | cmp
+//	cmp     = sum [ ( "==" | "!=" | "<" | "<=" | ">" | ">=" ) sum ]
+//	sum     = product { ( "+" | "-" ) product }
+//	product = unary { ( "*" | "/" ) unary }
+//	unary   = "-" unary | primary
+//	primary = number | string | "true" | "false" | ident | "(" or ")"
+//
+// Identifiers may contain dots (for example user.win_rate) and are resolved
+// at evaluation time through a Resolver. The language has no function calls,
+// assignments or loops, and both the source length and the nesting depth are
+// bounded, so evaluating a compiled expression is always cheap and side-effect
+// free.
+package ruleexpr
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	// MaxLength is the maximum accepted source length in bytes
+	MaxLength = 1024
+	// MaxDepth is the maximum nesting depth of the parsed expression
+	MaxDepth = 32
+)
+
+var (
+	// ErrUnknownVariable is returned when a resolver has no value for an identifier
+	ErrUnknownVariable = errors.New("unknown variable")
+	// ErrDivisionByZero is returned when the right operand of "/" is zero
+	ErrDivisionByZero = errors.New("division by zero")
+)
+
+// Kind is the type of a value
+type Kind int
+
+const (
+	KindNumber Kind = iota + 1
+	KindString
+	KindBool
+)
+
+// String returns the name of the kind
+func (k Kind) String() string {
+	switch k {
+	case KindNumber:
+		return "number"
+	case KindString:
+		return "string"
+	case KindBool:
+		return "bool"
+	default:
+		return "invalid"
+	}
+}
+
+// Value is a number, string or bool
+type Value struct {
+	kind Kind
+	num  float64
+	str  string
+	b    bool
+}
+
+// Number returns a number value
+func Number(f float64) Value { return Value{kind: KindNumber, num: f} }
+
+// String returns a string value
+func String(s string) Value { return Value{kind: KindString, str: s} }
+
+// Bool returns a bool value
+func Bool(b bool) Value { return Value{kind: KindBool, b: b} }
+
+// Kind returns the type of the value
+func (v Value) Kind() Kind { return v.kind }
+
+// Interface returns the value as float64, string or bool
+func (v Value) Interface() interface{} {
+	switch v.kind {
+	case KindNumber:
+		return v.num
+	case KindString:
+		return v.str
+	case KindBool:
+		return v.b
+	default:
+		return nil
+	}
+}
+
+// Resolver supplies the values of identifiers
+type Resolver interface {
+	Lookup(name string) (Value, error)
+}
+
+// Vars is a Resolver backed by a map
+type Vars map[string]Value
+
+// Lookup implements Resolver
+func (v Vars) Lookup(name string) (Value, error) {
+	if val, ok := v[name]; ok {
+		return val, nil
+	}
+	return Value{}, fmt.Errorf("%w: %s", ErrUnknownVariable, name)
+}
+
+// SyntaxError describes a malformed expression
+type SyntaxError struct {
+	Pos int
+	Msg string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("syntax error at %d: %s", e.Pos, e.Msg)
+}
+
+// Expr is a compiled expression
+type Expr struct {
+	src  string
+	root node
+	vars []string
+}
+
+// Compile parses src into an expression
+func Compile(src string) (*Expr, error) {
+	if len(src) > MaxLength {
+		return nil, &SyntaxError{Pos: MaxLength, Msg: fmt.Sprintf("expression longer than %d bytes", MaxLength)}
+	}
+	tokens, err := lex(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens, vars: map[string]bool{}}
+	root, err := p.parseOr(0)
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokEOF {
+		return nil, &SyntaxError{Pos: tok.pos, Msg: fmt.Sprintf("unexpected %q", tok.text)}
+	}
+
+	vars := make([]string, 0, len(p.vars))
+	for name := range p.vars {
+		vars = append(vars, name)
+	}
+	sort.Strings(vars)
+	return &Expr{src: src, root: root, vars: vars}, nil
+}
+
+// String returns the source of the expression
+func (e *Expr) String() string { return e.src }
+
+// Vars returns the identifiers referenced by the expression, sorted
+func (e *Expr) Vars() []string { return e.vars }
+
+// Check type-checks the expression against the declared variable kinds and
+// requires the result to be a bool
+func (e *Expr) Check(kinds map[string]Kind) error {
+	k, err := e.root.check(kinds)
+	if err != nil {
+		return err
+	}
+	if k != KindBool {
+		return fmt.Errorf("expression must evaluate to bool, got %s", k)
+	}
+	return nil
+}
+
+// Eval evaluates the expression
+func (e *Expr) Eval(r Resolver) (Value, error) {
+	return e.root.eval(r)
+}
+
+// EvalBool evaluates the expression and requires a bool result
+func (e *Expr) EvalBool(r Resolver) (bool, error) {
+	v, err := e.root.eval(r)
+	if err != nil {
+		return false, err
+	}
+	if v.kind != KindBool {
+		return false, fmt.Errorf("expression evaluated to %s, want bool", v.kind)
+	}
+	return v.b, nil
+}
+
+// ===== lexer =====
+
+type tokKind int
+
+const (
+	tokEOF tokKind = iota
+	tokNumber
+	tokString
+	tokIdent
+	tokOp
+	tokLParen
+	tokRParen
+)
+
+type token struct {
+	kind tokKind
+	text string
+	pos  int
+	num  float64
+}
+
+func lex(src string) ([]token, error) {
+	var tokens []token
+	i := 0
+	for i < len(src) {
+		c := src[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '(':
+			tokens = append(tokens, token{kind: tokLParen, text: "(", pos: i})
+			i++
+		case c == ')':
+			tokens = append(tokens, token{kind: tokRParen, text: ")", pos: i})
+			i++
+		case isDigit(c) || (c == '.' && i+1 < len(src) && isDigit(src[i+1])):
+			start := i
+			for i < len(src) && (isDigit(src[i]) || src[i] == '.') {
+				i++
+			}
+			f, err := strconv.ParseFloat(src[start:i], 64)
+			if err != nil {
+				return nil, &SyntaxError{Pos: start, Msg: fmt.Sprintf("invalid number %q", src[start:i])}
+			}
+			tokens = append(tokens, token{kind: tokNumber, text: src[start:i], pos: start, num: f})
+		case c == '\'' || c == '"':
+			start := i
+			var sb strings.Builder
+			i++
+			closed := false
+			for i < len(src) {
+				if src[i] == '\\' && i+1 < len(src) {
+					sb.WriteByte(src[i+1])
+					i += 2
+					continue
+				}
+				if src[i] == c {
+					closed = true
+					i++
+					break
+				}
+				sb.WriteByte(src[i])
+				i++
+			}
+			if !closed {
+				return nil, &SyntaxError{Pos: start, Msg: "unterminated string"}
+			}
+			tokens = append(tokens, token{kind: tokString, text: sb.String(), pos: start})
+		case isIdentStart(c):
+			start := i
+			for i < len(src) && (isIdentStart(src[i]) || isDigit(src[i]) || src[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokIdent, text: src[start:i], pos: start})
+		default:
+			if i+1 < len(src) {
+				switch two := src[i : i+2]; two {
+				case "&&", "||", "==", "!=", "<=", ">=":
+					tokens = append(tokens, token{kind: tokOp, text: two, pos: i})
+					i += 2
+					continue
+				}
+			}
+			switch c {
+			case '!', '<', '>', '+', '-', '*', '/':
+				tokens = append(tokens, token{kind: tokOp, text: string(c), pos: i})
+				i++
+			default:
+				return nil, &SyntaxError{Pos: i, Msg: fmt.Sprintf("unexpected character %q", c)}
+			}
+		}
+	}
+	return append(tokens, token{kind: tokEOF, pos: len(src)}), nil
+}
+
+func isDigit(c byte) bool { return c >= '0' && c <= '9' }
+
+func isIdentStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// ===== parser =====
+
+type parser struct {
+	tokens []token
+	pos    int
+	vars   map[string]bool
+}
+
+func (p *parser) peek() token { return p.tokens[p.pos] }
+
+func (p *parser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *parser) acceptOp(ops ...string) (string, bool) {
+	tok := p.peek()
+	if tok.kind != tokOp {
+		return "", false
+	}
+	for _, op := range ops {
+		if tok.text == op {
+			p.pos++
+			return op, true
+		}
+	}
+	return "", false
+}
+
+func (p *parser) enter(depth int) error {
+	if depth > MaxDepth {
+		return &SyntaxError{Pos: p.peek().pos, Msg: fmt.Sprintf("expression nested deeper than %d", MaxDepth)}
+	}
+	return nil
+}
+
+func (p *parser) parseOr(depth int) (node, error) {
+	if err := p.enter(depth); err != nil {
+		return nil, err
+	}
+	left, err := p.parseAnd(depth)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		if _, ok := p.acceptOp("||"); !ok {
+			return left, nil
+		}
+		right, err := p.parseAnd(depth)
+		if err != nil {
+			return nil, err
+		}
+		left = &binaryNode{op: "||", left: left, right: right}
+	}
+}
+
+func (p *parser) parseAnd(depth int) (node, error) {
+	left, err := p.parseNot(depth)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		if _, ok := p.acceptOp("&&"); !ok {
+			return left, nil
+		}
+		right, err := p.parseNot(depth)
+		if err != nil {
+			return nil, err
+		}
+		left = &binaryNode{op: "&&", left: left, right: right}
+	}
+}
+
+func (p *parser) parseNot(depth int) (node, error) {
+	if err := p.enter(depth); err != nil {
+		return nil, err
+	}
+	if _, ok := p.acceptOp("!"); ok {
+		x, err := p.parseNot(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		return &unaryNode{op: "!", x: x}, nil
+	}
+	return p.parseCmp(depth)
+}
+
+func (p *parser) parseCmp(depth int) (node, error) {
+	left, err := p.parseSum(depth)
+	if err != nil {
+		return nil, err
+	}
+	if op, ok := p.acceptOp("==", "!=", "<", "<=", ">", ">="); ok {
+		right, err := p.parseSum(depth)
+		if err != nil {
+			return nil, err
+		}
+		return &binaryNode{op: op, left: left, right: right}, nil
+	}
+	return left, nil
+}
+
+func (p *parser) parseSum(depth int) (node, error) {
+	left, err := p.parseProduct(depth)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op, ok := p.acceptOp("+", "-")
+		if !ok {
+			return left, nil
+		}
+		right, err := p.parseProduct(depth)
+		if err != nil {
+			return nil, err
+		}
+		left = &binaryNode{op: op, left: left, right: right}
+	}
+}
+
+func (p *parser) parseProduct(depth int) (node, error) {
+	left, err := p.parseUnary(depth)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op, ok := p.acceptOp("*", "/")
+		if !ok {
+			return left, nil
+		}
+		right, err := p.parseUnary(depth)
+		if err != nil {
+			return nil, err
+		}
+		left = &binaryNode{op: op, left: left, right: right}
+	}
+}
+
+func (p *parser) parseUnary(depth int) (node, error) {
+	if err := p.enter(depth); err != nil {
+		return nil, err
+	}
+	if _, ok := p.acceptOp("-"); ok {
+		x, err := p.parseUnary(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		return &unaryNode{op: "-", x: x}, nil
+	}
+	return p.parsePrimary(depth)
+}
+
+func (p *parser) parsePrimary(depth int) (node, error) {
+	tok := p.next()
+	switch tok.kind {
+	case tokNumber:
+		return &literalNode{v: Number(tok.num)}, nil
+	case tokString:
+		return &literalNode{v: String(tok.text)}, nil
+	case tokIdent:
+		switch tok.text {
+		case "true":
+			return &literalNode{v: Bool(true)}, nil
+		case "false":
+			return &literalNode{v: Bool(false)}, nil
+		}
+		p.vars[tok.text] = true
+		return &identNode{name: tok.text}, nil
+	case tokLParen:
+		x, err := p.parseOr(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); closing.kind != tokRParen {
+			return nil, &SyntaxError{Pos: closing.pos, Msg: "missing )"}
+		}
+		return x, nil
+	case tokEOF:
+		return nil, &SyntaxError{Pos: tok.pos, Msg: "unexpected end of expression"}
+	default:
+		return nil, &SyntaxError{Pos: tok.pos, Msg: fmt.Sprintf("unexpected %q", tok.text)}
+	}
+}
+
+// ===== evaluation =====
+
+type node interface {
+	eval(r Resolver) (Value, error)
+	check(kinds map[string]Kind) (Kind, error)
+}
+
+type literalNode struct{ v Value }
+
+func (n *literalNode) eval(Resolver) (Value, error) { return n.v, nil }
+
+func (n *literalNode) check(map[string]Kind) (Kind, error) { return n.v.kind, nil }
+
+type identNode struct{ name string }
+
+func (n *identNode) eval(r Resolver) (Value, error) { return r.Lookup(n.name) }
+
+func (n *identNode) check(kinds map[string]Kind) (Kind, error) {
+	k, ok := kinds[n.name]
+	if !ok {
+		return 0, fmt.Errorf("%w: %s", ErrUnknownVariable, n.name)
+	}
+	return k, nil
+}
+
+type unaryNode struct {
+	op string
+	x  node
+}
+
+func (n *unaryNode) eval(r Resolver) (Value, error) {
+	v, err := n.x.eval(r)
+	if err != nil {
+		return Value{}, err
+	}
+	if n.op == "!" {
+		if v.kind != KindBool {
+			return Value{}, typeError(n.op, v.kind)
+		}
+		return Bool(!v.b), nil
+	}
+	if v.kind != KindNumber {
+		return Value{}, typeError(n.op, v.kind)
+	}
+	return Number(-v.num), nil
+}
+
+func (n *unaryNode) check(kinds map[string]Kind) (Kind, error) {
+	k, err := n.x.check(kinds)
+	if err != nil {
+		return 0, err
+	}
+	want := KindNumber
+	if n.op == "!" {
+		want = KindBool
+	}
+	if k != want {
+		return 0, typeError(n.op, k)
+	}
+	return want, nil
+}
+
+type binaryNode struct {
+	op          string
+	left, right node
+}
+
+func (n *binaryNode) eval(r Resolver) (Value, error) {
+	l, err := n.left.eval(r)
+	if err != nil {
+		return Value{}, err
+	}
+
+	// && and || short-circuit
+	if n.op == "&&" || n.op == "||" {
+		if l.kind != KindBool {
+			return Value{}, typeError(n.op, l.kind)
+		}
+		if (n.op == "&&" && !l.b) || (n.op == "||" && l.b) {
+			return l, nil
+		}
+		rv, err := n.right.eval(r)
+		if err != nil {
+			return Value{}, err
+		}
+		if rv.kind != KindBool {
+			return Value{}, typeError(n.op, rv.kind)
+		}
+		return rv, nil
+	}
+
+	rv, err := n.right.eval(r)
+	if err != nil {
+		return Value{}, err
+	}
+
+	switch n.op {
+	case "==", "!=":
+		if l.kind != rv.kind {
+			return Value{}, fmt.Errorf("cannot compare %s with %s", l.kind, rv.kind)
+		}
+		eq := l == rv
+		if n.op == "!=" {
+			eq = !eq
+		}
+		return Bool(eq), nil
+	}
+
+	if l.kind != KindNumber {
+		return Value{}, typeError(n.op, l.kind)
+	}
+	if rv.kind != KindNumber {
+		return Value{}, typeError(n.op, rv.kind)
+	}
+	switch n.op {
+	case "<":
+		return Bool(l.num < rv.num), nil
+	case "<=":
+		return Bool(l.num <= rv.num), nil
+	case ">":
+		return Bool(l.num > rv.num), nil
+	case ">=":
+		return Bool(l.num >= rv.num), nil
+	case "+":
+		return Number(l.num + rv.num), nil
+	case "-":
+		return Number(l.num - rv.num), nil
+	case "*":
+		return Number(l.num * rv.num), nil
+	case "/":
+		if rv.num == 0 {
+			return Value{}, ErrDivisionByZero
+		}
+		return Number(l.num / rv.num), nil
+	}
+	return Value{}, fmt.Errorf("unknown operator %q", n.op)
+}
+
+func (n *binaryNode) check(kinds map[string]Kind) (Kind, error) {
+	l, err := n.left.check(kinds)
+	if err != nil {
+		return 0, err
+	}
+	r, err := n.right.check(kinds)
+	if err != nil {
+		return 0, err
+	}
+	switch n.op {
+	case "&&", "||":
+		if l != KindBool || r != KindBool {
+			return 0, fmt.Errorf("operator %s needs bool operands, got %s and %s", n.op, l, r)
+		}
+		return KindBool, nil
+	case "==", "!=":
+		if l != r {
+			return 0, fmt.Errorf("cannot compare %s with %s", l, r)
+		}
+		return KindBool, nil
+	}
+	if l != KindNumber || r != KindNumber {
+		return 0, fmt.Errorf("operator %s needs number operands, got %s and %s", n.op, l, r)
+	}
+	switch n.op {
+	case "<", "<=", ">", ">=":
+		return KindBool, nil
+	default:
+		return KindNumber, nil
+	}
+}
+
+func typeError(op string, k Kind) error {
+	return fmt.Errorf("operator %s cannot be applied to %s", op, k)
+}
@@ -0,0 +1,252 @@
+package ruleexpr
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var testVars = Vars{
+	"user.win_rate":   Number(0.8),
+	"user.rounds":     Number(120),
+	"user.new_device": Bool(true),
+	"user.country":    String("CN"),
+	"amount":          Number(5000),
+	"zero":            Number(0),
+}
+
+var testKinds = map[string]Kind{
+	"user.win_rate":   KindNumber,
+	"user.rounds":     KindNumber,
+	"user.new_device": KindBool,
+	"user.country":    KindString,
+	"amount":          KindNumber,
+	"zero":            KindNumber,
+}
+
+func TestEvalPrecedence(t *testing.T) {
+	tests := []struct {
+		src  string
+		want interface{}
+	}{
+		{"1 + 2 * 3", 7.0},
+		{"(1 + 2) * 3", 9.0},
+		{"10 - 4 - 3", 3.0},
+		{"24 / 4 / 2", 3.0},
+		{"-2 * 3", -6.0},
+		{"- -2", 2.0},
+		{"2 - -2", 4.0},
+		{"1 + 2 < 4", true},
+		{"(1 < 2) == true", true},
+		{"true || false && false", true},
+		{"(true || false) && false", false},
+		{"!true || true", true},
+		{"!(true || true)", false},
+		{"!!true", true},
+		{"1 + 1 == 2 && 3 > 2 || false", true},
+		{".5 + 0.25", 0.75},
+		{"'a' == \"a\"", true},
+		{`'it\'s' == "it's"`, true},
+		{"user.win_rate > 0.75 && user.rounds >= 100", true},
+		{"amount / 1000 * 2 == 10", true},
+		{"user.country != 'US' && user.new_device", true},
+	}
+	for _, tt := range tests {
+		e, err := Compile(tt.src)
+		if err != nil {
+			t.Errorf("Compile(%q): %v", tt.src, err)
+			continue
+		}
+		v, err := e.Eval(testVars)
+		if err != nil {
+			t.Errorf("Eval(%q): %v", tt.src, err)
+			continue
+		}
+		if got := v.Interface(); got != tt.want {
+			t.Errorf("Eval(%q) = %v, want %v", tt.src, got, tt.want)
+		}
+	}
+}
+
+func TestShortCircuit(t *testing.T) {
+	// the right operand would fail with an unknown variable or division by zero
+	for _, src := range []string{
+		"false && missing > 1",
+		"true || missing > 1",
+		"false && 1 / zero > 0",
+	} {
+		e, err := Compile(src)
+		if err != nil {
+			t.Fatalf("Compile(%q): %v", src, err)
+		}
+		if _, err := e.EvalBool(testVars); err != nil {
+			t.Errorf("EvalBool(%q) should short-circuit, got %v", src, err)
+		}
+	}
+}
+
+func TestSyntaxErrors(t *testing.T) {
+	tests := []struct {
+		src string
+		pos int
+	}{
+		{"", 0},
+		{"1 +", 3},
+		{"(1 + 2", 6},
+		{"1 + 2)", 5},
+		{"1 < 2 < 3", 6},
+		{"a = 1", 2},
+		{"a & b", 2},
+		{"'unterminated", 0},
+		{"1.2.3", 0},
+		{"f(x)", 1},
+		{"x # y", 2},
+		{"1 2", 2},
+	}
+	for _, tt := range tests {
+		_, err := Compile(tt.src)
+		var se *SyntaxError
+		if !errors.As(err, &se) {
+			t.Errorf("Compile(%q) = %v, want a syntax error", tt.src, err)
+			continue
+		}
+		if se.Pos != tt.pos {
+			t.Errorf("Compile(%q) error at %d, want %d (%v)", tt.src, se.Pos, tt.pos, err)
+		}
+	}
+}
+
+func TestLimits(t *testing.T) {
+	nested := func(n int) string {
+		return strings.Repeat("(", n) + "true" + strings.Repeat(")", n)
+	}
+	if _, err := Compile(nested(MaxDepth)); err != nil {
+		t.Errorf("nesting %d should compile: %v", MaxDepth, err)
+	}
+	for _, src := range []string{
+		nested(MaxDepth + 1),
+		strings.Repeat("!", MaxDepth+1) + "true",
+		strings.Repeat("-", MaxDepth+1) + "1 > 0",
+		nested(MaxDepth * 10),
+	} {
+		_, err := Compile(src)
+		var se *SyntaxError
+		if !errors.As(err, &se) || !strings.Contains(se.Msg, "nested deeper") {
+			t.Errorf("Compile(%.20q...) = %v, want a depth error", src, err)
+		}
+	}
+
+	long := strings.Repeat("1 + ", (MaxLength-1)/4) + "1"
+	if len(long) > MaxLength {
+		t.Fatalf("test expression is %d bytes", len(long))
+	}
+	if _, err := Compile(long); err != nil {
+		t.Errorf("expression of %d bytes should compile: %v", len(long), err)
+	}
+	tooLong := long + strings.Repeat(" ", MaxLength-len(long)+1)
+	_, err := Compile(tooLong)
+	var se *SyntaxError
+	if !errors.As(err, &se) || !strings.Contains(se.Msg, "longer than") {
+		t.Errorf("expression of %d bytes = %v, want a length error", len(tooLong), err)
+	}
+}
+
+func TestTypeErrors(t *testing.T) {
+	tests := []struct {
+		src      string
+		checkErr string
+	}{
+		{"user.country > 1", "needs number operands"},
+		{"user.country + 1 > 0", "needs number operands"},
+		{"user.new_device == 1", "cannot compare"},
+		{"-user.new_device", "cannot be applied to bool"},
+		{"!amount", "cannot be applied to number"},
+		{"amount && true", "needs bool operands"},
+		{"false || 'x'", "needs bool operands"},
+		{"amount + 1", "must evaluate to bool"},
+		{"'a' < 'b'", "needs number operands"},
+	}
+	for _, tt := range tests {
+		e, err := Compile(tt.src)
+		if err != nil {
+			t.Errorf("Compile(%q): %v", tt.src, err)
+			continue
+		}
+		if err := e.Check(testKinds); err == nil || !strings.Contains(err.Error(), tt.checkErr) {
+			t.Errorf("Check(%q) = %v, want error containing %q", tt.src, err, tt.checkErr)
+		}
+		// evaluation reports the same mismatch instead of producing a value
+		if _, err := e.EvalBool(testVars); err == nil {
+			t.Errorf("EvalBool(%q) should fail", tt.src)
+		}
+	}
+}
+
+func TestUnknownVariables(t *testing.T) {
+	e, err := Compile("user.win_rate > 0.5 && user.vip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"user.vip", "user.win_rate"}; !reflect.DeepEqual(e.Vars(), want) {
+		t.Errorf("Vars() = %v, want %v", e.Vars(), want)
+	}
+	if err := e.Check(testKinds); !errors.Is(err, ErrUnknownVariable) || !strings.Contains(err.Error(), "user.vip") {
+		t.Errorf("Check = %v, want ErrUnknownVariable naming user.vip", err)
+	}
+	if _, err := e.EvalBool(testVars); !errors.Is(err, ErrUnknownVariable) {
+		t.Errorf("EvalBool = %v, want ErrUnknownVariable", err)
+	}
+}
+
+func TestDivisionByZero(t *testing.T) {
+	for _, src := range []string{"amount / zero > 1", "1 / 0 > 1", "1 / (amount - 5000) > 0"} {
+		e, err := Compile(src)
+		if err != nil {
+			t.Fatalf("Compile(%q): %v", src, err)
+		}
+		if err := e.Check(testKinds); err != nil {
+			t.Errorf("Check(%q): %v", src, err)
+		}
+		if _, err := e.EvalBool(testVars); !errors.Is(err, ErrDivisionByZero) {
+			t.Errorf("EvalBool(%q) = %v, want ErrDivisionByZero", src, err)
+		}
+	}
+}
+
+// FuzzCompile checks that arbitrary input never panics and that expressions
+// accepted by Check evaluate to a bool or fail only on division by zero.
+func FuzzCompile(f *testing.F) {
+	for _, seed := range []string{
+		"user.win_rate > 0.75 && user.rounds >= 100",
+		"!(amount / zero > 1) || user.country == 'CN'",
+		"-(-amount) * 2 <= 1e3",
+		`'a\'b' != "c"`,
+		"((((true))))",
+		"1 < 2 < 3",
+		"",
+	} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, src string) {
+		e, err := Compile(src)
+		if err != nil {
+			var se *SyntaxError
+			if !errors.As(err, &se) {
+				t.Fatalf("Compile(%q) returned a non-syntax error: %v", src, err)
+			}
+			return
+		}
+		if len(src) > MaxLength {
+			t.Fatalf("Compile accepted %d bytes", len(src))
+		}
+		if e.Check(testKinds) != nil {
+			// still must not panic
+			_, _ = e.Eval(testVars)
+			return
+		}
+		if _, err := e.EvalBool(testVars); err != nil && !errors.Is(err, ErrDivisionByZero) {
+			t.Fatalf("EvalBool(%q) after a successful Check: %v", src, err)
+		}
+	})
+}
@@ -0,0 +1,317 @@
+// Package stats provides the significance tests used to monitor the fairness
+// of settled game rounds: Pearson's chi-square goodness-of-fit test, the
+// Wald-Wolfowitz runs test, the exact binomial and Poisson binomial
+// upper-tail tests, and the Benjamini-Hochberg procedure that controls the
+// false discovery rate across a family of such tests.
+//
+// All functions are pure and return p-values in [0, 1]. Inputs that do not
+// admit a test (no degrees of freedom, empty sequences, ...) yield ok == false
+// instead of a p-value.
+package stats
+
+import (
+	"math"
+	"sort"
+)
+
+const (
+	gammaMaxIterations = 500
+	gammaEpsilon       = 1e-14
+	gammaTiny          = 1e-300
+
+	// pmfCutoff is the relative mass below which the tails of a probability
+	// mass function are dropped while convolving. Upper tails smaller than
+	// about 1e-30 are therefore reported as 0.
+	pmfCutoff = 1e-30
+)
+
+// ChiSquare computes Pearson's statistic sum((o-e)^2/e) over the categories
+// with a positive expectation. df is the number of such categories minus one.
+func ChiSquare(observed, expected []float64) (stat float64, df int) {
+	categories := 0
+	for i := range observed {
+		if i >= len(expected) || expected[i] <= 0 {
+			continue
+		}
+		d := observed[i] - expected[i]
+		stat += d * d / expected[i]
+		categories++
+	}
+	if categories == 0 {
+		return 0, 0
+	}
+	return stat, categories - 1
+}
+
+// ChiSquareSurvival returns P(X >= stat) for a chi-square distribution with
+// df degrees of freedom.
+func ChiSquareSurvival(stat float64, df int) (float64, bool) {
+	if df <= 0 || math.IsNaN(stat) {
+		return 0, false
+	}
+	if stat <= 0 {
+		return 1, true
+	}
+	return clamp01(upperGammaRegularized(float64(df)/2, stat/2)), true
+}
+
+// RunsTest performs the two-sided Wald-Wolfowitz runs test on a binary
+// sequence using the normal approximation. It returns the number of runs, the
+// z score and the p-value; ok is false when the sequence has fewer than two
+// elements or contains only one kind of value.
+func RunsTest(seq []bool) (runs int, z, p float64, ok bool) {
+	n := len(seq)
+	if n < 2 {
+		return 0, 0, 0, false
+	}
+	n1 := 0
+	runs = 1
+	for i, v := range seq {
+		if v {
+			n1++
+		}
+		if i > 0 && v != seq[i-1] {
+			runs++
+		}
+	}
+	n2 := n - n1
+	if n1 == 0 || n2 == 0 {
+		return runs, 0, 0, false
+	}
+	fn, f1, f2 := float64(n), float64(n1), float64(n2)
+	mean := 2*f1*f2/fn + 1
+	variance := 2 * f1 * f2 * (2*f1*f2 - fn) / (fn * fn * (fn - 1))
+	if variance <= 0 {
+		return runs, 0, 0, false
+	}
+	z = (float64(runs) - mean) / math.Sqrt(variance)
+	return runs, z, NormalTwoSided(z), true
+}
+
+// NormalTwoSided returns the two-sided p-value P(|Z| >= |z|) of a standard
+// normal variable.
+func NormalTwoSided(z float64) float64 {
+	return clamp01(math.Erfc(math.Abs(z) / math.Sqrt2))
+}
+
+// BinomialUpperTail returns P(X >= k) for X ~ Binomial(n, p).
+func BinomialUpperTail(k, n int, p float64) float64 {
+	switch {
+	case k <= 0:
+		return 1
+	case k > n:
+		return 0
+	case p <= 0:
+		return 0
+	case p >= 1:
+		return 1
+	}
+	logP, logQ := math.Log(p), math.Log1p(-p)
+	lgN1, _ := math.Lgamma(float64(n) + 1)
+	logPMF := func(i int) float64 {
+		lgI, _ := math.Lgamma(float64(i) + 1)
+		lgR, _ := math.Lgamma(float64(n-i) + 1)
+		return lgN1 - lgI - lgR + float64(i)*logP + float64(n-i)*logQ
+	}
+
+	// Sum from the mode outward so that the largest terms dominate and the
+	// remaining tail can be cut once it stops contributing.
+	peak := logPMF(k)
+	if mode := int(float64(n+1) * p); mode > k && mode <= n {
+		peak = logPMF(mode)
+	}
+	sum := 0.0
+	for i := k; i <= n; i++ {
+		term := math.Exp(logPMF(i) - peak)
+		sum += term
+		if float64(i) > float64(n)*p && term < 1e-17*sum {
+			break
+		}
+	}
+	return clamp01(sum * math.Exp(peak))
+}
+
+// PoissonBinomialUpperTail returns P(X >= k) where X counts the successes of
+// independent trials whose success probabilities differ: probs[i] is the
+// success probability of counts[i] of the trials. Trials sharing a
+// probability are grouped so that the distribution is the convolution of one
+// binomial per distinct probability, which keeps the cost independent of the
+// number of trials when only a few probabilities occur.
+func PoissonBinomialUpperTail(k int, probs []float64, counts []int) float64 {
+	n := 0
+	for i := range probs {
+		if i < len(counts) && counts[i] > 0 {
+			n += counts[i]
+		}
+	}
+	switch {
+	case k <= 0:
+		return 1
+	case k > n:
+		return 0
+	}
+
+	lo, pmf := 0, []float64{1}
+	for i, p := range probs {
+		if i >= len(counts) || counts[i] <= 0 {
+			continue
+		}
+		blo, b := binomialPMF(counts[i], p)
+		lo, pmf = trimPMF(lo+blo, convolve(pmf, b))
+	}
+	if k <= lo {
+		return 1
+	}
+	tail := 0.0
+	for i := len(pmf) - 1; i >= 0 && lo+i >= k; i-- {
+		tail += pmf[i]
+	}
+	return clamp01(tail)
+}
+
+// binomialPMF returns the Binomial(n, p) probabilities from lo upwards,
+// leaving out the tails below pmfCutoff relative to the mode.
+func binomialPMF(n int, p float64) (lo int, pmf []float64) {
+	switch {
+	case p <= 0:
+		return 0, []float64{1}
+	case p >= 1:
+		return n, []float64{1}
+	}
+	logP, logQ := math.Log(p), math.Log1p(-p)
+	lgN1, _ := math.Lgamma(float64(n) + 1)
+	logPMF := func(i int) float64 {
+		lgI, _ := math.Lgamma(float64(i) + 1)
+		lgR, _ := math.Lgamma(float64(n-i) + 1)
+		return lgN1 - lgI - lgR + float64(i)*logP + float64(n-i)*logQ
+	}
+
+	mode := int(float64(n+1) * p)
+	if mode > n {
+		mode = n
+	}
+	floor := logPMF(mode) + math.Log(pmfCutoff)
+	lo, hi := mode, mode
+	for lo > 0 && logPMF(lo-1) >= floor {
+		lo--
+	}
+	for hi < n && logPMF(hi+1) >= floor {
+		hi++
+	}
+	pmf = make([]float64, hi-lo+1)
+	for i := range pmf {
+		pmf[i] = math.Exp(logPMF(lo + i))
+	}
+	return lo, pmf
+}
+
+// convolve returns the distribution of the sum of two independent variables.
+func convolve(a, b []float64) []float64 {
+	out := make([]float64, len(a)+len(b)-1)
+	for i, x := range a {
+		if x == 0 {
+			continue
+		}
+		for j, y := range b {
+			out[i+j] += x * y
+		}
+	}
+	return out
+}
+
+// trimPMF drops the leading and trailing probabilities below pmfCutoff
+// relative to the largest one. The distributions handled here are unimodal,
+// so only the tails are affected.
+func trimPMF(lo int, pmf []float64) (int, []float64) {
+	peak := 0.0
+	for _, v := range pmf {
+		peak = math.Max(peak, v)
+	}
+	floor := peak * pmfCutoff
+	start, end := 0, len(pmf)
+	for start < end-1 && pmf[start] < floor {
+		start++
+	}
+	for end > start+1 && pmf[end-1] < floor {
+		end--
+	}
+	return lo + start, pmf[start:end]
+}
+
+// BenjaminiHochberg applies the Benjamini-Hochberg step-up procedure to a
+// family of p-values at false discovery rate q. Exactly the p-values less
+// than or equal to the returned cutoff are rejected; rejected is their count
+// and cutoff is -1 when none is.
+func BenjaminiHochberg(pValues []float64, q float64) (cutoff float64, rejected int) {
+	m := len(pValues)
+	sorted := append([]float64(nil), pValues...)
+	sort.Float64s(sorted)
+	for i := m - 1; i >= 0; i-- {
+		if sorted[i] <= float64(i+1)/float64(m)*q {
+			return sorted[i], i + 1
+		}
+	}
+	return -1, 0
+}
+
+// upperGammaRegularized returns Q(a, x) = Γ(a, x) / Γ(a).
+func upperGammaRegularized(a, x float64) float64 {
+	if x < a+1 {
+		return 1 - lowerGammaSeries(a, x)
+	}
+	return upperGammaFraction(a, x)
+}
+
+// lowerGammaSeries evaluates P(a, x) by its power series (x < a+1).
+func lowerGammaSeries(a, x float64) float64 {
+	lg, _ := math.Lgamma(a)
+	ap, sum := a, 1/a
+	del := sum
+	for i := 0; i < gammaMaxIterations; i++ {
+		ap++
+		del *= x / ap
+		sum += del
+		if math.Abs(del) < math.Abs(sum)*gammaEpsilon {
+			break
+		}
+	}
+	return sum * math.Exp(-x+a*math.Log(x)-lg)
+}
+
+// upperGammaFraction evaluates Q(a, x) by Lentz's continued fraction (x >= a+1).
+func upperGammaFraction(a, x float64) float64 {
+	lg, _ := math.Lgamma(a)
+	b := x + 1 - a
+	c := 1 / gammaTiny
+	d := 1 / b
+	h := d
+	for i := 1; i <= gammaMaxIterations; i++ {
+		an := -float64(i) * (float64(i) - a)
+		b += 2
+		d = an*d + b
+		if math.Abs(d) < gammaTiny {
+			d = gammaTiny
+		}
+		c = b + an/c
+		if math.Abs(c) < gammaTiny {
+			c = gammaTiny
+		}
+		d = 1 / d
+		del := d * c
+		h *= del
+		if math.Abs(del-1) < gammaEpsilon {
+			break
+		}
+	}
+	return math.Exp(-x+a*math.Log(x)-lg) * h
+}
+
+func clamp01(v float64) float64 {
+	switch {
+	case math.IsNaN(v) || v < 0:
+		return 0
+	case v > 1:
+		return 1
+	}
+	return v
+}
@@ -0,0 +1,194 @@
+package stats
+
+import (
+	"math"
+	"testing"
+)
+
+func near(got, want, tol float64) bool {
+	return math.Abs(got-want) <= tol
+}
+
+func TestChiSquare(t *testing.T) {
+	tests := []struct {
+		name     string
+		observed []float64
+		expected []float64
+		stat     float64
+		df       int
+	}{
+		{"uniform fit", []float64{20, 20, 20}, []float64{20, 20, 20}, 0, 2},
+		{"deviation", []float64{10, 20, 30}, []float64{20, 20, 20}, 10, 2},
+		{"zero expectation skipped", []float64{10, 5, 30}, []float64{20, 0, 20}, 10, 1},
+		{"no categories", []float64{1, 2}, []float64{0, 0}, 0, 0},
+		{"expected shorter than observed", []float64{15, 7}, []float64{10}, 2.5, 0},
+	}
+	for _, tt := range tests {
+		stat, df := ChiSquare(tt.observed, tt.expected)
+		if !near(stat, tt.stat, 1e-12) || df != tt.df {
+			t.Errorf("%s: ChiSquare = (%v, %d), want (%v, %d)", tt.name, stat, df, tt.stat, tt.df)
+		}
+	}
+}
+
+func TestChiSquareSurvival(t *testing.T) {
+	tests := []struct {
+		stat float64
+		df   int
+		want float64
+		ok   bool
+	}{
+		// critical values at the 5% and 0.1% levels
+		{3.841458820694124, 1, 0.05, true},
+		{5.991464547107979, 2, 0.05, true},
+		{9.487729036781154, 4, 0.05, true},
+		{18.307038053275146, 10, 0.05, true},
+		{10.827566170662733, 1, 0.001, true},
+		{3.84, 1, 0.050043521248705, true},
+		{0, 3, 1, true},
+		{-1, 3, 1, true},
+		{1e6, 5, 0, true},
+		{5, 0, 0, false},
+		{5, -1, 0, false},
+		{math.NaN(), 2, 0, false},
+	}
+	for _, tt := range tests {
+		p, ok := ChiSquareSurvival(tt.stat, tt.df)
+		if ok != tt.ok || !near(p, tt.want, 1e-9) {
+			t.Errorf("ChiSquareSurvival(%v, %d) = (%v, %v), want (%v, %v)", tt.stat, tt.df, p, ok, tt.want, tt.ok)
+		}
+	}
+
+	// df = 2 has the closed form exp(-x/2)
+	for _, x := range []float64{0.1, 1, 4, 12, 40} {
+		p, _ := ChiSquareSurvival(x, 2)
+		if want := math.Exp(-x / 2); !near(p, want, 1e-12) {
+			t.Errorf("ChiSquareSurvival(%v, 2) = %v, want %v", x, p, want)
+		}
+	}
+}
+
+func TestRunsTest(t *testing.T) {
+	tests := []struct {
+		name string
+		seq  []bool
+		runs int
+		z    float64
+		p    float64
+		ok   bool
+	}{
+		{"alternating", []bool{true, false, true, false}, 4, 1.224744871391589, 0.22067136191984688, true},
+		{"two blocks", []bool{true, true, true, false, false, false}, 2, -1.8257418583505538, 0.06788915486182903, true},
+		{"empty", nil, 0, 0, 0, false},
+		{"single element", []bool{true}, 0, 0, 0, false},
+		{"one kind only", []bool{true, true, true}, 1, 0, 0, false},
+		{"zero variance", []bool{true, false}, 2, 0, 0, false},
+	}
+	for _, tt := range tests {
+		runs, z, p, ok := RunsTest(tt.seq)
+		if runs != tt.runs || ok != tt.ok || !near(z, tt.z, 1e-9) || !near(p, tt.p, 1e-9) {
+			t.Errorf("%s: RunsTest = (%d, %v, %v, %v), want (%d, %v, %v, %v)",
+				tt.name, runs, z, p, ok, tt.runs, tt.z, tt.p, tt.ok)
+		}
+	}
+}
+
+func TestNormalTwoSided(t *testing.T) {
+	tests := []struct{ z, want float64 }{
+		{0, 1},
+		{1.959963984540054, 0.05},
+		{-1.959963984540054, 0.05},
+		{3.290526731491926, 0.001},
+		{40, 0},
+	}
+	for _, tt := range tests {
+		if got := NormalTwoSided(tt.z); !near(got, tt.want, 1e-12) {
+			t.Errorf("NormalTwoSided(%v) = %v, want %v", tt.z, got, tt.want)
+		}
+	}
+}
+
+func TestBinomialUpperTail(t *testing.T) {
+	tests := []struct {
+		k, n int
+		p    float64
+		want float64
+	}{
+		{60, 100, 0.5, 0.028443966820490395},
+		{3, 10, 0.1, 0.07019082639999974},
+		{1, 1, 0.3, 0.3},
+		{50, 100, 0.5, 0.5397946186935894},
+		{0, 10, 0.2, 1},
+		{-3, 10, 0.2, 1},
+		{11, 10, 0.2, 0},
+		{1, 10, 0, 0},
+		{10, 10, 1, 1},
+		{1000, 1000, 0.5, math.Pow(0.5, 1000)},
+	}
+	for _, tt := range tests {
+		got := BinomialUpperTail(tt.k, tt.n, tt.p)
+		if !near(got, tt.want, 1e-12) && !near(got/tt.want, 1, 1e-9) {
+			t.Errorf("BinomialUpperTail(%d, %d, %v) = %v, want %v", tt.k, tt.n, tt.p, got, tt.want)
+		}
+	}
+}
+
+func TestPoissonBinomialUpperTail(t *testing.T) {
+	tests := []struct {
+		name   string
+		k      int
+		probs  []float64
+		counts []int
+		want   float64
+	}{
+		{"single probability is binomial", 60, []float64{0.5}, []int{100}, 0.028443966820490395},
+		{"two trials at least one", 1, []float64{0.5, 0.25}, []int{1, 1}, 0.625},
+		{"two trials both", 2, []float64{0.5, 0.25}, []int{1, 1}, 0.125},
+		{"certain and impossible trials", 2, []float64{1, 0, 0.5}, []int{1, 5, 2}, 0.75},
+		{"k at or below zero", 0, []float64{0.3}, []int{4}, 1},
+		{"k above the number of trials", 5, []float64{0.3}, []int{4}, 0},
+		{"missing and non-positive counts ignored", 1, []float64{0.5, 0.9, 0.9}, []int{1, 0, -2}, 0.5},
+		{"no trials", 1, nil, nil, 0},
+	}
+	for _, tt := range tests {
+		if got := PoissonBinomialUpperTail(tt.k, tt.probs, tt.counts); !near(got, tt.want, 1e-12) {
+			t.Errorf("%s: PoissonBinomialUpperTail = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestBenjaminiHochberg(t *testing.T) {
+	tests := []struct {
+		name     string
+		pValues  []float64
+		q        float64
+		cutoff   float64
+		rejected int
+	}{
+		// the worked example from Benjamini & Hochberg (1995)
+		{"original paper", []float64{
+			0.0001, 0.0004, 0.0019, 0.0095, 0.0201, 0.0278, 0.0298, 0.0344,
+			0.0459, 0.3240, 0.4262, 0.5719, 0.6528, 0.7590, 1.000,
+		}, 0.05, 0.0095, 4},
+		{"step-up rejects all below the largest passing rank", []float64{0.04, 0.005, 0.03, 0.01}, 0.05, 0.04, 4},
+		{"a passing rank above a failing one", []float64{0.02, 0.03}, 0.05, 0.03, 2},
+		{"only the smallest", []float64{0.001, 0.2, 0.5}, 0.05, 0.001, 1},
+		{"ties share the cutoff", []float64{0.01, 0.01, 0.9}, 0.05, 0.01, 2},
+		{"none rejected", []float64{0.5, 0.9}, 0.05, -1, 0},
+		{"empty family", nil, 0.05, -1, 0},
+	}
+	for _, tt := range tests {
+		cutoff, rejected := BenjaminiHochberg(tt.pValues, tt.q)
+		if cutoff != tt.cutoff || rejected != tt.rejected {
+			t.Errorf("%s: BenjaminiHochberg = (%v, %d), want (%v, %d)", tt.name, cutoff, rejected, tt.cutoff, tt.rejected)
+		}
+	}
+}
+
+func TestBenjaminiHochbergDoesNotReorderInput(t *testing.T) {
+	pValues := []float64{0.3, 0.01, 0.2}
+	BenjaminiHochberg(pValues, 0.05)
+	if pValues[0] != 0.3 || pValues[1] != 0.01 || pValues[2] != 0.2 {
+		t.Errorf("input was modified: %v", pValues)
+	}
+}